@@ -28,6 +28,8 @@ func addKnownTypes(s *runtime.Scheme) error {
 		&MCPVirtualServerList{},
 		&MCPGatewayExtension{},
 		&MCPGatewayExtensionList{},
+		&KeycloakToolRoleMappingPolicy{},
+		&KeycloakToolRoleMappingPolicyList{},
 	)
 	metav1.AddToGroupVersion(s, GroupVersion)
 	return nil
@@ -0,0 +1,201 @@
+package v1
+
+import (
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// ConditionTypeAccepted signals if a policy is valid and its generated resources were written
+	ConditionTypeAccepted = "Accepted"
+	// ConditionTypeEnforced signals if the generated AuthPolicy is enforced by Kuadrant
+	ConditionTypeEnforced = "Enforced"
+
+	// ConditionReasonAccepted is the reason seen when a policy is valid
+	ConditionReasonAccepted = "Accepted"
+	// ConditionReasonInvalidPolicy is the reason seen when a policy spec cannot be applied
+	ConditionReasonInvalidPolicy = "Invalid"
+	// ConditionReasonTargetNotFound is the reason seen when the policy target does not exist
+	ConditionReasonTargetNotFound = "TargetNotFound"
+	// ConditionReasonMissingDependency is the reason seen when the Kuadrant AuthPolicy API is not installed
+	ConditionReasonMissingDependency = "MissingDependency"
+	// ConditionReasonEnforcementPending is the reason seen until Kuadrant reports on the generated AuthPolicy
+	ConditionReasonEnforcementPending = "Pending"
+
+	// DefaultWristbandSigningKeySecret is the Secret holding the private key
+	// the generated AuthPolicy signs the x-mcp-authorized header with
+	DefaultWristbandSigningKeySecret = "trusted-headers-private-key"
+)
+
+// KeycloakToolRoleMappingPolicySpec defines the desired state of KeycloakToolRoleMappingPolicy.
+// +kubebuilder:validation:XValidation:rule="self.targetRef.kind != 'MCPGatewayExtension' || self.mappings.all(m, has(m.server) && size(m.server) > 0)",message="server is required on every mapping when targeting an MCPGatewayExtension"
+type KeycloakToolRoleMappingPolicySpec struct {
+	// targetRef identifies the MCPGatewayExtension or MCPServerRegistration the
+	// policy protects. It must be in the same namespace as the policy.
+	// Targeting an MCPGatewayExtension generates an AuthPolicy on the extension's
+	// Gateway listener that enforces tools/call and mints the x-mcp-authorized
+	// header used to filter tools/list. Targeting an MCPServerRegistration
+	// generates an AuthPolicy on the registration's HTTPRoute that enforces
+	// tools/call for that server only.
+	// +required
+	TargetRef ToolRoleMappingTargetReference `json:"targetRef,omitzero"`
+
+	// issuerURL is the URL of the Keycloak realm issuing access tokens,
+	// e.g. https://keycloak.example.com/realms/mcp.
+	// +required
+	// +kubebuilder:validation:Pattern=`^https?://`
+	// +kubebuilder:validation:MaxLength=2048
+	IssuerURL string `json:"issuerURL,omitempty"`
+
+	// mappings grant tools to holders of Keycloak client roles. A token is
+	// allowed a tool when it holds the role of any mapping listing the tool.
+	// +required
+	// +listType=atomic
+	// +kubebuilder:validation:MinItems=1
+	// +kubebuilder:validation:MaxItems=256
+	Mappings []ToolRoleMapping `json:"mappings,omitempty"`
+
+	// signingKeySecretName is the Secret holding the private key used to sign
+	// the x-mcp-authorized header. The Secret must be readable by Authorino in
+	// the Gateway namespace. Only used when targeting an MCPGatewayExtension.
+	// Defaults to trusted-headers-private-key.
+	// +optional
+	// +kubebuilder:validation:MaxLength=253
+	SigningKeySecretName string `json:"signingKeySecretName,omitempty"`
+
+	// resourceMetadataURL is advertised in the WWW-Authenticate header of
+	// 401 responses so clients can discover the authorization server.
+	// +optional
+	// +kubebuilder:validation:Pattern=`^https?://`
+	// +kubebuilder:validation:MaxLength=2048
+	ResourceMetadataURL string `json:"resourceMetadataURL,omitempty"`
+}
+
+// ToolRoleMappingTargetReference identifies the resource a KeycloakToolRoleMappingPolicy protects.
+type ToolRoleMappingTargetReference struct {
+	// group is the group of the target resource.
+	// +optional
+	// +default="mcp.kuadrant.io"
+	// +kubebuilder:validation:Enum=mcp.kuadrant.io
+	Group string `json:"group,omitempty"`
+
+	// kind is the kind of the target resource.
+	// +required
+	// +kubebuilder:validation:Enum=MCPGatewayExtension;MCPServerRegistration
+	Kind string `json:"kind,omitempty"`
+
+	// name is the name of the target resource.
+	// +required
+	// +kubebuilder:validation:MinLength=1
+	// +kubebuilder:validation:MaxLength=253
+	Name string `json:"name,omitempty"`
+}
+
+// ToolRoleMapping grants a set of tools to holders of a Keycloak client role.
+type ToolRoleMapping struct {
+	// server is the namespaced name (namespace/name) of the MCPServerRegistration
+	// whose tools are granted. Required when targeting an MCPGatewayExtension;
+	// defaults to the target when targeting an MCPServerRegistration.
+	// +optional
+	// +kubebuilder:validation:Pattern=`^[a-z0-9]([-a-z0-9]*[a-z0-9])?/[a-z0-9]([-.a-z0-9]*[a-z0-9])?$`
+	Server string `json:"server,omitempty"`
+
+	// clientID is the Keycloak client whose roles are read from the
+	// resource_access claim. Defaults to the server name.
+	// +optional
+	// +kubebuilder:validation:MaxLength=255
+	ClientID string `json:"clientID,omitempty"`
+
+	// role is the client role granting the tools.
+	// +required
+	// +kubebuilder:validation:MinLength=1
+	// +kubebuilder:validation:MaxLength=255
+	Role string `json:"role,omitempty"`
+
+	// tools lists the granted tools by their name on the upstream server,
+	// without the registration prefix. A trailing * matches every tool whose
+	// name starts with the text before it, e.g. issue_* or *.
+	// +required
+	// +listType=set
+	// +kubebuilder:validation:MinItems=1
+	// +kubebuilder:validation:MaxItems=256
+	// +kubebuilder:validation:items:MinLength=1
+	// +kubebuilder:validation:items:MaxLength=128
+	// +kubebuilder:validation:items:Pattern=`^[A-Za-z0-9_.-]*\*?$`
+	Tools []string `json:"tools,omitempty"`
+}
+
+// KeycloakToolRoleMappingPolicyStatus defines the observed state of KeycloakToolRoleMappingPolicy.
+type KeycloakToolRoleMappingPolicyStatus struct {
+	// conditions represent the current state of the policy.
+	// Accepted indicates the spec is valid and the AuthPolicy was written.
+	// Enforced mirrors the Enforced condition Kuadrant reports on the AuthPolicy.
+	// +listType=map
+	// +listMapKey=type
+	// +patchStrategy=merge
+	// +patchMergeKey=type
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty" patchStrategy:"merge" patchMergeKey:"type" protobuf:"bytes,1,rep,name=conditions"`
+
+	// authPolicyRef is the namespaced name (namespace/name) of the generated AuthPolicy.
+	// +optional
+	AuthPolicyRef string `json:"authPolicyRef,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:storageversion
+// +kubebuilder:resource:scope=Namespaced,shortName=ktrmp
+// +kubebuilder:printcolumn:name="Target",type="string",JSONPath=".spec.targetRef.name",description="Target MCPGatewayExtension or MCPServerRegistration"
+// +kubebuilder:printcolumn:name="Accepted",type="string",JSONPath=".status.conditions[?(@.type=='Accepted')].status",description="Accepted status"
+// +kubebuilder:printcolumn:name="Enforced",type="string",JSONPath=".status.conditions[?(@.type=='Enforced')].status",description="Enforced status"
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+
+// KeycloakToolRoleMappingPolicy grants MCP tools to Keycloak client roles.
+// The controller generates a Kuadrant AuthPolicy that validates Keycloak
+// access tokens, rejects tools/call requests for tools the token's roles do
+// not grant and, for MCPGatewayExtension targets, mints the x-mcp-authorized
+// header the broker uses to filter tools/list.
+type KeycloakToolRoleMappingPolicy struct {
+	metav1.TypeMeta `json:",inline"`
+
+	// metadata is a standard object metadata
+	// +optional
+	metav1.ObjectMeta `json:"metadata,omitzero"`
+
+	// spec defines the desired state of KeycloakToolRoleMappingPolicy
+	// +required
+	Spec KeycloakToolRoleMappingPolicySpec `json:"spec,omitzero"`
+
+	// status defines the observed state of KeycloakToolRoleMappingPolicy
+	// +optional
+	Status KeycloakToolRoleMappingPolicyStatus `json:"status,omitzero"`
+}
+
+// +kubebuilder:object:root=true
+
+// KeycloakToolRoleMappingPolicyList contains a list of KeycloakToolRoleMappingPolicy
+type KeycloakToolRoleMappingPolicyList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitzero"`
+	Items           []KeycloakToolRoleMappingPolicy `json:"items"`
+}
+
+// SetCondition sets a condition on the KeycloakToolRoleMappingPolicy status
+func (p *KeycloakToolRoleMappingPolicy) SetCondition(conditionType string, status metav1.ConditionStatus, reason, message string) {
+	meta.SetStatusCondition(&p.Status.Conditions, metav1.Condition{
+		Type:               conditionType,
+		Status:             status,
+		ObservedGeneration: p.Generation,
+		Reason:             reason,
+		Message:            message,
+	})
+}
+
+// SigningKeySecret returns the Secret holding the wristband signing key
+func (p *KeycloakToolRoleMappingPolicy) SigningKeySecret() string {
+	if p.Spec.SigningKeySecretName != "" {
+		return p.Spec.SigningKeySecretName
+	}
+	return DefaultWristbandSigningKeySecret
+}
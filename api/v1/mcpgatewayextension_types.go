@@ -24,6 +24,14 @@ type URLElicitationPolicy string
 // +kubebuilder:validation:Enum=debug;info;warn;error
 type LogLevel string
 
+// MetricsExporter selects where broker-router metrics are exported
+// +kubebuilder:validation:Enum=Prometheus;OTLP
+type MetricsExporter string
+
+// MetricsTemporality is the aggregation temporality reported over OTLP
+// +kubebuilder:validation:Enum=Cumulative;Delta;LowMemory
+type MetricsTemporality string
+
 const (
 	// ConditionTypeReady signals if a resource is ready
 	ConditionTypeReady = "Ready"
@@ -64,6 +72,18 @@ const (
 	// LogLevelError sets the broker-router --log-level flag to 8
 	LogLevelError LogLevel = "error"
 
+	// MetricsExporterPrometheus serves metrics on the broker-router's /metrics endpoint
+	MetricsExporterPrometheus MetricsExporter = "Prometheus"
+	// MetricsExporterOTLP pushes metrics to an OTLP endpoint
+	MetricsExporterOTLP MetricsExporter = "OTLP"
+
+	// MetricsTemporalityCumulative reports every instrument cumulatively
+	MetricsTemporalityCumulative MetricsTemporality = "Cumulative"
+	// MetricsTemporalityDelta reports counters and histograms as deltas
+	MetricsTemporalityDelta MetricsTemporality = "Delta"
+	// MetricsTemporalityLowMemory reports synchronous counters and histograms as deltas
+	MetricsTemporalityLowMemory MetricsTemporality = "LowMemory"
+
 	// GuardrailsSecretNotFound is the reason seen when the guardrails secret referenced
 	// by the guardrails-ref annotation is not found
 	GuardrailsSecretNotFound = "GuardrailsSecretNotFound"
@@ -139,6 +159,7 @@ type MCPGatewayExtensionSpec struct {
 	// bundle used as the base trust pool for:
 	// - broker connections to all upstream MCP servers (per-server caCertSecretRef appends)
 	// - 2025-11-25 protocol hairpin requests to the gateway HTTPS listener
+	// - TLS (rediss://) connections to the session store
 	// 2026-07-28 MCP calls do not hairpin and do not use this bundle for gateway TLS.
 	// Include both the gateway listener CA and upstream CAs when they differ.
 	// The Secret must have the label mcp.kuadrant.io/secret=true.
@@ -150,6 +171,74 @@ type MCPGatewayExtensionSpec struct {
 	// +optional
 	// +default=1048576
 	MaxBodyBytes *int32 `json:"maxBodyBytes,omitempty"`
+
+	// sessionSigningKeyRotation schedules rotation of the session signing key.
+	// When not set, the key is generated once and never rotated.
+	// +optional
+	SessionSigningKeyRotation *SessionSigningKeyRotation `json:"sessionSigningKeyRotation,omitempty"`
+
+	// metricsExport configures OTLP export of the broker-router's metrics,
+	// alongside or instead of the Prometheus /metrics endpoint.
+	// When not set, metrics are only served for Prometheus scraping.
+	// +optional
+	MetricsExport *MetricsExport `json:"metricsExport,omitempty"`
+}
+
+// MetricsExport configures where the broker-router's metrics are exported.
+// Every mcp_broker_* instrument is reported by each enabled exporter.
+type MetricsExport struct {
+	// exporters lists the enabled exporters. Prometheus serves /metrics on the
+	// metrics listener; OTLP pushes to endpoint.
+	// +optional
+	// +listType=set
+	// +kubebuilder:validation:MinItems=1
+	// +kubebuilder:validation:MaxItems=2
+	// +default={"Prometheus","OTLP"}
+	Exporters []MetricsExporter `json:"exporters,omitempty"`
+
+	// endpoint is the OTLP metrics endpoint: rpc://<host>:<port> for gRPC or
+	// http(s)://<host>:<port>/v1/metrics for HTTP.
+	// +required
+	// +kubebuilder:validation:Pattern=`^(rpc|https?)://.+`
+	Endpoint string `json:"endpoint,omitempty"`
+
+	// insecure disables TLS for gRPC export. http:// endpoints never use TLS.
+	// +optional
+	Insecure bool `json:"insecure,omitempty"`
+
+	// temporality is the aggregation temporality reported over OTLP.
+	// +optional
+	// +default="Cumulative"
+	Temporality MetricsTemporality `json:"temporality,omitempty"`
+
+	// intervalSeconds is how often metrics are pushed over OTLP.
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=3600
+	// +default=60
+	IntervalSeconds *int32 `json:"intervalSeconds,omitempty"`
+}
+
+// SessionSigningKeyRotation configures scheduled rotation of the session
+// signing key. A new key is first published for verification only and becomes
+// the signing key once the broker-router pods have restarted with it. The
+// replaced key keeps verifying sessions and decrypting cached tokens until
+// the sessions it signed have expired.
+type SessionSigningKeyRotation struct {
+	// intervalHours is how long a signing key is used before it is replaced.
+	// +required
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=8760
+	IntervalHours int32 `json:"intervalHours,omitempty"`
+
+	// retentionHours is how long a replaced key is kept for verification.
+	// It must cover the broker session length (--session-length, 24 hours by
+	// default), or sessions signed with the replaced key end early.
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=8760
+	// +default=24
+	RetentionHours *int32 `json:"retentionHours,omitempty"`
 }
 
 // OAuthProtectedResource configures the OAuth protected resource metadata
@@ -188,6 +277,63 @@ type OAuthProtectedResource struct {
 	// +kubebuilder:validation:MaxItems=50
 	// +listType=atomic
 	ScopesSupported []string `json:"scopesSupported,omitempty"`
+
+	// authorizationServerMetadata makes the broker proxy and cache the metadata
+	// of the authorization servers at /.well-known/oauth-authorization-server
+	// and /.well-known/openid-configuration, so clients pointed at the gateway
+	// can discover them without manual configuration.
+	// When not set, clients must fetch the metadata from the authorization servers.
+	// +optional
+	AuthorizationServerMetadata *AuthorizationServerMetadata `json:"authorizationServerMetadata,omitempty"`
+}
+
+// AuthorizationServerMetadata configures the authorization server metadata proxy.
+// A path after the well-known prefix, e.g. /.well-known/oauth-authorization-server/realms/mcp,
+// selects the authorization server with that path; otherwise the first one is served.
+type AuthorizationServerMetadata struct {
+	// cacheTTLSeconds is how long fetched metadata is served before it is refetched.
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=86400
+	// +default=300
+	CacheTTLSeconds *int32 `json:"cacheTTLSeconds,omitempty"`
+
+	// dynamicClientRegistration serves an OAuth 2.0 Dynamic Client Registration
+	// (RFC 7591) endpoint at /oauth/register that registers clients with the
+	// first authorization server. Requests are only forwarded when every
+	// redirect URI and grant type is allowed. The proxied metadata advertises
+	// the endpoint as registration_endpoint.
+	// When not set, the authorization server's own registration_endpoint is advertised.
+	// +optional
+	DynamicClientRegistration *DynamicClientRegistration `json:"dynamicClientRegistration,omitempty"`
+}
+
+// DynamicClientRegistration configures the policy applied to client registrations.
+type DynamicClientRegistration struct {
+	// allowedRedirectURIs lists the redirect URIs clients may register.
+	// A trailing * matches any URI starting with the text before it on the
+	// same host, e.g. http://localhost:* or https://app.example.com/callback/*.
+	// +required
+	// +kubebuilder:validation:MinItems=1
+	// +kubebuilder:validation:MaxItems=50
+	// +kubebuilder:validation:items:MaxLength=2048
+	// +kubebuilder:validation:items:Pattern=`^[a-zA-Z][a-zA-Z0-9+.-]*://[^,]+$`
+	// +listType=set
+	AllowedRedirectURIs []string `json:"allowedRedirectURIs,omitempty"`
+
+	// allowedGrantTypes lists the grant types clients may register.
+	// +optional
+	// +kubebuilder:validation:MaxItems=5
+	// +kubebuilder:validation:items:Enum=authorization_code;refresh_token;client_credentials;urn:ietf:params:oauth:grant-type:device_code;urn:ietf:params:oauth:grant-type:token-exchange
+	// +listType=set
+	// +default={"authorization_code","refresh_token"}
+	AllowedGrantTypes []string `json:"allowedGrantTypes,omitempty"`
+
+	// initialAccessTokenRef references a Secret holding an initial access token
+	// sent to the authorization server's registration endpoint, for servers
+	// that do not allow anonymous registration.
+	// +optional
+	InitialAccessTokenRef *SecretReference `json:"initialAccessTokenRef,omitempty"`
 }
 
 // CACertBundleReference identifies a Secret containing a PEM-encoded CA bundle.
@@ -208,6 +354,8 @@ type CACertBundleReference struct {
 type SessionStore struct {
 	// secretName is the name of the secret containing the CACHE_CONNECTION_STRING key.
 	// The value should be a redis connection string: redis://<user>:<pass>@<host>:<port>/<db>
+	// Sentinel and Cluster deployments use redis+sentinel://<host>:<port>?master_name=<name>
+	// and redis+cluster://<host>:<port>; rediss schemes connect over TLS trusting caCertBundleRef.
 	// +required
 	// +kubebuilder:validation:MinLength=1
 	SecretName string `json:"secretName,omitempty"`
@@ -248,6 +396,21 @@ type MCPGatewayExtensionStatus struct {
 	// +patchMergeKey=type
 	// +optional
 	Conditions []metav1.Condition `json:"conditions,omitempty" patchStrategy:"merge" patchMergeKey:"type" protobuf:"bytes,1,rep,name=conditions"`
+
+	// configVersion is the latest broker config version the controller has
+	// produced for this gateway. Only set when the config stream is enabled.
+	// +optional
+	ConfigVersion int64 `json:"configVersion,omitempty"`
+
+	// appliedConfigVersion is the lowest config version applied by the
+	// connected broker-router instances. Only set when the config stream is enabled.
+	// +optional
+	AppliedConfigVersion int64 `json:"appliedConfigVersion,omitempty"`
+
+	// configError is the reason a broker-router gave for rejecting its latest
+	// config version. Empty when every connected broker-router applied it.
+	// +optional
+	ConfigError string `json:"configError,omitempty"`
 }
 
 // +kubebuilder:object:root=true
@@ -9,6 +9,31 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AuthorizationServerMetadata) DeepCopyInto(out *AuthorizationServerMetadata) {
+	*out = *in
+	if in.CacheTTLSeconds != nil {
+		in, out := &in.CacheTTLSeconds, &out.CacheTTLSeconds
+		*out = new(int32)
+		**out = **in
+	}
+	if in.DynamicClientRegistration != nil {
+		in, out := &in.DynamicClientRegistration, &out.DynamicClientRegistration
+		*out = new(DynamicClientRegistration)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AuthorizationServerMetadata.
+func (in *AuthorizationServerMetadata) DeepCopy() *AuthorizationServerMetadata {
+	if in == nil {
+		return nil
+	}
+	out := new(AuthorizationServerMetadata)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *CACertBundleReference) DeepCopyInto(out *CACertBundleReference) {
 	*out = *in
@@ -39,6 +64,176 @@ func (in *CACertSecretReference) DeepCopy() *CACertSecretReference {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CanaryTarget) DeepCopyInto(out *CanaryTarget) {
+	*out = *in
+	out.TargetRef = in.TargetRef
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CanaryTarget.
+func (in *CanaryTarget) DeepCopy() *CanaryTarget {
+	if in == nil {
+		return nil
+	}
+	out := new(CanaryTarget)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ConcurrencyLimit) DeepCopyInto(out *ConcurrencyLimit) {
+	*out = *in
+	if in.QueueTimeoutMilliseconds != nil {
+		in, out := &in.QueueTimeoutMilliseconds, &out.QueueTimeoutMilliseconds
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ConcurrencyLimit.
+func (in *ConcurrencyLimit) DeepCopy() *ConcurrencyLimit {
+	if in == nil {
+		return nil
+	}
+	out := new(ConcurrencyLimit)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DynamicClientRegistration) DeepCopyInto(out *DynamicClientRegistration) {
+	*out = *in
+	if in.AllowedRedirectURIs != nil {
+		in, out := &in.AllowedRedirectURIs, &out.AllowedRedirectURIs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.AllowedGrantTypes != nil {
+		in, out := &in.AllowedGrantTypes, &out.AllowedGrantTypes
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.InitialAccessTokenRef != nil {
+		in, out := &in.InitialAccessTokenRef, &out.InitialAccessTokenRef
+		*out = new(SecretReference)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DynamicClientRegistration.
+func (in *DynamicClientRegistration) DeepCopy() *DynamicClientRegistration {
+	if in == nil {
+		return nil
+	}
+	out := new(DynamicClientRegistration)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KeycloakToolRoleMappingPolicy) DeepCopyInto(out *KeycloakToolRoleMappingPolicy) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeycloakToolRoleMappingPolicy.
+func (in *KeycloakToolRoleMappingPolicy) DeepCopy() *KeycloakToolRoleMappingPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(KeycloakToolRoleMappingPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *KeycloakToolRoleMappingPolicy) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KeycloakToolRoleMappingPolicyList) DeepCopyInto(out *KeycloakToolRoleMappingPolicyList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]KeycloakToolRoleMappingPolicy, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeycloakToolRoleMappingPolicyList.
+func (in *KeycloakToolRoleMappingPolicyList) DeepCopy() *KeycloakToolRoleMappingPolicyList {
+	if in == nil {
+		return nil
+	}
+	out := new(KeycloakToolRoleMappingPolicyList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *KeycloakToolRoleMappingPolicyList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KeycloakToolRoleMappingPolicySpec) DeepCopyInto(out *KeycloakToolRoleMappingPolicySpec) {
+	*out = *in
+	out.TargetRef = in.TargetRef
+	if in.Mappings != nil {
+		in, out := &in.Mappings, &out.Mappings
+		*out = make([]ToolRoleMapping, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeycloakToolRoleMappingPolicySpec.
+func (in *KeycloakToolRoleMappingPolicySpec) DeepCopy() *KeycloakToolRoleMappingPolicySpec {
+	if in == nil {
+		return nil
+	}
+	out := new(KeycloakToolRoleMappingPolicySpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KeycloakToolRoleMappingPolicyStatus) DeepCopyInto(out *KeycloakToolRoleMappingPolicyStatus) {
+	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]metav1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeycloakToolRoleMappingPolicyStatus.
+func (in *KeycloakToolRoleMappingPolicyStatus) DeepCopy() *KeycloakToolRoleMappingPolicyStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(KeycloakToolRoleMappingPolicyStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *MCPGatewayExtension) DeepCopyInto(out *MCPGatewayExtension) {
 	*out = *in
@@ -132,6 +327,16 @@ func (in *MCPGatewayExtensionSpec) DeepCopyInto(out *MCPGatewayExtensionSpec) {
 		*out = new(int32)
 		**out = **in
 	}
+	if in.SessionSigningKeyRotation != nil {
+		in, out := &in.SessionSigningKeyRotation, &out.SessionSigningKeyRotation
+		*out = new(SessionSigningKeyRotation)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.MetricsExport != nil {
+		in, out := &in.MetricsExport, &out.MetricsExport
+		*out = new(MetricsExport)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MCPGatewayExtensionSpec.
@@ -249,6 +454,11 @@ func (in *MCPServerRegistrationSpec) DeepCopyInto(out *MCPServerRegistrationSpec
 		*out = new(SecretReference)
 		**out = **in
 	}
+	if in.Maintenance != nil {
+		in, out := &in.Maintenance, &out.Maintenance
+		*out = new(MaintenanceWindow)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.CACertSecretRef != nil {
 		in, out := &in.CACertSecretRef, &out.CACertSecretRef
 		*out = new(CACertSecretReference)
@@ -257,7 +467,7 @@ func (in *MCPServerRegistrationSpec) DeepCopyInto(out *MCPServerRegistrationSpec
 	if in.TokenURLElicitation != nil {
 		in, out := &in.TokenURLElicitation, &out.TokenURLElicitation
 		*out = new(TokenURLElicitationConfig)
-		**out = **in
+		(*in).DeepCopyInto(*out)
 	}
 	if in.Category != nil {
 		in, out := &in.Category, &out.Category
@@ -269,6 +479,26 @@ func (in *MCPServerRegistrationSpec) DeepCopyInto(out *MCPServerRegistrationSpec
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.Concurrency != nil {
+		in, out := &in.Concurrency, &out.Concurrency
+		*out = new(ConcurrencyLimit)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ToolCalls != nil {
+		in, out := &in.ToolCalls, &out.ToolCalls
+		*out = new(ToolCallPolicy)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ResultCache != nil {
+		in, out := &in.ResultCache, &out.ResultCache
+		*out = new(ToolResultCache)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Canary != nil {
+		in, out := &in.Canary, &out.Canary
+		*out = new(CanaryTarget)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MCPServerRegistrationSpec.
@@ -409,6 +639,54 @@ func (in *MCPVirtualServerStatus) DeepCopy() *MCPVirtualServerStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MaintenanceWindow) DeepCopyInto(out *MaintenanceWindow) {
+	*out = *in
+	if in.Start != nil {
+		in, out := &in.Start, &out.Start
+		*out = (*in).DeepCopy()
+	}
+	if in.End != nil {
+		in, out := &in.End, &out.End
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MaintenanceWindow.
+func (in *MaintenanceWindow) DeepCopy() *MaintenanceWindow {
+	if in == nil {
+		return nil
+	}
+	out := new(MaintenanceWindow)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MetricsExport) DeepCopyInto(out *MetricsExport) {
+	*out = *in
+	if in.Exporters != nil {
+		in, out := &in.Exporters, &out.Exporters
+		*out = make([]MetricsExporter, len(*in))
+		copy(*out, *in)
+	}
+	if in.IntervalSeconds != nil {
+		in, out := &in.IntervalSeconds, &out.IntervalSeconds
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MetricsExport.
+func (in *MetricsExport) DeepCopy() *MetricsExport {
+	if in == nil {
+		return nil
+	}
+	out := new(MetricsExport)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *OAuthProtectedResource) DeepCopyInto(out *OAuthProtectedResource) {
 	*out = *in
@@ -427,6 +705,11 @@ func (in *OAuthProtectedResource) DeepCopyInto(out *OAuthProtectedResource) {
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.AuthorizationServerMetadata != nil {
+		in, out := &in.AuthorizationServerMetadata, &out.AuthorizationServerMetadata
+		*out = new(AuthorizationServerMetadata)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OAuthProtectedResource.
@@ -454,6 +737,26 @@ func (in *SecretReference) DeepCopy() *SecretReference {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SessionSigningKeyRotation) DeepCopyInto(out *SessionSigningKeyRotation) {
+	*out = *in
+	if in.RetentionHours != nil {
+		in, out := &in.RetentionHours, &out.RetentionHours
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SessionSigningKeyRotation.
+func (in *SessionSigningKeyRotation) DeepCopy() *SessionSigningKeyRotation {
+	if in == nil {
+		return nil
+	}
+	out := new(SessionSigningKeyRotation)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *SessionStore) DeepCopyInto(out *SessionStore) {
 	*out = *in
@@ -487,6 +790,11 @@ func (in *TargetReference) DeepCopy() *TargetReference {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *TokenURLElicitationConfig) DeepCopyInto(out *TokenURLElicitationConfig) {
 	*out = *in
+	if in.OAuth != nil {
+		in, out := &in.OAuth, &out.OAuth
+		*out = new(UpstreamOAuthClient)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TokenURLElicitationConfig.
@@ -499,6 +807,116 @@ func (in *TokenURLElicitationConfig) DeepCopy() *TokenURLElicitationConfig {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ToolCallPolicy) DeepCopyInto(out *ToolCallPolicy) {
+	*out = *in
+	if in.TimeoutMilliseconds != nil {
+		in, out := &in.TimeoutMilliseconds, &out.TimeoutMilliseconds
+		*out = new(int32)
+		**out = **in
+	}
+	if in.Retry != nil {
+		in, out := &in.Retry, &out.Retry
+		*out = new(ToolCallRetry)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ToolCallPolicy.
+func (in *ToolCallPolicy) DeepCopy() *ToolCallPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(ToolCallPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ToolCallRetry) DeepCopyInto(out *ToolCallRetry) {
+	*out = *in
+	if in.PerTryTimeoutMilliseconds != nil {
+		in, out := &in.PerTryTimeoutMilliseconds, &out.PerTryTimeoutMilliseconds
+		*out = new(int32)
+		**out = **in
+	}
+	if in.BudgetPercent != nil {
+		in, out := &in.BudgetPercent, &out.BudgetPercent
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ToolCallRetry.
+func (in *ToolCallRetry) DeepCopy() *ToolCallRetry {
+	if in == nil {
+		return nil
+	}
+	out := new(ToolCallRetry)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ToolResultCache) DeepCopyInto(out *ToolResultCache) {
+	*out = *in
+	if in.Tools != nil {
+		in, out := &in.Tools, &out.Tools
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.MaxResultBytes != nil {
+		in, out := &in.MaxResultBytes, &out.MaxResultBytes
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ToolResultCache.
+func (in *ToolResultCache) DeepCopy() *ToolResultCache {
+	if in == nil {
+		return nil
+	}
+	out := new(ToolResultCache)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ToolRoleMapping) DeepCopyInto(out *ToolRoleMapping) {
+	*out = *in
+	if in.Tools != nil {
+		in, out := &in.Tools, &out.Tools
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ToolRoleMapping.
+func (in *ToolRoleMapping) DeepCopy() *ToolRoleMapping {
+	if in == nil {
+		return nil
+	}
+	out := new(ToolRoleMapping)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ToolRoleMappingTargetReference) DeepCopyInto(out *ToolRoleMappingTargetReference) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ToolRoleMappingTargetReference.
+func (in *ToolRoleMappingTargetReference) DeepCopy() *ToolRoleMappingTargetReference {
+	if in == nil {
+		return nil
+	}
+	out := new(ToolRoleMappingTargetReference)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *TrustedHeadersKey) DeepCopyInto(out *TrustedHeadersKey) {
 	*out = *in
@@ -513,3 +931,28 @@ func (in *TrustedHeadersKey) DeepCopy() *TrustedHeadersKey {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *UpstreamOAuthClient) DeepCopyInto(out *UpstreamOAuthClient) {
+	*out = *in
+	if in.ClientSecretRef != nil {
+		in, out := &in.ClientSecretRef, &out.ClientSecretRef
+		*out = new(SecretReference)
+		**out = **in
+	}
+	if in.Scopes != nil {
+		in, out := &in.Scopes, &out.Scopes
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new UpstreamOAuthClient.
+func (in *UpstreamOAuthClient) DeepCopy() *UpstreamOAuthClient {
+	if in == nil {
+		return nil
+	}
+	out := new(UpstreamOAuthClient)
+	in.DeepCopyInto(out)
+	return out
+}
@@ -238,6 +238,21 @@ type MCPGatewayExtensionStatus struct {
 	// +patchMergeKey=type
 	// +optional
 	Conditions []metav1.Condition `json:"conditions,omitempty" patchStrategy:"merge" patchMergeKey:"type" protobuf:"bytes,1,rep,name=conditions"`
+
+	// configVersion is the latest broker config version the controller has
+	// produced for this gateway. Only set when the config stream is enabled.
+	// +optional
+	ConfigVersion int64 `json:"configVersion,omitempty"`
+
+	// appliedConfigVersion is the lowest config version applied by the
+	// connected broker-router instances. Only set when the config stream is enabled.
+	// +optional
+	AppliedConfigVersion int64 `json:"appliedConfigVersion,omitempty"`
+
+	// configError is the reason a broker-router gave for rejecting its latest
+	// config version. Empty when every connected broker-router applied it.
+	// +optional
+	ConfigError string `json:"configError,omitempty"`
 }
 
 // +kubebuilder:unservedversion
@@ -5,7 +5,7 @@ import (
 )
 
 // ServerState defines the desired operational state of an MCPServerRegistration.
-// +kubebuilder:validation:Enum=Enabled;Disabled
+// +kubebuilder:validation:Enum=Enabled;Disabled;Maintenance
 type ServerState string
 
 // ServerState constants define the valid operational states for an MCPServerRegistration.
@@ -14,6 +14,9 @@ const (
 	ServerStateEnabled ServerState = "Enabled"
 	// ServerStateDisabled indicates the broker should not connect to this server and should remove any registered tools.
 	ServerStateDisabled ServerState = "Disabled"
+	// ServerStateMaintenance keeps the server's tools registered but has the router answer new tool calls
+	// with an error while the maintenance window is open. Calls already in flight are allowed to finish.
+	ServerStateMaintenance ServerState = "Maintenance"
 )
 
 // UserSpecificListPolicy controls whether the broker fetches tools per-user from this server
@@ -27,6 +30,28 @@ const (
 	UserSpecificListDisabled UserSpecificListPolicy = "Disabled"
 )
 
+// SamplingPolicy controls whether this server may send sampling/createMessage to clients
+// +kubebuilder:validation:Enum=Enabled;Disabled
+type SamplingPolicy string
+
+const (
+	// SamplingEnabled forwards the server's sampling requests to clients that support sampling
+	SamplingEnabled SamplingPolicy = "Enabled"
+	// SamplingDisabled hides the client's sampling capability from the server and rejects its sampling requests
+	SamplingDisabled SamplingPolicy = "Disabled"
+)
+
+// CanaryToolMismatchPolicy controls what happens when a canary's tools differ from the stable version's
+// +kubebuilder:validation:Enum=Reject;Flag
+type CanaryToolMismatchPolicy string
+
+const (
+	// CanaryToolMismatchReject stops routing tool calls to the canary while its tools differ
+	CanaryToolMismatchReject CanaryToolMismatchPolicy = "Reject"
+	// CanaryToolMismatchFlag keeps routing tool calls to the canary and reports the differences
+	CanaryToolMismatchFlag CanaryToolMismatchPolicy = "Flag"
+)
+
 // +kubebuilder:unservedversion
 // +kubebuilder:object:root=true
 // +kubebuilder:subresource:status
@@ -92,12 +117,20 @@ type MCPServerRegistrationSpec struct {
 
 	// state dictates whether the broker should maintain a connection to this server.
 	// When set to Disabled, the broker will remove any registered tools and stop connecting to the server.
+	// When set to Maintenance, tools stay listed but new tool calls are answered with an error
+	// during the window described by maintenance.
 	// The server can be re-enabled at any time by setting this field back to Enabled.
 	// Defaults to Enabled.
 	// +optional
 	// +default="Enabled"
 	State ServerState `json:"state,omitempty"`
 
+	// maintenance describes the window applied while state is Maintenance. Without a start
+	// the window opens immediately; without an end it stays open until state changes.
+	// Ignored for other states.
+	// +optional
+	Maintenance *MaintenanceWindow `json:"maintenance,omitempty"`
+
 	// caCertSecretRef references a Secret containing a PEM-encoded CA certificate bundle.
 	// The broker uses this CA to verify TLS connections to the upstream MCP server.
 	// The referenced Secret must have the label mcp.kuadrant.io/secret=true.
@@ -118,6 +151,14 @@ type MCPServerRegistrationSpec struct {
 	// +default="Disabled"
 	UserSpecificList UserSpecificListPolicy `json:"userSpecificList,omitempty"`
 
+	// sampling allows this MCP server to send sampling/createMessage requests to
+	// clients during a tool call. Sampling spends the client's model tokens, so
+	// it is Disabled by default: the server is not told the client can sample
+	// and any sampling request it sends anyway is answered with an error.
+	// +optional
+	// +default="Disabled"
+	Sampling SamplingPolicy `json:"sampling,omitempty"`
+
 	// category assigns one or more categories to this MCP server for tool discovery.
 	// Used by the discover_tools meta-tool to allow agents to filter servers by category.
 	// +optional
@@ -142,15 +183,222 @@ type MCPServerRegistrationSpec struct {
 	// +kubebuilder:validation:items:MinLength=1
 	// +kubebuilder:validation:items:MaxLength=128
 	Tags []string `json:"tags,omitempty"`
+
+	// concurrency limits the tools/call requests the router forwards to this
+	// server at once. Calls over the limit wait up to queueTimeoutMilliseconds
+	// for a free slot and are then answered with a tool error instead of
+	// reaching the server. Unset means no limit.
+	// +optional
+	Concurrency *ConcurrencyLimit `json:"concurrency,omitempty"`
+
+	// toolCalls sets the timeout of tools/call requests routed to this server,
+	// and retries failed calls to tools annotated as read-only or idempotent.
+	// Unset keeps the timeout of the HTTPRoute and never retries.
+	// +optional
+	ToolCalls *ToolCallPolicy `json:"toolCalls,omitempty"`
+
+	// resultCache caches the results of tools/call requests to tools
+	// annotated as read-only, and answers repeated calls with the same
+	// arguments from the cache. Unset never caches.
+	// +optional
+	ResultCache *ToolResultCache `json:"resultCache,omitempty"`
+
+	// canary sends a weighted share of gateway sessions' tool calls to a second
+	// version of this server under the same prefix. Tools are always listed from
+	// the stable version; the broker compares the canary's tools against them.
+	// +optional
+	Canary *CanaryTarget `json:"canary,omitempty"`
+}
+
+// CanaryTarget is a second version of an MCP server that receives a share of its tool calls.
+type CanaryTarget struct {
+	// targetRef specifies the HTTPRoute of the canary version. It is served on the
+	// same path as the stable version.
+	// +required
+	TargetRef TargetReference `json:"targetRef,omitzero"`
+
+	// weight is the percentage of gateway sessions whose tool calls go to the canary.
+	// A session keeps the version it was assigned while the weight is unchanged.
+	// +required
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=100
+	Weight int32 `json:"weight"`
+
+	// toolMismatchPolicy decides what happens when the canary's tool names or
+	// schemas differ from the stable version's. Reject stops routing to the canary
+	// until they match again; Flag keeps routing and reports the differences.
+	// +optional
+	// +default="Reject"
+	ToolMismatchPolicy CanaryToolMismatchPolicy `json:"toolMismatchPolicy,omitempty"`
+}
+
+// MaintenanceWindow describes when an MCPServerRegistration is under maintenance
+// +kubebuilder:validation:XValidation:rule="!has(self.start) || !has(self.end) || timestamp(self.start) < timestamp(self.end)",message="end must be after start"
+type MaintenanceWindow struct {
+	// start is when the window opens. Defaults to immediately.
+	// +optional
+	Start *metav1.Time `json:"start,omitempty"`
+
+	// end is when the window closes and tool calls are routed again.
+	// +optional
+	End *metav1.Time `json:"end,omitempty"`
+
+	// message is returned to clients whose tool calls are rejected during the window.
+	// +optional
+	// +kubebuilder:validation:MaxLength=512
+	Message string `json:"message,omitempty"`
+}
+
+// ConcurrencyLimit bounds the tool calls in flight to an MCP server.
+type ConcurrencyLimit struct {
+	// maxInFlight is the maximum number of tools/call requests in flight to the server.
+	// +required
+	// +kubebuilder:validation:Minimum=1
+	MaxInFlight int32 `json:"maxInFlight"`
+
+	// queueTimeoutMilliseconds is how long a call over the limit waits for a
+	// free slot before it is rejected. 0 rejects it at once.
+	// +optional
+	// +default=1000
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=30000
+	QueueTimeoutMilliseconds *int32 `json:"queueTimeoutMilliseconds,omitempty"`
+}
+
+// ToolCallPolicy sets the timeout and retries of tool calls to an MCP server.
+type ToolCallPolicy struct {
+	// timeoutMilliseconds bounds a tools/call request to the server, retries
+	// included. Unset keeps the timeout of the HTTPRoute.
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=3600000
+	TimeoutMilliseconds *int32 `json:"timeoutMilliseconds,omitempty"`
+
+	// retry retries failed calls to tools whose annotations mark them
+	// read-only or idempotent. Other tools are never retried.
+	// +optional
+	Retry *ToolCallRetry `json:"retry,omitempty"`
+}
+
+// ToolCallRetry retries tool calls that failed with a 5xx, a reset or a timeout.
+type ToolCallRetry struct {
+	// maxRetries is how many times a failed call is retried.
+	// +required
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=5
+	MaxRetries int32 `json:"maxRetries"`
+
+	// perTryTimeoutMilliseconds bounds each attempt. Unset gives every attempt
+	// what is left of timeoutMilliseconds.
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=3600000
+	PerTryTimeoutMilliseconds *int32 `json:"perTryTimeoutMilliseconds,omitempty"`
+
+	// budgetPercent stops retries while more than this percentage of the
+	// server's recent tool calls failed, so a failing server is not sent
+	// more calls than it was already failing.
+	// +optional
+	// +default=20
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=100
+	BudgetPercent *int32 `json:"budgetPercent,omitempty"`
+}
+
+// ToolResultCacheScope says which callers a cached tool result is served to.
+// +kubebuilder:validation:Enum=Subject;Shared
+type ToolResultCacheScope string
+
+const (
+	// ToolResultCacheScopeSubject serves a result only to the caller whose
+	// call produced it, identified by the subject of its access token.
+	ToolResultCacheScopeSubject ToolResultCacheScope = "Subject"
+	// ToolResultCacheScopeShared serves a result to every caller.
+	ToolResultCacheScopeShared ToolResultCacheScope = "Shared"
+)
+
+// ToolResultCache caches tool call results of an MCP server. Results are
+// keyed by tool and arguments, and by caller unless the scope is Shared.
+// Error results are never cached.
+type ToolResultCache struct {
+	// ttlSeconds is how long a result is served from the cache.
+	// +required
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=86400
+	TTLSeconds int32 `json:"ttlSeconds"`
+
+	// tools limits caching to these tools, named as the server lists them,
+	// without the prefix. Unset caches every read-only tool. Tools not
+	// annotated read-only are never cached.
+	// +optional
+	// +listType=set
+	// +kubebuilder:validation:MaxItems=100
+	// +kubebuilder:validation:items:MinLength=1
+	Tools []string `json:"tools,omitempty"`
+
+	// scope is Subject to keep results per caller, or Shared to serve them
+	// to every caller. Use Shared only for tools that return the same result
+	// to everyone allowed to call them.
+	// +optional
+	// +default="Subject"
+	Scope ToolResultCacheScope `json:"scope,omitempty"`
+
+	// maxResultBytes is the size of the largest result cached. Larger
+	// results are returned but not cached.
+	// +optional
+	// +default=65536
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=1048576
+	MaxResultBytes *int32 `json:"maxResultBytes,omitempty"`
 }
 
 // TokenURLElicitationConfig configures per-user token collection via URL elicitation.
+// +kubebuilder:validation:XValidation:rule="!has(self.url) || !has(self.oauth)",message="url and oauth are mutually exclusive"
 type TokenURLElicitationConfig struct {
 	// url overrides the default broker token page URL.
 	// When set, users are directed to this external URL (e.g. a Vault UI) instead of the broker's built-in page.
 	// +optional
 	// +kubebuilder:validation:Pattern=`^https?://`
 	URL string `json:"url,omitempty"`
+
+	// oauth replaces the token page with an OAuth 2.0 authorization code flow
+	// with PKCE against the upstream's authorization server. The broker keeps
+	// the user's access and refresh tokens in the session and refreshes the
+	// access token before it expires.
+	// +optional
+	OAuth *UpstreamOAuthClient `json:"oauth,omitempty"`
+}
+
+// UpstreamOAuthClient is the OAuth client the broker uses to connect a
+// user's account on the upstream.
+type UpstreamOAuthClient struct {
+	// authorizationURL is the authorization endpoint users are sent to.
+	// +required
+	// +kubebuilder:validation:Pattern=`^https?://`
+	AuthorizationURL string `json:"authorizationURL"`
+
+	// tokenURL is the token endpoint the broker exchanges codes and refresh
+	// tokens at.
+	// +required
+	// +kubebuilder:validation:Pattern=`^https?://`
+	TokenURL string `json:"tokenURL"`
+
+	// clientID is the ID of the client registered with the authorization
+	// server. Its redirect URI must be https://<gateway host>/tokens/oauth/callback.
+	// +required
+	// +kubebuilder:validation:MinLength=1
+	ClientID string `json:"clientID"`
+
+	// clientSecretRef references the client secret. The secret must have the
+	// label mcp.kuadrant.io/secret=true. Unset for public clients.
+	// +optional
+	ClientSecretRef *SecretReference `json:"clientSecretRef,omitempty"`
+
+	// scopes are the scopes requested from the authorization server.
+	// +optional
+	// +kubebuilder:validation:MaxItems=20
+	// +kubebuilder:validation:items:MinLength=1
+	Scopes []string `json:"scopes,omitempty"`
 }
 
 // TargetReference identifies an HTTPRoute that points to MCP servers.
@@ -39,6 +39,42 @@ func (in *CACertSecretReference) DeepCopy() *CACertSecretReference {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CanaryTarget) DeepCopyInto(out *CanaryTarget) {
+	*out = *in
+	out.TargetRef = in.TargetRef
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CanaryTarget.
+func (in *CanaryTarget) DeepCopy() *CanaryTarget {
+	if in == nil {
+		return nil
+	}
+	out := new(CanaryTarget)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ConcurrencyLimit) DeepCopyInto(out *ConcurrencyLimit) {
+	*out = *in
+	if in.QueueTimeoutMilliseconds != nil {
+		in, out := &in.QueueTimeoutMilliseconds, &out.QueueTimeoutMilliseconds
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ConcurrencyLimit.
+func (in *ConcurrencyLimit) DeepCopy() *ConcurrencyLimit {
+	if in == nil {
+		return nil
+	}
+	out := new(ConcurrencyLimit)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *MCPGatewayExtension) DeepCopyInto(out *MCPGatewayExtension) {
 	*out = *in
@@ -244,6 +280,11 @@ func (in *MCPServerRegistrationSpec) DeepCopyInto(out *MCPServerRegistrationSpec
 		*out = new(SecretReference)
 		**out = **in
 	}
+	if in.Maintenance != nil {
+		in, out := &in.Maintenance, &out.Maintenance
+		*out = new(MaintenanceWindow)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.CACertSecretRef != nil {
 		in, out := &in.CACertSecretRef, &out.CACertSecretRef
 		*out = new(CACertSecretReference)
@@ -252,7 +293,7 @@ func (in *MCPServerRegistrationSpec) DeepCopyInto(out *MCPServerRegistrationSpec
 	if in.TokenURLElicitation != nil {
 		in, out := &in.TokenURLElicitation, &out.TokenURLElicitation
 		*out = new(TokenURLElicitationConfig)
-		**out = **in
+		(*in).DeepCopyInto(*out)
 	}
 	if in.Category != nil {
 		in, out := &in.Category, &out.Category
@@ -264,6 +305,26 @@ func (in *MCPServerRegistrationSpec) DeepCopyInto(out *MCPServerRegistrationSpec
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.Concurrency != nil {
+		in, out := &in.Concurrency, &out.Concurrency
+		*out = new(ConcurrencyLimit)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ToolCalls != nil {
+		in, out := &in.ToolCalls, &out.ToolCalls
+		*out = new(ToolCallPolicy)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ResultCache != nil {
+		in, out := &in.ResultCache, &out.ResultCache
+		*out = new(ToolResultCache)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Canary != nil {
+		in, out := &in.Canary, &out.Canary
+		*out = new(CanaryTarget)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MCPServerRegistrationSpec.
@@ -404,6 +465,29 @@ func (in *MCPVirtualServerStatus) DeepCopy() *MCPVirtualServerStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MaintenanceWindow) DeepCopyInto(out *MaintenanceWindow) {
+	*out = *in
+	if in.Start != nil {
+		in, out := &in.Start, &out.Start
+		*out = (*in).DeepCopy()
+	}
+	if in.End != nil {
+		in, out := &in.End, &out.End
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MaintenanceWindow.
+func (in *MaintenanceWindow) DeepCopy() *MaintenanceWindow {
+	if in == nil {
+		return nil
+	}
+	out := new(MaintenanceWindow)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *OAuthProtectedResource) DeepCopyInto(out *OAuthProtectedResource) {
 	*out = *in
@@ -482,6 +566,11 @@ func (in *TargetReference) DeepCopy() *TargetReference {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *TokenURLElicitationConfig) DeepCopyInto(out *TokenURLElicitationConfig) {
 	*out = *in
+	if in.OAuth != nil {
+		in, out := &in.OAuth, &out.OAuth
+		*out = new(UpstreamOAuthClient)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TokenURLElicitationConfig.
@@ -494,6 +583,81 @@ func (in *TokenURLElicitationConfig) DeepCopy() *TokenURLElicitationConfig {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ToolCallPolicy) DeepCopyInto(out *ToolCallPolicy) {
+	*out = *in
+	if in.TimeoutMilliseconds != nil {
+		in, out := &in.TimeoutMilliseconds, &out.TimeoutMilliseconds
+		*out = new(int32)
+		**out = **in
+	}
+	if in.Retry != nil {
+		in, out := &in.Retry, &out.Retry
+		*out = new(ToolCallRetry)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ToolCallPolicy.
+func (in *ToolCallPolicy) DeepCopy() *ToolCallPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(ToolCallPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ToolCallRetry) DeepCopyInto(out *ToolCallRetry) {
+	*out = *in
+	if in.PerTryTimeoutMilliseconds != nil {
+		in, out := &in.PerTryTimeoutMilliseconds, &out.PerTryTimeoutMilliseconds
+		*out = new(int32)
+		**out = **in
+	}
+	if in.BudgetPercent != nil {
+		in, out := &in.BudgetPercent, &out.BudgetPercent
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ToolCallRetry.
+func (in *ToolCallRetry) DeepCopy() *ToolCallRetry {
+	if in == nil {
+		return nil
+	}
+	out := new(ToolCallRetry)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ToolResultCache) DeepCopyInto(out *ToolResultCache) {
+	*out = *in
+	if in.Tools != nil {
+		in, out := &in.Tools, &out.Tools
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.MaxResultBytes != nil {
+		in, out := &in.MaxResultBytes, &out.MaxResultBytes
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ToolResultCache.
+func (in *ToolResultCache) DeepCopy() *ToolResultCache {
+	if in == nil {
+		return nil
+	}
+	out := new(ToolResultCache)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *TrustedHeadersKey) DeepCopyInto(out *TrustedHeadersKey) {
 	*out = *in
@@ -508,3 +672,28 @@ func (in *TrustedHeadersKey) DeepCopy() *TrustedHeadersKey {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *UpstreamOAuthClient) DeepCopyInto(out *UpstreamOAuthClient) {
+	*out = *in
+	if in.ClientSecretRef != nil {
+		in, out := &in.ClientSecretRef, &out.ClientSecretRef
+		*out = new(SecretReference)
+		**out = **in
+	}
+	if in.Scopes != nil {
+		in, out := &in.Scopes, &out.Scopes
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new UpstreamOAuthClient.
+func (in *UpstreamOAuthClient) DeepCopy() *UpstreamOAuthClient {
+	if in == nil {
+		return nil
+	}
+	out := new(UpstreamOAuthClient)
+	in.DeepCopyInto(out)
+	return out
+}
@@ -19,23 +19,34 @@ This package contains the main of the mcp controller
 package main
 
 import (
+	"context"
+	"errors"
 	"flag"
 	"log/slog"
+	"net"
 	"os"
 	"strconv"
+	"time"
 
 	"github.com/go-logr/logr"
 
 	goenv "github.com/caitlinelfring/go-env-default"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/keepalive"
 	istionetv1alpha3 "istio.io/client-go/pkg/apis/networking/v1alpha3"
 	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/util/runtime"
 	"k8s.io/client-go/kubernetes/scheme"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/cache"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
 	metricsserver "sigs.k8s.io/controller-runtime/pkg/metrics/server"
 	gatewayv1 "sigs.k8s.io/gateway-api/apis/v1"
 	gatewayv1beta1 "sigs.k8s.io/gateway-api/apis/v1beta1"
@@ -43,9 +54,18 @@ import (
 	mcpv1 "github.com/Kuadrant/mcp-gateway/api/v1"
 	mcpv1alpha1 "github.com/Kuadrant/mcp-gateway/api/v1alpha1"
 	"github.com/Kuadrant/mcp-gateway/internal/config"
+	"github.com/Kuadrant/mcp-gateway/internal/configstream"
 	"github.com/Kuadrant/mcp-gateway/internal/controller"
 )
 
+// configWriter is implemented by both the config Secret writer and the
+// config stream store
+type configWriter interface {
+	controller.MCPServerConfigReaderWriter
+	controller.VirtualServerConfigReaderWriter
+	controller.ConfigWriterDeleter
+}
+
 func init() {
 	runtime.Must(mcpv1.AddToScheme(scheme.Scheme))
 	runtime.Must(mcpv1alpha1.AddToScheme(scheme.Scheme))
@@ -59,6 +79,24 @@ func main() {
 	var logFormat string
 	flag.IntVar(&loglevel, "log-level", int(slog.LevelInfo), "log level: 0=info, 4=warn, 8=error, -4=debug")
 	flag.StringVar(&logFormat, "log-format", "txt", "log format: txt or json")
+	var configStreamBindAddress, configStreamAdvertiseAddress string
+	var configStreamTLSCertFile, configStreamTLSKeyFile, configStreamCAFile string
+	var configStreamInsecure bool
+	var configStreamSettleSecs int
+	flag.StringVar(&configStreamBindAddress, "config-stream-bind-address", goenv.GetDefault("CONFIG_STREAM_BIND_ADDRESS", ""),
+		"address for the config stream gRPC server, e.g. :18000. When empty, broker config is written to the mcp-gateway-config Secret (env: CONFIG_STREAM_BIND_ADDRESS)")
+	flag.StringVar(&configStreamAdvertiseAddress, "config-stream-advertise-address", goenv.GetDefault("CONFIG_STREAM_ADVERTISE_ADDRESS", ""),
+		"address broker-routers dial to reach the config stream, e.g. mcp-controller.mcp-system.svc:18000 (env: CONFIG_STREAM_ADVERTISE_ADDRESS)")
+	flag.StringVar(&configStreamTLSCertFile, "config-stream-tls-cert-file", goenv.GetDefault("CONFIG_STREAM_TLS_CERT_FILE", ""),
+		"PEM certificate the config stream serves, reloaded on change (env: CONFIG_STREAM_TLS_CERT_FILE)")
+	flag.StringVar(&configStreamTLSKeyFile, "config-stream-tls-key-file", goenv.GetDefault("CONFIG_STREAM_TLS_KEY_FILE", ""),
+		"PEM private key of --config-stream-tls-cert-file (env: CONFIG_STREAM_TLS_KEY_FILE)")
+	flag.StringVar(&configStreamCAFile, "config-stream-ca-file", goenv.GetDefault("CONFIG_STREAM_CA_FILE", ""),
+		"PEM CA bundle broker-routers verify the config stream certificate against. When empty, the system roots are used (env: CONFIG_STREAM_CA_FILE)")
+	flag.BoolVar(&configStreamInsecure, "config-stream-insecure", goenv.GetBoolDefault("CONFIG_STREAM_INSECURE", false),
+		"serve the config stream over plaintext gRPC. The stream carries broker config including credentials; only for development (env: CONFIG_STREAM_INSECURE)")
+	flag.IntVar(&configStreamSettleSecs, "config-stream-settle-seconds", 10,
+		"seconds to wait after the cache syncs before serving the config stream, so the initial reconciles can repopulate the config")
 	flag.Parse()
 
 	// flag value is a raw slog.Level (info=0, warn=4, error=8, debug=-4)
@@ -93,11 +131,59 @@ func main() {
 		panic("unable to start manager : " + err.Error())
 	}
 
-	configReaderWriter := config.SecretReaderWriter{
+	var configReaderWriter configWriter = &config.SecretReaderWriter{
 		Client: mgr.GetClient(),
 		Scheme: mgr.GetScheme(),
 		Logger: slogger,
 	}
+	var configStore *configstream.Store
+	var configStreamCACert string
+	var configEvents chan event.GenericEvent
+	if configStreamBindAddress != "" {
+		if configStreamAdvertiseAddress == "" {
+			panic("--config-stream-advertise-address is required when --config-stream-bind-address is set")
+		}
+		var creds credentials.TransportCredentials
+		switch {
+		case configStreamInsecure:
+			slogger.Warn("config stream serving plaintext, broker config is sent unencrypted")
+			creds = insecure.NewCredentials()
+		case configStreamTLSCertFile != "" && configStreamTLSKeyFile != "":
+			creds, err = configstream.ServerCredentials(configStreamTLSCertFile, configStreamTLSKeyFile)
+			if err != nil {
+				panic("unable to load config stream certificate : " + err.Error())
+			}
+		default:
+			panic("--config-stream-tls-cert-file and --config-stream-tls-key-file are required when --config-stream-bind-address is set, unless --config-stream-insecure is set")
+		}
+		if configStreamCAFile != "" {
+			ca, err := os.ReadFile(configStreamCAFile)
+			if err != nil {
+				panic("unable to read config stream CA : " + err.Error())
+			}
+			configStreamCACert = string(ca)
+		}
+		configEvents = make(chan event.GenericEvent, 1024)
+		configStore = configstream.NewStore(slogger, func(namespace string) {
+			select {
+			case configEvents <- event.GenericEvent{Object: &mcpv1.MCPGatewayExtension{
+				ObjectMeta: metav1.ObjectMeta{Namespace: namespace},
+			}}:
+			default:
+				slogger.Debug("config stream event dropped, queue full", "namespace", namespace)
+			}
+		})
+		configReaderWriter = configStore
+		streamServer := configstream.NewServer(configStore, func(ctx context.Context, namespace string) ([]string, error) {
+			return controller.SessionVerificationKeys(ctx, mgr.GetClient(), namespace)
+		}, slogger)
+		settle := time.Duration(configStreamSettleSecs) * time.Second
+		if err := mgr.Add(manager.RunnableFunc(func(ctx context.Context) error {
+			return serveConfigStream(ctx, mgr, streamServer, configStreamBindAddress, creds, settle, slogger)
+		})); err != nil {
+			panic("unable to start manager : " + err.Error())
+		}
+	}
 
 	mcpExtFinderValidator := &controller.MCPGatewayExtensionValidator{
 		Client:          mgr.GetClient(),
@@ -109,7 +195,7 @@ func main() {
 		Client:                mgr.GetClient(),
 		Scheme:                mgr.GetScheme(),
 		DirectAPIReader:       mgr.GetAPIReader(),
-		ConfigReaderWriter:    &configReaderWriter,
+		ConfigReaderWriter:    configReaderWriter,
 		MCPExtFinderValidator: mcpExtFinderValidator,
 	}).SetupWithManager(ctx, mgr); err != nil {
 		panic("unable to start manager : " + err.Error())
@@ -125,15 +211,23 @@ func main() {
 		}
 	}
 
-	if err = (&controller.MCPGatewayExtensionReconciler{
+	extensionReconciler := &controller.MCPGatewayExtensionReconciler{
 		Client:                mgr.GetClient(),
 		Scheme:                mgr.GetScheme(),
 		DirectAPIReader:       mgr.GetAPIReader(),
-		ConfigWriterDeleter:   &configReaderWriter,
+		ConfigWriterDeleter:   configReaderWriter,
 		MCPExtFinderValidator: mcpExtFinderValidator,
 		BrokerRouterImage:     brokerRouterImage,
 		BrokerRouterLogLevel:  brokerRouterLogLevel,
-	}).SetupWithManager(ctx, mgr); err != nil {
+	}
+	if configStore != nil {
+		extensionReconciler.ConfigStreamAddress = configStreamAdvertiseAddress
+		extensionReconciler.ConfigStreamCACert = configStreamCACert
+		extensionReconciler.ConfigStreamInsecure = configStreamInsecure
+		extensionReconciler.ConfigStatus = configStore
+		extensionReconciler.ConfigEvents = configEvents
+	}
+	if err = extensionReconciler.SetupWithManager(ctx, mgr); err != nil {
 		panic("unable to start manager : " + err.Error())
 	}
 
@@ -141,12 +235,19 @@ func main() {
 		Client:                mgr.GetClient(),
 		Scheme:                mgr.GetScheme(),
 		DirectAPIReader:       mgr.GetAPIReader(),
-		ConfigReaderWriter:    &configReaderWriter,
+		ConfigReaderWriter:    configReaderWriter,
 		MCPExtNamespaceLister: mcpExtFinderValidator,
 	}).SetupWithManager(ctx, mgr); err != nil {
 		panic("unable to start manager : " + err.Error())
 	}
 
+	if err = (&controller.KeycloakToolRoleMappingPolicyReconciler{
+		Client: mgr.GetClient(),
+		Scheme: mgr.GetScheme(),
+	}).SetupWithManager(ctx, mgr); err != nil {
+		panic("unable to start manager : " + err.Error())
+	}
+
 	if err := mgr.AddHealthzCheck("healthz", healthz.Ping); err != nil {
 		panic("unable to start manager : " + err.Error())
 	}
@@ -158,3 +259,34 @@ func main() {
 		panic("unable to start manager : " + err.Error())
 	}
 }
+
+// serveConfigStream serves the config stream until ctx is done. The store is
+// in memory, so serving waits for the cache to sync and the initial reconciles
+// to settle; otherwise a restarted controller could hand brokers a partial config.
+func serveConfigStream(ctx context.Context, mgr ctrl.Manager, server *configstream.Server, address string, creds credentials.TransportCredentials, settle time.Duration, logger *slog.Logger) error {
+	if !mgr.GetCache().WaitForCacheSync(ctx) {
+		return errors.New("config stream: cache did not sync")
+	}
+	select {
+	case <-ctx.Done():
+		return nil
+	case <-time.After(settle):
+	}
+
+	lc := net.ListenConfig{}
+	lis, err := lc.Listen(ctx, "tcp", address)
+	if err != nil {
+		return err
+	}
+	grpcServer := grpc.NewServer(grpc.Creds(creds), grpc.KeepaliveEnforcementPolicy(keepalive.EnforcementPolicy{
+		MinTime:             10 * time.Second,
+		PermitWithoutStream: true,
+	}))
+	server.Register(grpcServer)
+	go func() {
+		<-ctx.Done()
+		grpcServer.Stop()
+	}()
+	logger.Info("serving config stream", "address", address)
+	return grpcServer.Serve(lis)
+}
@@ -7,6 +7,8 @@ import (
 	"time"
 
 	"github.com/Kuadrant/mcp-gateway/internal/broker"
+	"github.com/Kuadrant/mcp-gateway/internal/broker/catalog"
+	"github.com/Kuadrant/mcp-gateway/internal/broker/credentials"
 	"github.com/Kuadrant/mcp-gateway/internal/broker/upstream"
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/propagation"
@@ -22,6 +24,13 @@ func (a *app) createBroker() {
 	if managerTickerInterval <= 0 {
 		panic("flag mcp-check-interval cannot be 0 or less seconds")
 	}
+	if a.brokerCfg.discoveryByteBudget > 0 && a.brokerCfg.discoveryTokenBudget > 0 {
+		panic("set only one of --discovery-byte-budget and --discovery-token-budget")
+	}
+	budget, budgetUnit := a.brokerCfg.discoveryByteBudget, broker.BudgetUnitBytes
+	if a.brokerCfg.discoveryTokenBudget > 0 {
+		budget, budgetUnit = a.brokerCfg.discoveryTokenBudget, broker.BudgetUnitTokens
+	}
 
 	brokerOpts := []broker.Option{
 		broker.WithEnforceCapabilityFilter(a.brokerCfg.enforceCapabilityFiltering),
@@ -31,25 +40,120 @@ func (a *app) createBroker() {
 		broker.WithElicitationEnabled(a.brokerCfg.enableURLElicitation),
 		broker.WithDiscoveryToolsEnabled(a.brokerCfg.discoveryToolsEnabled),
 		broker.WithDiscoveryToolThreshold(a.brokerCfg.discoveryToolThreshold),
+		broker.WithDiscoveryContextBudget(budget, budgetUnit),
 		broker.WithSessionCache(a.sessionCache),
+		broker.WithAdminToken(a.brokerCfg.adminToken),
 	}
 	if a.jwtMgr != nil {
 		brokerOpts = append(brokerOpts,
 			broker.WithSessionIDGenerator(a.jwtMgr.Generate),
 			broker.WithSessionValidator(a.jwtMgr.Validate),
 			broker.WithSessionTerminator(a.jwtMgr.Terminate),
+			broker.WithSessionRevoker(a.jwtMgr),
+			broker.WithSessionIDForSubject(a.jwtMgr.GenerateForSubject),
+			broker.WithSessionSubjectCheck(a.jwtMgr.CheckSubject),
 		)
 	}
+	brokerOpts = append(brokerOpts, a.sharedCatalogOptions()...)
+	brokerOpts = append(brokerOpts, a.credentialProviderOptions()...)
 	a.mcpBroker = broker.NewBroker(a.logger.With("component", "broker"), brokerOpts...)
 	a.tokenHandler = broker.NewTokenHandler(a.sessionCache, a.tokenElicitMap, *a.logger)
 	a.elicitHandler = &broker.ElicitationHandler{
 		ElicitationMap: a.tokenElicitMap,
 		Config:         a.mcpConfig,
 	}
+	a.upstreamOAuth = broker.NewUpstreamOAuthHandler(a.sessionCache, a.tokenElicitMap, a.mcpConfig, a.credentialProvider, a.logger.With("component", "upstream-oauth"))
 	a.setUpHTTPServer()
 	a.setUpMetricsServer()
 }
 
+// sharedCatalogOptions configures leader-elected discovery through the
+// redis cache when --shared-catalog is set
+func (a *app) sharedCatalogOptions() []broker.Option {
+	cfg := &a.brokerCfg
+	if !cfg.sharedCatalog {
+		return nil
+	}
+	if a.redisClient == nil {
+		panic("--shared-catalog requires --cache-connection-string: the catalog is shared through redis")
+	}
+	if cfg.sharedCatalogSyncSecs <= 0 || cfg.sharedCatalogLeaseSecs <= 0 {
+		panic("flags shared-catalog-sync-interval and shared-catalog-lease-duration must be greater than 0")
+	}
+	identity, err := os.Hostname()
+	if err != nil {
+		panic("failed to determine replica identity for the discovery lease: " + err.Error())
+	}
+	leaseDuration := time.Duration(cfg.sharedCatalogLeaseSecs) * time.Second
+	logger := a.logger.With("component", "catalog-election")
+
+	var elector catalog.Elector
+	switch cfg.sharedCatalogElection {
+	case "redis":
+		elector = &catalog.RedisLease{
+			Client:        a.redisClient,
+			Identity:      identity,
+			LeaseDuration: leaseDuration,
+			Logger:        logger,
+		}
+	case "kubernetes":
+		namespace := os.Getenv("POD_NAMESPACE")
+		if namespace == "" {
+			panic("--shared-catalog-election=kubernetes requires the POD_NAMESPACE environment variable")
+		}
+		elector, err = catalog.NewInClusterKubernetesLease(namespace, identity, leaseDuration, logger)
+		if err != nil {
+			panic("failed to set up kubernetes discovery lease: " + err.Error())
+		}
+	default:
+		panic("--shared-catalog-election must be redis or kubernetes")
+	}
+	a.logger.Info("shared catalog enabled", "election", cfg.sharedCatalogElection, "identity", identity)
+	return []broker.Option{
+		broker.WithSharedCatalog(catalog.New(catalog.WithRedisClient(a.redisClient)), elector),
+		broker.WithCatalogSyncInterval(time.Duration(cfg.sharedCatalogSyncSecs) * time.Second),
+	}
+}
+
+// credentialProviderOptions configures how upstream credential references
+// are resolved when --credential-provider is set
+func (a *app) credentialProviderOptions() []broker.Option {
+	cfg := &a.brokerCfg
+	if cfg.credentialProvider == "" {
+		return nil
+	}
+	if cfg.credentialCacheTTLSecs <= 0 {
+		panic("flag credential-cache-ttl must be greater than 0")
+	}
+
+	var provider credentials.CredentialProvider
+	switch cfg.credentialProvider {
+	case "kubernetes":
+		var err error
+		provider, err = credentials.NewInClusterKubernetesProvider(os.Getenv("POD_NAMESPACE"))
+		if err != nil {
+			panic("failed to set up kubernetes credential provider: " + err.Error())
+		}
+	case "file":
+		provider = &credentials.FileProvider{Dir: cfg.credentialDir}
+	case "vault":
+		if cfg.vaultAddress == "" {
+			panic("--credential-provider=vault requires --vault-address")
+		}
+		provider = &credentials.VaultProvider{
+			Address: cfg.vaultAddress,
+			Token:   os.Getenv("VAULT_TOKEN"),
+			Mount:   cfg.vaultMount,
+		}
+	default:
+		panic("--credential-provider must be kubernetes, file or vault")
+	}
+	ttl := time.Duration(cfg.credentialCacheTTLSecs) * time.Second
+	a.logger.Info("upstream credential provider enabled", "provider", cfg.credentialProvider, "cacheTTL", ttl)
+	a.credentialProvider = credentials.NewCache(provider, ttl)
+	return []broker.Option{broker.WithCredentialProvider(a.credentialProvider)}
+}
+
 func (a *app) setUpHTTPServer() {
 	cfg := &a.brokerCfg
 	mux := http.NewServeMux()
@@ -73,6 +177,19 @@ func (a *app) setUpHTTPServer() {
 	oauthHandler := broker.ProtectedResourceHandler{Logger: a.logger}
 	mux.HandleFunc("/.well-known/oauth-protected-resource", oauthHandler.Handle)
 	mux.HandleFunc("/.well-known/oauth-protected-resource/", oauthHandler.Handle)
+	if asConfig := broker.GetAuthorizationServerConfig(); asConfig != nil {
+		asHandler := broker.NewAuthorizationServerHandler(a.logger.With("component", "oauth-metadata"), asConfig)
+		mux.HandleFunc(broker.OAuthAuthorizationServerPath, asHandler.HandleMetadata)
+		mux.HandleFunc(broker.OAuthAuthorizationServerPath+"/", asHandler.HandleMetadata)
+		mux.HandleFunc(broker.OpenIDConfigurationPath, asHandler.HandleMetadata)
+		mux.HandleFunc(broker.OpenIDConfigurationPath+"/", asHandler.HandleMetadata)
+		if asHandler.DynamicClientRegistrationEnabled() {
+			mux.HandleFunc(broker.ClientRegistrationPath, asHandler.HandleRegistration)
+		}
+		a.logger.Info("authorization server metadata proxy enabled",
+			"authorizationServers", asConfig.AuthorizationServers,
+			"dynamicClientRegistration", asHandler.DynamicClientRegistrationEnabled())
+	}
 
 	// WriteTimeout of 0 (disabled) is important for SSE connections (GET /mcp).
 	// SSE streams notifications indefinitely - any write timeout would kill the connection.
@@ -94,6 +211,7 @@ func (a *app) setUpHTTPServer() {
 	mux.HandleFunc("/status/", a.mcpBroker.HandleStatusRequest)
 	if cfg.enableURLElicitation {
 		mux.Handle("/tokens", a.tokenHandler)
+		mux.Handle(broker.UpstreamOAuthPath, a.upstreamOAuth)
 		mux.Handle("/mcp/elicitation", a.elicitHandler)
 	}
 	mcpHandler := traceContextMiddleware(a.mcpBroker.MCPHandler())
@@ -114,6 +232,12 @@ func (a *app) setUpMetricsServer() {
 			http.Error(w, "metrics unavailable", http.StatusServiceUnavailable)
 		})
 	}
+	// the admin API shares the internal listener and is only served when
+	// ADMIN_TOKEN is set
+	if a.brokerCfg.adminToken != "" {
+		mux.Handle("/admin/", a.mcpBroker.AdminHandler())
+		a.logger.Info("admin API enabled", "address", a.brokerCfg.metricsAddr)
+	}
 	a.metricsServer = &http.Server{
 		Addr:              a.brokerCfg.metricsAddr,
 		Handler:           mux,
@@ -0,0 +1,387 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"sync/atomic"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/spf13/viper"
+	"sigs.k8s.io/yaml"
+
+	"github.com/Kuadrant/mcp-gateway/internal/broker"
+	"github.com/Kuadrant/mcp-gateway/internal/clients"
+	config "github.com/Kuadrant/mcp-gateway/internal/config"
+	"github.com/Kuadrant/mcp-gateway/internal/configlint"
+	"github.com/Kuadrant/mcp-gateway/internal/elicitation"
+	"github.com/Kuadrant/mcp-gateway/internal/idmap"
+	"github.com/Kuadrant/mcp-gateway/internal/protocol"
+	"github.com/Kuadrant/mcp-gateway/internal/routing"
+	"github.com/Kuadrant/mcp-gateway/internal/session"
+)
+
+// exit codes of the offline commands
+const (
+	exitOK     = 0
+	exitFailed = 1
+	exitUsage  = 2
+)
+
+const commandHelp = `usage:
+  mcp_gateway config validate [--tools snapshot.yaml] config.yaml...
+  mcp_gateway route explain --mcp-gateway-config config.yaml [--tools snapshot.yaml] [-H 'name: value']... --data '<json-rpc>'
+`
+
+// isCommand reports whether args start with an offline command rather than
+// the flags of the broker-router itself
+func isCommand(args []string) bool {
+	return len(args) > 0 && (args[0] == "config" || args[0] == "route")
+}
+
+// runCommand runs an offline command and returns the process exit code.
+// The commands never contact upstream servers, Redis or Kubernetes.
+func runCommand(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	if len(args) < 2 {
+		_, _ = fmt.Fprint(stderr, commandHelp)
+		return exitUsage
+	}
+	switch args[0] + " " + args[1] {
+	case "config validate":
+		return runConfigValidate(args[2:], stdout, stderr)
+	case "route explain":
+		return runRouteExplain(args[2:], stdin, stdout, stderr)
+	default:
+		_, _ = fmt.Fprintf(stderr, "unknown command %q\n%s", args[0]+" "+args[1], commandHelp)
+		return exitUsage
+	}
+}
+
+// runConfigValidate lints each config file, printing one line per finding.
+// It fails when a file cannot be read or has any error findings; warnings
+// alone do not fail it.
+func runConfigValidate(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("config validate", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	toolsFile := fs.String("tools", "", "tools snapshot to check virtual server tools and prompts against")
+	if err := fs.Parse(args); err != nil {
+		return exitUsage
+	}
+	if fs.NArg() == 0 {
+		_, _ = fmt.Fprint(stderr, "config validate: no config files given\n", commandHelp)
+		return exitUsage
+	}
+	snapshot, err := readToolsSnapshot(*toolsFile)
+	if err != nil {
+		_, _ = fmt.Fprintf(stderr, "config validate: %v\n", err)
+		return exitFailed
+	}
+
+	code := exitOK
+	for _, path := range fs.Args() {
+		cfg, err := readBrokerConfig(path)
+		if err != nil {
+			_, _ = fmt.Fprintf(stdout, "%s: error: %v\n", path, err)
+			code = exitFailed
+			continue
+		}
+		findings := configlint.Lint(cfg, snapshot)
+		for _, f := range findings {
+			_, _ = fmt.Fprintf(stdout, "%s: %s\n", path, f)
+		}
+		if configlint.HasErrors(findings) {
+			code = exitFailed
+		}
+	}
+	return code
+}
+
+// headerFlags collects repeated -H 'name: value' flags. Names are lowercased
+// as Envoy passes them to the router.
+type headerFlags map[string]string
+
+func (h headerFlags) String() string {
+	return fmt.Sprint(map[string]string(h))
+}
+
+func (h headerFlags) Set(value string) error {
+	name, val, ok := strings.Cut(value, ":")
+	if !ok || strings.TrimSpace(name) == "" {
+		return fmt.Errorf("header %q is not in the form 'name: value'", value)
+	}
+	h[strings.ToLower(strings.TrimSpace(name))] = strings.TrimSpace(val)
+	return nil
+}
+
+// explanation is the printed form of a routing.Decision
+type explanation struct {
+	Router       string            `json:"router"`
+	Authority    string            `json:"authority,omitempty"`
+	Path         string            `json:"path,omitempty"`
+	SetHeaders   map[string]string `json:"setHeaders,omitempty"`
+	UnsetHeaders []string          `json:"unsetHeaders,omitempty"`
+	Body         json.RawMessage   `json:"body,omitempty"`
+	BrokerPass   bool              `json:"brokerPass,omitempty"`
+	Error        *explainedError   `json:"error,omitempty"`
+}
+
+type explainedError struct {
+	StatusCode   int    `json:"statusCode"`
+	Message      string `json:"message,omitempty"`
+	JSONRPCError string `json:"jsonrpcError,omitempty"`
+}
+
+// runRouteExplain routes a single request through the router the gateway
+// would pick for it and prints the resulting decision as JSON
+func runRouteExplain(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("route explain", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	configFile := fs.String("mcp-gateway-config", "./config/samples/config.yaml", "the broker config to route against")
+	toolsFile := fs.String("tools", "", "tools snapshot to build the routing table from")
+	publicHost := fs.String("mcp-gateway-public-host", "", "the public host of the gateway, used as the request authority")
+	path := fs.String("path", "/mcp", "the request path")
+	data := fs.String("data", "", "the JSON-RPC request body, @file to read it from a file or @- to read stdin")
+	headers := headerFlags{}
+	fs.Var(headers, "H", "a request header as 'name: value', may be repeated")
+	if err := fs.Parse(args); err != nil {
+		return exitUsage
+	}
+	if *data == "" {
+		_, _ = fmt.Fprint(stderr, "route explain: --data is required\n", commandHelp)
+		return exitUsage
+	}
+
+	body, err := readData(*data, stdin)
+	if err != nil {
+		_, _ = fmt.Fprintf(stderr, "route explain: %v\n", err)
+		return exitFailed
+	}
+	cfg, err := readBrokerConfig(*configFile)
+	if err != nil {
+		_, _ = fmt.Fprintf(stderr, "route explain: %v\n", err)
+		return exitFailed
+	}
+	snapshot, err := readToolsSnapshot(*toolsFile)
+	if err != nil {
+		_, _ = fmt.Fprintf(stderr, "route explain: %v\n", err)
+		return exitFailed
+	}
+
+	out, err := explainRoute(context.Background(), cfg, snapshot, *publicHost, *path, headers, body)
+	if err != nil {
+		_, _ = fmt.Fprintf(stderr, "route explain: %v\n", err)
+		return exitFailed
+	}
+	enc := json.NewEncoder(stdout)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(out); err != nil {
+		_, _ = fmt.Fprintf(stderr, "route explain: %v\n", err)
+		return exitFailed
+	}
+	return exitOK
+}
+
+// explainRoute routes body the way the ext_proc server does, against a table
+// built from snapshot. Stateful requests are given a session minted for the
+// explanation, with a placeholder backend session for every server, so no
+// backend is ever initialized.
+func explainRoute(ctx context.Context, cfg config.BrokerConfig, snapshot config.ToolsSnapshot, publicHost, path string, headers map[string]string, body []byte) (*explanation, error) {
+	routingCfg := &config.MCPServersConfig{MCPGatewayExternalHostname: publicHost}
+	servers := make([]*config.MCPServer, len(cfg.Servers))
+	for i := range cfg.Servers {
+		servers[i] = &cfg.Servers[i]
+	}
+	virtualServers := make([]*config.VirtualServer, len(cfg.VirtualServers))
+	for i, vs := range cfg.VirtualServers {
+		virtualServers[i] = &config.VirtualServer{Name: vs.Name, Tools: vs.Tools, Prompts: vs.Prompts}
+	}
+	routingCfg.SetServers(servers, virtualServers)
+	var routingCfgPtr atomic.Pointer[config.MCPServersConfig]
+	routingCfgPtr.Store(routingCfg)
+	table := broker.BuildRoutingTable(cfg.Servers, snapshot)
+	tableFunc := func() routing.RoutingTable { return table }
+	logger := slog.New(slog.DiscardHandler)
+
+	// path-based protocol override: /mcp/stateful forces 2025
+	protocolVersion := headers["mcp-protocol-version"]
+	if strings.HasSuffix(path, protocol.PathSuffixStateful) {
+		protocolVersion = protocol.Version2025
+	}
+
+	var mcpReq routing.MCPRequest
+	if err := json.Unmarshal(body, &mcpReq); err != nil {
+		return &explanation{Error: &explainedError{StatusCode: 400, Message: "invalid request body"}}, nil
+	}
+	if _, err := mcpReq.Validate(); err != nil {
+		return &explanation{Error: &explainedError{StatusCode: 400, Message: "invalid mcp request"}}, nil
+	}
+	mcpReq.Headers = headers
+	req := &routing.Request{
+		MCPMethod:       mcpReq.Method,
+		MCPName:         mcpReq.ToolName(),
+		ProtocolVersion: headers["mcp-protocol-version"],
+		Authority:       publicHost,
+		Path:            path,
+		RequestID:       headers["x-request-id"],
+		Body:            body,
+		Parsed:          &mcpReq,
+		RawHeaders:      headers,
+	}
+
+	if protocolVersion == protocol.Version2026 {
+		req.MCPMethod = headers["mcp-method"]
+		req.MCPName = headers["mcp-name"]
+		router := &routing.Router202607{Table: tableFunc, RoutingConfig: &routingCfgPtr, Logger: logger}
+		return newExplanation("202607", router.RouteRequest(ctx, req)), nil
+	}
+
+	router, err := newExplainRouter202511(&routingCfgPtr, tableFunc, cfg.Servers, logger)
+	if err != nil {
+		return nil, err
+	}
+	if !mcpReq.IsInitializeRequest() {
+		headers[routing.SessionHeader] = router.JWTManager.Generate()
+		req.SessionID = headers[routing.SessionHeader]
+	}
+	return newExplanation("202511", router.RouteRequest(ctx, req)), nil
+}
+
+// newExplainRouter202511 builds a Router202511 backed by in-memory state.
+// Every server has a placeholder backend session, so InitForClient is never
+// reached.
+func newExplainRouter202511(routingCfg *atomic.Pointer[config.MCPServersConfig], table routing.RoutingTableFunc, servers []config.MCPServer, logger *slog.Logger) (*routing.Router202511, error) {
+	cache, err := session.NewCache()
+	if err != nil {
+		return nil, err
+	}
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+	jwtMgr, err := session.NewJWTManager(hex.EncodeToString(key), 0, logger, cache)
+	if err != nil {
+		return nil, err
+	}
+	elicitMap, err := idmap.New()
+	if err != nil {
+		return nil, err
+	}
+	tokenElicitMap, err := elicitation.New()
+	if err != nil {
+		return nil, err
+	}
+	return &routing.Router202511{
+		RoutingConfig: routingCfg,
+		Table:         table,
+		SessionCache:  &explainSessionCache{Cache: cache, servers: servers},
+		JWTManager:    jwtMgr,
+		InitForClient: func(context.Context, string, *config.MCPServer, map[string]string, clients.ClientCapabilities, *clients.HairpinClientPool) (*mcp.ClientSession, error) {
+			return nil, errors.New("backend sessions are not initialized offline")
+		},
+		ElicitationMap:      elicitMap,
+		TokenElicitationMap: tokenElicitMap,
+		Logger:              logger,
+	}, nil
+}
+
+// explainSessionCache reports a placeholder backend session for every
+// configured server, as if the client had already used each of them
+type explainSessionCache struct {
+	*session.Cache
+	servers []config.MCPServer
+}
+
+func (c *explainSessionCache) GetSession(_ context.Context, _ string) (map[string]string, error) {
+	sessions := make(map[string]string, len(c.servers))
+	for _, s := range c.servers {
+		sessions[s.Name] = "backend-session-" + s.Name
+	}
+	return sessions, nil
+}
+
+func newExplanation(router string, d *routing.Decision) *explanation {
+	out := &explanation{
+		Router:       router,
+		Authority:    d.Authority,
+		Path:         d.Path,
+		SetHeaders:   d.SetHeaders,
+		UnsetHeaders: d.UnsetHeaders,
+		BrokerPass:   d.BrokerPass,
+	}
+	if len(d.BodyMutation) > 0 {
+		if json.Valid(d.BodyMutation) {
+			out.Body = d.BodyMutation
+		} else {
+			out.Body, _ = json.Marshal(string(d.BodyMutation))
+		}
+	}
+	if d.Error != nil {
+		out.Error = &explainedError{StatusCode: d.Error.StatusCode, Message: d.Error.Message, JSONRPCError: d.Error.JSONRPCErr}
+	}
+	return out
+}
+
+// readBrokerConfig reads a config file the way the broker-router loads it
+func readBrokerConfig(path string) (config.BrokerConfig, error) {
+	v := viper.New()
+	v.SetConfigFile(path)
+	if err := v.ReadInConfig(); err != nil {
+		return config.BrokerConfig{}, fmt.Errorf("reading config file: %w", err)
+	}
+	servers, virtualServers, err := decodeConfig(v)
+	if err != nil {
+		return config.BrokerConfig{}, err
+	}
+	cfg := config.BrokerConfig{GatewayCACertPEM: v.GetString("gatewayCACertPEM")}
+	for _, s := range servers {
+		cfg.Servers = append(cfg.Servers, *s)
+	}
+	for _, vs := range virtualServers {
+		cfg.VirtualServers = append(cfg.VirtualServers, config.VirtualServerConfig{Name: vs.Name, Tools: vs.Tools, Prompts: vs.Prompts})
+	}
+	if v.IsSet("globalGuardrails") {
+		cfg.GlobalGuardrails = &config.GuardrailsConfig{}
+		if err := v.UnmarshalKey("globalGuardrails", cfg.GlobalGuardrails); err != nil {
+			return config.BrokerConfig{}, fmt.Errorf("decoding globalGuardrails config: %w", err)
+		}
+	}
+	return cfg, nil
+}
+
+// readToolsSnapshot reads a YAML or JSON tools snapshot. An empty path is an
+// empty snapshot.
+func readToolsSnapshot(path string) (config.ToolsSnapshot, error) {
+	var snapshot config.ToolsSnapshot
+	if path == "" {
+		return snapshot, nil
+	}
+	raw, err := os.ReadFile(path) //nolint:gosec // path is given by the user running the command
+	if err != nil {
+		return snapshot, fmt.Errorf("reading tools snapshot: %w", err)
+	}
+	if err := yaml.UnmarshalStrict(raw, &snapshot); err != nil {
+		return snapshot, fmt.Errorf("decoding tools snapshot %s: %w", path, err)
+	}
+	return snapshot, nil
+}
+
+// readData returns data itself, or the contents of the file or stdin it
+// names with a leading @
+func readData(data string, stdin io.Reader) ([]byte, error) {
+	switch {
+	case data == "@-":
+		return io.ReadAll(stdin)
+	case strings.HasPrefix(data, "@"):
+		return os.ReadFile(strings.TrimPrefix(data, "@")) //nolint:gosec // path is given by the user running the command
+	default:
+		return []byte(data), nil
+	}
+}
@@ -0,0 +1,263 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	config "github.com/Kuadrant/mcp-gateway/internal/config"
+	"github.com/Kuadrant/mcp-gateway/internal/routing"
+)
+
+const testConfigYAML = `servers:
+  - name: team-a/weather
+    url: http://weather.team-a.svc:8080/v1/mcp
+    hostname: weather.mcp.local
+    prefix: weather_
+virtualServers:
+  - name: team-a/forecasts
+    tools:
+      - weather_forecast
+`
+
+const testToolsYAML = `servers:
+  - name: team-a/weather
+    tools: [forecast]
+    prompts: [summary]
+`
+
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestRunConfigValidate(t *testing.T) {
+	valid := writeFile(t, "valid.yaml", testConfigYAML)
+	invalid := writeFile(t, "invalid.yaml", strings.Replace(testConfigYAML, "prefix: weather_", "prefix: Weather-", 1))
+	tools := writeFile(t, "tools.yaml", testToolsYAML)
+	missingTool := writeFile(t, "missing-tool.yaml", strings.Replace(testConfigYAML, "weather_forecast", "weather_history", 1))
+
+	cases := []struct {
+		name       string
+		args       []string
+		wantCode   int
+		wantStdout []string
+	}{
+		{"valid config", []string{valid}, exitOK, nil},
+		{"valid config against snapshot", []string{"--tools", tools, valid}, exitOK, nil},
+		{"invalid prefix", []string{valid, invalid}, exitFailed, []string{invalid + `: error: servers[0].prefix: "Weather-" does not match`}},
+		{"unknown tool in snapshot", []string{"--tools", tools, missingTool}, exitFailed, []string{`virtualServers[0].tools[0]: unknown tool "weather_history"`}},
+		{"missing file", []string{filepath.Join(t.TempDir(), "missing.yaml")}, exitFailed, []string{"reading config file"}},
+		{"no files", nil, exitUsage, nil},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			code := runCommand(append([]string{"config", "validate"}, tc.args...), nil, &stdout, &stderr)
+			if code != tc.wantCode {
+				t.Fatalf("exit code = %d, want %d (stdout %q, stderr %q)", code, tc.wantCode, stdout.String(), stderr.String())
+			}
+			if tc.wantStdout == nil && tc.wantCode == exitOK && stdout.Len() != 0 {
+				t.Errorf("unexpected output %q", stdout.String())
+			}
+			for _, want := range tc.wantStdout {
+				if !strings.Contains(stdout.String(), want) {
+					t.Errorf("output %q does not contain %q", stdout.String(), want)
+				}
+			}
+		})
+	}
+}
+
+func TestRunCommandUnknown(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	if code := runCommand([]string{"config", "apply"}, nil, &stdout, &stderr); code != exitUsage {
+		t.Errorf("exit code = %d, want %d", code, exitUsage)
+	}
+	if !strings.Contains(stderr.String(), "usage:") {
+		t.Errorf("expected usage on stderr, got %q", stderr.String())
+	}
+}
+
+func TestIsCommand(t *testing.T) {
+	if !isCommand([]string{"config", "validate"}) || !isCommand([]string{"route"}) {
+		t.Error("expected offline commands to be recognised")
+	}
+	if isCommand(nil) || isCommand([]string{"--log-level=-4"}) {
+		t.Error("expected broker-router flags not to be treated as a command")
+	}
+}
+
+func TestExplainRoute(t *testing.T) {
+	cfg, err := readBrokerConfig(writeFile(t, "config.yaml", testConfigYAML))
+	if err != nil {
+		t.Fatal(err)
+	}
+	snapshot, err := readToolsSnapshot(writeFile(t, "tools.yaml", testToolsYAML))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		name    string
+		path    string
+		headers map[string]string
+		body    string
+		check   func(t *testing.T, out *explanation)
+	}{
+		{
+			name: "stateful tool call is routed to the server",
+			body: `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"weather_forecast"}}`,
+			check: func(t *testing.T, out *explanation) {
+				if out.Router != "202511" || out.Error != nil {
+					t.Fatalf("unexpected explanation %+v", out)
+				}
+				if out.Authority != "weather.mcp.local" || out.Path != "/v1/mcp" {
+					t.Errorf("routed to %s%s", out.Authority, out.Path)
+				}
+				if out.SetHeaders[routing.ToolHeader] != "forecast" || out.SetHeaders[routing.MCPServerNameHeader] != "team-a/weather" {
+					t.Errorf("unexpected headers %v", out.SetHeaders)
+				}
+				if out.SetHeaders[routing.SessionHeader] != "backend-session-team-a/weather" {
+					t.Errorf("unexpected backend session %q", out.SetHeaders[routing.SessionHeader])
+				}
+				if !strings.Contains(string(out.Body), `"name":"forecast"`) {
+					t.Errorf("tool name not rewritten in body %s", out.Body)
+				}
+			},
+		},
+		{
+			name: "stateful prompt get",
+			body: `{"jsonrpc":"2.0","id":1,"method":"prompts/get","params":{"name":"weather_summary"}}`,
+			check: func(t *testing.T, out *explanation) {
+				if out.Error != nil || out.Authority != "weather.mcp.local" {
+					t.Errorf("unexpected explanation %+v", out)
+				}
+			},
+		},
+		{
+			name: "unknown tool",
+			body: `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"weather_history"}}`,
+			check: func(t *testing.T, out *explanation) {
+				if out.Error == nil || !strings.Contains(out.Error.JSONRPCError, "Tool not found") {
+					t.Errorf("expected tool not found, got %+v", out)
+				}
+			},
+		},
+		{
+			name: "initialize goes to the broker",
+			body: `{"jsonrpc":"2.0","id":1,"method":"initialize","params":{}}`,
+			check: func(t *testing.T, out *explanation) {
+				if out.Error != nil || out.Authority != "" || out.SetHeaders[routing.MethodHeader] != "initialize" {
+					t.Errorf("unexpected explanation %+v", out)
+				}
+			},
+		},
+		{
+			name:    "stateless tool call routes on headers",
+			headers: map[string]string{"mcp-protocol-version": "2026-07-28", "mcp-method": "tools/call", "mcp-name": "weather_forecast"},
+			body:    `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"weather_forecast"}}`,
+			check: func(t *testing.T, out *explanation) {
+				if out.Router != "202607" || out.Error != nil || out.Authority != "weather.mcp.local" {
+					t.Errorf("unexpected explanation %+v", out)
+				}
+			},
+		},
+		{
+			name:    "stateful path overrides the protocol version",
+			path:    "/mcp/stateful",
+			headers: map[string]string{"mcp-protocol-version": "2026-07-28"},
+			body:    `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"weather_forecast"}}`,
+			check: func(t *testing.T, out *explanation) {
+				if out.Router != "202511" {
+					t.Errorf("expected the 202511 router, got %q", out.Router)
+				}
+			},
+		},
+		{
+			name: "invalid body",
+			body: `not json`,
+			check: func(t *testing.T, out *explanation) {
+				if out.Error == nil || out.Error.StatusCode != 400 {
+					t.Errorf("expected a 400, got %+v", out)
+				}
+			},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			path := tc.path
+			if path == "" {
+				path = "/mcp"
+			}
+			headers := map[string]string{}
+			for k, v := range tc.headers {
+				headers[k] = v
+			}
+			out, err := explainRoute(context.Background(), cfg, snapshot, "mcp.example.com", path, headers, []byte(tc.body))
+			if err != nil {
+				t.Fatal(err)
+			}
+			tc.check(t, out)
+		})
+	}
+}
+
+func TestRunRouteExplain(t *testing.T) {
+	configPath := writeFile(t, "config.yaml", testConfigYAML)
+	toolsPath := writeFile(t, "tools.yaml", testToolsYAML)
+	stdin := strings.NewReader(`{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"weather_forecast"}}`)
+	var stdout, stderr bytes.Buffer
+	code := runCommand([]string{"route", "explain",
+		"--mcp-gateway-config", configPath,
+		"--tools", toolsPath,
+		"-H", "Content-Type: application/json",
+		"--data", "@-",
+	}, stdin, &stdout, &stderr)
+	if code != exitOK {
+		t.Fatalf("exit code = %d, stderr %q", code, stderr.String())
+	}
+	var out explanation
+	if err := json.Unmarshal(stdout.Bytes(), &out); err != nil {
+		t.Fatalf("output is not JSON: %v\n%s", err, stdout.String())
+	}
+	if out.Authority != "weather.mcp.local" {
+		t.Errorf("unexpected authority %q", out.Authority)
+	}
+}
+
+func TestReadBrokerConfig(t *testing.T) {
+	cfg, err := readBrokerConfig(writeFile(t, "config.yaml", testConfigYAML+`gatewayCACertPEM: ca
+globalGuardrails:
+  url: http://guardrails:8000
+  model: m
+  configIDs: [strict]
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := config.BrokerConfig{
+		Servers: []config.MCPServer{{
+			Name:     "team-a/weather",
+			URL:      "http://weather.team-a.svc:8080/v1/mcp",
+			Hostname: "weather.mcp.local",
+			Prefix:   "weather_",
+		}},
+		VirtualServers:   []config.VirtualServerConfig{{Name: "team-a/forecasts", Tools: []string{"weather_forecast"}}},
+		GatewayCACertPEM: "ca",
+		GlobalGuardrails: &config.GuardrailsConfig{URL: "http://guardrails:8000", Model: "m", ConfigIDs: []string{"strict"}},
+	}
+	got, _ := json.Marshal(cfg)
+	wantJSON, _ := json.Marshal(want)
+	if string(got) != string(wantJSON) {
+		t.Errorf("readBrokerConfig() = %s, want %s", got, wantJSON)
+	}
+}
@@ -11,17 +11,22 @@ import (
 	_ "net/http/pprof" //nolint:gosec // G108: intentional pprof endpoint for performance profiling
 	"os"
 	"os/signal"
+	"strings"
 	"sync"
 	"syscall"
 	"time"
 
+	"github.com/Kuadrant/mcp-gateway/internal/authn"
 	"github.com/Kuadrant/mcp-gateway/internal/broker"
+	"github.com/Kuadrant/mcp-gateway/internal/broker/credentials"
 	"github.com/Kuadrant/mcp-gateway/internal/clients"
 	config "github.com/Kuadrant/mcp-gateway/internal/config"
+	"github.com/Kuadrant/mcp-gateway/internal/configstream"
 	"github.com/Kuadrant/mcp-gateway/internal/elicitation"
 	"github.com/Kuadrant/mcp-gateway/internal/idmap"
 	mcpRouter "github.com/Kuadrant/mcp-gateway/internal/mcp-router"
 	mcpotel "github.com/Kuadrant/mcp-gateway/internal/otel"
+	"github.com/Kuadrant/mcp-gateway/internal/redisconn"
 	"github.com/Kuadrant/mcp-gateway/internal/session"
 	goenv "github.com/caitlinelfring/go-env-default"
 	"github.com/fsnotify/fsnotify"
@@ -37,16 +42,21 @@ var (
 )
 
 type commonConfig struct {
-	logLevel              int
-	logFormat             string
-	gatewaySigningKey     string
+	logLevel          int
+	logFormat         string
+	gatewaySigningKey string
+	// verificationKeys are comma-separated keys that verify session JWTs
+	// and decrypt cached tokens but never sign or encrypt
+	verificationKeys      string
 	cacheConnectionString string
-	sessionDurationMins   int64
-	publicHost            string
-	privateHost           string
-	configFile            string
-	enableURLElicitation  bool
-	enableA2A             bool
+	// cacheCACert is a PEM CA bundle trusted for rediss connections
+	cacheCACert          string
+	sessionDurationMins  int64
+	publicHost           string
+	privateHost          string
+	configFile           string
+	enableURLElicitation bool
+	enableA2A            bool
 }
 
 type routerConfig struct {
@@ -54,6 +64,17 @@ type routerConfig struct {
 	commonConfig
 	addr               string
 	maxRequestBodySize int
+	// oauth* configure built-in bearer token validation; enabled by
+	// oauthJWKSURL
+	oauthJWKSURL             string
+	oauthIssuer              string
+	oauthAudiences           string
+	oauthRequiredScopes      string
+	oauthJWKSRefreshSecs     int64
+	oauthResourceMetadataURL string
+	sharedConcurrencyLimits  bool
+	sharedToolResultCache    bool
+	toolResultCacheEntries   int
 }
 
 type brokerConfig struct {
@@ -66,34 +87,58 @@ type brokerConfig struct {
 	invalidToolPolicy          string
 	discoveryToolsEnabled      bool
 	discoveryToolThreshold     int
+	discoveryByteBudget        int64
+	discoveryTokenBudget       int64
 	enablePprof                bool
 	metricsAddr                string
+	sharedCatalog              bool
+	sharedCatalogElection      string
+	sharedCatalogSyncSecs      int64
+	sharedCatalogLeaseSecs     int64
+	configStreamAddress        string
+	configStreamNamespace      string
+	configStreamInsecure       bool
+	credentialProvider         string
+	credentialDir              string
+	credentialCacheTTLSecs     int64
+	vaultAddress               string
+	vaultMount                 string
+	adminToken                 string
+	sessionSubjectBinding      string
+	// configStreamCACert is a PEM CA bundle the config stream certificate
+	// is verified against, the system roots when empty
+	configStreamCACert string
 }
 
 type app struct {
-	routerCfg      routerConfig
-	brokerCfg      brokerConfig
-	mcpConfig      *config.MCPServersConfig
-	configMu       sync.RWMutex
-	logger         *slog.Logger
-	otelShutdown   func(context.Context) error
-	redisClient    *redis.Client
-	sessionCache   *session.Cache
-	jwtMgr         *session.JWTManager
-	elicitMap      idmap.Map
-	tokenElicitMap elicitation.Map
-	hairpinPool    *clients.HairpinClientPool
-	mcpBroker      broker.MCPBroker
-	tokenHandler   http.Handler
-	elicitHandler  http.Handler
-	metricsHandler http.Handler
-	brokerServer   *http.Server
-	metricsServer  *http.Server
-	grpcServer     *grpc.Server
-	server         *mcpRouter.ExtProcServer
+	routerCfg          routerConfig
+	brokerCfg          brokerConfig
+	mcpConfig          *config.MCPServersConfig
+	configMu           sync.RWMutex
+	logger             *slog.Logger
+	otelShutdown       func(context.Context) error
+	redisClient        redis.UniversalClient
+	sessionCache       *session.Cache
+	jwtMgr             *session.JWTManager
+	elicitMap          idmap.Map
+	tokenElicitMap     elicitation.Map
+	hairpinPool        *clients.HairpinClientPool
+	mcpBroker          broker.MCPBroker
+	tokenHandler       http.Handler
+	elicitHandler      http.Handler
+	upstreamOAuth      *broker.UpstreamOAuthHandler
+	credentialProvider credentials.CredentialProvider
+	metricsHandler     http.Handler
+	brokerServer       *http.Server
+	metricsServer      *http.Server
+	grpcServer         *grpc.Server
+	server             *mcpRouter.ExtProcServer
 }
 
 func main() {
+	if isCommand(os.Args[1:]) {
+		os.Exit(runCommand(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+	}
 	ctx := context.Background()
 	a := parseFlags()
 	logOpts, jsonLog := a.setupLogger()
@@ -105,7 +150,13 @@ func main() {
 	a.registerObservers()
 	a.mcpConfig.MCPGatewayExternalHostname = a.brokerCfg.publicHost
 	a.mcpConfig.MCPGatewayInternalHostname = a.brokerCfg.privateHost
-	a.loadAndWatchConfig(ctx)
+	a.mcpBroker.StartCatalogSync(ctx)
+	a.mcpBroker.StartDrainSync(ctx)
+	if a.brokerCfg.configStreamAddress != "" {
+		a.subscribeConfigStream(ctx)
+	} else {
+		a.loadAndWatchConfig(ctx)
+	}
 	a.run(ctx)
 }
 
@@ -137,9 +188,13 @@ func parseFlags() *app {
 		"Key used for JWT session signing and session cache encryption key derivation (env: GATEWAY_SIGNING_KEY or JWT_SESSION_SIGNING_KEY)")
 	flag.StringVar(&bc.gatewaySigningKey, "session-signing-key", gatewaySigningKeyDef,
 		"Deprecated alias for gateway-signing-key")
+	flag.StringVar(&bc.verificationKeys, "gateway-verification-keys", goenv.GetDefault("GATEWAY_VERIFICATION_KEYS", ""),
+		"Comma-separated keys that still verify session JWTs and decrypt cached tokens after a signing key rotation (env: GATEWAY_VERIFICATION_KEYS)")
+	flag.StringVar(&bc.sessionSubjectBinding, "session-subject-binding", goenv.GetDefault("SESSION_SUBJECT_BINDING", string(session.SubjectBindingStrict)),
+		"how a session is bound to the verified subject it was issued to: strict (default) rejects requests from any other subject, lenient only compares when both the session and the request have a subject, disabled turns the check off")
 	flag.StringVar(&bc.cacheConnectionString, "cache-connection-string",
 		goenv.GetDefault("CACHE_CONNECTION_STRING", ""),
-		"redis based cache connection string redis://<user>:<pass>@localhost:6379/<db> (env: CACHE_CONNECTION_STRING). If not set defaults to  in memory storage")
+		"redis based cache connection string redis://<user>:<pass>@localhost:6379/<db>, redis+sentinel://<host>:<port>?master_name=<name> or redis+cluster://<host>:<port>; rediss schemes use TLS (env: CACHE_CONNECTION_STRING). If not set defaults to  in memory storage")
 
 	// broker-specific flags
 	flag.StringVar(&bc.addr, "mcp-broker-public-address", "0.0.0.0:8080", "The public address for MCP broker")
@@ -153,12 +208,61 @@ func parseFlags() *app {
 		"enable discover_tools and select_tools meta-tools for progressive tool discovery")
 	flag.IntVar(&bc.discoveryToolThreshold, "discovery-tool-threshold", 0,
 		"tool count above which real tools are hidden and only meta-tools are shown. 0 means never hide.")
+	flag.Int64Var(&bc.discoveryByteBudget, "discovery-byte-budget", 0,
+		"serialized size in bytes of the visible tool schemas above which real tools are hidden and only meta-tools are shown; larger select_tools selections are rejected. 0 means no budget. Mutually exclusive with --discovery-token-budget")
+	flag.Int64Var(&bc.discoveryTokenBudget, "discovery-token-budget", 0,
+		"as --discovery-byte-budget but in estimated tokens (serialized bytes / 4). 0 means no budget")
 	flag.BoolVar(&bc.enablePprof, "enable-pprof", false, "enable pprof profiling server on localhost:6060")
 	flag.StringVar(&bc.metricsAddr, "metrics-addr", "0.0.0.0:9090", "address for the internal Prometheus metrics endpoint")
+	flag.BoolVar(&bc.sharedCatalog, "shared-catalog", goenv.GetBoolDefault("SHARED_CATALOG", false),
+		"share upstream discovery between replicas through the redis cache: one elected replica connects to upstreams and the rest serve its published catalog (env: SHARED_CATALOG). Requires --cache-connection-string")
+	flag.StringVar(&bc.sharedCatalogElection, "shared-catalog-election", goenv.GetDefault("SHARED_CATALOG_ELECTION", "redis"),
+		"lease used to elect the discovery replica: redis or kubernetes (env: SHARED_CATALOG_ELECTION). kubernetes needs POD_NAMESPACE and a service account allowed to manage leases")
+	flag.Int64Var(&bc.sharedCatalogSyncSecs, "shared-catalog-sync-interval", 5, "interval in seconds at which followers poll the shared catalog. Default 5 seconds.")
+	flag.Int64Var(&bc.sharedCatalogLeaseSecs, "shared-catalog-lease-duration", 15, "duration in seconds of the discovery lease. Default 15 seconds.")
+	flag.StringVar(&bc.configStreamAddress, "config-stream-address", goenv.GetDefault("CONFIG_STREAM_ADDRESS", ""),
+		"controller config stream address to subscribe to instead of reading --mcp-gateway-config (env: CONFIG_STREAM_ADDRESS)")
+	flag.StringVar(&bc.configStreamNamespace, "config-stream-namespace", goenv.GetDefault("CONFIG_STREAM_NAMESPACE", ""),
+		"namespace of the MCPGatewayExtension whose config is streamed (env: CONFIG_STREAM_NAMESPACE)")
+	flag.BoolVar(&bc.configStreamInsecure, "config-stream-insecure", goenv.GetBoolDefault("CONFIG_STREAM_INSECURE", false),
+		"subscribe to the config stream over plaintext gRPC instead of TLS; only for development (env: CONFIG_STREAM_INSECURE)")
+	flag.StringVar(&bc.credentialProvider, "credential-provider", goenv.GetDefault("CREDENTIAL_PROVIDER", ""),
+		"how upstream credential references are resolved: kubernetes, file or vault (env: CREDENTIAL_PROVIDER). Empty only allows inline credentials")
+	flag.StringVar(&bc.credentialDir, "credential-dir", goenv.GetDefault("CREDENTIAL_DIR", "/credentials"),
+		"directory holding mounted credential secrets as <namespace>/<name>/<key> for --credential-provider=file (env: CREDENTIAL_DIR)")
+	flag.Int64Var(&bc.credentialCacheTTLSecs, "credential-cache-ttl", 60,
+		"seconds a resolved upstream credential is reused before it is read again. Default 60 seconds.")
+	flag.StringVar(&bc.vaultAddress, "vault-address", goenv.GetDefault("VAULT_ADDR", ""),
+		"vault address for --credential-provider=vault (env: VAULT_ADDR). The token is read from VAULT_TOKEN")
+	flag.StringVar(&bc.vaultMount, "vault-mount", goenv.GetDefault("VAULT_MOUNT", "secret"),
+		"KV v2 secrets engine mount for --credential-provider=vault (env: VAULT_MOUNT)")
+
+	// read from the environment only so the token never shows in the process list
+	bc.adminToken = os.Getenv("ADMIN_TOKEN")
+	bc.cacheCACert = os.Getenv("CACHE_CA_CERT")
+	bc.configStreamCACert = os.Getenv("CONFIG_STREAM_CA_CERT")
 
 	// router-specific flags
 	flag.StringVar(&rc.addr, "mcp-router-address", "0.0.0.0:50051", "The address for MCP router")
 	flag.IntVar(&rc.maxRequestBodySize, "max-request-body-size", 5242880, "max request body size in bytes for the ext_proc router. Default 5MB.")
+	flag.StringVar(&rc.oauthJWKSURL, "oauth-jwks-url", goenv.GetDefault("OAUTH_JWKS_URL", ""),
+		"JWKS URL of the authorization server. When set the router validates client bearer tokens itself, for deployments without an AuthPolicy (env: OAUTH_JWKS_URL)")
+	flag.StringVar(&rc.oauthIssuer, "oauth-issuer", goenv.GetDefault("OAUTH_ISSUER", ""),
+		"required iss claim of client bearer tokens (env: OAUTH_ISSUER)")
+	flag.StringVar(&rc.oauthAudiences, "oauth-audience", goenv.GetDefault("OAUTH_AUDIENCE", ""),
+		"comma-separated audiences; client bearer tokens must carry at least one in their aud claim (env: OAUTH_AUDIENCE)")
+	flag.StringVar(&rc.oauthRequiredScopes, "oauth-required-scopes", goenv.GetDefault("OAUTH_REQUIRED_SCOPES", ""),
+		"comma-separated scopes every client bearer token must grant (env: OAUTH_REQUIRED_SCOPES)")
+	flag.Int64Var(&rc.oauthJWKSRefreshSecs, "oauth-jwks-refresh-interval", int64(authn.DefaultRefreshInterval/time.Second),
+		"interval in seconds at which JWKS keys are refetched. Tokens signed by an unknown key trigger an earlier refetch. Default 300 seconds.")
+	flag.StringVar(&rc.oauthResourceMetadataURL, "oauth-resource-metadata-url", goenv.GetDefault("OAUTH_RESOURCE_METADATA_URL", ""),
+		"protected resource metadata URL sent in the WWW-Authenticate header of rejected requests (env: OAUTH_RESOURCE_METADATA_URL). Defaults to https://<public-host>/.well-known/oauth-protected-resource/mcp")
+	flag.BoolVar(&rc.sharedConcurrencyLimits, "shared-concurrency-limits", goenv.GetBoolDefault("SHARED_CONCURRENCY_LIMITS", false),
+		"count in-flight tool calls against per-server concurrency limits in the redis cache, so limits hold across replicas (env: SHARED_CONCURRENCY_LIMITS). Requires --cache-connection-string")
+	flag.BoolVar(&rc.sharedToolResultCache, "shared-tool-result-cache", goenv.GetBoolDefault("SHARED_TOOL_RESULT_CACHE", false),
+		"keep cached tool results in the redis cache, so replicas serve each other's results (env: SHARED_TOOL_RESULT_CACHE). Requires --cache-connection-string")
+	flag.IntVar(&rc.toolResultCacheEntries, "tool-result-cache-max-entries", goenv.GetIntDefault("TOOL_RESULT_CACHE_MAX_ENTRIES", 10000),
+		"max cached tool results each replica keeps in memory, least recently used evicted first (env: TOOL_RESULT_CACHE_MAX_ENTRIES). Ignored with --shared-tool-result-cache")
 
 	flag.Parse()
 
@@ -172,6 +276,41 @@ func parseFlags() *app {
 	return a
 }
 
+// verificationKeyList splits the verification keys flag, skipping blanks
+func (bc *brokerConfig) verificationKeyList() []string {
+	return splitList(bc.verificationKeys)
+}
+
+// splitList splits a comma-separated flag value, skipping blanks
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// newAuthenticator returns the router's bearer token authenticator
+func (rc *routerConfig) newAuthenticator() (*authn.Authenticator, error) {
+	return authn.New(rc.oauthJWKSURL,
+		authn.WithIssuer(rc.oauthIssuer),
+		authn.WithAudiences(splitList(rc.oauthAudiences)...),
+		authn.WithRequiredScopes(splitList(rc.oauthRequiredScopes)...),
+		authn.WithRefreshInterval(time.Duration(rc.oauthJWKSRefreshSecs)*time.Second),
+	)
+}
+
+// resourceMetadataURL is where rejected clients are pointed for OAuth
+// discovery: the broker's protected resource metadata on the public host
+func (rc *routerConfig) resourceMetadataURL() string {
+	if rc.oauthResourceMetadataURL != "" {
+		return rc.oauthResourceMetadataURL
+	}
+	return "https://" + rc.publicHost + "/.well-known/oauth-protected-resource/mcp"
+}
+
 func (a *app) setupLogger() (*slog.HandlerOptions, bool) {
 	opts := &slog.HandlerOptions{}
 	// flag value is a raw slog.Level (info=0, warn=4, error=8, debug=-4)
@@ -199,11 +338,11 @@ func (a *app) setupTelemetry(ctx context.Context, logOpts *slog.HandlerOptions,
 func (a *app) setupSessionInfra(ctx context.Context) {
 	if a.brokerCfg.cacheConnectionString != "" {
 		a.logger.Info("cache using external redis store")
-		redisOpt, err := redis.ParseURL(a.brokerCfg.cacheConnectionString)
+		redisClient, err := redisconn.NewClient(a.brokerCfg.cacheConnectionString, a.brokerCfg.cacheCACert)
 		if err != nil {
 			panic("failed to parse redis connection string: " + err.Error())
 		}
-		a.redisClient = redis.NewClient(redisOpt)
+		a.redisClient = redisClient
 		if err := a.redisClient.Ping(ctx).Err(); err != nil {
 			panic("failed to connect to redis: " + err.Error())
 		}
@@ -216,7 +355,15 @@ func (a *app) setupSessionInfra(ctx context.Context) {
 		if err != nil {
 			panic("failed to derive encryption key: " + err.Error())
 		}
-		sessionCacheOpts = append(sessionCacheOpts, session.WithEncryptionKey(encKey))
+		var previous [][]byte
+		for _, key := range a.brokerCfg.verificationKeyList() {
+			prevKey, err := session.DeriveEncryptionKey([]byte(key))
+			if err != nil {
+				panic("failed to derive encryption key: " + err.Error())
+			}
+			previous = append(previous, prevKey)
+		}
+		sessionCacheOpts = append(sessionCacheOpts, session.WithEncryptionKey(encKey, previous...))
 	}
 	var err error
 	a.sessionCache, err = session.NewCache(sessionCacheOpts...)
@@ -229,7 +376,13 @@ func (a *app) setupSessionInfra(ctx context.Context) {
 			"When running via the controller, this is managed automatically. " +
 			"For standalone use, set the GATEWAY_SIGNING_KEY environment variable.")
 	}
-	a.jwtMgr, err = session.NewJWTManager(a.brokerCfg.gatewaySigningKey, a.brokerCfg.sessionDurationMins, a.logger, a.sessionCache)
+	subjectBinding, err := session.ParseSubjectBinding(a.brokerCfg.sessionSubjectBinding)
+	if err != nil {
+		panic("failed to setup jwt manager: " + err.Error())
+	}
+	a.jwtMgr, err = session.NewJWTManager(a.brokerCfg.gatewaySigningKey, a.brokerCfg.sessionDurationMins, a.logger, a.sessionCache,
+		session.WithRevocationStore(a.sessionCache), session.WithSubjectBinding(subjectBinding),
+		session.WithVerificationKeys(a.brokerCfg.verificationKeyList()...))
 	if err != nil {
 		panic("failed to setup jwt manager " + err.Error())
 	}
@@ -286,6 +439,65 @@ func (a *app) loadAndWatchConfig(ctx context.Context) {
 	})
 }
 
+// configStreamStartupTimeout bounds how long startup waits for the first
+// streamed config before serving with an empty one
+const configStreamStartupTimeout = 30 * time.Second
+
+// subscribeConfigStream applies config streamed from the controller in place
+// of the config file. The stream reconnects on its own, keeping the last
+// applied config while the controller is unreachable.
+func (a *app) subscribeConfigStream(ctx context.Context) {
+	if a.brokerCfg.configStreamNamespace == "" {
+		panic("--config-stream-namespace is required with --config-stream-address")
+	}
+	node, err := os.Hostname()
+	if err != nil {
+		node = "unknown"
+	}
+	transport := configstream.WithTLS(a.brokerCfg.configStreamCACert)
+	if a.brokerCfg.configStreamInsecure {
+		a.logger.Warn("subscribing to the config stream over plaintext, broker config is received unencrypted")
+		transport = configstream.WithInsecure()
+	}
+	client := configstream.NewClient(a.brokerCfg.configStreamAddress, a.brokerCfg.configStreamNamespace, node,
+		a.brokerCfg.gatewaySigningKey, a.applyStreamedConfig, a.logger, transport)
+	go func() {
+		if err := client.Run(ctx); err != nil {
+			a.logger.Error("config stream stopped", "error", err)
+		}
+	}()
+	select {
+	case <-client.Ready():
+	case <-time.After(configStreamStartupTimeout):
+		a.logger.Warn("no config received from config stream, starting with an empty config",
+			"address", a.brokerCfg.configStreamAddress)
+	}
+}
+
+// applyStreamedConfig applies a complete config received from the config stream
+func (a *app) applyStreamedConfig(ctx context.Context, cfg config.BrokerConfig) error {
+	newServers := make([]*config.MCPServer, 0, len(cfg.Servers))
+	for _, s := range cfg.Servers {
+		newServers = append(newServers, &s)
+	}
+	newVirtualServers := make([]*config.VirtualServer, 0, len(cfg.VirtualServers))
+	for _, vs := range cfg.VirtualServers {
+		newVirtualServers = append(newVirtualServers, &config.VirtualServer{
+			Name:    vs.Name,
+			Tools:   vs.Tools,
+			Prompts: vs.Prompts,
+		})
+	}
+	a.configMu.Lock()
+	err := a.applyConfig(newServers, newVirtualServers, cfg.GatewayCACertPEM)
+	a.configMu.Unlock()
+	if err != nil {
+		return err
+	}
+	a.mcpConfig.Notify(ctx)
+	return nil
+}
+
 // shutdown budgets, spent sequentially. their sum (27s) must fit inside the
 // pod's terminationGracePeriodSeconds (30s by default) or the kubelet kills the
 // process mid-drain and none of this runs to completion.
@@ -410,19 +622,35 @@ func (a *app) loadConfig(path string) error {
 	if err := viper.ReadInConfig(); err != nil {
 		return fmt.Errorf("reading config file: %w", err)
 	}
-	var newServers []*config.MCPServer
-	if err := viper.UnmarshalKey("servers", &newServers); err != nil {
-		return fmt.Errorf("decoding server config: %w", err)
+	if !viper.IsSet("virtualServers") {
+		a.logger.Debug("No virtualServers section found in configuration")
+	}
+	newServers, newVirtualServers, err := decodeConfig(viper.GetViper())
+	if err != nil {
+		return err
+	}
+	return a.applyConfig(newServers, newVirtualServers, viper.GetString("gatewayCACertPEM"))
+}
+
+// decodeConfig decodes the servers and virtual servers of a config file
+// already read into v
+func decodeConfig(v *viper.Viper) ([]*config.MCPServer, []*config.VirtualServer, error) {
+	var servers []*config.MCPServer
+	if err := v.UnmarshalKey("servers", &servers); err != nil {
+		return nil, nil, fmt.Errorf("decoding server config: %w", err)
 	}
-	var newVirtualServers []*config.VirtualServer
-	if viper.IsSet("virtualServers") {
-		if err := viper.UnmarshalKey("virtualServers", &newVirtualServers); err != nil {
-			return fmt.Errorf("decoding virtualServers config: %w", err)
+	var virtualServers []*config.VirtualServer
+	if v.IsSet("virtualServers") {
+		if err := v.UnmarshalKey("virtualServers", &virtualServers); err != nil {
+			return nil, nil, fmt.Errorf("decoding virtualServers config: %w", err)
 		}
-	} else {
-		a.logger.Debug("No virtualServers section found in configuration")
 	}
-	gatewayCACertPEM := viper.GetString("gatewayCACertPEM")
+	return servers, virtualServers, nil
+}
+
+// applyConfig rebuilds the hairpin client for the gateway CA and sets the
+// servers and virtual servers. Callers hold configMu and notify observers.
+func (a *app) applyConfig(newServers []*config.MCPServer, newVirtualServers []*config.VirtualServer, gatewayCACertPEM string) error {
 	if a.hairpinPool != nil {
 		if err := a.hairpinPool.Rebuild(a.brokerCfg.privateHost, a.brokerCfg.publicHost, gatewayCACertPEM); err != nil {
 			return fmt.Errorf("rebuilding hairpin client: %w", err)
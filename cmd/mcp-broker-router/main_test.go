@@ -1,8 +1,12 @@
 package main
 
 import (
+	"context"
+	"io"
 	"log/slog"
 	"testing"
+
+	config "github.com/Kuadrant/mcp-gateway/internal/config"
 )
 
 func TestSetupLoggerLevelMapping(t *testing.T) {
@@ -28,3 +32,58 @@ func TestSetupLoggerLevelMapping(t *testing.T) {
 		})
 	}
 }
+
+func TestApplyStreamedConfig(t *testing.T) {
+	a := &app{
+		mcpConfig: &config.MCPServersConfig{},
+		logger:    slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+	err := a.applyStreamedConfig(context.Background(), config.BrokerConfig{
+		Servers: []config.MCPServer{
+			{Name: "a", URL: "http://a/mcp", Prefix: "a_"},
+			{Name: "b", URL: "http://b/mcp", Prefix: "b_"},
+		},
+		VirtualServers: []config.VirtualServerConfig{
+			{Name: "vs", Tools: []string{"a_one"}, Prompts: []string{"b_two"}},
+		},
+		GatewayCACertPEM: "ca",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	servers := a.mcpConfig.ListServers()
+	if len(servers) != 2 || servers[0].Name != "a" || servers[1].Name != "b" {
+		t.Fatalf("unexpected servers: %+v", servers)
+	}
+	virtualServers := a.mcpConfig.ListVirtualServers()
+	if len(virtualServers) != 1 || virtualServers[0].Name != "vs" ||
+		virtualServers[0].Tools[0] != "a_one" || virtualServers[0].Prompts[0] != "b_two" {
+		t.Fatalf("unexpected virtual servers: %+v", virtualServers)
+	}
+	if got := a.mcpConfig.GetGatewayCACertPEM(); got != "ca" {
+		t.Errorf("gateway CA: got %q, want %q", got, "ca")
+	}
+}
+
+func TestRouterConfigResourceMetadataURL(t *testing.T) {
+	rc := &routerConfig{}
+	rc.publicHost = "mcp.example.com:8443"
+	if got, want := rc.resourceMetadataURL(), "https://mcp.example.com:8443/.well-known/oauth-protected-resource/mcp"; got != want {
+		t.Errorf("default resource metadata URL = %q, want %q", got, want)
+	}
+	rc.oauthResourceMetadataURL = "http://localhost:8080/.well-known/oauth-protected-resource"
+	if got := rc.resourceMetadataURL(); got != rc.oauthResourceMetadataURL {
+		t.Errorf("resource metadata URL = %q, want the configured %q", got, rc.oauthResourceMetadataURL)
+	}
+}
+
+func TestSplitList(t *testing.T) {
+	got := splitList(" a, ,b ,c,")
+	if len(got) != 3 || got[0] != "a" || got[1] != "b" || got[2] != "c" {
+		t.Errorf("splitList = %q, want [a b c]", got)
+	}
+	if got := splitList(""); got != nil {
+		t.Errorf("splitList of empty = %q, want nil", got)
+	}
+}
@@ -1,8 +1,12 @@
 package main
 
 import (
+	"os"
+
 	"github.com/Kuadrant/mcp-gateway/internal/clients"
+	"github.com/Kuadrant/mcp-gateway/internal/concurrency"
 	mcpRouter "github.com/Kuadrant/mcp-gateway/internal/mcp-router"
+	"github.com/Kuadrant/mcp-gateway/internal/resultcache"
 	"github.com/Kuadrant/mcp-gateway/internal/routing"
 	extProcV3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
 	"google.golang.org/grpc"
@@ -18,7 +22,40 @@ func (a *app) createRouter() {
 		ElicitationMap:     a.elicitMap,
 		MaxRequestBodySize: cfg.maxRequestBodySize,
 		EnableA2A:          cfg.enableA2A,
+		// the same key the broker verifies x-mcp-authorized with
+		TrustedHeadersPublicKey: os.Getenv("TRUSTED_HEADER_PUBLIC_KEY"),
+	}
+
+	if cfg.oauthJWKSURL != "" {
+		authenticator, err := cfg.newAuthenticator()
+		if err != nil {
+			panic("failed to setup bearer token authenticator: " + err.Error())
+		}
+		a.logger.Info("router validates client bearer tokens", "jwks", cfg.oauthJWKSURL, "issuer", cfg.oauthIssuer)
+		a.server.Authenticator = authenticator
+		a.server.ResourceMetadataURL = cfg.resourceMetadataURL()
+		a.server.ValidateBackendInitToken = a.jwtMgr.ValidateBackendInitToken
+	}
+
+	a.server.ConcurrencyLimiter = a.newConcurrencyLimiter()
+
+	canaryMetrics, err := mcpRouter.NewCanaryMetrics()
+	if err != nil {
+		panic("failed to setup canary metrics: " + err.Error())
+	}
+	a.server.CanaryMetrics = canaryMetrics
+
+	retryBudget, err := mcpRouter.NewRetryBudget()
+	if err != nil {
+		panic("failed to setup retry budget: " + err.Error())
+	}
+	a.server.RetryBudget = retryBudget
+
+	resultCache, err := mcpRouter.NewResultCache(a.newResultCacheStore())
+	if err != nil {
+		panic("failed to setup tool result cache: " + err.Error())
 	}
+	a.server.ResultCache = resultCache
 
 	if a.mcpConfig == nil {
 		panic("mcpConfig must be non-nil before constructing the ext_proc server")
@@ -34,18 +71,25 @@ func (a *app) createRouter() {
 		Logger: a.logger.With("component", "response-handler-202607"),
 	}
 
-	a.server.Router = &routing.Router202511{
-		RoutingConfig:       &a.server.RoutingConfig,
-		Table:               a.mcpBroker.RoutingTable,
-		SessionCache:        a.sessionCache,
-		JWTManager:          a.jwtMgr,
-		InitForClient:       clients.Initialize,
-		HairpinClientPool:   a.hairpinPool,
-		ElicitationMap:      a.elicitMap,
-		TokenElicitationMap: a.tokenElicitMap,
-		ElicitationEnabled:  cfg.enableURLElicitation,
-		Logger:              a.logger.With("component", "router-202511"),
+	router := &routing.Router202511{
+		RoutingConfig:        &a.server.RoutingConfig,
+		Table:                a.mcpBroker.RoutingTable,
+		SessionCache:         a.sessionCache,
+		JWTManager:           a.jwtMgr,
+		InitForClient:        clients.Initialize,
+		SetLogLevelForClient: clients.SetLoggingLevel,
+		RejectForClient:      clients.RejectServerRequest,
+		HairpinClientPool:    a.hairpinPool,
+		ElicitationMap:       a.elicitMap,
+		TokenElicitationMap:  a.tokenElicitMap,
+		ElicitationEnabled:   cfg.enableURLElicitation,
+		Logger:               a.logger.With("component", "router-202511"),
+	}
+	if cfg.enableURLElicitation {
+		router.TokenRefresher = a.upstreamOAuth
 	}
+	a.server.Router = router
+	a.server.RejectServerRequest = router.RejectServerRequest
 
 	a.server.ResponseHandler = &routing.ResponseHandler202511{
 		RoutingConfig:      &a.server.RoutingConfig,
@@ -57,3 +101,36 @@ func (a *app) createRouter() {
 
 	extProcV3.RegisterExternalProcessorServer(a.grpcServer, a.server)
 }
+
+// newConcurrencyLimiter returns the limiter for per-server concurrency limits,
+// counting in redis when the limits are shared between replicas
+func (a *app) newConcurrencyLimiter() concurrency.Limiter {
+	var limiter concurrency.Limiter
+	var err error
+	if a.routerCfg.sharedConcurrencyLimits {
+		if a.redisClient == nil {
+			panic("--shared-concurrency-limits requires --cache-connection-string")
+		}
+		a.logger.Info("concurrency limits shared through redis")
+		limiter, err = concurrency.New(concurrency.WithRedisClient(a.redisClient))
+	} else {
+		limiter, err = concurrency.New()
+	}
+	if err != nil {
+		panic("failed to setup concurrency limiter: " + err.Error())
+	}
+	return limiter
+}
+
+// newResultCacheStore returns the store for cached tool results, shared
+// through redis when --shared-tool-result-cache is set
+func (a *app) newResultCacheStore() resultcache.Store {
+	if a.routerCfg.sharedToolResultCache {
+		if a.redisClient == nil {
+			panic("--shared-tool-result-cache requires --cache-connection-string")
+		}
+		a.logger.Info("tool results cached in redis")
+		return resultcache.New(resultcache.WithRedisClient(a.redisClient))
+	}
+	return resultcache.New(resultcache.WithMaxEntries(a.routerCfg.toolResultCacheEntries))
+}
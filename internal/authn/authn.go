@@ -0,0 +1,258 @@
+// Package authn validates OAuth 2.0 bearer access tokens for deployments
+// that do not put an AuthPolicy in front of the gateway
+package authn
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	// DefaultRefreshInterval is how long fetched JWKS keys are used before
+	// they are fetched again
+	DefaultRefreshInterval = 5 * time.Minute
+	// DefaultMinRefreshInterval is the shortest gap between two JWKS fetches
+	DefaultMinRefreshInterval = 10 * time.Second
+	// DefaultLeeway is the clock skew allowed when checking exp and nbf
+	DefaultLeeway = 30 * time.Second
+)
+
+// RFC 6750 error codes carried in the WWW-Authenticate challenge
+const (
+	ErrorCodeInvalidRequest    = "invalid_request"
+	ErrorCodeInvalidToken      = "invalid_token"
+	ErrorCodeInsufficientScope = "insufficient_scope"
+)
+
+// signingMethods are the JWS algorithms accepted on access tokens. HMAC is
+// excluded: a JWKS only publishes public keys.
+var signingMethods = []string{
+	"RS256", "RS384", "RS512",
+	"PS256", "PS384", "PS512",
+	"ES256", "ES384", "ES512",
+	"EdDSA",
+}
+
+// Error is an authentication failure. Code is empty when the request had
+// no credentials, as RFC 6750 asks for a bare challenge in that case.
+type Error struct {
+	Code        string
+	Description string
+	// Scope lists the required scopes on insufficient_scope failures
+	Scope string
+	cause error
+}
+
+func (e *Error) Error() string {
+	if e.cause != nil {
+		return e.Description + ": " + e.cause.Error()
+	}
+	return e.Description
+}
+
+func (e *Error) Unwrap() error {
+	return e.cause
+}
+
+// StatusCode returns the HTTP status for the failure: 403 for a valid token
+// lacking a required scope, as RFC 6750 asks, otherwise 401
+func (e *Error) StatusCode() int {
+	if e.Code == ErrorCodeInsufficientScope {
+		return http.StatusForbidden
+	}
+	return http.StatusUnauthorized
+}
+
+// Challenge returns the WWW-Authenticate header value for the failure,
+// pointing clients at the protected resource metadata document as the MCP
+// authorization spec requires
+func (e *Error) Challenge(resourceMetadataURL string) string {
+	params := []string{fmt.Sprintf("resource_metadata=%q", resourceMetadataURL)}
+	if e.Code != "" {
+		params = append(params, fmt.Sprintf("error=%q", e.Code))
+		if e.Description != "" {
+			params = append(params, fmt.Sprintf("error_description=%q", e.Description))
+		}
+	}
+	if e.Scope != "" {
+		params = append(params, fmt.Sprintf("scope=%q", e.Scope))
+	}
+	return "Bearer " + strings.Join(params, ", ")
+}
+
+// Claims are the access token claims the gateway reads
+type Claims struct {
+	jwt.RegisteredClaims
+	// Scope is the space-separated scope claim of RFC 9068
+	Scope string `json:"scope,omitempty"`
+	// Scp is the scope claim some issuers send instead, as a list or a
+	// space-separated string
+	Scp any `json:"scp,omitempty"`
+}
+
+// Scopes returns the scopes granted by the token
+func (c *Claims) Scopes() []string {
+	scopes := strings.Fields(c.Scope)
+	switch scp := c.Scp.(type) {
+	case string:
+		scopes = append(scopes, strings.Fields(scp)...)
+	case []any:
+		for _, s := range scp {
+			if s, ok := s.(string); ok {
+				scopes = append(scopes, s)
+			}
+		}
+	}
+	return scopes
+}
+
+// Authenticator validates bearer JWTs against the keys of a JWKS
+type Authenticator struct {
+	keys           *keySet
+	issuer         string
+	audiences      []string
+	requiredScopes []string
+	leeway         time.Duration
+}
+
+// New returns an Authenticator verifying token signatures with the keys
+// published at jwksURL. Keys are fetched on first use.
+func New(jwksURL string, opts ...func(*Authenticator)) (*Authenticator, error) {
+	if jwksURL == "" {
+		return nil, errors.New("JWKS URL is required")
+	}
+	a := &Authenticator{
+		keys: &keySet{
+			url:                jwksURL,
+			client:             &http.Client{Timeout: 10 * time.Second},
+			refreshInterval:    DefaultRefreshInterval,
+			minRefreshInterval: DefaultMinRefreshInterval,
+			now:                time.Now,
+		},
+		leeway: DefaultLeeway,
+	}
+	for _, o := range opts {
+		o(a)
+	}
+	if a.keys.minRefreshInterval > a.keys.refreshInterval {
+		a.keys.minRefreshInterval = a.keys.refreshInterval
+	}
+	return a, nil
+}
+
+// WithIssuer requires the iss claim to equal issuer
+func WithIssuer(issuer string) func(*Authenticator) {
+	return func(a *Authenticator) {
+		a.issuer = issuer
+	}
+}
+
+// WithAudiences requires the aud claim to contain at least one of audiences
+func WithAudiences(audiences ...string) func(*Authenticator) {
+	return func(a *Authenticator) {
+		a.audiences = audiences
+	}
+}
+
+// WithRequiredScopes requires the token to grant every one of scopes
+func WithRequiredScopes(scopes ...string) func(*Authenticator) {
+	return func(a *Authenticator) {
+		a.requiredScopes = scopes
+	}
+}
+
+// WithRefreshInterval sets how long fetched keys are used before they are
+// fetched again
+func WithRefreshInterval(d time.Duration) func(*Authenticator) {
+	return func(a *Authenticator) {
+		if d > 0 {
+			a.keys.refreshInterval = d
+		}
+	}
+}
+
+// WithMinRefreshInterval sets the shortest gap between two JWKS fetches
+func WithMinRefreshInterval(d time.Duration) func(*Authenticator) {
+	return func(a *Authenticator) {
+		a.keys.minRefreshInterval = d
+	}
+}
+
+// WithHTTPClient sets the client used to fetch the JWKS
+func WithHTTPClient(client *http.Client) func(*Authenticator) {
+	return func(a *Authenticator) {
+		a.keys.client = client
+	}
+}
+
+// WithLeeway sets the clock skew allowed when checking exp and nbf
+func WithLeeway(d time.Duration) func(*Authenticator) {
+	return func(a *Authenticator) {
+		a.leeway = d
+	}
+}
+
+// withClock replaces the time source, for tests
+func withClock(now func() time.Time) func(*Authenticator) {
+	return func(a *Authenticator) {
+		a.keys.now = now
+	}
+}
+
+// Authenticate validates the bearer token in an Authorization header value:
+// signature, expiry, issuer, audience and required scopes. Failures are
+// returned as *Error.
+func (a *Authenticator) Authenticate(ctx context.Context, authHeader string) (*Claims, error) {
+	if authHeader == "" {
+		return nil, &Error{Description: "authentication required"}
+	}
+	scheme, token, ok := strings.Cut(authHeader, " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") || strings.TrimSpace(token) == "" {
+		return nil, &Error{Code: ErrorCodeInvalidRequest, Description: "expected a Bearer token"}
+	}
+
+	parserOpts := []jwt.ParserOption{
+		jwt.WithValidMethods(signingMethods),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(a.leeway),
+		jwt.WithTimeFunc(a.keys.now),
+	}
+	if a.issuer != "" {
+		parserOpts = append(parserOpts, jwt.WithIssuer(a.issuer))
+	}
+	if len(a.audiences) > 0 {
+		parserOpts = append(parserOpts, jwt.WithAudience(a.audiences...))
+	}
+
+	claims := &Claims{}
+	_, err := jwt.ParseWithClaims(strings.TrimSpace(token), claims, func(t *jwt.Token) (any, error) {
+		kid, _ := t.Header["kid"].(string)
+		return a.keys.key(ctx, kid)
+	}, parserOpts...)
+	if err != nil {
+		description := "the access token is invalid"
+		if errors.Is(err, jwt.ErrTokenExpired) {
+			description = "the access token expired"
+		}
+		return nil, &Error{Code: ErrorCodeInvalidToken, Description: description, cause: err}
+	}
+
+	granted := claims.Scopes()
+	for _, scope := range a.requiredScopes {
+		if !slices.Contains(granted, scope) {
+			return nil, &Error{
+				Code:        ErrorCodeInsufficientScope,
+				Description: "the access token lacks a required scope",
+				Scope:       strings.Join(a.requiredScopes, " "),
+			}
+		}
+	}
+	return claims, nil
+}
@@ -0,0 +1,373 @@
+package authn
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/require"
+)
+
+// jwksServer is a local stand-in for an issuer's JWKS endpoint
+type jwksServer struct {
+	*httptest.Server
+	mu       sync.Mutex
+	keys     []map[string]string
+	requests atomic.Int32
+	status   int
+}
+
+func newJWKSServer(t *testing.T) *jwksServer {
+	t.Helper()
+	s := &jwksServer{status: http.StatusOK}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		s.requests.Add(1)
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if s.status != http.StatusOK {
+			w.WriteHeader(s.status)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]any{"keys": s.keys})
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *jwksServer) setKeys(keys ...map[string]string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.keys = keys
+}
+
+func (s *jwksServer) setStatus(code int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.status = code
+}
+
+func b64(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func rsaJWK(kid string, key *rsa.PrivateKey) map[string]string {
+	return map[string]string{
+		"kty": "RSA",
+		"kid": kid,
+		"use": "sig",
+		"n":   b64(key.N.Bytes()),
+		"e":   b64(big.NewInt(int64(key.E)).Bytes()),
+	}
+}
+
+func ecJWK(kid string, key *ecdsa.PrivateKey) map[string]string {
+	point, err := key.PublicKey.Bytes()
+	if err != nil {
+		panic(err)
+	}
+	size := (len(point) - 1) / 2
+	return map[string]string{
+		"kty": "EC",
+		"kid": kid,
+		"crv": "P-256",
+		"x":   b64(point[1 : 1+size]),
+		"y":   b64(point[1+size:]),
+	}
+}
+
+func newRSAKey(t *testing.T) *rsa.PrivateKey {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	return key
+}
+
+func sign(t *testing.T, method jwt.SigningMethod, kid string, key any, claims jwt.MapClaims) string {
+	t.Helper()
+	tok := jwt.NewWithClaims(method, claims)
+	if kid != "" {
+		tok.Header["kid"] = kid
+	}
+	s, err := tok.SignedString(key)
+	require.NoError(t, err)
+	return s
+}
+
+func validClaims() jwt.MapClaims {
+	return jwt.MapClaims{
+		"sub":   "user-1",
+		"iss":   "https://issuer.example.com",
+		"aud":   "mcp-gateway",
+		"exp":   time.Now().Add(time.Hour).Unix(),
+		"scope": "openid mcp:tools",
+	}
+}
+
+func TestAuthenticate(t *testing.T) {
+	rsaKey := newRSAKey(t)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	otherKey := newRSAKey(t)
+
+	srv := newJWKSServer(t)
+	srv.setKeys(rsaJWK("rsa-1", rsaKey), ecJWK("ec-1", ecKey))
+
+	a, err := New(srv.URL,
+		WithIssuer("https://issuer.example.com"),
+		WithAudiences("mcp-gateway", "other"),
+		WithRequiredScopes("mcp:tools"),
+		WithLeeway(0),
+	)
+	require.NoError(t, err)
+
+	with := func(mutate func(jwt.MapClaims)) jwt.MapClaims {
+		c := validClaims()
+		mutate(c)
+		return c
+	}
+
+	tests := []struct {
+		name      string
+		header    string
+		wantSub   string
+		wantCode  string
+		wantScope string
+	}{
+		{
+			name:    "valid RSA token",
+			header:  "Bearer " + sign(t, jwt.SigningMethodRS256, "rsa-1", rsaKey, validClaims()),
+			wantSub: "user-1",
+		},
+		{
+			name:    "valid EC token",
+			header:  "Bearer " + sign(t, jwt.SigningMethodES256, "ec-1", ecKey, validClaims()),
+			wantSub: "user-1",
+		},
+		{
+			name:    "case insensitive scheme",
+			header:  "bearer " + sign(t, jwt.SigningMethodRS256, "rsa-1", rsaKey, validClaims()),
+			wantSub: "user-1",
+		},
+		{
+			name: "scp list grants the scope",
+			header: "Bearer " + sign(t, jwt.SigningMethodRS256, "rsa-1", rsaKey, with(func(c jwt.MapClaims) {
+				delete(c, "scope")
+				c["scp"] = []string{"mcp:tools"}
+			})),
+			wantSub: "user-1",
+		},
+		{
+			name:     "missing header",
+			header:   "",
+			wantCode: "",
+		},
+		{
+			name:     "not a bearer token",
+			header:   "Basic dXNlcjpwYXNz",
+			wantCode: ErrorCodeInvalidRequest,
+		},
+		{
+			name:     "malformed token",
+			header:   "Bearer not-a-jwt",
+			wantCode: ErrorCodeInvalidToken,
+		},
+		{
+			name:     "signed by an unknown key",
+			header:   "Bearer " + sign(t, jwt.SigningMethodRS256, "rsa-1", otherKey, validClaims()),
+			wantCode: ErrorCodeInvalidToken,
+		},
+		{
+			name:     "unknown kid",
+			header:   "Bearer " + sign(t, jwt.SigningMethodRS256, "rsa-2", otherKey, validClaims()),
+			wantCode: ErrorCodeInvalidToken,
+		},
+		{
+			name:     "HMAC token",
+			header:   "Bearer " + sign(t, jwt.SigningMethodHS256, "rsa-1", []byte("secret"), validClaims()),
+			wantCode: ErrorCodeInvalidToken,
+		},
+		{
+			name:     "expired",
+			header:   "Bearer " + sign(t, jwt.SigningMethodRS256, "rsa-1", rsaKey, with(func(c jwt.MapClaims) { c["exp"] = time.Now().Add(-time.Minute).Unix() })),
+			wantCode: ErrorCodeInvalidToken,
+		},
+		{
+			name:     "no expiry",
+			header:   "Bearer " + sign(t, jwt.SigningMethodRS256, "rsa-1", rsaKey, with(func(c jwt.MapClaims) { delete(c, "exp") })),
+			wantCode: ErrorCodeInvalidToken,
+		},
+		{
+			name:     "wrong issuer",
+			header:   "Bearer " + sign(t, jwt.SigningMethodRS256, "rsa-1", rsaKey, with(func(c jwt.MapClaims) { c["iss"] = "https://evil.example.com" })),
+			wantCode: ErrorCodeInvalidToken,
+		},
+		{
+			name:     "wrong audience",
+			header:   "Bearer " + sign(t, jwt.SigningMethodRS256, "rsa-1", rsaKey, with(func(c jwt.MapClaims) { c["aud"] = "someone-else" })),
+			wantCode: ErrorCodeInvalidToken,
+		},
+		{
+			name:      "missing scope",
+			header:    "Bearer " + sign(t, jwt.SigningMethodRS256, "rsa-1", rsaKey, with(func(c jwt.MapClaims) { c["scope"] = "openid" })),
+			wantCode:  ErrorCodeInsufficientScope,
+			wantScope: "mcp:tools",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims, err := a.Authenticate(context.Background(), tt.header)
+			if tt.wantSub != "" {
+				require.NoError(t, err)
+				require.Equal(t, tt.wantSub, claims.Subject)
+				return
+			}
+			require.Error(t, err)
+			var authErr *Error
+			require.True(t, errors.As(err, &authErr))
+			require.Equal(t, tt.wantCode, authErr.Code)
+			require.Equal(t, tt.wantScope, authErr.Scope)
+		})
+	}
+}
+
+func TestAuthenticate_Ed25519(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	srv := newJWKSServer(t)
+	srv.setKeys(map[string]string{"kty": "OKP", "crv": "Ed25519", "x": b64(pub)})
+
+	a, err := New(srv.URL)
+	require.NoError(t, err)
+
+	// a single key in the set matches tokens without a kid
+	claims, err := a.Authenticate(context.Background(), "Bearer "+sign(t, jwt.SigningMethodEdDSA, "", priv, validClaims()))
+	require.NoError(t, err)
+	require.Equal(t, "user-1", claims.Subject)
+}
+
+func TestAuthenticate_KeyRotation(t *testing.T) {
+	oldKey := newRSAKey(t)
+	newKey := newRSAKey(t)
+	srv := newJWKSServer(t)
+	srv.setKeys(rsaJWK("old", oldKey))
+
+	now := time.Now()
+	clock := func() time.Time { return now }
+	a, err := New(srv.URL, WithRefreshInterval(time.Hour), WithMinRefreshInterval(10*time.Second), withClock(clock))
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	_, err = a.Authenticate(ctx, "Bearer "+sign(t, jwt.SigningMethodRS256, "old", oldKey, validClaims()))
+	require.NoError(t, err)
+	_, err = a.Authenticate(ctx, "Bearer "+sign(t, jwt.SigningMethodRS256, "old", oldKey, validClaims()))
+	require.NoError(t, err)
+	require.EqualValues(t, 1, srv.requests.Load(), "cached keys must be reused")
+
+	// the issuer rotates; a token with the new kid is rejected while early
+	// refetches are throttled, then accepted once a refetch is allowed
+	srv.setKeys(rsaJWK("old", oldKey), rsaJWK("new", newKey))
+	newToken := "Bearer " + sign(t, jwt.SigningMethodRS256, "new", newKey, validClaims())
+	_, err = a.Authenticate(ctx, newToken)
+	require.Error(t, err)
+	require.EqualValues(t, 1, srv.requests.Load())
+
+	now = now.Add(11 * time.Second)
+	_, err = a.Authenticate(ctx, newToken)
+	require.NoError(t, err)
+	require.EqualValues(t, 2, srv.requests.Load())
+
+	// unknown kids cannot force a fetch per request
+	for range 5 {
+		_, err = a.Authenticate(ctx, "Bearer "+sign(t, jwt.SigningMethodRS256, "bogus", newKey, validClaims()))
+		require.Error(t, err)
+	}
+	require.EqualValues(t, 2, srv.requests.Load())
+}
+
+func TestAuthenticate_RefreshFailureKeepsKeys(t *testing.T) {
+	key := newRSAKey(t)
+	srv := newJWKSServer(t)
+	srv.setKeys(rsaJWK("k1", key))
+
+	now := time.Now()
+	a, err := New(srv.URL, WithRefreshInterval(time.Minute), withClock(func() time.Time { return now }))
+	require.NoError(t, err)
+	token := "Bearer " + sign(t, jwt.SigningMethodRS256, "k1", key, validClaims())
+
+	_, err = a.Authenticate(context.Background(), token)
+	require.NoError(t, err)
+
+	srv.setStatus(http.StatusInternalServerError)
+	now = now.Add(2 * time.Minute)
+	_, err = a.Authenticate(context.Background(), token)
+	require.NoError(t, err, "stale keys are served while the issuer is unavailable")
+	require.EqualValues(t, 2, srv.requests.Load())
+}
+
+func TestAuthenticate_JWKSUnavailable(t *testing.T) {
+	key := newRSAKey(t)
+	srv := newJWKSServer(t)
+	srv.setStatus(http.StatusServiceUnavailable)
+
+	a, err := New(srv.URL)
+	require.NoError(t, err)
+	_, err = a.Authenticate(context.Background(), "Bearer "+sign(t, jwt.SigningMethodRS256, "k1", key, validClaims()))
+	var authErr *Error
+	require.True(t, errors.As(err, &authErr))
+	require.Equal(t, ErrorCodeInvalidToken, authErr.Code)
+}
+
+func TestNew_RequiresJWKSURL(t *testing.T) {
+	_, err := New("")
+	require.Error(t, err)
+}
+
+func TestErrorChallenge(t *testing.T) {
+	const metadata = "https://mcp.example.com/.well-known/oauth-protected-resource/mcp"
+	tests := []struct {
+		name   string
+		err    *Error
+		want   string
+		status int
+	}{
+		{
+			name:   "no credentials",
+			err:    &Error{Description: "authentication required"},
+			want:   `Bearer resource_metadata="` + metadata + `"`,
+			status: http.StatusUnauthorized,
+		},
+		{
+			name:   "invalid token",
+			err:    &Error{Code: ErrorCodeInvalidToken, Description: "the access token expired"},
+			want:   `Bearer resource_metadata="` + metadata + `", error="invalid_token", error_description="the access token expired"`,
+			status: http.StatusUnauthorized,
+		},
+		{
+			name:   "insufficient scope",
+			err:    &Error{Code: ErrorCodeInsufficientScope, Description: "the access token lacks a required scope", Scope: "mcp:tools mcp:admin"},
+			want:   `Bearer resource_metadata="` + metadata + `", error="insufficient_scope", error_description="the access token lacks a required scope", scope="mcp:tools mcp:admin"`,
+			status: http.StatusForbidden,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, tt.err.Challenge(metadata))
+			require.Equal(t, tt.status, tt.err.StatusCode())
+		})
+	}
+}
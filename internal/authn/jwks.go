@@ -0,0 +1,238 @@
+package authn
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// maxJWKSSize bounds the JWKS document read from the issuer
+const maxJWKSSize = 1 << 20
+
+// jwk is the subset of RFC 7517 JSON Web Key members used for signature
+// verification
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Crv string `json:"crv"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// keySet caches the verification keys published at a JWKS URL. Keys are
+// refetched once refreshInterval has passed, and early when a token names a
+// key ID the cache does not hold, so issuer key rotation is picked up
+// without a restart. Fetches are limited to one per minRefreshInterval so
+// tokens with made-up key IDs, or an unreachable issuer, cannot turn every
+// request into a JWKS request.
+type keySet struct {
+	url                string
+	client             *http.Client
+	refreshInterval    time.Duration
+	minRefreshInterval time.Duration
+	now                func() time.Time
+
+	// fetchMu serialises fetches so concurrent misses share one request
+	fetchMu     sync.Mutex
+	mu          sync.RWMutex
+	keys        map[string]crypto.PublicKey
+	fetched     time.Time
+	lastAttempt time.Time
+}
+
+// key returns the verification key with the given key ID. An empty kid
+// matches only when the set holds a single key.
+func (ks *keySet) key(ctx context.Context, kid string) (crypto.PublicKey, error) {
+	if k, fresh := ks.lookup(kid); k != nil && fresh {
+		return k, nil
+	}
+	if err := ks.refresh(ctx, kid); err != nil {
+		// a failed refresh keeps serving the keys already held
+		if k, _ := ks.lookup(kid); k != nil {
+			return k, nil
+		}
+		return nil, err
+	}
+	if k, _ := ks.lookup(kid); k != nil {
+		return k, nil
+	}
+	return nil, fmt.Errorf("no signing key found for kid %q", kid)
+}
+
+// lookup reports the cached key for kid and whether the cache is within its
+// refresh interval
+func (ks *keySet) lookup(kid string) (crypto.PublicKey, bool) {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+	fresh := !ks.fetched.IsZero() && ks.now().Sub(ks.fetched) < ks.refreshInterval
+	if kid == "" {
+		if len(ks.keys) == 1 {
+			for _, k := range ks.keys {
+				return k, fresh
+			}
+		}
+		return nil, fresh
+	}
+	return ks.keys[kid], fresh
+}
+
+// refresh refetches the key set unless another caller already did so while
+// this one waited, or the last attempt was under minRefreshInterval ago
+func (ks *keySet) refresh(ctx context.Context, kid string) error {
+	ks.fetchMu.Lock()
+	defer ks.fetchMu.Unlock()
+
+	if k, fresh := ks.lookup(kid); k != nil && fresh {
+		return nil
+	}
+	ks.mu.RLock()
+	throttled := !ks.lastAttempt.IsZero() && ks.now().Sub(ks.lastAttempt) < ks.minRefreshInterval
+	ks.mu.RUnlock()
+	if throttled {
+		return nil
+	}
+
+	ks.mu.Lock()
+	ks.lastAttempt = ks.now()
+	ks.mu.Unlock()
+
+	keys, err := ks.fetch(ctx)
+	if err != nil {
+		return err
+	}
+	ks.mu.Lock()
+	ks.keys = keys
+	ks.fetched = ks.now()
+	ks.mu.Unlock()
+	return nil
+}
+
+func (ks *keySet) fetch(ctx context.Context) (map[string]crypto.PublicKey, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, ks.url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("building JWKS request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := ks.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("fetching JWKS: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetching JWKS: unexpected status %d", resp.StatusCode)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxJWKSSize))
+	if err != nil {
+		return nil, fmt.Errorf("reading JWKS: %w", err)
+	}
+	return parseJWKS(body)
+}
+
+// parseJWKS returns the signature keys of a JWKS document by key ID. Keys of
+// unsupported types or marked for encryption are skipped.
+func parseJWKS(body []byte) (map[string]crypto.PublicKey, error) {
+	var doc struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.Unmarshal(body, &doc); err != nil {
+		return nil, fmt.Errorf("decoding JWKS: %w", err)
+	}
+	keys := make(map[string]crypto.PublicKey, len(doc.Keys))
+	for _, k := range doc.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		pub, err := k.publicKey()
+		if err != nil {
+			continue
+		}
+		keys[k.Kid] = pub
+	}
+	if len(keys) == 0 {
+		return nil, errors.New("JWKS contains no usable signing keys")
+	}
+	return keys, nil
+}
+
+func (k jwk) publicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeBase64URL(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeBase64URL(k.E)
+		if err != nil {
+			return nil, err
+		}
+		exp := new(big.Int).SetBytes(e)
+		if !exp.IsInt64() || exp.Int64() < 2 || exp.Int64() > 1<<31-1 {
+			return nil, errors.New("invalid RSA exponent")
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(exp.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported EC curve %q", k.Crv)
+		}
+		x, err := decodeBase64URL(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeBase64URL(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		size := (curve.Params().BitSize + 7) / 8
+		if len(x) > size || len(y) > size {
+			return nil, errors.New("invalid EC point")
+		}
+		point := make([]byte, 1+2*size)
+		point[0] = 4
+		copy(point[1+size-len(x):1+size], x)
+		copy(point[1+2*size-len(y):], y)
+		return ecdsa.ParseUncompressedPublicKey(curve, point)
+	case "OKP":
+		if k.Crv != "Ed25519" {
+			return nil, fmt.Errorf("unsupported OKP curve %q", k.Crv)
+		}
+		x, err := decodeBase64URL(k.X)
+		if err != nil {
+			return nil, err
+		}
+		if len(x) != ed25519.PublicKeySize {
+			return nil, errors.New("invalid Ed25519 key")
+		}
+		return ed25519.PublicKey(x), nil
+	}
+	return nil, fmt.Errorf("unsupported key type %q", k.Kty)
+}
+
+func decodeBase64URL(s string) ([]byte, error) {
+	if s == "" {
+		return nil, errors.New("missing key parameter")
+	}
+	return base64.RawURLEncoding.DecodeString(s)
+}
@@ -0,0 +1,31 @@
+package authn
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseJWKS(t *testing.T) {
+	key := newRSAKey(t)
+	enc := rsaJWK("enc", key)
+	enc["use"] = "enc"
+	body, err := json.Marshal(map[string]any{"keys": []map[string]string{
+		rsaJWK("sig", key),
+		enc,
+		{"kty": "oct", "kid": "sym", "k": "c2VjcmV0"},
+		{"kty": "EC", "kid": "bad-curve", "crv": "P-192", "x": "AA", "y": "AA"},
+	}})
+	require.NoError(t, err)
+
+	keys, err := parseJWKS(body)
+	require.NoError(t, err)
+	require.Len(t, keys, 1)
+	require.Contains(t, keys, "sig")
+
+	_, err = parseJWKS([]byte(`{"keys":[{"kty":"oct","k":"c2VjcmV0"}]}`))
+	require.Error(t, err)
+	_, err = parseJWKS([]byte(`not json`))
+	require.Error(t, err)
+}
@@ -0,0 +1,319 @@
+package broker
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	internaljwt "github.com/Kuadrant/mcp-gateway/internal/jwt"
+	"github.com/Kuadrant/mcp-gateway/internal/routing"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// AdminSession describes a gateway session known to the session cache or
+// connected to this replica. ID is the log-safe session identifier: the
+// raw session ID is a bearer token and is never returned.
+type AdminSession struct {
+	ID        string            `json:"id"`
+	ExpiresAt *time.Time        `json:"expiresAt,omitempty"`
+	Connected bool              `json:"connected"`
+	Backends  map[string]string `json:"backends"`
+}
+
+// AdminHandler returns the operational admin API. Every request must carry
+// the admin token as a bearer token and every request is audit logged.
+//
+//	GET    /admin/sessions                      list gateway sessions
+//	DELETE /admin/sessions/{id}                 terminate a gateway session
+//	DELETE /admin/subjects/{subject}/sessions   revoke every session of a subject
+//	POST   /admin/refresh/{server...}           run discovery for a server now
+//	GET    /admin/drains                        list drained servers
+//	PUT    /admin/drains/{server...}            drain a server, ?duration= to expire
+//	DELETE /admin/drains/{server...}            lift a drain
+//	GET    /admin/routing-table                 dump the routing table
+func (m *mcpBrokerImpl) AdminHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.Handle("GET /admin/sessions", m.adminOperation("list-sessions", m.handleListSessions))
+	mux.Handle("DELETE /admin/sessions/{id}", m.adminOperation("terminate-session", m.handleTerminateSession))
+	mux.Handle("DELETE /admin/subjects/{subject}/sessions", m.adminOperation("revoke-subject-sessions", m.handleRevokeSubjectSessions))
+	mux.Handle("POST /admin/refresh/{server...}", m.adminOperation("refresh-server", m.handleRefreshServer))
+	mux.Handle("GET /admin/drains", m.adminOperation("list-drains", m.handleListDrains))
+	mux.Handle("PUT /admin/drains/{server...}", m.adminOperation("drain-server", m.handleDrainServer))
+	mux.Handle("DELETE /admin/drains/{server...}", m.adminOperation("undrain-server", m.handleUndrainServer))
+	mux.Handle("GET /admin/routing-table", m.adminOperation("dump-routing-table", m.handleDumpRoutingTable))
+	mux.Handle("/admin/", m.adminOperation("unknown", func(w http.ResponseWriter, _ *http.Request) {
+		writeAdminError(w, http.StatusNotFound, "unknown admin operation")
+	}))
+	return mux
+}
+
+// adminOperation authenticates the request and audit logs its outcome
+func (m *mcpBrokerImpl) adminOperation(operation string, next http.HandlerFunc) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		if m.authorizeAdmin(r) {
+			next(rec, r)
+		} else {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="mcp-gateway-admin"`)
+			writeAdminError(rec, http.StatusUnauthorized, "unauthorized")
+		}
+		target := r.PathValue("server")
+		if id := r.PathValue("id"); id != "" {
+			target = id
+		}
+		if subject := r.PathValue("subject"); subject != "" {
+			target = subject
+		}
+		m.logger.InfoContext(r.Context(), "admin operation", "audit", true,
+			"operation", operation,
+			"target", target,
+			"method", r.Method,
+			"path", r.URL.Path,
+			"remote_addr", r.RemoteAddr,
+			"status", rec.status)
+	})
+}
+
+// authorizeAdmin checks the bearer token in constant time. With no admin
+// token configured nothing is authorized.
+func (m *mcpBrokerImpl) authorizeAdmin(r *http.Request) bool {
+	if m.adminToken == "" {
+		return false
+	}
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(token), []byte(m.adminToken)) == 1
+}
+
+func (m *mcpBrokerImpl) handleListSessions(w http.ResponseWriter, r *http.Request) {
+	if m.sessionCache == nil {
+		writeAdminError(w, http.StatusServiceUnavailable, "no session cache configured")
+		return
+	}
+	cached, err := m.sessionCache.ListSessions(r.Context())
+	if err != nil {
+		writeAdminError(w, http.StatusInternalServerError, fmt.Sprintf("listing sessions: %v", err))
+		return
+	}
+	live := m.liveSessions()
+	for id := range live {
+		if _, ok := cached[id]; !ok {
+			cached[id] = map[string]string{}
+		}
+	}
+	sessions := make([]AdminSession, 0, len(cached))
+	for raw, backends := range cached {
+		s := AdminSession{
+			ID:        internaljwt.LogSafeSessionID(raw),
+			Connected: live[raw] != nil,
+			Backends:  make(map[string]string, len(backends)),
+		}
+		// backend session IDs are credentials to the upstream server too
+		for server, backend := range backends {
+			s.Backends[server] = internaljwt.LogSafeSessionID(backend)
+		}
+		var claims struct {
+			Exp int64 `json:"exp"`
+		}
+		if internaljwt.DecodePayload(raw, &claims) && claims.Exp > 0 {
+			exp := time.Unix(claims.Exp, 0).UTC()
+			s.ExpiresAt = &exp
+		}
+		sessions = append(sessions, s)
+	}
+	slices.SortFunc(sessions, func(a, b AdminSession) int { return strings.Compare(a.ID, b.ID) })
+	writeAdminJSON(w, http.StatusOK, map[string]any{"sessions": sessions})
+}
+
+// handleTerminateSession closes the session if it is connected to this
+// replica and removes its cached state. With a session revoker configured
+// the session ID is revoked too, so no replica accepts it again; otherwise
+// it remains valid until it expires.
+func (m *mcpBrokerImpl) handleTerminateSession(w http.ResponseWriter, r *http.Request) {
+	if m.sessionCache == nil {
+		writeAdminError(w, http.StatusServiceUnavailable, "no session cache configured")
+		return
+	}
+	id := r.PathValue("id")
+	cached, err := m.sessionCache.ListSessions(r.Context())
+	if err != nil {
+		writeAdminError(w, http.StatusInternalServerError, fmt.Sprintf("listing sessions: %v", err))
+		return
+	}
+	live := m.liveSessions()
+	var raw string
+	for candidate := range cached {
+		if internaljwt.LogSafeSessionID(candidate) == id {
+			raw = candidate
+		}
+	}
+	for candidate := range live {
+		if internaljwt.LogSafeSessionID(candidate) == id {
+			raw = candidate
+		}
+	}
+	if raw == "" {
+		writeAdminError(w, http.StatusNotFound, fmt.Sprintf("session %q not found", id))
+		return
+	}
+
+	revoked := m.sessionRevoker != nil
+	if revoked {
+		if err := m.sessionRevoker.Revoke(r.Context(), raw); err != nil {
+			writeAdminError(w, http.StatusInternalServerError, fmt.Sprintf("revoking session: %v", err))
+			return
+		}
+	}
+	connected := m.closeSession(raw, live)
+	if err := m.sessionCache.DeleteSessions(r.Context(), raw); err != nil {
+		writeAdminError(w, http.StatusInternalServerError, fmt.Sprintf("deleting session: %v", err))
+		return
+	}
+	writeAdminJSON(w, http.StatusOK, map[string]any{"id": id, "terminated": true, "connected": connected, "revoked": revoked})
+}
+
+// handleRevokeSubjectSessions revokes every session issued to a subject,
+// e.g. when a user is offboarded, and closes those connected to this
+// replica. Sessions on other replicas are rejected on their next request.
+func (m *mcpBrokerImpl) handleRevokeSubjectSessions(w http.ResponseWriter, r *http.Request) {
+	if m.sessionRevoker == nil {
+		writeAdminError(w, http.StatusServiceUnavailable, "no session revoker configured")
+		return
+	}
+	subject := r.PathValue("subject")
+	revoked, err := m.sessionRevoker.RevokeSubject(r.Context(), subject)
+	live := m.liveSessions()
+	ids := make([]string, 0, len(revoked))
+	for _, raw := range revoked {
+		m.closeSession(raw, live)
+		ids = append(ids, internaljwt.LogSafeSessionID(raw))
+	}
+	if err != nil {
+		writeAdminError(w, http.StatusInternalServerError, fmt.Sprintf("revoking sessions for subject: %v", err))
+		return
+	}
+	slices.Sort(ids)
+	writeAdminJSON(w, http.StatusOK, map[string]any{"subject": subject, "sessions": ids})
+}
+
+// closeSession closes a session connected to this replica, or releases its
+// per-session state when it is not, and reports whether it was connected
+func (m *mcpBrokerImpl) closeSession(raw string, live map[string]*mcp.ServerSession) bool {
+	ss := live[raw]
+	if ss == nil {
+		m.evictUserSessions(raw)
+		return false
+	}
+	// the session's end hook releases its per-session state
+	if err := ss.Close(); err != nil {
+		m.logger.Warn("closing terminated session", "session", internaljwt.LogSafeSessionID(raw), "error", err)
+	}
+	return true
+}
+
+// liveSessions returns the gateway sessions connected to this replica
+func (m *mcpBrokerImpl) liveSessions() map[string]*mcp.ServerSession {
+	live := map[string]*mcp.ServerSession{}
+	for ss := range m.gatewayServer.server.Sessions() {
+		if id := ss.ID(); id != "" {
+			live[id] = ss
+		}
+	}
+	return live
+}
+
+func (m *mcpBrokerImpl) handleRefreshServer(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("server")
+	srv := m.findServerByName(name)
+	if srv == nil {
+		writeAdminError(w, http.StatusNotFound, fmt.Sprintf("server %q not found", name))
+		return
+	}
+	srv.Refresh()
+	resp := map[string]any{"server": name, "refresh": "requested"}
+	if m.catalog != nil && !m.catalog.leader.Load() {
+		// followers re-list from the shared catalog; only the leader
+		// contacts the upstream
+		resp["note"] = "this replica follows the shared catalog, refresh on the catalog leader to rediscover the upstream"
+	}
+	writeAdminJSON(w, http.StatusAccepted, resp)
+}
+
+func (m *mcpBrokerImpl) handleListDrains(w http.ResponseWriter, _ *http.Request) {
+	writeAdminJSON(w, http.StatusOK, map[string]any{"drains": m.drainedServers()})
+}
+
+func (m *mcpBrokerImpl) handleDrainServer(w http.ResponseWriter, r *http.Request) {
+	var duration time.Duration
+	if raw := r.URL.Query().Get("duration"); raw != "" {
+		var err error
+		duration, err = time.ParseDuration(raw)
+		if err != nil || duration <= 0 {
+			writeAdminError(w, http.StatusBadRequest, fmt.Sprintf("invalid duration %q: must be a positive duration such as 15m", raw))
+			return
+		}
+	}
+	name := r.PathValue("server")
+	drain, err := m.drainServer(r.Context(), name, duration)
+	if errors.Is(err, errServerNotFound) {
+		writeAdminError(w, http.StatusNotFound, fmt.Sprintf("server %q not found", name))
+		return
+	}
+	if err != nil {
+		writeAdminError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeAdminJSON(w, http.StatusOK, drain)
+}
+
+func (m *mcpBrokerImpl) handleUndrainServer(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("server")
+	err := m.undrainServer(r.Context(), name)
+	if errors.Is(err, errNotDrained) {
+		writeAdminError(w, http.StatusNotFound, fmt.Sprintf("server %q is not drained", name))
+		return
+	}
+	if err != nil {
+		writeAdminError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (m *mcpBrokerImpl) handleDumpRoutingTable(w http.ResponseWriter, _ *http.Request) {
+	// RoutingTable builds the table on a cold start
+	table, ok := m.RoutingTable().(*routing.Table)
+	if !ok {
+		writeAdminError(w, http.StatusInternalServerError, "routing table unavailable")
+		return
+	}
+	writeAdminJSON(w, http.StatusOK, table.Snapshot())
+}
+
+// statusRecorder captures the response status for the audit log
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (s *statusRecorder) WriteHeader(status int) {
+	s.status = status
+	s.ResponseWriter.WriteHeader(status)
+}
+
+func writeAdminJSON(w http.ResponseWriter, status int, data any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(data)
+}
+
+func writeAdminError(w http.ResponseWriter, status int, message string) {
+	writeAdminJSON(w, status, map[string]string{"error": message})
+}
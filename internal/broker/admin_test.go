@@ -0,0 +1,332 @@
+package broker
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Kuadrant/mcp-gateway/internal/config"
+	internaljwt "github.com/Kuadrant/mcp-gateway/internal/jwt"
+	"github.com/Kuadrant/mcp-gateway/internal/routing"
+	"github.com/Kuadrant/mcp-gateway/internal/session"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testAdminToken = "admin-secret"
+
+// adminMockServer serves fixed tools and counts refresh requests
+type adminMockServer struct {
+	*mockActiveMCPServer
+	tools     []mcp.Tool
+	refreshes atomic.Int32
+}
+
+func (m *adminMockServer) GetManagedTools() []mcp.Tool { return m.tools }
+func (m *adminMockServer) Refresh()                    { m.refreshes.Add(1) }
+
+var adminTestServers = []config.MCPServer{
+	{Name: "team-a/weather", URL: "http://weather/mcp", Hostname: "weather.mcp.local", Prefix: "weather_"},
+	{Name: "team-a/files", URL: "http://files/mcp", Hostname: "files.mcp.local", Prefix: "files_"},
+}
+
+// newAdminTestBroker returns a broker with the weather and files servers
+// registered, logging to the returned buffer
+func newAdminTestBroker(t *testing.T) (*mcpBrokerImpl, *session.Cache, *bytes.Buffer) {
+	t.Helper()
+	cache, err := session.NewCache()
+	require.NoError(t, err)
+	b, logs := newAdminTestBrokerWithCache(t, cache)
+	return b, cache, logs
+}
+
+// newAdminTestBrokerWithCache builds an admin test broker on a given session
+// cache, standing in for one of several replicas sharing a store
+func newAdminTestBrokerWithCache(t *testing.T, cache *session.Cache) (*mcpBrokerImpl, *bytes.Buffer) {
+	t.Helper()
+	var logs bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&logs, nil))
+	b := NewBroker(logger,
+		WithAdminToken(testAdminToken),
+		WithSessionCache(cache),
+		WithDiscoveryToolsEnabled(false),
+	).(*mcpBrokerImpl)
+	t.Cleanup(func() { _ = b.Shutdown(context.Background()) })
+
+	b.mcpLock.Lock()
+	for _, cfg := range adminTestServers {
+		b.mcpServers[cfg.ID()] = &adminMockServer{
+			mockActiveMCPServer: newMockActiveMCPServer(cfg),
+			tools:               []mcp.Tool{{Name: "read"}},
+		}
+	}
+	b.refreshRoutingTable()
+	b.mcpLock.Unlock()
+	return b, &logs
+}
+
+// testSessionJWT builds an unsigned token shaped like a gateway session ID
+func testSessionJWT(jti string, exp time.Time) string {
+	payload, _ := json.Marshal(map[string]any{"jti": jti, "exp": exp.Unix()})
+	return "eyJhbGciOiJIUzI1NiJ9." + base64.RawURLEncoding.EncodeToString(payload) + ".sig"
+}
+
+func adminRequest(t *testing.T, h http.Handler, method, path string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(method, path, nil)
+	req.Header.Set("Authorization", "Bearer "+testAdminToken)
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestAdminHandler_Authentication(t *testing.T) {
+	b, _, logs := newAdminTestBroker(t)
+	h := b.AdminHandler()
+
+	for name, header := range map[string]string{
+		"missing token": "",
+		"wrong token":   "Bearer nope",
+		"not bearer":    "Basic " + testAdminToken,
+	} {
+		t.Run(name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/admin/routing-table", nil)
+			if header != "" {
+				req.Header.Set("Authorization", header)
+			}
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+			assert.Equal(t, http.StatusUnauthorized, rec.Code)
+			assert.NotEmpty(t, rec.Header().Get("WWW-Authenticate"))
+		})
+	}
+
+	// rejected requests are audited too
+	assert.Contains(t, logs.String(), `"operation":"dump-routing-table"`)
+	assert.Contains(t, logs.String(), `"status":401`)
+
+	// no token configured disables the API entirely
+	b.adminToken = ""
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/admin/routing-table", nil)
+	req.Header.Set("Authorization", "Bearer ")
+	h.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+}
+
+func TestAdminHandler_Sessions(t *testing.T) {
+	b, cache, logs := newAdminTestBroker(t)
+	h := b.AdminHandler()
+	ctx := context.Background()
+
+	exp := time.Now().Add(time.Hour).Truncate(time.Second).UTC()
+	first := testSessionJWT("first", exp)
+	second := testSessionJWT("second", exp)
+	_, err := cache.AddSession(ctx, first, "team-a/weather", "backend-1", 0)
+	require.NoError(t, err)
+	require.NoError(t, cache.SetUserToken(ctx, first, "team-a/weather", "user-token", 0))
+	_, err = cache.AddSession(ctx, second, "team-a/files", "backend-2", 0)
+	require.NoError(t, err)
+
+	rec := adminRequest(t, h, http.MethodGet, "/admin/sessions")
+	require.Equal(t, http.StatusOK, rec.Code)
+	var listed struct {
+		Sessions []AdminSession `json:"sessions"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &listed))
+	require.Equal(t, []AdminSession{
+		{ID: "jti:first", ExpiresAt: &exp, Backends: map[string]string{"team-a/weather": internaljwt.LogSafeSessionID("backend-1")}},
+		{ID: "jti:second", ExpiresAt: &exp, Backends: map[string]string{"team-a/files": internaljwt.LogSafeSessionID("backend-2")}},
+	}, listed.Sessions)
+	assert.NotContains(t, rec.Body.String(), first, "raw session IDs are bearer tokens")
+	assert.NotContains(t, rec.Body.String(), "backend-1", "raw backend session IDs are credentials")
+	assert.NotContains(t, rec.Body.String(), "user-token")
+
+	rec = adminRequest(t, h, http.MethodDelete, "/admin/sessions/jti:first")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	remaining, err := cache.ListSessions(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]map[string]string{second: {"team-a/files": "backend-2"}}, remaining)
+	assert.Contains(t, logs.String(), `"operation":"terminate-session","target":"jti:first"`)
+
+	rec = adminRequest(t, h, http.MethodDelete, "/admin/sessions/jti:first")
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+func TestAdminHandler_Refresh(t *testing.T) {
+	b, _, _ := newAdminTestBroker(t)
+	h := b.AdminHandler()
+
+	rec := adminRequest(t, h, http.MethodPost, "/admin/refresh/team-a/weather")
+	require.Equal(t, http.StatusAccepted, rec.Code)
+	srv := b.findServerByName("team-a/weather").(*adminMockServer)
+	assert.Equal(t, int32(1), srv.refreshes.Load())
+
+	rec = adminRequest(t, h, http.MethodPost, "/admin/refresh/team-a/unknown")
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+func TestAdminHandler_Drain(t *testing.T) {
+	b, _, logs := newAdminTestBroker(t)
+	h := b.AdminHandler()
+	weatherID := string(adminTestServers[0].ID())
+	filesID := string(adminTestServers[1].ID())
+	listTools := func() []string {
+		res := &mcp.ListToolsResult{Tools: []*mcp.Tool{
+			{Name: "weather_read", Meta: mcp.Meta{"kuadrant/id": weatherID}},
+			{Name: "files_read", Meta: mcp.Meta{"kuadrant/id": filesID}},
+		}}
+		b.FilterTools(context.Background(), http.Header{}, "", res)
+		var names []string
+		for _, tool := range res.Tools {
+			names = append(names, tool.Name)
+		}
+		return names
+	}
+	require.Equal(t, []string{"weather_read", "files_read"}, listTools())
+
+	rec := adminRequest(t, h, http.MethodPut, "/admin/drains/team-a/weather")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.JSONEq(t, `{"server":"team-a/weather"}`, rec.Body.String())
+	assert.Equal(t, []string{"files_read"}, listTools())
+	_, ok := b.RoutingTable().LookupTool("weather_read")
+	assert.False(t, ok, "drained server must not be routable")
+	_, ok = b.RoutingTable().LookupTool("files_read")
+	assert.True(t, ok)
+
+	rec = adminRequest(t, h, http.MethodGet, "/admin/drains")
+	assert.JSONEq(t, `{"drains":[{"server":"team-a/weather"}]}`, rec.Body.String())
+
+	rec = adminRequest(t, h, http.MethodDelete, "/admin/drains/team-a/weather")
+	require.Equal(t, http.StatusNoContent, rec.Code)
+	assert.Equal(t, []string{"weather_read", "files_read"}, listTools())
+	_, ok = b.RoutingTable().LookupTool("weather_read")
+	assert.True(t, ok)
+
+	rec = adminRequest(t, h, http.MethodDelete, "/admin/drains/team-a/weather")
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	rec = adminRequest(t, h, http.MethodPut, "/admin/drains/team-a/unknown")
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	rec = adminRequest(t, h, http.MethodPut, "/admin/drains/team-a/weather?duration=soon")
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	assert.Contains(t, logs.String(), `"operation":"drain-server","target":"team-a/weather"`)
+	assert.Contains(t, logs.String(), `"operation":"undrain-server","target":"team-a/weather"`)
+}
+
+func TestAdminHandler_DrainExpires(t *testing.T) {
+	b, _, _ := newAdminTestBroker(t)
+	h := b.AdminHandler()
+
+	rec := adminRequest(t, h, http.MethodPut, "/admin/drains/team-a/weather?duration=50ms")
+	require.Equal(t, http.StatusOK, rec.Code)
+	var drain DrainedServer
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &drain))
+	require.NotNil(t, drain.Until)
+
+	_, ok := b.RoutingTable().LookupTool("weather_read")
+	require.False(t, ok)
+	require.Eventually(t, func() bool {
+		_, ok := b.RoutingTable().LookupTool("weather_read")
+		return ok
+	}, time.Second, 10*time.Millisecond, "routes should return once the drain expires")
+	assert.Empty(t, b.drainedServers())
+}
+
+func TestAdminHandler_DrainSharedBetweenReplicas(t *testing.T) {
+	a, cache, _ := newAdminTestBroker(t)
+	b, _ := newAdminTestBrokerWithCache(t, cache)
+	routable := func(broker *mcpBrokerImpl) bool {
+		_, ok := broker.RoutingTable().LookupTool("weather_read")
+		return ok
+	}
+
+	rec := adminRequest(t, a.AdminHandler(), http.MethodPut, "/admin/drains/team-a/weather")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	require.False(t, routable(a))
+	require.True(t, routable(b), "the other replica applies the drain on its next sync")
+
+	b.syncDrains(context.Background())
+	assert.False(t, routable(b))
+	assert.Equal(t, []DrainedServer{{Server: "team-a/weather"}}, b.drainedServers())
+
+	// lifting through the other replica lifts it everywhere
+	rec = adminRequest(t, b.AdminHandler(), http.MethodDelete, "/admin/drains/team-a/weather")
+	require.Equal(t, http.StatusNoContent, rec.Code)
+	assert.True(t, routable(b))
+	a.syncDrains(context.Background())
+	assert.True(t, routable(a))
+	assert.Empty(t, a.drainedServers())
+}
+
+func TestAdminHandler_RoutingTable(t *testing.T) {
+	b, _, _ := newAdminTestBroker(t)
+	h := b.AdminHandler()
+
+	rec := adminRequest(t, h, http.MethodGet, "/admin/routing-table")
+	require.Equal(t, http.StatusOK, rec.Code)
+	var snap routing.TableSnapshot
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &snap))
+	require.Contains(t, snap.Tools, "weather_read")
+	assert.Equal(t, "weather.mcp.local", snap.Tools["weather_read"].Host)
+	assert.Contains(t, snap.Tools, "files_read")
+
+	rec = adminRequest(t, h, http.MethodGet, "/admin/nothing")
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	assert.Contains(t, rec.Body.String(), "unknown admin operation")
+}
+
+func TestAdminHandler_RevokeSubjectSessions(t *testing.T) {
+	b, cache, logs := newAdminTestBroker(t)
+	h := b.AdminHandler()
+	ctx := context.Background()
+
+	rec := adminRequest(t, h, http.MethodDelete, "/admin/subjects/alice/sessions")
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code, "revocation needs a revoker")
+
+	jwtMgr, err := session.NewJWTManager("admin-test-signing-key-at-least-32-bytes", 0, slog.Default(), cache, session.WithRevocationStore(cache))
+	require.NoError(t, err)
+	b.sessionRevoker = jwtMgr
+	alice, bob := jwtMgr.Generate(), jwtMgr.Generate()
+	for token, sub := range map[string]string{alice: "alice", bob: "bob"} {
+		require.NoError(t, jwtMgr.RecordSubjectSession(ctx, sub, token))
+		_, err := cache.AddSession(ctx, token, "team-a/weather", "backend-"+sub, 0)
+		require.NoError(t, err)
+	}
+
+	rec = adminRequest(t, h, http.MethodDelete, "/admin/subjects/alice/sessions")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	var resp struct {
+		Subject  string   `json:"subject"`
+		Sessions []string `json:"sessions"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	assert.Equal(t, "alice", resp.Subject)
+	assert.Equal(t, []string{internaljwt.LogSafeSessionID(alice)}, resp.Sessions)
+	assert.NotContains(t, rec.Body.String(), alice, "raw session IDs are bearer tokens")
+	assert.Contains(t, logs.String(), `"operation":"revoke-subject-sessions","target":"alice"`)
+
+	isInvalid, err := jwtMgr.Validate(alice)
+	require.NoError(t, err)
+	assert.True(t, isInvalid, "revoked session must not validate")
+	isInvalid, _ = jwtMgr.Validate(bob)
+	assert.False(t, isInvalid)
+	remaining, err := cache.ListSessions(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]map[string]string{bob: {"team-a/weather": "backend-bob"}}, remaining)
+
+	// terminating a session revokes it too
+	rec = adminRequest(t, h, http.MethodDelete, "/admin/sessions/"+internaljwt.LogSafeSessionID(bob))
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.Contains(t, rec.Body.String(), `"revoked":true`)
+	isInvalid, _ = jwtMgr.Validate(bob)
+	assert.True(t, isInvalid)
+}
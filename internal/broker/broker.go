@@ -13,9 +13,11 @@ import (
 	"sync/atomic"
 	"time"
 
+	"github.com/Kuadrant/mcp-gateway/internal/broker/credentials"
 	"github.com/Kuadrant/mcp-gateway/internal/broker/upstream"
 	"github.com/Kuadrant/mcp-gateway/internal/config"
 	internaljwt "github.com/Kuadrant/mcp-gateway/internal/jwt"
+	mcpotel "github.com/Kuadrant/mcp-gateway/internal/otel"
 	"github.com/Kuadrant/mcp-gateway/internal/routing"
 	"github.com/Kuadrant/mcp-gateway/internal/session"
 	"github.com/modelcontextprotocol/go-sdk/mcp"
@@ -75,6 +77,9 @@ type MCPBroker interface {
 	// HandleStatusRequest handles HTTP status endpoint requests
 	HandleStatusRequest(w http.ResponseWriter, r *http.Request)
 
+	// AdminHandler returns the operational admin API handler, served under /admin/
+	AdminHandler() http.Handler
+
 	// MCPHandler returns the composed /mcp HTTP handler
 	MCPHandler() http.Handler
 
@@ -84,6 +89,15 @@ type MCPBroker interface {
 	// RoutingTable returns a consistent snapshot of tool/prompt → server routing data
 	RoutingTable() routing.RoutingTable
 
+	// StartCatalogSync starts leader-elected discovery through the shared
+	// catalog when one is configured; it is a no-op otherwise
+	StartCatalogSync(ctx context.Context)
+
+	// StartDrainSync applies the server drains recorded in the session store
+	// and keeps following them, so a drain placed through any replica
+	// applies to all of them
+	StartDrainSync(ctx context.Context)
+
 	// Shutdown closes any resources associated with this Broker
 	Shutdown(ctx context.Context) error
 
@@ -163,6 +177,18 @@ type mcpBrokerImpl struct {
 	// sessionTerminator cleans up backend session cache on session end
 	sessionTerminator func(sessionID string) (bool, error)
 
+	// sessionIDForSubject issues session IDs bound to the verified subject
+	// of the initialize request; nil leaves the SDK to use sessionIDGenerator
+	sessionIDForSubject func(subject string) string
+
+	// sessionSubjectCheck rejects a request whose verified subject may not
+	// use the session; returns session.ErrSubjectMismatch
+	sessionSubjectCheck func(sessionID, subject string) error
+
+	// sessionRevoker revokes sessions a client or operator ends, so they
+	// cannot be resurrected; nil when revocation is not configured
+	sessionRevoker SessionRevoker
+
 	// serverVersions maps upstream server ID to supported protocol versions
 	serverVersions sync.Map // map[config.UpstreamMCPID][]string
 
@@ -171,6 +197,11 @@ type mcpBrokerImpl struct {
 	statefulTools  atomic.Pointer[protocolCacheEntry[*mcp.Tool]]
 	statelessTools atomic.Pointer[protocolCacheEntry[*mcp.Tool]]
 
+	// toolIndex ranks tools for discover_tools queries; rebuilt with the protocol caches
+	toolIndex atomic.Pointer[toolSearchIndex]
+	// toolSizes holds the serialized byte size of each tool by name; rebuilt with the protocol caches
+	toolSizes atomic.Pointer[map[string]int64]
+
 	// statefulPrompts and statelessPrompts cache pre-filtered prompt sets and their
 	// contributing server IDs for each protocol version
 	statefulPrompts  atomic.Pointer[protocolCacheEntry[*mcp.Prompt]]
@@ -180,6 +211,28 @@ type mcpBrokerImpl struct {
 	// keyed by gatewayCACert+serverCACert. avoids rebuilding cert pools per request.
 	statelessTransports sync.Map // map[string]http.RoundTripper
 
+	// catalog shares discovery between replicas; nil unless WithSharedCatalog
+	catalog *catalogSync
+
+	// credentialProvider resolves upstream credential references; nil unless
+	// WithCredentialProvider
+	credentialProvider credentials.CredentialProvider
+
+	// logging tracks client log levels for the upstreams' broker sessions
+	logging upstreamLogging
+
+	// adminToken authenticates the admin API; empty disables it
+	adminToken string
+
+	// drains holds the servers hidden from clients on this replica, keyed by
+	// server name. lock order is drainSyncMu, then mcpLock, then drainMu.
+	drainMu sync.Mutex
+	drains  map[string]*serverDrain
+	// drainSyncMu orders writes of the shared drains against their reload
+	drainSyncMu     sync.Mutex
+	drainSyncCancel context.CancelFunc
+	drainSyncDone   chan struct{}
+
 	// protocol handlers encapsulate version-specific broker behavior
 	handler2025 ProtocolHandler
 	handler2026 ProtocolHandler
@@ -240,6 +293,15 @@ func WithDiscoveryToolThreshold(threshold int) Option {
 	}
 }
 
+// WithDiscoveryContextBudget sets the serialized size, in bytes or estimated
+// tokens, above which only meta-tools are shown and selections are rejected.
+// A limit of 0 disables the budget.
+func WithDiscoveryContextBudget(limit int64, unit BudgetUnit) Option {
+	return func(mb *mcpBrokerImpl) {
+		mb.discovery.budget = contextBudget{limit: limit, unit: unit}
+	}
+}
+
 // WithSessionCache sets the session cache used for user-specific tool fetches
 func WithSessionCache(cache *session.Cache) Option {
 	return func(mb *mcpBrokerImpl) {
@@ -268,6 +330,37 @@ func WithSessionTerminator(t func(string) (bool, error)) Option {
 	}
 }
 
+// WithSessionIDForSubject sets the function that issues a session ID bound
+// to the verified subject of an initialize request
+func WithSessionIDForSubject(gen func(subject string) string) Option {
+	return func(mb *mcpBrokerImpl) {
+		mb.sessionIDForSubject = gen
+	}
+}
+
+// WithSessionSubjectCheck sets the function that checks a request's verified
+// subject may use its session
+func WithSessionSubjectCheck(check func(sessionID, subject string) error) Option {
+	return func(mb *mcpBrokerImpl) {
+		mb.sessionSubjectCheck = check
+	}
+}
+
+// SessionRevoker revokes gateway sessions on every replica.
+// session.JWTManager implements it.
+type SessionRevoker interface {
+	Revoke(ctx context.Context, sessionID string) error
+	RevokeSubject(ctx context.Context, subject string) ([]string, error)
+}
+
+// WithSessionRevoker sets the revoker used when a client deletes its session
+// and by the admin API
+func WithSessionRevoker(r SessionRevoker) Option {
+	return func(mb *mcpBrokerImpl) {
+		mb.sessionRevoker = r
+	}
+}
+
 // WithUserSpecificFetchTimeout sets the per-server timeout for user-specific tool fetches
 func WithUserSpecificFetchTimeout(timeout time.Duration) Option {
 	return func(mb *mcpBrokerImpl) {
@@ -275,6 +368,22 @@ func WithUserSpecificFetchTimeout(timeout time.Duration) Option {
 	}
 }
 
+// WithCredentialProvider sets the provider upstream clients use to resolve
+// credential references at request time
+func WithCredentialProvider(provider credentials.CredentialProvider) Option {
+	return func(mb *mcpBrokerImpl) {
+		mb.credentialProvider = provider
+	}
+}
+
+// WithAdminToken sets the bearer token the admin API requires. The admin API
+// rejects every request while no token is set.
+func WithAdminToken(token string) Option {
+	return func(mb *mcpBrokerImpl) {
+		mb.adminToken = token
+	}
+}
+
 // NewBroker creates a new MCPBroker accepts optional config functions such as WithEnforceCapabilityFilter
 func NewBroker(logger *slog.Logger, opts ...Option) MCPBroker {
 	mcpBkr := &mcpBrokerImpl{
@@ -339,6 +448,9 @@ func NewBroker(logger *slog.Logger, opts ...Option) MCPBroker {
 		defer mcpBkr.mcpLock.RUnlock()
 		mcpBkr.refreshRoutingTable()
 		mcpBkr.rebuildProtocolCaches()
+		if mcpBkr.catalog != nil {
+			mcpBkr.catalog.requestPublish()
+		}
 	}
 	srv.AddSendingMiddleware(mcpBkr.gatewayServer.notifyTargetMiddleware())
 
@@ -358,6 +470,9 @@ func (m *mcpBrokerImpl) onGatewaySessionEnd(sessionID string) {
 		m.scopeStore.deleteScope(sessionID)
 	}
 	m.evictUserSessions(sessionID)
+	if level, changed := m.logging.forget(sessionID); changed {
+		m.applyUpstreamLogLevel(context.Background(), level)
+	}
 	if m.sessionTerminator != nil {
 		// the wired terminator (JWTManager.Terminate) bounds its own cache
 		// deletion, so no watchdog is needed here
@@ -372,10 +487,20 @@ func (m *mcpBrokerImpl) onGatewaySessionEnd(sessionID string) {
 func (m *mcpBrokerImpl) tracingMiddleware() mcp.Middleware {
 	return func(next mcp.MethodHandler) mcp.MethodHandler {
 		return func(ctx context.Context, method string, req mcp.Request) (mcp.Result, error) {
-			ctx, span := brokerTracer().Start(ctx, "mcp-broker.handle-request", trace.WithAttributes(
-				brokerComponentAttr,
-				attribute.String("mcp.method", method),
-			))
+			params := requestParams(req)
+			// headers take precedence; _meta covers clients behind
+			// intermediaries that strip them
+			if params != nil {
+				ctx = mcpotel.ExtractMeta(ctx, params.GetMeta())
+			}
+			ctx, span := brokerTracer().Start(ctx, "mcp-broker.handle-request",
+				trace.WithSpanKind(trace.SpanKindServer),
+				trace.WithAttributes(
+					brokerComponentAttr,
+					attribute.String("mcp.method", method),
+				),
+				trace.WithAttributes(mcpotel.MCPMethodAttributes(method, requestTarget(params))...),
+			)
 			defer span.End()
 			// LogSafeSessionID hashes/decodes per call; only pay for it when
 			// the span is sampled
@@ -412,6 +537,19 @@ func (m *mcpBrokerImpl) filteringMiddleware() mcp.Middleware {
 			}
 
 			switch method {
+			case "initialize":
+				initResult, ok := result.(*mcp.InitializeResult)
+				if ok && initResult != nil && initResult.Capabilities != nil && m.supportsLogging() {
+					initResult.Capabilities.Logging = &mcp.LoggingCapabilities{}
+				}
+
+			case "logging/setLevel":
+				if params, ok := req.GetParams().(*mcp.SetLoggingLevelParams); ok && params != nil {
+					if s := req.GetSession(); s != nil {
+						m.onClientLogLevel(ctx, s.ID(), params.Level)
+					}
+				}
+
 			case "server/discover":
 				discoverResult, ok := result.(*mcp.DiscoverResult)
 				if ok && discoverResult != nil {
@@ -549,6 +687,7 @@ func (m *mcpBrokerImpl) deregisterStaleManagers(ctx context.Context, servers []*
 			toStop = append(toStop, man)
 			delete(m.mcpServers, serverID)
 			m.serverVersions.Delete(serverID)
+			m.forgetUpstream(serverID)
 		}
 	}
 
@@ -563,6 +702,7 @@ func (m *mcpBrokerImpl) deregisterStaleManagers(ctx context.Context, servers []*
 			toStop = append(toStop, man)
 			delete(m.mcpServers, mcpServer.ID())
 			m.serverVersions.Delete(mcpServer.ID())
+			m.forgetUpstream(mcpServer.ID())
 		}
 	}
 	return toStop
@@ -578,11 +718,18 @@ func (m *mcpBrokerImpl) startManagers(ctx context.Context, servers []*config.MCP
 		if _, ok := m.mcpServers[mcpServer.ID()]; ok {
 			continue
 		}
-		manager, err := upstream.NewUpstreamMCPManager(upstream.NewUpstreamMCP(mcpServer, m.gatewayCACertPEM, m.logger.With("sub-component", "mcp-upstream")), m.gatewayServer, m.gatewayServer, m.logger.With("sub-component", "mcp-manager"), m.managerTickerInterval, m.invalidToolPolicy)
+		up := m.wrapUpstream(upstream.NewUpstreamMCP(mcpServer, m.gatewayCACertPEM, m.logger.With("sub-component", "mcp-upstream"),
+			upstream.WithCredentialProvider(m.credentialProvider)))
+		m.wireUpstreamLogging(ctx, up)
+		manager, err := upstream.NewUpstreamMCPManager(up, m.gatewayServer, m.gatewayServer, m.logger.With("sub-component", "mcp-manager"), m.managerTickerInterval, m.invalidToolPolicy)
 		if err != nil {
 			m.logger.ErrorContext(ctx, "failed to create manager", "server id", mcpServer.ID(), "error", err)
 			continue
 		}
+		if canary := mcpServer.CanaryServer(); canary != nil {
+			manager.SetCanary(upstream.NewUpstreamMCP(canary, m.gatewayCACertPEM, m.logger.With("sub-component", "mcp-upstream"),
+				upstream.WithCredentialProvider(m.credentialProvider)))
+		}
 		m.logger.InfoContext(ctx, "Starting manager for", "mcpID", mcpServer.ID())
 		m.mcpServers[mcpServer.ID()] = manager.Start(ctx)
 	}
@@ -615,6 +762,10 @@ func (m *mcpBrokerImpl) startManagers(ctx context.Context, servers []*config.MCP
 	m.vsLock.Unlock()
 
 	m.refreshRoutingTable()
+	if m.catalog != nil {
+		m.catalog.configured.Store(true)
+		m.catalog.requestPublish()
+	}
 }
 
 func (m *mcpBrokerImpl) RegisteredMCPServers() map[config.UpstreamMCPID]upstream.ActiveMCPServer {
@@ -774,6 +925,10 @@ func (m *mcpBrokerImpl) IsBrokerToolName(name string) bool {
 }
 
 func (m *mcpBrokerImpl) Shutdown(_ context.Context) error {
+	// stop publishing first: stopping managers below empties the catalog
+	m.stopCatalogSync()
+	m.stopDrainSync()
+
 	// Avoid race with OnConfigChange()
 	m.mcpLock.RLock()
 	defer m.mcpLock.RUnlock()
@@ -787,6 +942,7 @@ func (m *mcpBrokerImpl) Shutdown(_ context.Context) error {
 		m.scopeStore.stop()
 	}
 	m.drainUserSessionPool()
+	m.stopDrainTimers()
 	return nil
 }
 
@@ -822,6 +978,9 @@ func (m *mcpBrokerImpl) ValidateAllServers() StatusResponse {
 		ScopedSessions:   scopedSessions,
 		Timestamp:        time.Now(),
 	}
+	if m.catalog != nil {
+		response.CatalogRole = m.catalog.role()
+	}
 
 	m.logger.Debug("ValidateAllServers: checking servers", "# servers", len(m.mcpServers))
 
@@ -831,6 +990,10 @@ func (m *mcpBrokerImpl) ValidateAllServers() StatusResponse {
 	// concurrent OnConfigChange() Lock() causes both goroutines to deadlock.
 	for _, upstream := range m.mcpServers {
 		status := upstream.GetStatus()
+		status.Maintenance = maintenanceStatus(upstream.Config(), response.Timestamp)
+		if status.Maintenance != nil && status.Maintenance.Active {
+			response.MaintenanceServers++
+		}
 		response.Servers = append(response.Servers, status)
 
 		if !status.Ready {
@@ -845,6 +1008,7 @@ func (m *mcpBrokerImpl) ValidateAllServers() StatusResponse {
 		"totalServers", response.TotalServers,
 		"healthyServers", response.HealthyServers,
 		"unhealthyServers", response.UnHealthyServers,
+		"maintenanceServers", response.MaintenanceServers,
 		"overallValid", response.OverallValid)
 
 	return response
@@ -881,7 +1045,7 @@ func (m *mcpBrokerImpl) FetchResources(ctx context.Context, result *mcp.ListReso
 	for _, srv := range servers {
 		cfg := srv.Config()
 
-		if !srv.SupportsResources() {
+		if !srv.SupportsResources() || m.isDrained(cfg.Name) {
 			continue
 		}
 		if cfg.Prefix == "" {
@@ -0,0 +1,85 @@
+// Package catalog shares upstream discovery results between broker replicas.
+// One replica, elected via a lease, runs discovery against every upstream and
+// publishes a per-server snapshot to the shared store; the others serve their
+// tool and prompt catalog from that snapshot instead of connecting upstream.
+package catalog
+
+import (
+	"context"
+	"time"
+
+	"github.com/Kuadrant/mcp-gateway/internal/broker/upstream"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	redis "github.com/redis/go-redis/v9"
+)
+
+// ServerSnapshot is the published discovery state of a single upstream
+type ServerSnapshot struct {
+	ID     string `json:"id"`
+	Name   string `json:"name"`
+	Prefix string `json:"prefix"`
+	// Tools and Prompts are stored as the gateway serves them: prefixed
+	// names stamped with the owning server ID.
+	Tools   []mcp.Tool   `json:"tools,omitempty"`
+	Prompts []mcp.Prompt `json:"prompts,omitempty"`
+	// Hints are the raw annotation hints keyed by served tool name
+	Hints             map[string]upstream.ToolHints   `json:"hints,omitempty"`
+	Status            upstream.ServerValidationStatus `json:"status"`
+	Init              *mcp.InitializeResult           `json:"init,omitempty"`
+	SupportedVersions []string                        `json:"supportedVersions,omitempty"`
+	ToolsCache        upstream.CacheMetadata          `json:"toolsCache"`
+	PromptsCache      upstream.CacheMetadata          `json:"promptsCache"`
+}
+
+// Snapshot is the full published catalog at a given revision
+type Snapshot struct {
+	Revision int64
+	Servers  map[string]*ServerSnapshot
+}
+
+// Store persists the shared catalog
+type Store interface {
+	// Publish replaces the catalog with servers and returns the new revision
+	Publish(ctx context.Context, servers []ServerSnapshot) (int64, error)
+	// Load returns the current catalog. An empty store returns revision 0.
+	Load(ctx context.Context) (*Snapshot, error)
+	// Revision returns the current revision without loading the catalog, so
+	// followers can poll cheaply
+	Revision(ctx context.Context) (int64, error)
+}
+
+// Elector campaigns for the discovery lease
+type Elector interface {
+	// Run campaigns for leadership until ctx is cancelled. onChange is called
+	// with true when this replica acquires the lease and false when it loses
+	// it. A held lease is released before Run returns.
+	Run(ctx context.Context, onChange func(leader bool))
+}
+
+// DefaultLeaseDuration is how long a lease is held without renewal
+const DefaultLeaseDuration = 15 * time.Second
+
+type storeConfig struct {
+	redisClient redis.UniversalClient
+}
+
+// New returns an initialized Store. Pass WithRedisClient to use a Redis-backed
+// store; otherwise an in-memory store is returned, which is only useful for a
+// single replica and tests.
+func New(opts ...func(*storeConfig)) Store {
+	cfg := &storeConfig{}
+	for _, o := range opts {
+		o(cfg)
+	}
+	if cfg.redisClient != nil {
+		return newRedisStore(cfg.redisClient)
+	}
+	return newInMemoryStore()
+}
+
+// WithRedisClient configures the Store to use an existing Redis client.
+func WithRedisClient(client redis.UniversalClient) func(*storeConfig) {
+	return func(c *storeConfig) {
+		c.redisClient = client
+	}
+}
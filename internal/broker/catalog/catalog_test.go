@@ -0,0 +1,120 @@
+package catalog
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/Kuadrant/mcp-gateway/internal/broker/upstream"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	redis "github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/require"
+)
+
+func newRedisClient(t *testing.T) *redis.Client {
+	t.Helper()
+	srv := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: srv.Addr()})
+	t.Cleanup(func() { _ = client.Close() })
+	return client
+}
+
+func testSnapshot(id, prefix string, tools ...string) ServerSnapshot {
+	yes := true
+	snap := ServerSnapshot{
+		ID:     id,
+		Name:   id,
+		Prefix: prefix,
+		Status: upstream.ServerValidationStatus{ID: id, Ready: true, TotalTools: len(tools)},
+		Init: &mcp.InitializeResult{
+			ProtocolVersion: "2025-11-25",
+			Capabilities:    &mcp.ServerCapabilities{Tools: &mcp.ToolCapabilities{ListChanged: true}},
+		},
+		SupportedVersions: []string{"2025-11-25"},
+	}
+	for _, name := range tools {
+		snap.Tools = append(snap.Tools, mcp.Tool{
+			Name:        prefix + name,
+			InputSchema: map[string]any{"type": "object"},
+			Meta:        mcp.Meta{upstream.GatewayServerID: id},
+		})
+	}
+	if len(tools) > 0 {
+		snap.Hints = map[string]upstream.ToolHints{
+			prefix + tools[0]: {ReadOnlyHint: &yes, Raw: json.RawMessage(`{"readOnlyHint":true}`)},
+		}
+	}
+	return snap
+}
+
+func TestStore(t *testing.T) {
+	stores := map[string]func(t *testing.T) Store{
+		"in-memory": func(_ *testing.T) Store { return New() },
+		"redis":     func(t *testing.T) Store { return New(WithRedisClient(newRedisClient(t))) },
+	}
+	for name, newStore := range stores {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			store := newStore(t)
+
+			rev, err := store.Revision(ctx)
+			require.NoError(t, err)
+			require.Zero(t, rev)
+			empty, err := store.Load(ctx)
+			require.NoError(t, err)
+			require.Zero(t, empty.Revision)
+			require.Empty(t, empty.Servers)
+
+			rev, err = store.Publish(ctx, []ServerSnapshot{
+				testSnapshot("a:a_:", "a_", "one", "two"),
+				testSnapshot("b:b_:", "b_"),
+			})
+			require.NoError(t, err)
+			require.Equal(t, int64(1), rev)
+
+			snap, err := store.Load(ctx)
+			require.NoError(t, err)
+			require.Equal(t, int64(1), snap.Revision)
+			require.Len(t, snap.Servers, 2)
+			a := snap.Servers["a:a_:"]
+			require.Len(t, a.Tools, 2)
+			require.Equal(t, "a_one", a.Tools[0].Name)
+			require.True(t, a.Status.Ready)
+			require.Equal(t, "2025-11-25", a.Init.ProtocolVersion)
+			require.True(t, *a.Hints["a_one"].ReadOnlyHint)
+			require.JSONEq(t, `{"readOnlyHint":true}`, string(a.Hints["a_one"].Raw))
+
+			// publishing replaces the catalog, dropping removed servers
+			rev, err = store.Publish(ctx, []ServerSnapshot{testSnapshot("b:b_:", "b_", "three")})
+			require.NoError(t, err)
+			require.Equal(t, int64(2), rev)
+			snap, err = store.Load(ctx)
+			require.NoError(t, err)
+			require.Len(t, snap.Servers, 1)
+			require.Equal(t, "b_three", snap.Servers["b:b_:"].Tools[0].Name)
+
+			rev, err = store.Publish(ctx, nil)
+			require.NoError(t, err)
+			require.Equal(t, int64(3), rev)
+			snap, err = store.Load(ctx)
+			require.NoError(t, err)
+			require.Empty(t, snap.Servers)
+		})
+	}
+}
+
+func TestStoreHintsWithoutRawStayNil(t *testing.T) {
+	ctx := context.Background()
+	store := New()
+	no := false
+	snap := testSnapshot("a:a_:", "a_", "one")
+	snap.Hints["a_one"] = upstream.ToolHints{DestructiveHint: &no}
+	_, err := store.Publish(ctx, []ServerSnapshot{snap})
+	require.NoError(t, err)
+	loaded, err := store.Load(ctx)
+	require.NoError(t, err)
+	h := loaded.Servers["a:a_:"].Hints["a_one"]
+	require.Nil(t, h.Raw, "absent annotations must not round-trip as a JSON null")
+	require.False(t, *h.DestructiveHint)
+}
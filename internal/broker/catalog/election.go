@@ -0,0 +1,111 @@
+package catalog
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	redis "github.com/redis/go-redis/v9"
+)
+
+// DefaultLeaseKey is the Redis key holding the discovery lease
+const DefaultLeaseKey = "catalog:leader"
+
+// renewScript extends the lease only while this replica still holds it
+var renewScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+return 0
+`)
+
+// releaseScript deletes the lease only while this replica still holds it
+var releaseScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0
+`)
+
+// RedisLease is an Elector backed by a single Redis key set with NX and a
+// PX expiry. the holder renews at a third of the lease duration; a replica
+// that cannot renew steps down once its lease would have expired, so two
+// replicas never both believe they lead for longer than one renew period.
+type RedisLease struct {
+	Client        redis.UniversalClient
+	Key           string
+	Identity      string
+	LeaseDuration time.Duration
+	Logger        *slog.Logger
+}
+
+var _ Elector = &RedisLease{}
+
+// Run implements Elector
+func (l *RedisLease) Run(ctx context.Context, onChange func(leader bool)) {
+	key := l.Key
+	if key == "" {
+		key = DefaultLeaseKey
+	}
+	lease := l.LeaseDuration
+	if lease <= 0 {
+		lease = DefaultLeaseDuration
+	}
+	logger := l.Logger
+	if logger == nil {
+		logger = slog.New(slog.DiscardHandler)
+	}
+
+	ticker := time.NewTicker(lease / 3)
+	defer ticker.Stop()
+
+	leader := false
+	var renewedAt time.Time
+	for {
+		now := time.Now()
+		if leader {
+			held, err := renewScript.Run(ctx, l.Client, []string{key}, l.Identity, lease.Milliseconds()).Int()
+			switch {
+			case err != nil && ctx.Err() == nil:
+				logger.Error("failed to renew discovery lease", "key", key, "error", err)
+				if now.Sub(renewedAt) >= lease {
+					leader = false
+				}
+			case err == nil && held == 0:
+				logger.Info("discovery lease lost", "key", key, "identity", l.Identity)
+				leader = false
+			case err == nil:
+				renewedAt = now
+			}
+			if !leader {
+				onChange(false)
+			}
+		} else {
+			acquired, err := l.Client.SetNX(ctx, key, l.Identity, lease).Result()
+			if err != nil && ctx.Err() == nil {
+				logger.Error("failed to acquire discovery lease", "key", key, "error", err)
+			}
+			if acquired {
+				logger.Info("discovery lease acquired", "key", key, "identity", l.Identity)
+				leader = true
+				renewedAt = now
+				onChange(true)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			if leader {
+				// the parent context is gone; release on a short one of our own
+				// so a successor does not wait out the full lease
+				releaseCtx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+				if err := releaseScript.Run(releaseCtx, l.Client, []string{key}, l.Identity).Err(); err != nil {
+					logger.Error("failed to release discovery lease", "key", key, "error", err)
+				}
+				cancel()
+			}
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package catalog
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRedisLease_SingleLeaderAndHandover(t *testing.T) {
+	client := newRedisClient(t)
+	lease := 150 * time.Millisecond
+
+	var aLeads, bLeads atomic.Bool
+	ctxA, cancelA := context.WithCancel(context.Background())
+	doneA := make(chan struct{})
+	go func() {
+		defer close(doneA)
+		(&RedisLease{Client: client, Identity: "a", LeaseDuration: lease}).Run(ctxA, aLeads.Store)
+	}()
+	require.Eventually(t, aLeads.Load, time.Second, 10*time.Millisecond, "first replica should acquire the lease")
+
+	ctxB, cancelB := context.WithCancel(context.Background())
+	defer cancelB()
+	doneB := make(chan struct{})
+	go func() {
+		defer close(doneB)
+		(&RedisLease{Client: client, Identity: "b", LeaseDuration: lease}).Run(ctxB, bLeads.Store)
+	}()
+	// a renews well within the lease, so b never gets in
+	require.Never(t, bLeads.Load, 4*lease, 10*time.Millisecond)
+
+	holder, err := client.Get(context.Background(), DefaultLeaseKey).Result()
+	require.NoError(t, err)
+	require.Equal(t, "a", holder)
+
+	// a releases on shutdown and b takes over without waiting out the lease
+	cancelA()
+	<-doneA
+	require.Eventually(t, bLeads.Load, lease, 5*time.Millisecond)
+
+	cancelB()
+	<-doneB
+	require.Zero(t, client.Exists(context.Background(), DefaultLeaseKey).Val(), "lease released on shutdown")
+}
+
+func TestRedisLease_StepsDownWhenLeaseTaken(t *testing.T) {
+	client := newRedisClient(t)
+	lease := 150 * time.Millisecond
+
+	var leads atomic.Bool
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		(&RedisLease{Client: client, Identity: "a", LeaseDuration: lease}).Run(ctx, leads.Store)
+	}()
+	require.Eventually(t, leads.Load, time.Second, 10*time.Millisecond)
+
+	// another replica grabbed the key, e.g. after a long pause expired ours
+	require.NoError(t, client.Set(context.Background(), DefaultLeaseKey, "b", time.Minute).Err())
+	require.Eventually(t, func() bool { return !leads.Load() }, time.Second, 10*time.Millisecond)
+
+	cancel()
+	<-done
+	holder, err := client.Get(context.Background(), DefaultLeaseKey).Result()
+	require.NoError(t, err)
+	require.Equal(t, "b", holder, "a non-holder must not release someone else's lease")
+}
@@ -0,0 +1,64 @@
+package catalog
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+)
+
+type inMemoryStore struct {
+	mu       sync.RWMutex
+	revision int64
+	// servers holds encoded snapshots so callers never share state with the
+	// store, matching the copy semantics of the Redis store
+	servers map[string][]byte
+}
+
+func newInMemoryStore() *inMemoryStore {
+	return &inMemoryStore{servers: map[string][]byte{}}
+}
+
+func (s *inMemoryStore) Publish(_ context.Context, servers []ServerSnapshot) (int64, error) {
+	next := make(map[string][]byte, len(servers))
+	for _, srv := range servers {
+		data, err := json.Marshal(srv)
+		if err != nil {
+			return 0, fmt.Errorf("marshal catalog entry %s: %w", srv.ID, err)
+		}
+		next[srv.ID] = data
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.servers = next
+	s.revision++
+	return s.revision, nil
+}
+
+func (s *inMemoryStore) Load(_ context.Context) (*Snapshot, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	snap := &Snapshot{Revision: s.revision, Servers: make(map[string]*ServerSnapshot, len(s.servers))}
+	for id, data := range s.servers {
+		srv, err := decodeServer(data)
+		if err != nil {
+			return nil, fmt.Errorf("unmarshal catalog entry %s: %w", id, err)
+		}
+		snap.Servers[id] = srv
+	}
+	return snap, nil
+}
+
+func (s *inMemoryStore) Revision(_ context.Context) (int64, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.revision, nil
+}
+
+func decodeServer(data []byte) (*ServerSnapshot, error) {
+	var srv ServerSnapshot
+	if err := json.Unmarshal(data, &srv); err != nil {
+		return nil, err
+	}
+	return &srv, nil
+}
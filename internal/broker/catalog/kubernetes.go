@@ -0,0 +1,103 @@
+package catalog
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/leaderelection"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
+)
+
+// DefaultLeaseName is the coordination.k8s.io Lease used for discovery
+const DefaultLeaseName = "mcp-gateway-discovery"
+
+// KubernetesLease is an Elector backed by a coordination.k8s.io Lease. the
+// broker's service account needs get, create and update on leases in
+// Namespace.
+type KubernetesLease struct {
+	Client        kubernetes.Interface
+	Name          string
+	Namespace     string
+	Identity      string
+	LeaseDuration time.Duration
+	Logger        *slog.Logger
+}
+
+var _ Elector = &KubernetesLease{}
+
+// NewInClusterKubernetesLease builds a KubernetesLease using the pod's
+// service account credentials
+func NewInClusterKubernetesLease(namespace, identity string, leaseDuration time.Duration, logger *slog.Logger) (*KubernetesLease, error) {
+	cfg, err := rest.InClusterConfig()
+	if err != nil {
+		return nil, fmt.Errorf("loading in-cluster config: %w", err)
+	}
+	client, err := kubernetes.NewForConfig(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("building kubernetes client: %w", err)
+	}
+	return &KubernetesLease{
+		Client:        client,
+		Name:          DefaultLeaseName,
+		Namespace:     namespace,
+		Identity:      identity,
+		LeaseDuration: leaseDuration,
+		Logger:        logger,
+	}, nil
+}
+
+// Run implements Elector. client-go's elector returns once leadership is
+// lost, so it is re-entered until ctx is cancelled.
+func (l *KubernetesLease) Run(ctx context.Context, onChange func(leader bool)) {
+	name := l.Name
+	if name == "" {
+		name = DefaultLeaseName
+	}
+	lease := l.LeaseDuration
+	if lease <= 0 {
+		lease = DefaultLeaseDuration
+	}
+	logger := l.Logger
+	if logger == nil {
+		logger = slog.New(slog.DiscardHandler)
+	}
+
+	lock := &resourcelock.LeaseLock{
+		LeaseMeta:  metav1.ObjectMeta{Name: name, Namespace: l.Namespace},
+		Client:     l.Client.CoordinationV1(),
+		LockConfig: resourcelock.ResourceLockConfig{Identity: l.Identity},
+	}
+	elector, err := leaderelection.NewLeaderElector(leaderelection.LeaderElectionConfig{
+		Lock:            lock,
+		LeaseDuration:   lease,
+		RenewDeadline:   lease * 2 / 3,
+		RetryPeriod:     lease / 3,
+		ReleaseOnCancel: true,
+		Name:            name,
+		Callbacks: leaderelection.LeaderCallbacks{
+			OnStartedLeading: func(context.Context) {
+				logger.Info("discovery lease acquired", "lease", name, "identity", l.Identity)
+				onChange(true)
+			},
+			OnStoppedLeading: func() {
+				if ctx.Err() != nil {
+					return
+				}
+				logger.Info("discovery lease lost", "lease", name, "identity", l.Identity)
+				onChange(false)
+			},
+		},
+	})
+	if err != nil {
+		logger.Error("invalid discovery lease config, running as follower", "error", err)
+		return
+	}
+	for ctx.Err() == nil {
+		elector.Run(ctx)
+	}
+}
@@ -0,0 +1,81 @@
+package catalog
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	redis "github.com/redis/go-redis/v9"
+)
+
+// the catalog keys share a hash tag so Publish and Load, which touch both in
+// one transaction, work on Redis Cluster
+const (
+	serversKey  = "{catalog}:servers"
+	revisionKey = "{catalog}:revision"
+)
+
+type redisStore struct {
+	client redis.UniversalClient
+}
+
+func newRedisStore(client redis.UniversalClient) *redisStore {
+	return &redisStore{client: client}
+}
+
+func (s *redisStore) Publish(ctx context.Context, servers []ServerSnapshot) (int64, error) {
+	fields := make([]any, 0, len(servers)*2)
+	for _, srv := range servers {
+		data, err := json.Marshal(srv)
+		if err != nil {
+			return 0, fmt.Errorf("marshal catalog entry %s: %w", srv.ID, err)
+		}
+		fields = append(fields, srv.ID, data)
+	}
+	// replace the hash and bump the revision atomically so a follower never
+	// loads a half-written catalog under a new revision
+	pipe := s.client.TxPipeline()
+	pipe.Del(ctx, serversKey)
+	if len(fields) > 0 {
+		pipe.HSet(ctx, serversKey, fields...)
+	}
+	rev := pipe.Incr(ctx, revisionKey)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return 0, fmt.Errorf("publish catalog: %w", err)
+	}
+	return rev.Val(), nil
+}
+
+func (s *redisStore) Load(ctx context.Context) (*Snapshot, error) {
+	pipe := s.client.TxPipeline()
+	rev := pipe.Get(ctx, revisionKey)
+	all := pipe.HGetAll(ctx, serversKey)
+	if _, err := pipe.Exec(ctx); err != nil && !errors.Is(err, redis.Nil) {
+		return nil, fmt.Errorf("load catalog: %w", err)
+	}
+	revision, err := rev.Int64()
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return nil, fmt.Errorf("load catalog revision: %w", err)
+	}
+	snap := &Snapshot{Revision: revision, Servers: make(map[string]*ServerSnapshot, len(all.Val()))}
+	for id, data := range all.Val() {
+		srv, err := decodeServer([]byte(data))
+		if err != nil {
+			return nil, fmt.Errorf("unmarshal catalog entry %s: %w", id, err)
+		}
+		snap.Servers[id] = srv
+	}
+	return snap, nil
+}
+
+func (s *redisStore) Revision(ctx context.Context) (int64, error) {
+	rev, err := s.client.Get(ctx, revisionKey).Int64()
+	if errors.Is(err, redis.Nil) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("load catalog revision: %w", err)
+	}
+	return rev, nil
+}
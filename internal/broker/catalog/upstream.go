@@ -0,0 +1,410 @@
+package catalog
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/Kuadrant/mcp-gateway/internal/broker/upstream"
+	"github.com/Kuadrant/mcp-gateway/internal/config"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+const (
+	notificationToolsListChanged   = "notifications/tools/list_changed"
+	notificationPromptsListChanged = "notifications/prompts/list_changed"
+)
+
+// Upstream is the upstream.MCP a manager drives when the shared catalog is
+// enabled. while this replica leads it delegates to the live upstream; as a
+// follower it answers connect, ping and listing from the published snapshot
+// and never contacts the upstream for discovery. switching role keeps the
+// same manager, so served tools survive a leadership handover untouched.
+type Upstream struct {
+	live upstream.MCP
+
+	mu       sync.RWMutex
+	leader   bool
+	snapshot *ServerSnapshot
+	notify   func(method string)
+}
+
+var _ upstream.MCP = &Upstream{}
+
+// NewUpstream wraps live. snapshot may be nil when nothing has been
+// published for this server yet; the wrapper starts as a follower so a
+// fresh replica serves the shared catalog before any election completes.
+func NewUpstream(live upstream.MCP, snapshot *ServerSnapshot) *Upstream {
+	return &Upstream{live: live, snapshot: snapshot}
+}
+
+// SetLeader switches between live discovery and serving the snapshot. on a
+// change the manager is nudged to re-list from the new source straight away.
+func (u *Upstream) SetLeader(leader bool) {
+	u.mu.Lock()
+	changed := u.leader != leader
+	u.leader = leader
+	u.mu.Unlock()
+	if !changed {
+		return
+	}
+	if !leader {
+		_ = u.live.Disconnect()
+	}
+	u.fire(notificationToolsListChanged)
+	u.fire(notificationPromptsListChanged)
+}
+
+// Apply records the latest published snapshot for this server. followers
+// re-list when the tools, prompts or readiness changed.
+func (u *Upstream) Apply(snapshot *ServerSnapshot) {
+	u.mu.Lock()
+	prev := u.snapshot
+	u.snapshot = snapshot
+	leader := u.leader
+	u.mu.Unlock()
+	if leader {
+		return
+	}
+	if prev == nil || snapshot == nil {
+		if prev != snapshot {
+			u.fire(notificationToolsListChanged)
+			u.fire(notificationPromptsListChanged)
+		}
+		return
+	}
+	if prev.Status.Ready != snapshot.Status.Ready || !reflect.DeepEqual(prev.Tools, snapshot.Tools) || !reflect.DeepEqual(prev.Hints, snapshot.Hints) {
+		u.fire(notificationToolsListChanged)
+	}
+	if !reflect.DeepEqual(prev.Prompts, snapshot.Prompts) {
+		u.fire(notificationPromptsListChanged)
+	}
+}
+
+// Snapshot builds the publishable state of this server from the manager
+// serving it and the live upstream's negotiated capabilities.
+func (u *Upstream) Snapshot(active upstream.ActiveMCPServer) ServerSnapshot {
+	cfg := u.live.GetConfig()
+	id := string(u.live.ID())
+	snap := ServerSnapshot{
+		ID:                id,
+		Name:              cfg.Name,
+		Prefix:            cfg.Prefix,
+		Status:            active.GetStatus(),
+		Init:              u.live.ProtocolInfo(),
+		SupportedVersions: u.live.SupportedVersions(),
+		ToolsCache:        u.live.ToolsCacheMetadata(),
+		PromptsCache:      u.live.PromptsCacheMetadata(),
+	}
+	// a new leader serves the previous snapshot until its first live
+	// connect; carry the negotiated details forward rather than blank them
+	if prev := u.currentSnapshot(); snap.Init == nil && prev != nil {
+		snap.Init = prev.Init
+		snap.SupportedVersions = prev.SupportedVersions
+	}
+	for _, t := range active.GetManagedTools() {
+		t.Name = cfg.Prefix + t.Name
+		t.Meta = mcp.Meta{upstream.GatewayServerID: id}
+		if h, ok := u.live.GetToolHints(t.Name); ok {
+			if snap.Hints == nil {
+				snap.Hints = map[string]upstream.ToolHints{}
+			}
+			snap.Hints[t.Name] = h
+		}
+		snap.Tools = append(snap.Tools, t)
+	}
+	for _, p := range active.GetManagedPrompts() {
+		p.Name = cfg.Prefix + p.Name
+		p.Meta = mcp.Meta{upstream.GatewayServerID: id}
+		snap.Prompts = append(snap.Prompts, p)
+	}
+	return snap
+}
+
+// state returns the role and snapshot under one read lock
+func (u *Upstream) state() (bool, *ServerSnapshot) {
+	u.mu.RLock()
+	defer u.mu.RUnlock()
+	return u.leader, u.snapshot
+}
+
+func (u *Upstream) currentSnapshot() *ServerSnapshot {
+	_, snap := u.state()
+	return snap
+}
+
+func (u *Upstream) fire(method string) {
+	u.mu.RLock()
+	handler := u.notify
+	u.mu.RUnlock()
+	if handler != nil {
+		handler(method)
+	}
+}
+
+func (u *Upstream) errNoSnapshot() error {
+	return fmt.Errorf("no catalog snapshot published for %s yet", u.live.ID())
+}
+
+// GetName implements upstream.MCP
+func (u *Upstream) GetName() string { return u.live.GetName() }
+
+// GetConfig implements upstream.MCP
+func (u *Upstream) GetConfig() config.MCPServer { return u.live.GetConfig() }
+
+// ID implements upstream.MCP
+func (u *Upstream) ID() config.UpstreamMCPID { return u.live.ID() }
+
+// GetPrefix implements upstream.MCP
+func (u *Upstream) GetPrefix() string { return u.live.GetPrefix() }
+
+// IsEnabled implements upstream.MCP
+func (u *Upstream) IsEnabled() bool { return u.live.IsEnabled() }
+
+// Connect implements upstream.MCP. followers "connect" once a snapshot exists.
+func (u *Upstream) Connect(ctx context.Context, onConnection func()) error {
+	leader, snap := u.state()
+	if leader {
+		return u.live.Connect(ctx, onConnection)
+	}
+	if snap == nil {
+		return u.errNoSnapshot()
+	}
+	onConnection()
+	return nil
+}
+
+// Disconnect implements upstream.MCP
+func (u *Upstream) Disconnect() error { return u.live.Disconnect() }
+
+// Ping implements upstream.MCP. followers report the leader's view of the
+// upstream's health.
+func (u *Upstream) Ping(ctx context.Context) error {
+	leader, snap := u.state()
+	if leader {
+		return u.live.Ping(ctx)
+	}
+	if snap == nil {
+		return u.errNoSnapshot()
+	}
+	if !snap.Status.Ready {
+		return fmt.Errorf("leader reports upstream unavailable: %s", snap.Status.Message)
+	}
+	return nil
+}
+
+// ListTools implements upstream.MCP, returning unprefixed tools as the
+// upstream would
+func (u *Upstream) ListTools(ctx context.Context) (*mcp.ListToolsResult, error) {
+	leader, snap := u.state()
+	if leader {
+		return u.live.ListTools(ctx)
+	}
+	if snap == nil {
+		return nil, u.errNoSnapshot()
+	}
+	res := &mcp.ListToolsResult{Tools: make([]*mcp.Tool, 0, len(snap.Tools))}
+	res.TTLMs = snap.ToolsCache.TTLMs
+	res.CacheScope = snap.ToolsCache.CacheScope
+	for _, t := range snap.Tools {
+		t.Name = strings.TrimPrefix(t.Name, snap.Prefix)
+		res.Tools = append(res.Tools, &t)
+	}
+	return res, nil
+}
+
+// ListPrompts implements upstream.MCP
+func (u *Upstream) ListPrompts(ctx context.Context) (*mcp.ListPromptsResult, error) {
+	leader, snap := u.state()
+	if leader {
+		return u.live.ListPrompts(ctx)
+	}
+	if snap == nil {
+		return nil, u.errNoSnapshot()
+	}
+	res := &mcp.ListPromptsResult{Prompts: make([]*mcp.Prompt, 0, len(snap.Prompts))}
+	res.TTLMs = snap.PromptsCache.TTLMs
+	res.CacheScope = snap.PromptsCache.CacheScope
+	for _, p := range snap.Prompts {
+		p.Name = strings.TrimPrefix(p.Name, snap.Prefix)
+		res.Prompts = append(res.Prompts, &p)
+	}
+	return res, nil
+}
+
+// ListResources implements upstream.MCP. resources are never cached, so a
+// follower lists them live, connecting on first use.
+func (u *Upstream) ListResources(ctx context.Context) (*mcp.ListResourcesResult, error) {
+	if leader, _ := u.state(); !leader {
+		if err := u.live.Connect(ctx, func() {}); err != nil {
+			return nil, err
+		}
+	}
+	return u.live.ListResources(ctx)
+}
+
+// capabilities returns the snapshot's server capabilities, nil if unknown
+func capabilities(snap *ServerSnapshot) *mcp.ServerCapabilities {
+	if snap == nil || snap.Init == nil {
+		return nil
+	}
+	return snap.Init.Capabilities
+}
+
+// SupportsToolsListChanged implements upstream.MCP
+func (u *Upstream) SupportsToolsListChanged() bool {
+	leader, snap := u.state()
+	if leader {
+		return u.live.SupportsToolsListChanged()
+	}
+	caps := capabilities(snap)
+	return caps != nil && caps.Tools != nil && caps.Tools.ListChanged
+}
+
+// SupportsPrompts implements upstream.MCP
+func (u *Upstream) SupportsPrompts() bool {
+	leader, snap := u.state()
+	if leader {
+		return u.live.SupportsPrompts()
+	}
+	caps := capabilities(snap)
+	return caps != nil && caps.Prompts != nil
+}
+
+// SupportsPromptsListChanged implements upstream.MCP
+func (u *Upstream) SupportsPromptsListChanged() bool {
+	leader, snap := u.state()
+	if leader {
+		return u.live.SupportsPromptsListChanged()
+	}
+	caps := capabilities(snap)
+	return caps != nil && caps.Prompts != nil && caps.Prompts.ListChanged
+}
+
+// SupportsResources implements upstream.MCP
+func (u *Upstream) SupportsResources() bool {
+	leader, snap := u.state()
+	if leader {
+		return u.live.SupportsResources()
+	}
+	caps := capabilities(snap)
+	return caps != nil && caps.Resources != nil
+}
+
+// SupportsLogging implements upstream.MCP
+func (u *Upstream) SupportsLogging() bool {
+	leader, snap := u.state()
+	if leader {
+		return u.live.SupportsLogging()
+	}
+	caps := capabilities(snap)
+	return caps != nil && caps.Logging != nil
+}
+
+// SetLoggingLevel implements upstream.MCP. the live upstream keeps the
+// level, so a follower promoted to leader logs at it once connected.
+func (u *Upstream) SetLoggingLevel(ctx context.Context, level mcp.LoggingLevel) error {
+	return u.live.SetLoggingLevel(ctx, level)
+}
+
+// OnLogMessage implements upstream.MCP. only a connected live upstream
+// sends log events, so followers forward none.
+func (u *Upstream) OnLogMessage(handler func(*mcp.LoggingMessageParams)) {
+	u.live.OnLogMessage(handler)
+}
+
+// OnNotification implements upstream.MCP. the handler is kept here too so
+// role switches and snapshot updates can trigger a re-list.
+func (u *Upstream) OnNotification(handler func(method string)) {
+	u.mu.Lock()
+	u.notify = handler
+	u.mu.Unlock()
+	u.live.OnNotification(handler)
+}
+
+// OnConnectionLost implements upstream.MCP. followers hold no connection.
+func (u *Upstream) OnConnectionLost(handler func(err error)) {
+	if leader, _ := u.state(); leader {
+		u.live.OnConnectionLost(handler)
+	}
+}
+
+// ProtocolInfo implements upstream.MCP
+func (u *Upstream) ProtocolInfo() *mcp.InitializeResult {
+	leader, snap := u.state()
+	if leader {
+		return u.live.ProtocolInfo()
+	}
+	if snap == nil {
+		return nil
+	}
+	return snap.Init
+}
+
+// GetToolHints implements upstream.MCP
+func (u *Upstream) GetToolHints(served string) (upstream.ToolHints, bool) {
+	leader, snap := u.state()
+	if leader {
+		return u.live.GetToolHints(served)
+	}
+	if snap == nil {
+		return upstream.ToolHints{}, false
+	}
+	h, ok := snap.Hints[served]
+	return h, ok
+}
+
+// SupportedVersions implements upstream.MCP
+func (u *Upstream) SupportedVersions() []string {
+	leader, snap := u.state()
+	if leader {
+		return u.live.SupportedVersions()
+	}
+	if snap == nil || len(snap.SupportedVersions) == 0 {
+		return nil
+	}
+	return slices.Clone(snap.SupportedVersions)
+}
+
+// SupportsVersion implements upstream.MCP
+func (u *Upstream) SupportsVersion(v string) bool {
+	leader, snap := u.state()
+	if leader {
+		return u.live.SupportsVersion(v)
+	}
+	return snap != nil && slices.Contains(snap.SupportedVersions, v)
+}
+
+// ToolsCacheMetadata implements upstream.MCP
+func (u *Upstream) ToolsCacheMetadata() upstream.CacheMetadata {
+	leader, snap := u.state()
+	if leader || snap == nil {
+		return u.live.ToolsCacheMetadata()
+	}
+	meta := snap.ToolsCache
+	meta.UserSpecificList = u.live.GetConfig().UserSpecificList
+	return meta
+}
+
+// PromptsCacheMetadata implements upstream.MCP
+func (u *Upstream) PromptsCacheMetadata() upstream.CacheMetadata {
+	leader, snap := u.state()
+	if leader || snap == nil {
+		return u.live.PromptsCacheMetadata()
+	}
+	meta := snap.PromptsCache
+	meta.UserSpecificList = u.live.GetConfig().UserSpecificList
+	return meta
+}
+
+// UsesStatelessProtocol implements upstream.MCP. followers have no
+// connection to recycle.
+func (u *Upstream) UsesStatelessProtocol() bool {
+	if leader, _ := u.state(); leader {
+		return u.live.UsesStatelessProtocol()
+	}
+	return false
+}
@@ -0,0 +1,164 @@
+package catalog
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Kuadrant/mcp-gateway/internal/broker/upstream"
+	"github.com/Kuadrant/mcp-gateway/internal/config"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeLive stands in for the real upstream. unimplemented methods panic via
+// the nil embedded interface, proving followers never reach them.
+type fakeLive struct {
+	upstream.MCP
+	cfg         config.MCPServer
+	connects    int
+	disconnects int
+	tools       []*mcp.Tool
+	notify      func(string)
+}
+
+func (f *fakeLive) GetConfig() config.MCPServer { return f.cfg }
+func (f *fakeLive) ID() config.UpstreamMCPID    { return f.cfg.ID() }
+func (f *fakeLive) GetPrefix() string           { return f.cfg.Prefix }
+func (f *fakeLive) Connect(_ context.Context, onConnection func()) error {
+	f.connects++
+	onConnection()
+	return nil
+}
+func (f *fakeLive) Disconnect() error                   { f.disconnects++; return nil }
+func (f *fakeLive) Ping(context.Context) error          { return nil }
+func (f *fakeLive) OnNotification(handler func(string)) { f.notify = handler }
+func (f *fakeLive) OnConnectionLost(func(error))        {}
+func (f *fakeLive) UsesStatelessProtocol() bool         { return true }
+func (f *fakeLive) ProtocolInfo() *mcp.InitializeResult { return nil }
+func (f *fakeLive) SupportedVersions() []string         { return nil }
+func (f *fakeLive) GetToolHints(string) (upstream.ToolHints, bool) {
+	return upstream.ToolHints{}, false
+}
+func (f *fakeLive) ToolsCacheMetadata() upstream.CacheMetadata   { return upstream.CacheMetadata{} }
+func (f *fakeLive) PromptsCacheMetadata() upstream.CacheMetadata { return upstream.CacheMetadata{} }
+func (f *fakeLive) ListTools(context.Context) (*mcp.ListToolsResult, error) {
+	return &mcp.ListToolsResult{Tools: f.tools}, nil
+}
+
+// fakeActive is the manager view Snapshot reads from
+type fakeActive struct {
+	upstream.ActiveMCPServer
+	tools   []mcp.Tool
+	prompts []mcp.Prompt
+	status  upstream.ServerValidationStatus
+}
+
+func (f *fakeActive) GetStatus() upstream.ServerValidationStatus { return f.status }
+func (f *fakeActive) GetManagedTools() []mcp.Tool                { return f.tools }
+func (f *fakeActive) GetManagedPrompts() []mcp.Prompt            { return f.prompts }
+
+func newFakeLive() *fakeLive {
+	return &fakeLive{cfg: config.MCPServer{Name: "weather", Prefix: "w_", URL: "http://weather"}}
+}
+
+func TestUpstream_FollowerServesSnapshot(t *testing.T) {
+	live := newFakeLive()
+	u := NewUpstream(live, nil)
+	ctx := context.Background()
+
+	// nothing published yet
+	require.ErrorContains(t, u.Connect(ctx, func() {}), "no catalog snapshot")
+	require.Error(t, u.Ping(ctx))
+
+	var notified []string
+	u.OnNotification(func(method string) { notified = append(notified, method) })
+	snap := testSnapshot(string(live.ID()), "w_", "forecast")
+	u.Apply(&snap)
+	require.ElementsMatch(t, []string{notificationToolsListChanged, notificationPromptsListChanged}, notified)
+
+	connected := false
+	require.NoError(t, u.Connect(ctx, func() { connected = true }))
+	require.True(t, connected)
+	require.NoError(t, u.Ping(ctx))
+	require.Zero(t, live.connects, "followers never connect for discovery")
+
+	res, err := u.ListTools(ctx)
+	require.NoError(t, err)
+	require.Len(t, res.Tools, 1)
+	require.Equal(t, "forecast", res.Tools[0].Name, "tools are returned unprefixed, as the upstream lists them")
+	require.True(t, u.SupportsToolsListChanged())
+	require.False(t, u.SupportsPrompts())
+	require.False(t, u.UsesStatelessProtocol())
+	require.True(t, u.SupportsVersion("2025-11-25"))
+	hints, ok := u.GetToolHints("w_forecast")
+	require.True(t, ok)
+	require.True(t, *hints.ReadOnlyHint)
+
+	// an unchanged snapshot does not trigger a re-list
+	notified = nil
+	same := testSnapshot(string(live.ID()), "w_", "forecast")
+	u.Apply(&same)
+	require.Empty(t, notified)
+
+	// the leader lost the upstream
+	down := testSnapshot(string(live.ID()), "w_", "forecast")
+	down.Status.Ready = false
+	down.Status.Message = "connection refused"
+	u.Apply(&down)
+	require.Equal(t, []string{notificationToolsListChanged}, notified)
+	require.ErrorContains(t, u.Ping(ctx), "connection refused")
+}
+
+func TestUpstream_LeaderDelegatesToLive(t *testing.T) {
+	live := newFakeLive()
+	live.tools = []*mcp.Tool{{Name: "live_tool"}}
+	snap := testSnapshot(string(live.ID()), "w_", "forecast")
+	u := NewUpstream(live, &snap)
+	ctx := context.Background()
+
+	var notified []string
+	u.OnNotification(func(method string) { notified = append(notified, method) })
+	require.NotNil(t, live.notify, "live notifications reach the manager")
+
+	u.SetLeader(true)
+	require.Len(t, notified, 2, "a role change re-lists from the new source")
+	require.NoError(t, u.Connect(ctx, func() {}))
+	require.Equal(t, 1, live.connects)
+	res, err := u.ListTools(ctx)
+	require.NoError(t, err)
+	require.Equal(t, "live_tool", res.Tools[0].Name)
+	require.True(t, u.UsesStatelessProtocol())
+
+	// snapshot updates are ignored while leading
+	notified = nil
+	other := testSnapshot(string(live.ID()), "w_", "other")
+	u.Apply(&other)
+	require.Empty(t, notified)
+
+	u.SetLeader(false)
+	require.Equal(t, 1, live.disconnects, "stepping down drops the live connection")
+	res, err = u.ListTools(ctx)
+	require.NoError(t, err)
+	require.Equal(t, "other", res.Tools[0].Name)
+}
+
+func TestUpstream_Snapshot(t *testing.T) {
+	live := newFakeLive()
+	prev := testSnapshot(string(live.ID()), "w_", "forecast")
+	u := NewUpstream(live, &prev)
+	active := &fakeActive{
+		tools:   []mcp.Tool{{Name: "forecast", InputSchema: map[string]any{"type": "object"}}},
+		prompts: []mcp.Prompt{{Name: "summarise"}},
+		status:  upstream.ServerValidationStatus{ID: string(live.ID()), Ready: true},
+	}
+
+	snap := u.Snapshot(active)
+	require.Equal(t, "weather:w_:", snap.ID)
+	require.Equal(t, "w_forecast", snap.Tools[0].Name)
+	require.Equal(t, "weather:w_:", snap.Tools[0].Meta[upstream.GatewayServerID])
+	require.Equal(t, "w_summarise", snap.Prompts[0].Name)
+	require.True(t, snap.Status.Ready)
+	// not yet connected live: negotiated details carry forward
+	require.Equal(t, prev.Init, snap.Init)
+	require.Equal(t, prev.SupportedVersions, snap.SupportedVersions)
+}
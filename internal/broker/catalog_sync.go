@@ -0,0 +1,271 @@
+package broker
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Kuadrant/mcp-gateway/internal/broker/catalog"
+	"github.com/Kuadrant/mcp-gateway/internal/broker/upstream"
+	"github.com/Kuadrant/mcp-gateway/internal/config"
+)
+
+const (
+	defaultCatalogSyncInterval = 5 * time.Second
+	catalogLoadTimeout         = 5 * time.Second
+
+	catalogRoleLeader   = "leader"
+	catalogRoleFollower = "follower"
+)
+
+// catalogSync shares upstream discovery between replicas. every manager
+// drives a catalog.Upstream: the elected leader discovers live and publishes
+// what its managers serve, followers serve the published snapshot.
+type catalogSync struct {
+	store    catalog.Store
+	elector  catalog.Elector
+	interval time.Duration
+
+	leader atomic.Bool
+	// configured is set once the first config has been applied, so a leader
+	// elected during boot never publishes an empty catalog over a good one
+	configured atomic.Bool
+	latest     atomic.Pointer[catalog.Snapshot]
+
+	mu        sync.Mutex
+	upstreams map[config.UpstreamMCPID]*catalog.Upstream
+
+	// publishSignal coalesces table changes into one publish
+	publishSignal chan struct{}
+	// lastPublished and revision are only touched from the sync loop
+	lastPublished []byte
+	revision      int64
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// WithSharedCatalog enables leader-elected discovery through a shared store.
+// only the replica holding the elector's lease connects to upstreams for
+// discovery; the rest serve the catalog it publishes.
+func WithSharedCatalog(store catalog.Store, elector catalog.Elector) Option {
+	return func(mb *mcpBrokerImpl) {
+		if store == nil || elector == nil {
+			return
+		}
+		mb.catalog = &catalogSync{
+			store:         store,
+			elector:       elector,
+			interval:      defaultCatalogSyncInterval,
+			upstreams:     map[config.UpstreamMCPID]*catalog.Upstream{},
+			publishSignal: make(chan struct{}, 1),
+		}
+	}
+}
+
+// WithCatalogSyncInterval sets how often followers poll the shared catalog
+// and the leader republishes status. Only applies with WithSharedCatalog.
+func WithCatalogSyncInterval(interval time.Duration) Option {
+	return func(mb *mcpBrokerImpl) {
+		if mb.catalog != nil && interval > 0 {
+			mb.catalog.interval = interval
+		}
+	}
+}
+
+// StartCatalogSync loads the shared catalog so managers created by the first
+// config load warm-start from it, then campaigns for the discovery lease and
+// keeps the catalog in sync until Shutdown. no-op without WithSharedCatalog.
+func (m *mcpBrokerImpl) StartCatalogSync(ctx context.Context) {
+	cs := m.catalog
+	if cs == nil {
+		return
+	}
+	loadCtx, cancel := context.WithTimeout(ctx, catalogLoadTimeout)
+	m.refreshCatalog(loadCtx)
+	cancel()
+
+	ctx, cs.cancel = context.WithCancel(ctx)
+	cs.done = make(chan struct{})
+	electorDone := make(chan struct{})
+	go func() {
+		defer close(electorDone)
+		cs.elector.Run(ctx, m.onLeadershipChange)
+	}()
+	go func() {
+		defer close(cs.done)
+		m.catalogLoop(ctx)
+		// wait for the lease release so a successor takes over promptly
+		<-electorDone
+	}()
+}
+
+// stopCatalogSync stops publishing before managers are torn down: a leader
+// must never publish the empty catalog its own shutdown produces.
+func (m *mcpBrokerImpl) stopCatalogSync() {
+	cs := m.catalog
+	if cs == nil || cs.cancel == nil {
+		return
+	}
+	cs.cancel()
+	<-cs.done
+}
+
+func (m *mcpBrokerImpl) onLeadershipChange(leader bool) {
+	cs := m.catalog
+	cs.leader.Store(leader)
+	m.logger.Info("shared catalog role changed", "role", cs.role())
+	cs.mu.Lock()
+	for _, up := range cs.upstreams {
+		up.SetLeader(leader)
+	}
+	cs.mu.Unlock()
+	if leader {
+		cs.requestPublish()
+	}
+}
+
+// wrapUpstream returns the catalog-aware upstream for a new manager, or live
+// unchanged when the shared catalog is disabled. callers hold mcpLock.
+func (m *mcpBrokerImpl) wrapUpstream(live upstream.MCP) upstream.MCP {
+	cs := m.catalog
+	if cs == nil {
+		return live
+	}
+	var snap *catalog.ServerSnapshot
+	if latest := cs.latest.Load(); latest != nil {
+		snap = latest.Servers[string(live.ID())]
+	}
+	up := catalog.NewUpstream(live, snap)
+	up.SetLeader(cs.leader.Load())
+	cs.mu.Lock()
+	cs.upstreams[live.ID()] = up
+	cs.mu.Unlock()
+	return up
+}
+
+// forgetUpstream drops a deregistered server's catalog upstream
+func (m *mcpBrokerImpl) forgetUpstream(id config.UpstreamMCPID) {
+	cs := m.catalog
+	if cs == nil {
+		return
+	}
+	cs.mu.Lock()
+	delete(cs.upstreams, id)
+	cs.mu.Unlock()
+}
+
+func (cs *catalogSync) requestPublish() {
+	select {
+	case cs.publishSignal <- struct{}{}:
+	default:
+	}
+}
+
+func (cs *catalogSync) role() string {
+	if cs.leader.Load() {
+		return catalogRoleLeader
+	}
+	return catalogRoleFollower
+}
+
+func (m *mcpBrokerImpl) catalogLoop(ctx context.Context) {
+	cs := m.catalog
+	ticker := time.NewTicker(cs.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-cs.publishSignal:
+			if cs.leader.Load() {
+				m.publishCatalog(ctx)
+			}
+		case <-ticker.C:
+			if cs.leader.Load() {
+				m.publishCatalog(ctx)
+			} else {
+				m.refreshCatalog(ctx)
+			}
+		}
+	}
+}
+
+// publishCatalog publishes what the leader's managers currently serve,
+// skipping the write when nothing changed since the last publish.
+func (m *mcpBrokerImpl) publishCatalog(ctx context.Context) {
+	cs := m.catalog
+	if !cs.configured.Load() {
+		return
+	}
+	m.mcpLock.RLock()
+	cs.mu.Lock()
+	servers := make([]catalog.ServerSnapshot, 0, len(m.mcpServers))
+	for id, active := range m.mcpServers {
+		if up, ok := cs.upstreams[id]; ok {
+			servers = append(servers, up.Snapshot(active))
+		}
+	}
+	cs.mu.Unlock()
+	m.mcpLock.RUnlock()
+
+	slices.SortFunc(servers, func(a, b catalog.ServerSnapshot) int {
+		return strings.Compare(a.ID, b.ID)
+	})
+	encoded, err := json.Marshal(servers)
+	if err != nil {
+		m.logger.ErrorContext(ctx, "failed to encode shared catalog", "error", err)
+		return
+	}
+	if bytes.Equal(encoded, cs.lastPublished) {
+		return
+	}
+	rev, err := cs.store.Publish(ctx, servers)
+	if err != nil {
+		if ctx.Err() == nil {
+			m.logger.ErrorContext(ctx, "failed to publish shared catalog", "error", err)
+		}
+		return
+	}
+	cs.lastPublished = encoded
+	cs.revision = rev
+	m.logger.DebugContext(ctx, "published shared catalog", "revision", rev, "servers", len(servers))
+}
+
+// refreshCatalog loads the shared catalog when its revision moved and hands
+// each server's snapshot to its catalog upstream.
+func (m *mcpBrokerImpl) refreshCatalog(ctx context.Context) {
+	cs := m.catalog
+	rev, err := cs.store.Revision(ctx)
+	if err != nil {
+		if ctx.Err() == nil {
+			m.logger.ErrorContext(ctx, "failed to read shared catalog revision", "error", err)
+		}
+		return
+	}
+	if rev == cs.revision && cs.latest.Load() != nil {
+		return
+	}
+	snap, err := cs.store.Load(ctx)
+	if err != nil {
+		m.logger.ErrorContext(ctx, "failed to load shared catalog", "error", err)
+		return
+	}
+	cs.revision = snap.Revision
+	cs.latest.Store(snap)
+	// a published catalog is now the baseline; a later leader compares
+	// against what it builds itself
+	cs.lastPublished = nil
+	m.logger.DebugContext(ctx, "loaded shared catalog", "revision", snap.Revision, "servers", len(snap.Servers))
+
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+	for id, up := range cs.upstreams {
+		up.Apply(snap.Servers[string(id)])
+	}
+}
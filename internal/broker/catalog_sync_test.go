@@ -0,0 +1,141 @@
+package broker
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Kuadrant/mcp-gateway/internal/broker/catalog"
+	"github.com/Kuadrant/mcp-gateway/internal/config"
+	"github.com/stretchr/testify/require"
+)
+
+// switchElector hands leadership changes to the broker on demand
+type switchElector struct {
+	changes chan bool
+}
+
+func newSwitchElector() *switchElector {
+	return &switchElector{changes: make(chan bool, 4)}
+}
+
+func (e *switchElector) Run(ctx context.Context, onChange func(leader bool)) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case leader := <-e.changes:
+			onChange(leader)
+		}
+	}
+}
+
+// countingUpstream is fakeUpstream counting initialize requests
+func countingUpstream(t *testing.T, toolName string, inits *atomic.Int32) *httptest.Server {
+	t.Helper()
+	inner := fakeUpstreamHandler(toolName, "sess", func() {})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			body, err := io.ReadAll(r.Body)
+			if err != nil {
+				http.Error(w, "bad request", http.StatusBadRequest)
+				return
+			}
+			if bytes.Contains(body, []byte(`"method":"initialize"`)) {
+				inits.Add(1)
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+		}
+		inner(w, r)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func newCatalogBroker(t *testing.T, store catalog.Store, elector catalog.Elector) *mcpBrokerImpl {
+	t.Helper()
+	b := NewBroker(slog.New(slog.DiscardHandler),
+		WithDiscoveryToolsEnabled(false),
+		WithSharedCatalog(store, elector),
+		WithCatalogSyncInterval(20*time.Millisecond),
+	).(*mcpBrokerImpl)
+	b.StartCatalogSync(context.Background())
+	t.Cleanup(func() { _ = b.Shutdown(context.Background()) })
+	return b
+}
+
+func TestSharedCatalog_FollowerServesLeaderDiscovery(t *testing.T) {
+	var inits atomic.Int32
+	up := countingUpstream(t, "echo", &inits)
+	conf := &config.MCPServersConfig{Servers: []*config.MCPServer{
+		{Name: "server-one", URL: up.URL, Prefix: "s1_"},
+	}}
+	store := catalog.New()
+
+	leaderElector := newSwitchElector()
+	leader := newCatalogBroker(t, store, leaderElector)
+	leaderElector.changes <- true
+	leader.OnConfigChange(context.Background(), conf)
+
+	follower := newCatalogBroker(t, store, newSwitchElector())
+	follower.OnConfigChange(context.Background(), conf)
+
+	require.Eventually(t, func() bool {
+		_, ok := follower.gatewayServer.ListTools()["s1_echo"]
+		return ok
+	}, 5*time.Second, 20*time.Millisecond, "follower should serve the leader's tools")
+	require.Equal(t, int32(1), inits.Load(), "only the leader initializes upstream")
+
+	route, ok := follower.RoutingTable().LookupTool("s1_echo")
+	require.True(t, ok)
+	require.Equal(t, "server-one", route.Name)
+
+	status := follower.ValidateAllServers()
+	require.Equal(t, catalogRoleFollower, status.CatalogRole)
+	require.Equal(t, 1, status.HealthyServers)
+}
+
+func TestSharedCatalog_WarmStartAndHandover(t *testing.T) {
+	var inits atomic.Int32
+	up := countingUpstream(t, "echo", &inits)
+	conf := &config.MCPServersConfig{Servers: []*config.MCPServer{
+		{Name: "server-one", URL: up.URL, Prefix: "s1_"},
+	}}
+	store := catalog.New()
+
+	// a previous leader left a catalog behind
+	firstElector := newSwitchElector()
+	first := newCatalogBroker(t, store, firstElector)
+	firstElector.changes <- true
+	first.OnConfigChange(context.Background(), conf)
+	require.Eventually(t, func() bool {
+		snap, err := store.Load(context.Background())
+		return err == nil && len(snap.Servers) == 1 && len(snap.Servers["server-one:s1_:"].Tools) == 1
+	}, 5*time.Second, 20*time.Millisecond, "leader should publish the catalog")
+	require.NoError(t, first.Shutdown(context.Background()))
+	initsBefore := inits.Load()
+
+	// a fresh replica serves the stored catalog before any election
+	elector := newSwitchElector()
+	next := newCatalogBroker(t, store, elector)
+	next.OnConfigChange(context.Background(), conf)
+	toolServed := func() bool {
+		_, ok := next.gatewayServer.ListTools()["s1_echo"]
+		return ok
+	}
+	require.Eventually(t, toolServed, 5*time.Second, 20*time.Millisecond, "warm start from the shared catalog")
+	require.Equal(t, initsBefore, inits.Load(), "warm start must not contact the upstream")
+
+	// taking over discovery keeps the tool served throughout
+	elector.changes <- true
+	require.Eventually(t, func() bool { return inits.Load() > initsBefore }, 5*time.Second, 20*time.Millisecond,
+		"new leader should discover live")
+	require.Never(t, func() bool { return !toolServed() }, 300*time.Millisecond, 10*time.Millisecond)
+	require.Equal(t, catalogRoleLeader, next.ValidateAllServers().CatalogRole)
+}
@@ -25,7 +25,13 @@ func fakeUpstream(t *testing.T, toolName, sessionID string, deleteDelay time.Dur
 // session termination (DELETE), so tests can gate manager Stop on channels.
 func fakeUpstreamWithStopHook(t *testing.T, toolName, sessionID string, onStop func()) *httptest.Server {
 	t.Helper()
-	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	return httptest.NewServer(fakeUpstreamHandler(toolName, sessionID, onStop))
+}
+
+// fakeUpstreamHandler is the handler behind fakeUpstreamWithStopHook, for
+// tests that wrap it.
+func fakeUpstreamHandler(toolName, sessionID string, onStop func()) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
 		switch r.Method {
 		case http.MethodGet:
 			w.WriteHeader(http.StatusOK)
@@ -68,7 +74,7 @@ func fakeUpstreamWithStopHook(t *testing.T, toolName, sessionID string, onStop f
 		default:
 			_ = json.NewEncoder(w).Encode(map[string]any{"jsonrpc": "2.0", "id": id, "result": map[string]any{}})
 		}
-	}))
+	}
 }
 
 // regression: on a config change the replaced manager's deferred Stop ran
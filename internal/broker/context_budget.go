@@ -0,0 +1,131 @@
+package broker
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// BudgetUnit is the unit a discovery context budget is measured in
+type BudgetUnit string
+
+const (
+	// BudgetUnitBytes measures tools by their serialized JSON size
+	BudgetUnitBytes BudgetUnit = "bytes"
+	// BudgetUnitTokens estimates tokens from the serialized JSON size
+	BudgetUnitTokens BudgetUnit = "tokens"
+
+	// bytesPerToken is the usual rough estimate for JSON-heavy text across
+	// common tokenizers; it is deliberately not model specific
+	bytesPerToken = 4
+
+	// budgetErrorLargestTools bounds how many tools a rejected selection names
+	budgetErrorLargestTools = 3
+)
+
+// contextBudget bounds the serialized size of the real tools exposed to a
+// session. A zero limit disables it.
+type contextBudget struct {
+	limit int64
+	unit  BudgetUnit
+}
+
+func (b contextBudget) enabled() bool {
+	return b.limit > 0
+}
+
+// sizeUnit is the unit tool sizes are reported in: the budget's unit, or
+// bytes when no budget is configured
+func (b contextBudget) sizeUnit() BudgetUnit {
+	if b.unit == BudgetUnitTokens {
+		return BudgetUnitTokens
+	}
+	return BudgetUnitBytes
+}
+
+// cost converts a serialized byte count into the budget's unit
+func (b contextBudget) cost(bytes int64) int64 {
+	if b.sizeUnit() == BudgetUnitTokens {
+		return (bytes + bytesPerToken - 1) / bytesPerToken
+	}
+	return bytes
+}
+
+// serializedToolSize returns the JSON-serialised byte count of a tool, the
+// same measure mcp_broker_tools_list_response_bytes uses per server.
+// returns 0 on marshal failure.
+func serializedToolSize(tool *mcp.Tool) int64 {
+	b, err := json.Marshal(tool)
+	if err != nil {
+		return 0
+	}
+	return int64(len(b))
+}
+
+// buildToolSizes measures every tool once so per-request budget checks are
+// map lookups
+func buildToolSizes(tools []*mcp.Tool) map[string]int64 {
+	sizes := make(map[string]int64, len(tools))
+	for _, tool := range tools {
+		sizes[tool.Name] = serializedToolSize(tool)
+	}
+	return sizes
+}
+
+// toolCost returns the size of a tool in the budget's unit, measuring it
+// when it is not in the cache (e.g. per-user tools fetched on request)
+func (m *mcpBrokerImpl) toolCost(tool *mcp.Tool) int64 {
+	if sizes := m.toolSizes.Load(); sizes != nil {
+		if size, ok := (*sizes)[tool.Name]; ok {
+			return m.discovery.budget.cost(size)
+		}
+	}
+	return m.discovery.budget.cost(serializedToolSize(tool))
+}
+
+// toolCostByName returns the cached size of a tool in the budget's unit
+func (m *mcpBrokerImpl) toolCostByName(name string) int64 {
+	if sizes := m.toolSizes.Load(); sizes != nil {
+		return m.discovery.budget.cost((*sizes)[name])
+	}
+	return 0
+}
+
+// checkSelectionBudget returns an error naming the largest tools when the
+// selected tools do not fit the context budget
+func (m *mcpBrokerImpl) checkSelectionBudget(selected []*mcp.Tool) error {
+	budget := m.discovery.budget
+	if !budget.enabled() {
+		return nil
+	}
+	type sized struct {
+		name string
+		cost int64
+	}
+	costs := make([]sized, 0, len(selected))
+	var total int64
+	for _, tool := range selected {
+		cost := m.toolCost(tool)
+		costs = append(costs, sized{name: tool.Name, cost: cost})
+		total += cost
+	}
+	if total <= budget.limit {
+		return nil
+	}
+	slices.SortFunc(costs, func(a, b sized) int {
+		if c := cmp.Compare(b.cost, a.cost); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.name, b.name)
+	})
+	largest := make([]string, 0, budgetErrorLargestTools)
+	for _, c := range costs[:min(len(costs), budgetErrorLargestTools)] {
+		largest = append(largest, fmt.Sprintf("%s (%d)", c.name, c.cost))
+	}
+	return fmt.Errorf("selected tools need %d %s, over the context budget of %d %s; largest: %s. Select fewer tools, using the sizes reported by %s",
+		total, budget.sizeUnit(), budget.limit, budget.sizeUnit(), strings.Join(largest, ", "), discoverToolsName)
+}
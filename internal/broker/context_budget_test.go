@@ -0,0 +1,217 @@
+package broker
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/stretchr/testify/require"
+)
+
+// bigTool returns a tool whose description pads its serialized size
+func bigTool(name string, descriptionBytes int) mcp.Tool {
+	return mcp.Tool{Name: name, Description: strings.Repeat("x", descriptionBytes)}
+}
+
+func TestContextBudget_Cost(t *testing.T) {
+	tests := []struct {
+		name   string
+		budget contextBudget
+		bytes  int64
+		want   int64
+		unit   BudgetUnit
+	}{
+		{"disabled reports bytes", contextBudget{}, 10, 10, BudgetUnitBytes},
+		{"bytes", contextBudget{limit: 100, unit: BudgetUnitBytes}, 10, 10, BudgetUnitBytes},
+		{"tokens round up", contextBudget{limit: 100, unit: BudgetUnitTokens}, 10, 3, BudgetUnitTokens},
+		{"tokens exact", contextBudget{limit: 100, unit: BudgetUnitTokens}, 12, 3, BudgetUnitTokens},
+		{"zero bytes", contextBudget{limit: 100, unit: BudgetUnitTokens}, 0, 0, BudgetUnitTokens},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.want, tc.budget.cost(tc.bytes))
+			require.Equal(t, tc.unit, tc.budget.sizeUnit())
+		})
+	}
+}
+
+func TestSerializedToolSize(t *testing.T) {
+	tool := &mcp.Tool{Name: "t", InputSchema: map[string]any{"type": "object"}}
+	b, err := json.Marshal(tool)
+	require.NoError(t, err)
+	require.Equal(t, int64(len(b)), serializedToolSize(tool))
+}
+
+func TestThresholdFilter_ContextBudget(t *testing.T) {
+	brokerTool := &mcp.Tool{Name: "discover_tools", Meta: mcp.Meta{brokerToolMetaKey: true}}
+	small := bigTool("small", 10)
+	large := bigTool("large", 1000)
+
+	tests := []struct {
+		name      string
+		opts      []Option
+		tools     []*mcp.Tool
+		wantTools int
+	}{
+		{
+			name:      "under byte budget",
+			opts:      []Option{WithDiscoveryContextBudget(2000, BudgetUnitBytes)},
+			tools:     []*mcp.Tool{&small, &large, brokerTool},
+			wantTools: 3,
+		},
+		{
+			name:      "over byte budget",
+			opts:      []Option{WithDiscoveryContextBudget(500, BudgetUnitBytes)},
+			tools:     []*mcp.Tool{&small, &large, brokerTool},
+			wantTools: 1,
+		},
+		{
+			name:      "over token budget",
+			opts:      []Option{WithDiscoveryContextBudget(200, BudgetUnitTokens)},
+			tools:     []*mcp.Tool{&small, &large, brokerTool},
+			wantTools: 1,
+		},
+		{
+			name:      "meta-tools do not count",
+			opts:      []Option{WithDiscoveryContextBudget(serializedToolSize(&small), BudgetUnitBytes)},
+			tools:     []*mcp.Tool{&small, brokerTool},
+			wantTools: 2,
+		},
+		{
+			name: "count threshold still applies under budget",
+			opts: []Option{
+				WithDiscoveryToolThreshold(1),
+				WithDiscoveryContextBudget(5000, BudgetUnitBytes),
+			},
+			tools:     []*mcp.Tool{&small, &large, brokerTool},
+			wantTools: 1,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			opts := append([]Option{WithDiscoveryToolsEnabled(true)}, tc.opts...)
+			b := NewBroker(logger, opts...).(*mcpBrokerImpl)
+			result := b.applyThresholdFilter(tc.tools)
+			require.Len(t, result, tc.wantTools)
+			if tc.wantTools == 1 {
+				require.Equal(t, "discover_tools", result[0].Name)
+			}
+		})
+	}
+}
+
+func TestDiscoverTools_ReportsToolSizes(t *testing.T) {
+	b := NewBroker(logger,
+		WithDiscoveryToolsEnabled(true),
+		WithDiscoveryContextBudget(1000, BudgetUnitTokens),
+	).(*mcpBrokerImpl)
+
+	b.mcpServers["s1"] = createTestManagerWithMeta(t,
+		"svc1", "s1_",
+		[]mcp.Tool{bigTool("small", 10), bigTool("large", 1000)},
+		[]string{"Test"}, "",
+	)
+	populateTestVersions(b)
+
+	call := func(args map[string]any) string {
+		t.Helper()
+		req := &mcp.CallToolRequest{
+			Params: &mcp.CallToolParamsRaw{Arguments: mustMarshalArgs(args)},
+			Extra:  &mcp.RequestExtra{Header: http.Header{}},
+		}
+		result, err := b.handleDiscoverTools(context.Background(), req)
+		require.NoError(t, err)
+		require.False(t, result.IsError)
+		return result.Content[0].(*mcp.TextContent).Text
+	}
+
+	var resp discoverToolsResponse
+	require.NoError(t, json.Unmarshal([]byte(call(map[string]any{})), &resp))
+	require.Equal(t, BudgetUnitTokens, resp.SizeUnit)
+	require.Equal(t, int64(1000), resp.Budget)
+	require.Len(t, resp.Servers, 1)
+	sizes := resp.Servers[0].ToolSizes
+	require.Len(t, sizes, 2)
+	require.Greater(t, sizes["s1_large"], sizes["s1_small"])
+	require.Greater(t, sizes["s1_large"], int64(1000/bytesPerToken))
+
+	var search discoverToolsSearchResponse
+	require.NoError(t, json.Unmarshal([]byte(call(map[string]any{"query": "large"})), &search))
+	require.Equal(t, BudgetUnitTokens, search.SizeUnit)
+	require.NotEmpty(t, search.Tools)
+	require.Equal(t, "s1_large", search.Tools[0].Name)
+	require.Equal(t, sizes["s1_large"], search.Tools[0].Size)
+}
+
+func TestDiscoverTools_ToolSizesWithoutBudget(t *testing.T) {
+	b := NewBroker(logger, WithDiscoveryToolsEnabled(true)).(*mcpBrokerImpl)
+	b.mcpServers["s1"] = createTestManagerWithMeta(t,
+		"svc1", "s1_",
+		[]mcp.Tool{bigTool("tool", 100)},
+		[]string{"Test"}, "",
+	)
+	populateTestVersions(b)
+
+	req := &mcp.CallToolRequest{
+		Params: &mcp.CallToolParamsRaw{Arguments: mustMarshalArgs(map[string]any{})},
+		Extra:  &mcp.RequestExtra{Header: http.Header{}},
+	}
+	result, err := b.handleDiscoverTools(context.Background(), req)
+	require.NoError(t, err)
+
+	var raw map[string]any
+	require.NoError(t, json.Unmarshal([]byte(result.Content[0].(*mcp.TextContent).Text), &raw))
+	require.Equal(t, "bytes", raw["sizeUnit"])
+	require.NotContains(t, raw, "budget")
+	tool := (*b.toolSizes.Load())["s1_tool"]
+	require.Greater(t, tool, int64(100))
+}
+
+func TestSelectTools_ContextBudget(t *testing.T) {
+	h := newDiscoveryHarness(t)
+	h.b.mcpServers["s1"] = createTestManagerWithMeta(t,
+		"svc1", "s1_",
+		[]mcp.Tool{bigTool("small", 10), bigTool("medium", 200), bigTool("large", 1000)},
+		[]string{"Test"}, "",
+	)
+	populateTestVersions(h.b)
+	sizes := *h.b.toolSizes.Load()
+	h.b.discovery.budget = contextBudget{limit: sizes["s1_small"] + sizes["s1_medium"], unit: BudgetUnitBytes}
+	cs := h.connect(t)
+
+	res, payload := selectTools(t, cs, []string{"s1_small", "s1_medium"})
+	require.False(t, res.IsError)
+	require.Equal(t, "scope set to 2 tools", payload["status"])
+
+	res, _ = selectTools(t, cs, []string{"s1_small", "s1_medium", "s1_large"})
+	require.True(t, res.IsError)
+	msg := res.Content[0].(*mcp.TextContent).Text
+	require.Contains(t, msg, "over the context budget")
+	require.Contains(t, msg, "bytes")
+	// largest tool is named first
+	require.Less(t, strings.Index(msg, "s1_large"), strings.Index(msg, "s1_medium"))
+
+	// a rejected selection keeps the previous scope
+	_, scoped := h.b.scopeStore.getScope(cs.ID())
+	require.Len(t, scoped, 2)
+	require.NotContains(t, scoped, "s1_large")
+}
+
+func TestSelectTools_ContextBudgetDoesNotRevealHiddenTools(t *testing.T) {
+	h := newDiscoveryHarness(t)
+	h.b.mcpServers["s1"] = createTestManagerWithMeta(t,
+		"svc1", "s1_",
+		[]mcp.Tool{bigTool("large", 1000)},
+		[]string{"Test"}, "",
+	)
+	populateTestVersions(h.b)
+	h.b.discovery.budget = contextBudget{limit: 1, unit: BudgetUnitBytes}
+	cs := h.connect(t)
+
+	res, _ := selectTools(t, cs, []string{"s1_large", "s1_missing"})
+	require.True(t, res.IsError)
+	require.Equal(t, "tool not available", res.Content[0].(*mcp.TextContent).Text)
+}
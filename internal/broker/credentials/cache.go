@@ -0,0 +1,73 @@
+package credentials
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/Kuadrant/mcp-gateway/internal/config"
+)
+
+// DefaultCacheTTL is how long a resolved credential is reused before the
+// provider is asked again, bounding how long a rotated credential goes
+// unnoticed
+const DefaultCacheTTL = time.Minute
+
+// Cache wraps a CredentialProvider and reuses each resolved credential for
+// a TTL. Errors are not cached.
+type Cache struct {
+	provider CredentialProvider
+	ttl      time.Duration
+	now      func() time.Time
+
+	mu      sync.Mutex
+	entries map[config.CredentialReference]cacheEntry
+}
+
+type cacheEntry struct {
+	value   string
+	expires time.Time
+}
+
+var _ CredentialProvider = &Cache{}
+
+// NewCache returns a Cache in front of provider. A ttl of zero or less uses
+// DefaultCacheTTL.
+func NewCache(provider CredentialProvider, ttl time.Duration) *Cache {
+	if ttl <= 0 {
+		ttl = DefaultCacheTTL
+	}
+	return &Cache{
+		provider: provider,
+		ttl:      ttl,
+		now:      time.Now,
+		entries:  map[config.CredentialReference]cacheEntry{},
+	}
+}
+
+// Credential implements CredentialProvider
+func (c *Cache) Credential(ctx context.Context, ref config.CredentialReference) (string, error) {
+	c.mu.Lock()
+	entry, ok := c.entries[ref]
+	c.mu.Unlock()
+	if ok && c.now().Before(entry.expires) {
+		return entry.value, nil
+	}
+
+	value, err := c.provider.Credential(ctx, ref)
+	if err != nil {
+		return "", err
+	}
+	c.mu.Lock()
+	c.entries[ref] = cacheEntry{value: value, expires: c.now().Add(c.ttl)}
+	c.mu.Unlock()
+	return value, nil
+}
+
+// Invalidate drops the cached value for ref so the next lookup reads it from
+// the provider, for example after the upstream rejected it
+func (c *Cache) Invalidate(ref config.CredentialReference) {
+	c.mu.Lock()
+	delete(c.entries, ref)
+	c.mu.Unlock()
+}
@@ -0,0 +1,72 @@
+package credentials
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/Kuadrant/mcp-gateway/internal/config"
+)
+
+// countingProvider returns the current value and counts lookups
+type countingProvider struct {
+	value string
+	err   error
+	calls int
+}
+
+func (p *countingProvider) Credential(_ context.Context, _ config.CredentialReference) (string, error) {
+	p.calls++
+	return p.value, p.err
+}
+
+func TestCache(t *testing.T) {
+	ctx := context.Background()
+	ref := config.CredentialReference{Namespace: "team-a", Name: "creds", Key: "token"}
+	provider := &countingProvider{value: "Bearer one"}
+	now := time.Unix(1000, 0)
+	cache := NewCache(provider, time.Minute)
+	cache.now = func() time.Time { return now }
+
+	value, err := cache.Credential(ctx, ref)
+	require.NoError(t, err)
+	require.Equal(t, "Bearer one", value)
+
+	// within the ttl the cached value is reused
+	provider.value = "Bearer two"
+	value, err = cache.Credential(ctx, ref)
+	require.NoError(t, err)
+	require.Equal(t, "Bearer one", value)
+	require.Equal(t, 1, provider.calls)
+
+	// once the ttl passes a rotated value is read
+	now = now.Add(time.Minute)
+	value, err = cache.Credential(ctx, ref)
+	require.NoError(t, err)
+	require.Equal(t, "Bearer two", value)
+	require.Equal(t, 2, provider.calls)
+
+	// invalidating forces a read before the ttl
+	provider.value = "Bearer three"
+	cache.Invalidate(ref)
+	value, err = cache.Credential(ctx, ref)
+	require.NoError(t, err)
+	require.Equal(t, "Bearer three", value)
+
+	// errors are not cached
+	other := config.CredentialReference{Namespace: "team-b", Name: "creds", Key: "token"}
+	provider.err = ErrNotFound
+	_, err = cache.Credential(ctx, other)
+	require.True(t, errors.Is(err, ErrNotFound))
+	provider.err = nil
+	value, err = cache.Credential(ctx, other)
+	require.NoError(t, err)
+	require.Equal(t, "Bearer three", value)
+}
+
+func TestNewCache_DefaultTTL(t *testing.T) {
+	require.Equal(t, DefaultCacheTTL, NewCache(&countingProvider{}, 0).ttl)
+}
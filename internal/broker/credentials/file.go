@@ -0,0 +1,39 @@
+package credentials
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/Kuadrant/mcp-gateway/internal/config"
+)
+
+// FileProvider reads credentials from Secrets mounted as volumes. Each
+// referenced Secret is expected at Dir/<namespace>/<name>, so a key resolves
+// to the file Dir/<namespace>/<name>/<key>. References without a namespace
+// resolve to Dir/<name>/<key>. The kubelet updates mounted files when the
+// Secret changes, so every read sees the current value.
+type FileProvider struct {
+	Dir string
+}
+
+var _ CredentialProvider = &FileProvider{}
+
+// Credential implements CredentialProvider
+func (p *FileProvider) Credential(_ context.Context, ref config.CredentialReference) (string, error) {
+	if err := validateRef(ref); err != nil {
+		return "", err
+	}
+	path := filepath.Join(p.Dir, ref.Namespace, ref.Name, ref.Key)
+	data, err := os.ReadFile(path) //nolint:gosec // path parts are validated above
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return "", fmt.Errorf("%w: %s", ErrNotFound, ref)
+		}
+		return "", fmt.Errorf("reading credential %s: %w", ref, err)
+	}
+	return string(data), nil
+}
@@ -0,0 +1,93 @@
+package credentials
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/Kuadrant/mcp-gateway/internal/config"
+)
+
+func TestFileProvider(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "team-a", "creds"), 0o750))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "team-a", "creds", "token"), []byte("Bearer team-a"), 0o600))
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "local"), 0o750))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "local", "token"), []byte("Bearer local"), 0o600))
+	provider := &FileProvider{Dir: dir}
+
+	tests := []struct {
+		name        string
+		ref         config.CredentialReference
+		expectValue string
+		expectErr   string
+		notFound    bool
+	}{
+		{
+			name:        "namespaced reference",
+			ref:         config.CredentialReference{Namespace: "team-a", Name: "creds", Key: "token"},
+			expectValue: "Bearer team-a",
+		},
+		{
+			name:        "reference without namespace",
+			ref:         config.CredentialReference{Name: "local", Key: "token"},
+			expectValue: "Bearer local",
+		},
+		{
+			name:     "missing key",
+			ref:      config.CredentialReference{Namespace: "team-a", Name: "creds", Key: "other"},
+			notFound: true,
+		},
+		{
+			name:      "path traversal in name",
+			ref:       config.CredentialReference{Namespace: "team-a", Name: "../team-b", Key: "token"},
+			expectErr: "invalid credential reference",
+		},
+		{
+			name:      "parent directory as namespace",
+			ref:       config.CredentialReference{Namespace: "..", Name: "creds", Key: "token"},
+			expectErr: "invalid credential reference",
+		},
+		{
+			name:      "missing key name",
+			ref:       config.CredentialReference{Namespace: "team-a", Name: "creds"},
+			expectErr: "needs a name and a key",
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			value, err := provider.Credential(context.Background(), tc.ref)
+			switch {
+			case tc.notFound:
+				require.True(t, errors.Is(err, ErrNotFound), "expected ErrNotFound, got %v", err)
+			case tc.expectErr != "":
+				require.ErrorContains(t, err, tc.expectErr)
+			default:
+				require.NoError(t, err)
+				require.Equal(t, tc.expectValue, value)
+			}
+		})
+	}
+}
+
+func TestFileProvider_ReadsRotatedValue(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "team-a", "creds", "token")
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o750))
+	require.NoError(t, os.WriteFile(path, []byte("Bearer one"), 0o600))
+	provider := &FileProvider{Dir: dir}
+	ref := config.CredentialReference{Namespace: "team-a", Name: "creds", Key: "token"}
+
+	value, err := provider.Credential(context.Background(), ref)
+	require.NoError(t, err)
+	require.Equal(t, "Bearer one", value)
+
+	require.NoError(t, os.WriteFile(path, []byte("Bearer two"), 0o600))
+	value, err = provider.Credential(context.Background(), ref)
+	require.NoError(t, err)
+	require.Equal(t, "Bearer two", value)
+}
@@ -0,0 +1,64 @@
+package credentials
+
+import (
+	"context"
+	"fmt"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+
+	"github.com/Kuadrant/mcp-gateway/internal/config"
+)
+
+// KubernetesProvider reads credentials from Secrets through the Kubernetes
+// API. The broker's service account needs get on each referenced Secret;
+// the controller grants it per MCPServerRegistration. References without a
+// namespace resolve in DefaultNamespace.
+type KubernetesProvider struct {
+	Client           kubernetes.Interface
+	DefaultNamespace string
+}
+
+var _ CredentialProvider = &KubernetesProvider{}
+
+// NewInClusterKubernetesProvider builds a KubernetesProvider using the pod's
+// service account credentials
+func NewInClusterKubernetesProvider(defaultNamespace string) (*KubernetesProvider, error) {
+	cfg, err := rest.InClusterConfig()
+	if err != nil {
+		return nil, fmt.Errorf("loading in-cluster config: %w", err)
+	}
+	client, err := kubernetes.NewForConfig(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("building kubernetes client: %w", err)
+	}
+	return &KubernetesProvider{Client: client, DefaultNamespace: defaultNamespace}, nil
+}
+
+// Credential implements CredentialProvider
+func (p *KubernetesProvider) Credential(ctx context.Context, ref config.CredentialReference) (string, error) {
+	if err := validateRef(ref); err != nil {
+		return "", err
+	}
+	namespace := ref.Namespace
+	if namespace == "" {
+		namespace = p.DefaultNamespace
+	}
+	secret, err := p.Client.CoreV1().Secrets(namespace).Get(ctx, ref.Name, metav1.GetOptions{})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return "", fmt.Errorf("%w: %s", ErrNotFound, ref)
+		}
+		return "", fmt.Errorf("getting credential secret %s: %w", ref, err)
+	}
+	if secret.Labels[ManagedSecretLabel] != "true" {
+		return "", fmt.Errorf("credential secret %s is missing required label %s=true", ref, ManagedSecretLabel)
+	}
+	value, ok := secret.Data[ref.Key]
+	if !ok {
+		return "", fmt.Errorf("%w: %s", ErrNotFound, ref)
+	}
+	return string(value), nil
+}
@@ -0,0 +1,88 @@
+package credentials
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/Kuadrant/mcp-gateway/internal/config"
+)
+
+func TestKubernetesProvider(t *testing.T) {
+	client := fake.NewClientset(
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "creds",
+				Namespace: "team-a",
+				Labels:    map[string]string{ManagedSecretLabel: "true"},
+			},
+			Data: map[string][]byte{"token": []byte("Bearer team-a")},
+		},
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "creds",
+				Namespace: "mcp-system",
+				Labels:    map[string]string{ManagedSecretLabel: "true"},
+			},
+			Data: map[string][]byte{"token": []byte("Bearer local")},
+		},
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "unlabelled", Namespace: "team-a"},
+			Data:       map[string][]byte{"token": []byte("Bearer hidden")},
+		},
+	)
+	provider := &KubernetesProvider{Client: client, DefaultNamespace: "mcp-system"}
+
+	tests := []struct {
+		name        string
+		ref         config.CredentialReference
+		expectValue string
+		expectErr   string
+		notFound    bool
+	}{
+		{
+			name:        "namespaced reference",
+			ref:         config.CredentialReference{Namespace: "team-a", Name: "creds", Key: "token"},
+			expectValue: "Bearer team-a",
+		},
+		{
+			name:        "reference without namespace uses the default",
+			ref:         config.CredentialReference{Name: "creds", Key: "token"},
+			expectValue: "Bearer local",
+		},
+		{
+			name:     "missing secret",
+			ref:      config.CredentialReference{Namespace: "team-b", Name: "creds", Key: "token"},
+			notFound: true,
+		},
+		{
+			name:     "missing key",
+			ref:      config.CredentialReference{Namespace: "team-a", Name: "creds", Key: "other"},
+			notFound: true,
+		},
+		{
+			name:      "secret without the managed label",
+			ref:       config.CredentialReference{Namespace: "team-a", Name: "unlabelled", Key: "token"},
+			expectErr: "missing required label",
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			value, err := provider.Credential(context.Background(), tc.ref)
+			switch {
+			case tc.notFound:
+				require.True(t, errors.Is(err, ErrNotFound), "expected ErrNotFound, got %v", err)
+			case tc.expectErr != "":
+				require.ErrorContains(t, err, tc.expectErr)
+			default:
+				require.NoError(t, err)
+				require.Equal(t, tc.expectValue, value)
+			}
+		})
+	}
+}
@@ -0,0 +1,38 @@
+// Package credentials resolves upstream credential references at use time,
+// so the broker config only ever carries a pointer to each credential.
+package credentials
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/Kuadrant/mcp-gateway/internal/config"
+)
+
+// ManagedSecretLabel must be set to "true" on a Secret before a provider
+// will hand out its data, matching the check the controller applies
+const ManagedSecretLabel = "mcp.kuadrant.io/secret" //nolint:gosec // not a credential, just a label name
+
+// ErrNotFound is returned when the referenced credential does not exist
+var ErrNotFound = errors.New("credential not found")
+
+// CredentialProvider resolves a credential reference to its current value
+type CredentialProvider interface {
+	Credential(ctx context.Context, ref config.CredentialReference) (string, error)
+}
+
+// validateRef rejects references whose parts could escape the location a
+// provider reads from, for example a file path or a URL path segment
+func validateRef(ref config.CredentialReference) error {
+	if ref.Name == "" || ref.Key == "" {
+		return fmt.Errorf("credential reference %s needs a name and a key", ref)
+	}
+	for _, value := range []string{ref.Namespace, ref.Name, ref.Key} {
+		if value == "." || value == ".." || strings.ContainsAny(value, `/\`) {
+			return fmt.Errorf("invalid credential reference %s", ref)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+package credentials
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/Kuadrant/mcp-gateway/internal/config"
+)
+
+// DefaultVaultMount is the KV v2 secrets engine mount used when none is set
+const DefaultVaultMount = "secret"
+
+// VaultProvider reads credentials from a Vault KV v2 secrets engine. It is
+// a minimal stand-in for a full Vault integration: it authenticates with a
+// static token and reads the secret at <mount>/data/<namespace>/<name>,
+// returning the field named by the reference key.
+type VaultProvider struct {
+	Address string
+	Token   string
+	Mount   string
+	Client  *http.Client
+}
+
+var _ CredentialProvider = &VaultProvider{}
+
+// kvV2Response is the subset of a KV v2 read response the provider uses
+type kvV2Response struct {
+	Data struct {
+		Data map[string]any `json:"data"`
+	} `json:"data"`
+}
+
+// Credential implements CredentialProvider
+func (p *VaultProvider) Credential(ctx context.Context, ref config.CredentialReference) (string, error) {
+	if err := validateRef(ref); err != nil {
+		return "", err
+	}
+	mount := p.Mount
+	if mount == "" {
+		mount = DefaultVaultMount
+	}
+	segments := []string{"v1", strings.Trim(mount, "/"), "data"}
+	if ref.Namespace != "" {
+		segments = append(segments, url.PathEscape(ref.Namespace))
+	}
+	segments = append(segments, url.PathEscape(ref.Name))
+	endpoint := strings.TrimSuffix(p.Address, "/") + "/" + strings.Join(segments, "/")
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return "", fmt.Errorf("building vault request for %s: %w", ref, err)
+	}
+	req.Header.Set("X-Vault-Token", p.Token)
+	client := p.Client
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("reading credential %s from vault: %w", ref, err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode == http.StatusNotFound {
+		return "", fmt.Errorf("%w: %s", ErrNotFound, ref)
+	}
+	if resp.StatusCode != http.StatusOK {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		return "", fmt.Errorf("reading credential %s from vault: unexpected status %d", ref, resp.StatusCode)
+	}
+	var body kvV2Response
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return "", fmt.Errorf("decoding vault response for %s: %w", ref, err)
+	}
+	value, ok := body.Data.Data[ref.Key].(string)
+	if !ok {
+		return "", fmt.Errorf("%w: %s", ErrNotFound, ref)
+	}
+	return value, nil
+}
@@ -0,0 +1,95 @@
+package credentials
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/Kuadrant/mcp-gateway/internal/config"
+)
+
+func TestVaultProvider(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != "root" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		secrets := map[string]map[string]any{
+			"/v1/kv/data/team-a/creds": {"token": "Bearer team-a", "count": 1},
+			"/v1/kv/data/creds":        {"token": "Bearer local"},
+		}
+		data, ok := secrets[r.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"data": map[string]any{"data": data}})
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name        string
+		token       string
+		ref         config.CredentialReference
+		expectValue string
+		expectErr   string
+		notFound    bool
+	}{
+		{
+			name:        "namespaced reference",
+			token:       "root",
+			ref:         config.CredentialReference{Namespace: "team-a", Name: "creds", Key: "token"},
+			expectValue: "Bearer team-a",
+		},
+		{
+			name:        "reference without namespace",
+			token:       "root",
+			ref:         config.CredentialReference{Name: "creds", Key: "token"},
+			expectValue: "Bearer local",
+		},
+		{
+			name:     "missing secret",
+			token:    "root",
+			ref:      config.CredentialReference{Namespace: "team-b", Name: "creds", Key: "token"},
+			notFound: true,
+		},
+		{
+			name:     "missing field",
+			token:    "root",
+			ref:      config.CredentialReference{Namespace: "team-a", Name: "creds", Key: "other"},
+			notFound: true,
+		},
+		{
+			name:     "non string field",
+			token:    "root",
+			ref:      config.CredentialReference{Namespace: "team-a", Name: "creds", Key: "count"},
+			notFound: true,
+		},
+		{
+			name:      "rejected token",
+			token:     "wrong",
+			ref:       config.CredentialReference{Namespace: "team-a", Name: "creds", Key: "token"},
+			expectErr: "unexpected status 403",
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			provider := &VaultProvider{Address: server.URL + "/", Token: tc.token, Mount: "kv", Client: server.Client()}
+			value, err := provider.Credential(context.Background(), tc.ref)
+			switch {
+			case tc.notFound:
+				require.True(t, errors.Is(err, ErrNotFound), "expected ErrNotFound, got %v", err)
+			case tc.expectErr != "":
+				require.ErrorContains(t, err, tc.expectErr)
+			default:
+				require.NoError(t, err)
+				require.Equal(t, tc.expectValue, value)
+			}
+		})
+	}
+}
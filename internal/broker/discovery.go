@@ -34,7 +34,7 @@ const (
 	gatewayInstructions = `This is an MCP Gateway that aggregates tools from multiple backend MCP servers into a single endpoint. The full tool set may be large.
 
 To avoid loading all tool schemas upfront, use the discovery tools:
-1. Call discover_tools to browse available servers, categories, and tool names (lightweight, no full schemas).
+1. Call discover_tools with a query describing your task to get the most relevant tools, or without one to browse available servers, categories, and tool names (lightweight, no full schemas).
 2. Call select_tools with the tool names relevant to your task. This sends a notifications/tools/list_changed notification. The filtered tool set is available on the next tools/list call, not in the current turn.
 3. To change scope, call select_tools again with a new set. Pass an empty list to reset to the full tool set.`
 )
@@ -64,11 +64,22 @@ func IsBrokerTool(tool *mcp.Tool) bool {
 type discoveryConfig struct {
 	enabled   bool
 	threshold int
+	budget    contextBudget
 }
 
 // discoverToolsResponse is the response from discover_tools
 type discoverToolsResponse struct {
-	Servers []serverInfo `json:"servers"`
+	Servers  []serverInfo `json:"servers"`
+	SizeUnit BudgetUnit   `json:"sizeUnit"`
+	Budget   int64        `json:"budget,omitempty"`
+}
+
+// discoverToolsSearchResponse is the response from discover_tools when a query is given
+type discoverToolsSearchResponse struct {
+	Query    string      `json:"query"`
+	Tools    []toolMatch `json:"tools"`
+	SizeUnit BudgetUnit  `json:"sizeUnit"`
+	Budget   int64       `json:"budget,omitempty"`
 }
 
 type serverInfo struct {
@@ -76,6 +87,11 @@ type serverInfo struct {
 	Categories []string `json:"categories"`
 	Hint       string   `json:"hint,omitempty"`
 	Tools      []string `json:"tools"`
+	// ToolSizes is the size of each tool's schema in the response's sizeUnit
+	ToolSizes map[string]int64 `json:"toolSizes"`
+	// Maintenance is set while the server is in the Maintenance state; calls
+	// to its tools fail while the window is active
+	Maintenance *upstream.MaintenanceStatus `json:"maintenance,omitempty"`
 }
 
 // headersFromRequest safely extracts HTTP headers from a CallToolRequest
@@ -102,7 +118,7 @@ func unmarshalArguments(req *mcp.CallToolRequest) (map[string]any, error) {
 func (m *mcpBrokerImpl) registerDiscoveryTools() {
 	discoverTool := mcp.Tool{
 		Name:        discoverToolsName,
-		Description: "Browse available servers and tools. Returns server names, categories, hints, and tool names without full schemas. Use the optional category parameter to filter by category.",
+		Description: "Browse available servers and tools. Returns server names, categories, hints, and tool names without full schemas. Use the optional category parameter to filter by category. Pass a query to instead get the tools most relevant to a task, ranked, with short descriptions. Each tool's schema size is reported so selections can stay within the gateway's context budget.",
 		InputSchema: map[string]any{
 			"type": "object",
 			"properties": map[string]any{
@@ -110,6 +126,16 @@ func (m *mcpBrokerImpl) registerDiscoveryTools() {
 					"type":        "string",
 					"description": "Filter servers by category (case-insensitive match against any element in the server's category list)",
 				},
+				"query": map[string]any{
+					"type":        "string",
+					"description": "Keywords describing the task. Returns the most relevant tools ranked by name, description, server hint, categories and tags",
+				},
+				"limit": map[string]any{
+					"type":        "integer",
+					"description": fmt.Sprintf("Maximum number of tools returned for a query (default %d, max %d)", defaultSearchLimit, maxSearchLimit),
+					"minimum":     1,
+					"maximum":     maxSearchLimit,
+				},
 			},
 		},
 	}
@@ -120,7 +146,7 @@ func (m *mcpBrokerImpl) registerDiscoveryTools() {
 
 	selectTool := mcp.Tool{
 		Name:        selectToolsName,
-		Description: "Scope your session to a specific set of tools. After calling this, subsequent tools/list calls will return only the selected tools with full schemas. Pass an empty list to reset to the full tool set.",
+		Description: "Scope your session to a specific set of tools. After calling this, subsequent tools/list calls will return only the selected tools with full schemas. Pass an empty list to reset to the full tool set. Selections larger than the gateway's context budget are rejected.",
 		InputSchema: map[string]any{
 			"type": "object",
 			"properties": map[string]any{
@@ -156,16 +182,44 @@ func (m *mcpBrokerImpl) handleDiscoverTools(ctx context.Context, req *mcp.CallTo
 		return upstream.NewToolResultError("invalid arguments"), nil
 	}
 	categoryFilter, _ := args["category"].(string)
+	query, _ := args["query"].(string)
+	query = strings.TrimSpace(query)
+	limit, err := parseSearchLimit(args["limit"])
+	if err != nil {
+		return upstream.NewToolResultError(err.Error()), nil
+	}
 
 	if span.IsRecording() {
 		span.SetAttributes(
 			attribute.String("discovery.tool", discoverToolsName),
 			attribute.String("discovery.category_filter", categoryFilter),
+			attribute.Bool("discovery.query", query != ""),
 		)
 	}
 
 	headers := headersFromRequest(req)
 
+	if query != "" {
+		m.mcpLock.RLock()
+		visible := m.getVisibleToolNames(headers)
+		inMaintenance := m.serversInMaintenance(time.Now())
+		m.mcpLock.RUnlock()
+		tools := m.toolIndex.Load().search(query, limit, visible, categoryFilter)
+		for i := range tools {
+			tools[i].Size = m.toolCostByName(tools[i].Name)
+			_, tools[i].Maintenance = inMaintenance[tools[i].Server]
+		}
+		if span.IsRecording() {
+			span.SetAttributes(attribute.Int("discovery.tools_returned", len(tools)))
+		}
+		return m.marshalToolResult(discoverToolsSearchResponse{
+			Query:    query,
+			Tools:    tools,
+			SizeUnit: m.discovery.budget.sizeUnit(),
+			Budget:   m.discovery.budget.limit,
+		}), nil
+	}
+
 	m.mcpLock.RLock()
 	resp := m.buildDiscoverResponse(headers, categoryFilter)
 	m.mcpLock.RUnlock()
@@ -185,7 +239,12 @@ func (m *mcpBrokerImpl) handleDiscoverTools(ctx context.Context, req *mcp.CallTo
 // caller must hold mcpLock.
 func (m *mcpBrokerImpl) buildDiscoverResponse(headers http.Header, categoryFilter string) discoverToolsResponse {
 	visible := m.getVisibleToolNames(headers)
-	resp := discoverToolsResponse{Servers: []serverInfo{}}
+	now := time.Now()
+	resp := discoverToolsResponse{
+		Servers:  []serverInfo{},
+		SizeUnit: m.discovery.budget.sizeUnit(),
+		Budget:   m.discovery.budget.limit,
+	}
 
 	for _, manager := range m.mcpServers {
 		cfg := manager.Config()
@@ -203,16 +262,35 @@ func (m *mcpBrokerImpl) buildDiscoverResponse(headers http.Header, categoryFilte
 			categories = []string{"uncategorised"}
 		}
 
+		toolSizes := make(map[string]int64, len(toolNames))
+		for _, name := range toolNames {
+			toolSizes[name] = m.toolCostByName(name)
+		}
+
 		resp.Servers = append(resp.Servers, serverInfo{
-			Name:       cfg.Name,
-			Categories: categories,
-			Hint:       cfg.Hint,
-			Tools:      toolNames,
+			Name:        cfg.Name,
+			Categories:  categories,
+			Hint:        cfg.Hint,
+			Tools:       toolNames,
+			ToolSizes:   toolSizes,
+			Maintenance: maintenanceStatus(cfg, now),
 		})
 	}
 	return resp
 }
 
+// serversInMaintenance returns the names of servers whose maintenance window
+// is open. caller must hold mcpLock.
+func (m *mcpBrokerImpl) serversInMaintenance(now time.Time) map[string]struct{} {
+	names := map[string]struct{}{}
+	for _, manager := range m.mcpServers {
+		if cfg := manager.Config(); cfg.InMaintenance(now) {
+			names[cfg.Name] = struct{}{}
+		}
+	}
+	return names
+}
+
 // visibleToolNames returns the prefixed names of tools on a server that are in the visible set.
 func (m *mcpBrokerImpl) visibleToolNames(prefix string, manager upstream.ActiveMCPServer, visible map[string]struct{}) []string {
 	var names []string
@@ -271,8 +349,12 @@ func (m *mcpBrokerImpl) handleSelectTools(ctx context.Context, req *mcp.CallTool
 	}
 
 	m.mcpLock.RLock()
-	valErr := m.validateToolSelectionLocked(toolNames, headers, sessionID)
+	selected, valErr := m.validateToolSelectionLocked(toolNames, headers, sessionID)
+	var budgetErr error
 	if valErr == nil {
+		budgetErr = m.checkSelectionBudget(selected)
+	}
+	if valErr == nil && budgetErr == nil {
 		m.scopeStore.setScope(sessionID, toolNames)
 	}
 	m.mcpLock.RUnlock()
@@ -280,6 +362,13 @@ func (m *mcpBrokerImpl) handleSelectTools(ctx context.Context, req *mcp.CallTool
 	if valErr != nil {
 		return upstream.NewToolResultError("tool not available"), nil
 	}
+	// only visible tools reach the budget check, so naming them leaks nothing
+	if budgetErr != nil {
+		if span.IsRecording() {
+			span.SetAttributes(attribute.Bool("discovery.budget_exceeded", true))
+		}
+		return upstream.NewToolResultError(budgetErr.Error()), nil
+	}
 
 	m.sendToolsListChanged(sessionID)
 
@@ -291,22 +380,28 @@ func (m *mcpBrokerImpl) handleSelectTools(ctx context.Context, req *mcp.CallTool
 	return m.marshalToolResult(m.selectResponse(status, toolNames)), nil
 }
 
-// validateToolSelectionLocked checks that every requested tool is visible and not a broker meta-tool.
-// caller must hold mcpLock.
-func (m *mcpBrokerImpl) validateToolSelectionLocked(toolNames []string, headers http.Header, sessionID string) error {
-	visible := m.getVisibleToolNames(headers)
+// validateToolSelectionLocked checks that every requested tool is visible and not a broker meta-tool,
+// returning the selected tools. caller must hold mcpLock.
+func (m *mcpBrokerImpl) validateToolSelectionLocked(toolNames []string, headers http.Header, sessionID string) ([]*mcp.Tool, error) {
+	visible := make(map[string]*mcp.Tool)
+	for _, t := range m.getVisibleTools(headers) {
+		visible[t.Name] = t
+	}
 
+	selected := make([]*mcp.Tool, 0, len(toolNames))
 	for _, name := range toolNames {
 		if isBrokerToolName(name) {
 			m.logger.Debug("select_tools: broker tool requested", "tool", name)
-			return fmt.Errorf("broker tool: %s", name)
+			return nil, fmt.Errorf("broker tool: %s", name)
 		}
-		if _, ok := visible[name]; !ok {
+		tool, ok := visible[name]
+		if !ok {
 			m.logger.Debug("select_tools: tool not available", "tool", name, "session", internaljwt.LogSafeSessionID(sessionID))
-			return fmt.Errorf("not visible: %s", name)
+			return nil, fmt.Errorf("not visible: %s", name)
 		}
+		selected = append(selected, tool)
 	}
-	return nil
+	return selected, nil
 }
 
 // selectResponse builds the map payload for a select_tools result.
@@ -327,14 +422,19 @@ func (m *mcpBrokerImpl) sendToolsListChanged(sessionID string) {
 	m.gatewayServer.TriggerToolsListChanged(sessionID)
 }
 
-// getVisibleToolNames returns a set of tool names visible to the current request,
-// after applying protocol version, auth and virtual server filtering.
-func (m *mcpBrokerImpl) getVisibleToolNames(headers http.Header) map[string]struct{} {
+// getVisibleTools returns the tools visible to the current request, after
+// applying protocol version, auth and virtual server filtering.
+func (m *mcpBrokerImpl) getVisibleTools(headers http.Header) []*mcp.Tool {
 	isStateless := isStatelessProtocol(headers)
 	tools := m.toolsForProtocol(isStateless)
 	tools = m.applyAuthorizedCapabilitiesFilter(headers, tools)
-	tools = m.applyVirtualServerFilter(headers, tools)
+	return m.applyVirtualServerFilter(headers, tools)
+}
 
+// getVisibleToolNames returns a set of tool names visible to the current request,
+// after applying protocol version, auth and virtual server filtering.
+func (m *mcpBrokerImpl) getVisibleToolNames(headers http.Header) map[string]struct{} {
+	tools := m.getVisibleTools(headers)
 	visible := make(map[string]struct{}, len(tools))
 	for _, t := range tools {
 		visible[t.Name] = struct{}{}
@@ -379,28 +479,37 @@ func filterByScope(tools []*mcp.Tool, scope map[string]struct{}) []*mcp.Tool {
 	})
 }
 
-// applyThresholdFilter hides real tools when count exceeds the threshold, leaving only meta-tools.
+// applyThresholdFilter hides real tools when their count exceeds the threshold, or their
+// combined size exceeds the context budget, leaving only meta-tools.
 func (m *mcpBrokerImpl) applyThresholdFilter(tools []*mcp.Tool) []*mcp.Tool {
-	if m.discovery.threshold <= 0 {
+	budget := m.discovery.budget
+	if m.discovery.threshold <= 0 && !budget.enabled() {
 		return tools
 	}
 
 	metaOnly := make([]*mcp.Tool, 0, len(tools))
 	realCount := 0
+	var realCost int64
 	for _, t := range tools {
 		if IsBrokerTool(t) {
 			metaOnly = append(metaOnly, t)
-		} else {
-			realCount++
+			continue
+		}
+		realCount++
+		if budget.enabled() {
+			realCost += m.toolCost(t)
 		}
 	}
 
-	if realCount <= m.discovery.threshold {
-		return tools
+	if m.discovery.threshold > 0 && realCount > m.discovery.threshold {
+		m.logger.Debug("threshold hiding activated", "real_tools", realCount, "threshold", m.discovery.threshold)
+		return metaOnly
 	}
-
-	m.logger.Debug("threshold hiding activated", "real_tools", realCount, "threshold", m.discovery.threshold)
-	return metaOnly
+	if budget.enabled() && realCost > budget.limit {
+		m.logger.Debug("context budget hiding activated", "real_tools", realCount, "size", realCost, "budget", budget.limit, "unit", budget.sizeUnit())
+		return metaOnly
+	}
+	return tools
 }
 
 // matchesCategory checks if any element in serverCategories matches the filter (case-insensitive)
@@ -410,6 +519,19 @@ func matchesCategory(serverCategories []string, filter string) bool {
 	})
 }
 
+// parseSearchLimit validates the optional discover_tools limit argument
+func parseSearchLimit(raw any) (int, error) {
+	if raw == nil {
+		return defaultSearchLimit, nil
+	}
+	// JSON numbers decode as float64
+	f, ok := raw.(float64)
+	if !ok || f != float64(int(f)) || f < 1 || f > maxSearchLimit {
+		return 0, fmt.Errorf("limit must be an integer between 1 and %d", maxSearchLimit)
+	}
+	return int(f), nil
+}
+
 // parseToolNames extracts a []string from the raw tools argument
 func parseToolNames(raw any) ([]string, error) {
 	if raw == nil {
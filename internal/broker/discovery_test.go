@@ -7,6 +7,7 @@ import (
 	"log/slog"
 	"net/http"
 	"testing"
+	"time"
 
 	"github.com/Kuadrant/mcp-gateway/internal/broker/upstream"
 	"github.com/Kuadrant/mcp-gateway/internal/config"
@@ -222,7 +223,7 @@ func TestSelectTools_AllOrNothing(t *testing.T) {
 	populateTestVersions(b)
 
 	b.mcpLock.RLock()
-	err := b.validateToolSelectionLocked([]string{"s1_real_tool", "s1_nonexistent"}, http.Header{}, "test-session-1")
+	_, err := b.validateToolSelectionLocked([]string{"s1_real_tool", "s1_nonexistent"}, http.Header{}, "test-session-1")
 	b.mcpLock.RUnlock()
 	require.Error(t, err, "should fail because s1_nonexistent doesn't exist")
 
@@ -334,7 +335,7 @@ func TestSelectTools_BrokerToolsNotSelectable(t *testing.T) {
 	b := NewBroker(logger, WithDiscoveryToolsEnabled(true)).(*mcpBrokerImpl)
 
 	b.mcpLock.RLock()
-	err := b.validateToolSelectionLocked([]string{"discover_tools"}, http.Header{}, "s1")
+	_, err := b.validateToolSelectionLocked([]string{"discover_tools"}, http.Header{}, "s1")
 	b.mcpLock.RUnlock()
 	require.Error(t, err)
 }
@@ -462,9 +463,11 @@ func TestSelectTools_ValidationSucceedsForValidTool(t *testing.T) {
 	populateTestVersions(b)
 
 	b.mcpLock.RLock()
-	err := b.validateToolSelectionLocked([]string{"s1_tool_a"}, http.Header{}, "validate-session")
+	selected, err := b.validateToolSelectionLocked([]string{"s1_tool_a"}, http.Header{}, "validate-session")
 	b.mcpLock.RUnlock()
 	require.NoError(t, err)
+	require.Len(t, selected, 1)
+	require.Equal(t, "s1_tool_a", selected[0].Name)
 }
 
 func TestScopeStore_CleanupOnDisconnect(t *testing.T) {
@@ -505,3 +508,143 @@ func TestDiscoveryDisabled_NoScopeStore(t *testing.T) {
 	_, hasDiscover := tools[discoverToolsName]
 	require.False(t, hasDiscover, "discover_tools should not be registered when disabled")
 }
+
+func TestDiscoverTools_Query(t *testing.T) {
+	b := NewBroker(logger, WithDiscoveryToolsEnabled(true)).(*mcpBrokerImpl)
+
+	b.mcpServers["s1"] = createTestManagerWithMeta(t,
+		"weather-service", "weather_",
+		[]mcp.Tool{
+			{Name: "forecast", Description: "Multi-day forecast for a city. Includes highs and lows."},
+			{Name: "current", Description: "Current conditions."},
+		},
+		[]string{"Weather"}, "weather data from OpenWeather",
+	)
+	b.mcpServers["s2"] = createTestManagerWithMeta(t,
+		"calendar-service", "cal_",
+		[]mcp.Tool{{Name: "book_meeting", Description: "Book a meeting with attendees."}},
+		[]string{"Scheduling"}, "",
+	)
+	populateTestVersions(b)
+
+	call := func(args map[string]any) *mcp.CallToolResult {
+		t.Helper()
+		req := &mcp.CallToolRequest{
+			Params: &mcp.CallToolParamsRaw{
+				Arguments: mustMarshalArgs(args),
+			},
+			Extra: &mcp.RequestExtra{Header: http.Header{}},
+		}
+		result, err := b.handleDiscoverTools(context.Background(), req)
+		require.NoError(t, err)
+		return result
+	}
+
+	result := call(map[string]any{"query": "  forecast  "})
+	require.False(t, result.IsError)
+	var resp discoverToolsSearchResponse
+	require.NoError(t, json.Unmarshal([]byte(result.Content[0].(*mcp.TextContent).Text), &resp))
+	require.Equal(t, "forecast", resp.Query)
+	require.NotEmpty(t, resp.Tools)
+	require.Equal(t, "weather_forecast", resp.Tools[0].Name)
+	require.Equal(t, "weather-service", resp.Tools[0].Server)
+	require.Equal(t, "Multi-day forecast for a city.", resp.Tools[0].Description)
+
+	result = call(map[string]any{"query": "weather", "limit": 1})
+	require.False(t, result.IsError)
+	resp = discoverToolsSearchResponse{}
+	require.NoError(t, json.Unmarshal([]byte(result.Content[0].(*mcp.TextContent).Text), &resp))
+	require.Len(t, resp.Tools, 1)
+
+	result = call(map[string]any{"query": "weather meeting", "category": "scheduling"})
+	resp = discoverToolsSearchResponse{}
+	require.NoError(t, json.Unmarshal([]byte(result.Content[0].(*mcp.TextContent).Text), &resp))
+	require.Len(t, resp.Tools, 1)
+	require.Equal(t, "cal_book_meeting", resp.Tools[0].Name)
+
+	result = call(map[string]any{"query": "weather", "limit": 0})
+	require.True(t, result.IsError)
+}
+
+func TestDiscoverTools_QueryIndexFollowsToolChanges(t *testing.T) {
+	b := NewBroker(logger, WithDiscoveryToolsEnabled(true)).(*mcpBrokerImpl)
+
+	b.mcpServers["s1"] = createTestManagerWithMeta(t,
+		"svc1", "s1_",
+		[]mcp.Tool{{Name: "lookup"}},
+		[]string{"Search"}, "",
+	)
+	populateTestVersions(b)
+	require.Len(t, b.toolIndex.Load().search("lookup", defaultSearchLimit, map[string]struct{}{"s1_lookup": {}}, ""), 1)
+
+	b.gatewayServer.DeleteTools("s1_lookup")
+	b.rebuildProtocolCaches()
+	require.Empty(t, b.toolIndex.Load().search("lookup", defaultSearchLimit, map[string]struct{}{"s1_lookup": {}}, ""))
+}
+
+func createMaintenanceManager(t *testing.T, serverName, prefix string, tools []mcp.Tool, window *config.MaintenanceConfig) upstream.ActiveMCPServer {
+	t.Helper()
+	mcpServer := upstream.NewUpstreamMCP(&config.MCPServer{
+		Name:        serverName,
+		Prefix:      prefix,
+		URL:         "http://test.local/mcp",
+		State:       config.StateMaintenance,
+		Maintenance: window,
+	}, "", nil)
+	manager, err := upstream.NewUpstreamMCPManager(mcpServer, newMockGateway(), nil, slog.Default(), 0, upstream.InvalidToolPolicyFilterOut)
+	require.NoError(t, err)
+	manager.SetToolsForTesting(tools)
+	manager.SetStatusForTesting(upstream.ServerValidationStatus{Name: serverName, Ready: true})
+	return upstream.NewActiveForTesting(manager)
+}
+
+func TestDiscoverTools_Maintenance(t *testing.T) {
+	b := NewBroker(logger, WithDiscoveryToolsEnabled(true)).(*mcpBrokerImpl)
+
+	end := time.Now().Add(time.Hour).UTC().Truncate(time.Second)
+	b.mcpServers["s1"] = createMaintenanceManager(t, "weather-service", "weather_",
+		[]mcp.Tool{{Name: "forecast", Description: "Multi-day forecast."}},
+		&config.MaintenanceConfig{End: &end, Message: "upgrading the forecast model"},
+	)
+	b.mcpServers["s2"] = createTestManagerWithMeta(t,
+		"calendar-service", "cal_",
+		[]mcp.Tool{{Name: "forecast_meetings", Description: "Forecast meeting load."}},
+		nil, "",
+	)
+	populateTestVersions(b)
+
+	call := func(args map[string]any) string {
+		t.Helper()
+		req := &mcp.CallToolRequest{
+			Params: &mcp.CallToolParamsRaw{Arguments: mustMarshalArgs(args)},
+			Extra:  &mcp.RequestExtra{Header: http.Header{}},
+		}
+		result, err := b.handleDiscoverTools(context.Background(), req)
+		require.NoError(t, err)
+		require.False(t, result.IsError)
+		return result.Content[0].(*mcp.TextContent).Text
+	}
+
+	// tools of a server under maintenance stay listed, with the server marked
+	var resp discoverToolsResponse
+	require.NoError(t, json.Unmarshal([]byte(call(map[string]any{})), &resp))
+	require.Len(t, resp.Servers, 2)
+	for _, server := range resp.Servers {
+		if server.Name != "weather-service" {
+			require.Nil(t, server.Maintenance)
+			continue
+		}
+		require.Equal(t, []string{"weather_forecast"}, server.Tools)
+		require.NotNil(t, server.Maintenance)
+		require.True(t, server.Maintenance.Active)
+		require.Equal(t, "upgrading the forecast model", server.Maintenance.Message)
+		require.True(t, end.Equal(*server.Maintenance.End))
+	}
+
+	var search discoverToolsSearchResponse
+	require.NoError(t, json.Unmarshal([]byte(call(map[string]any{"query": "forecast"})), &search))
+	require.Len(t, search.Tools, 2)
+	for _, tool := range search.Tools {
+		require.Equal(t, tool.Server == "weather-service", tool.Maintenance, tool.Name)
+	}
+}
@@ -0,0 +1,296 @@
+package broker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"time"
+
+	"github.com/Kuadrant/mcp-gateway/internal/config"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// drainSyncInterval is how often a replica reloads the shared drains
+const drainSyncInterval = 5 * time.Second
+
+// serverDrain is a server hidden from clients. a zero until keeps the drain
+// in place until it is lifted.
+type serverDrain struct {
+	until time.Time
+	timer *time.Timer
+}
+
+// DrainedServer describes an active drain
+type DrainedServer struct {
+	Server string     `json:"server"`
+	Until  *time.Time `json:"until,omitempty"`
+}
+
+// errServerNotFound is returned for operations naming an unknown server
+var errServerNotFound = errors.New("server not found")
+
+// errNotDrained is returned when lifting a drain that is not in place
+var errNotDrained = errors.New("server is not drained")
+
+// drainServer hides the named server's tools, prompts and resources from
+// clients and drops its routes, without touching its configuration. The
+// drain is recorded in the session store, from which the other replicas pick
+// it up. A positive duration lifts the drain automatically; draining an
+// already drained server replaces its duration.
+func (m *mcpBrokerImpl) drainServer(ctx context.Context, name string, duration time.Duration) (DrainedServer, error) {
+	if m.findServerByName(name) == nil {
+		return DrainedServer{}, errServerNotFound
+	}
+	var until time.Time
+	if duration > 0 {
+		until = time.Now().Add(duration)
+	}
+	m.drainSyncMu.Lock()
+	defer m.drainSyncMu.Unlock()
+	if m.sessionCache != nil {
+		if err := m.sessionCache.SetDrain(ctx, name, until); err != nil {
+			return DrainedServer{}, fmt.Errorf("storing drain: %w", err)
+		}
+	}
+	m.drainMu.Lock()
+	d := m.setDrainLocked(name, until)
+	m.drainMu.Unlock()
+	m.onDrainChange()
+	return d.describe(name), nil
+}
+
+// undrainServer lifts the drain on the named server on every replica
+func (m *mcpBrokerImpl) undrainServer(ctx context.Context, name string) error {
+	m.drainSyncMu.Lock()
+	defer m.drainSyncMu.Unlock()
+	stored := false
+	if m.sessionCache != nil {
+		var err error
+		if stored, err = m.sessionCache.DeleteDrain(ctx, name); err != nil {
+			return fmt.Errorf("removing drain: %w", err)
+		}
+	}
+	m.drainMu.Lock()
+	local := m.liftDrainLocked(name)
+	m.drainMu.Unlock()
+	if !stored && !local {
+		return errNotDrained
+	}
+	if local {
+		m.onDrainChange()
+	}
+	return nil
+}
+
+// setDrainLocked puts the drain on name in place on this replica, replacing
+// any earlier one. callers hold drainMu.
+func (m *mcpBrokerImpl) setDrainLocked(name string, until time.Time) *serverDrain {
+	d := &serverDrain{until: until}
+	if !until.IsZero() {
+		d.timer = time.AfterFunc(time.Until(until), func() {
+			m.drainMu.Lock()
+			current, ok := m.drains[name]
+			if !ok || current != d {
+				// replaced or lifted meanwhile
+				m.drainMu.Unlock()
+				return
+			}
+			delete(m.drains, name)
+			m.drainMu.Unlock()
+			m.logger.Info("server drain expired", "server", name)
+			m.onDrainChange()
+		})
+	}
+	if prev, ok := m.drains[name]; ok && prev.timer != nil {
+		prev.timer.Stop()
+	}
+	if m.drains == nil {
+		m.drains = map[string]*serverDrain{}
+	}
+	m.drains[name] = d
+	return d
+}
+
+// liftDrainLocked removes the drain on name from this replica, reporting
+// whether there was one. callers hold drainMu.
+func (m *mcpBrokerImpl) liftDrainLocked(name string) bool {
+	d, ok := m.drains[name]
+	if !ok {
+		return false
+	}
+	if d.timer != nil {
+		d.timer.Stop()
+	}
+	delete(m.drains, name)
+	return true
+}
+
+// syncDrains brings this replica's drains in line with the session store, so
+// drains placed or lifted through another replica take effect here
+func (m *mcpBrokerImpl) syncDrains(ctx context.Context) {
+	if m.sessionCache == nil {
+		return
+	}
+	m.drainSyncMu.Lock()
+	defer m.drainSyncMu.Unlock()
+	stored, err := m.sessionCache.Drains(ctx)
+	if err != nil {
+		m.logger.Warn("failed to load shared drains", "error", err)
+		return
+	}
+	changed := false
+	m.drainMu.Lock()
+	for name, until := range stored {
+		if d, ok := m.drains[name]; ok && d.until.Equal(until) {
+			continue
+		}
+		m.setDrainLocked(name, until)
+		changed = true
+	}
+	for name := range m.drains {
+		if _, ok := stored[name]; !ok {
+			m.liftDrainLocked(name)
+			changed = true
+		}
+	}
+	m.drainMu.Unlock()
+	if changed {
+		m.onDrainChange()
+	}
+}
+
+// StartDrainSync loads the drains recorded in the session store and keeps
+// them in sync until Shutdown. no-op without a session cache.
+func (m *mcpBrokerImpl) StartDrainSync(ctx context.Context) {
+	if m.sessionCache == nil {
+		return
+	}
+	m.syncDrains(ctx)
+	ctx, m.drainSyncCancel = context.WithCancel(ctx)
+	m.drainSyncDone = make(chan struct{})
+	go func() {
+		defer close(m.drainSyncDone)
+		ticker := time.NewTicker(drainSyncInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				m.syncDrains(ctx)
+			}
+		}
+	}()
+}
+
+// stopDrainSync stops reloading the shared drains
+func (m *mcpBrokerImpl) stopDrainSync() {
+	if m.drainSyncCancel == nil {
+		return
+	}
+	m.drainSyncCancel()
+	<-m.drainSyncDone
+}
+
+// drainedServers returns the active drains sorted by server name
+func (m *mcpBrokerImpl) drainedServers() []DrainedServer {
+	m.drainMu.Lock()
+	defer m.drainMu.Unlock()
+	out := make([]DrainedServer, 0, len(m.drains))
+	for _, name := range slices.Sorted(maps.Keys(m.drains)) {
+		out = append(out, m.drains[name].describe(name))
+	}
+	return out
+}
+
+func (d *serverDrain) describe(name string) DrainedServer {
+	ds := DrainedServer{Server: name}
+	if !d.until.IsZero() {
+		until := d.until
+		ds.Until = &until
+	}
+	return ds
+}
+
+// stopDrainTimers cancels pending drain expiries on shutdown
+func (m *mcpBrokerImpl) stopDrainTimers() {
+	m.drainMu.Lock()
+	defer m.drainMu.Unlock()
+	for _, d := range m.drains {
+		if d.timer != nil {
+			d.timer.Stop()
+		}
+	}
+}
+
+// isDrained reports whether the named server is drained. Safe to call under
+// mcpLock: drainMu is always taken after it.
+func (m *mcpBrokerImpl) isDrained(name string) bool {
+	m.drainMu.Lock()
+	defer m.drainMu.Unlock()
+	_, ok := m.drains[name]
+	return ok
+}
+
+// drainedServerIDs maps the drained server names to the IDs their tools and
+// prompts carry in kuadrant/id. nil when nothing is drained.
+func (m *mcpBrokerImpl) drainedServerIDs() map[config.UpstreamMCPID]bool {
+	m.drainMu.Lock()
+	if len(m.drains) == 0 {
+		m.drainMu.Unlock()
+		return nil
+	}
+	names := maps.Clone(m.drains)
+	m.drainMu.Unlock()
+
+	m.mcpLock.RLock()
+	defer m.mcpLock.RUnlock()
+	ids := map[config.UpstreamMCPID]bool{}
+	for id, srv := range m.mcpServers {
+		if _, ok := names[srv.MCPName()]; ok {
+			ids[id] = true
+		}
+	}
+	return ids
+}
+
+// applyDrainFilter removes the tools of drained servers
+func (m *mcpBrokerImpl) applyDrainFilter(tools []*mcp.Tool) []*mcp.Tool {
+	drained := m.drainedServerIDs()
+	if drained == nil {
+		return tools
+	}
+	return slices.DeleteFunc(tools, func(t *mcp.Tool) bool {
+		return drained[metaServerID(t.Meta)]
+	})
+}
+
+// applyDrainFilterForPrompts removes the prompts of drained servers
+func (m *mcpBrokerImpl) applyDrainFilterForPrompts(prompts []*mcp.Prompt) []*mcp.Prompt {
+	drained := m.drainedServerIDs()
+	if drained == nil {
+		return prompts
+	}
+	return slices.DeleteFunc(prompts, func(p *mcp.Prompt) bool {
+		return drained[metaServerID(p.Meta)]
+	})
+}
+
+// metaServerID returns the upstream ID stored in kuadrant/id, or "" for
+// broker meta-tools
+func metaServerID(meta mcp.Meta) config.UpstreamMCPID {
+	id, _ := meta["kuadrant/id"].(string)
+	return config.UpstreamMCPID(id)
+}
+
+// onDrainChange rebuilds the routing table and tells clients to re-list.
+// drains reach other replicas through the session store, not the shared
+// catalog: it is published from the managers, which a drain leaves untouched.
+func (m *mcpBrokerImpl) onDrainChange() {
+	m.mcpLock.RLock()
+	m.refreshRoutingTable()
+	m.mcpLock.RUnlock()
+	m.gatewayServer.TriggerToolsListChanged("")
+}
@@ -89,5 +89,8 @@ func (h *ElicitationHandler) buildElicitationURL(serverName, elicitationID strin
 	if scheme == "" {
 		scheme = "https"
 	}
+	if err == nil && serverConfig.UpstreamOAuth() != nil {
+		return scheme + "://" + h.Config.GetExternalHostname() + upstreamOAuthAuthorizePath + "?elicitation_id=" + escapedID
+	}
 	return scheme + "://" + h.Config.GetExternalHostname() + "/tokens?elicitation_id=" + escapedID
 }
@@ -181,6 +181,34 @@ func TestElicitationHandler_XForwardedProto(t *testing.T) {
 	assertSSEContains(t, body, "http://gateway.example.com/tokens?elicitation_id="+eid)
 }
 
+func TestElicitationHandler_UpstreamOAuth(t *testing.T) {
+	eid := "eid-oauth"
+	handler := setupElicitationHandler(
+		elicitation.Entry{SessionID: "sess1", ServerName: "github"},
+		eid,
+		&config.MCPServer{
+			Name: "github",
+			TokenURLElicitation: &config.TokenURLElicitationConfig{OAuth: &config.UpstreamOAuthConfig{
+				AuthorizationURL: "https://github.com/login/oauth/authorize",
+				TokenURL:         "https://github.com/login/oauth/access_token",
+				ClientID:         "gateway",
+			}},
+		},
+		"gateway.example.com",
+	)
+
+	req := httptest.NewRequest(http.MethodPost, "/mcp/elicitation", nil)
+	req.Header.Set(sharedheaders.ElicitationRequestID, "9")
+	req.Header.Set(sharedheaders.ElicitationID, eid)
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+	assertSSEContains(t, w.Body.String(), "https://gateway.example.com/tokens/oauth/authorize?elicitation_id="+eid)
+}
+
 func TestElicitationHandler_FallbackHostname(t *testing.T) {
 	eid := "eid-ghi"
 	handler := setupElicitationHandler(
@@ -24,6 +24,7 @@ func (broker *mcpBrokerImpl) FilterPrompts(ctx context.Context, headers http.Hea
 		return
 	}
 
+	prompts = broker.applyDrainFilterForPrompts(prompts)
 	prompts = broker.applyAuthorizedCapabilitiesFilterForPrompts(headers, prompts)
 	prompts = broker.applyVirtualServerFilterForPrompts(headers, prompts)
 	prompts = broker.removeGatewayMetaFromPrompts(prompts)
@@ -197,6 +197,12 @@ func (m *mockResourceServer) SupportsResources() bool { return false }
 func (m *mockResourceServer) ListResources(context.Context) (*mcp.ListResourcesResult, error) {
 	return &mcp.ListResourcesResult{}, nil
 }
+func (m *mockResourceServer) SupportsLogging() bool { return false }
+func (m *mockResourceServer) SetLoggingLevel(context.Context, mcp.LoggingLevel) error {
+	return nil
+}
+
+func (m *mockResourceServer) Refresh() {}
 
 func createTestResourcesJWT(t *testing.T, allowedResources map[string][]string) string {
 	t.Helper()
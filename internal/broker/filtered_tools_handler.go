@@ -2,16 +2,14 @@ package broker
 
 import (
 	"context"
-	"crypto/ecdsa"
-	"crypto/x509"
-	"encoding/json"
-	"encoding/pem"
 	"fmt"
 	"net/http"
 	"slices"
+	"strings"
 
 	"github.com/Kuadrant/mcp-gateway/internal/broker/upstream"
-	jwt "github.com/golang-jwt/jwt/v5"
+	"github.com/Kuadrant/mcp-gateway/internal/config"
+	internaljwt "github.com/Kuadrant/mcp-gateway/internal/jwt"
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/trace"
@@ -20,8 +18,6 @@ import (
 var authorizedCapabilitiesHeader = http.CanonicalHeaderKey("x-mcp-authorized")
 var virtualMCPHeader = http.CanonicalHeaderKey("x-mcp-virtualserver")
 
-const allowedCapabilitiesClaimKey = "allowed-capabilities"
-
 // FilterTools reduces the tool set based on authorization headers.
 // Priority: x-mcp-authorized JWT filtering, then x-mcp-virtualserver filtering.
 func (broker *mcpBrokerImpl) FilterTools(ctx context.Context, headers http.Header, sessionID string, mcpRes *mcp.ListToolsResult) {
@@ -36,6 +32,9 @@ func (broker *mcpBrokerImpl) FilterTools(ctx context.Context, headers http.Heade
 		return
 	}
 
+	// drained servers are hidden before any other filtering
+	tools = broker.applyDrainFilter(tools)
+
 	// step 1: apply x-mcp-authorized filtering (JWT-based)
 	tools = broker.applyAuthorizedCapabilitiesFilter(headers, tools)
 	broker.logger.DebugContext(ctx, "FilterTools authorized capabilities result", "output_tools_count", len(tools))
@@ -134,38 +133,9 @@ func (broker *mcpBrokerImpl) parseAuthorizedCapabilitiesJWT(headerValues []strin
 		return nil, fmt.Errorf("expected exactly 1 header value, got %d", len(headerValues))
 	}
 
-	jwtValue := headerValues[0]
-	if jwtValue == "" {
-		return nil, fmt.Errorf("empty header value")
-	}
-
-	if broker.trustedHeadersPublicKey == "" {
-		return nil, fmt.Errorf("no public key configured to validate JWT")
-	}
-
-	token, err := validateJWTHeader(jwtValue, broker.trustedHeadersPublicKey)
+	capabilities, err := internaljwt.ParseAuthorizedCapabilities(headerValues[0], broker.trustedHeadersPublicKey)
 	if err != nil {
-		return nil, fmt.Errorf("JWT validation failed: %w", err)
-	}
-
-	claims, ok := token.Claims.(jwt.MapClaims)
-	if !ok {
-		return nil, fmt.Errorf("failed to extract claims from JWT")
-	}
-
-	capabilitiesClaim, ok := claims[allowedCapabilitiesClaimKey]
-	if !ok {
-		return nil, fmt.Errorf("missing %s claim in JWT", allowedCapabilitiesClaimKey)
-	}
-
-	capabilitiesJSON, ok := capabilitiesClaim.(string)
-	if !ok {
-		return nil, fmt.Errorf("%s claim is not a string", allowedCapabilitiesClaimKey)
-	}
-
-	var capabilities map[string]map[string][]string
-	if err := json.Unmarshal([]byte(capabilitiesJSON), &capabilities); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal allowed-capabilities JSON: %w", err)
+		return nil, err
 	}
 
 	broker.logger.Debug("parsed authorized capabilities", "capabilities", capabilities)
@@ -188,23 +158,36 @@ func (broker *mcpBrokerImpl) findServerByName(name string) upstream.ActiveMCPSer
 // rebuilding from each upstream's cached managed tools: userSpecificList servers
 // cache no managed tools (they are fetched per-request and merged in earlier), so
 // rebuilding would silently drop them.
+//
+// A name with a trailing * allows every tool of that server whose upstream name
+// starts with the text before it. Pattern matches are scoped to the server by the
+// kuadrant/id meta, since an empty prefix would otherwise match across servers.
 func (broker *mcpBrokerImpl) filterToolsByServerMap(allowedTools map[string][]string, tools []*mcp.Tool) []*mcp.Tool {
 	allowed := make(map[string]struct{})
+	patterns := make(map[config.UpstreamMCPID][]string)
 	for serverName, toolNames := range allowedTools {
 		upstream := broker.findServerByName(serverName)
 		if upstream == nil {
 			broker.logger.Error("upstream not found", "server", serverName)
 			continue
 		}
-		prefix := upstream.Config().Prefix
+		cfg := upstream.Config()
 		for _, name := range toolNames {
-			allowed[prefix+name] = struct{}{}
+			if pattern, ok := strings.CutSuffix(name, "*"); ok {
+				patterns[cfg.ID()] = append(patterns[cfg.ID()], cfg.Prefix+pattern)
+				continue
+			}
+			allowed[cfg.Prefix+name] = struct{}{}
 		}
 	}
 
 	return slices.DeleteFunc(tools, func(t *mcp.Tool) bool {
-		_, ok := allowed[t.Name]
-		return !ok
+		if _, ok := allowed[t.Name]; ok {
+			return false
+		}
+		return !slices.ContainsFunc(patterns[metaServerID(t.Meta)], func(p string) bool {
+			return strings.HasPrefix(t.Name, p)
+		})
 	})
 }
 
@@ -235,23 +218,3 @@ func (broker *mcpBrokerImpl) applyVirtualServerFilter(headers http.Header, tools
 		return !inFilter
 	})
 }
-
-// validateJWTHeader validates the JWT header using ES256 algorithm.
-func validateJWTHeader(token string, publicKey string) (*jwt.Token, error) {
-	block, _ := pem.Decode([]byte(publicKey))
-	if block == nil {
-		return nil, fmt.Errorf("failed to decode PEM block")
-	}
-
-	return jwt.Parse(token, func(_ *jwt.Token) (any, error) {
-		pubkey, err := x509.ParsePKIXPublicKey(block.Bytes)
-		if err != nil {
-			return nil, err
-		}
-		key, ok := pubkey.(*ecdsa.PublicKey)
-		if !ok {
-			return nil, fmt.Errorf("expected *ecdsa.PublicKey, got %T", pubkey)
-		}
-		return key, nil
-	}, jwt.WithValidMethods([]string{jwt.SigningMethodES256.Alg()}))
-}
@@ -67,6 +67,11 @@ func createTestManager(t *testing.T, serverName, prefix string, tools []mcp.Tool
 	return manager
 }
 
+// testServerID is the upstream ID createTestManager gives a server
+func testServerID(serverName, prefix string) config.UpstreamMCPID {
+	return (&config.MCPServer{Name: serverName, Prefix: prefix, URL: "http://test.local/mcp"}).ID()
+}
+
 func TestFilteredTools(t *testing.T) {
 
 	testCases := []struct {
@@ -157,6 +162,68 @@ func TestFilteredTools(t *testing.T) {
 				{Name: "test2_tool"},
 			},
 		},
+		{
+			// patterns are scoped by kuadrant/id, so * on an unprefixed server
+			// must not grant the tools of another server
+			Name: "trailing wildcard allows matching tools of that server only",
+			FullToolList: &mcp.ListToolsResult{Tools: []*mcp.Tool{
+				{Name: "issue_create", Meta: mcp.Meta{"kuadrant/id": string(testServerID("mcp-test/github", ""))}},
+				{Name: "issue_close", Meta: mcp.Meta{"kuadrant/id": string(testServerID("mcp-test/github", ""))}},
+				{Name: "repo_delete", Meta: mcp.Meta{"kuadrant/id": string(testServerID("mcp-test/github", ""))}},
+				{Name: "w_forecast", Meta: mcp.Meta{"kuadrant/id": string(testServerID("mcp-test/weather", "w_"))}},
+				{Name: "w_alerts", Meta: mcp.Meta{"kuadrant/id": string(testServerID("mcp-test/weather", "w_"))}},
+			}},
+			RegisteredMCPServers: map[config.UpstreamMCPID]upstream.ActiveMCPServer{
+				testServerID("mcp-test/github", ""): upstream.NewActiveForTesting(createTestManager(t,
+					"mcp-test/github",
+					"",
+					nil,
+				)),
+				testServerID("mcp-test/weather", "w_"): upstream.NewActiveForTesting(createTestManager(t,
+					"mcp-test/weather",
+					"w_",
+					nil,
+				)),
+			},
+			AllowedToolsList: map[string][]string{
+				"mcp-test/github":  {"issue_*"},
+				"mcp-test/weather": {"forecast"},
+			},
+			enforceFilterList: true,
+			ExpectedTools: []mcp.Tool{
+				{Name: "issue_create"},
+				{Name: "issue_close"},
+				{Name: "w_forecast"},
+			},
+		},
+		{
+			Name: "wildcard allows every tool of the server",
+			FullToolList: &mcp.ListToolsResult{Tools: []*mcp.Tool{
+				{Name: "test1_tool", Meta: mcp.Meta{"kuadrant/id": string(testServerID("mcp-test/test-server1", "test1_"))}},
+				{Name: "test1_tool2", Meta: mcp.Meta{"kuadrant/id": string(testServerID("mcp-test/test-server1", "test1_"))}},
+				{Name: "test2_tool", Meta: mcp.Meta{"kuadrant/id": string(testServerID("mcp-test/test-server2", "test2_"))}},
+			}},
+			RegisteredMCPServers: map[config.UpstreamMCPID]upstream.ActiveMCPServer{
+				testServerID("mcp-test/test-server1", "test1_"): upstream.NewActiveForTesting(createTestManager(t,
+					"mcp-test/test-server1",
+					"test1_",
+					nil,
+				)),
+				testServerID("mcp-test/test-server2", "test2_"): upstream.NewActiveForTesting(createTestManager(t,
+					"mcp-test/test-server2",
+					"test2_",
+					nil,
+				)),
+			},
+			AllowedToolsList: map[string][]string{
+				"mcp-test/test-server1": {"*"},
+			},
+			enforceFilterList: true,
+			ExpectedTools: []mcp.Tool{
+				{Name: "test1_tool"},
+				{Name: "test1_tool2"},
+			},
+		},
 		{
 			Name: "test filters tools returns no tools if none allowed",
 			FullToolList: &mcp.ListToolsResult{Tools: []*mcp.Tool{
@@ -13,7 +13,9 @@ import (
 	"unicode"
 
 	"github.com/Kuadrant/mcp-gateway/internal/broker/upstream"
+	internaljwt "github.com/Kuadrant/mcp-gateway/internal/jwt"
 	"github.com/Kuadrant/mcp-gateway/internal/protocol"
+	"github.com/Kuadrant/mcp-gateway/internal/session"
 	"github.com/Kuadrant/mcp-gateway/internal/transport"
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 )
@@ -102,6 +104,7 @@ const (
 	msgInvalidContentType = "Invalid content type: must be 'application/json'"
 	msgInvalidSessionID   = "Invalid session ID"
 	msgSessionTerminated  = "Session terminated"
+	msgSubjectMismatch    = "Session belongs to a different subject"
 	msgBodyNotValidJSON   = "request body is not valid json"
 	msgInvalidVersion     = "Invalid JSON-RPC version"
 
@@ -123,6 +126,7 @@ const maxRequestBodyBytes = 10 << 20
 
 // unsupportedDomain maps methods mark3labs knew but the gateway never
 // enabled to the capability name in its "<domain> not supported" error.
+// logging/setLevel is only unsupported while no upstream offers logging.
 // the SDK would otherwise serve some of these (empty resource lists,
 // logging level accepted) and reject others with HTTP 400.
 var unsupportedDomain = map[string]string{
@@ -250,6 +254,10 @@ func (h *compatHandler) servePOST(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, msg, code)
 		return
 	}
+	if !h.subjectAllowed(r) {
+		http.Error(w, msgSubjectMismatch, http.StatusForbidden)
+		return
+	}
 
 	if env.JSONRPC != "2.0" {
 		writeMainJSONRPCError(w, http.StatusOK, env.idValue(), codeInvalidRequest, msgInvalidVersion)
@@ -280,6 +288,12 @@ func (h *compatHandler) servePOST(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// logging is served once an upstream offers it: the broker relays the
+	// upstreams' log events and the router fans the level out to backends
+	if env.Method == "logging/setLevel" && h.broker.supportsLogging() {
+		h.delegate(w, r, body, env.Method)
+		return
+	}
 	if domain, ok := unsupportedDomain[env.Method]; ok {
 		writeMainJSONRPCError(w, http.StatusOK, env.idValue(), codeMethodNotFound, domain+" not supported")
 		return
@@ -296,6 +310,10 @@ func (h *compatHandler) servePOST(w http.ResponseWriter, r *http.Request) {
 // version when the client did not name one.
 func (h *compatHandler) serveInitialize(w http.ResponseWriter, r *http.Request, body []byte, env *jsonrpcEnvelope) {
 	r.Header.Del(gatewaySessionHeader)
+	if !h.establishSubjectSession(r) {
+		http.Error(w, "failed connection", http.StatusInternalServerError)
+		return
+	}
 
 	var params struct {
 		ProtocolVersion string `json:"protocolVersion"`
@@ -307,6 +325,30 @@ func (h *compatHandler) serveInitialize(w http.ResponseWriter, r *http.Request,
 	h.delegate(w, r, body, env.Method)
 }
 
+// establishSubjectSession issues an authenticated client a session ID bound
+// to its verified subject. the SDK handler only mints IDs without seeing the
+// request, so the session is connected up front and the initialize is served
+// on it through the live table. unauthenticated clients are left to the SDK.
+// reports false when the session could not be connected.
+func (h *compatHandler) establishSubjectSession(r *http.Request) bool {
+	sub := extractRequestSub(r)
+	live, ok := h.next.(*sessionResurrectionHandler)
+	if h.broker.sessionIDForSubject == nil || sub == "" || !ok {
+		return true
+	}
+	sid := h.broker.sessionIDForSubject(sub)
+	if sid == "" {
+		return false
+	}
+	if err := live.establish(r, sid); err != nil {
+		h.broker.logger.Error("connecting subject-bound session failed",
+			"session", internaljwt.LogSafeSessionID(sid), "error", err)
+		return false
+	}
+	r.Header.Set(gatewaySessionHeader, sid)
+	return true
+}
+
 // rewriteInitializeVersion rebuilds an initialize body with the given
 // protocol version so the SDK negotiates what mark3labs would have.
 func rewriteInitializeVersion(body []byte, env *jsonrpcEnvelope, version string) []byte {
@@ -348,6 +390,10 @@ func (h *compatHandler) serveSamplingResponse(w http.ResponseWriter, r *http.Req
 		http.Error(w, msg, code)
 		return
 	}
+	if !h.subjectAllowed(r) {
+		http.Error(w, msgSubjectMismatch, http.StatusForbidden)
+		return
+	}
 	var requestID int64
 	if err := json.Unmarshal(env.ID, &requestID); err != nil {
 		http.Error(w, "Invalid request ID in sampling response", http.StatusBadRequest)
@@ -361,7 +407,7 @@ func (h *compatHandler) serveGET(w http.ResponseWriter, r *http.Request) {
 	// caller. sessions that fail validation get an equivalent hanging
 	// stream that carries nothing, keeping the surface indistinguishable
 	// while never attaching an unauthenticated client to real state.
-	if code, _ := h.checkSession(r.Header.Get(gatewaySessionHeader)); code != 0 {
+	if code, _ := h.checkSession(r.Header.Get(gatewaySessionHeader)); code != 0 || !h.subjectAllowed(r) {
 		serveDeadStream(w, r)
 		return
 	}
@@ -386,9 +432,22 @@ func serveDeadStream(w http.ResponseWriter, r *http.Request) {
 
 // serveDELETE mirrors mark3labs: terminate via the session manager and
 // answer 200 whether or not the session was known; the SDK's 204/404/400
-// never surface. live SDK state for the ID is still torn down.
+// never surface. live SDK state for the ID is still torn down. a client
+// DELETE also revokes the session, so a leaked ID cannot be resurrected on
+// another replica; idle closes only terminate.
 func (h *compatHandler) serveDELETE(w http.ResponseWriter, r *http.Request) {
 	sid := r.Header.Get(gatewaySessionHeader)
+	if !h.subjectAllowed(r) {
+		// another subject's session ID must not end the session
+		http.Error(w, msgSubjectMismatch, http.StatusForbidden)
+		return
+	}
+	if h.broker.sessionRevoker != nil && sid != "" {
+		if err := h.broker.sessionRevoker.Revoke(r.Context(), sid); err != nil {
+			http.Error(w, fmt.Sprintf("Session termination failed: %v", err), http.StatusInternalServerError)
+			return
+		}
+	}
 	if h.broker.sessionTerminator != nil {
 		notAllowed, err := h.broker.sessionTerminator(sid)
 		if err != nil {
@@ -423,6 +482,24 @@ func (h *compatHandler) checkSession(sid string) (int, string) {
 	return 0, ""
 }
 
+// subjectAllowed reports whether the router-verified subject of the request
+// may use its session. only a definite mismatch is refused: session IDs that
+// fail to parse are left to checkSession. a mismatch never drops the
+// session, which belongs to someone else.
+func (h *compatHandler) subjectAllowed(r *http.Request) bool {
+	sid := r.Header.Get(gatewaySessionHeader)
+	if h.broker.sessionSubjectCheck == nil || sid == "" {
+		return true
+	}
+	err := h.broker.sessionSubjectCheck(sid, extractRequestSub(r))
+	if errors.Is(err, session.ErrSubjectMismatch) {
+		h.broker.logger.Warn("rejecting request for another subject's session",
+			"session", internaljwt.LogSafeSessionID(sid), "method", r.Method)
+		return false
+	}
+	return true
+}
+
 // dropSession closes any live SDK or resurrected session for the request's
 // session ID by replaying it as a DELETE through the inner chain, so the
 // pod-local table entry and any hanging GET stream are released.
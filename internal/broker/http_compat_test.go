@@ -6,15 +6,20 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/Kuadrant/mcp-gateway/internal/broker/upstream"
 	"github.com/Kuadrant/mcp-gateway/internal/config"
+	sharedheaders "github.com/Kuadrant/mcp-gateway/internal/headers"
+	"github.com/Kuadrant/mcp-gateway/internal/session"
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 	"github.com/stretchr/testify/require"
 )
@@ -324,6 +329,96 @@ func TestCompat_DeleteAlways200(t *testing.T) {
 	require.True(t, ok, "terminator must run for DELETE")
 }
 
+// recordingRevoker records revoked session IDs, failing when err is set
+type recordingRevoker struct {
+	revoked sync.Map
+	err     error
+}
+
+func (r *recordingRevoker) Revoke(_ context.Context, sessionID string) error {
+	if r.err != nil {
+		return r.err
+	}
+	r.revoked.Store(sessionID, true)
+	return nil
+}
+
+func (r *recordingRevoker) RevokeSubject(context.Context, string) ([]string, error) {
+	return nil, r.err
+}
+
+// a client DELETE revokes the session so it cannot be resurrected elsewhere
+func TestCompat_DeleteRevokes(t *testing.T) {
+	revoker := &recordingRevoker{}
+	h := newBrokerHarness(t, func(h *brokerHarness) http.Handler {
+		return h.b.MCPHandler().(*protocolRouter).legacy
+	}, WithSessionRevoker(revoker))
+	sid := h.initialize(t)
+
+	res := h.do(t, http.MethodDelete, sid, "", nil)
+	require.Equal(t, http.StatusOK, res.status)
+	_, ok := revoker.revoked.Load(sid)
+	require.True(t, ok, "DELETE must revoke the session")
+	require.True(t, h.isTerminated(sid))
+
+	revoker.err = errors.New("store unavailable")
+	res = h.do(t, http.MethodDelete, h.initialize(t), "", nil)
+	require.Equal(t, http.StatusInternalServerError, res.status)
+	require.Contains(t, res.body, "Session termination failed")
+}
+
+// an initialize carrying a verified subject gets a session bound to it;
+// requests from another subject are refused without dropping the session
+func TestCompat_SubjectBoundSession(t *testing.T) {
+	var counter atomic.Int64
+	h := newBrokerHarness(t, func(h *brokerHarness) http.Handler {
+		ch := h.b.MCPHandler().(*protocolRouter).legacy
+		h.wrapped = ch.next.(*sessionResurrectionHandler)
+		return ch
+	},
+		WithSessionIDForSubject(func(sub string) string {
+			return fmt.Sprintf("valid-%s-%d", sub, counter.Add(1))
+		}),
+		WithSessionSubjectCheck(func(sid, sub string) error {
+			// IDs minted by the harness generator carry no subject
+			bound := ""
+			if parts := strings.Split(sid, "-"); len(parts) == 3 && parts[1] != "gen" {
+				bound = parts[1]
+			}
+			if bound != sub {
+				return session.ErrSubjectMismatch
+			}
+			return nil
+		}))
+	alice := map[string]string{sharedheaders.VerifiedSubHeader: "alice"}
+	bob := map[string]string{sharedheaders.VerifiedSubHeader: "bob"}
+
+	res := h.do(t, http.MethodPost, "", `{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2025-03-26","capabilities":{},"clientInfo":{"name":"c","version":"1"}}}`, alice)
+	require.Equal(t, http.StatusOK, res.status, res.body)
+	sid := res.header.Get("Mcp-Session-Id")
+	require.Equal(t, "valid-alice-1", sid)
+	ack := h.do(t, http.MethodPost, sid, `{"jsonrpc":"2.0","method":"notifications/initialized"}`, alice)
+	require.Equal(t, http.StatusAccepted, ack.status, ack.body)
+
+	list := h.do(t, http.MethodPost, sid, h.toolsListBody(), alice)
+	require.Equal(t, http.StatusOK, list.status, list.body)
+
+	list = h.do(t, http.MethodPost, sid, h.toolsListBody(), bob)
+	require.Equal(t, http.StatusForbidden, list.status, list.body)
+	require.Contains(t, list.body, "different subject")
+
+	del := h.do(t, http.MethodDelete, sid, "", bob)
+	require.Equal(t, http.StatusForbidden, del.status, del.body)
+	require.False(t, h.isTerminated(sid), "a refused DELETE must not terminate the session")
+	require.Equal(t, 1, h.serverSessionCount(sid))
+
+	list = h.do(t, http.MethodPost, sid, h.toolsListBody(), alice)
+	require.Equal(t, http.StatusOK, list.status, list.body)
+
+	// without a verified subject the SDK mints the ID as before
+	require.True(t, strings.HasPrefix(h.initialize(t), "valid-gen-"))
+}
+
 // re-initialize with an existing session header gets a fresh session, as
 // mark3labs always generated a new ID for initialize.
 func TestCompat_ReinitializeCreatesFreshSession(t *testing.T) {
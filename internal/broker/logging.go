@@ -0,0 +1,178 @@
+package broker
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/Kuadrant/mcp-gateway/internal/broker/upstream"
+	internaljwt "github.com/Kuadrant/mcp-gateway/internal/jwt"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// upstreamLogTimeout bounds delivery of one forwarded log event and each
+// upstream's logging/setLevel
+const upstreamLogTimeout = 5 * time.Second
+
+// logLevels orders levels from most to least verbose, as in RFC 5424
+var logLevels = []mcp.LoggingLevel{"debug", "info", "notice", "warning", "error", "critical", "alert", "emergency"}
+
+// upstreamLogging tracks the levels clients asked for. upstreams log to the
+// broker's shared session, so that session runs at the most verbose level
+// any client requested; the SDK then filters each client's copy down to
+// its own level.
+type upstreamLogging struct {
+	mu      sync.Mutex
+	clients map[string]mcp.LoggingLevel
+	level   mcp.LoggingLevel
+}
+
+// current returns the level currently applied to upstreams, empty until a
+// client sets one
+func (l *upstreamLogging) current() mcp.LoggingLevel {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.level
+}
+
+// set records a client's level and reports the new upstream level when it
+// changed
+func (l *upstreamLogging) set(sessionID string, level mcp.LoggingLevel) (mcp.LoggingLevel, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.clients == nil {
+		l.clients = map[string]mcp.LoggingLevel{}
+	}
+	l.clients[sessionID] = level
+	return l.recompute()
+}
+
+// forget drops an ended session. the upstream level only ever changes to a
+// level some remaining client wants; with no clients left it stays put.
+func (l *upstreamLogging) forget(sessionID string) (mcp.LoggingLevel, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if _, ok := l.clients[sessionID]; !ok {
+		return "", false
+	}
+	delete(l.clients, sessionID)
+	if len(l.clients) == 0 {
+		return "", false
+	}
+	return l.recompute()
+}
+
+// recompute is called holding mu
+func (l *upstreamLogging) recompute() (mcp.LoggingLevel, bool) {
+	verbose := -1
+	for _, level := range l.clients {
+		if i := slices.Index(logLevels, level); i >= 0 && (verbose < 0 || i < verbose) {
+			verbose = i
+		}
+	}
+	if verbose < 0 || logLevels[verbose] == l.level {
+		return "", false
+	}
+	l.level = logLevels[verbose]
+	return l.level, true
+}
+
+// wireUpstreamLogging attributes the upstream's log events to its server
+// and carries the current level into its next connect. called before the
+// manager starts so the first session is covered.
+func (m *mcpBrokerImpl) wireUpstreamLogging(ctx context.Context, up upstream.MCP) {
+	serverName := up.GetName()
+	up.OnLogMessage(func(params *mcp.LoggingMessageParams) {
+		m.forwardUpstreamLog(serverName, params)
+	})
+	if level := m.logging.current(); level != "" {
+		// not yet connected: this only records the level
+		_ = up.SetLoggingLevel(ctx, level)
+	}
+}
+
+// forwardUpstreamLog relays a log event from the broker's upstream session
+// to the client sessions that hold a backend session with the server, with
+// the logger prefixed by the originating server. events from routed calls
+// never come here: they stream back to the client on the call's own
+// response.
+func (m *mcpBrokerImpl) forwardUpstreamLog(serverName string, params *mcp.LoggingMessageParams) {
+	forwarded := *params
+	forwarded.Logger = serverName
+	if params.Logger != "" {
+		forwarded.Logger = serverName + "/" + params.Logger
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), upstreamLogTimeout)
+	defer cancel()
+	for ss := range m.gatewayServer.server.Sessions() {
+		// log events can carry user data: clients that never reached the
+		// server, through another virtual server or tenant, must not see them
+		if !m.holdsBackendSession(ctx, ss.ID(), serverName) {
+			continue
+		}
+		// Log drops the event for sessions without a level or below it
+		if err := ss.Log(ctx, &forwarded); err != nil { //nolint:staticcheck // logging is deprecated but still served to 2025 clients
+			m.logger.Debug("failed to forward upstream log", "server", serverName, "gatewaySessionID", internaljwt.LogSafeSessionID(ss.ID()), "error", err)
+		}
+	}
+}
+
+// holdsBackendSession reports whether the router opened a backend session
+// with serverName for a gateway session, as recorded in the session cache
+func (m *mcpBrokerImpl) holdsBackendSession(ctx context.Context, gatewaySessionID, serverName string) bool {
+	if m.sessionCache == nil {
+		return false
+	}
+	backends, err := m.sessionCache.GetSession(ctx, gatewaySessionID)
+	if err != nil {
+		m.logger.Debug("failed to look up backend sessions", "gatewaySessionID", internaljwt.LogSafeSessionID(gatewaySessionID), "error", err)
+		return false
+	}
+	return backends[serverName] != ""
+}
+
+// supportsLogging reports whether any upstream declared logging, in which
+// case the gateway advertises it too
+func (m *mcpBrokerImpl) supportsLogging() bool {
+	m.mcpLock.RLock()
+	defer m.mcpLock.RUnlock()
+	for _, server := range m.mcpServers {
+		if server.SupportsLogging() {
+			return true
+		}
+	}
+	return false
+}
+
+// onClientLogLevel records a client's logging/setLevel and raises the
+// upstream level when the client wants more than upstreams currently send
+func (m *mcpBrokerImpl) onClientLogLevel(ctx context.Context, sessionID string, level mcp.LoggingLevel) {
+	if next, changed := m.logging.set(sessionID, level); changed {
+		m.applyUpstreamLogLevel(ctx, next)
+	}
+}
+
+// applyUpstreamLogLevel sets level on every upstream's broker session.
+// best effort: an upstream rejecting it just sends nothing.
+func (m *mcpBrokerImpl) applyUpstreamLogLevel(ctx context.Context, level mcp.LoggingLevel) {
+	m.mcpLock.RLock()
+	servers := make([]upstream.ActiveMCPServer, 0, len(m.mcpServers))
+	for _, server := range m.mcpServers {
+		servers = append(servers, server)
+	}
+	m.mcpLock.RUnlock()
+
+	m.logger.DebugContext(ctx, "setting upstream logging level", "level", level)
+	var wg sync.WaitGroup
+	for _, server := range servers {
+		wg.Go(func() {
+			setCtx, cancel := context.WithTimeout(ctx, upstreamLogTimeout)
+			defer cancel()
+			if err := server.SetLoggingLevel(setCtx, level); err != nil {
+				m.logger.DebugContext(ctx, "upstream did not accept logging level", "server", server.MCPName(), "level", level, "error", err)
+			}
+		})
+	}
+	wg.Wait()
+}
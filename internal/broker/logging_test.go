@@ -0,0 +1,113 @@
+package broker
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Kuadrant/mcp-gateway/internal/config"
+	"github.com/Kuadrant/mcp-gateway/internal/session"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUpstreamLogging_MostVerboseLevelWins(t *testing.T) {
+	var l upstreamLogging
+	require.Empty(t, l.current())
+
+	level, changed := l.set("a", "warning")
+	require.True(t, changed)
+	require.Equal(t, mcp.LoggingLevel("warning"), level)
+
+	_, changed = l.set("b", "error")
+	require.False(t, changed, "a less verbose client does not lower what upstreams send")
+
+	level, changed = l.set("c", "debug")
+	require.True(t, changed)
+	require.Equal(t, mcp.LoggingLevel("debug"), level)
+
+	_, changed = l.set("d", "chatty")
+	require.False(t, changed, "unknown levels are ignored")
+
+	level, changed = l.forget("c")
+	require.True(t, changed)
+	require.Equal(t, mcp.LoggingLevel("warning"), level)
+
+	_, changed = l.forget("unknown")
+	require.False(t, changed)
+	l.forget("a")
+	l.forget("b")
+	l.forget("d")
+	require.Equal(t, mcp.LoggingLevel("error"), l.current(), "with no clients left the level stays put")
+}
+
+func TestCompat_LoggingNotSupportedWithoutUpstreamLogging(t *testing.T) {
+	h := newCompatHarness(t)
+	sid := h.initialize(t)
+	res := h.post(t, sid, `{"jsonrpc":"2.0","id":7,"method":"logging/setLevel","params":{"level":"debug"}}`)
+	require.JSONEq(t, `{"jsonrpc":"2.0","id":7,"error":{"code":-32601,"message":"logging not supported"}}`, res.body)
+}
+
+// an upstream's log event on the broker's session reaches clients with the
+// logger attributed to the upstream, at the level the client asked for
+func TestLogging_ForwardsUpstreamLogsWithServerPrefix(t *testing.T) {
+	upSrv := mcp.NewServer(&mcp.Implementation{Name: "up", Version: "0.0.1"}, &mcp.ServerOptions{
+		Capabilities: &mcp.ServerCapabilities{Logging: &mcp.LoggingCapabilities{}},
+	})
+	upTS := httptest.NewServer(mcp.NewStreamableHTTPHandler(func(*http.Request) *mcp.Server { return upSrv }, nil))
+	t.Cleanup(upTS.Close)
+
+	h := newCompatHarness(t)
+	cache, err := session.NewCache()
+	require.NoError(t, err)
+	h.b.sessionCache = cache
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+	defer cancel()
+	h.b.OnConfigChange(ctx, &config.MCPServersConfig{Servers: []*config.MCPServer{
+		{Name: "weather", URL: upTS.URL, Prefix: "w_"},
+	}})
+	t.Cleanup(func() { _ = h.b.Shutdown(context.Background()) })
+	require.Eventually(t, h.b.supportsLogging, 10*time.Second, 20*time.Millisecond)
+
+	connect := func(got chan *mcp.LoggingMessageParams) *mcp.ClientSession {
+		client := mcp.NewClient(&mcp.Implementation{Name: "c", Version: "0.0.1"}, &mcp.ClientOptions{
+			LoggingMessageHandler: func(_ context.Context, req *mcp.LoggingMessageRequest) {
+				got <- req.Params
+			},
+		})
+		cs, err := client.Connect(ctx, &mcp.StreamableClientTransport{Endpoint: h.ts.URL}, nil)
+		require.NoError(t, err)
+		t.Cleanup(func() { _ = cs.Close() })
+		require.NotNil(t, cs.InitializeResult().Capabilities.Logging, "the gateway advertises logging when an upstream does")
+		require.NoError(t, cs.SetLoggingLevel(ctx, &mcp.SetLoggingLevelParams{Level: "info"}))
+		return cs
+	}
+	got := make(chan *mcp.LoggingMessageParams, 16)
+	cs := connect(got)
+	// only this client has reached the weather server
+	_, err = cache.AddSession(ctx, cs.ID(), "weather", "backend-weather", 0)
+	require.NoError(t, err)
+	other := make(chan *mcp.LoggingMessageParams, 16)
+	connect(other)
+
+	// the upstream's stream to the broker and the client's to the gateway
+	// attach asynchronously; keep logging until an event makes it through
+	require.Eventually(t, func() bool {
+		for ss := range upSrv.Sessions() {
+			_ = ss.Log(ctx, &mcp.LoggingMessageParams{Level: "debug", Logger: "db", Data: "below the client level"}) //nolint:staticcheck // exercising the deprecated logging feature
+			_ = ss.Log(ctx, &mcp.LoggingMessageParams{Level: "warning", Logger: "db", Data: "disk low"})             //nolint:staticcheck // exercising the deprecated logging feature
+		}
+		select {
+		case params := <-got:
+			require.Equal(t, mcp.LoggingLevel("warning"), params.Level)
+			require.Equal(t, "weather/db", params.Logger)
+			require.Equal(t, "disk low", params.Data)
+			return true
+		case <-time.After(100 * time.Millisecond):
+			return false
+		}
+	}, 15*time.Second, 50*time.Millisecond, "upstream log never reached the client")
+	require.Empty(t, other, "a client without a weather session got its log event")
+}
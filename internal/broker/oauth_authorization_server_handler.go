@@ -0,0 +1,515 @@
+package broker
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"maps"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	envOAuthMetadataProxy          = "OAUTH_METADATA_PROXY"
+	envOAuthMetadataCacheTTL       = "OAUTH_METADATA_CACHE_TTL"
+	envOAuthDCREndpoint            = "OAUTH_DCR_ENDPOINT"
+	envOAuthDCRRedirectURIs        = "OAUTH_DCR_REDIRECT_URIS"
+	envOAuthDCRGrantTypes          = "OAUTH_DCR_GRANT_TYPES"
+	envOAuthDCRInitialAccessToken  = "OAUTH_DCR_INITIAL_ACCESS_TOKEN" // #nosec G101
+	defaultOAuthMetadataCacheTTL   = 5 * time.Minute
+	maxAuthorizationServerDocBytes = 1 << 20
+	maxClientRegistrationBytes     = 64 << 10
+
+	// OAuthAuthorizationServerPath is the RFC 8414 authorization server metadata path
+	OAuthAuthorizationServerPath = "/.well-known/oauth-authorization-server"
+	// OpenIDConfigurationPath is the OpenID Connect discovery path
+	OpenIDConfigurationPath = "/.well-known/openid-configuration"
+	// ClientRegistrationPath is the path of the proxied dynamic client registration endpoint
+	ClientRegistrationPath = "/oauth/register"
+)
+
+// metadataDocument is the kind of discovery document fetched from an authorization server
+type metadataDocument int
+
+const (
+	oauthAuthorizationServerDocument metadataDocument = iota
+	openIDConfigurationDocument
+)
+
+// wellKnownSuffix returns the well-known URI suffix of the document
+func (d metadataDocument) wellKnownSuffix() string {
+	if d == openIDConfigurationDocument {
+		return OpenIDConfigurationPath
+	}
+	return OAuthAuthorizationServerPath
+}
+
+// candidateURLs returns where the document of issuer may be served. RFC 8414
+// inserts the well-known suffix before the issuer path, OpenID Connect
+// Discovery appends it; servers such as Keycloak only serve the appended form.
+func (d metadataDocument) candidateURLs(issuer *url.URL) []string {
+	path := strings.TrimSuffix(issuer.Path, "/")
+	inserted := *issuer
+	inserted.Path = d.wellKnownSuffix() + path
+	appended := *issuer
+	appended.Path = path + d.wellKnownSuffix()
+	if path == "" {
+		return []string{appended.String()}
+	}
+	if d == openIDConfigurationDocument {
+		return []string{appended.String(), inserted.String()}
+	}
+	return []string{inserted.String(), appended.String()}
+}
+
+// AuthorizationServerConfig configures the authorization server metadata proxy
+type AuthorizationServerConfig struct {
+	// AuthorizationServers are the issuer URLs the metadata is fetched from
+	AuthorizationServers []string
+	// CacheTTL is how long fetched metadata is served before it is refetched
+	CacheTTL time.Duration
+	// RegistrationEndpoint is the public URL of the proxied registration
+	// endpoint. Dynamic client registration is disabled when empty.
+	RegistrationEndpoint string
+	// AllowedRedirectURIs are the redirect URIs clients may register; a
+	// trailing * matches by prefix on the same host
+	AllowedRedirectURIs []string
+	// AllowedGrantTypes are the grant types clients may register
+	AllowedGrantTypes []string
+	// AllowedScopes are the scopes clients may register, the scopes the
+	// protected resource advertises
+	AllowedScopes []string
+	// AllowedTokenEndpointAuthMethods are the client authentication methods
+	// clients may register
+	AllowedTokenEndpointAuthMethods []string
+	// InitialAccessToken is sent to the upstream registration endpoint when set
+	InitialAccessToken string
+}
+
+// GetAuthorizationServerConfig parses the metadata proxy configuration from
+// environment variables. It returns nil when the proxy is not enabled.
+func GetAuthorizationServerConfig() *AuthorizationServerConfig {
+	if enabled, _ := strconv.ParseBool(os.Getenv(envOAuthMetadataProxy)); !enabled {
+		return nil
+	}
+	cfg := &AuthorizationServerConfig{
+		AuthorizationServers: splitEnvList(os.Getenv(envOAuthAuthorizationServers)),
+		CacheTTL:             defaultOAuthMetadataCacheTTL,
+		RegistrationEndpoint: os.Getenv(envOAuthDCREndpoint),
+		AllowedRedirectURIs:  splitEnvList(os.Getenv(envOAuthDCRRedirectURIs)),
+		AllowedGrantTypes:    []string{"authorization_code", "refresh_token"},
+		AllowedScopes:        splitEnvList(os.Getenv(envOAuthScopesSupported)),
+		// methods that need the authorization server to fetch client keys
+		// or trust client certificates are left out
+		AllowedTokenEndpointAuthMethods: []string{"none", "client_secret_basic", "client_secret_post"},
+		InitialAccessToken:              os.Getenv(envOAuthDCRInitialAccessToken),
+	}
+	if ttl, err := strconv.Atoi(os.Getenv(envOAuthMetadataCacheTTL)); err == nil && ttl > 0 {
+		cfg.CacheTTL = time.Duration(ttl) * time.Second
+	}
+	if grantTypes := splitEnvList(os.Getenv(envOAuthDCRGrantTypes)); len(grantTypes) > 0 {
+		cfg.AllowedGrantTypes = grantTypes
+	}
+	return cfg
+}
+
+// splitEnvList splits a comma separated env value, dropping empty entries
+func splitEnvList(value string) []string {
+	var out []string
+	for item := range strings.SplitSeq(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			out = append(out, item)
+		}
+	}
+	return out
+}
+
+// cachedMetadata is a fetched discovery document
+type cachedMetadata struct {
+	doc       map[string]any
+	fetchedAt time.Time
+}
+
+// AuthorizationServerHandler proxies and caches the discovery documents of the
+// configured authorization servers, and registers clients with the first one
+// on their behalf once the registration passes the configured allowlists
+type AuthorizationServerHandler struct {
+	Logger *slog.Logger
+	Config *AuthorizationServerConfig
+	// Client fetches metadata and registers clients; http.DefaultClient when nil
+	Client *http.Client
+
+	mu    sync.Mutex
+	cache map[string]cachedMetadata
+}
+
+// NewAuthorizationServerHandler creates a handler serving the metadata described by cfg
+func NewAuthorizationServerHandler(logger *slog.Logger, cfg *AuthorizationServerConfig) *AuthorizationServerHandler {
+	return &AuthorizationServerHandler{
+		Logger: logger,
+		Config: cfg,
+		Client: &http.Client{Timeout: 10 * time.Second},
+		cache:  map[string]cachedMetadata{},
+	}
+}
+
+// DynamicClientRegistrationEnabled reports whether the registration endpoint is served
+func (h *AuthorizationServerHandler) DynamicClientRegistrationEnabled() bool {
+	return h.Config.RegistrationEndpoint != ""
+}
+
+// HandleMetadata handles the /.well-known/oauth-authorization-server and
+// /.well-known/openid-configuration endpoints. A path after the well-known
+// suffix selects the authorization server with that issuer path.
+func (h *AuthorizationServerHandler) HandleMetadata(w http.ResponseWriter, r *http.Request) {
+	setCORSHeaders(w)
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	kind := oauthAuthorizationServerDocument
+	issuerPath, found := strings.CutPrefix(r.URL.Path, OAuthAuthorizationServerPath)
+	if !found {
+		kind = openIDConfigurationDocument
+		issuerPath = strings.TrimPrefix(r.URL.Path, OpenIDConfigurationPath)
+	}
+	issuer := h.selectIssuer(issuerPath)
+	if issuer == "" {
+		http.Error(w, "unknown authorization server", http.StatusNotFound)
+		return
+	}
+
+	doc, err := h.metadata(r.Context(), kind, issuer)
+	if err != nil {
+		h.Logger.Error("failed to fetch authorization server metadata", "issuer", issuer, "error", err)
+		http.Error(w, "authorization server metadata unavailable", http.StatusBadGateway)
+		return
+	}
+	if h.DynamicClientRegistrationEnabled() && issuer == h.Config.AuthorizationServers[0] {
+		// copy so the cached document keeps the upstream registration endpoint
+		rewritten := maps.Clone(doc)
+		rewritten["registration_endpoint"] = h.Config.RegistrationEndpoint
+		doc = rewritten
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", fmt.Sprintf("public, max-age=%d", int(h.Config.CacheTTL/time.Second)))
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(doc); err != nil {
+		h.Logger.Error("failed to encode authorization server metadata", "error", err)
+	}
+}
+
+// selectIssuer returns the configured authorization server whose path is
+// issuerPath, or the first one when issuerPath is empty
+func (h *AuthorizationServerHandler) selectIssuer(issuerPath string) string {
+	issuerPath = strings.TrimSuffix(issuerPath, "/")
+	for _, server := range h.Config.AuthorizationServers {
+		if issuerPath == "" {
+			return server
+		}
+		u, err := url.Parse(server)
+		if err == nil && strings.TrimSuffix(u.Path, "/") == issuerPath {
+			return server
+		}
+	}
+	return ""
+}
+
+// metadata returns the cached document of issuer, fetching it once the cache
+// entry has expired. A stale document is served if the refetch fails.
+func (h *AuthorizationServerHandler) metadata(ctx context.Context, kind metadataDocument, issuer string) (map[string]any, error) {
+	key := kind.wellKnownSuffix() + " " + issuer
+	h.mu.Lock()
+	cached, ok := h.cache[key]
+	h.mu.Unlock()
+	if ok && time.Since(cached.fetchedAt) < h.Config.CacheTTL {
+		return cached.doc, nil
+	}
+
+	doc, err := h.fetchMetadata(ctx, kind, issuer)
+	if err != nil {
+		if ok {
+			h.Logger.Warn("serving stale authorization server metadata", "issuer", issuer, "error", err)
+			return cached.doc, nil
+		}
+		return nil, err
+	}
+	h.mu.Lock()
+	h.cache[key] = cachedMetadata{doc: doc, fetchedAt: time.Now()}
+	h.mu.Unlock()
+	return doc, nil
+}
+
+// fetchMetadata tries each candidate location of the document in turn
+func (h *AuthorizationServerHandler) fetchMetadata(ctx context.Context, kind metadataDocument, issuer string) (map[string]any, error) {
+	issuerURL, err := url.Parse(issuer)
+	if err != nil {
+		return nil, fmt.Errorf("invalid authorization server %q: %w", issuer, err)
+	}
+	var errs []error
+	for _, candidate := range kind.candidateURLs(issuerURL) {
+		doc, err := h.fetchDocument(ctx, candidate)
+		if err == nil {
+			return doc, nil
+		}
+		errs = append(errs, err)
+	}
+	return nil, errors.Join(errs...)
+}
+
+func (h *AuthorizationServerHandler) fetchDocument(ctx context.Context, docURL string) (map[string]any, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, docURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := h.client().Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch %s: %w", docURL, err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch %s: status %d", docURL, resp.StatusCode)
+	}
+	var doc map[string]any
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxAuthorizationServerDocBytes)).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("failed to decode %s: %w", docURL, err)
+	}
+	return doc, nil
+}
+
+func (h *AuthorizationServerHandler) client() *http.Client {
+	if h.Client != nil {
+		return h.Client
+	}
+	return http.DefaultClient
+}
+
+// clientRegistrationError is an RFC 7591 registration error response
+type clientRegistrationError struct {
+	Error       string `json:"error"`
+	Description string `json:"error_description"`
+}
+
+// HandleRegistration handles the /oauth/register endpoint. Registrations are
+// checked against the allowed redirect URIs, grant and response types, scopes
+// and token endpoint auth methods, and forwarded to the registration endpoint
+// of the first authorization server.
+func (h *AuthorizationServerHandler) HandleRegistration(w http.ResponseWriter, r *http.Request) {
+	setCORSHeaders(w)
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var registration map[string]any
+	if err := json.NewDecoder(io.LimitReader(r.Body, maxClientRegistrationBytes)).Decode(&registration); err != nil {
+		writeRegistrationError(w, "invalid_client_metadata", "the request body is not a JSON object")
+		return
+	}
+	if code, description := h.checkRegistration(registration); code != "" {
+		h.Logger.Info("rejecting client registration", "reason", description)
+		writeRegistrationError(w, code, description)
+		return
+	}
+
+	issuer := h.Config.AuthorizationServers[0]
+	doc, err := h.metadata(r.Context(), oauthAuthorizationServerDocument, issuer)
+	if err != nil {
+		h.Logger.Error("failed to fetch authorization server metadata", "issuer", issuer, "error", err)
+		http.Error(w, "authorization server metadata unavailable", http.StatusBadGateway)
+		return
+	}
+	endpoint, _ := doc["registration_endpoint"].(string)
+	if endpoint == "" {
+		h.Logger.Error("authorization server does not advertise a registration endpoint", "issuer", issuer)
+		http.Error(w, "authorization server does not support dynamic client registration", http.StatusBadGateway)
+		return
+	}
+
+	body, err := json.Marshal(registration)
+	if err != nil {
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+	req, err := http.NewRequestWithContext(r.Context(), http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	if h.Config.InitialAccessToken != "" {
+		req.Header.Set("Authorization", "Bearer "+h.Config.InitialAccessToken)
+	}
+	resp, err := h.client().Do(req)
+	if err != nil {
+		h.Logger.Error("failed to register client upstream", "endpoint", endpoint, "error", err)
+		http.Error(w, "client registration failed", http.StatusBadGateway)
+		return
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if contentType := resp.Header.Get("Content-Type"); contentType != "" {
+		w.Header().Set("Content-Type", contentType)
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(resp.StatusCode)
+	if _, err := io.Copy(w, io.LimitReader(resp.Body, maxClientRegistrationBytes)); err != nil {
+		h.Logger.Error("failed to copy client registration response", "error", err)
+	}
+}
+
+// responseTypeGrants maps each response type a client may register to the
+// grant type it is used with (RFC 7591 section 2.1)
+var responseTypeGrants = map[string]string{
+	"code":  "authorization_code",
+	"token": "implicit",
+}
+
+// checkRegistration validates a registration request against the allowlists.
+// It returns the RFC 7591 error code and description of the first violation.
+func (h *AuthorizationServerHandler) checkRegistration(registration map[string]any) (string, string) {
+	grantTypes, ok := stringList(registration["grant_types"])
+	if !ok {
+		return "invalid_client_metadata", "grant_types must be a list of strings"
+	}
+	if len(grantTypes) == 0 {
+		// RFC 7591 section 2 default
+		grantTypes = []string{"authorization_code"}
+	}
+	for _, grantType := range grantTypes {
+		if !slices.Contains(h.Config.AllowedGrantTypes, grantType) {
+			return "invalid_client_metadata", fmt.Sprintf("grant type %q is not allowed", grantType)
+		}
+	}
+
+	redirectURIs, ok := stringList(registration["redirect_uris"])
+	if !ok {
+		return "invalid_redirect_uri", "redirect_uris must be a list of strings"
+	}
+	if len(redirectURIs) == 0 && slices.Contains(grantTypes, "authorization_code") {
+		return "invalid_redirect_uri", "redirect_uris is required for the authorization_code grant"
+	}
+	for _, redirectURI := range redirectURIs {
+		if !redirectURIAllowed(h.Config.AllowedRedirectURIs, redirectURI) {
+			return "invalid_redirect_uri", fmt.Sprintf("redirect URI %q is not allowed", redirectURI)
+		}
+	}
+
+	responseTypes, ok := stringList(registration["response_types"])
+	if !ok {
+		return "invalid_client_metadata", "response_types must be a list of strings"
+	}
+	for _, responseType := range responseTypes {
+		if grantType, known := responseTypeGrants[responseType]; !known || !slices.Contains(grantTypes, grantType) {
+			return "invalid_client_metadata", fmt.Sprintf("response type %q is not allowed", responseType)
+		}
+	}
+
+	if value, present := registration["scope"]; present {
+		scope, ok := value.(string)
+		if !ok {
+			return "invalid_client_metadata", "scope must be a string"
+		}
+		for item := range strings.FieldsSeq(scope) {
+			if !slices.Contains(h.Config.AllowedScopes, item) {
+				return "invalid_client_metadata", fmt.Sprintf("scope %q is not allowed", item)
+			}
+		}
+	}
+
+	if value, present := registration["token_endpoint_auth_method"]; present {
+		method, ok := value.(string)
+		if !ok {
+			return "invalid_client_metadata", "token_endpoint_auth_method must be a string"
+		}
+		if !slices.Contains(h.Config.AllowedTokenEndpointAuthMethods, method) {
+			return "invalid_client_metadata", fmt.Sprintf("token endpoint auth method %q is not allowed", method)
+		}
+	}
+	return "", ""
+}
+
+// stringList converts a decoded JSON value to a list of strings. A missing
+// value is an empty list.
+func stringList(value any) ([]string, bool) {
+	if value == nil {
+		return nil, true
+	}
+	items, ok := value.([]any)
+	if !ok {
+		return nil, false
+	}
+	out := make([]string, 0, len(items))
+	for _, item := range items {
+		s, ok := item.(string)
+		if !ok {
+			return nil, false
+		}
+		out = append(out, s)
+	}
+	return out, true
+}
+
+// redirectURIAllowed reports whether redirectURI matches an allowed URI
+// exactly, or starts with an allowed pattern ending in * on the same host
+func redirectURIAllowed(allowed []string, redirectURI string) bool {
+	target, err := url.Parse(redirectURI)
+	if err != nil || target.Scheme == "" || target.User != nil || target.Fragment != "" {
+		return false
+	}
+	for _, pattern := range allowed {
+		prefix, wildcard := strings.CutSuffix(pattern, "*")
+		if !wildcard {
+			if redirectURI == pattern {
+				return true
+			}
+			continue
+		}
+		if !strings.HasPrefix(redirectURI, prefix) {
+			continue
+		}
+		// a prefix such as http://localhost: must not admit http://localhost:@evil.example
+		base, err := url.Parse(prefix)
+		if err == nil && strings.EqualFold(base.Hostname(), target.Hostname()) {
+			return true
+		}
+	}
+	return false
+}
+
+func writeRegistrationError(w http.ResponseWriter, code, description string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusBadRequest)
+	_ = json.NewEncoder(w).Encode(clientRegistrationError{Error: code, Description: description})
+}
+
+// setCORSHeaders allows browser based clients to run OAuth discovery
+func setCORSHeaders(w http.ResponseWriter) {
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Header().Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS, HEAD")
+	w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, Accept, Origin, X-Requested-With")
+	w.Header().Set("Access-Control-Max-Age", "3600")
+}
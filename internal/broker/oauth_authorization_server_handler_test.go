@@ -0,0 +1,427 @@
+package broker
+
+import (
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// fakeAuthorizationServer serves RFC 8414 metadata for the realm at /realms/mcp
+// in the appended form Keycloak uses, and a registration endpoint
+type fakeAuthorizationServer struct {
+	*httptest.Server
+	metadataFetches atomic.Int32
+	registrations   atomic.Int32
+	lastAuthHeader  atomic.Value
+	lastBody        atomic.Value
+	unavailable     atomic.Bool
+}
+
+func newFakeAuthorizationServer(t *testing.T) *fakeAuthorizationServer {
+	t.Helper()
+	as := &fakeAuthorizationServer{}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/realms/mcp/.well-known/oauth-authorization-server", func(w http.ResponseWriter, _ *http.Request) {
+		as.metadataFetches.Add(1)
+		if as.unavailable.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"issuer":                 as.URL + "/realms/mcp",
+			"authorization_endpoint": as.URL + "/realms/mcp/protocol/openid-connect/auth",
+			"registration_endpoint":  as.URL + "/realms/mcp/clients-registrations/openid-connect",
+		})
+	})
+	mux.HandleFunc("/realms/mcp/.well-known/openid-configuration", func(w http.ResponseWriter, _ *http.Request) {
+		as.metadataFetches.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"issuer":   as.URL + "/realms/mcp",
+			"jwks_uri": as.URL + "/realms/mcp/protocol/openid-connect/certs",
+		})
+	})
+	mux.HandleFunc("/realms/mcp/clients-registrations/openid-connect", func(w http.ResponseWriter, r *http.Request) {
+		as.registrations.Add(1)
+		as.lastAuthHeader.Store(r.Header.Get("Authorization"))
+		body, _ := io.ReadAll(r.Body)
+		as.lastBody.Store(string(body))
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(`{"client_id":"generated-client"}`))
+	})
+	as.Server = httptest.NewServer(mux)
+	t.Cleanup(as.Close)
+	return as
+}
+
+func newTestAuthorizationServerHandler(cfg *AuthorizationServerConfig) *AuthorizationServerHandler {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+	return NewAuthorizationServerHandler(logger, cfg)
+}
+
+func TestGetAuthorizationServerConfig(t *testing.T) {
+	t.Run("disabled by default", func(t *testing.T) {
+		t.Setenv(envOAuthMetadataProxy, "")
+		require.Nil(t, GetAuthorizationServerConfig())
+	})
+
+	t.Run("defaults", func(t *testing.T) {
+		t.Setenv(envOAuthMetadataProxy, "true")
+		t.Setenv(envOAuthAuthorizationServers, "https://a.example.com/realms/mcp, https://b.example.com")
+		cfg := GetAuthorizationServerConfig()
+		require.NotNil(t, cfg)
+		require.Equal(t, []string{"https://a.example.com/realms/mcp", "https://b.example.com"}, cfg.AuthorizationServers)
+		require.Equal(t, defaultOAuthMetadataCacheTTL, cfg.CacheTTL)
+		require.Empty(t, cfg.RegistrationEndpoint)
+		require.Equal(t, []string{"authorization_code", "refresh_token"}, cfg.AllowedGrantTypes)
+		require.Equal(t, []string{"none", "client_secret_basic", "client_secret_post"}, cfg.AllowedTokenEndpointAuthMethods)
+	})
+
+	t.Run("dynamic client registration", func(t *testing.T) {
+		t.Setenv(envOAuthMetadataProxy, "true")
+		t.Setenv(envOAuthAuthorizationServers, "https://a.example.com/realms/mcp")
+		t.Setenv(envOAuthMetadataCacheTTL, "60")
+		t.Setenv(envOAuthDCREndpoint, "https://mcp.example.com/oauth/register")
+		t.Setenv(envOAuthDCRRedirectURIs, "http://localhost:*,https://app.example.com/cb")
+		t.Setenv(envOAuthDCRGrantTypes, "authorization_code")
+		t.Setenv(envOAuthDCRInitialAccessToken, "iat")
+		t.Setenv(envOAuthScopesSupported, "basic,groups")
+		cfg := GetAuthorizationServerConfig()
+		require.NotNil(t, cfg)
+		require.Equal(t, time.Minute, cfg.CacheTTL)
+		require.Equal(t, "https://mcp.example.com/oauth/register", cfg.RegistrationEndpoint)
+		require.Equal(t, []string{"http://localhost:*", "https://app.example.com/cb"}, cfg.AllowedRedirectURIs)
+		require.Equal(t, []string{"authorization_code"}, cfg.AllowedGrantTypes)
+		require.Equal(t, []string{"basic", "groups"}, cfg.AllowedScopes)
+		require.Equal(t, "iat", cfg.InitialAccessToken)
+	})
+}
+
+func TestMetadataDocumentCandidateURLs(t *testing.T) {
+	testCases := []struct {
+		name   string
+		kind   metadataDocument
+		issuer string
+		want   []string
+	}{
+		{
+			name:   "oauth metadata of an issuer with a path tries the inserted form first",
+			kind:   oauthAuthorizationServerDocument,
+			issuer: "https://kc.example.com/realms/mcp",
+			want: []string{
+				"https://kc.example.com/.well-known/oauth-authorization-server/realms/mcp",
+				"https://kc.example.com/realms/mcp/.well-known/oauth-authorization-server",
+			},
+		},
+		{
+			name:   "openid configuration tries the appended form first",
+			kind:   openIDConfigurationDocument,
+			issuer: "https://kc.example.com/realms/mcp/",
+			want: []string{
+				"https://kc.example.com/realms/mcp/.well-known/openid-configuration",
+				"https://kc.example.com/.well-known/openid-configuration/realms/mcp",
+			},
+		},
+		{
+			name:   "issuer without a path",
+			kind:   oauthAuthorizationServerDocument,
+			issuer: "https://as.example.com",
+			want:   []string{"https://as.example.com/.well-known/oauth-authorization-server"},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			issuer, err := url.Parse(tc.issuer)
+			require.NoError(t, err)
+			require.Equal(t, tc.want, tc.kind.candidateURLs(issuer))
+		})
+	}
+}
+
+func TestAuthorizationServerHandler_HandleMetadata(t *testing.T) {
+	as := newFakeAuthorizationServer(t)
+	issuer := as.URL + "/realms/mcp"
+
+	get := func(t *testing.T, h *AuthorizationServerHandler, path string) (*httptest.ResponseRecorder, map[string]any) {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		h.HandleMetadata(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		var doc map[string]any
+		if rec.Code == http.StatusOK {
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &doc))
+		}
+		return rec, doc
+	}
+
+	t.Run("proxies and caches the oauth metadata", func(t *testing.T) {
+		fetchesBefore := as.metadataFetches.Load()
+		h := newTestAuthorizationServerHandler(&AuthorizationServerConfig{
+			AuthorizationServers: []string{issuer},
+			CacheTTL:             time.Minute,
+		})
+		rec, doc := get(t, h, OAuthAuthorizationServerPath)
+		require.Equal(t, http.StatusOK, rec.Code)
+		require.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+		require.Equal(t, "*", rec.Header().Get("Access-Control-Allow-Origin"))
+		require.Equal(t, issuer, doc["issuer"])
+		require.Equal(t, issuer+"/clients-registrations/openid-connect", doc["registration_endpoint"],
+			"the upstream registration endpoint is advertised without dynamic client registration")
+
+		_, _ = get(t, h, OAuthAuthorizationServerPath)
+		// the inserted form 404s, then the appended form is fetched once and cached
+		require.Equal(t, fetchesBefore+1, as.metadataFetches.Load())
+	})
+
+	t.Run("serves the openid configuration", func(t *testing.T) {
+		h := newTestAuthorizationServerHandler(&AuthorizationServerConfig{
+			AuthorizationServers: []string{issuer},
+			CacheTTL:             time.Minute,
+		})
+		rec, doc := get(t, h, OpenIDConfigurationPath)
+		require.Equal(t, http.StatusOK, rec.Code)
+		require.Equal(t, issuer+"/protocol/openid-connect/certs", doc["jwks_uri"])
+	})
+
+	t.Run("issuer path selects the authorization server", func(t *testing.T) {
+		h := newTestAuthorizationServerHandler(&AuthorizationServerConfig{
+			AuthorizationServers: []string{"https://other.example.com/realms/other", issuer},
+			CacheTTL:             time.Minute,
+		})
+		rec, doc := get(t, h, OAuthAuthorizationServerPath+"/realms/mcp")
+		require.Equal(t, http.StatusOK, rec.Code)
+		require.Equal(t, issuer, doc["issuer"])
+
+		rec, _ = get(t, h, OAuthAuthorizationServerPath+"/realms/unknown")
+		require.Equal(t, http.StatusNotFound, rec.Code)
+	})
+
+	t.Run("registration endpoint is rewritten when dynamic client registration is enabled", func(t *testing.T) {
+		h := newTestAuthorizationServerHandler(&AuthorizationServerConfig{
+			AuthorizationServers: []string{issuer},
+			CacheTTL:             time.Minute,
+			RegistrationEndpoint: "https://mcp.example.com/oauth/register",
+		})
+		_, doc := get(t, h, OAuthAuthorizationServerPath)
+		require.Equal(t, "https://mcp.example.com/oauth/register", doc["registration_endpoint"])
+
+		cached, err := h.metadata(t.Context(), oauthAuthorizationServerDocument, issuer)
+		require.NoError(t, err)
+		require.Equal(t, issuer+"/clients-registrations/openid-connect", cached["registration_endpoint"],
+			"the cached document keeps the upstream registration endpoint")
+	})
+
+	t.Run("stale metadata is served when the refetch fails", func(t *testing.T) {
+		h := newTestAuthorizationServerHandler(&AuthorizationServerConfig{
+			AuthorizationServers: []string{issuer},
+			CacheTTL:             time.Minute,
+		})
+		rec, _ := get(t, h, OAuthAuthorizationServerPath)
+		require.Equal(t, http.StatusOK, rec.Code)
+
+		as.unavailable.Store(true)
+		defer as.unavailable.Store(false)
+		for key, entry := range h.cache {
+			entry.fetchedAt = time.Now().Add(-time.Hour)
+			h.cache[key] = entry
+		}
+		rec, doc := get(t, h, OAuthAuthorizationServerPath)
+		require.Equal(t, http.StatusOK, rec.Code)
+		require.Equal(t, issuer, doc["issuer"])
+	})
+
+	t.Run("unreachable authorization server is a bad gateway", func(t *testing.T) {
+		as.unavailable.Store(true)
+		defer as.unavailable.Store(false)
+		h := newTestAuthorizationServerHandler(&AuthorizationServerConfig{
+			AuthorizationServers: []string{issuer},
+			CacheTTL:             time.Minute,
+		})
+		rec, _ := get(t, h, OAuthAuthorizationServerPath)
+		require.Equal(t, http.StatusBadGateway, rec.Code)
+	})
+
+	t.Run("rejects other methods", func(t *testing.T) {
+		h := newTestAuthorizationServerHandler(&AuthorizationServerConfig{AuthorizationServers: []string{issuer}})
+		rec := httptest.NewRecorder()
+		h.HandleMetadata(rec, httptest.NewRequest(http.MethodPost, OAuthAuthorizationServerPath, nil))
+		require.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+	})
+}
+
+func TestAuthorizationServerHandler_HandleRegistration(t *testing.T) {
+	as := newFakeAuthorizationServer(t)
+	h := newTestAuthorizationServerHandler(&AuthorizationServerConfig{
+		AuthorizationServers:            []string{as.URL + "/realms/mcp"},
+		CacheTTL:                        time.Minute,
+		RegistrationEndpoint:            "https://mcp.example.com/oauth/register",
+		AllowedRedirectURIs:             []string{"http://localhost:*", "https://app.example.com/callback"},
+		AllowedGrantTypes:               []string{"authorization_code", "refresh_token"},
+		AllowedScopes:                   []string{"basic", "groups"},
+		AllowedTokenEndpointAuthMethods: []string{"none", "client_secret_basic"},
+		InitialAccessToken:              "initial-token",
+	})
+
+	testCases := []struct {
+		name       string
+		body       string
+		wantStatus int
+		wantError  string
+	}{
+		{
+			name:       "allowed registration is forwarded",
+			body:       `{"client_name":"inspector","redirect_uris":["http://localhost:6274/oauth/callback"],"grant_types":["authorization_code","refresh_token"]}`,
+			wantStatus: http.StatusCreated,
+		},
+		{
+			name:       "grant types default to authorization_code",
+			body:       `{"redirect_uris":["https://app.example.com/callback"]}`,
+			wantStatus: http.StatusCreated,
+		},
+		{
+			name:       "redirect uri outside the allowlist",
+			body:       `{"redirect_uris":["https://evil.example.com/callback"]}`,
+			wantStatus: http.StatusBadRequest,
+			wantError:  "invalid_redirect_uri",
+		},
+		{
+			name:       "wildcard prefix does not admit another host",
+			body:       `{"redirect_uris":["http://localhost:@evil.example.com/callback"]}`,
+			wantStatus: http.StatusBadRequest,
+			wantError:  "invalid_redirect_uri",
+		},
+		{
+			name:       "authorization code grant requires redirect uris",
+			body:       `{"grant_types":["authorization_code"]}`,
+			wantStatus: http.StatusBadRequest,
+			wantError:  "invalid_redirect_uri",
+		},
+		{
+			name:       "grant type outside the allowlist",
+			body:       `{"redirect_uris":["http://localhost:8080/cb"],"grant_types":["client_credentials"]}`,
+			wantStatus: http.StatusBadRequest,
+			wantError:  "invalid_client_metadata",
+		},
+		{
+			name:       "malformed grant types",
+			body:       `{"redirect_uris":["http://localhost:8080/cb"],"grant_types":"authorization_code"}`,
+			wantStatus: http.StatusBadRequest,
+			wantError:  "invalid_client_metadata",
+		},
+		{
+			name:       "allowed response type, scope and auth method are forwarded",
+			body:       `{"redirect_uris":["http://localhost:8080/cb"],"response_types":["code"],"scope":"basic groups","token_endpoint_auth_method":"none"}`,
+			wantStatus: http.StatusCreated,
+		},
+		{
+			name:       "response type without its grant type",
+			body:       `{"redirect_uris":["http://localhost:8080/cb"],"response_types":["token"]}`,
+			wantStatus: http.StatusBadRequest,
+			wantError:  "invalid_client_metadata",
+		},
+		{
+			name:       "unknown response type",
+			body:       `{"redirect_uris":["http://localhost:8080/cb"],"response_types":["code id_token"]}`,
+			wantStatus: http.StatusBadRequest,
+			wantError:  "invalid_client_metadata",
+		},
+		{
+			name:       "malformed response types",
+			body:       `{"redirect_uris":["http://localhost:8080/cb"],"response_types":"code"}`,
+			wantStatus: http.StatusBadRequest,
+			wantError:  "invalid_client_metadata",
+		},
+		{
+			name:       "scope outside the allowlist",
+			body:       `{"redirect_uris":["http://localhost:8080/cb"],"scope":"basic admin"}`,
+			wantStatus: http.StatusBadRequest,
+			wantError:  "invalid_client_metadata",
+		},
+		{
+			name:       "malformed scope",
+			body:       `{"redirect_uris":["http://localhost:8080/cb"],"scope":["basic"]}`,
+			wantStatus: http.StatusBadRequest,
+			wantError:  "invalid_client_metadata",
+		},
+		{
+			name:       "token endpoint auth method outside the allowlist",
+			body:       `{"redirect_uris":["http://localhost:8080/cb"],"token_endpoint_auth_method":"private_key_jwt","jwks_uri":"http://169.254.169.254/"}`,
+			wantStatus: http.StatusBadRequest,
+			wantError:  "invalid_client_metadata",
+		},
+		{
+			name:       "malformed token endpoint auth method",
+			body:       `{"redirect_uris":["http://localhost:8080/cb"],"token_endpoint_auth_method":true}`,
+			wantStatus: http.StatusBadRequest,
+			wantError:  "invalid_client_metadata",
+		},
+		{
+			name:       "body is not json",
+			body:       `client_name=inspector`,
+			wantStatus: http.StatusBadRequest,
+			wantError:  "invalid_client_metadata",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			registrationsBefore := as.registrations.Load()
+			rec := httptest.NewRecorder()
+			h.HandleRegistration(rec, httptest.NewRequest(http.MethodPost, ClientRegistrationPath, strings.NewReader(tc.body)))
+			require.Equal(t, tc.wantStatus, rec.Code)
+			require.Equal(t, "no-store", rec.Header().Get("Cache-Control"))
+
+			if tc.wantError != "" {
+				var regErr clientRegistrationError
+				require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &regErr))
+				require.Equal(t, tc.wantError, regErr.Error)
+				require.Equal(t, registrationsBefore, as.registrations.Load(), "rejected registrations must not reach the authorization server")
+				return
+			}
+			require.JSONEq(t, `{"client_id":"generated-client"}`, rec.Body.String())
+			require.Equal(t, registrationsBefore+1, as.registrations.Load())
+			require.Equal(t, "Bearer initial-token", as.lastAuthHeader.Load())
+			require.JSONEq(t, tc.body, as.lastBody.Load().(string))
+		})
+	}
+
+	t.Run("rejects other methods", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		h.HandleRegistration(rec, httptest.NewRequest(http.MethodGet, ClientRegistrationPath, nil))
+		require.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+	})
+}
+
+func TestRedirectURIAllowed(t *testing.T) {
+	allowed := []string{"http://localhost:*", "https://app.example.com/callback", "https://app.example.com/oauth/*"}
+	testCases := []struct {
+		uri  string
+		want bool
+	}{
+		{"https://app.example.com/callback", true},
+		{"https://app.example.com/callback/extra", false},
+		{"http://localhost:6274/oauth/callback", true},
+		{"http://localhost:8080", true},
+		{"https://app.example.com/oauth/inspector", true},
+		{"http://localhost:@evil.example.com/", false},
+		{"http://localhost:8080.evil.example.com/", false},
+		{"https://app.example.com/callback#fragment", false},
+		{"/callback", false},
+		{"https://evil.example.com/callback", false},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.uri, func(t *testing.T) {
+			require.Equal(t, tc.want, redirectURIAllowed(allowed, tc.uri))
+		})
+	}
+}
@@ -45,7 +45,8 @@ type protocolCacheEntry[T any] struct {
 // rebuildProtocolCaches partitions the current gateway server tools and
 // prompts into stateful (2025) and stateless (2026) sets based on each
 // upstream server's supportedVersions. Broker meta-tools (those without
-// kuadrant/id) are included only in the stateful set.
+// kuadrant/id) are included only in the stateful set. The discover_tools
+// search index and tool sizes are rebuilt from the same tools.
 func (m *mcpBrokerImpl) rebuildProtocolCaches() {
 	// partition tools
 	allTools := m.gatewayServer.ListTools()
@@ -122,6 +123,21 @@ func (m *mcpBrokerImpl) rebuildProtocolCaches() {
 	m.statefulTools.Store(&statefulT)
 	m.statelessTools.Store(&statelessT)
 
+	if m.discovery.enabled {
+		servers := make(map[config.UpstreamMCPID]config.MCPServer, len(m.mcpServers))
+		for _, mgr := range m.mcpServers {
+			cfg := mgr.Config()
+			servers[cfg.ID()] = cfg
+		}
+		tools := make([]*mcp.Tool, 0, len(allTools))
+		for _, gt := range allTools {
+			tools = append(tools, &gt.Tool)
+		}
+		m.toolIndex.Store(buildToolSearchIndex(tools, servers))
+		sizes := buildToolSizes(tools)
+		m.toolSizes.Store(&sizes)
+	}
+
 	// partition prompts
 	allPrompts := m.gatewayServer.ListPrompts()
 	var statefulP, statelessP protocolCacheEntry[*mcp.Prompt]
@@ -1,6 +1,7 @@
 package broker
 
 import (
+	"github.com/Kuadrant/mcp-gateway/internal/config"
 	"github.com/Kuadrant/mcp-gateway/internal/routing"
 )
 
@@ -11,19 +12,15 @@ func (m *mcpBrokerImpl) buildRoutingTable() *routing.Table {
 
 	for id, up := range m.mcpServers {
 		cfg := up.Config()
-		route := &routing.ServerRoute{
-			Name:             cfg.Name,
-			Host:             cfg.Hostname,
-			Prefix:           cfg.Prefix,
-			URL:              cfg.URL,
-			UserSpecificList: cfg.UserSpecificList,
+		if m.isDrained(cfg.Name) {
+			continue
 		}
-		if p, err := cfg.Path(); err == nil {
-			route.Path = p
-		}
-		if cfg.TokenURLElicitation != nil {
-			route.TokenURLElicitation = &routing.TokenURLElicitationRoute{
-				URL: cfg.TokenURLElicitation.URL,
+		route := newServerRoute(cfg)
+		if canary := up.GetStatus().Canary; canary != nil && canary.Routed && cfg.Canary != nil {
+			route.Canary = &routing.CanaryRoute{
+				Name: canary.Name,
+				Host: cfg.Canary.Hostname,
+				URL:  cfg.Canary.URL,
 			}
 		}
 
@@ -58,13 +55,7 @@ func (m *mcpBrokerImpl) buildRoutingTable() *routing.Table {
 		}
 	}
 
-	// always register broker meta-tool names so the router forwards
-	// calls to the broker regardless of feature state — the broker
-	// returns a proper JSON-RPC error when the feature is disabled
-	b.AddBrokerTool(discoverToolsName)
-	b.AddBrokerTool(selectToolsName)
-	b.AddBrokerTool(listTagsName)
-	b.AddBrokerTool(filterToolsByTagsName)
+	addBrokerTools(b)
 
 	return b.Build()
 }
@@ -87,3 +78,61 @@ func (m *mcpBrokerImpl) RoutingTable() routing.RoutingTable {
 	m.refreshRoutingTable()
 	return m.cachedTable.Load()
 }
+
+// BuildRoutingTable creates a routing.Table for servers from a tools snapshot
+// instead of live upstream connections, registering routes the same way the
+// broker does. Servers missing from the snapshot contribute only their
+// prefix routes. Tool annotations and canary checks are not part of a
+// snapshot, so no annotations or canary routes are registered.
+func BuildRoutingTable(servers []config.MCPServer, snapshot config.ToolsSnapshot) *routing.Table {
+	b := routing.NewTableBuilder()
+	for _, cfg := range servers {
+		route := newServerRoute(cfg)
+		tools, _ := snapshot.Lookup(cfg.Name)
+		if cfg.UserSpecificList && cfg.Prefix != "" {
+			b.AddPrefix(cfg.Prefix, route)
+		}
+		if tools.Resources && cfg.Prefix != "" && resourcePrefixAllowlist.MatchString(cfg.Prefix) {
+			b.AddResourcePrefix(cfg.Prefix, route)
+		}
+		for _, tool := range tools.Tools {
+			b.AddTool(cfg.Prefix+tool, route)
+		}
+		for _, prompt := range tools.Prompts {
+			b.AddPrompt(cfg.Prefix+prompt, route)
+		}
+	}
+	addBrokerTools(b)
+	return b.Build()
+}
+
+// newServerRoute builds the route the router forwards to for a server
+func newServerRoute(cfg config.MCPServer) *routing.ServerRoute {
+	route := &routing.ServerRoute{
+		Name:             cfg.Name,
+		Host:             cfg.Hostname,
+		Prefix:           cfg.Prefix,
+		URL:              cfg.URL,
+		UserSpecificList: cfg.UserSpecificList,
+	}
+	if p, err := cfg.Path(); err == nil {
+		route.Path = p
+	}
+	if cfg.TokenURLElicitation != nil {
+		route.TokenURLElicitation = &routing.TokenURLElicitationRoute{
+			URL: cfg.TokenURLElicitation.URL,
+		}
+	}
+	return route
+}
+
+// addBrokerTools registers the broker meta-tool names. They are always
+// registered so the router forwards calls to the broker regardless of
+// feature state — the broker returns a proper JSON-RPC error when the
+// feature is disabled.
+func addBrokerTools(b *routing.TableBuilder) {
+	b.AddBrokerTool(discoverToolsName)
+	b.AddBrokerTool(selectToolsName)
+	b.AddBrokerTool(listTagsName)
+	b.AddBrokerTool(filterToolsByTagsName)
+}
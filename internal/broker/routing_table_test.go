@@ -7,6 +7,7 @@ import (
 
 	"github.com/Kuadrant/mcp-gateway/internal/broker/upstream"
 	"github.com/Kuadrant/mcp-gateway/internal/config"
+	"github.com/Kuadrant/mcp-gateway/internal/routing"
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 	"github.com/stretchr/testify/assert"
 )
@@ -18,12 +19,13 @@ import (
 type resourceCapableMockServer struct {
 	cfg               config.MCPServer
 	supportsResources bool
+	status            upstream.ServerValidationStatus
 }
 
 func (m *resourceCapableMockServer) Stop()           {}
 func (m *resourceCapableMockServer) MCPName() string { return m.cfg.Name }
 func (m *resourceCapableMockServer) GetStatus() upstream.ServerValidationStatus {
-	return upstream.ServerValidationStatus{}
+	return m.status
 }
 func (m *resourceCapableMockServer) GetManagedTools() []mcp.Tool           { return nil }
 func (m *resourceCapableMockServer) GetServedManagedTool(string) *mcp.Tool { return nil }
@@ -45,6 +47,41 @@ func (m *resourceCapableMockServer) SupportsResources() bool { return m.supports
 func (m *resourceCapableMockServer) ListResources(context.Context) (*mcp.ListResourcesResult, error) {
 	return nil, ErrListResourcesNotImplemented
 }
+func (m *resourceCapableMockServer) SupportsLogging() bool { return false }
+func (m *resourceCapableMockServer) SetLoggingLevel(context.Context, mcp.LoggingLevel) error {
+	return nil
+}
+
+func (m *resourceCapableMockServer) Refresh() {}
+
+func TestBuildRoutingTable_Canary(t *testing.T) {
+	canaryCfg := &config.CanaryConfig{URL: "http://weather-v2.mcp.local:8080/mcp", Hostname: "weather-v2.mcp.local", Weight: 10}
+	server := func(name string, canary *upstream.CanaryStatus) *resourceCapableMockServer {
+		return &resourceCapableMockServer{
+			cfg:    config.MCPServer{Name: name, Prefix: name + "_", UserSpecificList: true, Canary: canaryCfg},
+			status: upstream.ServerValidationStatus{Canary: canary},
+		}
+	}
+	b := &mcpBrokerImpl{
+		logger: slog.Default(),
+		mcpServers: map[config.UpstreamMCPID]upstream.ActiveMCPServer{
+			"routed":    server("routed", &upstream.CanaryStatus{Name: "routed#canary", Routed: true}),
+			"rejected":  server("rejected", &upstream.CanaryStatus{Name: "rejected#canary", Reachable: true}),
+			"unchecked": server("unchecked", nil),
+		},
+	}
+
+	table := b.buildRoutingTable()
+
+	route, ok := table.LookupPrefix("routed_forecast")
+	assert.True(t, ok)
+	assert.Equal(t, &routing.CanaryRoute{Name: "routed#canary", Host: "weather-v2.mcp.local", URL: "http://weather-v2.mcp.local:8080/mcp"}, route.Canary)
+	for _, tool := range []string{"rejected_forecast", "unchecked_forecast"} {
+		route, ok := table.LookupPrefix(tool)
+		assert.True(t, ok)
+		assert.Nil(t, route.Canary, tool)
+	}
+}
 
 // TestBuildRoutingTable_ResourcePrefixSkipConditions confirms
 // buildRoutingTable registers a resource-prefix route only for servers that
@@ -82,3 +119,42 @@ func TestBuildRoutingTable_ResourcePrefixSkipConditions(t *testing.T) {
 	_, ok = table.LookupResourcePrefix("unsup_template.html")
 	assert.False(t, ok, "server that doesn't support resources must not be resource-routable")
 }
+
+func TestBuildRoutingTable_FromSnapshot(t *testing.T) {
+	servers := []config.MCPServer{
+		{Name: "team-a/weather", URL: "http://weather.team-a.svc:8080/v1/mcp", Hostname: "weather.mcp.local", Prefix: "weather_"},
+		{Name: "team-a/files", URL: "http://files.team-a.svc:8080/mcp", Hostname: "files.mcp.local", Prefix: "files_"},
+		{Name: "team-a/github", URL: "https://api.github.com/mcp", Hostname: "github.mcp.local", Prefix: "gh_", UserSpecificList: true},
+	}
+	snapshot := config.ToolsSnapshot{Servers: []config.ServerTools{
+		{Name: "team-a/weather", Tools: []string{"forecast"}, Prompts: []string{"summary"}},
+		{Name: "team-a/files", Tools: []string{"read"}, Resources: true},
+	}}
+
+	table := BuildRoutingTable(servers, snapshot)
+
+	route, ok := table.LookupTool("weather_forecast")
+	assert.True(t, ok)
+	assert.Equal(t, "team-a/weather", route.Name)
+	assert.Equal(t, "weather.mcp.local", route.Host)
+	assert.Equal(t, "/v1/mcp", route.Path)
+
+	route, ok = table.LookupPrompt("weather_summary")
+	assert.True(t, ok)
+	assert.Equal(t, "team-a/weather", route.Name)
+
+	route, ok = table.LookupResourcePrefix("files_notes.txt")
+	assert.True(t, ok)
+	assert.Equal(t, "team-a/files", route.Name)
+	_, ok = table.LookupResourcePrefix("weather_notes.txt")
+	assert.False(t, ok, "server without resources in the snapshot must not be resource-routable")
+
+	// servers missing from the snapshot are only reachable by prefix
+	_, ok = table.LookupTool("gh_create_issue")
+	assert.False(t, ok)
+	route, ok = table.LookupPrefix("gh_create_issue")
+	assert.True(t, ok)
+	assert.Equal(t, "team-a/github", route.Name)
+
+	assert.True(t, table.IsBrokerTool(discoverToolsName))
+}
@@ -208,11 +208,39 @@ func (h *sessionResurrectionHandler) resurrect(r *http.Request, sid string) (*re
 		InitializedParams: &mcp.InitializedParams{},
 		LogLevel:          "info",
 	}
+	// resurrected sessions never send notifications/initialized, so wire
+	// the session-end cleanup InitializedHandler would normally register.
+	e, err := h.connect(r, sid, &mcp.ServerSessionOptions{State: state}, func() {
+		h.broker.onGatewaySessionEnd(sid)
+	})
+	if err != nil {
+		return nil, err
+	}
+	h.broker.logger.Debug("gateway client session resurrected",
+		"gatewaySessionID", internaljwt.LogSafeSessionID(sid))
+	return e, nil
+}
+
+// establish connects a new, uninitialised session under a session ID the
+// broker minted for an initialize request, in place of the SDK handler
+// assigning its own. it is served from the live table like a resurrected
+// session; the SDK runs the client's initialize on it and InitializedHandler
+// wires its session-end cleanup.
+func (h *sessionResurrectionHandler) establish(r *http.Request, sid string) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	_, err := h.connect(r, sid, nil, nil)
+	return err
+}
+
+// connect serves a new server session from the live table under sid. onEnd,
+// if set, runs once the session closes. callers hold h.mu.
+func (h *sessionResurrectionHandler) connect(r *http.Request, sid string, opts *mcp.ServerSessionOptions, onEnd func()) (*resurrectedSession, error) {
 	transport := &mcp.StreamableServerTransport{SessionID: sid}
 	// Server.Connect cannot wire the transport's unexported toolLookup, so
 	// the SDK's tools/call param-header validation (>= 2026-07-28 clients
 	// only) is skipped for resurrected sessions.
-	ss, err := h.broker.MCPServer().Connect(r.Context(), transport, &mcp.ServerSessionOptions{State: state})
+	ss, err := h.broker.MCPServer().Connect(r.Context(), transport, opts)
 	if err != nil {
 		return nil, err
 	}
@@ -221,17 +249,15 @@ func (h *sessionResurrectionHandler) resurrect(r *http.Request, sid string) (*re
 		e.timer = time.AfterFunc(h.idleTimeout, func() { _ = ss.Close() })
 	}
 	h.live.Store(sid, e)
-	// resurrected sessions never send notifications/initialized, so wire
-	// the session-end cleanup InitializedHandler would normally register.
 	// Wait returns on any close (DELETE, jwt invalidation, idle timeout).
 	go func() {
 		_ = ss.Wait()
 		e.stopTimer()
 		h.live.CompareAndDelete(sid, e)
-		h.broker.onGatewaySessionEnd(sid)
+		if onEnd != nil {
+			onEnd()
+		}
 	}()
-	h.broker.logger.Debug("gateway client session resurrected",
-		"gatewaySessionID", internaljwt.LogSafeSessionID(sid))
 	return e, nil
 }
 
@@ -10,6 +10,7 @@ import (
 	"time"
 
 	"github.com/Kuadrant/mcp-gateway/internal/broker/upstream"
+	"github.com/Kuadrant/mcp-gateway/internal/config"
 )
 
 // ServerValidationStatus contains the validation status of a single MCP server
@@ -63,6 +64,10 @@ type StatusResponse struct {
 	ToolConflicts    int                               `json:"toolConflicts"`
 	Timestamp        time.Time                         `json:"timestamp"`
 	ScopedSessions   int                               `json:"scopedSessions"`
+	// CatalogRole is leader or follower when the shared catalog is enabled
+	CatalogRole string `json:"catalogRole,omitempty"`
+	// MaintenanceServers counts servers whose maintenance window is open
+	MaintenanceServers int `json:"maintenanceServers"`
 }
 
 // StatusHandler handles HTTP requests to the status endpoint
@@ -148,3 +153,20 @@ func (h *StatusHandler) sendErrorResponse(w http.ResponseWriter, statusCode int,
 	response := map[string]string{"error": message}
 	h.sendJSONResponse(w, statusCode, response)
 }
+
+// maintenanceStatus describes the maintenance window of a server in the
+// Maintenance state, nil otherwise
+func maintenanceStatus(cfg config.MCPServer, now time.Time) *upstream.MaintenanceStatus {
+	if cfg.State != config.StateMaintenance {
+		return nil
+	}
+	status := &upstream.MaintenanceStatus{
+		Active:  cfg.InMaintenance(now),
+		Message: cfg.MaintenanceMessage(),
+	}
+	if cfg.Maintenance != nil {
+		status.Start = cfg.Maintenance.Start
+		status.End = cfg.Maintenance.End
+	}
+	return status
+}
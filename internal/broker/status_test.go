@@ -9,6 +9,7 @@ import (
 	"net/http/httptest"
 	"os"
 	"testing"
+	"time"
 
 	"github.com/Kuadrant/mcp-gateway/internal/broker/upstream"
 	"github.com/Kuadrant/mcp-gateway/internal/config"
@@ -101,6 +102,7 @@ func TestValidateAllServers(t *testing.T) {
 		wantHealthy      int
 		wantUnhealthy    int
 		wantOverallValid bool
+		wantMaintenance  int
 	}{
 		{
 			name:             "no servers",
@@ -149,6 +151,19 @@ func TestValidateAllServers(t *testing.T) {
 			wantUnhealthy:    1,
 			wantOverallValid: false,
 		},
+		{
+			name: "servers in maintenance",
+			setup: func(b *mcpBrokerImpl) {
+				start := time.Now().Add(time.Hour)
+				b.mcpServers["s1"] = createMaintenanceManager(t, "s1", "s1_", nil, nil)
+				b.mcpServers["s2"] = createMaintenanceManager(t, "s2", "s2_", nil, &config.MaintenanceConfig{Start: &start})
+			},
+			wantTotal:        2,
+			wantHealthy:      2,
+			wantUnhealthy:    0,
+			wantOverallValid: true,
+			wantMaintenance:  1,
+		},
 	}
 
 	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
@@ -161,7 +176,33 @@ func TestValidateAllServers(t *testing.T) {
 			require.Equal(t, tt.wantHealthy, resp.HealthyServers)
 			require.Equal(t, tt.wantUnhealthy, resp.UnHealthyServers)
 			require.Equal(t, tt.wantOverallValid, resp.OverallValid)
+			require.Equal(t, tt.wantMaintenance, resp.MaintenanceServers)
 			require.Len(t, resp.Servers, tt.wantTotal)
 		})
 	}
 }
+
+func TestMaintenanceStatus(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	end := now.Add(time.Hour)
+
+	require.Nil(t, maintenanceStatus(config.MCPServer{Name: "ns/s", State: "Enabled"}, now))
+
+	status := maintenanceStatus(config.MCPServer{
+		Name:        "ns/s",
+		State:       config.StateMaintenance,
+		Maintenance: &config.MaintenanceConfig{End: &end},
+	}, now)
+	require.NotNil(t, status)
+	require.True(t, status.Active)
+	require.Equal(t, "server ns/s is under maintenance until 2026-03-01T13:00:00Z", status.Message)
+	require.Nil(t, status.Start)
+	require.Equal(t, &end, status.End)
+
+	status = maintenanceStatus(config.MCPServer{
+		Name:        "ns/s",
+		State:       config.StateMaintenance,
+		Maintenance: &config.MaintenanceConfig{End: &end},
+	}, end)
+	require.False(t, status.Active)
+}
@@ -0,0 +1,217 @@
+package broker
+
+import (
+	"cmp"
+	"math"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/Kuadrant/mcp-gateway/internal/config"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+const (
+	// BM25 term frequency saturation and length normalisation
+	bm25K1 = 1.2
+	bm25B  = 0.75
+
+	// field weights applied to term frequencies: a query term in a tool name
+	// says more about the tool than the same term in its description
+	searchWeightName        = 3.0
+	searchWeightCategoryTag = 2.0
+	searchWeightDescription = 1.0
+	searchWeightHint        = 1.0
+
+	defaultSearchLimit = 10
+	maxSearchLimit     = 50
+
+	// maxShortDescriptionRunes bounds the description returned per search result
+	maxShortDescriptionRunes = 160
+)
+
+// searchDocument is an indexed tool
+type searchDocument struct {
+	name        string
+	server      string
+	description string
+	categories  []string
+	terms       map[string]float64
+	length      float64
+}
+
+// toolSearchIndex is an in-process BM25 index over the gateway's tools. It is
+// immutable once built; rebuildProtocolCaches swaps in a new one.
+type toolSearchIndex struct {
+	docs      []searchDocument
+	postings  map[string][]int
+	avgLength float64
+}
+
+// toolMatch is a discover_tools search result
+type toolMatch struct {
+	Name        string  `json:"name"`
+	Server      string  `json:"server"`
+	Description string  `json:"description,omitempty"`
+	Score       float64 `json:"score"`
+	// Size is the size of the tool's schema in the response's sizeUnit
+	Size int64 `json:"size"`
+	// Maintenance is true while the tool's server is under maintenance
+	Maintenance bool `json:"maintenance,omitempty"`
+}
+
+// buildToolSearchIndex indexes tools by name, description and the hint,
+// categories and tags of the server they belong to. Broker meta-tools and
+// tools of unknown servers are skipped.
+func buildToolSearchIndex(tools []*mcp.Tool, servers map[config.UpstreamMCPID]config.MCPServer) *toolSearchIndex {
+	idx := &toolSearchIndex{postings: map[string][]int{}}
+	var totalLength float64
+	for _, tool := range tools {
+		if IsBrokerTool(tool) {
+			continue
+		}
+		cfg, ok := servers[metaServerID(tool.Meta)]
+		if !ok {
+			continue
+		}
+		doc := searchDocument{
+			name:        tool.Name,
+			server:      cfg.Name,
+			description: tool.Description,
+			categories:  cfg.Category,
+			terms:       map[string]float64{},
+		}
+		doc.addTerms(tool.Name, searchWeightName)
+		doc.addTerms(tool.Title, searchWeightName)
+		doc.addTerms(tool.Description, searchWeightDescription)
+		doc.addTerms(cfg.Hint, searchWeightHint)
+		doc.addTerms(cfg.Name, searchWeightCategoryTag)
+		for _, category := range cfg.Category {
+			doc.addTerms(category, searchWeightCategoryTag)
+		}
+		for _, tag := range cfg.Tags {
+			doc.addTerms(tag, searchWeightCategoryTag)
+		}
+		if doc.length == 0 {
+			continue
+		}
+		for term := range doc.terms {
+			idx.postings[term] = append(idx.postings[term], len(idx.docs))
+		}
+		totalLength += doc.length
+		idx.docs = append(idx.docs, doc)
+	}
+	if len(idx.docs) > 0 {
+		idx.avgLength = totalLength / float64(len(idx.docs))
+	}
+	return idx
+}
+
+func (d *searchDocument) addTerms(text string, weight float64) {
+	for _, term := range tokenize(text) {
+		d.terms[term] += weight
+		d.length += weight
+	}
+}
+
+// search returns up to limit tools matching query, best first. Only tools in
+// visible are considered, and only those on servers in categoryFilter when set.
+func (idx *toolSearchIndex) search(query string, limit int, visible map[string]struct{}, categoryFilter string) []toolMatch {
+	if idx == nil || len(idx.docs) == 0 {
+		return []toolMatch{}
+	}
+	queryTerms := tokenize(query)
+	slices.Sort(queryTerms)
+	queryTerms = slices.Compact(queryTerms)
+
+	n := float64(len(idx.docs))
+	scores := map[int]float64{}
+	for _, term := range queryTerms {
+		postings := idx.postings[term]
+		if len(postings) == 0 {
+			continue
+		}
+		df := float64(len(postings))
+		idf := math.Log(1 + (n-df+0.5)/(df+0.5))
+		for _, i := range postings {
+			doc := &idx.docs[i]
+			tf := doc.terms[term]
+			norm := bm25K1 * (1 - bm25B + bm25B*doc.length/idx.avgLength)
+			scores[i] += idf * tf * (bm25K1 + 1) / (tf + norm)
+		}
+	}
+
+	matches := make([]toolMatch, 0, len(scores))
+	for i, score := range scores {
+		doc := &idx.docs[i]
+		if _, ok := visible[doc.name]; !ok {
+			continue
+		}
+		if categoryFilter != "" && !matchesCategory(doc.categories, categoryFilter) {
+			continue
+		}
+		matches = append(matches, toolMatch{
+			Name:        doc.name,
+			Server:      doc.server,
+			Description: shortDescription(doc.description),
+			Score:       math.Round(score*1000) / 1000,
+		})
+	}
+	slices.SortFunc(matches, func(a, b toolMatch) int {
+		if c := cmp.Compare(b.Score, a.Score); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.Name, b.Name)
+	})
+	if len(matches) > limit {
+		matches = matches[:limit]
+	}
+	return matches
+}
+
+// tokenize lowercases text and splits it into terms on anything that is not
+// a letter or digit, and on camelCase boundaries, so get_weather, getWeather
+// and "get weather" produce the same terms
+func tokenize(text string) []string {
+	var terms []string
+	var current []rune
+	flush := func() {
+		if len(current) > 0 {
+			terms = append(terms, strings.ToLower(string(current)))
+			current = current[:0]
+		}
+	}
+	var prev rune
+	for _, r := range text {
+		switch {
+		case !unicode.IsLetter(r) && !unicode.IsDigit(r):
+			flush()
+		case unicode.IsUpper(r) && (unicode.IsLower(prev) || unicode.IsDigit(prev)):
+			flush()
+			current = append(current, r)
+		default:
+			current = append(current, r)
+		}
+		prev = r
+	}
+	flush()
+	return terms
+}
+
+// shortDescription returns the first sentence of a tool description, cut at
+// maxShortDescriptionRunes
+func shortDescription(description string) string {
+	description = strings.TrimSpace(description)
+	if line, _, found := strings.Cut(description, "\n"); found {
+		description = strings.TrimSpace(line)
+	}
+	if i := strings.Index(description, ". "); i >= 0 {
+		description = description[:i+1]
+	}
+	if utf8.RuneCountInString(description) <= maxShortDescriptionRunes {
+		return description
+	}
+	runes := []rune(description)
+	return strings.TrimSpace(string(runes[:maxShortDescriptionRunes-1])) + "…"
+}
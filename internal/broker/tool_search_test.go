@@ -0,0 +1,250 @@
+package broker
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Kuadrant/mcp-gateway/internal/config"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/stretchr/testify/require"
+)
+
+func testSearchIndex() *toolSearchIndex {
+	servers := map[config.UpstreamMCPID]config.MCPServer{
+		"ns/weather:weather_:http://weather": {
+			Name:     "ns/weather",
+			Category: []string{"weather"},
+			Hint:     "forecasts and current conditions by location",
+		},
+		"ns/calendar:cal_:http://calendar": {
+			Name:     "ns/calendar",
+			Category: []string{"scheduling"},
+			Hint:     "book meetings and check availability",
+			Tags:     []string{"productivity"},
+		},
+	}
+	tools := []*mcp.Tool{
+		{
+			Name:        "weather_get_forecast",
+			Description: "Get the multi-day forecast for a city. Returns highs and lows.",
+			Meta:        mcp.Meta{"kuadrant/id": "ns/weather:weather_:http://weather"},
+		},
+		{
+			Name:        "weather_current",
+			Description: "Current temperature and wind for a location.",
+			Meta:        mcp.Meta{"kuadrant/id": "ns/weather:weather_:http://weather"},
+		},
+		{
+			Name:        "cal_createEvent",
+			Description: "Create a calendar event, optionally inviting attendees.",
+			Meta:        mcp.Meta{"kuadrant/id": "ns/calendar:cal_:http://calendar"},
+		},
+		{
+			Name:        "cal_free_busy",
+			Description: "Check which attendees are free in a time window.",
+			Meta:        mcp.Meta{"kuadrant/id": "ns/calendar:cal_:http://calendar"},
+		},
+		{
+			Name:        discoverToolsName,
+			Description: "broker tool mentioning weather forecast",
+		},
+		{
+			Name:        "orphan_forecast",
+			Description: "tool of a server that is no longer registered",
+			Meta:        mcp.Meta{"kuadrant/id": "ns/gone:orphan_:http://gone"},
+		},
+	}
+	return buildToolSearchIndex(tools, servers)
+}
+
+func allVisible(idx *toolSearchIndex) map[string]struct{} {
+	visible := map[string]struct{}{}
+	for _, doc := range idx.docs {
+		visible[doc.name] = struct{}{}
+	}
+	return visible
+}
+
+func matchNames(matches []toolMatch) []string {
+	names := make([]string, 0, len(matches))
+	for _, m := range matches {
+		names = append(names, m.Name)
+	}
+	return names
+}
+
+func TestBuildToolSearchIndex_SkipsBrokerAndUnknownServerTools(t *testing.T) {
+	idx := testSearchIndex()
+	var names []string
+	for _, doc := range idx.docs {
+		names = append(names, doc.name)
+	}
+	require.ElementsMatch(t, []string{"weather_get_forecast", "weather_current", "cal_createEvent", "cal_free_busy"}, names)
+	require.NotContains(t, idx.postings, "orphan")
+}
+
+func TestToolSearch_Ranking(t *testing.T) {
+	idx := testSearchIndex()
+	visible := allVisible(idx)
+
+	tests := []struct {
+		name  string
+		query string
+		first string
+		want  []string
+	}{
+		{
+			name:  "name match outranks description match",
+			query: "forecast",
+			first: "weather_get_forecast",
+			want:  []string{"weather_get_forecast"},
+		},
+		{
+			name:  "hint and category match every tool of the server",
+			query: "weather",
+			want:  []string{"weather_get_forecast", "weather_current"},
+		},
+		{
+			name:  "camelCase tool names are split",
+			query: "create event",
+			first: "cal_createEvent",
+			want:  []string{"cal_createEvent"},
+		},
+		{
+			name:  "tags are indexed",
+			query: "productivity",
+			want:  []string{"cal_createEvent", "cal_free_busy"},
+		},
+		{
+			name:  "case and punctuation are ignored",
+			query: "ATTENDEES!",
+			want:  []string{"cal_createEvent", "cal_free_busy"},
+		},
+		{
+			name:  "no matching terms",
+			query: "stock prices",
+			want:  []string{},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			matches := idx.search(tc.query, maxSearchLimit, visible, "")
+			require.ElementsMatch(t, tc.want, matchNames(matches))
+			if tc.first != "" {
+				require.Equal(t, tc.first, matches[0].Name)
+			}
+			for i := 1; i < len(matches); i++ {
+				require.GreaterOrEqual(t, matches[i-1].Score, matches[i].Score)
+			}
+		})
+	}
+}
+
+func TestToolSearch_Result(t *testing.T) {
+	idx := testSearchIndex()
+	matches := idx.search("forecast", 1, allVisible(idx), "")
+	require.Len(t, matches, 1)
+	require.Equal(t, "weather_get_forecast", matches[0].Name)
+	require.Equal(t, "ns/weather", matches[0].Server)
+	require.Equal(t, "Get the multi-day forecast for a city.", matches[0].Description)
+	require.Greater(t, matches[0].Score, 0.0)
+}
+
+func TestToolSearch_Filters(t *testing.T) {
+	idx := testSearchIndex()
+
+	t.Run("only visible tools are returned", func(t *testing.T) {
+		visible := map[string]struct{}{"weather_current": {}}
+		matches := idx.search("weather forecast", maxSearchLimit, visible, "")
+		require.Equal(t, []string{"weather_current"}, matchNames(matches))
+	})
+
+	t.Run("category filter is case-insensitive", func(t *testing.T) {
+		matches := idx.search("attendees location", maxSearchLimit, allVisible(idx), "Scheduling")
+		require.ElementsMatch(t, []string{"cal_createEvent", "cal_free_busy"}, matchNames(matches))
+	})
+
+	t.Run("limit truncates the ranked results", func(t *testing.T) {
+		matches := idx.search("weather attendees", 2, allVisible(idx), "")
+		require.Len(t, matches, 2)
+	})
+}
+
+func TestToolSearch_NilIndex(t *testing.T) {
+	var idx *toolSearchIndex
+	require.Empty(t, idx.search("weather", defaultSearchLimit, nil, ""))
+	require.Empty(t, buildToolSearchIndex(nil, nil).search("weather", defaultSearchLimit, nil, ""))
+}
+
+func TestTokenize(t *testing.T) {
+	tests := []struct {
+		in   string
+		want []string
+	}{
+		{"get_weather", []string{"get", "weather"}},
+		{"getWeather", []string{"get", "weather"}},
+		{"Get Weather", []string{"get", "weather"}},
+		{"ns/server-1", []string{"ns", "server", "1"}},
+		{"v2Api", []string{"v2", "api"}},
+		{"HTTPServer", []string{"httpserver"}},
+		{"", nil},
+		{"  --  ", nil},
+	}
+	for _, tc := range tests {
+		t.Run(tc.in, func(t *testing.T) {
+			require.Equal(t, tc.want, tokenize(tc.in))
+		})
+	}
+}
+
+func TestShortDescription(t *testing.T) {
+	long := strings.Repeat("a", maxShortDescriptionRunes+20)
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"empty", "", ""},
+		{"single sentence", "Adds two numbers.", "Adds two numbers."},
+		{"first sentence", "Adds two numbers. Returns their sum.", "Adds two numbers."},
+		{"first line", "Adds two numbers\n\nArgs: a, b", "Adds two numbers"},
+		{"decimal point kept", "Rounds to 2.5 units", "Rounds to 2.5 units"},
+		{"truncated", long, strings.Repeat("a", maxShortDescriptionRunes-1) + "…"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got := shortDescription(tc.in)
+			require.Equal(t, tc.want, got)
+			require.LessOrEqual(t, len([]rune(got)), maxShortDescriptionRunes)
+		})
+	}
+}
+
+func TestParseSearchLimit(t *testing.T) {
+	tests := []struct {
+		name    string
+		raw     any
+		want    int
+		wantErr bool
+	}{
+		{"absent", nil, defaultSearchLimit, false},
+		{"valid", float64(5), 5, false},
+		{"max", float64(maxSearchLimit), maxSearchLimit, false},
+		{"zero", float64(0), 0, true},
+		{"too large", float64(maxSearchLimit + 1), 0, true},
+		{"fractional", 2.5, 0, true},
+		{"string", "5", 0, true},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := parseSearchLimit(tc.raw)
+			if tc.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.want, got)
+		})
+	}
+}
@@ -2,8 +2,10 @@ package broker
 
 import (
 	"fmt"
+	"reflect"
 
 	mcpotel "github.com/Kuadrant/mcp-gateway/internal/otel"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/trace"
@@ -24,3 +26,27 @@ func recordBrokerError(span trace.Span, err error) {
 		attribute.String("error_source", "broker"),
 	)
 }
+
+// requestParams returns the params of req, or nil when it has none
+func requestParams(req mcp.Request) mcp.Params {
+	params := req.GetParams()
+	if params == nil || reflect.ValueOf(params).IsNil() {
+		return nil
+	}
+	return params
+}
+
+// requestTarget returns the tool, prompt or resource a request acts on
+func requestTarget(params mcp.Params) string {
+	switch p := params.(type) {
+	case *mcp.CallToolParamsRaw:
+		return p.Name
+	case *mcp.CallToolParams:
+		return p.Name
+	case *mcp.GetPromptParams:
+		return p.Name
+	case *mcp.ReadResourceParams:
+		return p.URI
+	}
+	return ""
+}
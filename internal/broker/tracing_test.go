@@ -11,8 +11,10 @@ import (
 	"github.com/stretchr/testify/require"
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
 	sdktrace "go.opentelemetry.io/otel/sdk/trace"
 	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
 )
 
 func setupTestTracer(t *testing.T) *tracetest.InMemoryExporter {
@@ -128,3 +130,41 @@ func TestTracingMiddleware_ErrorRecordedOnSpan(t *testing.T) {
 	}
 	require.True(t, found, "expected the tools/call span to record the broker error")
 }
+
+// requests from transports that strip headers carry trace context in
+// params._meta; the request span must join that trace and carry the MCP
+// semantic convention attributes.
+func TestTracingMiddleware_ExtractsMetaTraceContext(t *testing.T) {
+	exporter := setupTestTracer(t)
+	prev := otel.GetTextMapPropagator()
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() { otel.SetTextMapPropagator(prev) })
+
+	b := NewBroker(slog.Default(), WithDiscoveryToolsEnabled(false)).(*mcpBrokerImpl)
+	cs := connectInMemory(t, b)
+
+	const traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+	_, _ = cs.CallTool(context.Background(), &mcp.CallToolParams{
+		Name: "weather_get",
+		Meta: mcp.Meta{"traceparent": "00-" + traceID + "-00f067aa0ba902b7-01"},
+	})
+
+	var handle tracetest.SpanStub
+	for _, s := range exporter.GetSpans() {
+		if attr, ok := findAttribute(s.Attributes, "mcp.method.name"); ok && s.Name == "mcp-broker.handle-request" && attr.Value.AsString() == "tools/call" {
+			handle = s
+		}
+	}
+	require.NotEmpty(t, handle.Name, "expected a handle-request span for tools/call")
+	require.Equal(t, traceID, handle.SpanContext.TraceID().String())
+	require.Equal(t, "00f067aa0ba902b7", handle.Parent.SpanID().String())
+	require.True(t, handle.Parent.IsRemote())
+	require.Equal(t, trace.SpanKindServer, handle.SpanKind)
+
+	attr, ok := findAttribute(handle.Attributes, "gen_ai.operation.name")
+	require.True(t, ok)
+	require.Equal(t, "execute_tool", attr.Value.AsString())
+	attr, ok = findAttribute(handle.Attributes, "gen_ai.tool.name")
+	require.True(t, ok)
+	require.Equal(t, "weather_get", attr.Value.AsString())
+}
@@ -0,0 +1,146 @@
+package upstream
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// CanaryStatus reports how the canary version of a server compares with the
+// stable version. Routed is true while the router sends a share of tool
+// calls to the canary.
+type CanaryStatus struct {
+	Name        string    `json:"name"`
+	Reachable   bool      `json:"reachable"`
+	Compatible  bool      `json:"compatible"`
+	Routed      bool      `json:"routed"`
+	Message     string    `json:"message"`
+	Mismatches  []string  `json:"mismatches,omitempty"`
+	LastChecked time.Time `json:"lastChecked"`
+}
+
+// SetCanary sets the canary version of the managed server. The manager
+// compares the canary's tools with the stable tools each time it fetches
+// them. Must be called before Start.
+func (man *MCPManager) SetCanary(canary MCP) {
+	man.canary = canary
+}
+
+// checkCanary compares the canary's tools with the stable tools and records
+// the result in the status. The routing table is rebuilt when the canary
+// starts or stops receiving traffic. Must only be called from the Start()
+// event loop.
+func (man *MCPManager) checkCanary(ctx context.Context, stable []mcp.Tool) {
+	status := &CanaryStatus{Name: man.canary.GetName(), LastChecked: time.Now()}
+	tools, err := man.canaryTools(ctx)
+	if err != nil {
+		man.logger.ErrorContext(ctx, "canary check failed", "upstream mcp server", man.mcp.ID(), "canary", man.canary.ID(), "error", err)
+		_ = man.canary.Disconnect()
+		status.Message = err.Error()
+	} else {
+		status.Reachable = true
+		status.Mismatches = man.toolMismatches(stable, tools)
+		status.Compatible = len(status.Mismatches) == 0
+		cfg := man.mcp.GetConfig().Canary
+		status.Routed = status.Compatible || (cfg != nil && !cfg.RejectsCanaryToolMismatch())
+		switch {
+		case status.Compatible:
+			status.Message = "canary tools match the stable version"
+		case status.Routed:
+			status.Message = fmt.Sprintf("canary tools differ from the stable version (%d mismatches), routing anyway", len(status.Mismatches))
+		default:
+			status.Message = fmt.Sprintf("canary tools differ from the stable version (%d mismatches), not routing", len(status.Mismatches))
+		}
+		if !status.Compatible {
+			man.logger.WarnContext(ctx, "canary tools differ from the stable version", "upstream mcp server", man.mcp.ID(), "canary", man.canary.ID(), "mismatches", status.Mismatches, "routed", status.Routed)
+		}
+	}
+
+	man.statusMu.Lock()
+	wasRouted := man.status.Canary != nil && man.status.Canary.Routed
+	man.status.Canary = status
+	man.statusMu.Unlock()
+	if wasRouted != status.Routed {
+		man.logger.InfoContext(ctx, "canary routing changed", "upstream mcp server", man.mcp.ID(), "routed", status.Routed)
+		man.gatewayServer.NotifyMetadataChanged()
+	}
+}
+
+// canaryTools connects to the canary and lists its tools
+func (man *MCPManager) canaryTools(ctx context.Context) ([]mcp.Tool, error) {
+	if err := man.canary.Connect(ctx, func() {}); err != nil {
+		return nil, fmt.Errorf("failed to connect to canary %s : %w", man.canary.ID(), err)
+	}
+	if err := man.canary.Ping(ctx); err != nil {
+		return nil, fmt.Errorf("failed to ping canary %s : %w", man.canary.ID(), err)
+	}
+	res, err := man.canary.ListTools(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tools of canary %s : %w", man.canary.ID(), err)
+	}
+	tools := make([]mcp.Tool, 0, len(res.Tools))
+	for _, t := range res.Tools {
+		if t != nil {
+			tools = append(tools, *t)
+		}
+	}
+	return tools, nil
+}
+
+// toolMismatches describes the differences between the stable and canary
+// tools that would break a client holding the stable tool list: tools
+// added or removed, and tools whose input or output schema changed.
+func (man *MCPManager) toolMismatches(stable, canary []mcp.Tool) []string {
+	added, removed := man.diffTools(stable, canary)
+	mismatches := make([]string, 0, len(added)+len(removed))
+	for _, tool := range added {
+		mismatches = append(mismatches, fmt.Sprintf("%s: only in canary", tool.Tool.Name))
+	}
+	for _, name := range removed {
+		mismatches = append(mismatches, fmt.Sprintf("%s: missing from canary", name))
+	}
+
+	canaryTools := make(map[string]mcp.Tool, len(canary))
+	for _, tool := range canary {
+		canaryTools[tool.Name] = tool
+	}
+	for _, tool := range stable {
+		other, ok := canaryTools[tool.Name]
+		if !ok {
+			continue
+		}
+		var changed []string
+		if !schemaEqual(tool.InputSchema, other.InputSchema) {
+			changed = append(changed, "input")
+		}
+		if !schemaEqual(tool.OutputSchema, other.OutputSchema) {
+			changed = append(changed, "output")
+		}
+		if len(changed) > 0 {
+			mismatches = append(mismatches, fmt.Sprintf("%s: %s schema changed", prefixedName(man.mcp.GetPrefix(), tool.Name), strings.Join(changed, " and ")))
+		}
+	}
+	slices.Sort(mismatches)
+	return mismatches
+}
+
+// schemaEqual compares two tool schemas by their JSON encoding, so schemas
+// that decode to different Go types but describe the same shape are equal
+func schemaEqual(a, b any) bool {
+	aJSON, aErr := json.Marshal(a)
+	bJSON, bErr := json.Marshal(b)
+	if aErr != nil || bErr != nil {
+		return false
+	}
+	var aVal, bVal any
+	if json.Unmarshal(aJSON, &aVal) != nil || json.Unmarshal(bJSON, &bVal) != nil {
+		return false
+	}
+	return reflect.DeepEqual(aVal, bVal)
+}
@@ -0,0 +1,136 @@
+package upstream
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"testing"
+
+	"github.com/Kuadrant/mcp-gateway/internal/config"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// notifyCountingGateway counts routing table rebuild requests
+type notifyCountingGateway struct {
+	*MockToolsAdderDeleter
+	notified int
+}
+
+func (g *notifyCountingGateway) NotifyMetadataChanged() { g.notified++ }
+
+func newCanaryTestManager(t *testing.T, policy string, canaryTools []mcp.Tool) (*MCPManager, *MockMCP, *notifyCountingGateway) {
+	t.Helper()
+	stable := newMockMCP("mcp-test/weather", "weather_")
+	stable.cfg.Canary = &config.CanaryConfig{URL: "http://mock-v2/mcp", Weight: 10, ToolMismatch: policy}
+	canary := newMockMCP("mcp-test/weather#canary", "weather_")
+	canary.tools = canaryTools
+	gateway := &notifyCountingGateway{MockToolsAdderDeleter: newMockToolsAdderDeleter()}
+	manager, err := NewUpstreamMCPManager(stable, gateway, nil, slog.New(slog.NewTextHandler(os.Stdout, nil)), 0, InvalidToolPolicyFilterOut)
+	require.NoError(t, err)
+	manager.SetCanary(canary)
+	return manager, canary, gateway
+}
+
+func TestMCPManager_CanaryCompatible(t *testing.T) {
+	manager, canary, gateway := newCanaryTestManager(t, "", []mcp.Tool{{Name: "mock_tool", InputSchema: map[string]any{"type": "object"}}})
+
+	manager.manage(context.Background(), eventTypeTimer)
+
+	status := manager.GetStatus().Canary
+	require.NotNil(t, status)
+	assert.Equal(t, "mcp-test/weather#canary", status.Name)
+	assert.True(t, status.Reachable)
+	assert.True(t, status.Compatible)
+	assert.True(t, status.Routed)
+	assert.Empty(t, status.Mismatches)
+	assert.Equal(t, 1, gateway.notified)
+
+	// routing unchanged: no rebuild
+	manager.manage(context.Background(), eventTypeTimer)
+	assert.Equal(t, 1, gateway.notified)
+
+	// the canary going away stops routing to it
+	canary.pingErr = fmt.Errorf("ping timeout")
+	manager.manage(context.Background(), eventTypeTimer)
+	status = manager.GetStatus().Canary
+	assert.False(t, status.Reachable)
+	assert.False(t, status.Routed)
+	assert.Contains(t, status.Message, "failed to ping canary")
+	assert.Equal(t, 2, gateway.notified)
+	assert.False(t, canary.connected.Load())
+	assert.True(t, manager.GetStatus().Ready, "the stable version is unaffected")
+}
+
+func TestMCPManager_CanaryToolMismatch(t *testing.T) {
+	canaryTools := []mcp.Tool{
+		{Name: "mock_tool", InputSchema: map[string]any{"type": "object", "required": []any{"city"}}},
+		{Name: "new_tool", InputSchema: map[string]any{"type": "object"}},
+	}
+	tests := []struct {
+		policy     string
+		wantRouted bool
+	}{
+		{policy: "", wantRouted: false},
+		{policy: config.CanaryToolMismatchReject, wantRouted: false},
+		{policy: config.CanaryToolMismatchFlag, wantRouted: true},
+	}
+	for _, tc := range tests {
+		t.Run("policy "+tc.policy, func(t *testing.T) {
+			manager, _, gateway := newCanaryTestManager(t, tc.policy, canaryTools)
+
+			manager.manage(context.Background(), eventTypeTimer)
+
+			status := manager.GetStatus().Canary
+			require.NotNil(t, status)
+			assert.True(t, status.Reachable)
+			assert.False(t, status.Compatible)
+			assert.Equal(t, tc.wantRouted, status.Routed)
+			assert.Equal(t, []string{
+				"weather_mock_tool: input schema changed",
+				"weather_new_tool: only in canary",
+			}, status.Mismatches)
+			if tc.wantRouted {
+				assert.Equal(t, 1, gateway.notified)
+			} else {
+				assert.Zero(t, gateway.notified)
+			}
+		})
+	}
+}
+
+func TestMCPManager_CanaryCheckedOnlyWithStableTools(t *testing.T) {
+	manager, _, _ := newCanaryTestManager(t, "", nil)
+	manager.mcp.(*MockMCP).listToolsErr = fmt.Errorf("boom")
+
+	manager.manage(context.Background(), eventTypeTimer)
+	assert.Nil(t, manager.GetStatus().Canary)
+
+	// prompt notifications do not fetch tools
+	manager.mcp.(*MockMCP).listToolsErr = nil
+	manager.manage(context.Background(), eventTypePromptNotification)
+	assert.Nil(t, manager.GetStatus().Canary)
+}
+
+func TestMCPManager_ToolMismatches(t *testing.T) {
+	manager, _, _ := newCanaryTestManager(t, "", nil)
+	stable := []mcp.Tool{
+		{Name: "forecast", InputSchema: map[string]any{"type": "object"}},
+		{Name: "alerts", InputSchema: map[string]any{"type": "object"}, OutputSchema: map[string]any{"type": "object"}},
+		{Name: "retired", InputSchema: map[string]any{"type": "object"}},
+	}
+	canary := []mcp.Tool{
+		// the same schema in a different Go representation
+		{Name: "forecast", InputSchema: json.RawMessage(`{"type": "object"}`), Description: "descriptions may change"},
+		{Name: "alerts", InputSchema: map[string]any{"type": "object"}, OutputSchema: map[string]any{"type": "array"}},
+	}
+
+	assert.Equal(t, []string{
+		"weather_alerts: output schema changed",
+		"weather_retired: missing from canary",
+	}, manager.toolMismatches(stable, canary))
+	assert.Empty(t, manager.toolMismatches(stable, stable))
+}
@@ -0,0 +1,41 @@
+package upstream
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/Kuadrant/mcp-gateway/internal/broker/credentials"
+	"github.com/Kuadrant/mcp-gateway/internal/config"
+)
+
+// credentialInvalidator is implemented by providers that cache resolved
+// credentials, such as credentials.Cache
+type credentialInvalidator interface {
+	Invalidate(ref config.CredentialReference)
+}
+
+// credentialRoundTripper resolves the upstream credential on every request
+// and sets it as the Authorization header. A 401 from the upstream drops
+// any cached value so the next request re-reads a rotated credential.
+type credentialRoundTripper struct {
+	base     http.RoundTripper
+	ref      config.CredentialReference
+	provider credentials.CredentialProvider
+}
+
+// RoundTrip implements http.RoundTripper
+func (c *credentialRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	credential, err := c.provider.Credential(req.Context(), c.ref)
+	if err != nil {
+		return nil, fmt.Errorf("resolving credential %s: %w", c.ref, err)
+	}
+	r2 := req.Clone(req.Context())
+	r2.Header.Set("Authorization", credential)
+	resp, err := c.base.RoundTrip(r2)
+	if err == nil && resp.StatusCode == http.StatusUnauthorized {
+		if inv, ok := c.provider.(credentialInvalidator); ok {
+			inv.Invalidate(c.ref)
+		}
+	}
+	return resp, err
+}
@@ -23,7 +23,7 @@ type ToolHints struct {
 	OpenWorldHint   *bool
 	// Raw is the exact annotations object the upstream sent, nil when the
 	// tool declared none.
-	Raw json.RawMessage
+	Raw json.RawMessage `json:",omitempty"`
 }
 
 // rawTool is the slice of a tools/list result entry the tee cares about.
@@ -29,6 +29,7 @@ import (
 const (
 	notificationToolsListChanged   = "notifications/tools/list_changed"
 	notificationPromptsListChanged = "notifications/prompts/list_changed"
+	notificationLogMessage         = "notifications/message"
 	// GatewayServerID is the meta key stamped on every tool and prompt to
 	// identify which upstream server owns it.
 	GatewayServerID = "kuadrant/id"
@@ -62,6 +63,19 @@ type ServerValidationStatus struct {
 	InvalidPrompts     int                 `json:"invalidPrompts"`
 	InvalidPromptList  []InvalidPromptInfo `json:"invalidPromptList,omitempty"`
 	ProtocolValidation ProtocolValidation  `json:"protocolValidation"`
+	// Maintenance is set while the server is in the Maintenance state
+	Maintenance *MaintenanceStatus `json:"maintenance,omitempty"`
+	// Canary is set when the server has a canary version
+	Canary *CanaryStatus `json:"canary,omitempty"`
+}
+
+// MaintenanceStatus reports the maintenance window of a server. Active is
+// true while the window is open and the router rejects new tool calls.
+type MaintenanceStatus struct {
+	Active  bool       `json:"active"`
+	Message string     `json:"message"`
+	Start   *time.Time `json:"start,omitempty"`
+	End     *time.Time `json:"end,omitempty"`
 }
 
 // ProtocolValidation reports the MCP protocol version negotiated with the upstream.
@@ -107,6 +121,14 @@ type MCP interface {
 	PromptsCacheMetadata() CacheMetadata
 	// UsesStatelessProtocol returns true if the upstream negotiated 2026-07-28 or later.
 	UsesStatelessProtocol() bool
+	// SupportsLogging returns true if the upstream declared the logging capability.
+	SupportsLogging() bool
+	// SetLoggingLevel sets the level the upstream logs at on the broker's
+	// session, applied again on every reconnect.
+	SetLoggingLevel(ctx context.Context, level mcp.LoggingLevel) error
+	// OnLogMessage registers the handler for notifications/message the
+	// upstream sends on the broker's session.
+	OnLogMessage(func(*mcp.LoggingMessageParams))
 }
 
 // ActiveMCPServer is the handle returned by Start. It exposes read-only
@@ -130,6 +152,13 @@ type ActiveMCPServer interface {
 	// there is nothing for manage() to populate ahead of time.
 	SupportsResources() bool
 	ListResources(ctx context.Context) (*mcp.ListResourcesResult, error)
+	// SupportsLogging and SetLoggingLevel pass through to the upstream for
+	// the same reason.
+	SupportsLogging() bool
+	SetLoggingLevel(ctx context.Context, level mcp.LoggingLevel) error
+	// Refresh triggers an immediate manage cycle without waiting for the
+	// next health check tick.
+	Refresh()
 }
 
 // GatewayTool pairs a tool definition with the handler the gateway
@@ -228,19 +257,26 @@ type MCPManager struct {
 	// invalidToolPolicy controls behavior when upstream tools have invalid schemas
 	invalidToolPolicy InvalidToolPolicy
 
-	// toolEvents, promptEvents, and reconnectEvents funnel notifications into
-	// the Start() loop. Separate channels with buffer of 1 each ensure one
-	// event type cannot block another while still coalescing rapid same-type
-	// notifications.
+	// toolEvents, promptEvents, reconnectEvents and refreshEvents funnel
+	// notifications into the Start() loop. Separate channels with buffer of 1
+	// each ensure one event type cannot block another while still coalescing
+	// rapid same-type notifications.
 	toolEvents      chan struct{}
 	promptEvents    chan struct{}
 	reconnectEvents chan struct{}
+	refreshEvents   chan struct{}
 	done            chan struct{} // closed when the event loop exits
-	status          ServerValidationStatus
+	// statusMu guards status: the event loop writes it while /status and
+	// the shared catalog publisher read it
+	statusMu sync.RWMutex
+	status   ServerValidationStatus
 
 	// consecutiveFailures counts connect/ping failures since the last
 	// healthy pass. only touched from the event loop goroutine.
 	consecutiveFailures int
+
+	// canary is the canary version of the server, nil when it has none
+	canary MCP
 }
 
 // DefaultTickerInterval is the default interval for backend health checks
@@ -323,6 +359,7 @@ func NewUpstreamMCPManager(upstream MCP, gatewayServer ToolsAdderDeleter, prompt
 		toolEvents:         make(chan struct{}, 1),
 		promptEvents:       make(chan struct{}, 1),
 		reconnectEvents:    make(chan struct{}, 1),
+		refreshEvents:      make(chan struct{}, 1),
 		done:               make(chan struct{}),
 		toolsMap:           map[string]*mcp.Tool{},
 		servedToolsMap:     map[string]*mcp.Tool{},
@@ -359,6 +396,9 @@ func (man *MCPManager) Start(ctx context.Context) ActiveMCPServer {
 				if err := man.mcp.Disconnect(); err != nil {
 					man.logger.Error("failed to disconnect during stop", "upstream mcp server", man.mcp.ID(), "error", err)
 				}
+				if man.canary != nil {
+					_ = man.canary.Disconnect()
+				}
 				man.removeAllPrompts()
 				man.removeAllTools()
 
@@ -377,6 +417,9 @@ func (man *MCPManager) Start(ctx context.Context) ActiveMCPServer {
 			case <-man.reconnectEvents:
 				man.logger.Info("reconnecting after connection loss", "upstream mcp server", man.mcp.ID())
 				man.manage(ctx, eventTypeTimer)
+			case <-man.refreshEvents:
+				man.logger.Info("refresh requested", "upstream mcp server", man.mcp.ID())
+				man.manage(ctx, eventTypeTimer)
 			}
 		}
 	}()
@@ -416,6 +459,11 @@ func (a *activeMCP) SupportsResources() bool             { return a.manager.Supp
 func (a *activeMCP) ListResources(ctx context.Context) (*mcp.ListResourcesResult, error) {
 	return a.manager.ListResources(ctx)
 }
+func (a *activeMCP) Refresh()              { a.manager.Refresh() }
+func (a *activeMCP) SupportsLogging() bool { return a.manager.SupportsLogging() }
+func (a *activeMCP) SetLoggingLevel(ctx context.Context, level mcp.LoggingLevel) error {
+	return a.manager.SetLoggingLevel(ctx, level)
+}
 
 func (man *MCPManager) registerCallbacks() func() {
 	man.logger.Debug("registering callbacks", "upstream mcp server", man.mcp.ID())
@@ -450,6 +498,16 @@ func (man *MCPManager) registerCallbacks() func() {
 	}
 }
 
+// Refresh asks the event loop for an immediate full manage cycle, as if the
+// health check ticker had fired. A request made while one is already
+// pending is coalesced into it.
+func (man *MCPManager) Refresh() {
+	select {
+	case man.refreshEvents <- struct{}{}:
+	default:
+	}
+}
+
 // manage should be the only entry point that triggers changes to tools
 func (man *MCPManager) manage(ctx context.Context, event eventType) {
 	man.logger.DebugContext(ctx, "managing connection", "upstream mcp server", man.mcp.ID(), "event type", event)
@@ -503,11 +561,13 @@ func (man *MCPManager) manage(ctx context.Context, event eventType) {
 
 	if man.mcp.GetConfig().UserSpecificList {
 		man.logger.Debug("userSpecificList server healthy, tools fetched per-user", "upstream mcp server", man.mcp.ID())
+		man.statusMu.Lock()
 		man.status.ID = string(man.mcp.ID())
 		man.status.LastValidated = time.Now()
 		man.status.Name = man.MCPName()
 		man.status.Ready = true
 		man.status.Message = "userSpecificList server healthy, tools fetched per-user"
+		man.statusMu.Unlock()
 		man.resetBackoff()
 		return
 	}
@@ -598,6 +658,9 @@ func (man *MCPManager) manage(ctx context.Context, event eventType) {
 			man.discoveryTotal.Add(ctx, 1, metric.WithAttributes(serverAttr, attribute.String("status", "failure")))
 		} else {
 			man.discoveryTotal.Add(ctx, 1, metric.WithAttributes(serverAttr, attribute.String("status", "success")))
+			if man.canary != nil {
+				man.checkCanary(ctx, man.GetManagedTools())
+			}
 		}
 	}
 
@@ -677,12 +740,15 @@ func (man *MCPManager) shouldFetchPrompts(event eventType) bool {
 }
 
 // GetStatus returns the current status of the MCP Server
-// no locking is done here as it is expected to be called multiple times
 func (man *MCPManager) GetStatus() ServerValidationStatus {
+	man.statusMu.RLock()
+	defer man.statusMu.RUnlock()
 	return man.status
 }
 
 func (man *MCPManager) setStatus(err error, toolCount int, promptCount int, invalidTools []InvalidToolInfo, invalidPrompts []InvalidPromptInfo) {
+	man.statusMu.Lock()
+	defer man.statusMu.Unlock()
 	man.status.ID = string(man.mcp.ID())
 	man.status.LastValidated = time.Now()
 	man.status.Name = man.MCPName()
@@ -847,6 +913,16 @@ func (man *MCPManager) ListResources(ctx context.Context) (*mcp.ListResourcesRes
 	return man.mcp.ListResources(ctx)
 }
 
+// SupportsLogging reports whether the upstream declared the logging capability.
+func (man *MCPManager) SupportsLogging() bool {
+	return man.mcp.SupportsLogging()
+}
+
+// SetLoggingLevel sets the level the upstream logs at on the broker's session.
+func (man *MCPManager) SetLoggingLevel(ctx context.Context, level mcp.LoggingLevel) error {
+	return man.mcp.SetLoggingLevel(ctx, level)
+}
+
 // SetToolsForTesting sets the tools directly for testing purposes.
 // This bypasses the normal tool discovery flow and should only be used in tests.
 // TODO look to remove the need for this
@@ -864,7 +940,9 @@ func (man *MCPManager) SetToolsForTesting(tools []mcp.Tool) {
 // SetStatusForTesting sets the status directly for testing purposes.
 // This bypasses the normal status update flow and should only be used in tests.
 func (man *MCPManager) SetStatusForTesting(status ServerValidationStatus) {
+	man.statusMu.Lock()
 	man.status = status
+	man.statusMu.Unlock()
 }
 
 // SetCacheMetadataForTesting sets cache metadata directly for testing.
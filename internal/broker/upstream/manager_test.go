@@ -47,6 +47,8 @@ type MockMCP struct {
 	hasToolsCap         bool
 	hasPromptsCap       bool
 	hasResourcesCap     bool
+	hasLoggingCap       bool
+	logLevel            mcp.LoggingLevel
 	connected           atomic.Bool
 	notificationHandler func(method string)
 }
@@ -153,7 +155,7 @@ func (m *MockMCP) IsEnabled() bool {
 	if m.cfg == nil {
 		return true
 	}
-	return m.cfg.State == "" || m.cfg.State == string(mcpv1.ServerStateEnabled)
+	return m.cfg.State == "" || m.cfg.State == string(mcpv1.ServerStateEnabled) || m.cfg.State == string(mcpv1.ServerStateMaintenance)
 }
 
 func (m *MockMCP) GetToolHints(string) (ToolHints, bool) {
@@ -191,6 +193,12 @@ func (m *MockMCP) SupportsVersion(v string) bool {
 func (m *MockMCP) ToolsCacheMetadata() CacheMetadata   { return CacheMetadata{} }
 func (m *MockMCP) PromptsCacheMetadata() CacheMetadata { return CacheMetadata{} }
 func (m *MockMCP) UsesStatelessProtocol() bool         { return m.protocolVersion >= "2026-07-28" }
+func (m *MockMCP) SupportsLogging() bool               { return m.hasLoggingCap }
+func (m *MockMCP) SetLoggingLevel(_ context.Context, level mcp.LoggingLevel) error {
+	m.logLevel = level
+	return nil
+}
+func (m *MockMCP) OnLogMessage(func(*mcp.LoggingMessageParams)) {}
 
 // newMockMCP creates a MockMCP with sensible defaults for testing
 func newMockMCP(name, prefix string) *MockMCP {
@@ -1315,6 +1323,31 @@ func TestMCPManager_EventChannel_NotificationRoutesThrough(t *testing.T) {
 	}, time.Second, 10*time.Millisecond, "notification should trigger tool sync")
 }
 
+func TestMCPManager_Refresh(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+	mock := newMockMCP("test-server", "test_")
+	mock.tools = []mcp.Tool{validTool("tool1")}
+	gateway := NewMockGatewayServer()
+	manager, err := NewUpstreamMCPManager(mock, gateway, nil, logger, time.Hour, InvalidToolPolicyFilterOut)
+	require.NoError(t, err)
+
+	active := manager.Start(context.Background())
+	defer active.Stop()
+
+	require.Eventually(t, func() bool {
+		return len(gateway.ListTools()) == 1
+	}, time.Second, 10*time.Millisecond, "initial tools should be added")
+
+	// the upstream changes without notifying; only the hourly tick would see it
+	mock.tools = []mcp.Tool{validTool("tool1"), validTool("tool2")}
+	active.Refresh()
+
+	require.Eventually(t, func() bool {
+		_, has := gateway.ListTools()["test_tool2"]
+		return has
+	}, time.Second, 10*time.Millisecond, "refresh should run a manage cycle without waiting for the ticker")
+}
+
 // verifies GetManagedTools/GetServedManagedTool don't race with manage() under -race.
 func TestMCPManager_ConcurrentReadsDuringManage(t *testing.T) {
 	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
@@ -12,7 +12,9 @@ import (
 	"time"
 
 	mcpv1 "github.com/Kuadrant/mcp-gateway/api/v1"
+	"github.com/Kuadrant/mcp-gateway/internal/broker/credentials"
 	"github.com/Kuadrant/mcp-gateway/internal/config"
+	mcpotel "github.com/Kuadrant/mcp-gateway/internal/otel"
 	"github.com/Kuadrant/mcp-gateway/internal/protocol"
 	"github.com/Kuadrant/mcp-gateway/internal/transport"
 	"github.com/modelcontextprotocol/go-sdk/mcp"
@@ -75,6 +77,16 @@ type MCPServer struct {
 	// session connects, leaving no registration gap.
 	notifyMu      sync.RWMutex
 	notifyHandler func(method string)
+	// logHandler receives notifications/message, guarded by notifyMu
+	logHandler func(*mcp.LoggingMessageParams)
+
+	// logLevel is the level requested for the broker's session, re-applied
+	// on every connect. guarded by clientMu.
+	logLevel mcp.LoggingLevel
+
+	// credentials resolves CredentialRef on every request so a rotated
+	// credential is picked up without reconnecting
+	credentials credentials.CredentialProvider
 
 	// supportedVersions lists protocol versions this upstream supports.
 	// set to the single negotiated version after Connect. future work:
@@ -83,9 +95,20 @@ type MCPServer struct {
 	supportedVersions []string
 }
 
+// Option configures an MCPServer created by NewUpstreamMCP
+type Option func(*MCPServer)
+
+// WithCredentialProvider sets the provider used to resolve the server's
+// CredentialRef into the Authorization header
+func WithCredentialProvider(provider credentials.CredentialProvider) Option {
+	return func(up *MCPServer) {
+		up.credentials = provider
+	}
+}
+
 // NewUpstreamMCP creates a new MCPServer instance from the provided
 // configuration. A nil logger discards output.
-func NewUpstreamMCP(config *config.MCPServer, gatewayCACertPEM string, logger *slog.Logger) *MCPServer {
+func NewUpstreamMCP(config *config.MCPServer, gatewayCACertPEM string, logger *slog.Logger, opts ...Option) *MCPServer {
 	if logger == nil {
 		logger = slog.New(slog.DiscardHandler)
 	}
@@ -104,6 +127,9 @@ func NewUpstreamMCP(config *config.MCPServer, gatewayCACertPEM string, logger *s
 	if up.Credential != "" {
 		up.headers["Authorization"] = up.Credential
 	}
+	for _, opt := range opts {
+		opt(up)
+	}
 	return up
 }
 
@@ -140,9 +166,17 @@ func (up *MCPServer) buildHTTPClient() (*http.Client, error) {
 		}
 	}
 
+	var rt http.RoundTripper = base
+	if up.CredentialRef != nil {
+		if up.credentials == nil {
+			return nil, fmt.Errorf("upstream %s has a credential reference but no credential provider is configured", up.Name)
+		}
+		rt = &credentialRoundTripper{base: base, ref: *up.CredentialRef, provider: up.credentials}
+	}
+
 	return &http.Client{
 		Transport: &toolHintsTee{
-			base: &transport.HeaderRoundTripper{Base: base, Headers: up.headers},
+			base: &transport.HeaderRoundTripper{Base: rt, Headers: up.headers},
 			sink: up.storeToolHints,
 		},
 	}, nil
@@ -195,18 +229,22 @@ func (up *MCPServer) GetConfig() config.MCPServer {
 		State:               up.State,
 		Hostname:            up.Hostname,
 		Credential:          up.Credential,
+		CredentialRef:       up.CredentialRef,
 		CACert:              up.CACert,
 		TokenURLElicitation: up.TokenURLElicitation,
 		UserSpecificList:    up.UserSpecificList,
+		Sampling:            up.Sampling,
 		Category:            cat,
 		Hint:                up.Hint,
 		Tags:                tags,
+		Maintenance:         up.Maintenance,
 	}
 }
 
 // IsEnabled returns true if the server should be connected to and have its tools registered.
+// A server under maintenance stays connected so its tools remain listed.
 func (up *MCPServer) IsEnabled() bool {
-	return up.State == "" || up.State == string(mcpv1.ServerStateEnabled)
+	return up.State == "" || up.State == string(mcpv1.ServerStateEnabled) || up.State == string(mcpv1.ServerStateMaintenance)
 }
 
 // ProtocolInfo returns the initialize result with the protocol information stored in it
@@ -285,9 +323,14 @@ func (up *MCPServer) Connect(ctx context.Context, onConnection func()) error {
 	client.AddReceivingMiddleware(func(next mcp.MethodHandler) mcp.MethodHandler {
 		return func(ctx context.Context, method string, req mcp.Request) (mcp.Result, error) {
 			up.logger.Debug("upstream receiving middleware", "upstream", up.ID(), "method", method)
-			if method == "notifications/tools/list_changed" || method == "notifications/prompts/list_changed" {
+			switch method {
+			case "notifications/tools/list_changed", "notifications/prompts/list_changed":
 				up.logger.Debug("upstream notification received", "upstream", up.ID(), "notification", method)
 				up.notify(method)
+			case notificationLogMessage:
+				if params, ok := req.GetParams().(*mcp.LoggingMessageParams); ok {
+					up.logMessage(params)
+				}
 			}
 			return next(ctx, method, req)
 		}
@@ -327,6 +370,7 @@ func (up *MCPServer) Connect(ctx context.Context, onConnection func()) error {
 	} else {
 		up.logger.Debug("using subscriptions/listen for notifications (2026 upstream)", "upstream", up.ID())
 	}
+	up.applyLoggingLevel(ctx, session)
 
 	// register notification and connection-lost handlers after session is
 	// assigned so OnConnectionLost can start session.Wait() immediately
@@ -349,6 +393,7 @@ func (up *MCPServer) startNotificationWatcher(ctx context.Context, httpC *http.C
 		protocolVersion: up.init.ProtocolVersion,
 		serverID:        string(up.ID()),
 		notify:          up.notify,
+		logMessage:      up.logMessage,
 		logger:          up.logger,
 		done:            make(chan struct{}),
 	}
@@ -421,6 +466,61 @@ func (up *MCPServer) notify(method string) {
 	}
 }
 
+// OnLogMessage registers the handler for log notifications the upstream
+// sends on the broker's session. Like OnNotification it may be called
+// before Connect.
+func (up *MCPServer) OnLogMessage(handler func(*mcp.LoggingMessageParams)) {
+	up.notifyMu.Lock()
+	up.logHandler = handler
+	up.notifyMu.Unlock()
+}
+
+// logMessage dispatches a log notification to the registered handler.
+func (up *MCPServer) logMessage(params *mcp.LoggingMessageParams) {
+	up.notifyMu.RLock()
+	handler := up.logHandler
+	up.notifyMu.RUnlock()
+	if handler != nil && params != nil {
+		handler(params)
+	}
+}
+
+// SupportsLogging checks if the upstream server declared the logging capability
+func (up *MCPServer) SupportsLogging() bool {
+	up.clientMu.RLock()
+	defer up.clientMu.RUnlock()
+	return up.init != nil && up.init.Capabilities != nil && up.init.Capabilities.Logging != nil
+}
+
+// SetLoggingLevel records the level for the broker's session and applies it
+// when connected. upstreams only send notifications/message once a level is
+// set, so the level is kept and re-applied after every reconnect.
+func (up *MCPServer) SetLoggingLevel(ctx context.Context, level mcp.LoggingLevel) error {
+	up.clientMu.Lock()
+	up.logLevel = level
+	up.clientMu.Unlock()
+	session := up.currentSession()
+	if session == nil || !up.SupportsLogging() || up.UsesStatelessProtocol() {
+		return nil
+	}
+	return session.SetLoggingLevel(ctx, &mcp.SetLoggingLevelParams{Level: level})
+}
+
+// applyLoggingLevel re-applies the recorded level to a new session.
+// stateless upstreams carry the level per request and have no session to
+// set it on.
+func (up *MCPServer) applyLoggingLevel(ctx context.Context, session *mcp.ClientSession) {
+	up.clientMu.RLock()
+	level := up.logLevel
+	up.clientMu.RUnlock()
+	if level == "" || !up.SupportsLogging() || up.UsesStatelessProtocol() {
+		return
+	}
+	if err := session.SetLoggingLevel(ctx, &mcp.SetLoggingLevelParams{Level: level}); err != nil {
+		up.logger.Debug("upstream did not accept logging level", "upstream", up.ID(), "level", level, "error", err)
+	}
+}
+
 // OnConnectionLost registers a connection lost handler.
 // In the official SDK, connection loss is observed via session.Wait().
 // upstreams that return no Mcp-Session-Id from initialize do not need
@@ -526,7 +626,7 @@ func (up *MCPServer) ListPrompts(ctx context.Context) (*mcp.ListPromptsResult, e
 	if session == nil {
 		return nil, fmt.Errorf("client not connected")
 	}
-	result, err := session.ListPrompts(ctx, nil)
+	result, err := session.ListPrompts(ctx, &mcp.ListPromptsParams{Meta: mcpotel.TraceMeta(ctx)})
 	if err != nil {
 		return nil, err
 	}
@@ -544,7 +644,7 @@ func (up *MCPServer) ListTools(ctx context.Context) (*mcp.ListToolsResult, error
 	if session == nil {
 		return nil, fmt.Errorf("client not connected")
 	}
-	result, err := session.ListTools(ctx, nil)
+	result, err := session.ListTools(ctx, &mcp.ListToolsParams{Meta: mcpotel.TraceMeta(ctx)})
 	if err != nil {
 		return nil, err
 	}
@@ -572,5 +672,5 @@ func (up *MCPServer) ListResources(ctx context.Context) (*mcp.ListResourcesResul
 	if session == nil {
 		return nil, fmt.Errorf("client not connected")
 	}
-	return session.ListResources(ctx, nil)
+	return session.ListResources(ctx, &mcp.ListResourcesParams{Meta: mcpotel.TraceMeta(ctx)})
 }
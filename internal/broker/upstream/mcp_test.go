@@ -24,6 +24,7 @@ import (
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 
 	mcpv1 "github.com/Kuadrant/mcp-gateway/api/v1"
+	"github.com/Kuadrant/mcp-gateway/internal/broker/credentials"
 	"github.com/Kuadrant/mcp-gateway/internal/config"
 	"github.com/stretchr/testify/require"
 )
@@ -35,10 +36,13 @@ func TestNewUpstreamMCP(t *testing.T) {
 		Prefix:   "",
 		State:    string(mcpv1.ServerStateEnabled),
 		Hostname: "dummy",
+		Sampling: true,
 	}
 	up := NewUpstreamMCP(&testServer, "", nil)
 	require.NotNil(t, up)
 	require.Equal(t, testServer, up.GetConfig())
+	// the snapshot is compared against new config to detect changes
+	require.False(t, testServer.ConfigChanged(up.GetConfig()))
 }
 
 func TestMCPServer_IsEnabled(t *testing.T) {
@@ -62,6 +66,11 @@ func TestMCPServer_IsEnabled(t *testing.T) {
 			state:    string(mcpv1.ServerStateDisabled),
 			expected: false,
 		},
+		{
+			name:     "Maintenance state stays connected",
+			state:    string(mcpv1.ServerStateMaintenance),
+			expected: true,
+		},
 		{
 			name:     "unknown state returns false",
 			state:    "Unknown",
@@ -541,6 +550,76 @@ func TestBuildHTTPClient_InvalidGatewayCACert(t *testing.T) {
 	require.Contains(t, err.Error(), "gateway CA certificate bundle")
 }
 
+// rotatingProvider hands out the current credential and counts lookups
+type rotatingProvider struct {
+	value atomic.Value
+	calls atomic.Int32
+}
+
+func (p *rotatingProvider) Credential(_ context.Context, _ config.CredentialReference) (string, error) {
+	p.calls.Add(1)
+	return p.value.Load().(string), nil
+}
+
+func TestBuildHTTPClient_CredentialRef(t *testing.T) {
+	var seen atomic.Value
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen.Store(r.Header.Get("Authorization"))
+		if r.Header.Get("Authorization") == "Bearer revoked" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	provider := &rotatingProvider{}
+	provider.value.Store("Bearer one")
+	cache := credentials.NewCache(provider, time.Hour)
+	ref := config.CredentialReference{Namespace: "team-a", Name: "creds", Key: "token"}
+	up := NewUpstreamMCP(&config.MCPServer{
+		Name:          "test",
+		URL:           srv.URL,
+		Credential:    "Bearer inline",
+		CredentialRef: &ref,
+	}, "", nil, WithCredentialProvider(cache))
+	httpClient, err := up.buildHTTPClient()
+	require.NoError(t, err)
+
+	get := func() int {
+		resp, err := httpClient.Get(srv.URL)
+		require.NoError(t, err)
+		_ = resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	// the reference wins over an inline credential
+	require.Equal(t, http.StatusOK, get())
+	require.Equal(t, "Bearer one", seen.Load())
+
+	// once the upstream rejects a credential the next request re-reads it
+	// instead of waiting out the cache ttl
+	provider.value.Store("Bearer revoked")
+	cache.Invalidate(ref)
+	require.Equal(t, http.StatusUnauthorized, get())
+	provider.value.Store("Bearer two")
+	require.Equal(t, http.StatusOK, get())
+	require.Equal(t, "Bearer two", seen.Load())
+	require.Equal(t, int32(3), provider.calls.Load())
+
+	require.Equal(t, &ref, up.GetConfig().CredentialRef)
+}
+
+func TestBuildHTTPClient_CredentialRefWithoutProvider(t *testing.T) {
+	up := NewUpstreamMCP(&config.MCPServer{
+		Name:          "test",
+		URL:           "http://localhost/mcp",
+		CredentialRef: &config.CredentialReference{Name: "creds", Key: "token"},
+	}, "", nil)
+	_, err := up.buildHTTPClient()
+	require.ErrorContains(t, err, "no credential provider")
+}
+
 func TestMCPServer_ListResources(t *testing.T) {
 	srv := mcp.NewServer(&mcp.Implementation{Name: "up", Version: "0.0.1"}, nil)
 	srv.AddResource(&mcp.Resource{
@@ -13,6 +13,7 @@ import (
 	"sync/atomic"
 	"time"
 
+	"github.com/modelcontextprotocol/go-sdk/mcp"
 	"k8s.io/apimachinery/pkg/util/wait"
 )
 
@@ -22,8 +23,9 @@ import (
 // the stream at the broker's layer, where the failure semantics are ours:
 // never fatal to the session, infinite reconnects with capped backoff, and
 // a permanent stop only when the upstream says it does not offer the
-// stream. it exists purely to push tools/prompts list-changed
-// notifications into the manager's existing refresh path; the periodic
+// stream. it exists to push tools/prompts list-changed notifications
+// into the manager's existing refresh path and upstream log events to the
+// broker's clients; the periodic
 // re-list remains the freshness backstop because events sent while the
 // stream is down are not buffered by upstreams without event replay.
 
@@ -43,8 +45,8 @@ var watchBackoff = wait.Backoff{
 const maxSSELineBytes = 1 << 20
 
 // notificationWatcher holds an upstream standalone SSE stream open for one
-// connected session, dispatching list-changed notifications to the
-// upstream's handler. everything it needs is captured at construction so
+// connected session, dispatching list-changed and log notifications to the
+// upstream's handlers. everything it needs is captured at construction so
 // the watch goroutine never touches MCPServer state.
 type notificationWatcher struct {
 	endpoint        string
@@ -53,6 +55,7 @@ type notificationWatcher struct {
 	protocolVersion string
 	serverID        string
 	notify          func(method string)
+	logMessage      func(*mcp.LoggingMessageParams)
 	logger          *slog.Logger
 
 	// streamsEstablished counts healthy streams entered; read by tests to
@@ -178,6 +181,7 @@ func (w *notificationWatcher) consumeStream(ctx context.Context, body io.Reader,
 type jsonrpcFrame struct {
 	ID     json.RawMessage `json:"id,omitempty"`
 	Method string          `json:"method,omitempty"`
+	Params json.RawMessage `json:"params,omitempty"`
 }
 
 func (w *notificationWatcher) dispatch(ctx context.Context, payload string) {
@@ -190,6 +194,15 @@ func (w *notificationWatcher) dispatch(ctx context.Context, payload string) {
 	case notificationToolsListChanged, notificationPromptsListChanged:
 		w.logger.Debug("received upstream notification", "upstream mcp server", w.serverID, "method", frame.Method)
 		w.notify(frame.Method)
+	case notificationLogMessage:
+		var params mcp.LoggingMessageParams
+		if err := json.Unmarshal(frame.Params, &params); err != nil {
+			w.logger.Debug("ignoring malformed log notification", "upstream mcp server", w.serverID, "error", err)
+			return
+		}
+		if w.logMessage != nil {
+			w.logMessage(&params)
+		}
 	case "ping":
 		if len(frame.ID) > 0 {
 			w.respondPing(ctx, frame.ID)
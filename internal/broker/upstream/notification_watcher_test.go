@@ -322,3 +322,53 @@ func TestNotificationWatcher_CleanShutdown(t *testing.T) {
 	// disconnect is idempotent with the watcher already stopped
 	require.NoError(t, up.Disconnect())
 }
+
+// upstream log events on the broker's session reach the log handler, at
+// the level recorded before the session existed.
+func TestNotificationWatcher_DeliversLogMessages(t *testing.T) {
+	srv := mcp.NewServer(&mcp.Implementation{Name: "up", Version: "0.0.1"}, &mcp.ServerOptions{
+		Capabilities: &mcp.ServerCapabilities{Logging: &mcp.LoggingCapabilities{}},
+	})
+	handler := mcp.NewStreamableHTTPHandler(func(*http.Request) *mcp.Server { return srv }, nil)
+	ts := httptest.NewServer(handler)
+	defer ts.Close()
+
+	up := NewUpstreamMCP(&config.MCPServer{Name: "up", URL: ts.URL}, "", watcherTestLogger())
+	got := make(chan *mcp.LoggingMessageParams, 4)
+	up.OnLogMessage(func(params *mcp.LoggingMessageParams) { got <- params })
+
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+	require.NoError(t, up.SetLoggingLevel(ctx, "info"), "recording a level before Connect is not an error")
+	require.NoError(t, up.Connect(ctx, func() {}))
+	defer func() { _ = up.Disconnect() }()
+	require.True(t, up.SupportsLogging())
+	waitForWatcherStream(t, up)
+
+	logAll := func(level mcp.LoggingLevel, data string) {
+		for ss := range srv.Sessions() {
+			require.NoError(t, ss.Log(ctx, &mcp.LoggingMessageParams{Level: level, Logger: "db", Data: data})) //nolint:staticcheck // exercising the deprecated logging feature
+		}
+	}
+	logAll("debug", "below the level")
+	logAll("warning", "disk low")
+
+	select {
+	case params := <-got:
+		require.Equal(t, mcp.LoggingLevel("warning"), params.Level)
+		require.Equal(t, "db", params.Logger)
+		require.Equal(t, "disk low", params.Data)
+	case <-time.After(10 * time.Second):
+		t.Fatal("log message never reached the handler")
+	}
+
+	// lowering the level on a live session takes effect immediately
+	require.NoError(t, up.SetLoggingLevel(ctx, "debug"))
+	logAll("debug", "now visible")
+	select {
+	case params := <-got:
+		require.Equal(t, "now visible", params.Data)
+	case <-time.After(10 * time.Second):
+		t.Fatal("debug message never reached the handler")
+	}
+}
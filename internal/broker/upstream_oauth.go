@@ -0,0 +1,378 @@
+package broker
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/Kuadrant/mcp-gateway/internal/broker/credentials"
+	"github.com/Kuadrant/mcp-gateway/internal/config"
+	"github.com/Kuadrant/mcp-gateway/internal/elicitation"
+	"github.com/Kuadrant/mcp-gateway/internal/session"
+	"golang.org/x/oauth2"
+	"golang.org/x/sync/singleflight"
+)
+
+// Paths of the upstream OAuth flow. They sit under /tokens so the route to
+// the token page also serves them.
+const (
+	UpstreamOAuthPath          = "/tokens/oauth/"
+	upstreamOAuthAuthorizePath = "/tokens/oauth/authorize"
+	upstreamOAuthCallbackPath  = "/tokens/oauth/callback"
+)
+
+const (
+	// upstreamOAuthCookie binds the state of a flow to the browser that
+	// started it. The PKCE verifier and elicitation stay in the elicitation
+	// map under the state, so any replica can complete the flow.
+	upstreamOAuthCookie  = "mcp_upstream_oauth"
+	upstreamOAuthFlowTTL = 10 * time.Minute
+	// upstreamTokenRefreshMargin is how long before it expires an access
+	// token is refreshed
+	upstreamTokenRefreshMargin = time.Minute
+)
+
+// upstreamTokenStore is the subset of the session cache the upstream OAuth
+// flow needs: the user token and the grant that renews it
+type upstreamTokenStore interface {
+	tokenStore
+	GetUserToken(ctx context.Context, sessionID, serverName string) (string, bool, error)
+	DeleteUserToken(ctx context.Context, sessionID, serverName string) error
+	SetUserTokenGrant(ctx context.Context, sessionID, serverName string, grant session.UserTokenGrant, ttl time.Duration) error
+	GetUserTokenGrant(ctx context.Context, sessionID, serverName string) (session.UserTokenGrant, bool, error)
+	DeleteUserTokenGrant(ctx context.Context, sessionID, serverName string) error
+}
+
+// UpstreamOAuthHandler collects per-user upstream tokens with an OAuth 2.0
+// authorization code flow with PKCE, for servers whose URL elicitation is
+// configured with an OAuth client. It serves the authorize and callback
+// endpoints under /tokens/oauth/ and refreshes the tokens it stored before
+// they expire.
+type UpstreamOAuthHandler struct {
+	tokens         upstreamTokenStore
+	elicitationMap elicitation.Map
+	config         serverConfigLookup
+	credentials    credentials.CredentialProvider
+	httpClient     *http.Client
+	logger         *slog.Logger
+	refreshes      singleflight.Group
+	now            func() time.Time
+}
+
+// NewUpstreamOAuthHandler creates the upstream OAuth handler. provider
+// resolves client secrets and may be nil when only public clients are
+// configured.
+func NewUpstreamOAuthHandler(tokens upstreamTokenStore, elicitationMap elicitation.Map, cfg serverConfigLookup, provider credentials.CredentialProvider, logger *slog.Logger) *UpstreamOAuthHandler {
+	return &UpstreamOAuthHandler{
+		tokens:         tokens,
+		elicitationMap: elicitationMap,
+		config:         cfg,
+		credentials:    provider,
+		httpClient:     &http.Client{Timeout: 10 * time.Second},
+		logger:         logger,
+		now:            time.Now,
+	}
+}
+
+func (h *UpstreamOAuthHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		h.sendError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	switch r.URL.Path {
+	case upstreamOAuthAuthorizePath:
+		h.handleAuthorize(w, r)
+	case upstreamOAuthCallbackPath:
+		h.handleCallback(w, r)
+	default:
+		h.sendError(w, http.StatusNotFound, "not found")
+	}
+}
+
+// handleAuthorize starts the flow for an elicitation and redirects the
+// user to the upstream's authorization endpoint
+func (h *UpstreamOAuthHandler) handleAuthorize(w http.ResponseWriter, r *http.Request) {
+	elicitationID := r.URL.Query().Get("elicitation_id")
+	if elicitationID == "" {
+		h.sendError(w, http.StatusBadRequest, "missing elicitation_id parameter")
+		return
+	}
+	entry, ok, err := h.elicitationMap.Lookup(r.Context(), elicitationID)
+	if err != nil {
+		h.logger.Error("elicitation lookup failed", "error", err)
+		h.sendError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	if !ok {
+		h.sendError(w, http.StatusBadRequest, "invalid or expired elicitation_id")
+		return
+	}
+	oauth := h.upstreamOAuth(entry.ServerName)
+	if oauth == nil {
+		h.sendError(w, http.StatusBadRequest, "server does not use OAuth")
+		return
+	}
+
+	state, err := generateCSRFToken()
+	if err != nil {
+		h.logger.Error("failed to generate oauth state", "error", err)
+		h.sendError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	verifier := oauth2.GenerateVerifier()
+	flow := elicitation.OAuthFlow{ElicitationID: elicitationID, Verifier: verifier}
+	if err := h.elicitationMap.StoreOAuthFlow(r.Context(), state, flow, upstreamOAuthFlowTTL); err != nil {
+		h.logger.Error("failed to store oauth flow", "error", err)
+		h.sendError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	http.SetCookie(w, &http.Cookie{
+		Name:     upstreamOAuthCookie,
+		Value:    state,
+		Path:     UpstreamOAuthPath,
+		HttpOnly: true,
+		Secure:   true,
+		// the callback is a cross-site redirect from the authorization server
+		SameSite: http.SameSiteLaxMode,
+		MaxAge:   int(upstreamOAuthFlowTTL.Seconds()),
+	})
+
+	// the authorization URL needs no client secret
+	cfg := upstreamOAuthConfig(oauth, h.redirectURL(), "")
+	w.Header().Set("Referrer-Policy", "no-referrer")
+	http.Redirect(w, r, cfg.AuthCodeURL(state, oauth2.S256ChallengeOption(verifier)), http.StatusFound)
+}
+
+// handleCallback exchanges the authorization code for the user's tokens and
+// stores them in the session the elicitation was raised for
+func (h *UpstreamOAuthHandler) handleCallback(w http.ResponseWriter, r *http.Request) {
+	cookie, err := r.Cookie(upstreamOAuthCookie)
+	if err != nil || cookie.Value == "" {
+		h.sendError(w, http.StatusForbidden, "missing OAuth flow cookie")
+		return
+	}
+	http.SetCookie(w, &http.Cookie{Name: upstreamOAuthCookie, Path: UpstreamOAuthPath, MaxAge: -1})
+
+	query := r.URL.Query()
+	if authErr := query.Get("error"); authErr != "" {
+		h.logger.Info("upstream authorization failed", "error", authErr, "description", query.Get("error_description"))
+		h.sendError(w, http.StatusBadRequest, "authorization failed: "+authErr)
+		return
+	}
+	state := query.Get("state")
+	if state == "" || subtle.ConstantTimeCompare([]byte(state), []byte(cookie.Value)) != 1 {
+		h.sendError(w, http.StatusForbidden, "state validation failed")
+		return
+	}
+	code := query.Get("code")
+	if code == "" {
+		h.sendError(w, http.StatusBadRequest, "missing code")
+		return
+	}
+
+	ctx := r.Context()
+	flow, ok, err := h.elicitationMap.ClaimOAuthFlow(ctx, state)
+	if err != nil {
+		h.logger.Error("oauth flow claim failed", "error", err)
+		h.sendError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	if !ok {
+		h.sendError(w, http.StatusBadRequest, "invalid or expired OAuth flow")
+		return
+	}
+	entry, ok, err := h.elicitationMap.Claim(ctx, flow.ElicitationID)
+	if err != nil {
+		h.logger.Error("elicitation claim failed", "error", err)
+		h.sendError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	if !ok {
+		h.sendError(w, http.StatusBadRequest, "invalid or expired elicitation_id")
+		return
+	}
+	if entry.Sub != "" {
+		reqSub := extractRequestSub(r)
+		if reqSub == "" {
+			h.sendError(w, http.StatusForbidden, "no identity found in request")
+			return
+		}
+		if reqSub != entry.Sub {
+			h.logger.Warn("oauth callback sub mismatch", "expected", entry.Sub, "got", reqSub)
+			h.sendError(w, http.StatusForbidden, "identity mismatch")
+			return
+		}
+	}
+	ttl := gatewaySessionTTL(entry.SessionID)
+	if ttl <= 0 {
+		h.sendError(w, http.StatusBadRequest, "invalid or expired session")
+		return
+	}
+	oauth := h.upstreamOAuth(entry.ServerName)
+	if oauth == nil {
+		h.sendError(w, http.StatusBadRequest, "server does not use OAuth")
+		return
+	}
+
+	secret, err := h.clientSecret(ctx, oauth)
+	if err != nil {
+		h.logger.Error("failed to resolve oauth client secret", "server", entry.ServerName, "error", err)
+		h.sendError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	cfg := upstreamOAuthConfig(oauth, h.redirectURL(), secret)
+	token, err := cfg.Exchange(h.clientContext(ctx), code, oauth2.VerifierOption(flow.Verifier))
+	if err != nil {
+		h.logger.Error("upstream token exchange failed", "server", entry.ServerName, "error", err)
+		h.sendError(w, http.StatusBadGateway, "token exchange failed")
+		return
+	}
+	if err := h.storeToken(ctx, entry.SessionID, entry.ServerName, token, ttl); err != nil {
+		h.logger.Error("failed to store user token", "error", err)
+		h.sendError(w, http.StatusInternalServerError, "failed to store token")
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Referrer-Policy", "no-referrer")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write([]byte(renderTemplate("token_success.html", tokenSuccessData{ServerName: entry.ServerName})))
+}
+
+// RefreshUserToken renews the user's upstream token for serverName when it
+// expires within upstreamTokenRefreshMargin, or was dropped after the
+// upstream rejected it. A token the authorization server refuses to renew
+// is deleted, so the router elicits a new one. Tokens not obtained with
+// OAuth are left alone.
+func (h *UpstreamOAuthHandler) RefreshUserToken(ctx context.Context, sessionID, serverName string) error {
+	oauth := h.upstreamOAuth(serverName)
+	if oauth == nil {
+		return nil
+	}
+	// concurrent calls share one refresh, and read the grant inside it, as
+	// the authorization server may rotate the refresh token
+	_, err, _ := h.refreshes.Do(sessionID+"\x00"+serverName, func() (any, error) {
+		return nil, h.refresh(ctx, sessionID, serverName, oauth)
+	})
+	return err
+}
+
+func (h *UpstreamOAuthHandler) refresh(ctx context.Context, sessionID, serverName string, oauth *config.UpstreamOAuthConfig) error {
+	grant, ok, err := h.tokens.GetUserTokenGrant(ctx, sessionID, serverName)
+	if err != nil || !ok {
+		return err
+	}
+	_, hasToken, err := h.tokens.GetUserToken(ctx, sessionID, serverName)
+	if err != nil {
+		return err
+	}
+	if hasToken && (grant.Expiry.IsZero() || h.now().Add(upstreamTokenRefreshMargin).Before(grant.Expiry)) {
+		return nil
+	}
+	if grant.RefreshToken == "" {
+		h.forgetToken(ctx, sessionID, serverName)
+		return nil
+	}
+	ttl := gatewaySessionTTL(sessionID)
+	if ttl <= 0 {
+		return nil
+	}
+	secret, err := h.clientSecret(ctx, oauth)
+	if err != nil {
+		return fmt.Errorf("resolving oauth client secret: %w", err)
+	}
+	cfg := upstreamOAuthConfig(oauth, "", secret)
+	token, err := cfg.TokenSource(h.clientContext(ctx), &oauth2.Token{RefreshToken: grant.RefreshToken}).Token()
+	if err != nil {
+		// the authorization server answered: the grant is no longer valid
+		var retrieveErr *oauth2.RetrieveError
+		if errors.As(err, &retrieveErr) {
+			h.forgetToken(ctx, sessionID, serverName)
+		}
+		return fmt.Errorf("refreshing upstream token: %w", err)
+	}
+	h.logger.DebugContext(ctx, "refreshed upstream user token", "server", serverName, "expiry", token.Expiry)
+	return h.storeToken(ctx, sessionID, serverName, token, ttl)
+}
+
+// storeToken stores the access token as the user's upstream token and the
+// grant that renews it
+func (h *UpstreamOAuthHandler) storeToken(ctx context.Context, sessionID, serverName string, token *oauth2.Token, ttl time.Duration) error {
+	if err := h.tokens.SetUserToken(ctx, sessionID, serverName, token.Type()+" "+token.AccessToken, ttl); err != nil {
+		return err
+	}
+	grant := session.UserTokenGrant{RefreshToken: token.RefreshToken, Expiry: token.Expiry}
+	return h.tokens.SetUserTokenGrant(ctx, sessionID, serverName, grant, ttl)
+}
+
+// forgetToken deletes a token that can no longer be renewed
+func (h *UpstreamOAuthHandler) forgetToken(ctx context.Context, sessionID, serverName string) {
+	if err := h.tokens.DeleteUserToken(ctx, sessionID, serverName); err != nil {
+		h.logger.ErrorContext(ctx, "failed to delete user token", "server", serverName, "error", err)
+	}
+	if err := h.tokens.DeleteUserTokenGrant(ctx, sessionID, serverName); err != nil {
+		h.logger.ErrorContext(ctx, "failed to delete user token grant", "server", serverName, "error", err)
+	}
+}
+
+// upstreamOAuth returns the OAuth client of a server from the live config
+func (h *UpstreamOAuthHandler) upstreamOAuth(serverName string) *config.UpstreamOAuthConfig {
+	serverConfig, err := h.config.GetServerConfigByName(serverName)
+	if err != nil {
+		return nil
+	}
+	return serverConfig.UpstreamOAuth()
+}
+
+// clientSecret resolves the client secret, or returns "" for a public client
+func (h *UpstreamOAuthHandler) clientSecret(ctx context.Context, oauth *config.UpstreamOAuthConfig) (string, error) {
+	if oauth.ClientSecretRef == nil {
+		return "", nil
+	}
+	if h.credentials == nil {
+		return "", fmt.Errorf("client secret %s needs a credential provider", oauth.ClientSecretRef)
+	}
+	return h.credentials.Credential(ctx, *oauth.ClientSecretRef)
+}
+
+// clientContext makes the oauth2 package use the handler's HTTP client
+func (h *UpstreamOAuthHandler) clientContext(ctx context.Context) context.Context {
+	return context.WithValue(ctx, oauth2.HTTPClient, h.httpClient)
+}
+
+// redirectURL is the callback URL on the gateway's configured external
+// hostname. Request headers are not trusted to name it.
+func (h *UpstreamOAuthHandler) redirectURL() string {
+	return "https://" + h.config.GetExternalHostname() + upstreamOAuthCallbackPath
+}
+
+func (h *UpstreamOAuthHandler) sendError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(map[string]string{"error": message})
+}
+
+// upstreamOAuthConfig builds the oauth2 client for a server's OAuth config
+func upstreamOAuthConfig(oauth *config.UpstreamOAuthConfig, redirectURL, clientSecret string) *oauth2.Config {
+	authStyle := oauth2.AuthStyleAutoDetect
+	if clientSecret == "" {
+		// a public client identifies itself in the request body
+		authStyle = oauth2.AuthStyleInParams
+	}
+	return &oauth2.Config{
+		ClientID:     oauth.ClientID,
+		ClientSecret: clientSecret,
+		Endpoint: oauth2.Endpoint{
+			AuthURL:   oauth.AuthorizationURL,
+			TokenURL:  oauth.TokenURL,
+			AuthStyle: authStyle,
+		},
+		RedirectURL: redirectURL,
+		Scopes:      oauth.Scopes,
+	}
+}
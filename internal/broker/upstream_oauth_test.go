@@ -0,0 +1,438 @@
+package broker
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Kuadrant/mcp-gateway/internal/config"
+	"github.com/Kuadrant/mcp-gateway/internal/elicitation"
+	sharedheaders "github.com/Kuadrant/mcp-gateway/internal/headers"
+	"github.com/Kuadrant/mcp-gateway/internal/session"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeUpstreamTokenEndpoint is the token endpoint of an OAuth authorization
+// server that issues one hour tokens and rotates refresh tokens
+type fakeUpstreamTokenEndpoint struct {
+	*httptest.Server
+	mu        sync.Mutex
+	challenge string
+	refreshes int
+	// refreshToken is the only refresh token the server accepts
+	refreshToken string
+	forms        []url.Values
+}
+
+func newFakeUpstreamTokenEndpoint(t *testing.T) *fakeUpstreamTokenEndpoint {
+	t.Helper()
+	as := &fakeUpstreamTokenEndpoint{}
+	as.Server = httptest.NewServer(http.HandlerFunc(as.token))
+	t.Cleanup(as.Close)
+	return as
+}
+
+func (as *fakeUpstreamTokenEndpoint) token(w http.ResponseWriter, r *http.Request) {
+	as.mu.Lock()
+	defer as.mu.Unlock()
+	_ = r.ParseForm()
+	as.forms = append(as.forms, r.PostForm)
+	tokenError := func(code string) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(w).Encode(map[string]string{"error": code})
+	}
+	switch r.PostForm.Get("grant_type") {
+	case "authorization_code":
+		sum := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+		if r.PostForm.Get("code") != "the-code" || base64.RawURLEncoding.EncodeToString(sum[:]) != as.challenge {
+			tokenError("invalid_grant")
+			return
+		}
+		as.refreshToken = "refresh-0"
+	case "refresh_token":
+		if as.refreshToken == "" || r.PostForm.Get("refresh_token") != as.refreshToken {
+			tokenError("invalid_grant")
+			return
+		}
+		as.refreshes++
+		as.refreshToken = "refresh-" + string(rune('0'+as.refreshes))
+	default:
+		tokenError("unsupported_grant_type")
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]any{
+		"access_token":  "access-" + as.refreshToken,
+		"token_type":    "Bearer",
+		"expires_in":    3600,
+		"refresh_token": as.refreshToken,
+	})
+}
+
+type upstreamOAuthTest struct {
+	handler *UpstreamOAuthHandler
+	eMap    elicitation.Map
+	cache   *session.Cache
+	as      *fakeUpstreamTokenEndpoint
+	oauth   *config.UpstreamOAuthConfig
+}
+
+func setupUpstreamOAuthHandler(t *testing.T) *upstreamOAuthTest {
+	t.Helper()
+	eMap, err := elicitation.New()
+	require.NoError(t, err)
+	cache, err := session.NewCache()
+	require.NoError(t, err)
+	as := newFakeUpstreamTokenEndpoint(t)
+	oauth := &config.UpstreamOAuthConfig{
+		AuthorizationURL: "https://idp.example.com/authorize",
+		TokenURL:         as.URL + "/token",
+		ClientID:         "gateway",
+		Scopes:           []string{"repo", "read:user"},
+	}
+	cfg := &stubServerConfig{
+		servers: map[string]*config.MCPServer{
+			"github": {Name: "github", TokenURLElicitation: &config.TokenURLElicitationConfig{OAuth: oauth}},
+			"jira":   {Name: "jira", TokenURLElicitation: &config.TokenURLElicitationConfig{}},
+		},
+		externalHostname: "gateway.example.com",
+	}
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	return &upstreamOAuthTest{
+		handler: NewUpstreamOAuthHandler(cache, eMap, cfg, nil, logger),
+		eMap:    eMap,
+		cache:   cache,
+		as:      as,
+		oauth:   oauth,
+	}
+}
+
+// authorize starts a flow and returns the redirect location and flow cookie
+func (tt *upstreamOAuthTest) authorize(t *testing.T, elicitationID string) (*url.URL, *http.Cookie) {
+	t.Helper()
+	return tt.authorizeRequest(t, httptest.NewRequest(http.MethodGet, "http://gateway.example.com/tokens/oauth/authorize?elicitation_id="+elicitationID, nil))
+}
+
+func (tt *upstreamOAuthTest) authorizeRequest(t *testing.T, req *http.Request) (*url.URL, *http.Cookie) {
+	t.Helper()
+	w := httptest.NewRecorder()
+	tt.handler.ServeHTTP(w, req)
+	require.Equal(t, http.StatusFound, w.Code, w.Body.String())
+
+	location, err := url.Parse(w.Header().Get("Location"))
+	require.NoError(t, err)
+	tt.as.mu.Lock()
+	tt.as.challenge = location.Query().Get("code_challenge")
+	tt.as.mu.Unlock()
+	for _, c := range w.Result().Cookies() {
+		if c.Name == upstreamOAuthCookie {
+			return location, c
+		}
+	}
+	t.Fatal("no flow cookie in authorize response")
+	return nil, nil
+}
+
+func callbackRequest(cookie *http.Cookie, query url.Values) *http.Request {
+	req := httptest.NewRequest(http.MethodGet, "http://gateway.example.com/tokens/oauth/callback?"+query.Encode(), nil)
+	if cookie != nil {
+		req.AddCookie(cookie)
+	}
+	return req
+}
+
+func TestUpstreamOAuthHandler_Authorize(t *testing.T) {
+	tt := setupUpstreamOAuthHandler(t)
+	id, _ := tt.eMap.Store(context.Background(), buildSessionJWT(time.Now().Add(time.Hour)), "github", "")
+
+	location, cookie := tt.authorize(t, id)
+	require.Equal(t, "idp.example.com", location.Host)
+	query := location.Query()
+	require.Equal(t, "code", query.Get("response_type"))
+	require.Equal(t, "gateway", query.Get("client_id"))
+	require.Equal(t, "https://gateway.example.com/tokens/oauth/callback", query.Get("redirect_uri"))
+	require.Equal(t, "repo read:user", query.Get("scope"))
+	require.Equal(t, "S256", query.Get("code_challenge_method"))
+	require.NotEmpty(t, query.Get("code_challenge"))
+	require.NotEmpty(t, query.Get("state"))
+
+	require.True(t, cookie.HttpOnly)
+	require.True(t, cookie.Secure)
+	require.Equal(t, http.SameSiteLaxMode, cookie.SameSite)
+	require.Equal(t, UpstreamOAuthPath, cookie.Path)
+	// only the state leaves the gateway, the verifier is kept under it
+	require.Equal(t, query.Get("state"), cookie.Value)
+	flow, ok, err := tt.eMap.ClaimOAuthFlow(context.Background(), cookie.Value)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, id, flow.ElicitationID)
+	require.NotEmpty(t, flow.Verifier)
+
+	// the elicitation is only claimed by the callback
+	_, ok, _ = tt.eMap.Lookup(context.Background(), id)
+	require.True(t, ok)
+}
+
+func TestUpstreamOAuthHandler_AuthorizeIgnoresRequestHost(t *testing.T) {
+	tt := setupUpstreamOAuthHandler(t)
+	id, _ := tt.eMap.Store(context.Background(), buildSessionJWT(time.Now().Add(time.Hour)), "github", "")
+
+	req := httptest.NewRequest(http.MethodGet, "http://attacker.example.com/tokens/oauth/authorize?elicitation_id="+id, nil)
+	req.Header.Set("X-Forwarded-Proto", "http")
+	location, cookie := tt.authorizeRequest(t, req)
+	require.Equal(t, "https://gateway.example.com/tokens/oauth/callback", location.Query().Get("redirect_uri"))
+	require.True(t, cookie.Secure)
+}
+
+func TestUpstreamOAuthHandler_AuthorizeRejected(t *testing.T) {
+	tt := setupUpstreamOAuthHandler(t)
+	ctx := context.Background()
+	jiraID, _ := tt.eMap.Store(ctx, buildSessionJWT(time.Now().Add(time.Hour)), "jira", "")
+
+	for name, target := range map[string]string{
+		"missing elicitation":  "/tokens/oauth/authorize",
+		"unknown elicitation":  "/tokens/oauth/authorize?elicitation_id=nope",
+		"server without oauth": "/tokens/oauth/authorize?elicitation_id=" + jiraID,
+	} {
+		t.Run(name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			tt.handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, target, nil))
+			require.Equal(t, http.StatusBadRequest, w.Code)
+		})
+	}
+
+	w := httptest.NewRecorder()
+	tt.handler.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/tokens/oauth/authorize", nil))
+	require.Equal(t, http.StatusMethodNotAllowed, w.Code)
+}
+
+func TestUpstreamOAuthHandler_Callback(t *testing.T) {
+	tt := setupUpstreamOAuthHandler(t)
+	ctx := context.Background()
+	sessionID := buildSessionJWT(time.Now().Add(time.Hour))
+	id, _ := tt.eMap.Store(ctx, sessionID, "github", "user123")
+	location, cookie := tt.authorize(t, id)
+
+	req := callbackRequest(cookie, url.Values{"code": {"the-code"}, "state": {location.Query().Get("state")}})
+	req.Header.Set(sharedheaders.VerifiedSubHeader, "user123")
+	w := httptest.NewRecorder()
+	tt.handler.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	require.Contains(t, w.Body.String(), "github")
+
+	token, ok, err := tt.cache.GetUserToken(ctx, sessionID, "github")
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, "Bearer access-refresh-0", token)
+	grant, ok, err := tt.cache.GetUserTokenGrant(ctx, sessionID, "github")
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, "refresh-0", grant.RefreshToken)
+	require.WithinDuration(t, time.Now().Add(time.Hour), grant.Expiry, time.Minute)
+
+	// the exchange sent the redirect URI of the authorization request
+	require.Equal(t, "https://gateway.example.com/tokens/oauth/callback", tt.as.forms[0].Get("redirect_uri"))
+
+	// the elicitation and the flow cookie are single use
+	_, ok, _ = tt.eMap.Lookup(ctx, id)
+	require.False(t, ok)
+	w = httptest.NewRecorder()
+	tt.handler.ServeHTTP(w, req)
+	require.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestUpstreamOAuthHandler_CallbackRejected(t *testing.T) {
+	tests := []struct {
+		name       string
+		sub        string
+		query      func(state string) url.Values
+		noCookie   bool
+		wantStatus int
+	}{
+		{
+			name:       "state mismatch",
+			query:      func(string) url.Values { return url.Values{"code": {"the-code"}, "state": {"forged"}} },
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name:       "missing flow cookie",
+			query:      func(state string) url.Values { return url.Values{"code": {"the-code"}, "state": {state}} },
+			noCookie:   true,
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name:       "sub mismatch",
+			sub:        "attacker",
+			query:      func(state string) url.Values { return url.Values{"code": {"the-code"}, "state": {state}} },
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name:       "authorization denied",
+			query:      func(state string) url.Values { return url.Values{"error": {"access_denied"}, "state": {state}} },
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "exchange failed",
+			query:      func(state string) url.Values { return url.Values{"code": {"stolen-code"}, "state": {state}} },
+			wantStatus: http.StatusBadGateway,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tt := setupUpstreamOAuthHandler(t)
+			ctx := context.Background()
+			sessionID := buildSessionJWT(time.Now().Add(time.Hour))
+			id, _ := tt.eMap.Store(ctx, sessionID, "github", "user123")
+			location, cookie := tt.authorize(t, id)
+			if tc.noCookie {
+				cookie = nil
+			}
+
+			req := callbackRequest(cookie, tc.query(location.Query().Get("state")))
+			sub := tc.sub
+			if sub == "" {
+				sub = "user123"
+			}
+			req.Header.Set(sharedheaders.VerifiedSubHeader, sub)
+			w := httptest.NewRecorder()
+			tt.handler.ServeHTTP(w, req)
+			require.Equal(t, tc.wantStatus, w.Code, w.Body.String())
+
+			_, ok, err := tt.cache.GetUserToken(ctx, sessionID, "github")
+			require.NoError(t, err)
+			require.False(t, ok)
+		})
+	}
+}
+
+// connect runs the flow and returns the session the token is stored in
+func (tt *upstreamOAuthTest) connect(t *testing.T) string {
+	t.Helper()
+	sessionID := buildSessionJWT(time.Now().Add(2 * time.Hour))
+	id, _ := tt.eMap.Store(context.Background(), sessionID, "github", "")
+	location, cookie := tt.authorize(t, id)
+	w := httptest.NewRecorder()
+	tt.handler.ServeHTTP(w, callbackRequest(cookie, url.Values{"code": {"the-code"}, "state": {location.Query().Get("state")}}))
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	return sessionID
+}
+
+func TestUpstreamOAuthHandler_RefreshUserToken(t *testing.T) {
+	tt := setupUpstreamOAuthHandler(t)
+	ctx := context.Background()
+	sessionID := tt.connect(t)
+
+	// a fresh token is not refreshed
+	require.NoError(t, tt.handler.RefreshUserToken(ctx, sessionID, "github"))
+	require.Zero(t, tt.as.refreshes)
+
+	// within the margin of its expiry the token is renewed and the rotated
+	// refresh token kept
+	tt.handler.now = func() time.Time { return time.Now().Add(time.Hour - 30*time.Second) }
+	require.NoError(t, tt.handler.RefreshUserToken(ctx, sessionID, "github"))
+	require.Equal(t, 1, tt.as.refreshes)
+	token, _, _ := tt.cache.GetUserToken(ctx, sessionID, "github")
+	require.Equal(t, "Bearer access-refresh-1", token)
+	grant, _, _ := tt.cache.GetUserTokenGrant(ctx, sessionID, "github")
+	require.Equal(t, "refresh-1", grant.RefreshToken)
+
+	// a token dropped after the upstream rejected it is renewed too
+	tt.handler.now = time.Now
+	require.NoError(t, tt.cache.DeleteUserToken(ctx, sessionID, "github"))
+	require.NoError(t, tt.handler.RefreshUserToken(ctx, sessionID, "github"))
+	token, ok, _ := tt.cache.GetUserToken(ctx, sessionID, "github")
+	require.True(t, ok)
+	require.Equal(t, "Bearer access-refresh-2", token)
+	require.Equal(t, "client_id=gateway&grant_type=refresh_token&refresh_token=refresh-1", tt.as.forms[len(tt.as.forms)-1].Encode())
+}
+
+func TestUpstreamOAuthHandler_RefreshConcurrent(t *testing.T) {
+	tt := setupUpstreamOAuthHandler(t)
+	ctx := context.Background()
+	sessionID := tt.connect(t)
+	require.NoError(t, tt.cache.DeleteUserToken(ctx, sessionID, "github"))
+
+	var wg sync.WaitGroup
+	for range 5 {
+		wg.Go(func() {
+			require.NoError(t, tt.handler.RefreshUserToken(ctx, sessionID, "github"))
+		})
+	}
+	wg.Wait()
+
+	// later callers see the renewed token rather than presenting the
+	// rotated refresh token again
+	require.Equal(t, 1, tt.as.refreshes)
+	token, ok, err := tt.cache.GetUserToken(ctx, sessionID, "github")
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, "Bearer access-refresh-1", token)
+}
+
+func TestUpstreamOAuthHandler_RefreshRejected(t *testing.T) {
+	tt := setupUpstreamOAuthHandler(t)
+	ctx := context.Background()
+	sessionID := tt.connect(t)
+
+	// the authorization server revoked the grant
+	tt.as.mu.Lock()
+	tt.as.refreshToken = "revoked"
+	tt.as.mu.Unlock()
+	tt.handler.now = func() time.Time { return time.Now().Add(time.Hour) }
+	err := tt.handler.RefreshUserToken(ctx, sessionID, "github")
+	require.Error(t, err)
+	require.True(t, strings.Contains(err.Error(), "invalid_grant"), err.Error())
+
+	// the user is elicited again
+	_, ok, _ := tt.cache.GetUserToken(ctx, sessionID, "github")
+	require.False(t, ok)
+	_, ok, _ = tt.cache.GetUserTokenGrant(ctx, sessionID, "github")
+	require.False(t, ok)
+}
+
+func TestUpstreamOAuthHandler_RefreshIgnoresOtherTokens(t *testing.T) {
+	tt := setupUpstreamOAuthHandler(t)
+	ctx := context.Background()
+	sessionID := buildSessionJWT(time.Now().Add(time.Hour))
+
+	// a pasted token has no grant and is left alone
+	require.NoError(t, tt.cache.SetUserToken(ctx, sessionID, "github", "ghp_pasted", time.Hour))
+	require.NoError(t, tt.handler.RefreshUserToken(ctx, sessionID, "github"))
+	token, _, _ := tt.cache.GetUserToken(ctx, sessionID, "github")
+	require.Equal(t, "ghp_pasted", token)
+
+	require.NoError(t, tt.handler.RefreshUserToken(ctx, sessionID, "jira"))
+	require.NoError(t, tt.handler.RefreshUserToken(ctx, sessionID, "unknown"))
+	require.Empty(t, tt.as.forms)
+}
+
+func TestUpstreamOAuthHandler_ClientSecret(t *testing.T) {
+	tt := setupUpstreamOAuthHandler(t)
+	tt.oauth.ClientSecretRef = &config.CredentialReference{Namespace: "mcp-test", Name: "github-oauth", Key: "secret"}
+
+	// without a credential provider a confidential client cannot be used
+	_, err := tt.handler.clientSecret(context.Background(), tt.oauth)
+	require.Error(t, err)
+
+	tt.handler.credentials = stubCredentialProvider{"mcp-test/github-oauth/secret": "s3cret"}
+	secret, err := tt.handler.clientSecret(context.Background(), tt.oauth)
+	require.NoError(t, err)
+	require.Equal(t, "s3cret", secret)
+}
+
+type stubCredentialProvider map[string]string
+
+func (p stubCredentialProvider) Credential(_ context.Context, ref config.CredentialReference) (string, error) {
+	return p[ref.Namespace+"/"+ref.Name+"/"+ref.Key], nil
+}
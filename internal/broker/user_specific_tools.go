@@ -14,6 +14,7 @@ import (
 	"github.com/Kuadrant/mcp-gateway/internal/broker/upstream"
 	"github.com/Kuadrant/mcp-gateway/internal/config"
 	internaljwt "github.com/Kuadrant/mcp-gateway/internal/jwt"
+	mcpotel "github.com/Kuadrant/mcp-gateway/internal/otel"
 	"github.com/Kuadrant/mcp-gateway/internal/protocol"
 	"github.com/Kuadrant/mcp-gateway/internal/transport"
 	"github.com/modelcontextprotocol/go-sdk/mcp"
@@ -167,7 +168,7 @@ func (broker *mcpBrokerImpl) doFetchTools(ctx context.Context, srv userSpecificS
 		return nil, fmt.Errorf("connect: %w", err)
 	}
 
-	toolsResult, err := session.ListTools(fetchCtx, nil)
+	toolsResult, err := session.ListTools(fetchCtx, &mcp.ListToolsParams{Meta: mcpotel.TraceMeta(fetchCtx)})
 	if err != nil {
 		// stale session; evict and retry once
 		broker.evictUserSession(gatewaySessionID, srv.name)
@@ -175,7 +176,7 @@ func (broker *mcpBrokerImpl) doFetchTools(ctx context.Context, srv userSpecificS
 		if err != nil {
 			return nil, fmt.Errorf("reconnect: %w", err)
 		}
-		toolsResult, err = session.ListTools(fetchCtx, nil)
+		toolsResult, err = session.ListTools(fetchCtx, &mcp.ListToolsParams{Meta: mcpotel.TraceMeta(fetchCtx)})
 		if err != nil {
 			broker.evictUserSession(gatewaySessionID, srv.name)
 			return nil, fmt.Errorf("list tools: %w", err)
@@ -255,7 +256,7 @@ func (broker *mcpBrokerImpl) fetchToolsStateless(ctx context.Context, srv userSp
 	}
 	defer func() { _ = session.Close() }()
 
-	toolsResult, err := session.ListTools(fetchCtx, nil)
+	toolsResult, err := session.ListTools(fetchCtx, &mcp.ListToolsParams{Meta: mcpotel.TraceMeta(fetchCtx)})
 	if err != nil {
 		span.SetStatus(codes.Error, err.Error())
 		return nil, fmt.Errorf("list tools: %w", err)
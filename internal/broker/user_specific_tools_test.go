@@ -426,6 +426,12 @@ func (m *mockActiveMCPServer) SupportsResources() bool { return false }
 func (m *mockActiveMCPServer) ListResources(context.Context) (*mcp.ListResourcesResult, error) {
 	return &mcp.ListResourcesResult{}, nil
 }
+func (m *mockActiveMCPServer) SupportsLogging() bool { return false }
+func (m *mockActiveMCPServer) SetLoggingLevel(context.Context, mcp.LoggingLevel) error {
+	return nil
+}
+
+func (m *mockActiveMCPServer) Refresh() {}
 
 // seedUserSession dials the fake upstream and stores a pooled session for
 // the given gateway session ID.
@@ -98,3 +98,9 @@ func (m *mockActiveServer) ListResources(context.Context) (*mcp.ListResourcesRes
 	}
 	return &mcp.ListResourcesResult{}, nil
 }
+func (m *mockActiveServer) SupportsLogging() bool { return false }
+func (m *mockActiveServer) SetLoggingLevel(context.Context, mcp.LoggingLevel) error {
+	return nil
+}
+
+func (m *mockActiveServer) Refresh() {}
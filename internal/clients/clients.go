@@ -4,10 +4,14 @@ Package clients provides a set of clients for use with the gateway code
 package clients
 
 import (
+	"bytes"
 	"context"
 	"crypto/tls"
 	"crypto/x509"
+	"encoding/json"
 	"fmt"
+	"io"
+	"mime"
 	"net"
 	"net/http"
 	"strings"
@@ -109,10 +113,20 @@ func buildHairpinURL(gatewayHost, mcpPath string) string {
 	return "http://" + gatewayHost + mcpPath
 }
 
+// ClientCapabilities are the server-initiated request capabilities a backend
+// session advertises on behalf of the gateway client. The router forwards
+// those requests to the client, so each must only be set when the client
+// declared it.
+type ClientCapabilities struct {
+	Elicitation bool
+	Sampling    bool
+	Roots       bool
+}
+
 // Initialize will create a new initialize and initialized request and return the associated client session.
 // This method makes a request back through the gateway to ensure any AuthPolicy is triggered.
 // The caller must set the routing key and mcp-init-host headers in passThroughHeaders before calling.
-func Initialize(ctx context.Context, gatewayHost string, conf *config.MCPServer, passThroughHeaders map[string]string, clientElicitation bool, hairpinClientPool *HairpinClientPool) (*mcp.ClientSession, error) {
+func Initialize(ctx context.Context, gatewayHost string, conf *config.MCPServer, passThroughHeaders map[string]string, clientCaps ClientCapabilities, hairpinClientPool *HairpinClientPool) (*mcp.ClientSession, error) {
 	mcpPath, err := conf.Path()
 	if err != nil {
 		return nil, err
@@ -155,9 +169,15 @@ func Initialize(ctx context.Context, gatewayHost string, conf *config.MCPServer,
 	}
 
 	caps := &mcp.ClientCapabilities{}
-	if clientElicitation {
+	if clientCaps.Elicitation {
 		caps.Elicitation = &mcp.ElicitationCapabilities{}
 	}
+	if clientCaps.Sampling {
+		caps.Sampling = &mcp.SamplingCapabilities{}
+	}
+	if clientCaps.Roots {
+		caps.RootsV2 = &mcp.RootCapabilities{} //nolint:staticcheck // roots is deprecated but still served to 2025 clients
+	}
 
 	client := mcp.NewClient(&mcp.Implementation{
 		Name:    "mcp-gateway",
@@ -174,6 +194,110 @@ func Initialize(ctx context.Context, gatewayHost string, conf *config.MCPServer,
 	return session, nil
 }
 
+// maxSetLevelResponseBytes bounds how much of the reply to a message posted on
+// a backend session is read; it is empty or an empty result, so anything
+// larger is not a well-formed reply.
+const maxSetLevelResponseBytes = 64 * 1024
+
+// SetLoggingLevel sends logging/setLevel on an existing backend session. Like
+// Initialize it hairpins through the gateway so AuthPolicy applies; the
+// caller must set the routing key and mcp-init-host headers in
+// passThroughHeaders. A JSON-RPC error from the upstream is returned as an error.
+func SetLoggingLevel(ctx context.Context, gatewayHost string, conf *config.MCPServer, backendSessionID, level string, passThroughHeaders map[string]string, hairpinClientPool *HairpinClientPool) error {
+	body, err := json.Marshal(map[string]any{
+		"jsonrpc": "2.0",
+		"id":      1,
+		"method":  "logging/setLevel",
+		"params":  map[string]any{"level": level},
+	})
+	if err != nil {
+		return err
+	}
+	return postToSession(ctx, gatewayHost, conf, backendSessionID, body, passThroughHeaders, hairpinClientPool)
+}
+
+// RejectServerRequest answers the request id a backend sent on one of its
+// sessions with a JSON-RPC error, for server-to-client requests the gateway
+// will not forward. It hairpins like SetLoggingLevel.
+func RejectServerRequest(ctx context.Context, gatewayHost string, conf *config.MCPServer, backendSessionID string, id any, code int, message string, passThroughHeaders map[string]string, hairpinClientPool *HairpinClientPool) error {
+	body, err := json.Marshal(map[string]any{
+		"jsonrpc": "2.0",
+		"id":      id,
+		"error":   map[string]any{"code": code, "message": message},
+	})
+	if err != nil {
+		return err
+	}
+	return postToSession(ctx, gatewayHost, conf, backendSessionID, body, passThroughHeaders, hairpinClientPool)
+}
+
+// postToSession posts a JSON-RPC message on a backend session through the
+// gateway and returns any JSON-RPC error in the reply
+func postToSession(ctx context.Context, gatewayHost string, conf *config.MCPServer, backendSessionID string, body []byte, passThroughHeaders map[string]string, hairpinClientPool *HairpinClientPool) error {
+	mcpPath, err := conf.Path()
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, buildHairpinURL(gatewayHost, mcpPath), bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	for k, v := range passThroughHeaders {
+		req.Header.Set(k, v)
+	}
+	req.Header.Set("x-client-id", "lazyinit")
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json, text/event-stream")
+	req.Header.Set("Mcp-Session-Id", backendSessionID)
+
+	resp, err := (&transport.StatusCapturingRoundTripper{Base: roundTripper(hairpinClientPool.Get(conf.Hostname))}).RoundTrip(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	raw, err := io.ReadAll(io.LimitReader(resp.Body, maxSetLevelResponseBytes))
+	if err != nil {
+		return err
+	}
+	return jsonRPCError(resp.Header.Get("Content-Type"), raw)
+}
+
+func roundTripper(c *http.Client) http.RoundTripper {
+	if c.Transport == nil {
+		return http.DefaultTransport
+	}
+	return c.Transport
+}
+
+// jsonRPCError extracts the error member from a JSON or SSE framed JSON-RPC
+// response. an empty body (202) is a success.
+func jsonRPCError(contentType string, raw []byte) error {
+	if mt, _, _ := mime.ParseMediaType(contentType); mt == "text/event-stream" {
+		for _, event := range bytes.Split(raw, []byte("\n\n")) {
+			if data, _ := transport.SSEEventData(event); len(data) > 0 {
+				raw = bytes.Join(data, []byte("\n"))
+				break
+			}
+		}
+	}
+	if len(bytes.TrimSpace(raw)) == 0 {
+		return nil
+	}
+	var msg struct {
+		Error *struct {
+			Code    int    `json:"code"`
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(raw, &msg); err != nil {
+		return fmt.Errorf("invalid logging/setLevel response: %w", err)
+	}
+	if msg.Error != nil {
+		return fmt.Errorf("logging/setLevel rejected: %d %s", msg.Error.Code, msg.Error.Message)
+	}
+	return nil
+}
+
 // BuildHairpinHTTPClientPool returns a HairpinClientPool for hairpin requests.
 // For HTTPS private hosts it configures TLS with the publicHost as the default
 // ServerName (SNI). Servers on a different HTTPS listener can obtain a client
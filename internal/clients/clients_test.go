@@ -25,6 +25,7 @@ import (
 	"time"
 
 	"github.com/Kuadrant/mcp-gateway/internal/config"
+	"github.com/Kuadrant/mcp-gateway/internal/transport"
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 	"github.com/stretchr/testify/require"
 )
@@ -104,7 +105,7 @@ func TestInitialize(t *testing.T) {
 				defaultClient: &http.Client{},
 				clients:       make(map[string]*http.Client),
 			}
-			client, err := Initialize(context.Background(), tc.gatewayHost, tc.conf, tc.passThroughHeaders, false, pool)
+			client, err := Initialize(context.Background(), tc.gatewayHost, tc.conf, tc.passThroughHeaders, ClientCapabilities{}, pool)
 			if tc.expectedError {
 				require.Error(t, err)
 				return
@@ -161,7 +162,7 @@ func TestInitializeAgainstLegacyServer(t *testing.T) {
 		Hostname: u.Host,
 	}
 
-	session, err := Initialize(context.Background(), u.Host, conf, map[string]string{}, false, pool)
+	session, err := Initialize(context.Background(), u.Host, conf, map[string]string{}, ClientCapabilities{}, pool)
 	require.NoError(t, err)
 	require.NotEmpty(t, session.ID(), "hairpin init must yield a backend session id")
 
@@ -319,3 +320,129 @@ func generateHairpinTestCAPEM(t *testing.T) []byte {
 	require.NoError(t, err)
 	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certDER})
 }
+
+func TestSetLoggingLevel(t *testing.T) {
+	levels := make(chan mcp.LoggingLevel, 1)
+	server := mcp.NewServer(&mcp.Implementation{Name: "test-backend", Version: "0.0.1"}, nil)
+	server.AddReceivingMiddleware(func(next mcp.MethodHandler) mcp.MethodHandler {
+		return func(ctx context.Context, method string, req mcp.Request) (mcp.Result, error) {
+			if p, ok := req.GetParams().(*mcp.SetLoggingLevelParams); ok && method == "logging/setLevel" {
+				levels <- p.Level
+			}
+			return next(ctx, method, req)
+		}
+	})
+	handler := mcp.NewStreamableHTTPHandler(func(*http.Request) *mcp.Server { return server }, nil)
+	var headers http.Header
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		headers = r.Header.Clone()
+		handler.ServeHTTP(w, r)
+	}))
+	defer ts.Close()
+
+	u, err := url.Parse(ts.URL)
+	require.NoError(t, err)
+	pool := &HairpinClientPool{
+		defaultClient: &http.Client{},
+		clients:       make(map[string]*http.Client),
+	}
+	conf := &config.MCPServer{
+		Name:     "test-server",
+		URL:      ts.URL + "/mcp",
+		Hostname: u.Host,
+	}
+	session, err := Initialize(context.Background(), u.Host, conf, map[string]string{}, ClientCapabilities{}, pool)
+	require.NoError(t, err)
+	defer func() { _ = session.Close() }()
+
+	passThrough := map[string]string{"mcp-init-host": u.Host, "authorization": "Bearer user"}
+	require.NoError(t, SetLoggingLevel(context.Background(), u.Host, conf, session.ID(), "warning", passThrough, pool))
+	require.Equal(t, mcp.LoggingLevel("warning"), <-levels)
+	require.Equal(t, session.ID(), headers.Get("Mcp-Session-Id"))
+	require.Equal(t, u.Host, headers.Get("mcp-init-host"))
+	require.Equal(t, "Bearer user", headers.Get("Authorization"))
+
+	// an unknown backend session surfaces the upstream status
+	err = SetLoggingLevel(context.Background(), u.Host, conf, "gone", "warning", passThrough, pool)
+	var statusErr *transport.HTTPStatusError
+	require.ErrorAs(t, err, &statusErr)
+	require.Equal(t, http.StatusNotFound, statusErr.Code)
+}
+
+func TestRejectServerRequest(t *testing.T) {
+	samplingErrs := make(chan error, 1)
+	server := mcp.NewServer(&mcp.Implementation{Name: "test-backend", Version: "0.0.1"}, nil)
+	mcp.AddTool(server, &mcp.Tool{Name: "summarize"}, func(ctx context.Context, req *mcp.CallToolRequest, _ any) (*mcp.CallToolResult, any, error) {
+		_, err := req.Session.CreateMessage(ctx, &mcp.CreateMessageParams{
+			Messages:  []*mcp.SamplingMessage{{Role: "user", Content: &mcp.TextContent{Text: "hi"}}},
+			MaxTokens: 10,
+		})
+		samplingErrs <- err
+		return &mcp.CallToolResult{Content: []mcp.Content{&mcp.TextContent{Text: "done"}}}, nil, nil
+	})
+	handler := mcp.NewStreamableHTTPHandler(func(*http.Request) *mcp.Server { return server }, nil)
+	ts := httptest.NewServer(handler)
+	defer ts.Close()
+
+	u, err := url.Parse(ts.URL)
+	require.NoError(t, err)
+	pool := &HairpinClientPool{
+		defaultClient: &http.Client{},
+		clients:       make(map[string]*http.Client),
+	}
+	conf := &config.MCPServer{
+		Name:     "test-server",
+		URL:      ts.URL + "/mcp",
+		Hostname: u.Host,
+	}
+	session, err := Initialize(context.Background(), u.Host, conf, map[string]string{}, ClientCapabilities{Sampling: true}, pool)
+	require.NoError(t, err)
+	defer func() { _ = session.Close() }()
+
+	// call the tool and read its stream up to the sampling request
+	call, err := http.NewRequest(http.MethodPost, ts.URL+"/mcp",
+		bytes.NewReader([]byte(`{"jsonrpc":"2.0","id":5,"method":"tools/call","params":{"name":"summarize","arguments":{}}}`)))
+	require.NoError(t, err)
+	call.Header.Set("Content-Type", "application/json")
+	call.Header.Set("Accept", "application/json, text/event-stream")
+	call.Header.Set("Mcp-Session-Id", session.ID())
+	resp, err := http.DefaultClient.Do(call)
+	require.NoError(t, err)
+	defer func() { _ = resp.Body.Close() }()
+	var samplingID any
+	buf := make([]byte, 4096)
+	var stream []byte
+	for samplingID == nil {
+		n, err := resp.Body.Read(buf)
+		require.NoError(t, err)
+		stream = append(stream, buf[:n]...)
+		for _, event := range bytes.Split(stream, []byte("\n\n")) {
+			data, _ := transport.SSEEventData(event)
+			var msg struct {
+				ID     any    `json:"id"`
+				Method string `json:"method"`
+			}
+			if len(data) > 0 && json.Unmarshal(bytes.Join(data, nil), &msg) == nil && msg.Method == "sampling/createMessage" {
+				samplingID = msg.ID
+			}
+		}
+	}
+
+	require.NoError(t, RejectServerRequest(context.Background(), u.Host, conf, session.ID(), samplingID, -1, "sampling is not allowed", map[string]string{}, pool))
+	select {
+	case err := <-samplingErrs:
+		require.ErrorContains(t, err, "sampling is not allowed")
+	case <-time.After(5 * time.Second):
+		t.Fatal("backend never received the rejection")
+	}
+}
+
+func TestJSONRPCError(t *testing.T) {
+	require.NoError(t, jsonRPCError("application/json", nil))
+	require.NoError(t, jsonRPCError("application/json", []byte(`{"jsonrpc":"2.0","id":1,"result":{}}`)))
+	require.NoError(t, jsonRPCError("text/event-stream", []byte("event: message\ndata: {\"jsonrpc\":\"2.0\",\"id\":1,\"result\":{}}\n\n")))
+	require.ErrorContains(t, jsonRPCError("text/event-stream",
+		[]byte("event: message\ndata: {\"jsonrpc\":\"2.0\",\"id\":1,\"error\":{\"code\":-32601,\"message\":\"method not found\"}}\n\n")),
+		"method not found")
+	require.Error(t, jsonRPCError("application/json", []byte("not json")))
+}
@@ -0,0 +1,82 @@
+package concurrency
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// inMemoryLimiter counts the calls of this replica only
+type inMemoryLimiter struct {
+	mu      sync.Mutex
+	servers map[string]*serverSlots
+}
+
+type serverSlots struct {
+	inFlight int
+	// freed is closed, and replaced, whenever a slot is released so
+	// queued callers wake up and try again
+	freed chan struct{}
+}
+
+func newInMemoryLimiter() *inMemoryLimiter {
+	return &inMemoryLimiter{servers: map[string]*serverSlots{}}
+}
+
+func (l *inMemoryLimiter) Acquire(ctx context.Context, server string, limit int, wait time.Duration) (func(), error) {
+	var timeout <-chan time.Time
+	if wait > 0 {
+		timer := time.NewTimer(wait)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+	for {
+		freed, ok := l.tryAcquire(server, limit)
+		if ok {
+			return sync.OnceFunc(func() { l.release(server) }), nil
+		}
+		if timeout == nil {
+			return nil, ErrLimitReached
+		}
+		select {
+		case <-freed:
+		case <-timeout:
+			return nil, ErrLimitReached
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// tryAcquire takes a slot if one is free, otherwise it returns the channel
+// closed on the next release
+func (l *inMemoryLimiter) tryAcquire(server string, limit int) (<-chan struct{}, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	slots, ok := l.servers[server]
+	if !ok {
+		slots = &serverSlots{freed: make(chan struct{})}
+		l.servers[server] = slots
+	}
+	if slots.inFlight < limit {
+		slots.inFlight++
+		return nil, true
+	}
+	return slots.freed, false
+}
+
+func (l *inMemoryLimiter) release(server string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	slots, ok := l.servers[server]
+	if !ok {
+		return
+	}
+	slots.inFlight--
+	close(slots.freed)
+	if slots.inFlight <= 0 {
+		delete(l.servers, server)
+		return
+	}
+	slots.freed = make(chan struct{})
+}
@@ -0,0 +1,135 @@
+// Package concurrency bounds the tool calls routed to each upstream MCP server at once.
+package concurrency
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	redis "github.com/redis/go-redis/v9"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// ErrLimitReached is returned by Acquire when no slot freed up within the wait
+var ErrLimitReached = errors.New("concurrency limit reached")
+
+// defaultSlotTTL bounds how long a Redis-held slot outlives a replica that
+// never released it (e.g. a crash). Calls running longer than this no longer
+// count against the limit.
+const defaultSlotTTL = 5 * time.Minute
+
+// Limiter bounds in-flight calls per upstream server.
+type Limiter interface {
+	// Acquire takes one of limit slots for server, waiting up to wait for a
+	// slot to free up. The returned release func gives the slot back and is
+	// safe to call more than once. ErrLimitReached means no slot freed up in
+	// time; any other error means the limit could not be checked.
+	Acquire(ctx context.Context, server string, limit int, wait time.Duration) (release func(), err error)
+}
+
+type limiterConfig struct {
+	redisClient redis.UniversalClient
+	slotTTL     time.Duration
+}
+
+// New returns a Limiter. Pass WithRedisClient to share in-flight counts
+// between replicas; otherwise each replica counts its own calls.
+func New(opts ...func(*limiterConfig)) (Limiter, error) {
+	cfg := &limiterConfig{}
+	for _, o := range opts {
+		o(cfg)
+	}
+	m, err := newMetrics()
+	if err != nil {
+		return nil, err
+	}
+	var backend Limiter
+	if cfg.redisClient != nil {
+		backend = newRedisLimiter(cfg.redisClient, cfg.slotTTL)
+	} else {
+		backend = newInMemoryLimiter()
+	}
+	return &instrumented{backend: backend, metrics: m}, nil
+}
+
+// WithRedisClient configures the Limiter to keep in-flight counts in Redis.
+func WithRedisClient(client redis.UniversalClient) func(*limiterConfig) {
+	return func(c *limiterConfig) {
+		c.redisClient = client
+	}
+}
+
+// WithSlotTTL sets how long a Redis-held slot survives without a release.
+// Only applies when a Redis client is configured.
+func WithSlotTTL(ttl time.Duration) func(*limiterConfig) {
+	return func(c *limiterConfig) {
+		c.slotTTL = ttl
+	}
+}
+
+type metrics struct {
+	inFlight  metric.Int64UpDownCounter
+	rejected  metric.Int64Counter
+	queueWait metric.Float64Histogram
+}
+
+func newMetrics() (*metrics, error) {
+	meter := otel.GetMeterProvider().Meter("mcp-router")
+
+	inFlight, err := meter.Int64UpDownCounter("mcp_router_upstream_inflight_calls",
+		metric.WithDescription("tool calls this replica has in flight per upstream server with a concurrency limit"),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create mcp_router_upstream_inflight_calls: %w", err)
+	}
+
+	rejected, err := meter.Int64Counter("mcp_router_upstream_concurrency_rejections",
+		metric.WithDescription("tool calls answered with an error because the upstream server's concurrency limit was reached"),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create mcp_router_upstream_concurrency_rejections: %w", err)
+	}
+
+	queueWait, err := meter.Float64Histogram("mcp_router_upstream_queue_wait_seconds",
+		metric.WithDescription("time tool calls waited for a free slot on an upstream server with a concurrency limit"),
+		metric.WithUnit("s"),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create mcp_router_upstream_queue_wait_seconds: %w", err)
+	}
+
+	return &metrics{inFlight: inFlight, rejected: rejected, queueWait: queueWait}, nil
+}
+
+// instrumented records metrics around a backend Limiter
+type instrumented struct {
+	backend Limiter
+	metrics *metrics
+}
+
+func (l *instrumented) Acquire(ctx context.Context, server string, limit int, wait time.Duration) (func(), error) {
+	attrs := metric.WithAttributes(attribute.String("server_name", server))
+	start := time.Now()
+	release, err := l.backend.Acquire(ctx, server, limit, wait)
+	// a call whose context ended while queued is neither admitted nor rejected
+	if ctx.Err() == nil {
+		l.metrics.queueWait.Record(ctx, time.Since(start).Seconds(), attrs)
+	}
+	if errors.Is(err, ErrLimitReached) {
+		l.metrics.rejected.Add(ctx, 1, attrs)
+	}
+	if err != nil {
+		return nil, err
+	}
+	// the stream context may be done by the time the call ends
+	metricsCtx := context.WithoutCancel(ctx)
+	l.metrics.inFlight.Add(metricsCtx, 1, attrs)
+	return sync.OnceFunc(func() {
+		release()
+		l.metrics.inFlight.Add(metricsCtx, -1, attrs)
+	}), nil
+}
@@ -0,0 +1,199 @@
+package concurrency
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	redis "github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestLimiters(t *testing.T) map[string]Limiter {
+	t.Helper()
+	redisServer := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: redisServer.Addr()})
+	t.Cleanup(func() {
+		require.NoError(t, client.Close())
+	})
+	inMemory, err := New()
+	require.NoError(t, err)
+	shared, err := New(WithRedisClient(client))
+	require.NoError(t, err)
+	return map[string]Limiter{"in-memory": inMemory, "redis": shared}
+}
+
+func TestLimiter_RejectsOverLimit(t *testing.T) {
+	for name, limiter := range newTestLimiters(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			first, err := limiter.Acquire(ctx, "weather", 2, 0)
+			require.NoError(t, err)
+			second, err := limiter.Acquire(ctx, "weather", 2, 0)
+			require.NoError(t, err)
+
+			_, err = limiter.Acquire(ctx, "weather", 2, 0)
+			assert.ErrorIs(t, err, ErrLimitReached)
+
+			// other servers have their own slots
+			other, err := limiter.Acquire(ctx, "news", 1, 0)
+			require.NoError(t, err)
+			other()
+
+			first()
+			third, err := limiter.Acquire(ctx, "weather", 2, 0)
+			require.NoError(t, err)
+			second()
+			third()
+		})
+	}
+}
+
+func TestLimiter_ReleaseIsIdempotent(t *testing.T) {
+	for name, limiter := range newTestLimiters(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			first, err := limiter.Acquire(ctx, "weather", 2, 0)
+			require.NoError(t, err)
+			second, err := limiter.Acquire(ctx, "weather", 2, 0)
+			require.NoError(t, err)
+
+			first()
+			first()
+
+			// a double release must not free the slot second still holds
+			third, err := limiter.Acquire(ctx, "weather", 2, 0)
+			require.NoError(t, err)
+			_, err = limiter.Acquire(ctx, "weather", 2, 0)
+			assert.ErrorIs(t, err, ErrLimitReached)
+			second()
+			third()
+		})
+	}
+}
+
+func TestLimiter_QueuedCallGetsReleasedSlot(t *testing.T) {
+	for name, limiter := range newTestLimiters(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			held, err := limiter.Acquire(ctx, "weather", 1, 0)
+			require.NoError(t, err)
+
+			go func() {
+				time.Sleep(50 * time.Millisecond)
+				held()
+			}()
+
+			start := time.Now()
+			release, err := limiter.Acquire(ctx, "weather", 1, 2*time.Second)
+			require.NoError(t, err)
+			assert.GreaterOrEqual(t, time.Since(start), 50*time.Millisecond)
+			release()
+		})
+	}
+}
+
+func TestLimiter_QueueTimeout(t *testing.T) {
+	for name, limiter := range newTestLimiters(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			held, err := limiter.Acquire(ctx, "weather", 1, 0)
+			require.NoError(t, err)
+			defer held()
+
+			start := time.Now()
+			_, err = limiter.Acquire(ctx, "weather", 1, 100*time.Millisecond)
+			assert.ErrorIs(t, err, ErrLimitReached)
+			assert.GreaterOrEqual(t, time.Since(start), 100*time.Millisecond)
+		})
+	}
+}
+
+func TestLimiter_ContextCancelledWhileQueued(t *testing.T) {
+	for name, limiter := range newTestLimiters(t) {
+		t.Run(name, func(t *testing.T) {
+			held, err := limiter.Acquire(context.Background(), "weather", 1, 0)
+			require.NoError(t, err)
+			defer held()
+
+			ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+			defer cancel()
+			_, err = limiter.Acquire(ctx, "weather", 1, 5*time.Second)
+			require.Error(t, err)
+			assert.NotErrorIs(t, err, ErrLimitReached)
+			assert.ErrorIs(t, ctx.Err(), context.DeadlineExceeded)
+		})
+	}
+}
+
+func TestRedisLimiter_SharedBetweenReplicas(t *testing.T) {
+	redisServer := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: redisServer.Addr()})
+	t.Cleanup(func() {
+		require.NoError(t, client.Close())
+	})
+	replicaA, err := New(WithRedisClient(client))
+	require.NoError(t, err)
+	replicaB, err := New(WithRedisClient(client))
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	release, err := replicaA.Acquire(ctx, "weather", 1, 0)
+	require.NoError(t, err)
+	_, err = replicaB.Acquire(ctx, "weather", 1, 0)
+	assert.ErrorIs(t, err, ErrLimitReached)
+
+	release()
+	release, err = replicaB.Acquire(ctx, "weather", 1, 0)
+	require.NoError(t, err)
+	release()
+}
+
+func TestRedisLimiter_ExpiredSlotsAreFreed(t *testing.T) {
+	redisServer := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: redisServer.Addr()})
+	t.Cleanup(func() {
+		require.NoError(t, client.Close())
+	})
+	limiter, err := New(WithRedisClient(client))
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	// a slot left behind by a replica that died an hour ago
+	expired := time.Now().Add(-time.Hour).UnixMilli()
+	require.NoError(t, client.ZAdd(ctx, keyPrefix+"weather", redis.Z{Score: float64(expired), Member: "crashed"}).Err())
+
+	release, err := limiter.Acquire(ctx, "weather", 1, 0)
+	require.NoError(t, err)
+	release()
+
+	members, err := client.ZRange(ctx, keyPrefix+"weather", 0, -1).Result()
+	require.NoError(t, err)
+	assert.Empty(t, members)
+}
+
+func TestRedisLimiter_Unavailable(t *testing.T) {
+	redisServer := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: redisServer.Addr(), MaxRetries: -1})
+	t.Cleanup(func() {
+		_ = client.Close()
+	})
+	limiter, err := New(WithRedisClient(client))
+	require.NoError(t, err)
+	redisServer.Close()
+
+	_, err = limiter.Acquire(context.Background(), "weather", 1, 0)
+	require.Error(t, err)
+	assert.NotErrorIs(t, err, ErrLimitReached)
+}
+
+func TestInMemoryLimiter_ForgetsIdleServers(t *testing.T) {
+	limiter := newInMemoryLimiter()
+	release, err := limiter.Acquire(context.Background(), "weather", 1, 0)
+	require.NoError(t, err)
+	assert.Len(t, limiter.servers, 1)
+	release()
+	assert.Empty(t, limiter.servers)
+}
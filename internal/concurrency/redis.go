@@ -0,0 +1,77 @@
+package concurrency
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	redis "github.com/redis/go-redis/v9"
+)
+
+const (
+	keyPrefix = "concurrency:"
+	// pollInterval is how often a queued call re-checks a Redis-held limit:
+	// releases on other replicas cannot wake it
+	pollInterval = 25 * time.Millisecond
+)
+
+// acquireScript takes a slot when fewer than limit unexpired slots are held.
+// Slots are sorted-set members scored by their expiry so a replica that dies
+// holding slots cannot leak them past the slot TTL.
+//
+// KEYS[1] server key; ARGV: now ms, limit, slot expiry ms, slot id, key ttl ms
+var acquireScript = redis.NewScript(`
+redis.call('ZREMRANGEBYSCORE', KEYS[1], '-inf', ARGV[1])
+if redis.call('ZCARD', KEYS[1]) < tonumber(ARGV[2]) then
+	redis.call('ZADD', KEYS[1], ARGV[3], ARGV[4])
+	redis.call('PEXPIRE', KEYS[1], ARGV[5])
+	return 1
+end
+return 0
+`)
+
+// redisLimiter shares in-flight counts between replicas
+type redisLimiter struct {
+	client  redis.UniversalClient
+	slotTTL time.Duration
+}
+
+func newRedisLimiter(client redis.UniversalClient, slotTTL time.Duration) *redisLimiter {
+	if slotTTL <= 0 {
+		slotTTL = defaultSlotTTL
+	}
+	return &redisLimiter{client: client, slotTTL: slotTTL}
+}
+
+func (l *redisLimiter) Acquire(ctx context.Context, server string, limit int, wait time.Duration) (func(), error) {
+	key := keyPrefix + server
+	slot := uuid.NewString()
+	deadline := time.Now().Add(wait)
+	for {
+		now := time.Now()
+		ok, err := acquireScript.Run(ctx, l.client, []string{key},
+			now.UnixMilli(), limit, now.Add(l.slotTTL).UnixMilli(), slot, l.slotTTL.Milliseconds(),
+		).Bool()
+		if err != nil {
+			return nil, fmt.Errorf("acquire concurrency slot: %w", err)
+		}
+		if ok {
+			return sync.OnceFunc(func() {
+				// the stream context may be done by the time the call ends;
+				// best-effort: an unreleased slot expires after the slot TTL
+				l.client.ZRem(context.WithoutCancel(ctx), key, slot)
+			}), nil
+		}
+		remaining := time.Until(deadline)
+		if remaining <= 0 {
+			return nil, ErrLimitReached
+		}
+		select {
+		case <-time.After(min(pollInterval, remaining)):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
@@ -0,0 +1,38 @@
+package config
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+)
+
+// ValidateCACertPEM checks that the data contains at least one valid PEM-encoded
+// CA certificate. Certificates that explicitly declare BasicConstraints CA:FALSE
+// are rejected; certificates that omit BasicConstraints entirely are accepted,
+// since older root CAs and dev certs often don't set it.
+func ValidateCACertPEM(data []byte) error {
+	rest := data
+	found := false
+	for {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			return fmt.Errorf("unexpected PEM block type %q, expected CERTIFICATE", block.Type)
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return fmt.Errorf("failed to parse certificate: %w", err)
+		}
+		if cert.BasicConstraintsValid && !cert.IsCA {
+			return fmt.Errorf("certificate for %q is not a CA certificate", cert.Subject.CommonName)
+		}
+		found = true
+	}
+	if !found {
+		return fmt.Errorf("no valid PEM certificate blocks found")
+	}
+	return nil
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 )
@@ -156,6 +157,109 @@ func TestMCPServer_ConfigChanged(t *testing.T) {
 			},
 			expectChanged: false,
 		},
+		{
+			name: "maintenance window changed",
+			current: &MCPServer{
+				Name:        "server1",
+				State:       StateMaintenance,
+				Maintenance: &MaintenanceConfig{Message: "upgrading to v2"},
+			},
+			existing: MCPServer{
+				Name:        "server1",
+				State:       StateMaintenance,
+				Maintenance: &MaintenanceConfig{Message: "upgrading"},
+			},
+			expectChanged: true,
+		},
+		{
+			name: "canary target changed",
+			current: &MCPServer{
+				Name:   "server1",
+				Canary: &CanaryConfig{URL: "http://server1-v2/mcp", Hostname: "server1-v2.local", Weight: 10},
+			},
+			existing: MCPServer{
+				Name:   "server1",
+				Canary: &CanaryConfig{URL: "http://server1-v3/mcp", Hostname: "server1-v3.local", Weight: 10},
+			},
+			expectChanged: true,
+		},
+		{
+			name: "canary removed",
+			current: &MCPServer{
+				Name: "server1",
+			},
+			existing: MCPServer{
+				Name:   "server1",
+				Canary: &CanaryConfig{URL: "http://server1-v2/mcp", Weight: 10},
+			},
+			expectChanged: true,
+		},
+		{
+			name: "canary weight change does not trigger change",
+			current: &MCPServer{
+				Name:   "server1",
+				Canary: &CanaryConfig{URL: "http://server1-v2/mcp", Weight: 50, ToolMismatch: CanaryToolMismatchReject},
+			},
+			existing: MCPServer{
+				Name:   "server1",
+				Canary: &CanaryConfig{URL: "http://server1-v2/mcp", Weight: 10},
+			},
+			expectChanged: false,
+		},
+		{
+			name: "sampling changed",
+			current: &MCPServer{
+				Name:     "server1",
+				Prefix:   "s1_",
+				Sampling: true,
+			},
+			existing: MCPServer{
+				Name:   "server1",
+				Prefix: "s1_",
+			},
+			expectChanged: true,
+		},
+		{
+			name: "credential ref key changed",
+			current: &MCPServer{
+				Name:          "server1",
+				Prefix:        "s1_",
+				CredentialRef: &CredentialReference{Namespace: "team-a", Name: "creds", Key: "token-v2"},
+			},
+			existing: MCPServer{
+				Name:          "server1",
+				Prefix:        "s1_",
+				CredentialRef: &CredentialReference{Namespace: "team-a", Name: "creds", Key: "token"},
+			},
+			expectChanged: true,
+		},
+		{
+			name: "credential ref removed",
+			current: &MCPServer{
+				Name:   "server1",
+				Prefix: "s1_",
+			},
+			existing: MCPServer{
+				Name:          "server1",
+				Prefix:        "s1_",
+				CredentialRef: &CredentialReference{Namespace: "team-a", Name: "creds", Key: "token"},
+			},
+			expectChanged: true,
+		},
+		{
+			name: "equal credential refs",
+			current: &MCPServer{
+				Name:          "server1",
+				Prefix:        "s1_",
+				CredentialRef: &CredentialReference{Namespace: "team-a", Name: "creds", Key: "token"},
+			},
+			existing: MCPServer{
+				Name:          "server1",
+				Prefix:        "s1_",
+				CredentialRef: &CredentialReference{Namespace: "team-a", Name: "creds", Key: "token"},
+			},
+			expectChanged: false,
+		},
 		{
 			name: "name changed",
 			current: &MCPServer{
@@ -603,6 +707,70 @@ func TestMCPServersConfig_GetServerConfigByName(t *testing.T) {
 	}
 }
 
+func TestMCPServersConfig_GetServerConfigByName_Canary(t *testing.T) {
+	config := &MCPServersConfig{
+		Servers: []*MCPServer{
+			{
+				Name:     "ns/weather",
+				URL:      "http://weather/mcp",
+				Hostname: "weather.mcp.local",
+				Prefix:   "weather_",
+				Sampling: true,
+				Canary:   &CanaryConfig{URL: "http://weather-v2/mcp", Hostname: "weather-v2.mcp.local", Weight: 10},
+			},
+			{Name: "ns/news", URL: "http://news/mcp"},
+		},
+	}
+
+	canary, err := config.GetServerConfigByName("ns/weather" + CanaryNameSuffix)
+	require.NoError(t, err)
+	require.Equal(t, "ns/weather#canary", canary.Name)
+	require.Equal(t, "http://weather-v2/mcp", canary.URL)
+	require.Equal(t, "weather-v2.mcp.local", canary.Hostname)
+	require.Equal(t, "weather_", canary.Prefix)
+	require.True(t, canary.Sampling)
+	require.Nil(t, canary.Canary)
+
+	// the stable config is left untouched
+	stable, err := config.GetServerConfigByName("ns/weather")
+	require.NoError(t, err)
+	require.Equal(t, "http://weather/mcp", stable.URL)
+	require.NotNil(t, stable.Canary)
+
+	_, err = config.GetServerConfigByName("ns/news" + CanaryNameSuffix)
+	require.Error(t, err)
+}
+
+func TestRegistrationName(t *testing.T) {
+	require.Equal(t, "ns/weather", RegistrationName("ns/weather"))
+	require.Equal(t, "ns/weather", RegistrationName("ns/weather"+CanaryNameSuffix))
+}
+
+func TestCanaryConfig_RejectsCanaryToolMismatch(t *testing.T) {
+	require.True(t, (&CanaryConfig{}).RejectsCanaryToolMismatch())
+	require.True(t, (&CanaryConfig{ToolMismatch: CanaryToolMismatchReject}).RejectsCanaryToolMismatch())
+	require.False(t, (&CanaryConfig{ToolMismatch: CanaryToolMismatchFlag}).RejectsCanaryToolMismatch())
+}
+
+func TestMCPServer_UpstreamOAuth(t *testing.T) {
+	require.Nil(t, (&MCPServer{}).UpstreamOAuth())
+	require.Nil(t, (&MCPServer{TokenURLElicitation: &TokenURLElicitationConfig{URL: "https://tokens.example.com"}}).UpstreamOAuth())
+	oauth := &UpstreamOAuthConfig{ClientID: "gateway"}
+	require.Same(t, oauth, (&MCPServer{TokenURLElicitation: &TokenURLElicitationConfig{OAuth: oauth}}).UpstreamOAuth())
+}
+
+func TestResultCacheConfig(t *testing.T) {
+	all := &ResultCacheConfig{TTLSeconds: 90}
+	require.Equal(t, 90*time.Second, all.TTL())
+	require.False(t, all.Shared())
+	require.True(t, all.CachesTool("lookup"))
+
+	listed := &ResultCacheConfig{Tools: []string{"lookup"}, Scope: ResultCacheScopeShared}
+	require.True(t, listed.Shared())
+	require.True(t, listed.CachesTool("lookup"))
+	require.False(t, listed.CachesTool("order"))
+}
+
 func TestMCPServersConfig_GetServerConfigByName_EmptyServers(t *testing.T) {
 	config := &MCPServersConfig{
 		Servers: []*MCPServer{},
@@ -678,3 +846,55 @@ func TestMCPServersConfig_Notify(t *testing.T) {
 	require.Equal(t, config, observer.receivedConf)
 	observer.mu.Unlock()
 }
+
+func TestMCPServer_InMaintenance(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	before := now.Add(-time.Hour)
+	after := now.Add(time.Hour)
+
+	testCases := []struct {
+		name        string
+		state       string
+		maintenance *MaintenanceConfig
+		expected    bool
+	}{
+		{name: "enabled", state: "Enabled", expected: false},
+		{name: "enabled with a window", state: "Enabled", maintenance: &MaintenanceConfig{Start: &before}, expected: false},
+		{name: "maintenance without a window", state: StateMaintenance, expected: true},
+		{name: "open window", state: StateMaintenance, maintenance: &MaintenanceConfig{Start: &before, End: &after}, expected: true},
+		{name: "no end", state: StateMaintenance, maintenance: &MaintenanceConfig{Start: &before}, expected: true},
+		{name: "no start", state: StateMaintenance, maintenance: &MaintenanceConfig{End: &after}, expected: true},
+		{name: "scheduled", state: StateMaintenance, maintenance: &MaintenanceConfig{Start: &after}, expected: false},
+		{name: "ended", state: StateMaintenance, maintenance: &MaintenanceConfig{End: &before}, expected: false},
+		{name: "ends now", state: StateMaintenance, maintenance: &MaintenanceConfig{End: &now}, expected: false},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			server := &MCPServer{Name: "ns/server", State: tc.state, Maintenance: tc.maintenance}
+			if got := server.InMaintenance(now); got != tc.expected {
+				t.Errorf("expected InMaintenance %v, got %v", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestMCPServer_MaintenanceMessage(t *testing.T) {
+	end := time.Date(2026, 3, 1, 14, 0, 0, 0, time.UTC)
+	testCases := []struct {
+		name        string
+		maintenance *MaintenanceConfig
+		expected    string
+	}{
+		{name: "default", expected: "server ns/server is under maintenance"},
+		{name: "with end", maintenance: &MaintenanceConfig{End: &end}, expected: "server ns/server is under maintenance until 2026-03-01T14:00:00Z"},
+		{name: "custom message", maintenance: &MaintenanceConfig{End: &end, Message: "database migration, back at 14:00 UTC"}, expected: "database migration, back at 14:00 UTC"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			server := &MCPServer{Name: "ns/server", State: StateMaintenance, Maintenance: tc.maintenance}
+			if got := server.MaintenanceMessage(); got != tc.expected {
+				t.Errorf("expected %q, got %q", tc.expected, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,30 @@
+package config
+
+// ToolsSnapshot records the tools and prompts each upstream server lists,
+// under the names the servers use themselves. It stands in for live
+// tools/list and prompts/list results when checking a config or a routing
+// decision offline.
+type ToolsSnapshot struct {
+	Servers []ServerTools `json:"servers" yaml:"servers"`
+}
+
+// ServerTools is the snapshot of a single upstream server, matched to its
+// MCPServer config by name
+type ServerTools struct {
+	Name    string   `json:"name"                yaml:"name"`
+	Tools   []string `json:"tools,omitempty"     yaml:"tools,omitempty"`
+	Prompts []string `json:"prompts,omitempty"   yaml:"prompts,omitempty"`
+	// Resources marks a server that supports resources, so resources/read
+	// is routed to it by prefix
+	Resources bool `json:"resources,omitempty" yaml:"resources,omitempty"`
+}
+
+// Lookup returns the snapshot of the named server
+func (s ToolsSnapshot) Lookup(name string) (ServerTools, bool) {
+	for _, st := range s.Servers {
+		if st.Name == name {
+			return st, true
+		}
+	}
+	return ServerTools{}, false
+}
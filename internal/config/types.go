@@ -6,7 +6,9 @@ import (
 	"fmt"
 	"net/url"
 	"slices"
+	"strings"
 	"sync"
+	"time"
 )
 
 // UpstreamMCPID is used as type for identifying individual upstreams
@@ -98,6 +100,14 @@ func (config *MCPServersConfig) GetServerConfigByName(serverName string) (*MCPSe
 			return server, nil
 		}
 	}
+	// a canary variant resolves to the config of its registration's canary
+	if base, ok := strings.CutSuffix(serverName, CanaryNameSuffix); ok {
+		for _, server := range config.Servers {
+			if server.Name == base && server.Canary != nil {
+				return server.CanaryServer(), nil
+			}
+		}
+	}
 	return nil, fmt.Errorf("unknown server")
 }
 
@@ -109,14 +119,174 @@ type MCPServer struct {
 	Prefix              string                     `json:"prefix,omitempty"              yaml:"prefix,omitempty"`
 	Auth                *AuthConfig                `json:"auth,omitempty"                yaml:"auth,omitempty"`
 	Credential          string                     `json:"credential,omitempty"          yaml:"credential,omitempty"`
+	CredentialRef       *CredentialReference       `json:"credentialRef,omitempty"       yaml:"credentialRef,omitempty"`
 	CACert              string                     `json:"caCert,omitempty"              yaml:"caCert,omitempty"`
 	State               string                     `json:"state"                         yaml:"state"`
 	TokenURLElicitation *TokenURLElicitationConfig `json:"tokenURLElicitation,omitempty" yaml:"tokenURLElicitation,omitempty"`
 	UserSpecificList    bool                       `json:"userSpecificList,omitempty"    yaml:"userSpecificList,omitempty"`
+	Sampling            bool                       `json:"sampling,omitempty"            yaml:"sampling,omitempty"`
 	Category            []string                   `json:"category,omitempty"            yaml:"category,omitempty"`
 	Hint                string                     `json:"hint,omitempty"                yaml:"hint,omitempty"`
 	Tags                []string                   `json:"tags,omitempty"                yaml:"tags,omitempty"`
 	GuardrailsConfigIDs []string                   `json:"guardrailsConfigIDs,omitempty" yaml:"guardrailsConfigIDs,omitempty"`
+	Concurrency         *ConcurrencyConfig         `json:"concurrency,omitempty"         yaml:"concurrency,omitempty"`
+	ToolCalls           *ToolCallConfig            `json:"toolCalls,omitempty"           yaml:"toolCalls,omitempty"`
+	ResultCache         *ResultCacheConfig         `json:"resultCache,omitempty"         yaml:"resultCache,omitempty"`
+	Maintenance         *MaintenanceConfig         `json:"maintenance,omitempty"         yaml:"maintenance,omitempty"`
+	Canary              *CanaryConfig              `json:"canary,omitempty"              yaml:"canary,omitempty"`
+}
+
+// CanaryNameSuffix marks the server name of a canary version. Backend
+// sessions are keyed by server name, so a gateway session holds separate
+// sessions with the stable and canary versions.
+const CanaryNameSuffix = "#canary"
+
+// Canary tool mismatch policies
+const (
+	CanaryToolMismatchReject = "Reject"
+	CanaryToolMismatchFlag   = "Flag"
+)
+
+// CanaryConfig is a second version of a server that receives a weighted
+// share of its tool calls
+type CanaryConfig struct {
+	URL      string `json:"url"                yaml:"url"`
+	Hostname string `json:"hostname,omitempty" yaml:"hostname,omitempty"`
+	// Weight is the percentage of gateway sessions routed to the canary
+	Weight int `json:"weight" yaml:"weight"`
+	// ToolMismatch is Reject or Flag. Empty means Reject.
+	ToolMismatch string `json:"toolMismatch,omitempty" yaml:"toolMismatch,omitempty"`
+}
+
+// CanaryServer returns the config of the server's canary version, or nil
+// when the server has no canary
+func (mcpServer *MCPServer) CanaryServer() *MCPServer {
+	if mcpServer.Canary == nil {
+		return nil
+	}
+	canary := *mcpServer
+	canary.Name = mcpServer.Name + CanaryNameSuffix
+	canary.URL = mcpServer.Canary.URL
+	canary.Hostname = mcpServer.Canary.Hostname
+	canary.Canary = nil
+	return &canary
+}
+
+// RejectsCanaryToolMismatch reports whether tool calls stop going to the
+// canary while its tools differ from the stable version's
+func (c *CanaryConfig) RejectsCanaryToolMismatch() bool {
+	return c.ToolMismatch != CanaryToolMismatchFlag
+}
+
+// RegistrationName returns the name of the server a stable or canary server
+// name belongs to
+func RegistrationName(serverName string) string {
+	name, _ := strings.CutSuffix(serverName, CanaryNameSuffix)
+	return name
+}
+
+// StateMaintenance is the MCPServer state in which tools stay listed but the
+// router rejects new tool calls while the maintenance window is open
+const StateMaintenance = "Maintenance"
+
+// MaintenanceConfig is the maintenance window of a server in the Maintenance
+// state. A nil Start opens the window immediately, a nil End leaves it open.
+type MaintenanceConfig struct {
+	Start   *time.Time `json:"start,omitempty"   yaml:"start,omitempty"`
+	End     *time.Time `json:"end,omitempty"     yaml:"end,omitempty"`
+	Message string     `json:"message,omitempty" yaml:"message,omitempty"`
+}
+
+// InMaintenance reports whether the server's maintenance window is open at now
+func (mcpServer *MCPServer) InMaintenance(now time.Time) bool {
+	if mcpServer.State != StateMaintenance {
+		return false
+	}
+	if mcpServer.Maintenance == nil {
+		return true
+	}
+	if mcpServer.Maintenance.Start != nil && now.Before(*mcpServer.Maintenance.Start) {
+		return false
+	}
+	return mcpServer.Maintenance.End == nil || now.Before(*mcpServer.Maintenance.End)
+}
+
+// MaintenanceMessage returns the message for clients whose tool calls are
+// rejected during the maintenance window
+func (mcpServer *MCPServer) MaintenanceMessage() string {
+	if mcpServer.Maintenance != nil && mcpServer.Maintenance.Message != "" {
+		return mcpServer.Maintenance.Message
+	}
+	if mcpServer.Maintenance != nil && mcpServer.Maintenance.End != nil {
+		return fmt.Sprintf("server %s is under maintenance until %s", mcpServer.Name, mcpServer.Maintenance.End.UTC().Format(time.RFC3339))
+	}
+	return fmt.Sprintf("server %s is under maintenance", mcpServer.Name)
+}
+
+// ConcurrencyConfig bounds the tool calls the router forwards to a server at once.
+type ConcurrencyConfig struct {
+	MaxInFlight int `json:"maxInFlight" yaml:"maxInFlight"`
+	// QueueTimeoutMilliseconds is how long a call over the limit waits for a
+	// free slot before it is answered with a tool error. 0 rejects at once.
+	QueueTimeoutMilliseconds int `json:"queueTimeoutMilliseconds,omitempty" yaml:"queueTimeoutMilliseconds,omitempty"`
+}
+
+// QueueTimeout returns QueueTimeoutMilliseconds as a duration
+func (c *ConcurrencyConfig) QueueTimeout() time.Duration {
+	return time.Duration(c.QueueTimeoutMilliseconds) * time.Millisecond
+}
+
+// ToolCallConfig is the timeout and retry policy the router applies to tool
+// calls routed to a server
+type ToolCallConfig struct {
+	// TimeoutMilliseconds bounds a call, retries included. 0 keeps the
+	// timeout of the route.
+	TimeoutMilliseconds int `json:"timeoutMilliseconds,omitempty" yaml:"timeoutMilliseconds,omitempty"`
+	// Retry is only applied to tools annotated as read-only or idempotent
+	Retry *RetryConfig `json:"retry,omitempty" yaml:"retry,omitempty"`
+}
+
+// RetryConfig retries tool calls that failed with a 5xx, a reset or a timeout
+type RetryConfig struct {
+	MaxRetries int `json:"maxRetries" yaml:"maxRetries"`
+	// PerTryTimeoutMilliseconds bounds each attempt. 0 gives every attempt
+	// what is left of the call timeout.
+	PerTryTimeoutMilliseconds int `json:"perTryTimeoutMilliseconds,omitempty" yaml:"perTryTimeoutMilliseconds,omitempty"`
+	// BudgetPercent stops retries while more than this percentage of the
+	// server's recent tool calls failed
+	BudgetPercent int `json:"budgetPercent" yaml:"budgetPercent"`
+}
+
+// Result cache scopes
+const (
+	ResultCacheScopeSubject = "Subject"
+	ResultCacheScopeShared  = "Shared"
+)
+
+// ResultCacheConfig caches the results of calls to a server's read-only tools
+type ResultCacheConfig struct {
+	TTLSeconds int `json:"ttlSeconds" yaml:"ttlSeconds"`
+	// Tools limits caching to these unprefixed tool names. Empty caches
+	// every read-only tool.
+	Tools []string `json:"tools,omitempty" yaml:"tools,omitempty"`
+	// Scope is Subject or Shared. Empty means Subject.
+	Scope          string `json:"scope,omitempty" yaml:"scope,omitempty"`
+	MaxResultBytes int    `json:"maxResultBytes"  yaml:"maxResultBytes"`
+}
+
+// TTL returns how long a result is served from the cache
+func (c *ResultCacheConfig) TTL() time.Duration {
+	return time.Duration(c.TTLSeconds) * time.Second
+}
+
+// Shared reports whether results are served to every caller
+func (c *ResultCacheConfig) Shared() bool {
+	return c.Scope == ResultCacheScopeShared
+}
+
+// CachesTool reports whether results of the unprefixed tool may be cached
+func (c *ResultCacheConfig) CachesTool(tool string) bool {
+	return len(c.Tools) == 0 || slices.Contains(c.Tools, tool)
 }
 
 // GuardrailsConfig holds the resolved guardrails server config parsed from
@@ -128,9 +298,49 @@ type GuardrailsConfig struct {
 	FailMode  string   `json:"failMode,omitempty"  yaml:"failMode,omitempty"` // "deny" | "allow"
 }
 
+// CredentialReference points at an upstream credential held outside the
+// config. The broker resolves it when it talks to the upstream, so the
+// config never carries the credential itself.
+type CredentialReference struct {
+	Namespace string `json:"namespace,omitempty" yaml:"namespace,omitempty"`
+	Name      string `json:"name"                yaml:"name"`
+	Key       string `json:"key"                 yaml:"key"`
+}
+
+// String returns the reference as namespace/name#key
+func (ref CredentialReference) String() string {
+	if ref.Namespace == "" {
+		return ref.Name + "#" + ref.Key
+	}
+	return ref.Namespace + "/" + ref.Name + "#" + ref.Key
+}
+
 // TokenURLElicitationConfig configures per-user token collection via URL elicitation.
 type TokenURLElicitationConfig struct {
 	URL string `json:"url,omitempty" yaml:"url,omitempty"`
+	// OAuth, when set, collects the token with an authorization code flow
+	// run by the broker instead of the token page
+	OAuth *UpstreamOAuthConfig `json:"oauth,omitempty" yaml:"oauth,omitempty"`
+}
+
+// UpstreamOAuthConfig is the OAuth client the broker uses to get per-user
+// upstream tokens. The client secret is resolved at use time, so the config
+// only carries a reference to it.
+type UpstreamOAuthConfig struct {
+	AuthorizationURL string               `json:"authorizationURL"          yaml:"authorizationURL"`
+	TokenURL         string               `json:"tokenURL"                  yaml:"tokenURL"`
+	ClientID         string               `json:"clientID"                  yaml:"clientID"`
+	ClientSecretRef  *CredentialReference `json:"clientSecretRef,omitempty" yaml:"clientSecretRef,omitempty"`
+	Scopes           []string             `json:"scopes,omitempty"          yaml:"scopes,omitempty"`
+}
+
+// UpstreamOAuth returns the server's OAuth client, or nil when its per-user
+// tokens are not collected with OAuth
+func (mcpServer *MCPServer) UpstreamOAuth() *UpstreamOAuthConfig {
+	if mcpServer.TokenURLElicitation == nil {
+		return nil
+	}
+	return mcpServer.TokenURLElicitation.OAuth
 }
 
 // ID returns a unique id for the a registered server
@@ -146,18 +356,24 @@ func normalizeState(state string) string {
 }
 
 // ConfigChanged checks if a server's config has changed in a way that will affect the gateway.
-// This means having a different name, prefix, url, hostname, credential, state, category, hint, or tags.
+// This means having a different name, prefix, url, hostname, credential or credential reference, state, maintenance window, canary target, sampling, category, hint, or tags.
+// A canary weight change is not a config change: the router reads the weight from the live config.
+// Neither is an upstream OAuth client change: the broker reads the client from the live config.
 func (mcpServer *MCPServer) ConfigChanged(existingConfig MCPServer) bool {
 	if existingConfig.Name != mcpServer.Name ||
 		existingConfig.Prefix != mcpServer.Prefix ||
 		existingConfig.URL != mcpServer.URL ||
 		existingConfig.Hostname != mcpServer.Hostname ||
 		existingConfig.Credential != mcpServer.Credential ||
+		credentialRefChanged(existingConfig.CredentialRef, mcpServer.CredentialRef) ||
 		existingConfig.CACert != mcpServer.CACert ||
 		normalizeState(existingConfig.State) != normalizeState(mcpServer.State) ||
 		existingConfig.UserSpecificList != mcpServer.UserSpecificList ||
+		existingConfig.Sampling != mcpServer.Sampling ||
 		existingConfig.Hint != mcpServer.Hint ||
 		guardrailsConfigChanged(existingConfig.GuardrailsConfigIDs, mcpServer.GuardrailsConfigIDs) ||
+		maintenanceChanged(existingConfig.Maintenance, mcpServer.Maintenance) ||
+		canaryChanged(existingConfig.Canary, mcpServer.Canary) ||
 		tokenURLElicitationChanged(mcpServer.TokenURLElicitation, existingConfig.TokenURLElicitation) {
 		return true
 	}
@@ -188,6 +404,13 @@ func tagsEqual(a, b []string) bool {
 	return true
 }
 
+func credentialRefChanged(a, b *CredentialReference) bool {
+	if (a == nil) != (b == nil) {
+		return true
+	}
+	return a != nil && *a != *b
+}
+
 func tokenURLElicitationChanged(a, b *TokenURLElicitationConfig) bool {
 	if (a == nil) != (b == nil) {
 		return true
@@ -205,6 +428,33 @@ func guardrailsConfigChanged(a, b []string) bool {
 	return !slices.Equal(a, b)
 }
 
+func maintenanceChanged(a, b *MaintenanceConfig) bool {
+	if (a == nil) != (b == nil) {
+		return true
+	}
+	if a == nil {
+		return false
+	}
+	return a.Message != b.Message || !timeEqual(a.Start, b.Start) || !timeEqual(a.End, b.End)
+}
+
+func canaryChanged(a, b *CanaryConfig) bool {
+	if (a == nil) != (b == nil) {
+		return true
+	}
+	if a == nil {
+		return false
+	}
+	return a.URL != b.URL || a.Hostname != b.Hostname || a.RejectsCanaryToolMismatch() != b.RejectsCanaryToolMismatch()
+}
+
+func timeEqual(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Equal(*b)
+}
+
 // Path returns the path part of the mcp url
 func (mcpServer *MCPServer) Path() (string, error) {
 	parsedURL, err := url.Parse(mcpServer.URL)
@@ -0,0 +1,330 @@
+// Package configlint checks a broker config offline, reporting the problems
+// the broker and controller would otherwise only surface once deployed.
+package configlint
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"sigs.k8s.io/yaml"
+
+	"github.com/Kuadrant/mcp-gateway/internal/config"
+	"github.com/Kuadrant/mcp-gateway/internal/guardrails"
+)
+
+// prefixPattern is the MCPServerRegistration prefix validation pattern
+var prefixPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_]*$`)
+
+// Severity is how serious a Finding is
+type Severity string
+
+// Severities reported by Lint. Only errors fail validation.
+const (
+	SeverityError   Severity = "error"
+	SeverityWarning Severity = "warning"
+)
+
+// Finding is a single problem found in a config
+type Finding struct {
+	Severity Severity
+	// Field locates the problem in the config, e.g. servers[1].prefix
+	Field   string
+	Message string
+}
+
+func (f Finding) String() string {
+	return fmt.Sprintf("%s: %s: %s", f.Severity, f.Field, f.Message)
+}
+
+// HasErrors reports whether any finding is an error
+func HasErrors(findings []Finding) bool {
+	for _, f := range findings {
+		if f.Severity == SeverityError {
+			return true
+		}
+	}
+	return false
+}
+
+// Lint checks cfg and returns its findings in config order. Tools and
+// prompts referenced by virtual servers are checked by name against the
+// servers in snapshot, and need only carry the prefix of any other server.
+func Lint(cfg config.BrokerConfig, snapshot config.ToolsSnapshot) []Finding {
+	l := &linter{}
+	l.servers(cfg.Servers)
+	if cfg.GatewayCACertPEM != "" {
+		if err := config.ValidateCACertPEM([]byte(cfg.GatewayCACertPEM)); err != nil {
+			l.errorf("gatewayCACertPEM", "%v", err)
+		}
+	}
+	l.guardrails(cfg)
+	l.virtualServers(cfg, snapshot)
+	return l.findings
+}
+
+type linter struct {
+	findings []Finding
+}
+
+func (l *linter) errorf(field, format string, args ...any) {
+	l.findings = append(l.findings, Finding{Severity: SeverityError, Field: field, Message: fmt.Sprintf(format, args...)})
+}
+
+func (l *linter) warnf(field, format string, args ...any) {
+	l.findings = append(l.findings, Finding{Severity: SeverityWarning, Field: field, Message: fmt.Sprintf(format, args...)})
+}
+
+func (l *linter) servers(servers []config.MCPServer) {
+	names := map[string]int{}
+	prefixes := map[string]int{}
+	for i, s := range servers {
+		field := fmt.Sprintf("servers[%d]", i)
+		if s.Name == "" {
+			l.errorf(field+".name", "name is required")
+		} else if first, dup := names[s.Name]; dup {
+			l.errorf(field+".name", "duplicate server name %q, first used by servers[%d]", s.Name, first)
+		} else {
+			names[s.Name] = i
+		}
+
+		if err := validateURL(s.URL); err != nil {
+			l.errorf(field+".url", "%v", err)
+		}
+
+		switch {
+		case s.Hostname == "":
+			l.warnf(field+".hostname", "hostname is empty, so routed requests keep the gateway authority")
+		case !isValidHostname(s.Hostname):
+			l.errorf(field+".hostname", "%q is not a valid host", s.Hostname)
+		}
+
+		if s.Prefix != "" {
+			if !prefixPattern.MatchString(s.Prefix) {
+				l.errorf(field+".prefix", "%q does not match %s", s.Prefix, prefixPattern)
+			}
+			if first, dup := prefixes[s.Prefix]; dup {
+				l.warnf(field+".prefix", "prefix %q is also used by servers[%d], tools with the same name will conflict", s.Prefix, first)
+			} else {
+				prefixes[s.Prefix] = i
+			}
+		}
+
+		if s.CACert != "" {
+			if err := config.ValidateCACertPEM([]byte(s.CACert)); err != nil {
+				l.errorf(field+".caCert", "%v", err)
+			}
+		}
+
+		if s.Concurrency != nil {
+			if s.Concurrency.MaxInFlight < 1 {
+				l.errorf(field+".concurrency.maxInFlight", "must be at least 1, got %d", s.Concurrency.MaxInFlight)
+			}
+			if s.Concurrency.QueueTimeoutMilliseconds < 0 {
+				l.errorf(field+".concurrency.queueTimeoutMilliseconds", "must not be negative, got %d", s.Concurrency.QueueTimeoutMilliseconds)
+			}
+		}
+
+		if t := s.ToolCalls; t != nil {
+			if t.TimeoutMilliseconds < 0 {
+				l.errorf(field+".toolCalls.timeoutMilliseconds", "must not be negative, got %d", t.TimeoutMilliseconds)
+			}
+			if r := t.Retry; r != nil {
+				if r.MaxRetries < 1 || r.MaxRetries > 5 {
+					l.errorf(field+".toolCalls.retry.maxRetries", "must be between 1 and 5, got %d", r.MaxRetries)
+				}
+				if r.PerTryTimeoutMilliseconds < 0 {
+					l.errorf(field+".toolCalls.retry.perTryTimeoutMilliseconds", "must not be negative, got %d", r.PerTryTimeoutMilliseconds)
+				}
+				if r.BudgetPercent < 0 || r.BudgetPercent > 100 {
+					l.errorf(field+".toolCalls.retry.budgetPercent", "must be between 0 and 100, got %d", r.BudgetPercent)
+				}
+				if t.TimeoutMilliseconds > 0 && r.PerTryTimeoutMilliseconds > t.TimeoutMilliseconds {
+					l.warnf(field+".toolCalls.retry.perTryTimeoutMilliseconds", "%d exceeds timeoutMilliseconds %d, so no retry fits in the timeout", r.PerTryTimeoutMilliseconds, t.TimeoutMilliseconds)
+				}
+			}
+		}
+
+		if c := s.ResultCache; c != nil {
+			if c.TTLSeconds < 1 {
+				l.errorf(field+".resultCache.ttlSeconds", "must be at least 1, got %d", c.TTLSeconds)
+			}
+			if c.MaxResultBytes < 1 {
+				l.errorf(field+".resultCache.maxResultBytes", "must be at least 1, got %d", c.MaxResultBytes)
+			}
+			switch c.Scope {
+			case "", config.ResultCacheScopeSubject:
+			case config.ResultCacheScopeShared:
+				if s.TokenURLElicitation != nil {
+					l.warnf(field+".resultCache.scope", "%s results of a server called with per-user tokens are served to every user", c.Scope)
+				}
+			default:
+				l.errorf(field+".resultCache.scope", "must be %s or %s, got %q", config.ResultCacheScopeSubject, config.ResultCacheScopeShared, c.Scope)
+			}
+		}
+
+		if o := s.UpstreamOAuth(); o != nil {
+			if s.TokenURLElicitation.URL != "" {
+				l.errorf(field+".tokenURLElicitation", "url and oauth are mutually exclusive")
+			}
+			if err := validateURL(o.AuthorizationURL); err != nil {
+				l.errorf(field+".tokenURLElicitation.oauth.authorizationURL", "%v", err)
+			}
+			if err := validateURL(o.TokenURL); err != nil {
+				l.errorf(field+".tokenURLElicitation.oauth.tokenURL", "%v", err)
+			}
+			if o.ClientID == "" {
+				l.errorf(field+".tokenURLElicitation.oauth.clientID", "clientID is required")
+			}
+		}
+
+		if m := s.Maintenance; m != nil {
+			if s.State != config.StateMaintenance {
+				l.warnf(field+".maintenance", "maintenance window is ignored unless state is %s", config.StateMaintenance)
+			}
+			if m.Start != nil && m.End != nil && !m.End.After(*m.Start) {
+				l.errorf(field+".maintenance.end", "must be after start")
+			}
+		}
+
+		if c := s.Canary; c != nil {
+			if err := validateURL(c.URL); err != nil {
+				l.errorf(field+".canary.url", "%v", err)
+			}
+			if c.Hostname != "" && !isValidHostname(c.Hostname) {
+				l.errorf(field+".canary.hostname", "%q is not a valid host", c.Hostname)
+			}
+			if c.Weight < 0 || c.Weight > 100 {
+				l.errorf(field+".canary.weight", "must be between 0 and 100, got %d", c.Weight)
+			}
+			switch c.ToolMismatch {
+			case "", config.CanaryToolMismatchReject, config.CanaryToolMismatchFlag:
+			default:
+				l.errorf(field+".canary.toolMismatch", "must be %q or %q, got %q", config.CanaryToolMismatchReject, config.CanaryToolMismatchFlag, c.ToolMismatch)
+			}
+		}
+	}
+}
+
+func (l *linter) guardrails(cfg config.BrokerConfig) {
+	if cfg.GlobalGuardrails == nil {
+		for i, s := range cfg.Servers {
+			if len(s.GuardrailsConfigIDs) > 0 {
+				l.warnf(fmt.Sprintf("servers[%d].guardrailsConfigIDs", i), "guardrails config IDs are set but globalGuardrails is not configured")
+			}
+		}
+		return
+	}
+	// check the config the way the controller checks the guardrails Secret
+	// it was parsed from
+	raw, err := yaml.Marshal(cfg.GlobalGuardrails)
+	if err != nil {
+		l.errorf("globalGuardrails", "%v", err)
+		return
+	}
+	if _, err := guardrails.EnsureNeMoConfigData(guardrails.SecretTypeNeMo, map[string][]byte{guardrails.ConfigDataKey: raw}); err != nil {
+		l.errorf("globalGuardrails", "%v", err)
+	}
+}
+
+func (l *linter) virtualServers(cfg config.BrokerConfig, snapshot config.ToolsSnapshot) {
+	known := knownNames(cfg.Servers, snapshot)
+	names := map[string]int{}
+	for i, vs := range cfg.VirtualServers {
+		field := fmt.Sprintf("virtualServers[%d]", i)
+		if vs.Name == "" {
+			l.errorf(field+".name", "name is required")
+		} else if first, dup := names[vs.Name]; dup {
+			l.errorf(field+".name", "duplicate virtual server name %q, first used by virtualServers[%d]", vs.Name, first)
+		} else {
+			names[vs.Name] = i
+		}
+		for j, tool := range vs.Tools {
+			if !known.tool(tool) {
+				l.errorf(fmt.Sprintf("%s.tools[%d]", field, j), "unknown tool %q", tool)
+			}
+		}
+		for j, prompt := range vs.Prompts {
+			if !known.prompt(prompt) {
+				l.errorf(fmt.Sprintf("%s.prompts[%d]", field, j), "unknown prompt %q", prompt)
+			}
+		}
+	}
+}
+
+// knownSet resolves whether a served tool or prompt name exists
+type knownSet struct {
+	tools    map[string]bool
+	prompts  map[string]bool
+	prefixes []string
+}
+
+// knownNames collects the served names from snapshot. Servers missing from
+// the snapshot, and servers listing tools per user, are matched by prefix
+// instead.
+func knownNames(servers []config.MCPServer, snapshot config.ToolsSnapshot) knownSet {
+	n := knownSet{tools: map[string]bool{}, prompts: map[string]bool{}}
+	for _, s := range servers {
+		st, ok := snapshot.Lookup(s.Name)
+		if !ok || s.UserSpecificList {
+			n.prefixes = append(n.prefixes, s.Prefix)
+		}
+		if !ok {
+			continue
+		}
+		for _, t := range st.Tools {
+			n.tools[s.Prefix+t] = true
+		}
+		for _, p := range st.Prompts {
+			n.prompts[s.Prefix+p] = true
+		}
+	}
+	return n
+}
+
+func (n knownSet) tool(name string) bool {
+	return n.tools[name] || n.hasPrefix(name)
+}
+
+func (n knownSet) prompt(name string) bool {
+	return n.prompts[name] || n.hasPrefix(name)
+}
+
+func (n knownSet) hasPrefix(name string) bool {
+	for _, p := range n.prefixes {
+		if strings.HasPrefix(name, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// validateURL mirrors the MCPServerRegistration URL rules: an absolute
+// http or https URL with a host
+func validateURL(raw string) error {
+	if raw == "" {
+		return fmt.Errorf("url is required")
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return fmt.Errorf("invalid url: %w", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("url %q must use http or https", raw)
+	}
+	if u.Host == "" {
+		return fmt.Errorf("url %q has no host", raw)
+	}
+	return nil
+}
+
+// isValidHostname accepts a host with an optional port and nothing else,
+// the same check the controller applies to backend hostnames
+func isValidHostname(hostname string) bool {
+	u, err := url.Parse("//" + hostname)
+	if err != nil {
+		return false
+	}
+	return u.Host == hostname
+}
@@ -0,0 +1,284 @@
+package configlint
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/Kuadrant/mcp-gateway/internal/config"
+)
+
+func testCACertPEM(t *testing.T) string {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))
+}
+
+func validServer() config.MCPServer {
+	return config.MCPServer{Name: "team-a/weather", URL: "http://weather.team-a.svc:8080/mcp", Hostname: "weather.mcp.local", Prefix: "weather_"}
+}
+
+func TestLint(t *testing.T) {
+	caPEM := testCACertPEM(t)
+	withServer := func(mutate func(*config.MCPServer)) config.BrokerConfig {
+		s := validServer()
+		mutate(&s)
+		return config.BrokerConfig{Servers: []config.MCPServer{s}}
+	}
+
+	tests := []struct {
+		name   string
+		cfg    config.BrokerConfig
+		expect []Finding
+	}{
+		{
+			name: "valid config",
+			cfg: config.BrokerConfig{
+				Servers:          []config.MCPServer{validServer()},
+				VirtualServers:   []config.VirtualServerConfig{{Name: "vs", Tools: []string{"weather_forecast"}}},
+				GatewayCACertPEM: caPEM,
+				GlobalGuardrails: &config.GuardrailsConfig{URL: "http://guardrails:8000", Model: "m"},
+			},
+		},
+		{
+			name:   "missing name",
+			cfg:    withServer(func(s *config.MCPServer) { s.Name = "" }),
+			expect: []Finding{{SeverityError, "servers[0].name", "name is required"}},
+		},
+		{
+			name: "duplicate name and prefix",
+			cfg: config.BrokerConfig{Servers: []config.MCPServer{
+				validServer(),
+				{Name: "team-a/weather", URL: "http://other/mcp", Hostname: "other.mcp.local", Prefix: "weather_"},
+			}},
+			expect: []Finding{
+				{SeverityError, "servers[1].name", `duplicate server name "team-a/weather", first used by servers[0]`},
+				{SeverityWarning, "servers[1].prefix", `prefix "weather_" is also used by servers[0], tools with the same name will conflict`},
+			},
+		},
+		{
+			name:   "missing url",
+			cfg:    withServer(func(s *config.MCPServer) { s.URL = "" }),
+			expect: []Finding{{SeverityError, "servers[0].url", "url is required"}},
+		},
+		{
+			name:   "url without http scheme",
+			cfg:    withServer(func(s *config.MCPServer) { s.URL = "grpc://weather:8080" }),
+			expect: []Finding{{SeverityError, "servers[0].url", `url "grpc://weather:8080" must use http or https`}},
+		},
+		{
+			name:   "url without host",
+			cfg:    withServer(func(s *config.MCPServer) { s.URL = "http:///mcp" }),
+			expect: []Finding{{SeverityError, "servers[0].url", `url "http:///mcp" has no host`}},
+		},
+		{
+			name:   "empty hostname",
+			cfg:    withServer(func(s *config.MCPServer) { s.Hostname = "" }),
+			expect: []Finding{{SeverityWarning, "servers[0].hostname", "hostname is empty, so routed requests keep the gateway authority"}},
+		},
+		{
+			name:   "hostname with path",
+			cfg:    withServer(func(s *config.MCPServer) { s.Hostname = "weather.mcp.local/mcp" }),
+			expect: []Finding{{SeverityError, "servers[0].hostname", `"weather.mcp.local/mcp" is not a valid host`}},
+		},
+		{
+			name:   "prefix not matching the CRD pattern",
+			cfg:    withServer(func(s *config.MCPServer) { s.Prefix = "Weather-" }),
+			expect: []Finding{{SeverityError, "servers[0].prefix", `"Weather-" does not match ^[a-z0-9][a-z0-9_]*$`}},
+		},
+		{
+			name:   "invalid server CA cert",
+			cfg:    withServer(func(s *config.MCPServer) { s.CACert = "not a cert" }),
+			expect: []Finding{{SeverityError, "servers[0].caCert", "no valid PEM certificate blocks found"}},
+		},
+		{
+			name: "invalid concurrency limit",
+			cfg: withServer(func(s *config.MCPServer) {
+				s.Concurrency = &config.ConcurrencyConfig{MaxInFlight: 0, QueueTimeoutMilliseconds: -1}
+			}),
+			expect: []Finding{
+				{SeverityError, "servers[0].concurrency.maxInFlight", "must be at least 1, got 0"},
+				{SeverityError, "servers[0].concurrency.queueTimeoutMilliseconds", "must not be negative, got -1"},
+			},
+		},
+		{
+			name: "invalid tool call retry policy",
+			cfg: withServer(func(s *config.MCPServer) {
+				s.ToolCalls = &config.ToolCallConfig{
+					TimeoutMilliseconds: -1,
+					Retry:               &config.RetryConfig{MaxRetries: 6, PerTryTimeoutMilliseconds: -1, BudgetPercent: 101},
+				}
+			}),
+			expect: []Finding{
+				{SeverityError, "servers[0].toolCalls.timeoutMilliseconds", "must not be negative, got -1"},
+				{SeverityError, "servers[0].toolCalls.retry.maxRetries", "must be between 1 and 5, got 6"},
+				{SeverityError, "servers[0].toolCalls.retry.perTryTimeoutMilliseconds", "must not be negative, got -1"},
+				{SeverityError, "servers[0].toolCalls.retry.budgetPercent", "must be between 0 and 100, got 101"},
+			},
+		},
+		{
+			name: "per try timeout longer than the call timeout",
+			cfg: withServer(func(s *config.MCPServer) {
+				s.ToolCalls = &config.ToolCallConfig{
+					TimeoutMilliseconds: 1000,
+					Retry:               &config.RetryConfig{MaxRetries: 2, PerTryTimeoutMilliseconds: 2000, BudgetPercent: 20},
+				}
+			}),
+			expect: []Finding{{SeverityWarning, "servers[0].toolCalls.retry.perTryTimeoutMilliseconds", "2000 exceeds timeoutMilliseconds 1000, so no retry fits in the timeout"}},
+		},
+		{
+			name: "invalid result cache",
+			cfg: withServer(func(s *config.MCPServer) {
+				s.ResultCache = &config.ResultCacheConfig{Scope: "Session"}
+			}),
+			expect: []Finding{
+				{SeverityError, "servers[0].resultCache.ttlSeconds", "must be at least 1, got 0"},
+				{SeverityError, "servers[0].resultCache.maxResultBytes", "must be at least 1, got 0"},
+				{SeverityError, "servers[0].resultCache.scope", `must be Subject or Shared, got "Session"`},
+			},
+		},
+		{
+			name: "shared result cache with per-user tokens",
+			cfg: withServer(func(s *config.MCPServer) {
+				s.TokenURLElicitation = &config.TokenURLElicitationConfig{URL: "https://tokens.example.com"}
+				s.ResultCache = &config.ResultCacheConfig{TTLSeconds: 60, Scope: config.ResultCacheScopeShared, MaxResultBytes: 1024}
+			}),
+			expect: []Finding{{SeverityWarning, "servers[0].resultCache.scope", "Shared results of a server called with per-user tokens are served to every user"}},
+		},
+		{
+			name: "upstream oauth client with an external url and no client id",
+			cfg: withServer(func(s *config.MCPServer) {
+				s.TokenURLElicitation = &config.TokenURLElicitationConfig{
+					URL:   "https://tokens.example.com",
+					OAuth: &config.UpstreamOAuthConfig{AuthorizationURL: "https://github.com/login/oauth/authorize", TokenURL: "github.com/login/oauth/access_token"},
+				}
+			}),
+			expect: []Finding{
+				{SeverityError, "servers[0].tokenURLElicitation", "url and oauth are mutually exclusive"},
+				{SeverityError, "servers[0].tokenURLElicitation.oauth.tokenURL", `url "github.com/login/oauth/access_token" must use http or https`},
+				{SeverityError, "servers[0].tokenURLElicitation.oauth.clientID", "clientID is required"},
+			},
+		},
+		{
+			name: "maintenance window ending before it starts",
+			cfg: withServer(func(s *config.MCPServer) {
+				start := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+				end := start.Add(-time.Hour)
+				s.State = config.StateMaintenance
+				s.Maintenance = &config.MaintenanceConfig{Start: &start, End: &end}
+			}),
+			expect: []Finding{{SeverityError, "servers[0].maintenance.end", "must be after start"}},
+		},
+		{
+			name: "maintenance window without maintenance state",
+			cfg: withServer(func(s *config.MCPServer) {
+				s.Maintenance = &config.MaintenanceConfig{Message: "upgrading"}
+			}),
+			expect: []Finding{{SeverityWarning, "servers[0].maintenance", "maintenance window is ignored unless state is Maintenance"}},
+		},
+		{
+			name: "canary with an out of range weight and unknown policy",
+			cfg: withServer(func(s *config.MCPServer) {
+				s.Canary = &config.CanaryConfig{URL: "http://weather-v2.mcp-test.svc.cluster.local:8080/mcp", Weight: 150, ToolMismatch: "Ignore"}
+			}),
+			expect: []Finding{
+				{SeverityError, "servers[0].canary.weight", "must be between 0 and 100, got 150"},
+				{SeverityError, "servers[0].canary.toolMismatch", `must be "Reject" or "Flag", got "Ignore"`},
+			},
+		},
+		{
+			name: "canary without a url",
+			cfg: withServer(func(s *config.MCPServer) {
+				s.Canary = &config.CanaryConfig{Weight: 10}
+			}),
+			expect: []Finding{{SeverityError, "servers[0].canary.url", "url is required"}},
+		},
+		{
+			name:   "invalid gateway CA bundle",
+			cfg:    config.BrokerConfig{GatewayCACertPEM: "not a cert"},
+			expect: []Finding{{SeverityError, "gatewayCACertPEM", "no valid PEM certificate blocks found"}},
+		},
+		{
+			name:   "guardrails without a model",
+			cfg:    config.BrokerConfig{GlobalGuardrails: &config.GuardrailsConfig{URL: "http://guardrails:8000"}},
+			expect: []Finding{{SeverityError, "globalGuardrails", "config.yaml: model is required"}},
+		},
+		{
+			name:   "guardrails with an unknown fail mode",
+			cfg:    config.BrokerConfig{GlobalGuardrails: &config.GuardrailsConfig{URL: "http://guardrails:8000", Model: "m", FailMode: "ignore"}},
+			expect: []Finding{{SeverityError, "globalGuardrails", `config.yaml: failMode must be "deny" or "allow", got "ignore"`}},
+		},
+		{
+			name:   "guardrails config IDs without guardrails",
+			cfg:    withServer(func(s *config.MCPServer) { s.GuardrailsConfigIDs = []string{"strict"} }),
+			expect: []Finding{{SeverityWarning, "servers[0].guardrailsConfigIDs", "guardrails config IDs are set but globalGuardrails is not configured"}},
+		},
+		{
+			name: "virtual server with unknown tool and prompt",
+			cfg: config.BrokerConfig{
+				Servers: []config.MCPServer{validServer()},
+				VirtualServers: []config.VirtualServerConfig{
+					{Name: "vs", Tools: []string{"weather_forecast", "files_read"}, Prompts: []string{"summary"}},
+					{Name: "vs"},
+				},
+			},
+			expect: []Finding{
+				{SeverityError, "virtualServers[0].tools[1]", `unknown tool "files_read"`},
+				{SeverityError, "virtualServers[0].prompts[0]", `unknown prompt "summary"`},
+				{SeverityError, "virtualServers[1].name", `duplicate virtual server name "vs", first used by virtualServers[0]`},
+			},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			findings := Lint(tc.cfg, config.ToolsSnapshot{})
+			require.Equal(t, tc.expect, findings)
+			require.Equal(t, len(tc.expect) > 0 && tc.expect[0].Severity == SeverityError, HasErrors(findings))
+		})
+	}
+}
+
+func TestLint_VirtualServersAgainstSnapshot(t *testing.T) {
+	cfg := config.BrokerConfig{
+		Servers: []config.MCPServer{
+			validServer(),
+			{Name: "team-a/github", URL: "https://api.github.com/mcp", Hostname: "github.mcp.local", Prefix: "gh_", UserSpecificList: true},
+			{Name: "team-a/files", URL: "http://files/mcp", Hostname: "files.mcp.local", Prefix: "files_"},
+		},
+		VirtualServers: []config.VirtualServerConfig{{
+			Name:    "vs",
+			Tools:   []string{"weather_forecast", "weather_history", "gh_create_issue", "files_read"},
+			Prompts: []string{"weather_summary", "weather_report"},
+		}},
+	}
+	snapshot := config.ToolsSnapshot{Servers: []config.ServerTools{
+		{Name: "team-a/weather", Tools: []string{"forecast"}, Prompts: []string{"summary"}},
+		{Name: "team-a/github", Tools: []string{"list_repos"}},
+	}}
+
+	// per-user tools and servers missing from the snapshot fall back to their prefix
+	require.Equal(t, []Finding{
+		{SeverityError, "virtualServers[0].tools[1]", `unknown tool "weather_history"`},
+		{SeverityError, "virtualServers[0].prompts[1]", `unknown prompt "weather_report"`},
+	}, Lint(cfg, snapshot))
+}
@@ -0,0 +1,220 @@
+package configstream
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/keepalive"
+	"google.golang.org/grpc/metadata"
+
+	"github.com/Kuadrant/mcp-gateway/internal/config"
+)
+
+const (
+	// defaultMinBackoff is the first reconnect delay after a stream fails
+	defaultMinBackoff = time.Second
+	// defaultMaxBackoff caps the reconnect delay
+	defaultMaxBackoff = 30 * time.Second
+)
+
+// ApplyFunc applies a complete broker config. Returning an error NACKs the
+// version that produced it.
+type ApplyFunc func(ctx context.Context, cfg config.BrokerConfig) error
+
+// Client subscribes a broker to the config stream and applies each update
+type Client struct {
+	address    string
+	namespace  string
+	node       string
+	token      string
+	apply      ApplyFunc
+	logger     *slog.Logger
+	dialOpts   []grpc.DialOption
+	minBackoff time.Duration
+	maxBackoff time.Duration
+
+	// transport returns the stream's transport credentials, nil until
+	// WithTLS or WithInsecure is given
+	transport func() (credentials.TransportCredentials, error)
+
+	// current is the last config successfully applied and version its version
+	current config.BrokerConfig
+	version uint64
+
+	readyOnce sync.Once
+	ready     chan struct{}
+}
+
+// ClientOption configures a Client
+type ClientOption func(*Client)
+
+// WithDialOptions adds grpc dial options
+func WithDialOptions(opts ...grpc.DialOption) ClientOption {
+	return func(c *Client) {
+		c.dialOpts = append(c.dialOpts, opts...)
+	}
+}
+
+// WithTLS connects over TLS, verifying the controller's certificate against
+// the PEM CA bundle caPEM, or against the system roots when caPEM is empty
+func WithTLS(caPEM string) ClientOption {
+	return func(c *Client) {
+		c.transport = func() (credentials.TransportCredentials, error) {
+			return clientCredentials(caPEM)
+		}
+	}
+}
+
+// WithInsecure connects in plaintext. The stream carries the broker config,
+// so this is only for tests and for meshes that encrypt the traffic themselves.
+func WithInsecure() ClientOption {
+	return func(c *Client) {
+		c.transport = func() (credentials.TransportCredentials, error) {
+			return insecure.NewCredentials(), nil
+		}
+	}
+}
+
+// WithBackoff sets the minimum and maximum delay between reconnect attempts
+func WithBackoff(minBackoff, maxBackoff time.Duration) ClientOption {
+	return func(c *Client) {
+		c.minBackoff = minBackoff
+		c.maxBackoff = maxBackoff
+	}
+}
+
+// NewClient returns a Client that subscribes to the config for namespace at
+// address, authenticating with a token derived from signingKey, and calls
+// apply for every update.
+func NewClient(address, namespace, node, signingKey string, apply ApplyFunc, logger *slog.Logger, opts ...ClientOption) *Client {
+	c := &Client{
+		address:    address,
+		namespace:  namespace,
+		node:       node,
+		token:      Token(signingKey, namespace),
+		apply:      apply,
+		logger:     logger.With("namespace", namespace, "address", address),
+		minBackoff: defaultMinBackoff,
+		maxBackoff: defaultMaxBackoff,
+		ready:      make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// Ready is closed once the first config has been applied
+func (c *Client) Ready() <-chan struct{} {
+	return c.ready
+}
+
+// Run subscribes to the config stream until ctx is done, reconnecting with
+// backoff whenever the stream fails. The last applied config stays in place
+// while disconnected.
+func (c *Client) Run(ctx context.Context) error {
+	if c.transport == nil {
+		return errors.New("config stream client requires TLS, or plaintext explicitly allowed")
+	}
+	creds, err := c.transport()
+	if err != nil {
+		return fmt.Errorf("config stream client for %s: %w", c.address, err)
+	}
+	conn, err := grpc.NewClient(c.address, c.clientDialOptions(creds)...)
+	if err != nil {
+		return fmt.Errorf("config stream client for %s: %w", c.address, err)
+	}
+	defer func() { _ = conn.Close() }()
+
+	backoff := c.minBackoff
+	for {
+		applied := c.version
+		err := c.stream(ctx, conn)
+		if ctx.Err() != nil {
+			return nil
+		}
+		if c.version != applied {
+			// the stream made progress, so start over from the shortest delay
+			backoff = c.minBackoff
+		}
+		c.logger.Error("config stream disconnected, reconnecting", "error", err, "backoff", backoff)
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, c.maxBackoff)
+	}
+}
+
+func (c *Client) clientDialOptions(creds credentials.TransportCredentials) []grpc.DialOption {
+	opts := []grpc.DialOption{
+		grpc.WithTransportCredentials(creds),
+		grpc.WithDefaultCallOptions(grpc.CallContentSubtype(codecName)),
+		grpc.WithKeepaliveParams(keepalive.ClientParameters{
+			Time:    30 * time.Second,
+			Timeout: 10 * time.Second,
+		}),
+	}
+	return append(opts, c.dialOpts...)
+}
+
+// stream runs a single subscription until it fails
+func (c *Client) stream(ctx context.Context, conn *grpc.ClientConn) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	ctx = metadata.AppendToOutgoingContext(ctx, authorizationHeader, "Bearer "+c.token)
+
+	cs, err := conn.NewStream(ctx, &ServiceDesc.Streams[0], streamMethod)
+	if err != nil {
+		return err
+	}
+	stream := &grpc.GenericClientStream[Request, Update]{ClientStream: cs}
+	if err := stream.Send(&Request{Namespace: c.namespace, Node: c.node, Version: c.version}); err != nil {
+		return err
+	}
+
+	for {
+		upd, err := stream.Recv()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return errors.New("stream closed by server")
+			}
+			return err
+		}
+		resp := &Request{Version: upd.Version}
+		if err := c.handle(ctx, upd); err != nil {
+			c.logger.Error("rejecting config update", "version", upd.Version, "error", err)
+			resp.Error = err.Error()
+		}
+		if err := stream.Send(resp); err != nil {
+			return err
+		}
+	}
+}
+
+// handle validates and applies an update
+func (c *Client) handle(ctx context.Context, upd *Update) error {
+	if !upd.Full && c.version == 0 {
+		return errors.New("incremental update without a base config")
+	}
+	next := patch(c.current, upd)
+	if err := Validate(next); err != nil {
+		return fmt.Errorf("invalid config: %w", err)
+	}
+	if err := c.apply(ctx, next); err != nil {
+		return err
+	}
+	c.current, c.version = next, upd.Version
+	c.logger.Info("applied config from stream", "version", upd.Version, "full", upd.Full, "servers", len(next.Servers))
+	c.readyOnce.Do(func() { close(c.ready) })
+	return nil
+}
@@ -0,0 +1,125 @@
+package configstream
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/Kuadrant/mcp-gateway/internal/config"
+)
+
+// diff builds the Update that takes a broker from base to next. A nil base
+// means the broker has no acknowledged config and gets a full snapshot.
+func diff(base *config.BrokerConfig, next config.BrokerConfig, version uint64) *Update {
+	upd := &Update{
+		Version:          version,
+		GatewayCACertPEM: next.GatewayCACertPEM,
+		GlobalGuardrails: next.GlobalGuardrails,
+	}
+	if base == nil {
+		upd.Full = true
+		upd.Servers = slices.Clone(next.Servers)
+		upd.VirtualServers = slices.Clone(next.VirtualServers)
+		return upd
+	}
+
+	existingServers := make(map[string]config.MCPServer, len(base.Servers))
+	for _, s := range base.Servers {
+		existingServers[s.Name] = s
+	}
+	for _, s := range next.Servers {
+		existing, ok := existingServers[s.Name]
+		if !ok || s.ConfigChanged(existing) {
+			upd.Servers = append(upd.Servers, s)
+		}
+		delete(existingServers, s.Name)
+	}
+	for _, s := range base.Servers {
+		if _, removed := existingServers[s.Name]; removed {
+			upd.RemovedServers = append(upd.RemovedServers, s.Name)
+		}
+	}
+
+	existingVirtualServers := make(map[string]config.VirtualServerConfig, len(base.VirtualServers))
+	for _, vs := range base.VirtualServers {
+		existingVirtualServers[vs.Name] = vs
+	}
+	for _, vs := range next.VirtualServers {
+		existing, ok := existingVirtualServers[vs.Name]
+		if !ok || !slices.Equal(existing.Tools, vs.Tools) || !slices.Equal(existing.Prompts, vs.Prompts) {
+			upd.VirtualServers = append(upd.VirtualServers, vs)
+		}
+		delete(existingVirtualServers, vs.Name)
+	}
+	for _, vs := range base.VirtualServers {
+		if _, removed := existingVirtualServers[vs.Name]; removed {
+			upd.RemovedVirtualServers = append(upd.RemovedVirtualServers, vs.Name)
+		}
+	}
+	return upd
+}
+
+// patch applies an Update to current and returns the resulting config.
+// current is not modified.
+func patch(current config.BrokerConfig, upd *Update) config.BrokerConfig {
+	next := config.BrokerConfig{
+		GatewayCACertPEM: upd.GatewayCACertPEM,
+		GlobalGuardrails: upd.GlobalGuardrails,
+	}
+	if upd.Full {
+		next.Servers = slices.Clone(upd.Servers)
+		next.VirtualServers = slices.Clone(upd.VirtualServers)
+		return next
+	}
+
+	next.Servers = slices.DeleteFunc(slices.Clone(current.Servers), func(s config.MCPServer) bool {
+		return slices.Contains(upd.RemovedServers, s.Name)
+	})
+	for _, s := range upd.Servers {
+		if i := slices.IndexFunc(next.Servers, func(e config.MCPServer) bool { return e.Name == s.Name }); i >= 0 {
+			next.Servers[i] = s
+			continue
+		}
+		next.Servers = append(next.Servers, s)
+	}
+
+	next.VirtualServers = slices.DeleteFunc(slices.Clone(current.VirtualServers), func(vs config.VirtualServerConfig) bool {
+		return slices.Contains(upd.RemovedVirtualServers, vs.Name)
+	})
+	for _, vs := range upd.VirtualServers {
+		if i := slices.IndexFunc(next.VirtualServers, func(e config.VirtualServerConfig) bool { return e.Name == vs.Name }); i >= 0 {
+			next.VirtualServers[i] = vs
+			continue
+		}
+		next.VirtualServers = append(next.VirtualServers, vs)
+	}
+	return next
+}
+
+// Validate checks a config is safe for a broker to apply: every server has a
+// name and url, and server and virtual server names are unique.
+func Validate(cfg config.BrokerConfig) error {
+	servers := make(map[string]struct{}, len(cfg.Servers))
+	for _, s := range cfg.Servers {
+		if s.Name == "" {
+			return fmt.Errorf("server with url %q has no name", s.URL)
+		}
+		if s.URL == "" {
+			return fmt.Errorf("server %q has no url", s.Name)
+		}
+		if _, dup := servers[s.Name]; dup {
+			return fmt.Errorf("duplicate server %q", s.Name)
+		}
+		servers[s.Name] = struct{}{}
+	}
+	virtualServers := make(map[string]struct{}, len(cfg.VirtualServers))
+	for _, vs := range cfg.VirtualServers {
+		if vs.Name == "" {
+			return fmt.Errorf("virtual server has no name")
+		}
+		if _, dup := virtualServers[vs.Name]; dup {
+			return fmt.Errorf("duplicate virtual server %q", vs.Name)
+		}
+		virtualServers[vs.Name] = struct{}{}
+	}
+	return nil
+}
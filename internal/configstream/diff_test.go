@@ -0,0 +1,169 @@
+package configstream
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/Kuadrant/mcp-gateway/internal/config"
+)
+
+func TestDiffAndPatch(t *testing.T) {
+	guardrails := &config.GuardrailsConfig{URL: "http://guardrails", Model: "m"}
+	base := config.BrokerConfig{
+		Servers: []config.MCPServer{
+			{Name: "a", URL: "http://a/mcp", Prefix: "a_"},
+			{Name: "b", URL: "http://b/mcp", Prefix: "b_"},
+			{Name: "c", URL: "http://c/mcp", Prefix: "c_"},
+		},
+		VirtualServers: []config.VirtualServerConfig{
+			{Name: "vs1", Tools: []string{"a_one"}},
+			{Name: "vs2", Tools: []string{"b_one"}},
+		},
+		GatewayCACertPEM: "ca",
+	}
+
+	tests := []struct {
+		name                  string
+		base                  *config.BrokerConfig
+		next                  config.BrokerConfig
+		expectFull            bool
+		expectServers         []string
+		expectRemoved         []string
+		expectVirtualServers  []string
+		expectRemovedVirtuals []string
+	}{
+		{
+			name:                 "nil base sends full snapshot",
+			base:                 nil,
+			next:                 base,
+			expectFull:           true,
+			expectServers:        []string{"a", "b", "c"},
+			expectVirtualServers: []string{"vs1", "vs2"},
+		},
+		{
+			name: "unchanged config sends no servers",
+			base: &base,
+			next: base,
+		},
+		{
+			name: "added changed and removed servers",
+			base: &base,
+			next: config.BrokerConfig{
+				Servers: []config.MCPServer{
+					{Name: "a", URL: "http://a/mcp", Prefix: "a_"},
+					{Name: "b", URL: "http://b/mcp", Prefix: "b2_"},
+					{Name: "d", URL: "http://d/mcp"},
+				},
+				VirtualServers: []config.VirtualServerConfig{
+					{Name: "vs1", Tools: []string{"a_one", "a_two"}},
+					{Name: "vs3", Tools: []string{"d_one"}},
+				},
+				GatewayCACertPEM: "ca2",
+				GlobalGuardrails: guardrails,
+			},
+			expectServers:         []string{"b", "d"},
+			expectRemoved:         []string{"c"},
+			expectVirtualServers:  []string{"vs1", "vs3"},
+			expectRemovedVirtuals: []string{"vs2"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			upd := diff(tc.base, tc.next, 7)
+			require.Equal(t, uint64(7), upd.Version)
+			require.Equal(t, tc.expectFull, upd.Full)
+			require.Equal(t, tc.expectServers, serverNames(upd.Servers))
+			require.Equal(t, tc.expectRemoved, upd.RemovedServers)
+			require.Equal(t, tc.expectVirtualServers, virtualServerNames(upd.VirtualServers))
+			require.Equal(t, tc.expectRemovedVirtuals, upd.RemovedVirtualServers)
+			require.Equal(t, tc.next.GatewayCACertPEM, upd.GatewayCACertPEM)
+			require.Equal(t, tc.next.GlobalGuardrails, upd.GlobalGuardrails)
+
+			// applying the update to the base must reproduce next
+			current := config.BrokerConfig{}
+			if tc.base != nil {
+				current = *tc.base
+			}
+			patched := patch(current, upd)
+			require.ElementsMatch(t, tc.next.Servers, patched.Servers)
+			require.ElementsMatch(t, tc.next.VirtualServers, patched.VirtualServers)
+			require.Equal(t, tc.next.GatewayCACertPEM, patched.GatewayCACertPEM)
+			require.Equal(t, tc.next.GlobalGuardrails, patched.GlobalGuardrails)
+		})
+	}
+}
+
+func TestPatchDoesNotModifyCurrent(t *testing.T) {
+	current := config.BrokerConfig{
+		Servers: []config.MCPServer{{Name: "a", URL: "http://a/mcp"}},
+	}
+	patch(current, &Update{Version: 2, Servers: []config.MCPServer{{Name: "a", URL: "http://other/mcp"}}})
+	require.Equal(t, "http://a/mcp", current.Servers[0].URL)
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name      string
+		cfg       config.BrokerConfig
+		expectErr string
+	}{
+		{
+			name: "valid",
+			cfg: config.BrokerConfig{
+				Servers:        []config.MCPServer{{Name: "a", URL: "http://a/mcp"}},
+				VirtualServers: []config.VirtualServerConfig{{Name: "vs"}},
+			},
+		},
+		{
+			name:      "server without name",
+			cfg:       config.BrokerConfig{Servers: []config.MCPServer{{URL: "http://a/mcp"}}},
+			expectErr: "has no name",
+		},
+		{
+			name:      "server without url",
+			cfg:       config.BrokerConfig{Servers: []config.MCPServer{{Name: "a"}}},
+			expectErr: `server "a" has no url`,
+		},
+		{
+			name: "duplicate server",
+			cfg: config.BrokerConfig{Servers: []config.MCPServer{
+				{Name: "a", URL: "http://a/mcp"},
+				{Name: "a", URL: "http://b/mcp"},
+			}},
+			expectErr: `duplicate server "a"`,
+		},
+		{
+			name:      "duplicate virtual server",
+			cfg:       config.BrokerConfig{VirtualServers: []config.VirtualServerConfig{{Name: "vs"}, {Name: "vs"}}},
+			expectErr: `duplicate virtual server "vs"`,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := Validate(tc.cfg)
+			if tc.expectErr == "" {
+				require.NoError(t, err)
+				return
+			}
+			require.ErrorContains(t, err, tc.expectErr)
+		})
+	}
+}
+
+func serverNames(servers []config.MCPServer) []string {
+	var names []string
+	for _, s := range servers {
+		names = append(names, s.Name)
+	}
+	return names
+}
+
+func virtualServerNames(virtualServers []config.VirtualServerConfig) []string {
+	var names []string
+	for _, vs := range virtualServers {
+		names = append(names, vs.Name)
+	}
+	return names
+}
@@ -0,0 +1,158 @@
+package configstream
+
+import (
+	"context"
+	"crypto/subtle"
+	"log/slog"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/Kuadrant/mcp-gateway/internal/config"
+)
+
+// VerificationKeysFunc returns the session signing keys of the gateway in
+// namespace that broker tokens may be derived from: the active key and the
+// keys that still verify sessions. Accepting all of them keeps brokers that
+// started before a key rotation connected.
+type VerificationKeysFunc func(ctx context.Context, namespace string) ([]string, error)
+
+// Server serves the config stream from a Store
+type Server struct {
+	store            *Store
+	verificationKeys VerificationKeysFunc
+	logger           *slog.Logger
+}
+
+var _ ConfigStreamServer = &Server{}
+
+// NewServer returns a Server streaming config from store. Brokers must present
+// the Token for their namespace derived from one of the keys returned by
+// verificationKeys.
+func NewServer(store *Store, verificationKeys VerificationKeysFunc, logger *slog.Logger) *Server {
+	return &Server{store: store, verificationKeys: verificationKeys, logger: logger}
+}
+
+// Register registers the config stream service on grpcServer
+func (s *Server) Register(grpcServer *grpc.Server) {
+	grpcServer.RegisterService(&ServiceDesc, s)
+}
+
+// Stream implements ConfigStreamServer. It sends a full snapshot, then an
+// incremental update for each new version once the previous one has been
+// answered. A NACKed version is not resent; the next update is computed
+// against the last version the broker ACKed.
+func (s *Server) Stream(stream grpc.BidiStreamingServer[Request, Update]) error {
+	ctx := stream.Context()
+	sub, err := stream.Recv()
+	if err != nil {
+		return err
+	}
+	if sub.Namespace == "" {
+		return status.Error(codes.InvalidArgument, "namespace is required")
+	}
+	if err := s.authenticate(ctx, sub.Namespace); err != nil {
+		return err
+	}
+
+	logger := s.logger.With("namespace", sub.Namespace, "node", sub.Node)
+	logger.Info("broker subscribed to config stream", "version", sub.Version)
+	b := s.store.connect(sub.Namespace, sub.Node)
+	defer func() {
+		s.store.disconnect(b)
+		logger.Info("broker unsubscribed from config stream")
+	}()
+
+	responses := make(chan *Request)
+	recvErr := make(chan error, 1)
+	go func() {
+		for {
+			req, err := stream.Recv()
+			if err != nil {
+				recvErr <- err
+				return
+			}
+			select {
+			case responses <- req:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	var (
+		// acked is the config the broker last ACKed, nil until the first ACK
+		acked *config.BrokerConfig
+		// answered is the last version the broker responded to
+		answered uint64
+		// pending is the config of the outstanding update
+		pending        config.BrokerConfig
+		pendingVersion uint64
+	)
+	for {
+		var changed <-chan struct{}
+		if pendingVersion == 0 {
+			cfg, version, ch := s.store.snapshot(sub.Namespace)
+			changed = ch
+			if version > answered {
+				upd := diff(acked, cfg, version)
+				if err := stream.Send(upd); err != nil {
+					return err
+				}
+				logger.Debug("sent config update", "version", version, "full", upd.Full)
+				pending, pendingVersion = cfg, version
+				changed = nil
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case err := <-recvErr:
+			return err
+		case <-changed:
+		case resp := <-responses:
+			if resp.Version != pendingVersion {
+				logger.Debug("ignoring response for unexpected version", "version", resp.Version, "pending", pendingVersion)
+				continue
+			}
+			if resp.Error != "" {
+				logger.Error("broker rejected config", "version", resp.Version, "error", resp.Error)
+			} else {
+				logger.Debug("broker applied config", "version", resp.Version)
+				applied := pending
+				acked = &applied
+			}
+			s.store.ack(b, resp.Version, resp.Error)
+			answered, pendingVersion = resp.Version, 0
+		}
+	}
+}
+
+// authenticate checks the bearer token in the stream metadata matches the
+// token for namespace derived from one of its verification keys
+func (s *Server) authenticate(ctx context.Context, namespace string) error {
+	md, _ := metadata.FromIncomingContext(ctx)
+	values := md.Get(authorizationHeader)
+	if len(values) == 0 {
+		return status.Error(codes.Unauthenticated, "missing authorization")
+	}
+	token, ok := strings.CutPrefix(values[0], "Bearer ")
+	if !ok || token == "" {
+		return status.Error(codes.Unauthenticated, "invalid authorization")
+	}
+	keys, err := s.verificationKeys(ctx, namespace)
+	if err != nil {
+		s.logger.Error("failed to read signing keys for config stream", "namespace", namespace, "error", err)
+		return status.Error(codes.Unavailable, "unable to verify token")
+	}
+	for _, key := range keys {
+		if key != "" && subtle.ConstantTimeCompare([]byte(token), []byte(Token(key, namespace))) == 1 {
+			return nil
+		}
+	}
+	return status.Error(codes.PermissionDenied, "token is not valid for namespace")
+}
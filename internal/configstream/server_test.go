@@ -0,0 +1,327 @@
+package configstream
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"github.com/Kuadrant/mcp-gateway/internal/config"
+)
+
+const (
+	testSigningKey = "test-signing-key"
+	// testRetiredKey signed sessions before the last rotation and still verifies them
+	testRetiredKey = "test-retired-key"
+)
+
+// startServer serves store over an in-memory listener and returns a dial option for it
+func startServer(t *testing.T, store *Store, opts ...grpc.ServerOption) grpc.DialOption {
+	t.Helper()
+	lis := bufconn.Listen(1 << 20)
+	grpcServer := grpc.NewServer(opts...)
+	NewServer(store, func(_ context.Context, namespace string) ([]string, error) {
+		if namespace == "no-key" {
+			return nil, errors.New("secret not found")
+		}
+		return []string{testSigningKey, testRetiredKey}, nil
+	}, testLogger()).Register(grpcServer)
+	go func() { _ = grpcServer.Serve(lis) }()
+	t.Cleanup(grpcServer.Stop)
+	return grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+		return lis.DialContext(ctx)
+	})
+}
+
+// recordingApply records every applied config and rejects configs with a
+// server named "reject"
+type recordingApply struct {
+	mu      sync.Mutex
+	applied []config.BrokerConfig
+}
+
+func (r *recordingApply) apply(_ context.Context, cfg config.BrokerConfig) error {
+	for _, s := range cfg.Servers {
+		if s.Name == "reject" {
+			return errors.New("rejected by test")
+		}
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.applied = append(r.applied, cfg)
+	return nil
+}
+
+func (r *recordingApply) last() (config.BrokerConfig, int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.applied) == 0 {
+		return config.BrokerConfig{}, 0
+	}
+	return r.applied[len(r.applied)-1], len(r.applied)
+}
+
+func TestStream_RoundTrip(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	nn := config.NamespaceName("team-a")
+	store := NewStore(testLogger(), nil)
+	require.NoError(t, store.UpsertMCPServer(ctx, config.MCPServer{Name: "a", URL: "http://a/mcp", Prefix: "a_"}, nn))
+	require.NoError(t, store.WriteCACertBundle(ctx, "ca", nn))
+
+	dialer := startServer(t, store)
+	rec := &recordingApply{}
+	client := NewClient("passthrough:///bufnet", "team-a", "broker-1", testSigningKey, rec.apply, testLogger(),
+		WithDialOptions(dialer), WithInsecure(), WithBackoff(10*time.Millisecond, 50*time.Millisecond))
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_ = client.Run(ctx)
+	}()
+
+	select {
+	case <-client.Ready():
+	case <-time.After(5 * time.Second):
+		t.Fatal("client never applied initial config")
+	}
+	cfg, _ := rec.last()
+	require.Equal(t, []config.MCPServer{{Name: "a", URL: "http://a/mcp", Prefix: "a_"}}, cfg.Servers)
+	require.Equal(t, "ca", cfg.GatewayCACertPEM)
+	require.Eventually(t, func() bool {
+		s := store.Status("team-a")
+		return s.Brokers == 1 && s.AppliedVersion == s.Version
+	}, 5*time.Second, 10*time.Millisecond)
+
+	// an incremental update is applied on top of the existing config
+	require.NoError(t, store.UpsertMCPServer(ctx, config.MCPServer{Name: "b", URL: "http://b/mcp"}, nn))
+	require.Eventually(t, func() bool {
+		cfg, _ := rec.last()
+		return len(cfg.Servers) == 2
+	}, 5*time.Second, 10*time.Millisecond)
+	require.Eventually(t, func() bool {
+		s := store.Status("team-a")
+		return s.AppliedVersion == s.Version
+	}, 5*time.Second, 10*time.Millisecond)
+
+	// a rejected update is NACKed and the previous config stays applied
+	require.NoError(t, store.UpsertMCPServer(ctx, config.MCPServer{Name: "reject", URL: "http://r/mcp"}, nn))
+	require.Eventually(t, func() bool {
+		return store.Status("team-a").Error == "rejected by test"
+	}, 5*time.Second, 10*time.Millisecond)
+	status := store.Status("team-a")
+	require.Less(t, status.AppliedVersion, status.Version)
+	cfg, applies := rec.last()
+	require.Len(t, cfg.Servers, 2)
+
+	// fixing the config clears the error and the delta is computed from the last ACK
+	require.NoError(t, store.RemoveMCPServer(ctx, "reject"))
+	require.NoError(t, store.RemoveMCPServer(ctx, "a"))
+	require.Eventually(t, func() bool {
+		s := store.Status("team-a")
+		return s.AppliedVersion == s.Version && s.Error == ""
+	}, 5*time.Second, 10*time.Millisecond)
+	cfg, n := rec.last()
+	require.Greater(t, n, applies)
+	require.Equal(t, []config.MCPServer{{Name: "b", URL: "http://b/mcp"}}, cfg.Servers)
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("client did not stop")
+	}
+	require.Eventually(t, func() bool {
+		return store.Status("team-a").Brokers == 0
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestStream_WaitsForConfig(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	store := NewStore(testLogger(), nil)
+	dialer := startServer(t, store)
+	rec := &recordingApply{}
+	client := NewClient("passthrough:///bufnet", "team-a", "broker-1", testSigningKey, rec.apply, testLogger(), WithDialOptions(dialer), WithInsecure())
+	go func() { _ = client.Run(ctx) }()
+
+	require.Eventually(t, func() bool {
+		return store.Status("team-a").Brokers == 1
+	}, 5*time.Second, 10*time.Millisecond)
+	_, n := rec.last()
+	require.Zero(t, n)
+
+	require.NoError(t, store.EnsureConfigExists(ctx, config.NamespaceName("team-a")))
+	select {
+	case <-client.Ready():
+	case <-time.After(5 * time.Second):
+		t.Fatal("client never applied config")
+	}
+}
+
+func TestStream_Authentication(t *testing.T) {
+	store := NewStore(testLogger(), nil)
+	require.NoError(t, store.EnsureConfigExists(context.Background(), config.NamespaceName("team-a")))
+	dialer := startServer(t, store)
+	conn, err := grpc.NewClient("passthrough:///bufnet", dialer,
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithDefaultCallOptions(grpc.CallContentSubtype(codecName)))
+	require.NoError(t, err)
+	defer func() { _ = conn.Close() }()
+
+	tests := []struct {
+		name       string
+		namespace  string
+		authHeader string
+		expectCode codes.Code
+	}{
+		{name: "missing namespace", namespace: "", authHeader: "Bearer " + Token(testSigningKey, ""), expectCode: codes.InvalidArgument},
+		{name: "missing token", namespace: "team-a", expectCode: codes.Unauthenticated},
+		{name: "not a bearer token", namespace: "team-a", authHeader: Token(testSigningKey, "team-a"), expectCode: codes.Unauthenticated},
+		{name: "token for another namespace", namespace: "team-a", authHeader: "Bearer " + Token(testSigningKey, "team-b"), expectCode: codes.PermissionDenied},
+		{name: "token from another key", namespace: "team-a", authHeader: "Bearer " + Token("other", "team-a"), expectCode: codes.PermissionDenied},
+		{name: "token from a retired key", namespace: "team-a", authHeader: "Bearer " + Token(testRetiredKey, "team-a"), expectCode: codes.OK},
+		{name: "signing key unavailable", namespace: "no-key", authHeader: "Bearer " + Token(testSigningKey, "no-key"), expectCode: codes.Unavailable},
+		{name: "valid token", namespace: "team-a", authHeader: "Bearer " + Token(testSigningKey, "team-a"), expectCode: codes.OK},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			if tc.authHeader != "" {
+				ctx = metadata.AppendToOutgoingContext(ctx, authorizationHeader, tc.authHeader)
+			}
+			cs, err := conn.NewStream(ctx, &ServiceDesc.Streams[0], streamMethod)
+			require.NoError(t, err)
+			stream := &grpc.GenericClientStream[Request, Update]{ClientStream: cs}
+			require.NoError(t, stream.Send(&Request{Namespace: tc.namespace, Node: "test"}))
+			upd, err := stream.Recv()
+			if tc.expectCode == codes.OK {
+				require.NoError(t, err)
+				require.True(t, upd.Full)
+				return
+			}
+			require.Equal(t, tc.expectCode, status.Code(err))
+		})
+	}
+}
+
+func TestStream_TLS(t *testing.T) {
+	caPEM, certFile, keyFile := writeTestServerCert(t)
+	creds, err := ServerCredentials(certFile, keyFile)
+	require.NoError(t, err)
+	store := NewStore(testLogger(), nil)
+	require.NoError(t, store.EnsureConfigExists(context.Background(), config.NamespaceName("team-a")))
+	dialer := startServer(t, store, grpc.Creds(creds))
+
+	t.Run("verified against the CA", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		client := NewClient("passthrough:///bufnet", "team-a", "broker-1", testSigningKey, (&recordingApply{}).apply, testLogger(),
+			WithDialOptions(dialer), WithTLS(string(caPEM)))
+		go func() { _ = client.Run(ctx) }()
+		select {
+		case <-client.Ready():
+		case <-time.After(5 * time.Second):
+			t.Fatal("client never applied config over TLS")
+		}
+	})
+
+	t.Run("untrusted certificate", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+		defer cancel()
+		otherCA, _, _ := writeTestServerCert(t)
+		client := NewClient("passthrough:///bufnet", "team-a", "broker-1", testSigningKey, (&recordingApply{}).apply, testLogger(),
+			WithDialOptions(dialer), WithTLS(string(otherCA)), WithBackoff(10*time.Millisecond, 50*time.Millisecond))
+		require.NoError(t, client.Run(ctx))
+		select {
+		case <-client.Ready():
+			t.Fatal("client applied config from a server it should not trust")
+		default:
+		}
+	})
+
+	t.Run("plaintext refused by the server", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+		defer cancel()
+		client := NewClient("passthrough:///bufnet", "team-a", "broker-1", testSigningKey, (&recordingApply{}).apply, testLogger(),
+			WithDialOptions(dialer), WithInsecure(), WithBackoff(10*time.Millisecond, 50*time.Millisecond))
+		require.NoError(t, client.Run(ctx))
+		select {
+		case <-client.Ready():
+			t.Fatal("client applied config over plaintext")
+		default:
+		}
+	})
+}
+
+func TestClient_RequiresTransportSecurity(t *testing.T) {
+	client := NewClient("passthrough:///bufnet", "team-a", "broker-1", testSigningKey, (&recordingApply{}).apply, testLogger())
+	require.ErrorContains(t, client.Run(context.Background()), "requires TLS")
+
+	client = NewClient("passthrough:///bufnet", "team-a", "broker-1", testSigningKey, (&recordingApply{}).apply, testLogger(),
+		WithTLS("not a certificate"))
+	require.ErrorContains(t, client.Run(context.Background()), "no certificates found")
+}
+
+// writeTestServerCert writes a certificate for "bufnet" signed by a new CA,
+// returning the CA PEM and the certificate and key files
+func writeTestServerCert(t *testing.T) (caPEM []byte, certFile, keyFile string) {
+	t.Helper()
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	caTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, caTemplate, caTemplate, &caKey.PublicKey, caKey)
+	require.NoError(t, err)
+	caCert, err := x509.ParseCertificate(caDER)
+	require.NoError(t, err)
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: "bufnet"},
+		DNSNames:     []string{"bufnet"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	certDER, err := x509.CreateCertificate(rand.Reader, template, caCert, &key.PublicKey, caKey)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	certFile = filepath.Join(dir, "tls.crt")
+	keyFile = filepath.Join(dir, "tls.key")
+	require.NoError(t, os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certDER}), 0o600))
+	require.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600))
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: caDER}), certFile, keyFile
+}
@@ -0,0 +1,297 @@
+package configstream
+
+import (
+	"context"
+	"log/slog"
+	"reflect"
+	"slices"
+	"sync"
+
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/Kuadrant/mcp-gateway/internal/config"
+)
+
+// Status is the config version state for one namespace
+type Status struct {
+	// Version is the latest config version the controller has produced, zero
+	// when no config exists
+	Version uint64
+	// AppliedVersion is the lowest version ACKed by the connected brokers,
+	// zero when no broker has ACKed a version
+	AppliedVersion uint64
+	// Brokers is the number of brokers subscribed to the config
+	Brokers int
+	// Error is the reason given by a broker that rejected its latest update
+	Error string
+}
+
+// Store holds the desired broker config per config NamespacedName in memory.
+// It implements the same write methods as config.SecretReaderWriter so the
+// reconcilers can use either. Every change bumps a store-wide version and wakes
+// the streams waiting on it.
+//
+// The Store is not persisted. After a controller restart it is rebuilt by the
+// initial reconcile of every resource, so the server should only start
+// serving once that has settled.
+type Store struct {
+	mu      sync.Mutex
+	version uint64
+	configs map[types.NamespacedName]*storeEntry
+	brokers map[string]map[*broker]struct{}
+	changed chan struct{}
+	logger  *slog.Logger
+	// onChange is called with the namespace whose Status may have changed
+	onChange func(namespace string)
+}
+
+type storeEntry struct {
+	version uint64
+	config  config.BrokerConfig
+}
+
+// broker is the state of one subscribed broker stream
+type broker struct {
+	namespace string
+	node      string
+	applied   uint64
+	err       string
+}
+
+// NewStore returns an empty Store. onChange, when not nil, is called after any
+// change that may affect the Status of a namespace. It must not block.
+func NewStore(logger *slog.Logger, onChange func(namespace string)) *Store {
+	return &Store{
+		configs:  map[types.NamespacedName]*storeEntry{},
+		brokers:  map[string]map[*broker]struct{}{},
+		changed:  make(chan struct{}),
+		logger:   logger,
+		onChange: onChange,
+	}
+}
+
+// update applies fn to a copy of the config for namespaceName, creating it when
+// missing. If fn reports a change the copy is stored under a new version.
+func (s *Store) update(namespaceName types.NamespacedName, fn func(cfg *config.BrokerConfig) bool) {
+	s.mu.Lock()
+	entry, exists := s.configs[namespaceName]
+	next := config.BrokerConfig{}
+	if exists {
+		next = cloneConfig(entry.config)
+	}
+	if !fn(&next) && exists {
+		s.mu.Unlock()
+		return
+	}
+	s.bumpLocked(namespaceName, &next)
+	s.mu.Unlock()
+	s.notify(namespaceName.Namespace)
+}
+
+// bumpLocked stores cfg under a new version, or removes the entry when cfg is
+// nil, and wakes waiting streams. s.mu must be held.
+func (s *Store) bumpLocked(namespaceName types.NamespacedName, cfg *config.BrokerConfig) {
+	s.version++
+	if cfg == nil {
+		delete(s.configs, namespaceName)
+	} else {
+		s.configs[namespaceName] = &storeEntry{version: s.version, config: *cfg}
+	}
+	close(s.changed)
+	s.changed = make(chan struct{})
+	s.logger.Debug("config stream store updated", "config", namespaceName, "version", s.version)
+}
+
+func (s *Store) notify(namespace string) {
+	if s.onChange != nil {
+		s.onChange(namespace)
+	}
+}
+
+// snapshot returns the config and version for namespace, and a channel that is
+// closed on the next change to the store. The version is zero when there is
+// no config for namespace.
+func (s *Store) snapshot(namespace string) (config.BrokerConfig, uint64, <-chan struct{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entry, ok := s.configs[config.NamespaceName(namespace)]
+	if !ok {
+		return config.BrokerConfig{}, 0, s.changed
+	}
+	return entry.config, entry.version, s.changed
+}
+
+// connect registers a broker subscribed to namespace
+func (s *Store) connect(namespace, node string) *broker {
+	b := &broker{namespace: namespace, node: node}
+	s.mu.Lock()
+	if s.brokers[namespace] == nil {
+		s.brokers[namespace] = map[*broker]struct{}{}
+	}
+	s.brokers[namespace][b] = struct{}{}
+	s.mu.Unlock()
+	return b
+}
+
+// disconnect removes a broker registered with connect
+func (s *Store) disconnect(b *broker) {
+	s.mu.Lock()
+	delete(s.brokers[b.namespace], b)
+	if len(s.brokers[b.namespace]) == 0 {
+		delete(s.brokers, b.namespace)
+	}
+	s.mu.Unlock()
+	s.notify(b.namespace)
+}
+
+// ack records a broker's response to version. A non-empty nackErr records a
+// rejection and leaves the applied version unchanged.
+func (s *Store) ack(b *broker, version uint64, nackErr string) {
+	s.mu.Lock()
+	if nackErr == "" {
+		b.applied = version
+	}
+	b.err = nackErr
+	s.mu.Unlock()
+	s.notify(b.namespace)
+}
+
+// Status returns the config version state for namespace
+func (s *Store) Status(namespace string) Status {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	status := Status{Brokers: len(s.brokers[namespace])}
+	if entry, ok := s.configs[config.NamespaceName(namespace)]; ok {
+		status.Version = entry.version
+	}
+	for b := range s.brokers[namespace] {
+		if b.applied != 0 && (status.AppliedVersion == 0 || b.applied < status.AppliedVersion) {
+			status.AppliedVersion = b.applied
+		}
+		if b.err != "" && status.Error == "" {
+			status.Error = b.err
+		}
+	}
+	return status
+}
+
+// UpsertMCPServer updates or inserts a single MCPServer in the config for
+// namespaceName. Unchanged servers do not produce a new version.
+func (s *Store) UpsertMCPServer(_ context.Context, server config.MCPServer, namespaceName types.NamespacedName) error {
+	s.update(namespaceName, func(cfg *config.BrokerConfig) bool {
+		for i, existing := range cfg.Servers {
+			if existing.Name == server.Name {
+				if !server.ConfigChanged(existing) {
+					return false
+				}
+				cfg.Servers[i] = server
+				return true
+			}
+		}
+		cfg.Servers = append(cfg.Servers, server)
+		return true
+	})
+	return nil
+}
+
+// RemoveMCPServer removes a single MCPServer by name from every config in the store
+func (s *Store) RemoveMCPServer(_ context.Context, serverName string) error {
+	var namespaces []string
+	s.mu.Lock()
+	for namespaceName, entry := range s.configs {
+		if !slices.ContainsFunc(entry.config.Servers, func(srv config.MCPServer) bool {
+			return srv.Name == serverName
+		}) {
+			continue
+		}
+		next := cloneConfig(entry.config)
+		next.Servers = slices.DeleteFunc(next.Servers, func(srv config.MCPServer) bool {
+			return srv.Name == serverName
+		})
+		s.bumpLocked(namespaceName, &next)
+		namespaces = append(namespaces, namespaceName.Namespace)
+	}
+	s.mu.Unlock()
+	for _, ns := range namespaces {
+		s.notify(ns)
+	}
+	return nil
+}
+
+// WriteVirtualServerConfig replaces the virtual servers in the config for namespaceName
+func (s *Store) WriteVirtualServerConfig(_ context.Context, virtualServers []config.VirtualServerConfig, namespaceName types.NamespacedName) error {
+	s.update(namespaceName, func(cfg *config.BrokerConfig) bool {
+		if reflect.DeepEqual(cfg.VirtualServers, virtualServers) {
+			return false
+		}
+		cfg.VirtualServers = slices.Clone(virtualServers)
+		return true
+	})
+	return nil
+}
+
+// WriteCACertBundle sets the gateway CA bundle in the config for namespaceName
+func (s *Store) WriteCACertBundle(_ context.Context, caCertPEM string, namespaceName types.NamespacedName) error {
+	s.update(namespaceName, func(cfg *config.BrokerConfig) bool {
+		if cfg.GatewayCACertPEM == caCertPEM {
+			return false
+		}
+		cfg.GatewayCACertPEM = caCertPEM
+		return true
+	})
+	return nil
+}
+
+// WriteGlobalGuardrails sets the global guardrails in the config for
+// namespaceName. Pass nil to clear them.
+func (s *Store) WriteGlobalGuardrails(_ context.Context, guardrailsConfig *config.GuardrailsConfig, namespaceName types.NamespacedName) error {
+	s.update(namespaceName, func(cfg *config.BrokerConfig) bool {
+		if reflect.DeepEqual(cfg.GlobalGuardrails, guardrailsConfig) {
+			return false
+		}
+		cfg.GlobalGuardrails = guardrailsConfig
+		return true
+	})
+	return nil
+}
+
+// EnsureConfigExists creates an empty config for namespaceName if none exists
+func (s *Store) EnsureConfigExists(_ context.Context, namespaceName types.NamespacedName) error {
+	s.update(namespaceName, func(_ *config.BrokerConfig) bool {
+		return false
+	})
+	return nil
+}
+
+// WriteEmptyConfig replaces the config for namespaceName with an empty one
+func (s *Store) WriteEmptyConfig(_ context.Context, namespaceName types.NamespacedName) error {
+	s.update(namespaceName, func(cfg *config.BrokerConfig) bool {
+		if reflect.DeepEqual(*cfg, config.BrokerConfig{}) {
+			return false
+		}
+		*cfg = config.BrokerConfig{}
+		return true
+	})
+	return nil
+}
+
+// DeleteConfig removes the config for namespaceName. Subscribed brokers keep
+// their last applied config until a new one is written.
+func (s *Store) DeleteConfig(_ context.Context, namespaceName types.NamespacedName) error {
+	s.mu.Lock()
+	if _, ok := s.configs[namespaceName]; !ok {
+		s.mu.Unlock()
+		return nil
+	}
+	s.bumpLocked(namespaceName, nil)
+	s.mu.Unlock()
+	s.notify(namespaceName.Namespace)
+	return nil
+}
+
+// cloneConfig copies the slices of cfg so a stored config is never mutated
+func cloneConfig(cfg config.BrokerConfig) config.BrokerConfig {
+	cfg.Servers = slices.Clone(cfg.Servers)
+	cfg.VirtualServers = slices.Clone(cfg.VirtualServers)
+	return cfg
+}
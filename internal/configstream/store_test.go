@@ -0,0 +1,138 @@
+package configstream
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/Kuadrant/mcp-gateway/internal/config"
+)
+
+func testLogger() *slog.Logger {
+	return slog.New(slog.NewTextHandler(io.Discard, nil))
+}
+
+func TestStore_WritesBumpVersion(t *testing.T) {
+	ctx := context.Background()
+	nn := config.NamespaceName("team-a")
+	store := NewStore(testLogger(), nil)
+
+	_, version, _ := store.snapshot("team-a")
+	require.Zero(t, version)
+
+	require.NoError(t, store.EnsureConfigExists(ctx, nn))
+	_, v1, _ := store.snapshot("team-a")
+	require.NotZero(t, v1)
+
+	// ensuring an existing config is a no-op
+	require.NoError(t, store.EnsureConfigExists(ctx, nn))
+	_, version, _ = store.snapshot("team-a")
+	require.Equal(t, v1, version)
+
+	server := config.MCPServer{Name: "a", URL: "http://a/mcp", Prefix: "a_"}
+	require.NoError(t, store.UpsertMCPServer(ctx, server, nn))
+	cfg, v2, _ := store.snapshot("team-a")
+	require.Greater(t, v2, v1)
+	require.Equal(t, []config.MCPServer{server}, cfg.Servers)
+
+	// an unchanged server does not produce a new version
+	require.NoError(t, store.UpsertMCPServer(ctx, server, nn))
+	_, version, _ = store.snapshot("team-a")
+	require.Equal(t, v2, version)
+
+	require.NoError(t, store.WriteVirtualServerConfig(ctx, []config.VirtualServerConfig{{Name: "vs", Tools: []string{"a_one"}}}, nn))
+	require.NoError(t, store.WriteCACertBundle(ctx, "ca", nn))
+	require.NoError(t, store.WriteGlobalGuardrails(ctx, &config.GuardrailsConfig{URL: "http://g"}, nn))
+	cfg, v3, _ := store.snapshot("team-a")
+	require.Equal(t, v2+3, v3)
+	require.Len(t, cfg.VirtualServers, 1)
+	require.Equal(t, "ca", cfg.GatewayCACertPEM)
+	require.Equal(t, "http://g", cfg.GlobalGuardrails.URL)
+
+	require.NoError(t, store.WriteEmptyConfig(ctx, nn))
+	cfg, v4, _ := store.snapshot("team-a")
+	require.Greater(t, v4, v3)
+	require.Equal(t, config.BrokerConfig{}, cfg)
+
+	require.NoError(t, store.DeleteConfig(ctx, nn))
+	_, version, _ = store.snapshot("team-a")
+	require.Zero(t, version)
+}
+
+func TestStore_RemoveMCPServerFromAllConfigs(t *testing.T) {
+	ctx := context.Background()
+	store := NewStore(testLogger(), nil)
+	server := config.MCPServer{Name: "shared", URL: "http://shared/mcp"}
+	other := config.MCPServer{Name: "other", URL: "http://other/mcp"}
+	require.NoError(t, store.UpsertMCPServer(ctx, server, config.NamespaceName("team-a")))
+	require.NoError(t, store.UpsertMCPServer(ctx, other, config.NamespaceName("team-a")))
+	require.NoError(t, store.UpsertMCPServer(ctx, server, config.NamespaceName("team-b")))
+	require.NoError(t, store.UpsertMCPServer(ctx, other, config.NamespaceName("team-c")))
+	_, teamCVersion, _ := store.snapshot("team-c")
+
+	require.NoError(t, store.RemoveMCPServer(ctx, "shared"))
+
+	cfg, _, _ := store.snapshot("team-a")
+	require.Equal(t, []config.MCPServer{other}, cfg.Servers)
+	cfg, _, _ = store.snapshot("team-b")
+	require.Empty(t, cfg.Servers)
+	// configs without the server keep their version
+	_, version, _ := store.snapshot("team-c")
+	require.Equal(t, teamCVersion, version)
+}
+
+func TestStore_SnapshotIsNotMutatedByLaterWrites(t *testing.T) {
+	ctx := context.Background()
+	nn := config.NamespaceName("team-a")
+	store := NewStore(testLogger(), nil)
+	require.NoError(t, store.UpsertMCPServer(ctx, config.MCPServer{Name: "a", URL: "http://a/mcp"}, nn))
+	cfg, _, changed := store.snapshot("team-a")
+
+	require.NoError(t, store.UpsertMCPServer(ctx, config.MCPServer{Name: "a", URL: "http://a2/mcp"}, nn))
+	require.Equal(t, "http://a/mcp", cfg.Servers[0].URL)
+	select {
+	case <-changed:
+	default:
+		t.Fatal("expected changed channel to be closed after a write")
+	}
+}
+
+func TestStore_Status(t *testing.T) {
+	ctx := context.Background()
+	var notified []string
+	store := NewStore(testLogger(), func(namespace string) {
+		notified = append(notified, namespace)
+	})
+	require.NoError(t, store.EnsureConfigExists(ctx, config.NamespaceName("team-a")))
+	require.Equal(t, []string{"team-a"}, notified)
+
+	status := store.Status("team-a")
+	require.NotZero(t, status.Version)
+	require.Zero(t, status.AppliedVersion)
+	require.Zero(t, status.Brokers)
+
+	b1 := store.connect("team-a", "broker-1")
+	b2 := store.connect("team-a", "broker-2")
+	store.ack(b1, 4, "")
+	store.ack(b2, 3, "")
+	status = store.Status("team-a")
+	require.Equal(t, 2, status.Brokers)
+	require.Equal(t, uint64(3), status.AppliedVersion)
+	require.Empty(t, status.Error)
+
+	// a nack keeps the applied version and reports the error
+	store.ack(b2, 4, "bad config")
+	status = store.Status("team-a")
+	require.Equal(t, uint64(3), status.AppliedVersion)
+	require.Equal(t, "bad config", status.Error)
+
+	store.disconnect(b2)
+	status = store.Status("team-a")
+	require.Equal(t, 1, status.Brokers)
+	require.Equal(t, uint64(4), status.AppliedVersion)
+	require.Empty(t, status.Error)
+	require.Equal(t, "team-a", notified[len(notified)-1])
+}
@@ -0,0 +1,121 @@
+// Package configstream streams broker configuration from the controller to
+// broker-router instances over gRPC.
+//
+// It is an alternative to the config Secret mounted into the broker. The
+// controller holds the desired BrokerConfig per namespace in a Store, and each
+// broker subscribes with a bidirectional stream. The first Update on a stream
+// is a full snapshot. Later updates only carry the servers and virtual servers
+// that changed since the last version the broker ACKed. The broker ACKs or
+// NACKs every version, and the Store records the applied version so it can be
+// surfaced on MCPGatewayExtension status.
+//
+// There is no protobuf definition for the service. Messages are encoded as JSON
+// using a codec registered under the "json" content subtype, which keeps the
+// wire types identical to the config file types.
+package configstream
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/encoding"
+
+	"github.com/Kuadrant/mcp-gateway/internal/config"
+)
+
+const (
+	// codecName is the gRPC content subtype used for stream messages
+	codecName = "json"
+	// serviceName is the fully qualified gRPC service name
+	serviceName = "mcp.kuadrant.io.configstream.v1.ConfigStream"
+	// streamMethod is the full method name of the config stream
+	streamMethod = "/" + serviceName + "/Stream"
+	// authorizationHeader carries the broker's bearer token
+	authorizationHeader = "authorization"
+)
+
+// Request is sent by a broker. The first Request on a stream subscribes to the
+// config for Namespace. Every later Request responds to an Update: an ACK when
+// Error is empty, a NACK otherwise.
+type Request struct {
+	// Namespace of the MCPGatewayExtension whose config the broker serves
+	Namespace string `json:"namespace,omitempty"`
+	// Node identifies the broker instance, typically its pod name
+	Node string `json:"node,omitempty"`
+	// Version is the config version being ACKed or NACKed. On subscribe it is
+	// the version the broker last applied, if any.
+	Version uint64 `json:"version,omitempty"`
+	// Error is set when the broker rejected Version
+	Error string `json:"error,omitempty"`
+}
+
+// Update is sent by the controller. Servers and VirtualServers are upserted by
+// name and the Removed lists are deleted by name. When Full is set the update
+// replaces the broker's config entirely. The CA bundle and global guardrails
+// are always sent in full.
+type Update struct {
+	Version               uint64                       `json:"version"`
+	Full                  bool                         `json:"full,omitempty"`
+	Servers               []config.MCPServer           `json:"servers,omitempty"`
+	RemovedServers        []string                     `json:"removedServers,omitempty"`
+	VirtualServers        []config.VirtualServerConfig `json:"virtualServers,omitempty"`
+	RemovedVirtualServers []string                     `json:"removedVirtualServers,omitempty"`
+	GatewayCACertPEM      string                       `json:"gatewayCACertPEM,omitempty"`
+	GlobalGuardrails      *config.GuardrailsConfig     `json:"globalGuardrails,omitempty"`
+}
+
+// ConfigStreamServer is the server API for the config stream service
+type ConfigStreamServer interface {
+	Stream(grpc.BidiStreamingServer[Request, Update]) error
+}
+
+// ServiceDesc is the grpc.ServiceDesc for the config stream service. Register
+// it with grpc.Server.RegisterService.
+var ServiceDesc = grpc.ServiceDesc{
+	ServiceName: serviceName,
+	HandlerType: (*ConfigStreamServer)(nil),
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Stream",
+			Handler:       streamHandler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
+	},
+	Metadata: "configstream",
+}
+
+func streamHandler(srv any, stream grpc.ServerStream) error {
+	return srv.(ConfigStreamServer).Stream(&grpc.GenericServerStream[Request, Update]{ServerStream: stream})
+}
+
+// Token returns the bearer token a broker presents to subscribe to the config
+// for namespace. It is an HMAC of the namespace keyed by the gateway session
+// signing key, which both the controller and the broker already hold.
+func Token(signingKey, namespace string) string {
+	mac := hmac.New(sha256.New, []byte(signingKey))
+	mac.Write([]byte(namespace))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// jsonCodec encodes stream messages as JSON
+type jsonCodec struct{}
+
+func (jsonCodec) Marshal(v any) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (jsonCodec) Unmarshal(data []byte, v any) error {
+	return json.Unmarshal(data, v)
+}
+
+func (jsonCodec) Name() string {
+	return codecName
+}
+
+func init() {
+	encoding.RegisterCodec(jsonCodec{})
+}
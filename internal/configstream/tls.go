@@ -0,0 +1,43 @@
+package configstream
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+
+	"google.golang.org/grpc/credentials"
+)
+
+// ServerCredentials returns the TLS credentials the config stream is served
+// with. The key pair is read on every handshake, so a renewed certificate is
+// picked up without a restart.
+func ServerCredentials(certFile, keyFile string) (credentials.TransportCredentials, error) {
+	// fail at startup rather than on the first broker handshake
+	if _, err := tls.LoadX509KeyPair(certFile, keyFile); err != nil {
+		return nil, err
+	}
+	return credentials.NewTLS(&tls.Config{
+		MinVersion: tls.VersionTLS12,
+		GetCertificate: func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+			cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+			if err != nil {
+				return nil, err
+			}
+			return &cert, nil
+		},
+	}), nil
+}
+
+// clientCredentials returns TLS credentials that verify the server
+// certificate against caPEM, or the system roots when caPEM is empty
+func clientCredentials(caPEM string) (credentials.TransportCredentials, error) {
+	cfg := &tls.Config{MinVersion: tls.VersionTLS12}
+	if caPEM != "" {
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM([]byte(caPEM)) {
+			return nil, errors.New("no certificates found in config stream CA bundle")
+		}
+		cfg.RootCAs = pool
+	}
+	return credentials.NewTLS(cfg), nil
+}
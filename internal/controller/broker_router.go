@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"net"
 	"slices"
+	"strconv"
 	"strings"
 
 	mcpv1 "github.com/Kuadrant/mcp-gateway/api/v1"
@@ -34,6 +35,22 @@ const (
 	brokerHTTPPort   = 8080
 	brokerGRPCPort   = 50051
 	brokerConfigPort = 8181
+
+	// paths served by the broker's authorization server metadata proxy
+	oauthAuthorizationServerPath = "/.well-known/oauth-authorization-server"
+	openIDConfigurationPath      = "/.well-known/openid-configuration"
+	clientRegistrationPath       = "/oauth/register"
+
+	// the broker reads upstream credential Secrets through the Kubernetes API
+	// with a projected service account token. automounting stays disabled so
+	// the token is the only API credential in the pod.
+	credentialTokenVolumeName     = "kube-api-access"
+	credentialTokenMountPath      = "/var/run/secrets/kubernetes.io/serviceaccount" //nolint:gosec // not a credential
+	credentialTokenExpirationSecs = int64(3600)
+
+	// configStreamCACertEnvVar carries the PEM CA bundle the broker verifies
+	// the config stream certificate against
+	configStreamCACertEnvVar = "CONFIG_STREAM_CA_CERT"
 )
 
 // managedCommandFlags are the flags the controller owns and reconciles.
@@ -48,10 +65,14 @@ var managedCommandFlags = []string{
 	"--mcp-broker-public-address",
 	"--mcp-gateway-private-host",
 	"--mcp-gateway-config",
+	"--config-stream-address",
+	"--config-stream-namespace",
+	"--config-stream-insecure",
 	"--mcp-check-interval",
 	"--mcp-gateway-public-host",
 	"--mcp-router-key",
 	"--enable-url-elicitation",
+	"--credential-provider",
 	"--log-level",
 	"--gateway-ca-cert", // no longer generated; see comment above
 }
@@ -63,6 +84,7 @@ var managedCommandFlags = []string{
 // volume and mount are stripped on the next reconcile.
 var managedVolumeNames = []string{
 	"config-volume",
+	credentialTokenVolumeName,
 	"gateway-ca", // no longer generated; stripped from manual pre-v1 setups
 }
 
@@ -71,12 +93,97 @@ var managedVolumeNames = []string{
 var managedEnvVarNames = []string{
 	"TRUSTED_HEADER_PUBLIC_KEY",
 	"CACHE_CONNECTION_STRING",
+	"CACHE_CA_CERT",
+	configStreamCACertEnvVar,
 	sessionSigningKeyEnvVar,
+	sessionVerificationKeysEnvVar,
 	"OAUTH_RESOURCE_NAME",
 	"OAUTH_RESOURCE",
 	"OAUTH_AUTHORIZATION_SERVERS",
 	"OAUTH_BEARER_METHODS_SUPPORTED",
 	"OAUTH_SCOPES_SUPPORTED",
+	"OAUTH_METADATA_PROXY",
+	"OAUTH_METADATA_CACHE_TTL",
+	"OAUTH_DCR_ENDPOINT",
+	"OAUTH_DCR_REDIRECT_URIS",
+	"OAUTH_DCR_GRANT_TYPES",
+	"OAUTH_DCR_INITIAL_ACCESS_TOKEN",
+	"OTEL_METRICS_EXPORTER",
+	"OTEL_EXPORTER_OTLP_METRICS_ENDPOINT",
+	"OTEL_EXPORTER_OTLP_METRICS_INSECURE",
+	"OTEL_EXPORTER_OTLP_METRICS_TEMPORALITY_PREFERENCE",
+	"OTEL_METRIC_EXPORT_INTERVAL",
+}
+
+// metricsExportEnvVars maps spec.metricsExport to the standard OpenTelemetry
+// env vars read by the broker-router. Unset fields take their CRD defaults.
+func metricsExportEnvVars(me *mcpv1.MetricsExport) []corev1.EnvVar {
+	exporters := me.Exporters
+	if len(exporters) == 0 {
+		exporters = []mcpv1.MetricsExporter{mcpv1.MetricsExporterPrometheus, mcpv1.MetricsExporterOTLP}
+	}
+	names := make([]string, 0, len(exporters))
+	for _, e := range exporters {
+		names = append(names, strings.ToLower(string(e)))
+	}
+	temporality := me.Temporality
+	if temporality == "" {
+		temporality = mcpv1.MetricsTemporalityCumulative
+	}
+	intervalSeconds := int32(60)
+	if me.IntervalSeconds != nil {
+		intervalSeconds = *me.IntervalSeconds
+	}
+	return []corev1.EnvVar{
+		{Name: "OTEL_METRICS_EXPORTER", Value: strings.Join(names, ",")},
+		{Name: "OTEL_EXPORTER_OTLP_METRICS_ENDPOINT", Value: me.Endpoint},
+		{Name: "OTEL_EXPORTER_OTLP_METRICS_INSECURE", Value: strconv.FormatBool(me.Insecure)},
+		{Name: "OTEL_EXPORTER_OTLP_METRICS_TEMPORALITY_PREFERENCE", Value: strings.ToLower(string(temporality))},
+		{Name: "OTEL_METRIC_EXPORT_INTERVAL", Value: strconv.Itoa(int(intervalSeconds) * 1000)},
+	}
+}
+
+// authorizationServerMetadataEnvVars enables the broker's authorization server
+// metadata proxy and, when configured, its dynamic client registration endpoint.
+// Unset fields take their CRD defaults.
+func authorizationServerMetadataEnvVars(asm *mcpv1.AuthorizationServerMetadata, publicHost string) []corev1.EnvVar {
+	cacheTTLSeconds := int32(300)
+	if asm.CacheTTLSeconds != nil {
+		cacheTTLSeconds = *asm.CacheTTLSeconds
+	}
+	envVars := []corev1.EnvVar{
+		{Name: "OAUTH_METADATA_PROXY", Value: "true"},
+		{Name: "OAUTH_METADATA_CACHE_TTL", Value: strconv.Itoa(int(cacheTTLSeconds))},
+	}
+	dcr := asm.DynamicClientRegistration
+	if dcr == nil {
+		return envVars
+	}
+	grantTypes := dcr.AllowedGrantTypes
+	if len(grantTypes) == 0 {
+		grantTypes = []string{"authorization_code", "refresh_token"}
+	}
+	envVars = append(envVars,
+		corev1.EnvVar{Name: "OAUTH_DCR_ENDPOINT", Value: "https://" + publicHost + clientRegistrationPath},
+		corev1.EnvVar{Name: "OAUTH_DCR_REDIRECT_URIS", Value: strings.Join(dcr.AllowedRedirectURIs, ",")},
+		corev1.EnvVar{Name: "OAUTH_DCR_GRANT_TYPES", Value: strings.Join(grantTypes, ",")},
+	)
+	if ref := dcr.InitialAccessTokenRef; ref != nil {
+		key := ref.Key
+		if key == "" {
+			key = "token"
+		}
+		envVars = append(envVars, corev1.EnvVar{
+			Name: "OAUTH_DCR_INITIAL_ACCESS_TOKEN",
+			ValueFrom: &corev1.EnvVarSource{
+				SecretKeyRef: &corev1.SecretKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{Name: ref.Name},
+					Key:                  key,
+				},
+			},
+		})
+	}
+	return envVars
 }
 
 // logLevelFlagValues maps spec.logLevel to the numeric value expected by the
@@ -101,8 +208,39 @@ func (r *MCPGatewayExtensionReconciler) buildBrokerRouterDeployment(mcpExt *mcpv
 	replicas := int32(1)
 
 	command := []string{"./mcp_gateway", fmt.Sprintf("--mcp-broker-public-address=0.0.0.0:%d", brokerHTTPPort),
-		"--mcp-gateway-private-host=" + internalHost,
-		"--mcp-gateway-config=/config/config.yaml"}
+		"--mcp-gateway-private-host=" + internalHost}
+	// with the config stream enabled the broker subscribes to the controller
+	// instead of reading the config Secret, so there is nothing to mount
+	var volumeMounts []corev1.VolumeMount
+	var volumes []corev1.Volume
+	if r.ConfigStreamAddress != "" {
+		command = append(command,
+			"--config-stream-address="+r.ConfigStreamAddress,
+			"--config-stream-namespace="+mcpExt.Namespace)
+		if r.ConfigStreamInsecure {
+			command = append(command, "--config-stream-insecure")
+		}
+	} else {
+		command = append(command, "--mcp-gateway-config=/config/config.yaml")
+		volumeMounts = []corev1.VolumeMount{
+			{
+				Name:      "config-volume",
+				MountPath: "/config",
+				ReadOnly:  true,
+			},
+		}
+		volumes = []corev1.Volume{
+			{
+				Name: "config-volume",
+				VolumeSource: corev1.VolumeSource{
+					Secret: &corev1.SecretVolumeSource{
+						SecretName:  "mcp-gateway-config",
+						DefaultMode: ptr.To(int32(420)), // 0644 octal
+					},
+				},
+			},
+		}
+	}
 	// only override the binary's default (60s) when explicitly set in spec
 	if mcpExt.Spec.BackendPingIntervalSeconds != nil {
 		command = append(command, fmt.Sprintf("--mcp-check-interval=%d", *mcpExt.Spec.BackendPingIntervalSeconds))
@@ -112,6 +250,13 @@ func (r *MCPGatewayExtensionReconciler) buildBrokerRouterDeployment(mcpExt *mcpv
 	if urlElicitationEnabled {
 		command = append(command, "--enable-url-elicitation")
 	}
+	command = append(command, "--credential-provider=kubernetes")
+	volumeMounts = append(volumeMounts, corev1.VolumeMount{
+		Name:      credentialTokenVolumeName,
+		MountPath: credentialTokenMountPath,
+		ReadOnly:  true,
+	})
+	volumes = append(volumes, credentialTokenVolume())
 	if v, ok := logLevelFlagValues[mcpExt.Spec.LogLevel]; ok {
 		command = append(command, "--log-level="+v)
 	} else if r.BrokerRouterLogLevel != "" {
@@ -130,6 +275,19 @@ func (r *MCPGatewayExtensionReconciler) buildBrokerRouterDeployment(mcpExt *mcpv
 				},
 			},
 		},
+		{
+			Name: sessionVerificationKeysEnvVar,
+			ValueFrom: &corev1.EnvVarSource{
+				SecretKeyRef: &corev1.SecretKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{
+						Name: sessionSigningKeySecretName,
+					},
+					Key: sessionVerificationKeysDataKey,
+					// absent until the key is first rotated
+					Optional: ptr.To(true),
+				},
+			},
+		},
 	}
 	if mcpExt.Spec.TrustedHeadersKey != nil {
 		envVars = append(envVars, corev1.EnvVar{
@@ -156,6 +314,23 @@ func (r *MCPGatewayExtensionReconciler) buildBrokerRouterDeployment(mcpExt *mcpv
 				},
 			},
 		})
+		// rediss:// session stores trust the CA bundle, read at startup
+		// because the store is connected before config is loaded
+		if ref := mcpExt.Spec.CACertBundleRef; ref != nil {
+			key := ref.Key
+			if key == "" {
+				key = "ca.crt"
+			}
+			envVars = append(envVars, corev1.EnvVar{
+				Name: "CACHE_CA_CERT",
+				ValueFrom: &corev1.EnvVarSource{
+					SecretKeyRef: &corev1.SecretKeySelector{
+						LocalObjectReference: corev1.LocalObjectReference{Name: ref.Name},
+						Key:                  key,
+					},
+				},
+			})
+		}
 	}
 	if opr := mcpExt.Spec.OAuthProtectedResource; opr != nil {
 		resourceName := "MCP Server"
@@ -181,6 +356,15 @@ func (r *MCPGatewayExtensionReconciler) buildBrokerRouterDeployment(mcpExt *mcpv
 			corev1.EnvVar{Name: "OAUTH_BEARER_METHODS_SUPPORTED", Value: strings.Join(bearerMethods, ",")},
 			corev1.EnvVar{Name: "OAUTH_SCOPES_SUPPORTED", Value: strings.Join(scopes, ",")},
 		)
+		if opr.AuthorizationServerMetadata != nil {
+			envVars = append(envVars, authorizationServerMetadataEnvVars(opr.AuthorizationServerMetadata, publicHost)...)
+		}
+	}
+	if mcpExt.Spec.MetricsExport != nil {
+		envVars = append(envVars, metricsExportEnvVars(mcpExt.Spec.MetricsExport)...)
+	}
+	if r.ConfigStreamAddress != "" && r.ConfigStreamCACert != "" {
+		envVars = append(envVars, corev1.EnvVar{Name: configStreamCACertEnvVar, Value: r.ConfigStreamCACert})
 	}
 
 	return &appsv1.Deployment{
@@ -225,13 +409,7 @@ func (r *MCPGatewayExtensionReconciler) buildBrokerRouterDeployment(mcpExt *mcpv
 									Protocol:      corev1.ProtocolTCP,
 								},
 							},
-							VolumeMounts: []corev1.VolumeMount{
-								{
-									Name:      "config-volume",
-									MountPath: "/config",
-									ReadOnly:  true,
-								},
-							},
+							VolumeMounts: volumeMounts,
 							ReadinessProbe: &corev1.Probe{
 								ProbeHandler: corev1.ProbeHandler{
 									HTTPGet: &corev1.HTTPGetAction{
@@ -248,15 +426,33 @@ func (r *MCPGatewayExtensionReconciler) buildBrokerRouterDeployment(mcpExt *mcpv
 							},
 						},
 					},
-					Volumes: []corev1.Volume{
-						{
-							Name: "config-volume",
-							VolumeSource: corev1.VolumeSource{
-								Secret: &corev1.SecretVolumeSource{
-									SecretName:  "mcp-gateway-config",
-									DefaultMode: ptr.To(int32(420)), // 0644 octal
-								},
-							},
+					Volumes: volumes,
+				},
+			},
+		},
+	}
+}
+
+// credentialTokenVolume projects the service account token and cluster CA to
+// the path in-cluster clients read them from. every field the API server
+// would default is set so the volume compares equal once created.
+func credentialTokenVolume() corev1.Volume {
+	return corev1.Volume{
+		Name: credentialTokenVolumeName,
+		VolumeSource: corev1.VolumeSource{
+			Projected: &corev1.ProjectedVolumeSource{
+				DefaultMode: ptr.To(int32(420)), // 0644 octal
+				Sources: []corev1.VolumeProjection{
+					{
+						ServiceAccountToken: &corev1.ServiceAccountTokenProjection{
+							Path:              "token",
+							ExpirationSeconds: ptr.To(credentialTokenExpirationSecs),
+						},
+					},
+					{
+						ConfigMap: &corev1.ConfigMapProjection{
+							LocalObjectReference: corev1.LocalObjectReference{Name: "kube-root-ca.crt"},
+							Items:                []corev1.KeyToPath{{Key: "ca.crt", Path: "ca.crt"}},
 						},
 					},
 				},
@@ -398,6 +594,11 @@ func (r *MCPGatewayExtensionReconciler) reconcileBrokerRouter(ctx context.Contex
 
 	// reconcile deployment
 	deployment := r.buildBrokerRouterDeployment(mcpExt, publicHost, internalHost)
+	keyIDs, err := r.sessionSigningKeyIDs(ctx, mcpExt.Namespace)
+	if err != nil {
+		return false, err
+	}
+	deployment.Spec.Template.Annotations = map[string]string{signingKeyIDsAnnotation: keyIDs}
 	if err := controllerutil.SetControllerReference(mcpExt, deployment, r.Scheme); err != nil {
 		return false, fmt.Errorf("failed to set controller reference on deployment: %w", err)
 	}
@@ -427,6 +628,10 @@ func (r *MCPGatewayExtensionReconciler) reconcileBrokerRouter(ctx context.Contex
 		existingContainer.ReadinessProbe = desiredContainer.ReadinessProbe
 		existingDeployment.Spec.Template.Spec.Containers[0] = existingContainer
 		existingDeployment.Spec.Template.Spec.Volumes = mergeVolumes(deployment.Spec.Template.Spec.Volumes, existingDeployment.Spec.Template.Spec.Volumes)
+		if existingDeployment.Spec.Template.Annotations == nil {
+			existingDeployment.Spec.Template.Annotations = map[string]string{}
+		}
+		existingDeployment.Spec.Template.Annotations[signingKeyIDsAnnotation] = deployment.Spec.Template.Annotations[signingKeyIDsAnnotation]
 		if err := r.Update(ctx, existingDeployment); err != nil {
 			return false, fmt.Errorf("failed to update deployment: %w", err)
 		}
@@ -531,6 +736,10 @@ func deploymentNeedsUpdate(desired, existing *appsv1.Deployment) (bool, string)
 	desiredContainer := desired.Spec.Template.Spec.Containers[0]
 	existingContainer := existing.Spec.Template.Spec.Containers[0]
 
+	// the signing key ring is read from env, so pods restart when it changes
+	if desired.Spec.Template.Annotations[signingKeyIDsAnnotation] != existing.Spec.Template.Annotations[signingKeyIDsAnnotation] {
+		return true, "session signing keys changed"
+	}
 	if desiredContainer.Image != existingContainer.Image {
 		return true, fmt.Sprintf("image changed: %q -> %q", existingContainer.Image, desiredContainer.Image)
 	}
@@ -666,6 +875,27 @@ func mergeVolumeMounts(desired, existing []corev1.VolumeMount) []corev1.VolumeMo
 	return slices.Concat(desired, userMounts)
 }
 
+// oauthMetadataRouteRule routes the authorization server metadata proxy and,
+// when enabled, the dynamic client registration endpoint to the broker
+func oauthMetadataRouteRule(asm *mcpv1.AuthorizationServerMetadata, backendRefs []gatewayv1.HTTPBackendRef) gatewayv1.HTTPRouteRule {
+	prefix := gatewayv1.PathMatchPathPrefix
+	exact := gatewayv1.PathMatchExact
+	matches := []gatewayv1.HTTPRouteMatch{
+		{Path: &gatewayv1.HTTPPathMatch{Type: &prefix, Value: ptr.To(oauthAuthorizationServerPath)}},
+		{Path: &gatewayv1.HTTPPathMatch{Type: &prefix, Value: ptr.To(openIDConfigurationPath)}},
+	}
+	if asm.DynamicClientRegistration != nil {
+		matches = append(matches, gatewayv1.HTTPRouteMatch{
+			Path: &gatewayv1.HTTPPathMatch{Type: &exact, Value: ptr.To(clientRegistrationPath)},
+		})
+	}
+	return gatewayv1.HTTPRouteRule{
+		Name:        ptr.To(gatewayv1.SectionName("oauth")),
+		Matches:     matches,
+		BackendRefs: backendRefs,
+	}
+}
+
 func (r *MCPGatewayExtensionReconciler) buildGatewayHTTPRoute(mcpExt *mcpv1.MCPGatewayExtension, publicHost string) *gatewayv1.HTTPRoute {
 	labels := brokerRouterLabels()
 	pathType := gatewayv1.PathMatchPathPrefix
@@ -741,6 +971,9 @@ func (r *MCPGatewayExtensionReconciler) buildGatewayHTTPRoute(mcpExt *mcpv1.MCPG
 			BackendRefs: backendRefs,
 		},
 	}
+	if opr := mcpExt.Spec.OAuthProtectedResource; opr != nil && opr.AuthorizationServerMetadata != nil {
+		rules = append(rules, oauthMetadataRouteRule(opr.AuthorizationServerMetadata, backendRefs))
+	}
 
 	return &gatewayv1.HTTPRoute{
 		ObjectMeta: metav1.ObjectMeta{
@@ -51,7 +51,7 @@ func (r *MCPGatewayExtensionReconciler) reconcileCACertBundle(ctx context.Contex
 		return newValidationError(mcpv1.ConditionReasonSecretInvalid,
 			fmt.Sprintf("CA bundle data in secret %s exceeds maximum size (%d bytes)", ref.Name, maxCACertBundleSize))
 	}
-	if err := validateCACertPEM(val); err != nil {
+	if err := config.ValidateCACertPEM(val); err != nil {
 		return newValidationError(mcpv1.ConditionReasonSecretInvalid,
 			fmt.Sprintf("CA bundle in secret %s is invalid: %v", ref.Name, err))
 	}
@@ -0,0 +1,170 @@
+package controller
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"slices"
+
+	mcpv1 "github.com/Kuadrant/mcp-gateway/api/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+const credentialAccessPrefix = "mcp-gateway-credentials-" //nolint:gosec // not a credential
+
+// credentialAccessName names the Role and RoleBinding that let broker-routers
+// read a registration's credential Secret. Names too long for the API are
+// shortened with a hash suffix so they stay unique.
+func credentialAccessName(mcpsr *mcpv1.MCPServerRegistration) string {
+	name := credentialAccessPrefix + mcpsr.Name
+	if len(name) <= validation.DNS1123SubdomainMaxLength {
+		return name
+	}
+	sum := sha256.Sum256([]byte(mcpsr.Name))
+	suffix := "-" + hex.EncodeToString(sum[:])[:10]
+	return name[:validation.DNS1123SubdomainMaxLength-len(suffix)] + suffix
+}
+
+// credentialSecretNames returns the Secrets the broker resolves at use time
+// for a registration: its credential and its upstream OAuth client secret
+func credentialSecretNames(spec mcpv1.MCPServerRegistrationSpec) []string {
+	var names []string
+	if spec.CredentialRef != nil {
+		names = append(names, spec.CredentialRef.Name)
+	}
+	if elicit := spec.TokenURLElicitation; elicit != nil && elicit.OAuth != nil && elicit.OAuth.ClientSecretRef != nil {
+		names = append(names, elicit.OAuth.ClientSecretRef.Name)
+	}
+	slices.Sort(names)
+	return slices.Compact(names)
+}
+
+// buildCredentialAccessRole grants get on the registration's credential
+// Secrets and nothing else
+func buildCredentialAccessRole(mcpsr *mcpv1.MCPServerRegistration) *rbacv1.Role {
+	return &rbacv1.Role{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      credentialAccessName(mcpsr),
+			Namespace: mcpsr.Namespace,
+			Labels:    map[string]string{labelManagedBy: labelManagedByValue},
+		},
+		Rules: []rbacv1.PolicyRule{
+			{
+				APIGroups:     []string{""},
+				Resources:     []string{"secrets"},
+				ResourceNames: credentialSecretNames(mcpsr.Spec),
+				Verbs:         []string{"get"},
+			},
+		},
+	}
+}
+
+// buildCredentialAccessRoleBinding binds the credential Role to the
+// broker-router service account of every gateway the registration is
+// configured on
+func buildCredentialAccessRoleBinding(mcpsr *mcpv1.MCPServerRegistration, brokerNamespaces []string) *rbacv1.RoleBinding {
+	namespaces := slices.Clone(brokerNamespaces)
+	slices.Sort(namespaces)
+	namespaces = slices.Compact(namespaces)
+	subjects := make([]rbacv1.Subject, 0, len(namespaces))
+	for _, ns := range namespaces {
+		subjects = append(subjects, rbacv1.Subject{
+			Kind:      rbacv1.ServiceAccountKind,
+			Name:      brokerRouterName,
+			Namespace: ns,
+		})
+	}
+	return &rbacv1.RoleBinding{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      credentialAccessName(mcpsr),
+			Namespace: mcpsr.Namespace,
+			Labels:    map[string]string{labelManagedBy: labelManagedByValue},
+		},
+		RoleRef: rbacv1.RoleRef{
+			APIGroup: rbacv1.GroupName,
+			Kind:     "Role",
+			Name:     credentialAccessName(mcpsr),
+		},
+		Subjects: subjects,
+	}
+}
+
+// reconcileCredentialAccess lets the broker-routers in brokerNamespaces read
+// the registration's credential Secrets, which the broker resolves at use time
+// instead of receiving them in its config. Without a credentialRef or OAuth
+// client secret any access granted earlier is revoked. Both objects are owned by the registration so
+// they are garbage collected with it.
+func (r *MCPReconciler) reconcileCredentialAccess(ctx context.Context, mcpsr *mcpv1.MCPServerRegistration, brokerNamespaces []string) error {
+	if len(credentialSecretNames(mcpsr.Spec)) == 0 {
+		return r.deleteCredentialAccess(ctx, mcpsr)
+	}
+
+	role := buildCredentialAccessRole(mcpsr)
+	existingRole := &rbacv1.Role{}
+	if err := r.reconcileCredentialAccessObject(ctx, mcpsr, role, existingRole, func() bool {
+		if equality.Semantic.DeepEqual(existingRole.Rules, role.Rules) {
+			return false
+		}
+		existingRole.Rules = role.Rules
+		return true
+	}); err != nil {
+		return fmt.Errorf("failed to reconcile credential role: %w", err)
+	}
+
+	binding := buildCredentialAccessRoleBinding(mcpsr, brokerNamespaces)
+	existingBinding := &rbacv1.RoleBinding{}
+	if err := r.reconcileCredentialAccessObject(ctx, mcpsr, binding, existingBinding, func() bool {
+		// roleRef is immutable and always the same, so only subjects change
+		if equality.Semantic.DeepEqual(existingBinding.Subjects, binding.Subjects) {
+			return false
+		}
+		existingBinding.Subjects = binding.Subjects
+		return true
+	}); err != nil {
+		return fmt.Errorf("failed to reconcile credential role binding: %w", err)
+	}
+	return nil
+}
+
+// reconcileCredentialAccessObject creates desired, or reads it into existing
+// and updates it when mutate reports a change. reads are uncached so the
+// controller does not have to watch every Role and RoleBinding.
+func (r *MCPReconciler) reconcileCredentialAccessObject(ctx context.Context, mcpsr *mcpv1.MCPServerRegistration, desired, existing client.Object, mutate func() bool) error {
+	err := r.DirectAPIReader.Get(ctx, client.ObjectKeyFromObject(desired), existing)
+	if apierrors.IsNotFound(err) {
+		if err := controllerutil.SetControllerReference(mcpsr, desired, r.Scheme); err != nil {
+			return err
+		}
+		if err := r.Create(ctx, desired); err != nil && !apierrors.IsAlreadyExists(err) {
+			return err
+		}
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if !mutate() {
+		return nil
+	}
+	return r.Update(ctx, existing)
+}
+
+// deleteCredentialAccess removes the Role and RoleBinding for a registration
+// that no longer references a credential
+func (r *MCPReconciler) deleteCredentialAccess(ctx context.Context, mcpsr *mcpv1.MCPServerRegistration) error {
+	meta := metav1.ObjectMeta{Name: credentialAccessName(mcpsr), Namespace: mcpsr.Namespace}
+	if err := r.Delete(ctx, &rbacv1.RoleBinding{ObjectMeta: meta}); client.IgnoreNotFound(err) != nil {
+		return fmt.Errorf("failed to delete credential role binding: %w", err)
+	}
+	if err := r.Delete(ctx, &rbacv1.Role{ObjectMeta: meta}); client.IgnoreNotFound(err) != nil {
+		return fmt.Errorf("failed to delete credential role: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,167 @@
+package controller
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	mcpv1 "github.com/Kuadrant/mcp-gateway/api/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func testCredentialAccessReconciler() *MCPReconciler {
+	scheme := runtime.NewScheme()
+	_ = rbacv1.AddToScheme(scheme)
+	_ = mcpv1.AddToScheme(scheme)
+	fakeClient := fake.NewClientBuilder().WithScheme(scheme).Build()
+	return &MCPReconciler{Client: fakeClient, Scheme: scheme, DirectAPIReader: fakeClient}
+}
+
+func TestReconcileCredentialAccess(t *testing.T) {
+	ctx := context.Background()
+	r := testCredentialAccessReconciler()
+	mcpsr := &mcpv1.MCPServerRegistration{
+		ObjectMeta: metav1.ObjectMeta{Name: "weather", Namespace: "team-a", UID: types.UID("weather-uid")},
+		Spec: mcpv1.MCPServerRegistrationSpec{
+			CredentialRef: &mcpv1.SecretReference{Name: "weather-creds", Key: "token"},
+		},
+	}
+	key := client.ObjectKey{Name: "mcp-gateway-credentials-weather", Namespace: "team-a"}
+
+	if err := r.reconcileCredentialAccess(ctx, mcpsr, []string{"gw-b", "gw-a", "gw-b"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	role := &rbacv1.Role{}
+	if err := r.Get(ctx, key, role); err != nil {
+		t.Fatalf("expected role to be created: %v", err)
+	}
+	if len(role.Rules) != 1 || role.Rules[0].ResourceNames[0] != "weather-creds" || role.Rules[0].Verbs[0] != "get" {
+		t.Errorf("expected get on the credential secret only, got %+v", role.Rules)
+	}
+	if len(role.OwnerReferences) != 1 || role.OwnerReferences[0].UID != mcpsr.UID {
+		t.Errorf("expected role to be owned by the registration, got %+v", role.OwnerReferences)
+	}
+	binding := &rbacv1.RoleBinding{}
+	if err := r.Get(ctx, key, binding); err != nil {
+		t.Fatalf("expected role binding to be created: %v", err)
+	}
+	if binding.RoleRef.Name != key.Name {
+		t.Errorf("expected binding to reference %s, got %s", key.Name, binding.RoleRef.Name)
+	}
+	wantSubjects := []rbacv1.Subject{
+		{Kind: rbacv1.ServiceAccountKind, Name: brokerRouterName, Namespace: "gw-a"},
+		{Kind: rbacv1.ServiceAccountKind, Name: brokerRouterName, Namespace: "gw-b"},
+	}
+	if len(binding.Subjects) != len(wantSubjects) || binding.Subjects[0] != wantSubjects[0] || binding.Subjects[1] != wantSubjects[1] {
+		t.Errorf("expected subjects %+v, got %+v", wantSubjects, binding.Subjects)
+	}
+
+	// moving the registration to another gateway and secret updates both objects
+	mcpsr.Spec.CredentialRef.Name = "weather-creds-v2"
+	if err := r.reconcileCredentialAccess(ctx, mcpsr, []string{"gw-c"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := r.Get(ctx, key, role); err != nil {
+		t.Fatal(err)
+	}
+	if role.Rules[0].ResourceNames[0] != "weather-creds-v2" {
+		t.Errorf("expected role to follow the credential secret, got %+v", role.Rules)
+	}
+	if err := r.Get(ctx, key, binding); err != nil {
+		t.Fatal(err)
+	}
+	if len(binding.Subjects) != 1 || binding.Subjects[0].Namespace != "gw-c" {
+		t.Errorf("expected binding to follow the gateway, got %+v", binding.Subjects)
+	}
+
+	// dropping the credential reference revokes access
+	mcpsr.Spec.CredentialRef = nil
+	if err := r.reconcileCredentialAccess(ctx, mcpsr, []string{"gw-c"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := r.Get(ctx, key, &rbacv1.Role{}); !apierrors.IsNotFound(err) {
+		t.Errorf("expected role to be deleted, got %v", err)
+	}
+	if err := r.Get(ctx, key, &rbacv1.RoleBinding{}); !apierrors.IsNotFound(err) {
+		t.Errorf("expected role binding to be deleted, got %v", err)
+	}
+
+	// and is a no-op when nothing was granted
+	if err := r.reconcileCredentialAccess(ctx, mcpsr, nil); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestReconcileCredentialAccess_OAuthClientSecret(t *testing.T) {
+	ctx := context.Background()
+	r := testCredentialAccessReconciler()
+	mcpsr := &mcpv1.MCPServerRegistration{
+		ObjectMeta: metav1.ObjectMeta{Name: "github", Namespace: "team-a", UID: types.UID("github-uid")},
+		Spec: mcpv1.MCPServerRegistrationSpec{
+			CredentialRef: &mcpv1.SecretReference{Name: "github-creds", Key: "token"},
+			TokenURLElicitation: &mcpv1.TokenURLElicitationConfig{OAuth: &mcpv1.UpstreamOAuthClient{
+				AuthorizationURL: "https://github.com/login/oauth/authorize",
+				TokenURL:         "https://github.com/login/oauth/access_token",
+				ClientID:         "gateway",
+				ClientSecretRef:  &mcpv1.SecretReference{Name: "github-oauth", Key: "client_secret"},
+			}},
+		},
+	}
+	key := client.ObjectKey{Name: "mcp-gateway-credentials-github", Namespace: "team-a"}
+
+	if err := r.reconcileCredentialAccess(ctx, mcpsr, []string{"gw-a"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	role := &rbacv1.Role{}
+	if err := r.Get(ctx, key, role); err != nil {
+		t.Fatalf("expected role to be created: %v", err)
+	}
+	if got := role.Rules[0].ResourceNames; len(got) != 2 || got[0] != "github-creds" || got[1] != "github-oauth" {
+		t.Errorf("expected get on the credential and client secrets, got %v", got)
+	}
+
+	// the client secret alone still needs access
+	mcpsr.Spec.CredentialRef = nil
+	if err := r.reconcileCredentialAccess(ctx, mcpsr, []string{"gw-a"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := r.Get(ctx, key, role); err != nil {
+		t.Fatal(err)
+	}
+	if got := role.Rules[0].ResourceNames; len(got) != 1 || got[0] != "github-oauth" {
+		t.Errorf("expected get on the client secret only, got %v", got)
+	}
+
+	// a public client needs none
+	mcpsr.Spec.TokenURLElicitation.OAuth.ClientSecretRef = nil
+	if err := r.reconcileCredentialAccess(ctx, mcpsr, []string{"gw-a"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := r.Get(ctx, key, &rbacv1.Role{}); !apierrors.IsNotFound(err) {
+		t.Errorf("expected role to be deleted, got %v", err)
+	}
+}
+
+func TestCredentialAccessName(t *testing.T) {
+	short := &mcpv1.MCPServerRegistration{ObjectMeta: metav1.ObjectMeta{Name: "weather"}}
+	if got := credentialAccessName(short); got != "mcp-gateway-credentials-weather" {
+		t.Errorf("credentialAccessName() = %q", got)
+	}
+
+	long := &mcpv1.MCPServerRegistration{ObjectMeta: metav1.ObjectMeta{Name: strings.Repeat("a", 250)}}
+	other := &mcpv1.MCPServerRegistration{ObjectMeta: metav1.ObjectMeta{Name: strings.Repeat("a", 249) + "b"}}
+	got := credentialAccessName(long)
+	if len(got) > validation.DNS1123SubdomainMaxLength {
+		t.Errorf("expected name within %d characters, got %d", validation.DNS1123SubdomainMaxLength, len(got))
+	}
+	if got == credentialAccessName(other) {
+		t.Errorf("expected distinct names for distinct long registrations, got %q", got)
+	}
+}
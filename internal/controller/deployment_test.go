@@ -219,6 +219,20 @@ func TestDeploymentNeedsUpdate(t *testing.T) {
 			},
 			expected: true,
 		},
+		{
+			name: "signing keys changed",
+			modify: func(d *appsv1.Deployment) {
+				d.Spec.Template.Annotations = map[string]string{signingKeyIDsAnnotation: "0123456789abcdef"}
+			},
+			expected: true,
+		},
+		{
+			name: "unrelated pod annotation ignored",
+			modify: func(d *appsv1.Deployment) {
+				d.Spec.Template.Annotations = map[string]string{"kubectl.kubernetes.io/restartedAt": "2026-10-19T00:00:00Z"}
+			},
+			expected: false,
+		},
 	}
 
 	for _, tt := range tests {
@@ -812,11 +826,14 @@ func TestBuildBrokerRouterDeployment_TrustedHeadersKey(t *testing.T) {
 
 			// JWT_SESSION_SIGNING_KEY should always be present
 			var jwtEnv *corev1.EnvVar
+			var verificationEnv *corev1.EnvVar
 			var trustedEnv *corev1.EnvVar
 			for i := range container.Env {
 				switch container.Env[i].Name {
 				case "GATEWAY_SIGNING_KEY":
 					jwtEnv = &container.Env[i]
+				case "GATEWAY_VERIFICATION_KEYS":
+					verificationEnv = &container.Env[i]
 				case "TRUSTED_HEADER_PUBLIC_KEY":
 					trustedEnv = &container.Env[i]
 				}
@@ -834,6 +851,12 @@ func TestBuildBrokerRouterDeployment_TrustedHeadersKey(t *testing.T) {
 			if jwtEnv.ValueFrom.SecretKeyRef.Key != sessionSigningKeyDataKey {
 				t.Errorf("expected gateway signing key secret key %q, got %q", sessionSigningKeyDataKey, jwtEnv.ValueFrom.SecretKeyRef.Key)
 			}
+			if verificationEnv == nil || verificationEnv.ValueFrom == nil || verificationEnv.ValueFrom.SecretKeyRef == nil {
+				t.Fatal("expected GATEWAY_VERIFICATION_KEYS env var with secretKeyRef")
+			}
+			if ref := verificationEnv.ValueFrom.SecretKeyRef; ref.Key != sessionVerificationKeysDataKey || ref.Optional == nil || !*ref.Optional {
+				t.Errorf("expected optional reference to %q, got %+v", sessionVerificationKeysDataKey, ref)
+			}
 
 			if !tt.wantTrustedEnv {
 				if trustedEnv != nil {
@@ -858,6 +881,149 @@ func TestBuildBrokerRouterDeployment_TrustedHeadersKey(t *testing.T) {
 	}
 }
 
+func TestBuildBrokerRouterDeployment_SessionStoreCACert(t *testing.T) {
+	tests := []struct {
+		name         string
+		sessionStore *mcpv1.SessionStore
+		bundleRef    *mcpv1.CACertBundleReference
+		wantKey      string
+	}{
+		{
+			name:      "no session store",
+			bundleRef: &mcpv1.CACertBundleReference{Name: "ca-bundle"},
+		},
+		{
+			name:         "session store without bundle",
+			sessionStore: &mcpv1.SessionStore{SecretName: "redis"},
+		},
+		{
+			name:         "session store with default bundle key",
+			sessionStore: &mcpv1.SessionStore{SecretName: "redis"},
+			bundleRef:    &mcpv1.CACertBundleReference{Name: "ca-bundle"},
+			wantKey:      "ca.crt",
+		},
+		{
+			name:         "session store with custom bundle key",
+			sessionStore: &mcpv1.SessionStore{SecretName: "redis"},
+			bundleRef:    &mcpv1.CACertBundleReference{Name: "ca-bundle", Key: "bundle.pem"},
+			wantKey:      "bundle.pem",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &MCPGatewayExtensionReconciler{BrokerRouterImage: "test-image:v1"}
+			mcpExt := &mcpv1.MCPGatewayExtension{
+				ObjectMeta: metav1.ObjectMeta{Name: "test-ext", Namespace: "test-ns"},
+				Spec: mcpv1.MCPGatewayExtensionSpec{
+					SessionStore:    tt.sessionStore,
+					CACertBundleRef: tt.bundleRef,
+					TargetRef: mcpv1.MCPGatewayExtensionTargetReference{
+						Name:      "my-gateway",
+						Namespace: "gateway-system",
+					},
+				},
+			}
+
+			deployment := r.buildBrokerRouterDeployment(mcpExt, "mcp.example.com", mcpExt.InternalHost(8080, "istio"))
+			var caEnv *corev1.EnvVar
+			for i, env := range deployment.Spec.Template.Spec.Containers[0].Env {
+				if env.Name == "CACHE_CA_CERT" {
+					caEnv = &deployment.Spec.Template.Spec.Containers[0].Env[i]
+				}
+			}
+
+			if tt.wantKey == "" {
+				if caEnv != nil {
+					t.Errorf("expected no CACHE_CA_CERT env var, got %+v", caEnv)
+				}
+				return
+			}
+			if caEnv == nil || caEnv.ValueFrom == nil || caEnv.ValueFrom.SecretKeyRef == nil {
+				t.Fatal("expected CACHE_CA_CERT env var with secretKeyRef")
+			}
+			if ref := caEnv.ValueFrom.SecretKeyRef; ref.Name != "ca-bundle" || ref.Key != tt.wantKey {
+				t.Errorf("expected reference to ca-bundle/%s, got %s/%s", tt.wantKey, ref.Name, ref.Key)
+			}
+		})
+	}
+}
+
+func TestBuildBrokerRouterDeployment_MetricsExport(t *testing.T) {
+	tests := []struct {
+		name          string
+		metricsExport *mcpv1.MetricsExport
+		want          map[string]string
+	}{
+		{
+			name: "not set",
+		},
+		{
+			name:          "defaults",
+			metricsExport: &mcpv1.MetricsExport{Endpoint: "rpc://collector:4317"},
+			want: map[string]string{
+				"OTEL_METRICS_EXPORTER":                             "prometheus,otlp",
+				"OTEL_EXPORTER_OTLP_METRICS_ENDPOINT":               "rpc://collector:4317",
+				"OTEL_EXPORTER_OTLP_METRICS_INSECURE":               "false",
+				"OTEL_EXPORTER_OTLP_METRICS_TEMPORALITY_PREFERENCE": "cumulative",
+				"OTEL_METRIC_EXPORT_INTERVAL":                       "60000",
+			},
+		},
+		{
+			name: "otlp only",
+			metricsExport: &mcpv1.MetricsExport{
+				Exporters:       []mcpv1.MetricsExporter{mcpv1.MetricsExporterOTLP},
+				Endpoint:        "rpc://collector:4317",
+				Insecure:        true,
+				Temporality:     mcpv1.MetricsTemporalityLowMemory,
+				IntervalSeconds: ptr.To(int32(15)),
+			},
+			want: map[string]string{
+				"OTEL_METRICS_EXPORTER":                             "otlp",
+				"OTEL_EXPORTER_OTLP_METRICS_ENDPOINT":               "rpc://collector:4317",
+				"OTEL_EXPORTER_OTLP_METRICS_INSECURE":               "true",
+				"OTEL_EXPORTER_OTLP_METRICS_TEMPORALITY_PREFERENCE": "lowmemory",
+				"OTEL_METRIC_EXPORT_INTERVAL":                       "15000",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &MCPGatewayExtensionReconciler{BrokerRouterImage: "test-image:v1"}
+			mcpExt := &mcpv1.MCPGatewayExtension{
+				ObjectMeta: metav1.ObjectMeta{Name: "test-ext", Namespace: "test-ns"},
+				Spec: mcpv1.MCPGatewayExtensionSpec{
+					MetricsExport: tt.metricsExport,
+					TargetRef: mcpv1.MCPGatewayExtensionTargetReference{
+						Name:      "my-gateway",
+						Namespace: "gateway-system",
+					},
+				},
+			}
+
+			deployment := r.buildBrokerRouterDeployment(mcpExt, "mcp.example.com", mcpExt.InternalHost(8080, "istio"))
+			got := map[string]string{}
+			for _, env := range deployment.Spec.Template.Spec.Containers[0].Env {
+				if strings.HasPrefix(env.Name, "OTEL_") {
+					got[env.Name] = env.Value
+					if !slices.Contains(managedEnvVarNames, env.Name) {
+						t.Errorf("env var %s is not managed", env.Name)
+					}
+				}
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("expected OTEL env %v, got %v", tt.want, got)
+			}
+			for name, value := range tt.want {
+				if got[name] != value {
+					t.Errorf("%s = %q, want %q", name, got[name], value)
+				}
+			}
+		})
+	}
+}
+
 func TestServiceAccountNeedsUpdate(t *testing.T) {
 	trueVal := true
 	falseVal := false
@@ -2053,6 +2219,57 @@ func TestHTTPRouteNeedsUpdate(t *testing.T) {
 	}
 }
 
+func TestBuildGatewayHTTPRoute_OAuthMetadata(t *testing.T) {
+	reconciler := &MCPGatewayExtensionReconciler{}
+	mcpExt := &mcpv1.MCPGatewayExtension{
+		ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "test-ns"},
+		Spec: mcpv1.MCPGatewayExtensionSpec{
+			TargetRef: mcpv1.MCPGatewayExtensionTargetReference{
+				Name:        "my-gateway",
+				Namespace:   "gateway-ns",
+				SectionName: "mcp",
+			},
+			OAuthProtectedResource: &mcpv1.OAuthProtectedResource{
+				AuthorizationServers: []string{"https://keycloak.example.com/realms/mcp"},
+			},
+		},
+	}
+
+	matchedPaths := func(route *gatewayv1.HTTPRoute) []string {
+		for _, rule := range route.Spec.Rules {
+			if rule.Name == nil || *rule.Name != "oauth" {
+				continue
+			}
+			var paths []string
+			for _, m := range rule.Matches {
+				paths = append(paths, *m.Path.Value)
+			}
+			return paths
+		}
+		return nil
+	}
+
+	if paths := matchedPaths(reconciler.buildGatewayHTTPRoute(mcpExt, "mcp.example.com")); paths != nil {
+		t.Errorf("expected no oauth rule without authorizationServerMetadata, got %v", paths)
+	}
+
+	mcpExt.Spec.OAuthProtectedResource.AuthorizationServerMetadata = &mcpv1.AuthorizationServerMetadata{}
+	paths := matchedPaths(reconciler.buildGatewayHTTPRoute(mcpExt, "mcp.example.com"))
+	want := []string{"/.well-known/oauth-authorization-server", "/.well-known/openid-configuration"}
+	if !slices.Equal(paths, want) {
+		t.Errorf("oauth rule paths = %v, want %v", paths, want)
+	}
+
+	mcpExt.Spec.OAuthProtectedResource.AuthorizationServerMetadata.DynamicClientRegistration = &mcpv1.DynamicClientRegistration{
+		AllowedRedirectURIs: []string{"http://localhost:*"},
+	}
+	paths = matchedPaths(reconciler.buildGatewayHTTPRoute(mcpExt, "mcp.example.com"))
+	want = append(want, "/oauth/register")
+	if !slices.Equal(paths, want) {
+		t.Errorf("oauth rule paths = %v, want %v", paths, want)
+	}
+}
+
 func TestBuildTokensHTTPRoute(t *testing.T) {
 	reconciler := &MCPGatewayExtensionReconciler{}
 	mcpExt := &mcpv1.MCPGatewayExtension{
@@ -2349,6 +2566,68 @@ func TestBuildBrokerRouterDeployment_OAuthProtectedResource(t *testing.T) {
 		}
 	})
 
+	t.Run("authorization server metadata proxy sets its env vars", func(t *testing.T) {
+		mcpExt := base()
+		mcpExt.Spec.OAuthProtectedResource = &mcpv1.OAuthProtectedResource{
+			AuthorizationServers:        []string{"https://keycloak.example.com/realms/mcp"},
+			AuthorizationServerMetadata: &mcpv1.AuthorizationServerMetadata{},
+		}
+		dep := reconciler.buildBrokerRouterDeployment(mcpExt, "mcp.example.com", "internal:8080")
+		envMap := make(map[string]string)
+		for _, e := range dep.Spec.Template.Spec.Containers[0].Env {
+			envMap[e.Name] = e.Value
+		}
+		if envMap["OAUTH_METADATA_PROXY"] != "true" {
+			t.Errorf("OAUTH_METADATA_PROXY = %q, want %q", envMap["OAUTH_METADATA_PROXY"], "true")
+		}
+		if envMap["OAUTH_METADATA_CACHE_TTL"] != "300" {
+			t.Errorf("OAUTH_METADATA_CACHE_TTL = %q, want %q", envMap["OAUTH_METADATA_CACHE_TTL"], "300")
+		}
+		for _, name := range []string{"OAUTH_DCR_ENDPOINT", "OAUTH_DCR_REDIRECT_URIS", "OAUTH_DCR_GRANT_TYPES", "OAUTH_DCR_INITIAL_ACCESS_TOKEN"} {
+			if _, ok := envMap[name]; ok {
+				t.Errorf("unexpected env var %q without dynamicClientRegistration", name)
+			}
+		}
+	})
+
+	t.Run("dynamic client registration sets its env vars", func(t *testing.T) {
+		mcpExt := base()
+		mcpExt.Spec.OAuthProtectedResource = &mcpv1.OAuthProtectedResource{
+			AuthorizationServers: []string{"https://keycloak.example.com/realms/mcp"},
+			AuthorizationServerMetadata: &mcpv1.AuthorizationServerMetadata{
+				CacheTTLSeconds: ptr.To(int32(60)),
+				DynamicClientRegistration: &mcpv1.DynamicClientRegistration{
+					AllowedRedirectURIs:   []string{"http://localhost:*", "https://app.example.com/callback"},
+					InitialAccessTokenRef: &mcpv1.SecretReference{Name: "dcr-token"},
+				},
+			},
+		}
+		dep := reconciler.buildBrokerRouterDeployment(mcpExt, "mcp.example.com", "internal:8080")
+		envMap := make(map[string]corev1.EnvVar)
+		for _, e := range dep.Spec.Template.Spec.Containers[0].Env {
+			envMap[e.Name] = e
+		}
+		want := map[string]string{
+			"OAUTH_METADATA_CACHE_TTL": "60",
+			"OAUTH_DCR_ENDPOINT":       "https://mcp.example.com/oauth/register",
+			"OAUTH_DCR_REDIRECT_URIS":  "http://localhost:*,https://app.example.com/callback",
+			"OAUTH_DCR_GRANT_TYPES":    "authorization_code,refresh_token",
+		}
+		for name, value := range want {
+			if envMap[name].Value != value {
+				t.Errorf("%s = %q, want %q", name, envMap[name].Value, value)
+			}
+		}
+		token := envMap["OAUTH_DCR_INITIAL_ACCESS_TOKEN"]
+		if token.ValueFrom == nil || token.ValueFrom.SecretKeyRef == nil {
+			t.Fatal("OAUTH_DCR_INITIAL_ACCESS_TOKEN should be read from a secret")
+		}
+		if token.ValueFrom.SecretKeyRef.Name != "dcr-token" || token.ValueFrom.SecretKeyRef.Key != "token" {
+			t.Errorf("OAUTH_DCR_INITIAL_ACCESS_TOKEN secretKeyRef = %s/%s, want dcr-token/token",
+				token.ValueFrom.SecretKeyRef.Name, token.ValueFrom.SecretKeyRef.Key)
+		}
+	})
+
 	t.Run("oauth env change triggers deployment update", func(t *testing.T) {
 		mcpExt := base()
 		mcpExt.Spec.OAuthProtectedResource = &mcpv1.OAuthProtectedResource{
@@ -2442,3 +2721,146 @@ func TestMergeCommand_StripsLegacyGatewayCACertFlag(t *testing.T) {
 		t.Errorf("mergeCommand should preserve unrelated user flags, got %v", got)
 	}
 }
+
+// hasConfigVolume reports whether the config secret is mounted
+func hasConfigVolume(dep *appsv1.Deployment) bool {
+	mounted := slices.ContainsFunc(dep.Spec.Template.Spec.Containers[0].VolumeMounts, func(m corev1.VolumeMount) bool {
+		return m.Name == "config-volume"
+	})
+	return mounted && slices.ContainsFunc(dep.Spec.Template.Spec.Volumes, func(v corev1.Volume) bool {
+		return v.Name == "config-volume"
+	})
+}
+
+func TestBuildBrokerRouterDeployment_CredentialToken(t *testing.T) {
+	mcpExt := &mcpv1.MCPGatewayExtension{
+		ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "test-ns"},
+	}
+	r := &MCPGatewayExtensionReconciler{BrokerRouterImage: "test-image:v1"}
+	dep := r.buildBrokerRouterDeployment(mcpExt, "mcp.example.com", "internal:8080")
+	podSpec := dep.Spec.Template.Spec
+	container := podSpec.Containers[0]
+
+	if !slices.Contains(container.Command, "--credential-provider=kubernetes") {
+		t.Errorf("expected --credential-provider=kubernetes, got %v", container.Command)
+	}
+	// the token is projected explicitly rather than automounted
+	if podSpec.AutomountServiceAccountToken == nil || *podSpec.AutomountServiceAccountToken {
+		t.Error("expected automountServiceAccountToken to stay false")
+	}
+	if !slices.Contains(container.VolumeMounts, corev1.VolumeMount{
+		Name:      credentialTokenVolumeName,
+		MountPath: credentialTokenMountPath,
+		ReadOnly:  true,
+	}) {
+		t.Errorf("expected token mount, got %+v", container.VolumeMounts)
+	}
+	idx := slices.IndexFunc(podSpec.Volumes, func(v corev1.Volume) bool { return v.Name == credentialTokenVolumeName })
+	if idx < 0 || podSpec.Volumes[idx].Projected == nil {
+		t.Fatalf("expected projected token volume, got %+v", podSpec.Volumes)
+	}
+	sources := podSpec.Volumes[idx].Projected.Sources
+	if len(sources) != 2 || sources[0].ServiceAccountToken == nil || sources[1].ConfigMap == nil {
+		t.Errorf("expected token and CA projections, got %+v", sources)
+	}
+
+	// a freshly built deployment compares equal to itself so the volume
+	// does not trigger an update on every reconcile
+	if needsUpdate, reason := deploymentNeedsUpdate(dep, r.buildBrokerRouterDeployment(mcpExt, "mcp.example.com", "internal:8080")); needsUpdate {
+		t.Errorf("unexpected update: %s", reason)
+	}
+}
+
+func TestBuildBrokerRouterDeployment_ConfigStream(t *testing.T) {
+	mcpExt := &mcpv1.MCPGatewayExtension{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test",
+			Namespace: "test-ns",
+		},
+	}
+
+	t.Run("file mode mounts the config secret", func(t *testing.T) {
+		r := &MCPGatewayExtensionReconciler{BrokerRouterImage: "test-image:v1"}
+		dep := r.buildBrokerRouterDeployment(mcpExt, "mcp.example.com", "internal:8080")
+		container := dep.Spec.Template.Spec.Containers[0]
+		if !slices.Contains(container.Command, "--mcp-gateway-config=/config/config.yaml") {
+			t.Errorf("expected --mcp-gateway-config flag, got %v", container.Command)
+		}
+		if slices.ContainsFunc(container.Command, func(arg string) bool {
+			return strings.HasPrefix(arg, "--config-stream-")
+		}) {
+			t.Errorf("unexpected config stream flag, got %v", container.Command)
+		}
+		if !hasConfigVolume(dep) {
+			t.Errorf("expected config volume and mount, got %+v %+v", container.VolumeMounts, dep.Spec.Template.Spec.Volumes)
+		}
+	})
+
+	t.Run("stream mode subscribes to the controller", func(t *testing.T) {
+		r := &MCPGatewayExtensionReconciler{
+			BrokerRouterImage:   "test-image:v1",
+			ConfigStreamAddress: "mcp-controller.mcp-system.svc:18000",
+		}
+		dep := r.buildBrokerRouterDeployment(mcpExt, "mcp.example.com", "internal:8080")
+		container := dep.Spec.Template.Spec.Containers[0]
+		for _, want := range []string{
+			"--config-stream-address=mcp-controller.mcp-system.svc:18000",
+			"--config-stream-namespace=test-ns",
+		} {
+			if !slices.Contains(container.Command, want) {
+				t.Errorf("expected %s, got %v", want, container.Command)
+			}
+		}
+		if slices.ContainsFunc(container.Command, func(arg string) bool {
+			return strings.HasPrefix(arg, "--mcp-gateway-config")
+		}) {
+			t.Errorf("unexpected --mcp-gateway-config flag, got %v", container.Command)
+		}
+		if hasConfigVolume(dep) {
+			t.Errorf("expected no config volume, got %+v %+v", container.VolumeMounts, dep.Spec.Template.Spec.Volumes)
+		}
+	})
+
+	t.Run("stream mode passes the CA and insecure flag", func(t *testing.T) {
+		r := &MCPGatewayExtensionReconciler{
+			BrokerRouterImage:   "test-image:v1",
+			ConfigStreamAddress: "mcp-controller.mcp-system.svc:18000",
+			ConfigStreamCACert:  "-----BEGIN CERTIFICATE-----",
+		}
+		container := r.buildBrokerRouterDeployment(mcpExt, "mcp.example.com", "internal:8080").Spec.Template.Spec.Containers[0]
+		if !slices.Contains(container.Env, corev1.EnvVar{Name: configStreamCACertEnvVar, Value: "-----BEGIN CERTIFICATE-----"}) {
+			t.Errorf("expected %s env var, got %+v", configStreamCACertEnvVar, container.Env)
+		}
+		if slices.Contains(container.Command, "--config-stream-insecure") {
+			t.Errorf("unexpected --config-stream-insecure, got %v", container.Command)
+		}
+
+		r.ConfigStreamCACert = ""
+		r.ConfigStreamInsecure = true
+		container = r.buildBrokerRouterDeployment(mcpExt, "mcp.example.com", "internal:8080").Spec.Template.Spec.Containers[0]
+		if !slices.Contains(container.Command, "--config-stream-insecure") {
+			t.Errorf("expected --config-stream-insecure, got %v", container.Command)
+		}
+		if slices.ContainsFunc(container.Env, func(env corev1.EnvVar) bool { return env.Name == configStreamCACertEnvVar }) {
+			t.Errorf("unexpected %s env var, got %+v", configStreamCACertEnvVar, container.Env)
+		}
+	})
+
+	t.Run("switching to stream mode updates the deployment", func(t *testing.T) {
+		fileMode := (&MCPGatewayExtensionReconciler{BrokerRouterImage: "test-image:v1"}).
+			buildBrokerRouterDeployment(mcpExt, "mcp.example.com", "internal:8080")
+		streamMode := (&MCPGatewayExtensionReconciler{
+			BrokerRouterImage:   "test-image:v1",
+			ConfigStreamAddress: "mcp-controller.mcp-system.svc:18000",
+		}).buildBrokerRouterDeployment(mcpExt, "mcp.example.com", "internal:8080")
+		if needsUpdate, _ := deploymentNeedsUpdate(streamMode, fileMode); !needsUpdate {
+			t.Error("expected deployment update when switching to the config stream")
+		}
+		merged := mergeCommand(streamMode.Spec.Template.Spec.Containers[0].Command, fileMode.Spec.Template.Spec.Containers[0].Command)
+		if slices.ContainsFunc(merged, func(arg string) bool {
+			return strings.HasPrefix(arg, "--mcp-gateway-config")
+		}) {
+			t.Errorf("merged command kept --mcp-gateway-config: %v", merged)
+		}
+	})
+}
@@ -0,0 +1,214 @@
+package controller
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	mcpv1 "github.com/Kuadrant/mcp-gateway/api/v1"
+)
+
+// authPolicyGVK is the Kuadrant AuthPolicy generated for KeycloakToolRoleMappingPolicy
+var authPolicyGVK = schema.GroupVersionKind{Group: "kuadrant.io", Version: "v1", Kind: "AuthPolicy"}
+
+const (
+	// labels linking a generated AuthPolicy back to its KeycloakToolRoleMappingPolicy,
+	// which may live in another namespace
+	labelToolRoleMappingPolicyName      = "mcp.kuadrant.io/toolrolemappingpolicy-name"
+	labelToolRoleMappingPolicyNamespace = "mcp.kuadrant.io/toolrolemappingpolicy-namespace"
+
+	// toolRoleMappingRuleName names the AuthPolicy authorization rule; the
+	// wristband reads the capabilities it computes
+	toolRoleMappingRuleName = "tool-role-mapping"
+	// wristbandTokenDuration is the lifetime in seconds of the minted x-mcp-authorized JWT
+	wristbandTokenDuration = 300
+)
+
+// toolRoleMappingRego grants each server the union of the tools mapped to the
+// client roles the token holds, and denies tools/call for any tool outside it.
+// %s is replaced with the JSON mapping list.
+const toolRoleMappingRego = `mappings := %s
+capabilities = {"tools": {server: tools |
+  server := mappings[_].server
+  tools := sort({t |
+    m := mappings[_]
+    m.server == server
+    m.role == input.auth.identity.resource_access[m.client].roles[_]
+    t := m.tools[_]
+  })
+  count(tools) > 0
+}}
+call_server := object.get(input.request.headers, "x-mcp-servername", "")
+call_tool := object.get(input.request.headers, "x-mcp-toolname", "")
+granted(pattern, name) { pattern == name }
+granted(pattern, name) { endswith(pattern, "*"); startswith(name, trim_suffix(pattern, "*")) }
+allow { object.get(input.request.headers, "x-mcp-method", "") != "tools/call" }
+allow { granted(capabilities.tools[call_server][_], call_tool) }
+`
+
+// authPolicyTarget is the Gateway API resource a generated AuthPolicy attaches to
+type authPolicyTarget struct {
+	namespace   string
+	kind        string
+	name        string
+	sectionName string
+	// wristband mints the x-mcp-authorized header; only useful where tools/list passes
+	wristband bool
+	// defaultServer is the server mappings without one apply to
+	defaultServer string
+}
+
+// toolRoleMapping is a mapping as written into the generated rego
+type toolRoleMapping struct {
+	Server string   `json:"server"`
+	Client string   `json:"client"`
+	Role   string   `json:"role"`
+	Tools  []string `json:"tools"`
+}
+
+// authPolicyName returns the name of the AuthPolicy generated for policy. The
+// namespace is included as the AuthPolicy may be written to the target's namespace.
+func authPolicyName(policy *mcpv1.KeycloakToolRoleMappingPolicy) string {
+	return fmt.Sprintf("mcp-tool-roles-%s-%s", policy.Namespace, policy.Name)
+}
+
+// resolveToolRoleMappings applies the mapping defaults and checks every mapping
+// names a server the target can route to
+func resolveToolRoleMappings(policy *mcpv1.KeycloakToolRoleMappingPolicy, target authPolicyTarget) ([]toolRoleMapping, *validationError) {
+	mappings := make([]toolRoleMapping, 0, len(policy.Spec.Mappings))
+	for i, m := range policy.Spec.Mappings {
+		server := m.Server
+		switch {
+		case server == "" && target.defaultServer == "":
+			return nil, newValidationError(mcpv1.ConditionReasonInvalidPolicy,
+				fmt.Sprintf("mappings[%d]: server is required when targeting an MCPGatewayExtension", i))
+		case server == "":
+			server = target.defaultServer
+		case target.defaultServer != "" && server != target.defaultServer:
+			return nil, newValidationError(mcpv1.ConditionReasonInvalidPolicy,
+				fmt.Sprintf("mappings[%d]: server %s does not match the targeted MCPServerRegistration %s", i, server, target.defaultServer))
+		}
+		clientID := m.ClientID
+		if clientID == "" {
+			clientID = server
+		}
+		tools := append([]string(nil), m.Tools...)
+		sort.Strings(tools)
+		mappings = append(mappings, toolRoleMapping{Server: server, Client: clientID, Role: m.Role, Tools: tools})
+	}
+	return mappings, nil
+}
+
+// buildAuthPolicy generates the Kuadrant AuthPolicy enforcing policy on target
+func buildAuthPolicy(policy *mcpv1.KeycloakToolRoleMappingPolicy, target authPolicyTarget, mappings []toolRoleMapping) (*unstructured.Unstructured, error) {
+	mappingsJSON, err := json.Marshal(mappings)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode tool role mappings: %w", err)
+	}
+
+	targetRef := map[string]any{
+		"group": "gateway.networking.k8s.io",
+		"kind":  target.kind,
+		"name":  target.name,
+	}
+	if target.sectionName != "" {
+		targetRef["sectionName"] = target.sectionName
+	}
+
+	response := map[string]any{
+		"unauthenticated": deniedResponse(policy.Spec.ResourceMetadataURL, "Unauthorized", "Access denied: Authentication required."),
+		"unauthorized":    deniedResponse("", "Forbidden", "Access denied: Insufficient permissions for this tool."),
+	}
+	if target.wristband {
+		response["success"] = map[string]any{
+			"headers": map[string]any{
+				"x-mcp-authorized": map[string]any{
+					"wristband": map[string]any{
+						"issuer": "authorino",
+						"customClaims": map[string]any{
+							"allowed-capabilities": map[string]any{
+								"selector": "auth.authorization." + toolRoleMappingRuleName + ".capabilities.@tostr",
+							},
+						},
+						"tokenDuration": int64(wristbandTokenDuration),
+						"signingKeyRefs": []any{
+							map[string]any{"name": policy.SigningKeySecret(), "algorithm": "ES256"},
+						},
+					},
+				},
+			},
+		}
+	}
+
+	ap := &unstructured.Unstructured{}
+	ap.SetGroupVersionKind(authPolicyGVK)
+	ap.SetName(authPolicyName(policy))
+	ap.SetNamespace(target.namespace)
+	ap.SetLabels(map[string]string{
+		labelManagedBy:                      labelManagedByValue,
+		labelToolRoleMappingPolicyName:      policy.Name,
+		labelToolRoleMappingPolicyNamespace: policy.Namespace,
+	})
+	ap.Object["spec"] = map[string]any{
+		"targetRef": targetRef,
+		"when": []any{
+			map[string]any{"predicate": "!request.path.contains('/.well-known')"},
+			// clients register before they hold a token
+			map[string]any{"predicate": "request.path != '" + clientRegistrationPath + "'"},
+			// the router validates its own hairpin initialize requests
+			map[string]any{"predicate": "!request.headers.exists(h, h == 'router-key')"},
+		},
+		"rules": map[string]any{
+			"authentication": map[string]any{
+				"keycloak": map[string]any{
+					"jwt": map[string]any{"issuerUrl": policy.Spec.IssuerURL},
+				},
+			},
+			"authorization": map[string]any{
+				toolRoleMappingRuleName: map[string]any{
+					"opa": map[string]any{
+						"rego":      fmt.Sprintf(toolRoleMappingRego, mappingsJSON),
+						"allValues": true,
+					},
+				},
+			},
+			"response": response,
+		},
+	}
+	return ap, nil
+}
+
+// deniedResponse builds an AuthPolicy denial with a JSON body, advertising
+// resourceMetadataURL in WWW-Authenticate when set
+func deniedResponse(resourceMetadataURL, errorName, message string) map[string]any {
+	body, _ := json.Marshal(map[string]string{"error": errorName, "message": message})
+	denied := map[string]any{
+		"body": map[string]any{"value": string(body)},
+	}
+	if resourceMetadataURL != "" {
+		denied["headers"] = map[string]any{
+			"WWW-Authenticate": map[string]any{"value": "Bearer resource_metadata=" + resourceMetadataURL},
+		}
+	}
+	return denied
+}
+
+// authPolicyCondition returns the condition of type conditionType reported on
+// a Kuadrant AuthPolicy, if any
+func authPolicyCondition(ap *unstructured.Unstructured, conditionType string) (status, reason, message string, found bool) {
+	conditions, _, _ := unstructured.NestedSlice(ap.Object, "status", "conditions")
+	for _, c := range conditions {
+		cond, ok := c.(map[string]any)
+		if !ok || cond["type"] != conditionType {
+			continue
+		}
+		status, _ = cond["status"].(string)
+		reason, _ = cond["reason"].(string)
+		message, _ = cond["message"].(string)
+		return status, reason, message, true
+	}
+	return "", "", "", false
+}
@@ -0,0 +1,298 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/go-logr/logr"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	mcpv1 "github.com/Kuadrant/mcp-gateway/api/v1"
+)
+
+// KeycloakToolRoleMappingPolicyReconciler reconciles a KeycloakToolRoleMappingPolicy
+// into a Kuadrant AuthPolicy
+type KeycloakToolRoleMappingPolicyReconciler struct {
+	client.Client
+	Scheme *runtime.Scheme
+	log    *slog.Logger
+	// authPolicyAvailable is false when the Kuadrant AuthPolicy CRD was not
+	// installed at startup; policies then report a missing dependency
+	authPolicyAvailable bool
+}
+
+// +kubebuilder:rbac:groups=mcp.kuadrant.io,resources=keycloaktoolrolemappingpolicies,verbs=get;list;watch;update
+// +kubebuilder:rbac:groups=mcp.kuadrant.io,resources=keycloaktoolrolemappingpolicies/status,verbs=get;update
+// +kubebuilder:rbac:groups=mcp.kuadrant.io,resources=keycloaktoolrolemappingpolicies/finalizers,verbs=update
+// +kubebuilder:rbac:groups=kuadrant.io,resources=authpolicies,verbs=get;list;watch;create;update;delete
+
+// Reconcile generates the AuthPolicy for a KeycloakToolRoleMappingPolicy and
+// reports its enforcement
+func (r *KeycloakToolRoleMappingPolicyReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	policy := &mcpv1.KeycloakToolRoleMappingPolicy{}
+	if err := r.Get(ctx, req.NamespacedName, policy); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+	r.log.Debug("reconciling keycloaktoolrolemappingpolicy", "name", policy.Name, "namespace", policy.Namespace)
+
+	if !policy.DeletionTimestamp.IsZero() {
+		if !controllerutil.ContainsFinalizer(policy, mcpGatewayFinalizer) {
+			return ctrl.Result{}, nil
+		}
+		if err := r.deleteAuthPolicy(ctx, policy.Status.AuthPolicyRef); err != nil {
+			return ctrl.Result{}, err
+		}
+		controllerutil.RemoveFinalizer(policy, mcpGatewayFinalizer)
+		return ctrl.Result{}, r.Update(ctx, policy)
+	}
+
+	if controllerutil.AddFinalizer(policy, mcpGatewayFinalizer) {
+		if err := r.Update(ctx, policy); err != nil {
+			if apierrors.IsConflict(err) {
+				return ctrl.Result{RequeueAfter: defaultRequeueTime}, nil
+			}
+			return ctrl.Result{}, err
+		}
+	}
+
+	original := policy.Status.DeepCopy()
+	if err := r.reconcileAuthPolicy(ctx, policy); err != nil {
+		var vErr *validationError
+		if !errors.As(err, &vErr) {
+			return ctrl.Result{}, err
+		}
+		policy.SetCondition(mcpv1.ConditionTypeAccepted, metav1.ConditionFalse, vErr.reason, vErr.message)
+		meta.RemoveStatusCondition(&policy.Status.Conditions, mcpv1.ConditionTypeEnforced)
+	}
+
+	if equality.Semantic.DeepEqual(original, &policy.Status) {
+		return ctrl.Result{}, nil
+	}
+	if err := r.Status().Update(ctx, policy); err != nil {
+		if apierrors.IsConflict(err) {
+			return ctrl.Result{RequeueAfter: defaultRequeueTime}, nil
+		}
+		return ctrl.Result{}, err
+	}
+	return ctrl.Result{}, nil
+}
+
+// reconcileAuthPolicy writes the generated AuthPolicy and sets the policy
+// conditions. Spec and target problems are returned as *validationError.
+func (r *KeycloakToolRoleMappingPolicyReconciler) reconcileAuthPolicy(ctx context.Context, policy *mcpv1.KeycloakToolRoleMappingPolicy) error {
+	if !r.authPolicyAvailable {
+		return newValidationError(mcpv1.ConditionReasonMissingDependency,
+			"the Kuadrant AuthPolicy API (kuadrant.io/v1) is not installed; restart the controller after installing Kuadrant")
+	}
+
+	target, err := r.resolveTarget(ctx, policy)
+	if err != nil {
+		return err
+	}
+	mappings, vErr := resolveToolRoleMappings(policy, target)
+	if vErr != nil {
+		return vErr
+	}
+	desired, err := buildAuthPolicy(policy, target, mappings)
+	if err != nil {
+		return err
+	}
+
+	// the AuthPolicy follows the target, so remove the one written for a previous target
+	ref := desired.GetNamespace() + "/" + desired.GetName()
+	if policy.Status.AuthPolicyRef != "" && policy.Status.AuthPolicyRef != ref {
+		if err := r.deleteAuthPolicy(ctx, policy.Status.AuthPolicyRef); err != nil {
+			return err
+		}
+	}
+
+	current, err := r.applyAuthPolicy(ctx, desired)
+	if err != nil {
+		return err
+	}
+	policy.Status.AuthPolicyRef = ref
+	policy.SetCondition(mcpv1.ConditionTypeAccepted, metav1.ConditionTrue, mcpv1.ConditionReasonAccepted,
+		fmt.Sprintf("AuthPolicy %s generated", ref))
+
+	status, reason, message, found := authPolicyCondition(current, mcpv1.ConditionTypeEnforced)
+	if !found {
+		policy.SetCondition(mcpv1.ConditionTypeEnforced, metav1.ConditionUnknown, mcpv1.ConditionReasonEnforcementPending,
+			fmt.Sprintf("waiting for Kuadrant to report on AuthPolicy %s", ref))
+		return nil
+	}
+	policy.SetCondition(mcpv1.ConditionTypeEnforced, metav1.ConditionStatus(status), reason, message)
+	return nil
+}
+
+// resolveTarget finds where the AuthPolicy for policy attaches
+func (r *KeycloakToolRoleMappingPolicyReconciler) resolveTarget(ctx context.Context, policy *mcpv1.KeycloakToolRoleMappingPolicy) (authPolicyTarget, error) {
+	key := types.NamespacedName{Namespace: policy.Namespace, Name: policy.Spec.TargetRef.Name}
+	switch policy.Spec.TargetRef.Kind {
+	case "MCPGatewayExtension":
+		mcpExt := &mcpv1.MCPGatewayExtension{}
+		if err := r.Get(ctx, key, mcpExt); err != nil {
+			return authPolicyTarget{}, targetLookupError(err, policy)
+		}
+		namespace := mcpExt.Spec.TargetRef.Namespace
+		if namespace == "" {
+			namespace = mcpExt.Namespace
+		}
+		return authPolicyTarget{
+			namespace:   namespace,
+			kind:        "Gateway",
+			name:        mcpExt.Spec.TargetRef.Name,
+			sectionName: mcpExt.Spec.TargetRef.SectionName,
+			wristband:   true,
+		}, nil
+	case "MCPServerRegistration":
+		mcpsr := &mcpv1.MCPServerRegistration{}
+		if err := r.Get(ctx, key, mcpsr); err != nil {
+			return authPolicyTarget{}, targetLookupError(err, policy)
+		}
+		namespace := mcpsr.Spec.TargetRef.Namespace
+		if namespace == "" {
+			namespace = mcpsr.Namespace
+		}
+		return authPolicyTarget{
+			namespace:     namespace,
+			kind:          "HTTPRoute",
+			name:          mcpsr.Spec.TargetRef.Name,
+			defaultServer: mcpServerName(mcpsr),
+		}, nil
+	}
+	return authPolicyTarget{}, newValidationError(mcpv1.ConditionReasonInvalidPolicy,
+		fmt.Sprintf("unsupported target kind %q", policy.Spec.TargetRef.Kind))
+}
+
+func targetLookupError(err error, policy *mcpv1.KeycloakToolRoleMappingPolicy) error {
+	if apierrors.IsNotFound(err) {
+		return newValidationError(mcpv1.ConditionReasonTargetNotFound,
+			fmt.Sprintf("%s %s/%s not found", policy.Spec.TargetRef.Kind, policy.Namespace, policy.Spec.TargetRef.Name))
+	}
+	return fmt.Errorf("failed to get %s %s: %w", policy.Spec.TargetRef.Kind, policy.Spec.TargetRef.Name, err)
+}
+
+// applyAuthPolicy creates or updates the AuthPolicy and returns it as stored
+func (r *KeycloakToolRoleMappingPolicyReconciler) applyAuthPolicy(ctx context.Context, desired *unstructured.Unstructured) (*unstructured.Unstructured, error) {
+	current := &unstructured.Unstructured{}
+	current.SetGroupVersionKind(authPolicyGVK)
+	err := r.Get(ctx, client.ObjectKeyFromObject(desired), current)
+	if apierrors.IsNotFound(err) {
+		r.log.Info("creating authpolicy", "namespace", desired.GetNamespace(), "name", desired.GetName())
+		if err := r.Create(ctx, desired); err != nil {
+			return nil, fmt.Errorf("failed to create authpolicy %s/%s: %w", desired.GetNamespace(), desired.GetName(), err)
+		}
+		return desired, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get authpolicy %s/%s: %w", desired.GetNamespace(), desired.GetName(), err)
+	}
+
+	if equality.Semantic.DeepEqual(current.Object["spec"], desired.Object["spec"]) &&
+		equality.Semantic.DeepEqual(current.GetLabels(), desired.GetLabels()) {
+		return current, nil
+	}
+	r.log.Info("updating authpolicy", "namespace", desired.GetNamespace(), "name", desired.GetName())
+	current.Object["spec"] = desired.Object["spec"]
+	current.SetLabels(desired.GetLabels())
+	if err := r.Update(ctx, current); err != nil {
+		return nil, fmt.Errorf("failed to update authpolicy %s/%s: %w", desired.GetNamespace(), desired.GetName(), err)
+	}
+	return current, nil
+}
+
+// deleteAuthPolicy removes the generated AuthPolicy referenced as namespace/name
+func (r *KeycloakToolRoleMappingPolicyReconciler) deleteAuthPolicy(ctx context.Context, ref string) error {
+	if ref == "" || !r.authPolicyAvailable {
+		return nil
+	}
+	namespace, name, ok := strings.Cut(ref, "/")
+	if !ok {
+		return nil
+	}
+	ap := &unstructured.Unstructured{}
+	ap.SetGroupVersionKind(authPolicyGVK)
+	ap.SetNamespace(namespace)
+	ap.SetName(name)
+	r.log.Info("deleting authpolicy", "namespace", namespace, "name", name)
+	if err := r.Delete(ctx, ap); err != nil && !apierrors.IsNotFound(err) {
+		return fmt.Errorf("failed to delete authpolicy %s: %w", ref, err)
+	}
+	return nil
+}
+
+// SetupWithManager sets up the controller with the Manager. The AuthPolicy
+// watch is only added when Kuadrant is installed.
+func (r *KeycloakToolRoleMappingPolicyReconciler) SetupWithManager(_ context.Context, mgr ctrl.Manager) error {
+	r.log = slog.New(logr.ToSlogHandler(mgr.GetLogger()))
+
+	_, err := mgr.GetRESTMapper().RESTMapping(authPolicyGVK.GroupKind(), authPolicyGVK.Version)
+	switch {
+	case err == nil:
+		r.authPolicyAvailable = true
+	case meta.IsNoMatchError(err):
+		r.log.Info("kuadrant AuthPolicy API not found, KeycloakToolRoleMappingPolicy resources will not be enforced")
+	default:
+		return fmt.Errorf("failed to look up the AuthPolicy API: %w", err)
+	}
+
+	b := ctrl.NewControllerManagedBy(mgr).
+		For(&mcpv1.KeycloakToolRoleMappingPolicy{}).
+		Watches(&mcpv1.MCPGatewayExtension{}, handler.EnqueueRequestsFromMapFunc(r.enqueuePoliciesForTarget("MCPGatewayExtension"))).
+		Watches(&mcpv1.MCPServerRegistration{}, handler.EnqueueRequestsFromMapFunc(r.enqueuePoliciesForTarget("MCPServerRegistration")))
+	if r.authPolicyAvailable {
+		// cross-namespace, so we use Watches instead of Owns
+		ap := &unstructured.Unstructured{}
+		ap.SetGroupVersionKind(authPolicyGVK)
+		b = b.Watches(ap, handler.EnqueueRequestsFromMapFunc(r.enqueuePolicyForAuthPolicy))
+	}
+	return b.Named("keycloaktoolrolemappingpolicy").Complete(r)
+}
+
+// enqueuePoliciesForTarget returns a map func enqueuing the policies in the
+// object's namespace that target it
+func (r *KeycloakToolRoleMappingPolicyReconciler) enqueuePoliciesForTarget(kind string) handler.MapFunc {
+	return func(ctx context.Context, obj client.Object) []reconcile.Request {
+		list := &mcpv1.KeycloakToolRoleMappingPolicyList{}
+		if err := r.List(ctx, list, client.InNamespace(obj.GetNamespace())); err != nil {
+			r.log.Error("failed to list keycloaktoolrolemappingpolicies", "error", err)
+			return nil
+		}
+		var requests []reconcile.Request
+		for _, p := range list.Items {
+			if p.Spec.TargetRef.Kind == kind && p.Spec.TargetRef.Name == obj.GetName() {
+				requests = append(requests, reconcile.Request{
+					NamespacedName: types.NamespacedName{Namespace: p.Namespace, Name: p.Name},
+				})
+			}
+		}
+		return requests
+	}
+}
+
+func (r *KeycloakToolRoleMappingPolicyReconciler) enqueuePolicyForAuthPolicy(_ context.Context, obj client.Object) []reconcile.Request {
+	labels := obj.GetLabels()
+	if labels[labelManagedBy] != labelManagedByValue {
+		return nil
+	}
+	name := labels[labelToolRoleMappingPolicyName]
+	namespace := labels[labelToolRoleMappingPolicyNamespace]
+	if name == "" || namespace == "" {
+		return nil
+	}
+	return []reconcile.Request{{NamespacedName: types.NamespacedName{Namespace: namespace, Name: name}}}
+}
@@ -0,0 +1,353 @@
+package controller
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	mcpv1 "github.com/Kuadrant/mcp-gateway/api/v1"
+)
+
+func newToolRoleMappingPolicy(kind, target string, mappings ...mcpv1.ToolRoleMapping) *mcpv1.KeycloakToolRoleMappingPolicy {
+	return &mcpv1.KeycloakToolRoleMappingPolicy{
+		ObjectMeta: metav1.ObjectMeta{Name: "tool-roles", Namespace: "mcp-system"},
+		Spec: mcpv1.KeycloakToolRoleMappingPolicySpec{
+			TargetRef: mcpv1.ToolRoleMappingTargetReference{Group: "mcp.kuadrant.io", Kind: kind, Name: target},
+			IssuerURL: "https://keycloak.example.com/realms/mcp",
+			Mappings:  mappings,
+		},
+	}
+}
+
+func TestResolveToolRoleMappings(t *testing.T) {
+	serverTarget := authPolicyTarget{defaultServer: "mcp-system/weather"}
+	gatewayTarget := authPolicyTarget{wristband: true}
+
+	tests := []struct {
+		name     string
+		target   authPolicyTarget
+		mappings []mcpv1.ToolRoleMapping
+		want     []toolRoleMapping
+		wantErr  string
+	}{
+		{
+			name:     "server and client default to the targeted registration",
+			target:   serverTarget,
+			mappings: []mcpv1.ToolRoleMapping{{Role: "reader", Tools: []string{"forecast", "alerts_*"}}},
+			want: []toolRoleMapping{
+				{Server: "mcp-system/weather", Client: "mcp-system/weather", Role: "reader", Tools: []string{"alerts_*", "forecast"}},
+			},
+		},
+		{
+			name:   "explicit client is kept",
+			target: gatewayTarget,
+			mappings: []mcpv1.ToolRoleMapping{
+				{Server: "team-a/github", ClientID: "github-tools", Role: "dev", Tools: []string{"*"}},
+			},
+			want: []toolRoleMapping{
+				{Server: "team-a/github", Client: "github-tools", Role: "dev", Tools: []string{"*"}},
+			},
+		},
+		{
+			name:     "server required for gateway targets",
+			target:   gatewayTarget,
+			mappings: []mcpv1.ToolRoleMapping{{Role: "dev", Tools: []string{"*"}}},
+			wantErr:  "mappings[0]: server is required",
+		},
+		{
+			name:     "server must match the targeted registration",
+			target:   serverTarget,
+			mappings: []mcpv1.ToolRoleMapping{{Server: "mcp-system/other", Role: "dev", Tools: []string{"*"}}},
+			wantErr:  "does not match the targeted MCPServerRegistration",
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			policy := newToolRoleMappingPolicy("MCPServerRegistration", "weather", tc.mappings...)
+			got, vErr := resolveToolRoleMappings(policy, tc.target)
+			if tc.wantErr != "" {
+				if vErr == nil || !strings.Contains(vErr.Error(), tc.wantErr) {
+					t.Fatalf("error = %v, want containing %q", vErr, tc.wantErr)
+				}
+				if vErr.reason != mcpv1.ConditionReasonInvalidPolicy {
+					t.Errorf("reason = %q, want %q", vErr.reason, mcpv1.ConditionReasonInvalidPolicy)
+				}
+				return
+			}
+			if vErr != nil {
+				t.Fatalf("unexpected error: %v", vErr)
+			}
+			if len(got) != len(tc.want) {
+				t.Fatalf("got %d mappings, want %d", len(got), len(tc.want))
+			}
+			for i := range got {
+				if got[i].Server != tc.want[i].Server || got[i].Client != tc.want[i].Client ||
+					got[i].Role != tc.want[i].Role || strings.Join(got[i].Tools, ",") != strings.Join(tc.want[i].Tools, ",") {
+					t.Errorf("mapping %d = %+v, want %+v", i, got[i], tc.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestBuildAuthPolicy(t *testing.T) {
+	mappings := []toolRoleMapping{{Server: "mcp-system/weather", Client: "weather", Role: "reader", Tools: []string{"forecast"}}}
+
+	t.Run("gateway target mints the wristband", func(t *testing.T) {
+		policy := newToolRoleMappingPolicy("MCPGatewayExtension", "ext")
+		policy.Spec.ResourceMetadataURL = "https://mcp.example.com/.well-known/oauth-protected-resource/mcp"
+		target := authPolicyTarget{namespace: "gateway-system", kind: "Gateway", name: "mcp-gateway", sectionName: "mcp", wristband: true}
+
+		ap, err := buildAuthPolicy(policy, target, mappings)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if ap.GetNamespace() != "gateway-system" || ap.GetName() != "mcp-tool-roles-mcp-system-tool-roles" {
+			t.Errorf("authpolicy = %s/%s", ap.GetNamespace(), ap.GetName())
+		}
+		if ap.GetLabels()[labelToolRoleMappingPolicyNamespace] != "mcp-system" || ap.GetLabels()[labelToolRoleMappingPolicyName] != "tool-roles" {
+			t.Errorf("labels = %v", ap.GetLabels())
+		}
+		if section, _, _ := unstructured.NestedString(ap.Object, "spec", "targetRef", "sectionName"); section != "mcp" {
+			t.Errorf("sectionName = %q, want mcp", section)
+		}
+		if issuer, _, _ := unstructured.NestedString(ap.Object, "spec", "rules", "authentication", "keycloak", "jwt", "issuerUrl"); issuer != policy.Spec.IssuerURL {
+			t.Errorf("issuerUrl = %q", issuer)
+		}
+		rego, _, _ := unstructured.NestedString(ap.Object, "spec", "rules", "authorization", toolRoleMappingRuleName, "opa", "rego")
+		if !strings.Contains(rego, `mappings := [{"server":"mcp-system/weather","client":"weather","role":"reader","tools":["forecast"]}]`) {
+			t.Errorf("rego does not embed the mappings:\n%s", rego)
+		}
+		selector, _, _ := unstructured.NestedString(ap.Object, "spec", "rules", "response", "success", "headers",
+			"x-mcp-authorized", "wristband", "customClaims", "allowed-capabilities", "selector")
+		if selector != "auth.authorization.tool-role-mapping.capabilities.@tostr" {
+			t.Errorf("wristband selector = %q", selector)
+		}
+		keys, _, _ := unstructured.NestedSlice(ap.Object, "spec", "rules", "response", "success", "headers",
+			"x-mcp-authorized", "wristband", "signingKeyRefs")
+		if len(keys) != 1 || keys[0].(map[string]any)["name"] != mcpv1.DefaultWristbandSigningKeySecret {
+			t.Errorf("signingKeyRefs = %v", keys)
+		}
+		challenge, _, _ := unstructured.NestedString(ap.Object, "spec", "rules", "response", "unauthenticated", "headers", "WWW-Authenticate", "value")
+		if challenge != "Bearer resource_metadata="+policy.Spec.ResourceMetadataURL {
+			t.Errorf("WWW-Authenticate = %q", challenge)
+		}
+		// unstructured content must survive a deep copy, which panics on non-JSON types
+		_ = ap.DeepCopy()
+	})
+
+	t.Run("route target only enforces tools/call", func(t *testing.T) {
+		policy := newToolRoleMappingPolicy("MCPServerRegistration", "weather")
+		target := authPolicyTarget{namespace: "mcp-system", kind: "HTTPRoute", name: "weather-route", defaultServer: "mcp-system/weather"}
+
+		ap, err := buildAuthPolicy(policy, target, mappings)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if kind, _, _ := unstructured.NestedString(ap.Object, "spec", "targetRef", "kind"); kind != "HTTPRoute" {
+			t.Errorf("targetRef kind = %q, want HTTPRoute", kind)
+		}
+		if _, found, _ := unstructured.NestedFieldNoCopy(ap.Object, "spec", "targetRef", "sectionName"); found {
+			t.Error("route target should not set sectionName")
+		}
+		if _, found, _ := unstructured.NestedFieldNoCopy(ap.Object, "spec", "rules", "response", "success"); found {
+			t.Error("route target should not mint the wristband")
+		}
+		if _, found, _ := unstructured.NestedFieldNoCopy(ap.Object, "spec", "rules", "response", "unauthenticated", "headers"); found {
+			t.Error("WWW-Authenticate should be omitted without a resource metadata URL")
+		}
+	})
+}
+
+func newToolRoleMappingReconciler(t *testing.T, objs ...client.Object) *KeycloakToolRoleMappingPolicyReconciler {
+	t.Helper()
+	scheme := runtime.NewScheme()
+	if err := mcpv1.AddToScheme(scheme); err != nil {
+		t.Fatalf("scheme: %v", err)
+	}
+	fakeClient := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(objs...).
+		WithStatusSubresource(&mcpv1.KeycloakToolRoleMappingPolicy{}).
+		Build()
+	return &KeycloakToolRoleMappingPolicyReconciler{
+		Client:              fakeClient,
+		Scheme:              scheme,
+		log:                 slog.New(slog.NewTextHandler(io.Discard, nil)),
+		authPolicyAvailable: true,
+	}
+}
+
+func reconcileToolRoleMappingPolicy(t *testing.T, r *KeycloakToolRoleMappingPolicyReconciler, policy *mcpv1.KeycloakToolRoleMappingPolicy) *mcpv1.KeycloakToolRoleMappingPolicy {
+	t.Helper()
+	key := types.NamespacedName{Namespace: policy.Namespace, Name: policy.Name}
+	// the first pass adds the finalizer
+	for range 2 {
+		if _, err := r.Reconcile(context.Background(), reconcile.Request{NamespacedName: key}); err != nil {
+			t.Fatalf("reconcile: %v", err)
+		}
+	}
+	got := &mcpv1.KeycloakToolRoleMappingPolicy{}
+	if err := r.Get(context.Background(), key, got); err != nil {
+		t.Fatalf("get policy: %v", err)
+	}
+	return got
+}
+
+func getAuthPolicy(t *testing.T, r *KeycloakToolRoleMappingPolicyReconciler, namespace, name string) (*unstructured.Unstructured, error) {
+	t.Helper()
+	ap := &unstructured.Unstructured{}
+	ap.SetGroupVersionKind(authPolicyGVK)
+	return ap, r.Get(context.Background(), types.NamespacedName{Namespace: namespace, Name: name}, ap)
+}
+
+func TestKeycloakToolRoleMappingPolicyReconciler(t *testing.T) {
+	mcpExt := &mcpv1.MCPGatewayExtension{
+		ObjectMeta: metav1.ObjectMeta{Name: "ext", Namespace: "mcp-system"},
+		Spec: mcpv1.MCPGatewayExtensionSpec{
+			TargetRef: mcpv1.MCPGatewayExtensionTargetReference{Name: "mcp-gateway", Namespace: "gateway-system", SectionName: "mcp"},
+		},
+	}
+	mapping := mcpv1.ToolRoleMapping{Server: "mcp-system/weather", Role: "reader", Tools: []string{"forecast"}}
+
+	t.Run("generates the authpolicy and reports pending enforcement", func(t *testing.T) {
+		policy := newToolRoleMappingPolicy("MCPGatewayExtension", "ext", mapping)
+		r := newToolRoleMappingReconciler(t, mcpExt, policy)
+
+		got := reconcileToolRoleMappingPolicy(t, r, policy)
+		if got.Status.AuthPolicyRef != "gateway-system/mcp-tool-roles-mcp-system-tool-roles" {
+			t.Errorf("authPolicyRef = %q", got.Status.AuthPolicyRef)
+		}
+		if !meta.IsStatusConditionTrue(got.Status.Conditions, mcpv1.ConditionTypeAccepted) {
+			t.Errorf("expected Accepted=True, got %v", got.Status.Conditions)
+		}
+		enforced := meta.FindStatusCondition(got.Status.Conditions, mcpv1.ConditionTypeEnforced)
+		if enforced == nil || enforced.Status != metav1.ConditionUnknown || enforced.Reason != mcpv1.ConditionReasonEnforcementPending {
+			t.Errorf("Enforced = %+v, want Unknown/Pending", enforced)
+		}
+		if _, err := getAuthPolicy(t, r, "gateway-system", "mcp-tool-roles-mcp-system-tool-roles"); err != nil {
+			t.Errorf("authpolicy not created: %v", err)
+		}
+	})
+
+	t.Run("mirrors the authpolicy enforced condition", func(t *testing.T) {
+		policy := newToolRoleMappingPolicy("MCPGatewayExtension", "ext", mapping)
+		r := newToolRoleMappingReconciler(t, mcpExt, policy)
+		reconcileToolRoleMappingPolicy(t, r, policy)
+
+		ap, err := getAuthPolicy(t, r, "gateway-system", "mcp-tool-roles-mcp-system-tool-roles")
+		if err != nil {
+			t.Fatalf("get authpolicy: %v", err)
+		}
+		ap.Object["status"] = map[string]any{"conditions": []any{map[string]any{
+			"type": "Enforced", "status": "False", "reason": "Overridden", "message": "AuthPolicy is overridden",
+		}}}
+		if err := r.Update(context.Background(), ap); err != nil {
+			t.Fatalf("update authpolicy status: %v", err)
+		}
+
+		got := reconcileToolRoleMappingPolicy(t, r, policy)
+		enforced := meta.FindStatusCondition(got.Status.Conditions, mcpv1.ConditionTypeEnforced)
+		if enforced == nil || enforced.Status != metav1.ConditionFalse || enforced.Reason != "Overridden" {
+			t.Errorf("Enforced = %+v, want False/Overridden", enforced)
+		}
+	})
+
+	t.Run("missing target is not accepted", func(t *testing.T) {
+		policy := newToolRoleMappingPolicy("MCPServerRegistration", "missing", mapping)
+		r := newToolRoleMappingReconciler(t, policy)
+
+		got := reconcileToolRoleMappingPolicy(t, r, policy)
+		accepted := meta.FindStatusCondition(got.Status.Conditions, mcpv1.ConditionTypeAccepted)
+		if accepted == nil || accepted.Status != metav1.ConditionFalse || accepted.Reason != mcpv1.ConditionReasonTargetNotFound {
+			t.Errorf("Accepted = %+v, want False/TargetNotFound", accepted)
+		}
+	})
+
+	t.Run("reports missing kuadrant", func(t *testing.T) {
+		policy := newToolRoleMappingPolicy("MCPGatewayExtension", "ext", mapping)
+		r := newToolRoleMappingReconciler(t, mcpExt, policy)
+		r.authPolicyAvailable = false
+
+		got := reconcileToolRoleMappingPolicy(t, r, policy)
+		accepted := meta.FindStatusCondition(got.Status.Conditions, mcpv1.ConditionTypeAccepted)
+		if accepted == nil || accepted.Reason != mcpv1.ConditionReasonMissingDependency {
+			t.Errorf("Accepted = %+v, want MissingDependency", accepted)
+		}
+	})
+
+	t.Run("retargeting removes the previous authpolicy", func(t *testing.T) {
+		mcpsr := &mcpv1.MCPServerRegistration{
+			ObjectMeta: metav1.ObjectMeta{Name: "weather", Namespace: "mcp-system"},
+			Spec:       mcpv1.MCPServerRegistrationSpec{TargetRef: mcpv1.TargetReference{Name: "weather-route"}},
+		}
+		policy := newToolRoleMappingPolicy("MCPGatewayExtension", "ext", mapping)
+		r := newToolRoleMappingReconciler(t, mcpExt, mcpsr, policy)
+		got := reconcileToolRoleMappingPolicy(t, r, policy)
+
+		got.Spec.TargetRef = mcpv1.ToolRoleMappingTargetReference{Group: "mcp.kuadrant.io", Kind: "MCPServerRegistration", Name: "weather"}
+		if err := r.Update(context.Background(), got); err != nil {
+			t.Fatalf("update policy: %v", err)
+		}
+		got = reconcileToolRoleMappingPolicy(t, r, got)
+		if got.Status.AuthPolicyRef != "mcp-system/mcp-tool-roles-mcp-system-tool-roles" {
+			t.Errorf("authPolicyRef = %q", got.Status.AuthPolicyRef)
+		}
+		if _, err := getAuthPolicy(t, r, "gateway-system", "mcp-tool-roles-mcp-system-tool-roles"); err == nil {
+			t.Error("expected the gateway authpolicy to be deleted")
+		}
+		ap, err := getAuthPolicy(t, r, "mcp-system", "mcp-tool-roles-mcp-system-tool-roles")
+		if err != nil {
+			t.Fatalf("route authpolicy not created: %v", err)
+		}
+		if name, _, _ := unstructured.NestedString(ap.Object, "spec", "targetRef", "name"); name != "weather-route" {
+			t.Errorf("targetRef name = %q, want weather-route", name)
+		}
+	})
+
+	t.Run("deletion removes the authpolicy", func(t *testing.T) {
+		policy := newToolRoleMappingPolicy("MCPGatewayExtension", "ext", mapping)
+		r := newToolRoleMappingReconciler(t, mcpExt, policy)
+		got := reconcileToolRoleMappingPolicy(t, r, policy)
+
+		if err := r.Delete(context.Background(), got); err != nil {
+			t.Fatalf("delete policy: %v", err)
+		}
+		if _, err := r.Reconcile(context.Background(), reconcile.Request{NamespacedName: types.NamespacedName{Namespace: policy.Namespace, Name: policy.Name}}); err != nil {
+			t.Fatalf("reconcile: %v", err)
+		}
+		if _, err := getAuthPolicy(t, r, "gateway-system", "mcp-tool-roles-mcp-system-tool-roles"); err == nil {
+			t.Error("expected the authpolicy to be deleted")
+		}
+	})
+}
+
+func TestEnqueuePolicyForAuthPolicy(t *testing.T) {
+	r := &KeycloakToolRoleMappingPolicyReconciler{}
+	ap := &unstructured.Unstructured{}
+	ap.SetLabels(map[string]string{
+		labelManagedBy:                      labelManagedByValue,
+		labelToolRoleMappingPolicyName:      "tool-roles",
+		labelToolRoleMappingPolicyNamespace: "mcp-system",
+	})
+	reqs := r.enqueuePolicyForAuthPolicy(context.Background(), ap)
+	if len(reqs) != 1 || reqs[0].Namespace != "mcp-system" || reqs[0].Name != "tool-roles" {
+		t.Errorf("requests = %v", reqs)
+	}
+
+	ap.SetLabels(map[string]string{labelToolRoleMappingPolicyName: "tool-roles"})
+	if reqs := r.enqueuePolicyForAuthPolicy(context.Background(), ap); len(reqs) != 0 {
+		t.Errorf("unmanaged authpolicy enqueued %v", reqs)
+	}
+}
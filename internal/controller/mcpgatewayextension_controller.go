@@ -20,11 +20,14 @@ import (
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/event"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 
 	mcpv1 "github.com/Kuadrant/mcp-gateway/api/v1"
 	"github.com/Kuadrant/mcp-gateway/internal/config"
+	"github.com/Kuadrant/mcp-gateway/internal/configstream"
 	"github.com/Kuadrant/mcp-gateway/internal/guardrails"
 	"github.com/go-logr/logr"
 	"google.golang.org/protobuf/proto"
@@ -106,6 +109,11 @@ type ConfigWriterDeleter interface {
 	WriteGlobalGuardrails(ctx context.Context, guardrailsConfig *config.GuardrailsConfig, namespaceName types.NamespacedName) error
 }
 
+// ConfigStatusReporter reports the config stream versions for a namespace
+type ConfigStatusReporter interface {
+	Status(namespace string) configstream.Status
+}
+
 // MCPGatewayExtensionReconciler reconciles a MCPGatewayExtension object
 type MCPGatewayExtensionReconciler struct {
 	client.Client
@@ -118,6 +126,19 @@ type MCPGatewayExtensionReconciler struct {
 	// BrokerRouterLogLevel, when non-empty, is passed to the broker-router
 	// as --log-level (sourced from the BROKER_ROUTER_LOG_LEVEL env var)
 	BrokerRouterLogLevel string
+	// ConfigStreamAddress, when non-empty, is the controller config stream
+	// address the broker-router subscribes to instead of mounting the config Secret
+	ConfigStreamAddress string
+	// ConfigStreamCACert is the PEM CA bundle the broker-router verifies the
+	// config stream certificate against; empty uses the system roots
+	ConfigStreamCACert string
+	// ConfigStreamInsecure subscribes the broker-router over plaintext gRPC
+	ConfigStreamInsecure bool
+	// ConfigStatus reports the config versions applied by the broker-router,
+	// nil unless the config stream is enabled
+	ConfigStatus ConfigStatusReporter
+	// ConfigEvents receives an event for a namespace whose config status changed
+	ConfigEvents <-chan event.GenericEvent
 }
 
 // +kubebuilder:rbac:groups=mcp.kuadrant.io,resources=mcpgatewayextensions,verbs=get;list;watch;update
@@ -227,7 +248,9 @@ func (r *MCPGatewayExtensionReconciler) reconcileActive(ctx context.Context, mcp
 		return ctrl.Result{}, err
 	}
 
-	if err := r.reconcileSessionSigningKey(ctx, mcpExt); err != nil {
+	// requeue when the signing key ring is next due to advance
+	keyRotationDue, err := r.reconcileSessionSigningKey(ctx, mcpExt)
+	if err != nil {
 		var valErr *validationError
 		if errors.As(err, &valErr) {
 			return ctrl.Result{}, r.updateStatus(ctx, mcpExt, metav1.ConditionFalse, valErr.reason, valErr.message)
@@ -286,7 +309,7 @@ func (r *MCPGatewayExtensionReconciler) reconcileActive(ctx context.Context, mcp
 		return ctrl.Result{RequeueAfter: 5 * time.Second}, nil
 	}
 
-	return ctrl.Result{}, r.updateStatus(ctx, mcpExt, metav1.ConditionTrue, mcpv1.ConditionReasonSuccess, "successfully verified and configured")
+	return ctrl.Result{RequeueAfter: keyRotationDue}, r.updateStatus(ctx, mcpExt, metav1.ConditionTrue, mcpv1.ConditionReasonSuccess, "successfully verified and configured")
 }
 
 func (r *MCPGatewayExtensionReconciler) validateGatewayTarget(ctx context.Context, mcpExt *mcpv1.MCPGatewayExtension) (*gatewayv1.Gateway, *ListenerConfig, error) {
@@ -502,13 +525,50 @@ func (r *MCPGatewayExtensionReconciler) updateStatus(ctx context.Context, mcpExt
 
 	mcpExt.SetReadyCondition(status, reason, message)
 	updated := meta.FindStatusCondition(mcpExt.Status.Conditions, mcpv1.ConditionTypeReady)
+	configStatusChanged := r.setConfigStatus(mcpExt)
 
-	if existing != nil && equality.Semantic.DeepEqual(existingCopy, *updated) {
+	if existing != nil && equality.Semantic.DeepEqual(existingCopy, *updated) && !configStatusChanged {
 		return nil
 	}
 	return r.Status().Update(ctx, mcpExt)
 }
 
+// setConfigStatus copies the config stream versions into the status and
+// reports whether they changed
+func (r *MCPGatewayExtensionReconciler) setConfigStatus(mcpExt *mcpv1.MCPGatewayExtension) bool {
+	if r.ConfigStatus == nil {
+		return false
+	}
+	configStatus := r.ConfigStatus.Status(mcpExt.Namespace)
+	version := int64(configStatus.Version)        //nolint:gosec // versions are a counter that never nears MaxInt64
+	applied := int64(configStatus.AppliedVersion) //nolint:gosec // versions are a counter that never nears MaxInt64
+	if mcpExt.Status.ConfigVersion == version &&
+		mcpExt.Status.AppliedConfigVersion == applied &&
+		mcpExt.Status.ConfigError == configStatus.Error {
+		return false
+	}
+	mcpExt.Status.ConfigVersion = version
+	mcpExt.Status.AppliedConfigVersion = applied
+	mcpExt.Status.ConfigError = configStatus.Error
+	return true
+}
+
+// enqueueMCPGatewayExtForConfigEvent maps a config stream status change in a
+// namespace to the MCPGatewayExtensions in that namespace
+func (r *MCPGatewayExtensionReconciler) enqueueMCPGatewayExtForConfigEvent(ctx context.Context, obj client.Object) []reconcile.Request {
+	extList := &mcpv1.MCPGatewayExtensionList{}
+	if err := r.List(ctx, extList, client.InNamespace(obj.GetNamespace())); err != nil {
+		return nil
+	}
+	requests := make([]reconcile.Request, 0, len(extList.Items))
+	for _, ext := range extList.Items {
+		requests = append(requests, reconcile.Request{
+			NamespacedName: types.NamespacedName{Name: ext.Name, Namespace: ext.Namespace},
+		})
+	}
+	return requests
+}
+
 // GatewayListenerConditionType is the condition type for MCP Gateway Extension
 const GatewayListenerConditionType gatewayv1.ListenerConditionType = "MCPGatewayExtension"
 
@@ -975,7 +1035,7 @@ func (r *MCPGatewayExtensionReconciler) SetupWithManager(ctx context.Context, mg
 	// enqueue mcpgateway extensions when the gateway changes
 	// enqueue when reference grants change
 	// enqueue when envoy filter changes (cross-namespace, so we use Watches instead of Owns)
-	return ctrl.NewControllerManagedBy(mgr).
+	b := ctrl.NewControllerManagedBy(mgr).
 		For(&mcpv1.MCPGatewayExtension{}).
 		Owns(&appsv1.Deployment{}).
 		Owns(&corev1.Service{}).
@@ -984,7 +1044,10 @@ func (r *MCPGatewayExtensionReconciler) SetupWithManager(ctx context.Context, mg
 		Watches(&gatewayv1.Gateway{}, handler.EnqueueRequestsFromMapFunc(r.enqueueMCPGatewayExtForGateway)).
 		Watches(&gatewayv1beta1.ReferenceGrant{}, handler.EnqueueRequestsFromMapFunc(r.enqueueMCPGatewayExtForReferenceGrant)).
 		Watches(&istionetv1alpha3.EnvoyFilter{}, handler.EnqueueRequestsFromMapFunc(r.enqueueMCPGatewayExtForEnvoyFilter)).
-		Watches(&corev1.Secret{}, handler.EnqueueRequestsFromMapFunc(r.enqueueMCPGatewayExtForSecret)).
-		Named("mcpgatewayextension").
-		Complete(r)
+		Watches(&corev1.Secret{}, handler.EnqueueRequestsFromMapFunc(r.enqueueMCPGatewayExtForSecret))
+	// enqueue when a broker ACKs or NACKs a config stream version
+	if r.ConfigEvents != nil {
+		b = b.WatchesRawSource(source.Channel(r.ConfigEvents, handler.EnqueueRequestsFromMapFunc(r.enqueueMCPGatewayExtForConfigEvent)))
+	}
+	return b.Named("mcpgatewayextension").Complete(r)
 }
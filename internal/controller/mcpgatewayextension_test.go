@@ -4,6 +4,7 @@ import (
 	"testing"
 
 	mcpv1 "github.com/Kuadrant/mcp-gateway/api/v1"
+	"github.com/Kuadrant/mcp-gateway/internal/configstream"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/utils/ptr"
 	gatewayv1 "sigs.k8s.io/gateway-api/apis/v1"
@@ -261,3 +262,41 @@ func TestHTTPRouteAttachesToListener(t *testing.T) {
 		})
 	}
 }
+
+type fakeConfigStatus map[string]configstream.Status
+
+func (f fakeConfigStatus) Status(namespace string) configstream.Status {
+	return f[namespace]
+}
+
+func TestSetConfigStatus(t *testing.T) {
+	mcpExt := &mcpv1.MCPGatewayExtension{
+		ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "team-a"},
+	}
+
+	// file mode leaves the status untouched
+	r := &MCPGatewayExtensionReconciler{}
+	if r.setConfigStatus(mcpExt) {
+		t.Fatal("expected no change without a config status reporter")
+	}
+
+	statuses := fakeConfigStatus{"team-a": {Version: 5, AppliedVersion: 4, Brokers: 1, Error: "bad config"}}
+	r.ConfigStatus = statuses
+	if !r.setConfigStatus(mcpExt) {
+		t.Fatal("expected status change")
+	}
+	if mcpExt.Status.ConfigVersion != 5 || mcpExt.Status.AppliedConfigVersion != 4 || mcpExt.Status.ConfigError != "bad config" {
+		t.Errorf("unexpected status %+v", mcpExt.Status)
+	}
+	if r.setConfigStatus(mcpExt) {
+		t.Error("expected no change when versions are unchanged")
+	}
+
+	statuses["team-a"] = configstream.Status{Version: 6, AppliedVersion: 6, Brokers: 1}
+	if !r.setConfigStatus(mcpExt) {
+		t.Fatal("expected status change")
+	}
+	if mcpExt.Status.AppliedConfigVersion != 6 || mcpExt.Status.ConfigError != "" {
+		t.Errorf("unexpected status %+v", mcpExt.Status)
+	}
+}
@@ -2,12 +2,12 @@ package controller
 
 import (
 	"context"
-	"crypto/x509"
-	"encoding/pem"
 	"fmt"
 	"net"
 	"net/url"
+	"slices"
 	"strings"
+	"time"
 
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
@@ -40,6 +40,15 @@ const (
 	// maxCACertSize is the maximum allowed size for CA certificate PEM data (64 KiB)
 	// single CA cert; see maxCACertBundleSize for multi-cert bundles
 	maxCACertSize = 64 * 1024
+	// defaultConcurrencyQueueTimeoutMilliseconds matches the CRD default for
+	// spec.concurrency.queueTimeoutMilliseconds
+	defaultConcurrencyQueueTimeoutMilliseconds = 1000
+	// defaultRetryBudgetPercent matches the CRD default for
+	// spec.toolCalls.retry.budgetPercent
+	defaultRetryBudgetPercent = 20
+	// defaultResultCacheMaxResultBytes matches the CRD default for
+	// spec.resultCache.maxResultBytes
+	defaultResultCacheMaxResultBytes = 65536
 	// HTTPRouteIndex used to find MCPServerRegistrations
 	HTTPRouteIndex = "spec.targetRef.httproute"
 	// ProgrammedHTTPRouteIndex used to find programmed httproutes
@@ -53,6 +62,14 @@ const (
 	conditionReasonNotReady = "NotReady"
 	// conditionReasonDisabled is the reason used when the MCPServerRegistration is disabled
 	conditionReasonDisabled = "Disabled"
+	// conditionTypeMaintenance reports the maintenance window of a registration in the Maintenance state
+	conditionTypeMaintenance = "Maintenance"
+	// conditionReasonMaintenanceScheduled is the reason used before the maintenance window opens
+	conditionReasonMaintenanceScheduled = "MaintenanceScheduled"
+	// conditionReasonInMaintenance is the reason used while the maintenance window is open
+	conditionReasonInMaintenance = "InMaintenance"
+	// conditionReasonMaintenanceEnded is the reason used once the maintenance window has closed
+	conditionReasonMaintenanceEnded = "MaintenanceEnded"
 	// conditionReasonPrefixConflict is the reason used when another active MCPServerRegistration
 	// feeding the same MCPGatewayExtension already uses this prefix
 	conditionReasonPrefixConflict = "PrefixConflict"
@@ -94,6 +111,7 @@ type MCPReconciler struct {
 // +kubebuilder:rbac:groups=gateway.networking.k8s.io,resources=httproutes/status,verbs=update
 // +kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;watch;create;update;delete
 // +kubebuilder:rbac:groups="",resources=services,verbs=get
+// +kubebuilder:rbac:groups=rbac.authorization.k8s.io,resources=roles;rolebindings,verbs=get;create;update;delete
 
 // TODO: consider making targetRef immutable since changing it is not currently handled
 
@@ -254,6 +272,15 @@ func (r *MCPReconciler) Reconcile(ctx context.Context, req reconcile.Request) (r
 		}
 		return reconcile.Result{}, fmt.Errorf("failed to reconcile %s %w", mcpsr.Name, err)
 	}
+	if err := r.reconcileCredentialAccess(ctx, mcpsr, validNamespaces); err != nil {
+		if err := r.updateStatus(ctx, mcpsr, false, conditionReasonNotReady, err.Error()); err != nil {
+			if apierrors.IsConflict(err) {
+				return ctrl.Result{RequeueAfter: defaultRequeueTime}, nil
+			}
+			return ctrl.Result{}, fmt.Errorf("reconcile failed: status update failed %w", err)
+		}
+		return reconcile.Result{}, fmt.Errorf("failed to reconcile %s %w", mcpsr.Name, err)
+	}
 	for _, configNs := range validNamespaces {
 		if err := r.ConfigReaderWriter.UpsertMCPServer(ctx, *mcpServerconfig, config.NamespaceName(configNs)); err != nil {
 			if err := r.updateStatus(ctx, mcpsr, false, conditionReasonNotReady, err.Error()); err != nil {
@@ -290,7 +317,8 @@ func (r *MCPReconciler) Reconcile(ctx context.Context, req reconcile.Request) (r
 		return ctrl.Result{}, fmt.Errorf("reconcile failed: HTTPRoute status update failed %w", err)
 	}
 
-	return reconcile.Result{}, nil
+	// come back when the maintenance window opens or closes so the condition follows it
+	return reconcile.Result{RequeueAfter: untilMaintenanceBoundary(mcpsr, time.Now())}, nil
 
 }
 
@@ -480,16 +508,9 @@ func (r *MCPReconciler) buildMCPServerConfig(ctx context.Context, targetRoute *g
 	// scheme. the gateway CA bundle is shared trust material only and must not change the
 	// scheme: a plain-HTTP backend on a gateway that has a bundle stays HTTP. the upstream
 	// scheme otherwise comes from the service port (appProtocol/name https) in determineProtocol.
-	endpoint := serverInfo.Endpoint
-	if mcpsr.Spec.CACertSecretRef != nil {
-		u, err := url.Parse(endpoint)
-		if err != nil {
-			return nil, fmt.Errorf("failed to parse endpoint URL %q: %w", endpoint, err)
-		}
-		if strings.EqualFold(u.Scheme, "http") {
-			u.Scheme = "https"
-			endpoint = u.String()
-		}
+	endpoint, err := upstreamEndpoint(serverInfo.Endpoint, mcpsr.Spec.CACertSecretRef != nil)
+	if err != nil {
+		return nil, err
 	}
 
 	userSpecificListEnabled := mcpsr.Spec.UserSpecificList == mcpv1.UserSpecificListEnabled
@@ -503,6 +524,7 @@ func (r *MCPReconciler) buildMCPServerConfig(ctx context.Context, targetRoute *g
 		Category:         append([]string(nil), mcpsr.Spec.Category...),
 		Hint:             mcpsr.Spec.Hint,
 		UserSpecificList: userSpecificListEnabled,
+		Sampling:         mcpsr.Spec.Sampling == mcpv1.SamplingEnabled,
 		Tags:             append([]string(nil), mcpsr.Spec.Tags...),
 	}
 
@@ -512,32 +534,124 @@ func (r *MCPReconciler) buildMCPServerConfig(ctx context.Context, targetRoute *g
 		}
 	}
 
-	// add credential env var if configured
-	if mcpsr.Spec.CredentialRef != nil {
-		secret := &corev1.Secret{}
-		err := r.DirectAPIReader.Get(ctx, types.NamespacedName{
-			Name:      mcpsr.Spec.CredentialRef.Name,
-			Namespace: mcpsr.Namespace,
-		}, secret)
+	if mcpsr.Spec.State == mcpv1.ServerStateMaintenance {
+		serverConfig.State = config.StateMaintenance
+		if window := mcpsr.Spec.Maintenance; window != nil {
+			serverConfig.Maintenance = &config.MaintenanceConfig{Message: window.Message}
+			if window.Start != nil {
+				serverConfig.Maintenance.Start = ptr.To(window.Start.UTC())
+			}
+			if window.End != nil {
+				serverConfig.Maintenance.End = ptr.To(window.End.UTC())
+			}
+		}
+	}
+
+	if canary := mcpsr.Spec.Canary; canary != nil {
+		canaryRoute := &gatewayv1.HTTPRoute{}
+		if err := r.Get(ctx, types.NamespacedName{Namespace: mcpsr.Namespace, Name: canary.TargetRef.Name}, canaryRoute); err != nil {
+			return nil, fmt.Errorf("failed to get canary httproute %w", err)
+		}
+		canaryInfo, err := r.buildServerInfoFromHTTPRoute(ctx, canaryRoute, mcpsr.Spec.Path)
 		if err != nil {
-			if apierrors.IsNotFound(err) {
-				return nil, fmt.Errorf("credential secret %s not found", mcpsr.Spec.CredentialRef.Name)
+			return nil, fmt.Errorf("invalid canary httproute: %w", err)
+		}
+		canaryEndpoint, err := upstreamEndpoint(canaryInfo.Endpoint, mcpsr.Spec.CACertSecretRef != nil)
+		if err != nil {
+			return nil, err
+		}
+		serverConfig.Canary = &config.CanaryConfig{
+			URL:          canaryEndpoint,
+			Hostname:     canaryInfo.Hostname,
+			Weight:       int(canary.Weight),
+			ToolMismatch: string(canary.ToolMismatchPolicy),
+		}
+	}
+
+	if limit := mcpsr.Spec.Concurrency; limit != nil {
+		queueTimeout := int32(defaultConcurrencyQueueTimeoutMilliseconds)
+		if limit.QueueTimeoutMilliseconds != nil {
+			queueTimeout = *limit.QueueTimeoutMilliseconds
+		}
+		serverConfig.Concurrency = &config.ConcurrencyConfig{
+			MaxInFlight:              int(limit.MaxInFlight),
+			QueueTimeoutMilliseconds: int(queueTimeout),
+		}
+	}
+
+	if policy := mcpsr.Spec.ToolCalls; policy != nil {
+		toolCalls := &config.ToolCallConfig{}
+		if policy.TimeoutMilliseconds != nil {
+			toolCalls.TimeoutMilliseconds = int(*policy.TimeoutMilliseconds)
+		}
+		if retry := policy.Retry; retry != nil {
+			budget := int32(defaultRetryBudgetPercent)
+			if retry.BudgetPercent != nil {
+				budget = *retry.BudgetPercent
+			}
+			toolCalls.Retry = &config.RetryConfig{
+				MaxRetries:    int(retry.MaxRetries),
+				BudgetPercent: int(budget),
+			}
+			if retry.PerTryTimeoutMilliseconds != nil {
+				toolCalls.Retry.PerTryTimeoutMilliseconds = int(*retry.PerTryTimeoutMilliseconds)
 			}
-			return nil, fmt.Errorf("failed to get credential secret: %w", err)
 		}
+		serverConfig.ToolCalls = toolCalls
+	}
 
-		// check for required label
-		if secret.Labels == nil || secret.Labels[ManagedSecretLabel] != ManagedSecretValue {
-			return nil, fmt.Errorf("credential secret %s is missing required label %s=%s",
-				mcpsr.Spec.CredentialRef.Name, ManagedSecretLabel, ManagedSecretValue)
+	if cache := mcpsr.Spec.ResultCache; cache != nil {
+		scope := mcpv1.ToolResultCacheScopeSubject
+		if cache.Scope != "" {
+			scope = cache.Scope
 		}
+		maxResultBytes := int32(defaultResultCacheMaxResultBytes)
+		if cache.MaxResultBytes != nil {
+			maxResultBytes = *cache.MaxResultBytes
+		}
+		serverConfig.ResultCache = &config.ResultCacheConfig{
+			TTLSeconds:     int(cache.TTLSeconds),
+			Tools:          slices.Clone(cache.Tools),
+			Scope:          string(scope),
+			MaxResultBytes: int(maxResultBytes),
+		}
+	}
 
-		val, ok := secret.Data[mcpsr.Spec.CredentialRef.Key]
-		if !ok {
-			return nil, fmt.Errorf("credential secret %s missing key %s", mcpsr.Spec.CredentialRef.Name, mcpsr.Spec.CredentialRef.Key)
+	// validate the credential secret now so a broken reference shows up on the
+	// registration status, but only hand the broker a reference to it: the
+	// broker resolves the value at use time, so the shared config never
+	// carries credentials
+	if mcpsr.Spec.CredentialRef != nil {
+		if err := r.validateReferencedSecret(ctx, mcpsr.Namespace, mcpsr.Spec.CredentialRef, "credential secret"); err != nil {
+			return nil, err
 		}
-		serverConfig.Credential = string(val)
+		serverConfig.CredentialRef = &config.CredentialReference{
+			Namespace: mcpsr.Namespace,
+			Name:      mcpsr.Spec.CredentialRef.Name,
+			Key:       mcpsr.Spec.CredentialRef.Key,
+		}
+	}
 
+	// the OAuth client secret is handled like the credential: validated
+	// here, resolved by the broker
+	if elicit := mcpsr.Spec.TokenURLElicitation; elicit != nil && elicit.OAuth != nil {
+		oauth := &config.UpstreamOAuthConfig{
+			AuthorizationURL: elicit.OAuth.AuthorizationURL,
+			TokenURL:         elicit.OAuth.TokenURL,
+			ClientID:         elicit.OAuth.ClientID,
+			Scopes:           append([]string(nil), elicit.OAuth.Scopes...),
+		}
+		if ref := elicit.OAuth.ClientSecretRef; ref != nil {
+			if err := r.validateReferencedSecret(ctx, mcpsr.Namespace, ref, "OAuth client secret"); err != nil {
+				return nil, err
+			}
+			oauth.ClientSecretRef = &config.CredentialReference{
+				Namespace: mcpsr.Namespace,
+				Name:      ref.Name,
+				Key:       ref.Key,
+			}
+		}
+		serverConfig.TokenURLElicitation.OAuth = oauth
 	}
 
 	if mcpsr.Spec.CACertSecretRef != nil {
@@ -569,7 +683,7 @@ func (r *MCPReconciler) buildMCPServerConfig(ctx context.Context, targetRoute *g
 		if len(val) > maxCACertSize {
 			return nil, fmt.Errorf("CA certificate data in secret %s exceeds maximum size (%d bytes)", mcpsr.Spec.CACertSecretRef.Name, maxCACertSize)
 		}
-		if err := validateCACertPEM(val); err != nil {
+		if err := config.ValidateCACertPEM(val); err != nil {
 			return nil, fmt.Errorf("CA certificate in secret %s is invalid: %w", mcpsr.Spec.CACertSecretRef.Name, err)
 		}
 		serverConfig.CACert = string(val)
@@ -578,6 +692,22 @@ func (r *MCPReconciler) buildMCPServerConfig(ctx context.Context, targetRoute *g
 	return &serverConfig, nil
 }
 
+// upstreamEndpoint returns endpoint with its scheme upgraded to https when
+// the server has its own CA cert
+func upstreamEndpoint(endpoint string, hasCACert bool) (string, error) {
+	if !hasCACert {
+		return endpoint, nil
+	}
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse endpoint URL %q: %w", endpoint, err)
+	}
+	if strings.EqualFold(u.Scheme, "http") {
+		u.Scheme = "https"
+	}
+	return u.String(), nil
+}
+
 func (r *MCPReconciler) buildServerInfoFromHTTPRoute(ctx context.Context, httpRoute *gatewayv1.HTTPRoute, path string) (*ServerInfo, error) {
 	route := WrapHTTPRoute(httpRoute)
 
@@ -780,6 +910,9 @@ func (r *MCPReconciler) updateStatus(
 		mcpsr.Status.Conditions = append(mcpsr.Status.Conditions, condition)
 		statusChanged = true
 	}
+	if setMaintenanceCondition(mcpsr, time.Now()) {
+		statusChanged = true
+	}
 
 	if !statusChanged {
 		return nil
@@ -851,15 +984,23 @@ func setupIndexProgrammedHTTPRoutes(ctx context.Context, indexer client.FieldInd
 func setupIndexMCPRegistrationToHTTPRoute(ctx context.Context, indexer client.FieldIndexer) error {
 	if err := indexer.IndexField(ctx, &mcpv1.MCPServerRegistration{}, HTTPRouteIndex, func(rawObj client.Object) []string {
 		mcpsr := rawObj.(*mcpv1.MCPServerRegistration)
-		targetRef := mcpsr.Spec.TargetRef
-		if targetRef.Kind == "HTTPRoute" {
+		targetRefs := []mcpv1.TargetReference{mcpsr.Spec.TargetRef}
+		// a canary route change must also reconcile the registration
+		if mcpsr.Spec.Canary != nil {
+			targetRefs = append(targetRefs, mcpsr.Spec.Canary.TargetRef)
+		}
+		values := []string{}
+		for _, targetRef := range targetRefs {
+			if targetRef.Kind != "HTTPRoute" {
+				continue
+			}
 			namespace := targetRef.Namespace
 			if namespace == "" {
 				namespace = mcpsr.Namespace
 			}
-			return []string{httpRouteIndexValue(namespace, targetRef.Name)}
+			values = append(values, httpRouteIndexValue(namespace, targetRef.Name))
 		}
-		return []string{}
+		return values
 	}); err != nil {
 		return err
 	}
@@ -907,42 +1048,39 @@ func (r *MCPReconciler) findMCPServerRegistrationsForHTTPRoute(ctx context.Conte
 	return requests
 }
 
-// validateCACertPEM checks that the data contains at least one valid PEM-encoded
-// CA certificate. Certificates that explicitly declare BasicConstraints CA:FALSE
-// are rejected; certificates that omit BasicConstraints entirely are accepted,
-// since older root CAs and dev certs often don't set it.
-func validateCACertPEM(data []byte) error {
-	rest := data
-	found := false
-	for {
-		var block *pem.Block
-		block, rest = pem.Decode(rest)
-		if block == nil {
-			break
-		}
-		if block.Type != "CERTIFICATE" {
-			return fmt.Errorf("unexpected PEM block type %q, expected CERTIFICATE", block.Type)
-		}
-		cert, err := x509.ParseCertificate(block.Bytes)
-		if err != nil {
-			return fmt.Errorf("failed to parse certificate: %w", err)
-		}
-		if cert.BasicConstraintsValid && !cert.IsCA {
-			return fmt.Errorf("certificate for %q is not a CA certificate", cert.Subject.CommonName)
+// validateReferencedSecret checks that a Secret the broker will resolve
+// exists, carries the managed secret label and holds the referenced key.
+// what names the secret in errors.
+func (r *MCPReconciler) validateReferencedSecret(ctx context.Context, namespace string, ref *mcpv1.SecretReference, what string) error {
+	secret := &corev1.Secret{}
+	err := r.DirectAPIReader.Get(ctx, types.NamespacedName{
+		Name:      ref.Name,
+		Namespace: namespace,
+	}, secret)
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return fmt.Errorf("%s %s not found", what, ref.Name)
 		}
-		found = true
+		return fmt.Errorf("failed to get %s: %w", what, err)
 	}
-	if !found {
-		return fmt.Errorf("no valid PEM certificate blocks found")
+
+	// check for required label
+	if secret.Labels == nil || secret.Labels[ManagedSecretLabel] != ManagedSecretValue {
+		return fmt.Errorf("%s %s is missing required label %s=%s",
+			what, ref.Name, ManagedSecretLabel, ManagedSecretValue)
+	}
+
+	if _, ok := secret.Data[ref.Key]; !ok {
+		return fmt.Errorf("%s %s missing key %s", what, ref.Name, ref.Key)
 	}
 	return nil
 }
 
 // mcpsrReferencesSecret checks whether a MCPServerRegistration references the named secret
-// via either credentialRef or caCertSecretRef.
+// via credentialRef, caCertSecretRef or the OAuth clientSecretRef.
 func mcpsrReferencesSecret(spec mcpv1.MCPServerRegistrationSpec, secretName string) bool {
-	return (spec.CredentialRef != nil && spec.CredentialRef.Name == secretName) ||
-		(spec.CACertSecretRef != nil && spec.CACertSecretRef.Name == secretName)
+	return (spec.CACertSecretRef != nil && spec.CACertSecretRef.Name == secretName) ||
+		slices.Contains(credentialSecretNames(spec), secretName)
 }
 
 // findMCPServerRegistrationsForSecret finds MCPServerRegistrations referencing the given secret
@@ -1041,3 +1179,50 @@ func (r *MCPReconciler) findMCPServerRegistrationsForMCPGatewayExtension(ctx con
 	logger.V(1).Info("Found MCPServerRegistrations for MCPGatewayExtension", "count", len(requests))
 	return requests
 }
+
+// setMaintenanceCondition reflects the maintenance window in the Maintenance
+// condition, which is removed when the registration is not in the Maintenance
+// state. It reports whether the conditions changed.
+func setMaintenanceCondition(mcpsr *mcpv1.MCPServerRegistration, now time.Time) bool {
+	if mcpsr.Spec.State != mcpv1.ServerStateMaintenance {
+		return meta.RemoveStatusCondition(&mcpsr.Status.Conditions, conditionTypeMaintenance)
+	}
+	condition := metav1.Condition{
+		Type:               conditionTypeMaintenance,
+		Status:             metav1.ConditionTrue,
+		Reason:             conditionReasonInMaintenance,
+		Message:            "tool calls are rejected until state changes",
+		ObservedGeneration: mcpsr.Generation,
+	}
+	if window := mcpsr.Spec.Maintenance; window != nil {
+		switch {
+		case window.Start != nil && now.Before(window.Start.Time):
+			condition.Status = metav1.ConditionFalse
+			condition.Reason = conditionReasonMaintenanceScheduled
+			condition.Message = "maintenance starts at " + window.Start.UTC().Format(time.RFC3339)
+		case window.End != nil && !now.Before(window.End.Time):
+			condition.Status = metav1.ConditionFalse
+			condition.Reason = conditionReasonMaintenanceEnded
+			condition.Message = "maintenance ended at " + window.End.UTC().Format(time.RFC3339)
+		case window.End != nil:
+			condition.Message = "tool calls are rejected until " + window.End.UTC().Format(time.RFC3339)
+		}
+	}
+	return meta.SetStatusCondition(&mcpsr.Status.Conditions, condition)
+}
+
+// untilMaintenanceBoundary returns how long until the registration's
+// maintenance window next opens or closes, or 0 when no boundary is ahead
+func untilMaintenanceBoundary(mcpsr *mcpv1.MCPServerRegistration, now time.Time) time.Duration {
+	window := mcpsr.Spec.Maintenance
+	if mcpsr.Spec.State != mcpv1.ServerStateMaintenance || window == nil {
+		return 0
+	}
+	for _, boundary := range []*metav1.Time{window.Start, window.End} {
+		if boundary != nil && now.Before(boundary.Time) {
+			// a second late so the condition is past the boundary when reconciled
+			return boundary.Sub(now) + time.Second
+		}
+	}
+	return 0
+}
@@ -18,6 +18,7 @@ import (
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -821,12 +822,33 @@ var _ = Describe("MCPServerRegistration Controller", func() {
 				for _, server := range configWriter.upsertedServers {
 					if server.Name == fmt.Sprintf("default/%s", resourceName) {
 						g.Expect(server.CACert).To(ContainSubstring("BEGIN CERTIFICATE"))
-						g.Expect(server.Credential).To(Equal("Bearer test-token"))
+						g.Expect(server.Credential).To(BeEmpty())
+						g.Expect(server.CredentialRef).To(Equal(&config.CredentialReference{
+							Namespace: "default",
+							Name:      "test-cred-with-ca",
+							Key:       "token",
+						}))
 						return
 					}
 				}
 				g.Expect(false).To(BeTrue(), "server not found in upserted configs")
 			}, testTimeout, testRetryInterval).Should(Succeed())
+
+			// the broker is granted read access to the credential secret only
+			role := &rbacv1.Role{}
+			Expect(testK8sClient.Get(ctx, types.NamespacedName{
+				Name:      credentialAccessPrefix + resourceName,
+				Namespace: "default",
+			}, role)).To(Succeed())
+			Expect(role.Rules).To(HaveLen(1))
+			Expect(role.Rules[0].ResourceNames).To(Equal([]string{"test-cred-with-ca"}))
+			Expect(role.Rules[0].Verbs).To(Equal([]string{"get"}))
+			binding := &rbacv1.RoleBinding{}
+			Expect(testK8sClient.Get(ctx, client.ObjectKeyFromObject(role), binding)).To(Succeed())
+			Expect(binding.Subjects).NotTo(BeEmpty())
+			for _, subject := range binding.Subjects {
+				Expect(subject.Name).To(Equal(brokerRouterName))
+			}
 		})
 
 		It("should fail when CA cert contains invalid PEM data", func() {
@@ -1,20 +1,19 @@
 package controller
 
 import (
-	"crypto/ecdsa"
-	"crypto/elliptic"
-	"crypto/rand"
-	"crypto/x509"
-	"crypto/x509/pkix"
-	"encoding/pem"
-	"math/big"
-	"strings"
+	"context"
+	"reflect"
 	"testing"
 	"time"
 
 	mcpv1 "github.com/Kuadrant/mcp-gateway/api/v1"
+	"github.com/Kuadrant/mcp-gateway/internal/config"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 	gatewayv1 "sigs.k8s.io/gateway-api/apis/v1"
 )
 
@@ -24,7 +23,9 @@ func TestMcpsrReferencesSecret(t *testing.T) {
 		secretName string
 		credRef    *mcpv1.SecretReference
 		caCertRef  *mcpv1.CACertSecretReference
-		wantMatch  bool
+		// oauthSecret is the upstream OAuth clientSecretRef
+		oauthSecret *mcpv1.SecretReference
+		wantMatch   bool
 	}{
 		{
 			name:       "matches caCertSecretRef",
@@ -52,6 +53,13 @@ func TestMcpsrReferencesSecret(t *testing.T) {
 			caCertRef:  &mcpv1.CACertSecretReference{Name: "my-ca"},
 			wantMatch:  false,
 		},
+		{
+			name:        "matches OAuth clientSecretRef",
+			secretName:  "my-oauth",
+			credRef:     &mcpv1.SecretReference{Name: "my-cred"},
+			oauthSecret: &mcpv1.SecretReference{Name: "my-oauth", Key: "client_secret"},
+			wantMatch:   true,
+		},
 		{
 			name:       "nil refs",
 			secretName: "any",
@@ -65,6 +73,11 @@ func TestMcpsrReferencesSecret(t *testing.T) {
 				CredentialRef:   tt.credRef,
 				CACertSecretRef: tt.caCertRef,
 			}
+			if tt.oauthSecret != nil {
+				spec.TokenURLElicitation = &mcpv1.TokenURLElicitationConfig{
+					OAuth: &mcpv1.UpstreamOAuthClient{ClientSecretRef: tt.oauthSecret},
+				}
+			}
 			if got := mcpsrReferencesSecret(spec, tt.secretName); got != tt.wantMatch {
 				t.Errorf("mcpsrReferencesSecret() = %v, want %v", got, tt.wantMatch)
 			}
@@ -72,147 +85,6 @@ func TestMcpsrReferencesSecret(t *testing.T) {
 	}
 }
 
-func testCACertPEM(t *testing.T) []byte {
-	t.Helper()
-	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
-	if err != nil {
-		t.Fatal(err)
-	}
-	template := &x509.Certificate{
-		SerialNumber:          big.NewInt(1),
-		Subject:               pkix.Name{CommonName: "Test CA"},
-		NotBefore:             time.Now().Add(-time.Hour),
-		NotAfter:              time.Now().Add(time.Hour),
-		KeyUsage:              x509.KeyUsageCertSign,
-		BasicConstraintsValid: true,
-		IsCA:                  true,
-	}
-	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
-	if err != nil {
-		t.Fatal(err)
-	}
-	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
-}
-
-// leafCertPEM builds a cert like a server's own TLS cert: BasicConstraints is
-// present and explicitly says IsCA=false. This is what someone gets by mistakenly
-// pasting a leaf cert into ca.crt instead of the issuing CA.
-func leafCertPEM(t *testing.T) []byte {
-	t.Helper()
-	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
-	if err != nil {
-		t.Fatal(err)
-	}
-	template := &x509.Certificate{
-		SerialNumber:          big.NewInt(1),
-		Subject:               pkix.Name{CommonName: "my-service.example.com"},
-		NotBefore:             time.Now().Add(-time.Hour),
-		NotAfter:              time.Now().Add(time.Hour),
-		KeyUsage:              x509.KeyUsageDigitalSignature,
-		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
-		BasicConstraintsValid: true,
-		IsCA:                  false,
-	}
-	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
-	if err != nil {
-		t.Fatal(err)
-	}
-	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
-}
-
-// legacyRootCertPEM builds a cert like an old-style self-signed root CA that never
-// set the BasicConstraints extension at all. These must keep validating successfully.
-func legacyRootCertPEM(t *testing.T) []byte {
-	t.Helper()
-	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
-	if err != nil {
-		t.Fatal(err)
-	}
-	template := &x509.Certificate{
-		SerialNumber:          big.NewInt(2),
-		Subject:               pkix.Name{CommonName: "Legacy Root CA"},
-		NotBefore:             time.Now().Add(-time.Hour),
-		NotAfter:              time.Now().Add(time.Hour),
-		KeyUsage:              x509.KeyUsageCertSign,
-		BasicConstraintsValid: false,
-	}
-	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
-	if err != nil {
-		t.Fatal(err)
-	}
-	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
-}
-
-func TestValidateCACertPEM(t *testing.T) {
-	validPEM := testCACertPEM(t)
-
-	tests := []struct {
-		name    string
-		data    []byte
-		wantErr string
-	}{
-		{
-			name: "valid single cert",
-			data: validPEM,
-		},
-		{
-			name: "valid chain",
-			data: append(validPEM, testCACertPEM(t)...),
-		},
-		{
-			name:    "not PEM at all",
-			data:    []byte("this is not PEM data"),
-			wantErr: "no valid PEM certificate blocks found",
-		},
-		{
-			name:    "empty",
-			data:    []byte{},
-			wantErr: "no valid PEM certificate blocks found",
-		},
-		{
-			name:    "wrong block type",
-			data:    pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: []byte("fake")}),
-			wantErr: "unexpected PEM block type",
-		},
-		{
-			name:    "corrupt certificate DER",
-			data:    pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: []byte("not-valid-der")}),
-			wantErr: "failed to parse certificate",
-		},
-		{
-			name:    "leaf certificate explicitly not a CA",
-			data:    leafCertPEM(t),
-			wantErr: "not a CA certificate",
-		},
-		{
-			name: "legacy root CA without BasicConstraints must still be accepted",
-			data: legacyRootCertPEM(t),
-		},
-		{
-			name:    "chain with valid CA followed by a leaf cert",
-			data:    append(testCACertPEM(t), leafCertPEM(t)...),
-			wantErr: "not a CA certificate",
-		},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			err := validateCACertPEM(tt.data)
-			if tt.wantErr == "" {
-				if err != nil {
-					t.Errorf("validateCACertPEM() unexpected error: %v", err)
-				}
-			} else {
-				if err == nil {
-					t.Errorf("validateCACertPEM() expected error containing %q, got nil", tt.wantErr)
-				} else if got := err.Error(); !strings.Contains(got, tt.wantErr) {
-					t.Errorf("validateCACertPEM() error = %q, want substring %q", got, tt.wantErr)
-				}
-			}
-		})
-	}
-}
-
 func TestIsValidHostname(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -403,3 +275,479 @@ func TestFindOldestMCPServerRegistration_TieBreakIsSymmetric(t *testing.T) {
 		t.Errorf("expected the lower UID (%q) to win the tie, got %q", a.UID, fromA.UID)
 	}
 }
+
+// externalHostnameRoute returns an HTTPRoute to an external host, which
+// buildMCPServerConfig resolves without reading from the cluster
+func externalHostnameRoute() *gatewayv1.HTTPRoute {
+	return &gatewayv1.HTTPRoute{
+		ObjectMeta: metav1.ObjectMeta{Name: "weather", Namespace: "mcp-test"},
+		Spec: gatewayv1.HTTPRouteSpec{
+			Hostnames: []gatewayv1.Hostname{"weather.mcp.local"},
+			Rules: []gatewayv1.HTTPRouteRule{{
+				BackendRefs: []gatewayv1.HTTPBackendRef{{
+					BackendRef: gatewayv1.BackendRef{
+						BackendObjectReference: gatewayv1.BackendObjectReference{
+							Group: ptrTo(gatewayv1.Group("networking.istio.io")),
+							Kind:  ptrTo(gatewayv1.Kind("Hostname")),
+							Name:  "api.weather.example.com",
+						},
+					},
+				}},
+			}},
+		},
+	}
+}
+
+func TestBuildMCPServerConfig_Concurrency(t *testing.T) {
+	route := externalHostnameRoute()
+
+	tests := []struct {
+		name        string
+		concurrency *mcpv1.ConcurrencyLimit
+		want        *config.ConcurrencyConfig
+	}{
+		{
+			name: "unset",
+		},
+		{
+			name:        "queue timeout defaulted",
+			concurrency: &mcpv1.ConcurrencyLimit{MaxInFlight: 2},
+			want:        &config.ConcurrencyConfig{MaxInFlight: 2, QueueTimeoutMilliseconds: 1000},
+		},
+		{
+			name:        "explicit zero queue timeout",
+			concurrency: &mcpv1.ConcurrencyLimit{MaxInFlight: 4, QueueTimeoutMilliseconds: ptrTo(int32(0))},
+			want:        &config.ConcurrencyConfig{MaxInFlight: 4},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			mcpsr := &mcpv1.MCPServerRegistration{
+				ObjectMeta: metav1.ObjectMeta{Name: "weather", Namespace: "mcp-test"},
+				Spec: mcpv1.MCPServerRegistrationSpec{
+					Prefix:      "weather_",
+					Path:        "/mcp",
+					Concurrency: tc.concurrency,
+				},
+			}
+			r := &MCPReconciler{}
+			got, err := r.buildMCPServerConfig(context.Background(), route, mcpsr)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got.Concurrency, tc.want) {
+				t.Errorf("expected concurrency %+v, got %+v", tc.want, got.Concurrency)
+			}
+		})
+	}
+}
+
+func TestBuildMCPServerConfig_ToolCalls(t *testing.T) {
+	route := externalHostnameRoute()
+
+	tests := []struct {
+		name      string
+		toolCalls *mcpv1.ToolCallPolicy
+		want      *config.ToolCallConfig
+	}{
+		{
+			name: "unset",
+		},
+		{
+			name:      "timeout only",
+			toolCalls: &mcpv1.ToolCallPolicy{TimeoutMilliseconds: ptrTo(int32(5000))},
+			want:      &config.ToolCallConfig{TimeoutMilliseconds: 5000},
+		},
+		{
+			name: "retry budget defaulted",
+			toolCalls: &mcpv1.ToolCallPolicy{
+				TimeoutMilliseconds: ptrTo(int32(5000)),
+				Retry:               &mcpv1.ToolCallRetry{MaxRetries: 2, PerTryTimeoutMilliseconds: ptrTo(int32(2000))},
+			},
+			want: &config.ToolCallConfig{
+				TimeoutMilliseconds: 5000,
+				Retry:               &config.RetryConfig{MaxRetries: 2, PerTryTimeoutMilliseconds: 2000, BudgetPercent: 20},
+			},
+		},
+		{
+			name: "explicit zero budget",
+			toolCalls: &mcpv1.ToolCallPolicy{
+				Retry: &mcpv1.ToolCallRetry{MaxRetries: 1, BudgetPercent: ptrTo(int32(0))},
+			},
+			want: &config.ToolCallConfig{Retry: &config.RetryConfig{MaxRetries: 1}},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			mcpsr := &mcpv1.MCPServerRegistration{
+				ObjectMeta: metav1.ObjectMeta{Name: "weather", Namespace: "mcp-test"},
+				Spec: mcpv1.MCPServerRegistrationSpec{
+					Prefix:    "weather_",
+					Path:      "/mcp",
+					ToolCalls: tc.toolCalls,
+				},
+			}
+			r := &MCPReconciler{}
+			got, err := r.buildMCPServerConfig(context.Background(), route, mcpsr)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got.ToolCalls, tc.want) {
+				t.Errorf("expected tool calls %+v, got %+v", tc.want, got.ToolCalls)
+			}
+		})
+	}
+}
+
+func TestBuildMCPServerConfig_ResultCache(t *testing.T) {
+	route := externalHostnameRoute()
+
+	tests := []struct {
+		name        string
+		resultCache *mcpv1.ToolResultCache
+		want        *config.ResultCacheConfig
+	}{
+		{
+			name: "unset",
+		},
+		{
+			name:        "defaulted",
+			resultCache: &mcpv1.ToolResultCache{TTLSeconds: 300},
+			want:        &config.ResultCacheConfig{TTLSeconds: 300, Scope: config.ResultCacheScopeSubject, MaxResultBytes: 65536},
+		},
+		{
+			name: "explicit",
+			resultCache: &mcpv1.ToolResultCache{
+				TTLSeconds:     60,
+				Tools:          []string{"lookup", "search"},
+				Scope:          mcpv1.ToolResultCacheScopeShared,
+				MaxResultBytes: ptrTo(int32(4096)),
+			},
+			want: &config.ResultCacheConfig{
+				TTLSeconds:     60,
+				Tools:          []string{"lookup", "search"},
+				Scope:          config.ResultCacheScopeShared,
+				MaxResultBytes: 4096,
+			},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			mcpsr := &mcpv1.MCPServerRegistration{
+				ObjectMeta: metav1.ObjectMeta{Name: "catalog", Namespace: "mcp-test"},
+				Spec: mcpv1.MCPServerRegistrationSpec{
+					Prefix:      "catalog_",
+					Path:        "/mcp",
+					ResultCache: tc.resultCache,
+				},
+			}
+			r := &MCPReconciler{}
+			got, err := r.buildMCPServerConfig(context.Background(), route, mcpsr)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got.ResultCache, tc.want) {
+				t.Errorf("expected result cache %+v, got %+v", tc.want, got.ResultCache)
+			}
+		})
+	}
+}
+
+func TestSetMaintenanceCondition(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	before := metav1.NewTime(now.Add(-time.Hour))
+	after := metav1.NewTime(now.Add(time.Hour))
+
+	tests := []struct {
+		name          string
+		state         mcpv1.ServerState
+		window        *mcpv1.MaintenanceWindow
+		wantCondition bool
+		wantStatus    metav1.ConditionStatus
+		wantReason    string
+		wantMessage   string
+	}{
+		{
+			name:  "enabled",
+			state: mcpv1.ServerStateEnabled,
+		},
+		{
+			name:          "maintenance without a window",
+			state:         mcpv1.ServerStateMaintenance,
+			wantCondition: true,
+			wantStatus:    metav1.ConditionTrue,
+			wantReason:    conditionReasonInMaintenance,
+			wantMessage:   "tool calls are rejected until state changes",
+		},
+		{
+			name:          "scheduled",
+			state:         mcpv1.ServerStateMaintenance,
+			window:        &mcpv1.MaintenanceWindow{Start: &after},
+			wantCondition: true,
+			wantStatus:    metav1.ConditionFalse,
+			wantReason:    conditionReasonMaintenanceScheduled,
+			wantMessage:   "maintenance starts at 2026-03-01T13:00:00Z",
+		},
+		{
+			name:          "open window",
+			state:         mcpv1.ServerStateMaintenance,
+			window:        &mcpv1.MaintenanceWindow{Start: &before, End: &after, Message: "upgrading"},
+			wantCondition: true,
+			wantStatus:    metav1.ConditionTrue,
+			wantReason:    conditionReasonInMaintenance,
+			wantMessage:   "tool calls are rejected until 2026-03-01T13:00:00Z",
+		},
+		{
+			name:          "ended",
+			state:         mcpv1.ServerStateMaintenance,
+			window:        &mcpv1.MaintenanceWindow{End: &before},
+			wantCondition: true,
+			wantStatus:    metav1.ConditionFalse,
+			wantReason:    conditionReasonMaintenanceEnded,
+			wantMessage:   "maintenance ended at 2026-03-01T11:00:00Z",
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			mcpsr := &mcpv1.MCPServerRegistration{
+				Spec: mcpv1.MCPServerRegistrationSpec{State: tc.state, Maintenance: tc.window},
+			}
+			changed := setMaintenanceCondition(mcpsr, now)
+			if changed != tc.wantCondition {
+				t.Errorf("expected changed %v, got %v", tc.wantCondition, changed)
+			}
+			cond := meta.FindStatusCondition(mcpsr.Status.Conditions, conditionTypeMaintenance)
+			if !tc.wantCondition {
+				if cond != nil {
+					t.Fatalf("expected no Maintenance condition, got %+v", cond)
+				}
+				return
+			}
+			if cond == nil {
+				t.Fatal("expected a Maintenance condition")
+			}
+			if cond.Status != tc.wantStatus || cond.Reason != tc.wantReason || cond.Message != tc.wantMessage {
+				t.Errorf("expected %s/%s/%q, got %s/%s/%q", tc.wantStatus, tc.wantReason, tc.wantMessage, cond.Status, cond.Reason, cond.Message)
+			}
+			if setMaintenanceCondition(mcpsr, now) {
+				t.Error("expected no change when the window is unchanged")
+			}
+		})
+	}
+}
+
+func TestSetMaintenanceCondition_RemovedWhenEnabled(t *testing.T) {
+	mcpsr := &mcpv1.MCPServerRegistration{
+		Spec: mcpv1.MCPServerRegistrationSpec{State: mcpv1.ServerStateMaintenance},
+	}
+	now := time.Now()
+	if !setMaintenanceCondition(mcpsr, now) {
+		t.Fatal("expected the Maintenance condition to be added")
+	}
+	mcpsr.Spec.State = mcpv1.ServerStateEnabled
+	if !setMaintenanceCondition(mcpsr, now) {
+		t.Fatal("expected the Maintenance condition to be removed")
+	}
+	if meta.FindStatusCondition(mcpsr.Status.Conditions, conditionTypeMaintenance) != nil {
+		t.Error("expected no Maintenance condition")
+	}
+}
+
+func TestUntilMaintenanceBoundary(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	start := metav1.NewTime(now.Add(time.Hour))
+	end := metav1.NewTime(now.Add(3 * time.Hour))
+	past := metav1.NewTime(now.Add(-time.Hour))
+
+	tests := []struct {
+		name   string
+		state  mcpv1.ServerState
+		window *mcpv1.MaintenanceWindow
+		want   time.Duration
+	}{
+		{name: "enabled", state: mcpv1.ServerStateEnabled, window: &mcpv1.MaintenanceWindow{Start: &start}},
+		{name: "no window", state: mcpv1.ServerStateMaintenance},
+		{name: "before start", state: mcpv1.ServerStateMaintenance, window: &mcpv1.MaintenanceWindow{Start: &start, End: &end}, want: time.Hour + time.Second},
+		{name: "before end", state: mcpv1.ServerStateMaintenance, window: &mcpv1.MaintenanceWindow{Start: &past, End: &end}, want: 3*time.Hour + time.Second},
+		{name: "ended", state: mcpv1.ServerStateMaintenance, window: &mcpv1.MaintenanceWindow{End: &past}},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			mcpsr := &mcpv1.MCPServerRegistration{
+				Spec: mcpv1.MCPServerRegistrationSpec{State: tc.state, Maintenance: tc.window},
+			}
+			if got := untilMaintenanceBoundary(mcpsr, now); got != tc.want {
+				t.Errorf("expected %v, got %v", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestBuildMCPServerConfig_Maintenance(t *testing.T) {
+	route := externalHostnameRoute()
+	end := metav1.NewTime(time.Date(2026, 3, 1, 14, 0, 0, 0, time.UTC))
+	window := &mcpv1.MaintenanceWindow{End: &end, Message: "upgrading"}
+
+	r := &MCPReconciler{}
+	for _, state := range []mcpv1.ServerState{mcpv1.ServerStateEnabled, mcpv1.ServerStateMaintenance} {
+		mcpsr := &mcpv1.MCPServerRegistration{
+			ObjectMeta: metav1.ObjectMeta{Name: "weather", Namespace: "mcp-test"},
+			Spec: mcpv1.MCPServerRegistrationSpec{
+				Prefix:      "weather_",
+				Path:        "/mcp",
+				State:       state,
+				Maintenance: window,
+			},
+		}
+		got, err := r.buildMCPServerConfig(context.Background(), route, mcpsr)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if state != mcpv1.ServerStateMaintenance {
+			if got.Maintenance != nil {
+				t.Errorf("expected the window to be dropped for state %s, got %+v", state, got.Maintenance)
+			}
+			continue
+		}
+		if got.State != config.StateMaintenance || got.Maintenance == nil {
+			t.Fatalf("expected a maintenance window, got state %q and %+v", got.State, got.Maintenance)
+		}
+		if got.Maintenance.Start != nil || !got.Maintenance.End.Equal(end.Time) || got.Maintenance.Message != "upgrading" {
+			t.Errorf("unexpected maintenance window %+v", got.Maintenance)
+		}
+	}
+}
+
+func TestBuildMCPServerConfig_Canary(t *testing.T) {
+	canaryRoute := externalHostnameRoute()
+	canaryRoute.Name = "weather-v2"
+	canaryRoute.Spec.Hostnames = []gatewayv1.Hostname{"weather-v2.mcp.local"}
+	canaryRoute.Spec.Rules[0].BackendRefs[0].Name = "v2.api.weather.example.com"
+
+	scheme := runtime.NewScheme()
+	_ = gatewayv1.Install(scheme)
+	r := &MCPReconciler{Client: fake.NewClientBuilder().WithScheme(scheme).WithObjects(canaryRoute).Build()}
+
+	mcpsr := &mcpv1.MCPServerRegistration{
+		ObjectMeta: metav1.ObjectMeta{Name: "weather", Namespace: "mcp-test"},
+		Spec: mcpv1.MCPServerRegistrationSpec{
+			Prefix: "weather_",
+			Path:   "/mcp",
+			Canary: &mcpv1.CanaryTarget{
+				TargetRef:          mcpv1.TargetReference{Kind: "HTTPRoute", Name: "weather-v2"},
+				Weight:             10,
+				ToolMismatchPolicy: mcpv1.CanaryToolMismatchFlag,
+			},
+		},
+	}
+	got, err := r.buildMCPServerConfig(context.Background(), externalHostnameRoute(), mcpsr)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := &config.CanaryConfig{
+		URL:          "https://v2.api.weather.example.com:443/mcp",
+		Hostname:     "weather-v2.mcp.local",
+		Weight:       10,
+		ToolMismatch: config.CanaryToolMismatchFlag,
+	}
+	if !reflect.DeepEqual(got.Canary, want) {
+		t.Errorf("expected canary %+v, got %+v", want, got.Canary)
+	}
+	if got.URL != "https://api.weather.example.com:443/mcp" {
+		t.Errorf("expected the stable url to be kept, got %s", got.URL)
+	}
+
+	mcpsr.Spec.Canary.TargetRef.Name = "missing"
+	if _, err := r.buildMCPServerConfig(context.Background(), externalHostnameRoute(), mcpsr); err == nil {
+		t.Error("expected an error for a missing canary httproute")
+	}
+}
+
+func TestBuildMCPServerConfig_UpstreamOAuth(t *testing.T) {
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "github-oauth",
+			Namespace: "mcp-test",
+			Labels:    map[string]string{ManagedSecretLabel: ManagedSecretValue},
+		},
+		Data: map[string][]byte{"client_secret": []byte("s3cret")},
+	}
+	scheme := runtime.NewScheme()
+	_ = corev1.AddToScheme(scheme)
+	fakeClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(secret).Build()
+	r := &MCPReconciler{Client: fakeClient, DirectAPIReader: fakeClient}
+
+	mcpsr := &mcpv1.MCPServerRegistration{
+		ObjectMeta: metav1.ObjectMeta{Name: "github", Namespace: "mcp-test"},
+		Spec: mcpv1.MCPServerRegistrationSpec{
+			Prefix: "github_",
+			Path:   "/mcp",
+			TokenURLElicitation: &mcpv1.TokenURLElicitationConfig{OAuth: &mcpv1.UpstreamOAuthClient{
+				AuthorizationURL: "https://github.com/login/oauth/authorize",
+				TokenURL:         "https://github.com/login/oauth/access_token",
+				ClientID:         "gateway",
+				ClientSecretRef:  &mcpv1.SecretReference{Name: "github-oauth", Key: "client_secret"},
+				Scopes:           []string{"repo", "read:user"},
+			}},
+		},
+	}
+	got, err := r.buildMCPServerConfig(context.Background(), externalHostnameRoute(), mcpsr)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := &config.UpstreamOAuthConfig{
+		AuthorizationURL: "https://github.com/login/oauth/authorize",
+		TokenURL:         "https://github.com/login/oauth/access_token",
+		ClientID:         "gateway",
+		ClientSecretRef:  &config.CredentialReference{Namespace: "mcp-test", Name: "github-oauth", Key: "client_secret"},
+		Scopes:           []string{"repo", "read:user"},
+	}
+	if !reflect.DeepEqual(got.UpstreamOAuth(), want) {
+		t.Errorf("expected oauth client %+v, got %+v", want, got.UpstreamOAuth())
+	}
+
+	mcpsr.Spec.TokenURLElicitation.OAuth.ClientSecretRef.Key = "missing"
+	_, err = r.buildMCPServerConfig(context.Background(), externalHostnameRoute(), mcpsr)
+	if err == nil || err.Error() != "OAuth client secret github-oauth missing key missing" {
+		t.Errorf("expected a missing key error, got %v", err)
+	}
+
+	mcpsr.Spec.TokenURLElicitation.OAuth.ClientSecretRef = nil
+	got, err = r.buildMCPServerConfig(context.Background(), externalHostnameRoute(), mcpsr)
+	if err != nil {
+		t.Fatalf("unexpected error for a public client: %v", err)
+	}
+	if got.UpstreamOAuth().ClientSecretRef != nil {
+		t.Errorf("expected no client secret for a public client, got %+v", got.UpstreamOAuth().ClientSecretRef)
+	}
+}
+
+// capturingIndexer records the extract funcs registered with it
+type capturingIndexer struct {
+	funcs map[string]client.IndexerFunc
+}
+
+func (c *capturingIndexer) IndexField(_ context.Context, _ client.Object, field string, extract client.IndexerFunc) error {
+	c.funcs[field] = extract
+	return nil
+}
+
+func TestHTTPRouteIndex_IncludesCanary(t *testing.T) {
+	indexer := &capturingIndexer{funcs: map[string]client.IndexerFunc{}}
+	if err := setupIndexMCPRegistrationToHTTPRoute(context.Background(), indexer); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	mcpsr := &mcpv1.MCPServerRegistration{
+		ObjectMeta: metav1.ObjectMeta{Name: "weather", Namespace: "mcp-test"},
+		Spec: mcpv1.MCPServerRegistrationSpec{
+			TargetRef: mcpv1.TargetReference{Kind: "HTTPRoute", Name: "weather"},
+		},
+	}
+	if got := indexer.funcs[HTTPRouteIndex](mcpsr); !reflect.DeepEqual(got, []string{"mcp-test/weather"}) {
+		t.Errorf("unexpected index values %v", got)
+	}
+	mcpsr.Spec.Canary = &mcpv1.CanaryTarget{TargetRef: mcpv1.TargetReference{Kind: "HTTPRoute", Name: "weather-v2"}, Weight: 10}
+	if got := indexer.funcs[HTTPRouteIndex](mcpsr); !reflect.DeepEqual(got, []string{"mcp-test/weather", "mcp-test/weather-v2"}) {
+		t.Errorf("unexpected index values %v", got)
+	}
+}
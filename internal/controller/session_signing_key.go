@@ -4,9 +4,15 @@ import (
 	"context"
 	"crypto/rand"
 	"encoding/hex"
+	"encoding/json"
 	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"time"
 
 	mcpv1 "github.com/Kuadrant/mcp-gateway/api/v1"
+	internaljwt "github.com/Kuadrant/mcp-gateway/internal/jwt"
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -15,15 +21,34 @@ import (
 )
 
 const (
-	sessionSigningKeySecretName = "mcp-gateway-session-signing-key" //nolint:gosec // not a credential
-	sessionSigningKeyDataKey    = "key"
-	sessionSigningKeyEnvVar     = "GATEWAY_SIGNING_KEY"
-	sessionSigningKeyBytes      = 32 // 256-bit key for HS256
+	sessionSigningKeySecretName    = "mcp-gateway-session-signing-key" //nolint:gosec // not a credential
+	sessionSigningKeyDataKey       = "key"
+	sessionSigningKeyNextDataKey   = "next-key"
+	sessionVerificationKeysDataKey = "verification-keys"
+	sessionSigningKeyEnvVar        = "GATEWAY_SIGNING_KEY"
+	sessionVerificationKeysEnvVar  = "GATEWAY_VERIFICATION_KEYS"
+	sessionSigningKeyBytes         = 32 // 256-bit key for HS256
+
+	signingKeyActivatedAtAnnotation = "mcp.kuadrant.io/signing-key-activated-at"
+	signingKeyNextAtAnnotation      = "mcp.kuadrant.io/next-signing-key-activates-at"
+	signingKeyRetiredAnnotation     = "mcp.kuadrant.io/retired-signing-keys"
+	// signingKeyIDsAnnotation on the broker-router pod template restarts the
+	// pods when the signing key ring changes
+	signingKeyIDsAnnotation = "mcp.kuadrant.io/signing-key-ids"
+
+	// signingKeyStageDelay is how long a new key is published for
+	// verification before it signs, giving the broker-router rollout time to
+	// complete
+	signingKeyStageDelay = 10 * time.Minute
+	// defaultSigningKeyRetention matches the broker's default session length
+	defaultSigningKeyRetention = 24 * time.Hour
 )
 
 // reconcileSessionSigningKey ensures a secret containing a random JWT signing
-// key exists. Corrupted or misconfigured secrets are repaired in-place.
-func (r *MCPGatewayExtensionReconciler) reconcileSessionSigningKey(ctx context.Context, mcpExt *mcpv1.MCPGatewayExtension) error {
+// key exists. Corrupted or misconfigured secrets are repaired in-place. With
+// rotation configured it also advances the key ring; the returned duration is
+// when the ring next needs attention, zero if never.
+func (r *MCPGatewayExtensionReconciler) reconcileSessionSigningKey(ctx context.Context, mcpExt *mcpv1.MCPGatewayExtension) (time.Duration, error) {
 	existing := &corev1.Secret{}
 	err := r.DirectAPIReader.Get(ctx, client.ObjectKey{
 		Name:      sessionSigningKeySecretName,
@@ -35,7 +60,7 @@ func (r *MCPGatewayExtensionReconciler) reconcileSessionSigningKey(ctx context.C
 		// ensure owner reference is set
 		if !hasOwnerReference(existing, mcpExt) {
 			if err := controllerutil.SetControllerReference(mcpExt, existing, r.Scheme); err != nil {
-				return fmt.Errorf("failed to set owner reference on session signing key secret: %w", err)
+				return 0, fmt.Errorf("failed to set owner reference on session signing key secret: %w", err)
 			}
 			needsUpdate = true
 		}
@@ -55,32 +80,43 @@ func (r *MCPGatewayExtensionReconciler) reconcileSessionSigningKey(ctx context.C
 
 		// verify key data
 		if existing.Data == nil || len(existing.Data[sessionSigningKeyDataKey]) == 0 {
-			keyBytes := make([]byte, sessionSigningKeyBytes)
-			if _, err := rand.Read(keyBytes); err != nil {
-				return fmt.Errorf("failed to generate session signing key: %w", err)
+			key, err := generateSessionSigningKey()
+			if err != nil {
+				return 0, err
 			}
 			existing.Data = map[string][]byte{
-				sessionSigningKeyDataKey: []byte(hex.EncodeToString(keyBytes)),
+				sessionSigningKeyDataKey: []byte(key),
 			}
 			needsUpdate = true
 			r.log.Info("regenerating corrupted session signing key", "name", sessionSigningKeySecretName)
 		}
 
+		ring := readSigningKeyRing(existing)
+		rotated, next, err := ring.advance(time.Now(), mcpExt.Spec.SessionSigningKeyRotation)
+		if err != nil {
+			return 0, err
+		}
+		if rotated {
+			ring.write(existing)
+			needsUpdate = true
+			r.log.Info("advanced session signing key ring", "name", sessionSigningKeySecretName, "keys", ring.keyIDs())
+		}
+
 		if needsUpdate {
 			if err := r.Update(ctx, existing); err != nil {
-				return fmt.Errorf("failed to update session signing key secret: %w", err)
+				return 0, fmt.Errorf("failed to update session signing key secret: %w", err)
 			}
 		}
-		return nil
+		return next, nil
 	}
 	if !apierrors.IsNotFound(err) {
-		return fmt.Errorf("failed to check session signing key secret: %w", err)
+		return 0, fmt.Errorf("failed to check session signing key secret: %w", err)
 	}
 
 	// generate a random key
-	keyBytes := make([]byte, sessionSigningKeyBytes)
-	if _, err := rand.Read(keyBytes); err != nil {
-		return fmt.Errorf("failed to generate session signing key: %w", err)
+	key, err := generateSessionSigningKey()
+	if err != nil {
+		return 0, err
 	}
 
 	secret := &corev1.Secret{
@@ -93,23 +129,186 @@ func (r *MCPGatewayExtensionReconciler) reconcileSessionSigningKey(ctx context.C
 			},
 		},
 		Data: map[string][]byte{
-			sessionSigningKeyDataKey: []byte(hex.EncodeToString(keyBytes)),
+			sessionSigningKeyDataKey: []byte(key),
 		},
 	}
+	ring := readSigningKeyRing(secret)
+	ring.activatedAt = time.Now()
+	ring.write(secret)
 
 	if err := controllerutil.SetControllerReference(mcpExt, secret, r.Scheme); err != nil {
-		return fmt.Errorf("failed to set owner reference on session signing key secret: %w", err)
+		return 0, fmt.Errorf("failed to set owner reference on session signing key secret: %w", err)
 	}
 
 	r.log.Info("creating session signing key secret", "name", sessionSigningKeySecretName)
 	if err := r.Create(ctx, secret); err != nil {
 		if apierrors.IsAlreadyExists(err) {
-			return nil
+			return 0, nil
+		}
+		return 0, fmt.Errorf("failed to create session signing key secret: %w", err)
+	}
+
+	_, next, err := ring.advance(time.Now(), mcpExt.Spec.SessionSigningKeyRotation)
+	return next, err
+}
+
+func generateSessionSigningKey() (string, error) {
+	keyBytes := make([]byte, sessionSigningKeyBytes)
+	if _, err := rand.Read(keyBytes); err != nil {
+		return "", fmt.Errorf("failed to generate session signing key: %w", err)
+	}
+	return hex.EncodeToString(keyBytes), nil
+}
+
+// signingKeyRing is the key material held in the session signing key secret.
+// Only the active key signs. The next key and the retired keys are published
+// to the broker for verification only: the next key so every pod accepts
+// sessions signed with it before any pod starts signing with it, the retired
+// keys until the sessions they signed have expired.
+type signingKeyRing struct {
+	active      string
+	activatedAt time.Time
+	next        string
+	nextAt      time.Time
+	// retired maps a replaced key to when it stops being published
+	retired map[string]time.Time
+}
+
+func readSigningKeyRing(secret *corev1.Secret) *signingKeyRing {
+	ring := &signingKeyRing{
+		active:  string(secret.Data[sessionSigningKeyDataKey]),
+		next:    string(secret.Data[sessionSigningKeyNextDataKey]),
+		retired: map[string]time.Time{},
+	}
+	ring.activatedAt, _ = time.Parse(time.RFC3339, secret.Annotations[signingKeyActivatedAtAnnotation])
+	ring.nextAt, _ = time.Parse(time.RFC3339, secret.Annotations[signingKeyNextAtAnnotation])
+	expiries := map[string]time.Time{}
+	_ = json.Unmarshal([]byte(secret.Annotations[signingKeyRetiredAnnotation]), &expiries)
+	for _, key := range strings.Split(string(secret.Data[sessionVerificationKeysDataKey]), ",") {
+		if key == "" || key == ring.next || key == ring.active {
+			continue
 		}
-		return fmt.Errorf("failed to create session signing key secret: %w", err)
+		// a key with a lost expiry is kept until the next advance sets one
+		ring.retired[key] = expiries[internaljwt.SigningKeyID(key)]
 	}
+	return ring
+}
+
+// write stores the ring in the secret. key IDs, not keys, go in annotations.
+func (ring *signingKeyRing) write(secret *corev1.Secret) {
+	if secret.Annotations == nil {
+		secret.Annotations = map[string]string{}
+	}
+	secret.Data[sessionSigningKeyDataKey] = []byte(ring.active)
+	secret.Annotations[signingKeyActivatedAtAnnotation] = ring.activatedAt.UTC().Format(time.RFC3339)
 
-	return nil
+	verification := make([]string, 0, len(ring.retired)+1)
+	if ring.next != "" {
+		secret.Data[sessionSigningKeyNextDataKey] = []byte(ring.next)
+		secret.Annotations[signingKeyNextAtAnnotation] = ring.nextAt.UTC().Format(time.RFC3339)
+		verification = append(verification, ring.next)
+	} else {
+		delete(secret.Data, sessionSigningKeyNextDataKey)
+		delete(secret.Annotations, signingKeyNextAtAnnotation)
+	}
+	expiries := map[string]string{}
+	for _, key := range slices.Sorted(maps.Keys(ring.retired)) {
+		verification = append(verification, key)
+		expiries[internaljwt.SigningKeyID(key)] = ring.retired[key].UTC().Format(time.RFC3339)
+	}
+	secret.Data[sessionVerificationKeysDataKey] = []byte(strings.Join(verification, ","))
+	if len(expiries) == 0 {
+		delete(secret.Annotations, signingKeyRetiredAnnotation)
+		return
+	}
+	data, _ := json.Marshal(expiries)
+	secret.Annotations[signingKeyRetiredAnnotation] = string(data)
+}
+
+// advance moves the ring forward to now: it drops expired retired keys,
+// promotes a staged key that is due, and stages a new key once the active
+// one has been in use for the rotation interval. Without rotation a staged
+// key is still promoted, so turning rotation off never strands one. Returns
+// whether the ring changed and how long until it next needs to advance.
+func (ring *signingKeyRing) advance(now time.Time, rotation *mcpv1.SessionSigningKeyRotation) (bool, time.Duration, error) {
+	changed := false
+	retention := defaultSigningKeyRetention
+	if rotation != nil && rotation.RetentionHours != nil {
+		retention = time.Duration(*rotation.RetentionHours) * time.Hour
+	}
+
+	if ring.activatedAt.IsZero() && rotation != nil {
+		// rotation just enabled on a key of unknown age: start its interval now
+		ring.activatedAt = now
+		changed = true
+	}
+	for key, expiresAt := range ring.retired {
+		switch {
+		case expiresAt.IsZero():
+			ring.retired[key] = now.Add(retention)
+			changed = true
+		case !now.Before(expiresAt):
+			delete(ring.retired, key)
+			changed = true
+		}
+	}
+	if ring.next != "" && !now.Before(ring.nextAt) {
+		ring.retired[ring.active] = now.Add(retention)
+		ring.active, ring.activatedAt = ring.next, now
+		ring.next, ring.nextAt = "", time.Time{}
+		changed = true
+	}
+	if rotation != nil && ring.next == "" && !now.Before(ring.activatedAt.Add(rotationInterval(rotation))) {
+		key, err := generateSessionSigningKey()
+		if err != nil {
+			return false, 0, err
+		}
+		ring.next, ring.nextAt = key, now.Add(signingKeyStageDelay)
+		changed = true
+	}
+
+	// earliest pending event
+	var due []time.Time
+	if ring.next != "" {
+		due = append(due, ring.nextAt)
+	} else if rotation != nil {
+		due = append(due, ring.activatedAt.Add(rotationInterval(rotation)))
+	}
+	for _, expiresAt := range ring.retired {
+		due = append(due, expiresAt)
+	}
+	if len(due) == 0 {
+		return changed, 0, nil
+	}
+	return changed, max(slices.MinFunc(due, time.Time.Compare).Sub(now), time.Second), nil
+}
+
+// keyIDs identifies the keys that sign now or soon. stamped on the
+// broker-router pod template so pods restart to pick up a staged or promoted
+// key; retired keys expiring does not need a restart.
+func (ring *signingKeyRing) keyIDs() string {
+	ids := internaljwt.SigningKeyID(ring.active)
+	if ring.next != "" {
+		ids += "," + internaljwt.SigningKeyID(ring.next)
+	}
+	return ids
+}
+
+func rotationInterval(rotation *mcpv1.SessionSigningKeyRotation) time.Duration {
+	return time.Duration(rotation.IntervalHours) * time.Hour
+}
+
+// sessionSigningKeyIDs returns the key IDs of the namespace's signing key
+// ring for the broker-router pod template
+func (r *MCPGatewayExtensionReconciler) sessionSigningKeyIDs(ctx context.Context, namespace string) (string, error) {
+	secret := &corev1.Secret{}
+	if err := r.DirectAPIReader.Get(ctx, client.ObjectKey{
+		Name:      sessionSigningKeySecretName,
+		Namespace: namespace,
+	}, secret); err != nil {
+		return "", fmt.Errorf("failed to read session signing key secret: %w", err)
+	}
+	return readSigningKeyRing(secret).keyIDs(), nil
 }
 
 func hasOwnerReference(secret *corev1.Secret, owner *mcpv1.MCPGatewayExtension) bool {
@@ -120,3 +319,23 @@ func hasOwnerReference(secret *corev1.Secret, owner *mcpv1.MCPGatewayExtension)
 	}
 	return false
 }
+
+// SessionVerificationKeys returns the keys the session signing key ring of
+// the MCPGatewayExtension in namespace verifies with: the active key, then the
+// staged and retired ones. The config stream accepts broker tokens derived
+// from any of them, so brokers still on a rotated-out key can reconnect.
+func SessionVerificationKeys(ctx context.Context, reader client.Reader, namespace string) ([]string, error) {
+	secret := &corev1.Secret{}
+	if err := reader.Get(ctx, client.ObjectKey{
+		Name:      sessionSigningKeySecretName,
+		Namespace: namespace,
+	}, secret); err != nil {
+		return nil, fmt.Errorf("failed to read session signing key secret: %w", err)
+	}
+	ring := readSigningKeyRing(secret)
+	keys := []string{ring.active}
+	if ring.next != "" {
+		keys = append(keys, ring.next)
+	}
+	return append(keys, slices.Sorted(maps.Keys(ring.retired))...), nil
+}
@@ -3,13 +3,17 @@ package controller
 import (
 	"context"
 	"log/slog"
+	"strings"
 	"testing"
+	"time"
 
 	mcpv1 "github.com/Kuadrant/mcp-gateway/api/v1"
+	internaljwt "github.com/Kuadrant/mcp-gateway/internal/jwt"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/ptr"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 )
@@ -46,7 +50,7 @@ func TestReconcileSessionSigningKey_CreatesSecret(t *testing.T) {
 	r := testReconciler()
 	mcpExt := testMCPExt()
 
-	if err := r.reconcileSessionSigningKey(context.Background(), mcpExt); err != nil {
+	if _, err := r.reconcileSessionSigningKey(context.Background(), mcpExt); err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
 
@@ -101,7 +105,7 @@ func TestReconcileSessionSigningKey_ExistingSecretIsNoop(t *testing.T) {
 	r := testReconciler(existing)
 	mcpExt := testMCPExt()
 
-	if err := r.reconcileSessionSigningKey(context.Background(), mcpExt); err != nil {
+	if _, err := r.reconcileSessionSigningKey(context.Background(), mcpExt); err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
 
@@ -132,7 +136,7 @@ func TestReconcileSessionSigningKey_EmptyKeyRegenerates(t *testing.T) {
 	r := testReconciler(existing)
 	mcpExt := testMCPExt()
 
-	if err := r.reconcileSessionSigningKey(context.Background(), mcpExt); err != nil {
+	if _, err := r.reconcileSessionSigningKey(context.Background(), mcpExt); err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
 
@@ -159,7 +163,7 @@ func TestReconcileSessionSigningKey_NilDataRegenerates(t *testing.T) {
 	r := testReconciler(existing)
 	mcpExt := testMCPExt()
 
-	if err := r.reconcileSessionSigningKey(context.Background(), mcpExt); err != nil {
+	if _, err := r.reconcileSessionSigningKey(context.Background(), mcpExt); err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
 
@@ -189,7 +193,7 @@ func TestReconcileSessionSigningKey_RepairsMissingLabels(t *testing.T) {
 	r := testReconciler(existing)
 	mcpExt := testMCPExt()
 
-	if err := r.reconcileSessionSigningKey(context.Background(), mcpExt); err != nil {
+	if _, err := r.reconcileSessionSigningKey(context.Background(), mcpExt); err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
 
@@ -222,3 +226,186 @@ func TestReconcileSessionSigningKey_RepairsMissingLabels(t *testing.T) {
 		t.Errorf("expected owner UID %q, got %q", mcpExt.UID, secret.OwnerReferences[0].UID)
 	}
 }
+
+func TestSigningKeyRing_Advance(t *testing.T) {
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	rotation := &mcpv1.SessionSigningKeyRotation{IntervalHours: 24 * 30, RetentionHours: ptr.To(int32(48))}
+	active := strings.Repeat("a", 64)
+
+	t.Run("without rotation nothing changes", func(t *testing.T) {
+		ring := &signingKeyRing{active: active, retired: map[string]time.Time{}}
+		changed, due, err := ring.advance(now, nil)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if changed || due != 0 || ring.active != active {
+			t.Errorf("expected no change, got changed=%v due=%v", changed, due)
+		}
+	})
+
+	t.Run("enabling rotation starts the interval", func(t *testing.T) {
+		ring := &signingKeyRing{active: active, retired: map[string]time.Time{}}
+		changed, due, _ := ring.advance(now, rotation)
+		if !changed || !ring.activatedAt.Equal(now) {
+			t.Errorf("expected activation time to be set, got %v", ring.activatedAt)
+		}
+		if due != 30*24*time.Hour {
+			t.Errorf("expected rotation due in 30 days, got %v", due)
+		}
+		if ring.next != "" {
+			t.Error("expected no key to be staged yet")
+		}
+	})
+
+	t.Run("due rotation stages the next key", func(t *testing.T) {
+		ring := &signingKeyRing{active: active, activatedAt: now.Add(-31 * 24 * time.Hour), retired: map[string]time.Time{}}
+		changed, due, _ := ring.advance(now, rotation)
+		if !changed || len(ring.next) != 64 || ring.next == active {
+			t.Fatalf("expected a new key to be staged, got %q", ring.next)
+		}
+		if ring.active != active {
+			t.Error("expected the staged key not to sign yet")
+		}
+		if due != signingKeyStageDelay {
+			t.Errorf("expected promotion due in %v, got %v", signingKeyStageDelay, due)
+		}
+	})
+
+	t.Run("staged key is promoted and the old key retired", func(t *testing.T) {
+		next := strings.Repeat("b", 64)
+		ring := &signingKeyRing{active: active, activatedAt: now.Add(-31 * 24 * time.Hour),
+			next: next, nextAt: now.Add(-time.Minute), retired: map[string]time.Time{}}
+		changed, due, _ := ring.advance(now, rotation)
+		if !changed || ring.active != next || ring.next != "" {
+			t.Fatalf("expected %q to be promoted, got active=%q next=%q", next, ring.active, ring.next)
+		}
+		if !ring.retired[active].Equal(now.Add(48 * time.Hour)) {
+			t.Errorf("expected the old key to be retained for 48h, got %v", ring.retired[active])
+		}
+		if due != 48*time.Hour {
+			t.Errorf("expected the retired key expiry to be due next, got %v", due)
+		}
+	})
+
+	t.Run("staged key is promoted without rotation", func(t *testing.T) {
+		next := strings.Repeat("b", 64)
+		ring := &signingKeyRing{active: active, next: next, nextAt: now, retired: map[string]time.Time{}}
+		if changed, _, _ := ring.advance(now, nil); !changed || ring.active != next {
+			t.Errorf("expected the staged key to be promoted, got %q", ring.active)
+		}
+	})
+
+	t.Run("expired retired keys are dropped", func(t *testing.T) {
+		old := strings.Repeat("c", 64)
+		ring := &signingKeyRing{active: active, activatedAt: now,
+			retired: map[string]time.Time{old: now.Add(-time.Second)}}
+		changed, _, _ := ring.advance(now, rotation)
+		if !changed || len(ring.retired) != 0 {
+			t.Errorf("expected the retired key to be dropped, got %v", ring.retired)
+		}
+	})
+}
+
+func TestSigningKeyRing_RoundTrip(t *testing.T) {
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	ring := &signingKeyRing{
+		active:      strings.Repeat("a", 64),
+		activatedAt: now,
+		next:        strings.Repeat("b", 64),
+		nextAt:      now.Add(signingKeyStageDelay),
+		retired:     map[string]time.Time{strings.Repeat("c", 64): now.Add(time.Hour)},
+	}
+	secret := &corev1.Secret{Data: map[string][]byte{}}
+	ring.write(secret)
+
+	if got := string(secret.Data[sessionVerificationKeysDataKey]); got != ring.next+","+strings.Repeat("c", 64) {
+		t.Errorf("unexpected verification keys %q", got)
+	}
+	if strings.Contains(secret.Annotations[signingKeyRetiredAnnotation], strings.Repeat("c", 64)) {
+		t.Error("annotations must not contain key material")
+	}
+
+	read := readSigningKeyRing(secret)
+	if read.active != ring.active || read.next != ring.next || !read.activatedAt.Equal(now) || !read.nextAt.Equal(ring.nextAt) {
+		t.Errorf("ring did not round trip: %+v", read)
+	}
+	if !read.retired[strings.Repeat("c", 64)].Equal(now.Add(time.Hour)) {
+		t.Errorf("retired key expiry did not round trip: %v", read.retired)
+	}
+	if want := internaljwt.SigningKeyID(ring.active) + "," + internaljwt.SigningKeyID(ring.next); read.keyIDs() != want {
+		t.Errorf("expected key IDs %q, got %q", want, read.keyIDs())
+	}
+}
+
+func TestSessionVerificationKeys(t *testing.T) {
+	ring := &signingKeyRing{
+		active:  strings.Repeat("a", 64),
+		next:    strings.Repeat("b", 64),
+		retired: map[string]time.Time{strings.Repeat("c", 64): time.Now().Add(time.Hour)},
+	}
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: sessionSigningKeySecretName, Namespace: "test-ns"},
+		Data:       map[string][]byte{},
+	}
+	ring.write(secret)
+	r := testReconciler(secret)
+
+	keys, err := SessionVerificationKeys(context.Background(), r.Client, "test-ns")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []string{ring.active, ring.next, strings.Repeat("c", 64)}
+	if strings.Join(keys, ",") != strings.Join(want, ",") {
+		t.Errorf("expected keys %v, got %v", want, keys)
+	}
+
+	if _, err := SessionVerificationKeys(context.Background(), r.Client, "other-ns"); err == nil {
+		t.Error("expected an error without a signing key secret")
+	}
+}
+
+func TestReconcileSessionSigningKey_Rotates(t *testing.T) {
+	existing := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      sessionSigningKeySecretName,
+			Namespace: "test-ns",
+			Annotations: map[string]string{
+				signingKeyActivatedAtAnnotation: time.Now().Add(-48 * time.Hour).UTC().Format(time.RFC3339),
+			},
+		},
+		Data: map[string][]byte{
+			sessionSigningKeyDataKey: []byte(strings.Repeat("a", 64)),
+		},
+	}
+	r := testReconciler(existing)
+	mcpExt := testMCPExt()
+	mcpExt.Spec.SessionSigningKeyRotation = &mcpv1.SessionSigningKeyRotation{IntervalHours: 24}
+
+	due, err := r.reconcileSessionSigningKey(context.Background(), mcpExt)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if due <= 0 || due > signingKeyStageDelay {
+		t.Errorf("expected a requeue for promotion, got %v", due)
+	}
+
+	secret := &corev1.Secret{}
+	if err := r.Get(context.Background(), client.ObjectKey{Name: sessionSigningKeySecretName, Namespace: "test-ns"}, secret); err != nil {
+		t.Fatalf("expected secret to exist: %v", err)
+	}
+	if string(secret.Data[sessionSigningKeyDataKey]) != strings.Repeat("a", 64) {
+		t.Error("expected the active key to keep signing until the staged key is promoted")
+	}
+	next := string(secret.Data[sessionSigningKeyNextDataKey])
+	if len(next) != 64 || string(secret.Data[sessionVerificationKeysDataKey]) != next {
+		t.Errorf("expected the staged key to be published for verification, got %q", secret.Data[sessionVerificationKeysDataKey])
+	}
+
+	ids, err := r.sessionSigningKeyIDs(context.Background(), "test-ns")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(ids, internaljwt.SigningKeyID(next)) {
+		t.Errorf("expected pod template key IDs to include the staged key, got %q", ids)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 
 	mcpv1 "github.com/Kuadrant/mcp-gateway/api/v1"
+	"github.com/Kuadrant/mcp-gateway/internal/redisconn"
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/types"
@@ -12,7 +13,8 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 )
 
-// validateSessionStore checks that the session store secret exists and contains the CACHE_CONNECTION_STRING key.
+// validateSessionStore checks that the session store secret exists and contains a parseable
+// CACHE_CONNECTION_STRING for a standalone, sentinel or cluster Redis.
 func (r *MCPGatewayExtensionReconciler) validateSessionStore(ctx context.Context, mcpExt *mcpv1.MCPGatewayExtension) error {
 	if mcpExt.Spec.SessionStore == nil {
 		return nil
@@ -45,6 +47,10 @@ func (r *MCPGatewayExtensionReconciler) validateSessionStore(ctx context.Context
 		return newValidationError(mcpv1.ConditionReasonSecretInvalid,
 			fmt.Sprintf("session store secret %s has empty \"CACHE_CONNECTION_STRING\" value", secretName))
 	}
+	if _, err := redisconn.Parse(string(val)); err != nil {
+		return newValidationError(mcpv1.ConditionReasonSecretInvalid,
+			fmt.Sprintf("session store secret %s has invalid \"CACHE_CONNECTION_STRING\": %v", secretName, err))
+	}
 
 	return nil
 }
@@ -14,9 +14,15 @@ type inMemoryEntry struct {
 	expiresAt time.Time
 }
 
+type inMemoryFlow struct {
+	flow      OAuthFlow
+	expiresAt time.Time
+}
+
 type inMemoryMap struct {
 	mu       sync.Mutex
 	entries  map[string]inMemoryEntry
+	flows    map[string]inMemoryFlow
 	entryTTL time.Duration
 	stopCh   chan struct{}
 }
@@ -24,6 +30,7 @@ type inMemoryMap struct {
 func newInMemoryMap(entryTTL time.Duration) *inMemoryMap {
 	m := &inMemoryMap{
 		entries:  make(map[string]inMemoryEntry),
+		flows:    make(map[string]inMemoryFlow),
 		entryTTL: entryTTL,
 		stopCh:   make(chan struct{}),
 	}
@@ -45,6 +52,11 @@ func (m *inMemoryMap) reapLoop() {
 					delete(m.entries, id)
 				}
 			}
+			for state, f := range m.flows {
+				if now.After(f.expiresAt) {
+					delete(m.flows, state)
+				}
+			}
 			m.mu.Unlock()
 		}
 	}
@@ -111,3 +123,25 @@ func (m *inMemoryMap) Remove(_ context.Context, elicitationID string) {
 	defer m.mu.Unlock()
 	delete(m.entries, elicitationID)
 }
+
+func (m *inMemoryMap) StoreOAuthFlow(_ context.Context, state string, flow OAuthFlow, ttl time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.flows[state] = inMemoryFlow{flow: flow, expiresAt: time.Now().Add(ttl)}
+	return nil
+}
+
+func (m *inMemoryMap) ClaimOAuthFlow(_ context.Context, state string) (OAuthFlow, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	f, ok := m.flows[state]
+	if !ok {
+		return OAuthFlow{}, false, nil
+	}
+	delete(m.flows, state)
+	if time.Now().After(f.expiresAt) {
+		return OAuthFlow{}, false, nil
+	}
+	return f.flow, true, nil
+}
@@ -14,6 +14,13 @@ type Entry struct {
 	Sub        string `json:"sub,omitempty"`
 }
 
+// OAuthFlow holds the server side of an upstream OAuth flow from the
+// authorize request to the callback.
+type OAuthFlow struct {
+	ElicitationID string `json:"elicitationID"`
+	Verifier      string `json:"verifier"`
+}
+
 // Map stores and retrieves token elicitation entries.
 // Entries are short-lived and single-use.
 type Map interface {
@@ -22,12 +29,16 @@ type Map interface {
 	// Claim atomically looks up and deletes an entry, ensuring single-use.
 	Claim(ctx context.Context, elicitationID string) (Entry, bool, error)
 	Remove(ctx context.Context, elicitationID string)
+	// StoreOAuthFlow keeps an upstream OAuth flow under its state for ttl.
+	StoreOAuthFlow(ctx context.Context, state string, flow OAuthFlow, ttl time.Duration) error
+	// ClaimOAuthFlow atomically looks up and deletes the flow of a state.
+	ClaimOAuthFlow(ctx context.Context, state string) (OAuthFlow, bool, error)
 	// Close stops background goroutines. Safe to call multiple times.
 	Close()
 }
 
 type mapConfig struct {
-	redisClient *redis.Client
+	redisClient redis.UniversalClient
 	entryTTL    time.Duration
 }
 
@@ -50,7 +61,7 @@ func New(opts ...func(*mapConfig)) (Map, error) {
 }
 
 // WithRedisClient configures the Map to use an existing Redis client.
-func WithRedisClient(client *redis.Client) func(*mapConfig) {
+func WithRedisClient(client redis.UniversalClient) func(*mapConfig) {
 	return func(c *mapConfig) {
 		c.redisClient = client
 	}
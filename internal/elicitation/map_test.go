@@ -149,3 +149,29 @@ func TestInMemoryMap_EmptySub(t *testing.T) {
 		t.Fatalf("expected empty sub, got %q", entry.Sub)
 	}
 }
+
+func TestInMemoryMap_OAuthFlow(t *testing.T) {
+	m := newInMemoryMap(5 * time.Minute)
+	ctx := context.Background()
+
+	want := OAuthFlow{ElicitationID: "e1", Verifier: "v1"}
+	if err := m.StoreOAuthFlow(ctx, "state1", want, time.Minute); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, ok, _ := m.ClaimOAuthFlow(ctx, "other"); ok {
+		t.Fatal("flow should not exist under another state")
+	}
+	got, ok, err := m.ClaimOAuthFlow(ctx, "state1")
+	if err != nil || !ok || got != want {
+		t.Fatalf("expected %+v, got %+v ok=%v err=%v", want, got, ok, err)
+	}
+	if _, ok, _ := m.ClaimOAuthFlow(ctx, "state1"); ok {
+		t.Fatal("flow should not exist after claim")
+	}
+
+	_ = m.StoreOAuthFlow(ctx, "state2", want, time.Millisecond)
+	time.Sleep(5 * time.Millisecond)
+	if _, ok, _ := m.ClaimOAuthFlow(ctx, "state2"); ok {
+		t.Fatal("expired flow should not be claimed")
+	}
+}
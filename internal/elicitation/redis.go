@@ -11,14 +11,17 @@ import (
 	redis "github.com/redis/go-redis/v9"
 )
 
-const tokenElicitationPrefix = "tokenelicitation:"
+const (
+	tokenElicitationPrefix = "tokenelicitation:"
+	oauthFlowPrefix        = "upstreamoauthflow:"
+)
 
 type redisMap struct {
-	client   *redis.Client
+	client   redis.UniversalClient
 	entryTTL time.Duration
 }
 
-func newRedisMap(client *redis.Client, entryTTL time.Duration) *redisMap {
+func newRedisMap(client redis.UniversalClient, entryTTL time.Duration) *redisMap {
 	return &redisMap{client: client, entryTTL: entryTTL}
 }
 
@@ -73,4 +76,30 @@ func (m *redisMap) Remove(ctx context.Context, elicitationID string) {
 	m.client.Del(ctx, tokenElicitationPrefix+elicitationID)
 }
 
+func (m *redisMap) StoreOAuthFlow(ctx context.Context, state string, flow OAuthFlow, ttl time.Duration) error {
+	data, err := json.Marshal(flow)
+	if err != nil {
+		return fmt.Errorf("marshal oauth flow: %w", err)
+	}
+	if err := m.client.Set(ctx, oauthFlowPrefix+state, data, ttl).Err(); err != nil {
+		return fmt.Errorf("store oauth flow: %w", err)
+	}
+	return nil
+}
+
+func (m *redisMap) ClaimOAuthFlow(ctx context.Context, state string) (OAuthFlow, bool, error) {
+	data, err := m.client.GetDel(ctx, oauthFlowPrefix+state).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return OAuthFlow{}, false, nil
+	}
+	if err != nil {
+		return OAuthFlow{}, false, fmt.Errorf("claim oauth flow: %w", err)
+	}
+	var flow OAuthFlow
+	if err := json.Unmarshal(data, &flow); err != nil {
+		return OAuthFlow{}, false, fmt.Errorf("unmarshal oauth flow: %w", err)
+	}
+	return flow, true, nil
+}
+
 func (m *redisMap) Close() {}
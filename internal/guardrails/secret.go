@@ -24,8 +24,8 @@ const (
 	FailModeAllow = "allow"
 )
 
-// configDataKey is the Secret data key holding the provider config YAML.
-const configDataKey = "config.yaml"
+// ConfigDataKey is the Secret data key holding the provider config YAML.
+const ConfigDataKey = "config.yaml"
 
 // EnsureNeMoConfigData validates a guardrails Secret's type and parses its
 // config.yaml into a GuardrailsConfig, defaulting failMode to deny.
@@ -34,26 +34,26 @@ func EnsureNeMoConfigData(secretType corev1.SecretType, data map[string][]byte)
 		return nil, fmt.Errorf("unsupported guardrails secret type %q, expected %q", secretType, SecretTypeNeMo)
 	}
 
-	raw, ok := data[configDataKey]
+	raw, ok := data[ConfigDataKey]
 	if !ok {
-		return nil, fmt.Errorf("missing required key %q", configDataKey)
+		return nil, fmt.Errorf("missing required key %q", ConfigDataKey)
 	}
 
 	cfg := &config.GuardrailsConfig{}
 	if err := yaml.Unmarshal(raw, cfg); err != nil {
-		return nil, fmt.Errorf("failed to parse %s: %w", configDataKey, err)
+		return nil, fmt.Errorf("failed to parse %s: %w", ConfigDataKey, err)
 	}
 
 	if cfg.URL == "" {
-		return nil, fmt.Errorf("%s: url is required", configDataKey)
+		return nil, fmt.Errorf("%s: url is required", ConfigDataKey)
 	}
 	parsed, err := url.Parse(cfg.URL)
 	if err != nil || parsed.Scheme == "" || parsed.Host == "" {
-		return nil, fmt.Errorf("%s: url %q is not a valid absolute URL", configDataKey, cfg.URL)
+		return nil, fmt.Errorf("%s: url %q is not a valid absolute URL", ConfigDataKey, cfg.URL)
 	}
 
 	if cfg.Model == "" {
-		return nil, fmt.Errorf("%s: model is required", configDataKey)
+		return nil, fmt.Errorf("%s: model is required", ConfigDataKey)
 	}
 
 	switch cfg.FailMode {
@@ -61,7 +61,7 @@ func EnsureNeMoConfigData(secretType corev1.SecretType, data map[string][]byte)
 		cfg.FailMode = FailModeDeny
 	case FailModeDeny, FailModeAllow:
 	default:
-		return nil, fmt.Errorf("%s: failMode must be %q or %q, got %q", configDataKey, FailModeDeny, FailModeAllow, cfg.FailMode)
+		return nil, fmt.Errorf("%s: failMode must be %q or %q, got %q", ConfigDataKey, FailModeDeny, FailModeAllow, cfg.FailMode)
 	}
 
 	return cfg, nil
@@ -9,7 +9,7 @@ import (
 func TestEnsureNeMoConfigData(t *testing.T) {
 	t.Run("rejects a secret whose type isn't the NeMo guardrails type", func(t *testing.T) {
 		_, err := EnsureNeMoConfigData("Opaque", map[string][]byte{
-			configDataKey: []byte(`
+			ConfigDataKey: []byte(`
 url: https://nemo-guardrails.internal:8080
 model: meta/llama-3.1-8b-instruct
 `),
@@ -19,7 +19,7 @@ model: meta/llama-3.1-8b-instruct
 
 	t.Run("parses a valid config and defaults failMode to deny", func(t *testing.T) {
 		cfg, err := EnsureNeMoConfigData(SecretTypeNeMo, map[string][]byte{
-			configDataKey: []byte(`
+			ConfigDataKey: []byte(`
 url: https://nemo-guardrails.internal:8080
 configIDs:
   - tool-safety-v1
@@ -35,7 +35,7 @@ model: meta/llama-3.1-8b-instruct
 
 	t.Run("accepts an explicit failMode: allow", func(t *testing.T) {
 		cfg, err := EnsureNeMoConfigData(SecretTypeNeMo, map[string][]byte{
-			configDataKey: []byte(`
+			ConfigDataKey: []byte(`
 url: https://nemo-guardrails.internal:8080
 model: meta/llama-3.1-8b-instruct
 failMode: allow
@@ -52,14 +52,14 @@ failMode: allow
 
 	t.Run("errors when url is missing", func(t *testing.T) {
 		_, err := EnsureNeMoConfigData(SecretTypeNeMo, map[string][]byte{
-			configDataKey: []byte(`model: meta/llama-3.1-8b-instruct`),
+			ConfigDataKey: []byte(`model: meta/llama-3.1-8b-instruct`),
 		})
 		require.Error(t, err)
 	})
 
 	t.Run("errors when url is not absolute", func(t *testing.T) {
 		_, err := EnsureNeMoConfigData(SecretTypeNeMo, map[string][]byte{
-			configDataKey: []byte(`
+			ConfigDataKey: []byte(`
 url: not-a-url
 model: meta/llama-3.1-8b-instruct
 `),
@@ -69,14 +69,14 @@ model: meta/llama-3.1-8b-instruct
 
 	t.Run("errors when model is missing", func(t *testing.T) {
 		_, err := EnsureNeMoConfigData(SecretTypeNeMo, map[string][]byte{
-			configDataKey: []byte(`url: https://nemo-guardrails.internal:8080`),
+			ConfigDataKey: []byte(`url: https://nemo-guardrails.internal:8080`),
 		})
 		require.Error(t, err)
 	})
 
 	t.Run("errors on an unrecognized failMode", func(t *testing.T) {
 		_, err := EnsureNeMoConfigData(SecretTypeNeMo, map[string][]byte{
-			configDataKey: []byte(`
+			ConfigDataKey: []byte(`
 url: https://nemo-guardrails.internal:8080
 model: meta/llama-3.1-8b-instruct
 failMode: retry
@@ -0,0 +1,88 @@
+package jwt
+
+import (
+	"crypto/ecdsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"strings"
+
+	jwtv5 "github.com/golang-jwt/jwt/v5"
+)
+
+// AllowedCapabilitiesClaimKey is the claim of the signed x-mcp-authorized
+// header holding the JSON-encoded capabilities map
+const AllowedCapabilitiesClaimKey = "allowed-capabilities"
+
+// ParseAuthorizedCapabilities verifies the ES256 signature of an
+// x-mcp-authorized header value against publicKey, a PEM-encoded ECDSA key,
+// and returns its capabilities keyed by capability type ("tools", "prompts",
+// "resources"), then server name.
+func ParseAuthorizedCapabilities(token, publicKey string) (map[string]map[string][]string, error) {
+	if token == "" {
+		return nil, fmt.Errorf("empty header value")
+	}
+	if publicKey == "" {
+		return nil, fmt.Errorf("no public key configured to validate JWT")
+	}
+	parsed, err := validateES256(token, publicKey)
+	if err != nil {
+		return nil, fmt.Errorf("JWT validation failed: %w", err)
+	}
+	claims, ok := parsed.Claims.(jwtv5.MapClaims)
+	if !ok {
+		return nil, fmt.Errorf("failed to extract claims from JWT")
+	}
+	capabilitiesClaim, ok := claims[AllowedCapabilitiesClaimKey]
+	if !ok {
+		return nil, fmt.Errorf("missing %s claim in JWT", AllowedCapabilitiesClaimKey)
+	}
+	capabilitiesJSON, ok := capabilitiesClaim.(string)
+	if !ok {
+		return nil, fmt.Errorf("%s claim is not a string", AllowedCapabilitiesClaimKey)
+	}
+	var capabilities map[string]map[string][]string
+	if err := json.Unmarshal([]byte(capabilitiesJSON), &capabilities); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal allowed-capabilities JSON: %w", err)
+	}
+	return capabilities, nil
+}
+
+// ToolAllowed reports whether capabilities allow the upstream tool of
+// server. A name with a trailing * allows every tool starting with the text
+// before it.
+func ToolAllowed(capabilities map[string]map[string][]string, server, tool string) bool {
+	for _, name := range capabilities["tools"][server] {
+		if pattern, ok := strings.CutSuffix(name, "*"); ok {
+			if strings.HasPrefix(tool, pattern) {
+				return true
+			}
+			continue
+		}
+		if name == tool {
+			return true
+		}
+	}
+	return false
+}
+
+// validateES256 validates the JWT with the ES256 algorithm
+func validateES256(token string, publicKey string) (*jwtv5.Token, error) {
+	block, _ := pem.Decode([]byte(publicKey))
+	if block == nil {
+		return nil, fmt.Errorf("failed to decode PEM block")
+	}
+
+	return jwtv5.Parse(token, func(_ *jwtv5.Token) (any, error) {
+		pubkey, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		key, ok := pubkey.(*ecdsa.PublicKey)
+		if !ok {
+			return nil, fmt.Errorf("expected *ecdsa.PublicKey, got %T", pubkey)
+		}
+		return key, nil
+	}, jwtv5.WithValidMethods([]string{jwtv5.SigningMethodES256.Alg()}))
+}
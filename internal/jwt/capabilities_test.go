@@ -0,0 +1,42 @@
+package jwt
+
+import "testing"
+
+func TestToolAllowed(t *testing.T) {
+	capabilities := map[string]map[string][]string{
+		"tools": {
+			"team-a/weather": {"forecast", "alert*"},
+			"team-a/files":   {"*"},
+		},
+		"prompts": {"team-a/docs": {"summary"}},
+	}
+	tests := []struct {
+		server string
+		tool   string
+		want   bool
+	}{
+		{server: "team-a/weather", tool: "forecast", want: true},
+		{server: "team-a/weather", tool: "alerts_list", want: true},
+		{server: "team-a/weather", tool: "forecast_v2", want: false},
+		{server: "team-a/files", tool: "read", want: true},
+		{server: "team-a/docs", tool: "summary", want: false},
+		{server: "team-b/weather", tool: "forecast", want: false},
+	}
+	for _, tc := range tests {
+		if got := ToolAllowed(capabilities, tc.server, tc.tool); got != tc.want {
+			t.Errorf("ToolAllowed(%q, %q) = %v, want %v", tc.server, tc.tool, got, tc.want)
+		}
+	}
+}
+
+func TestParseAuthorizedCapabilities_Errors(t *testing.T) {
+	if _, err := ParseAuthorizedCapabilities("", "key"); err == nil {
+		t.Error("expected an error for an empty header")
+	}
+	if _, err := ParseAuthorizedCapabilities("a.b.c", ""); err == nil {
+		t.Error("expected an error without a public key")
+	}
+	if _, err := ParseAuthorizedCapabilities("a.b.c", "not pem"); err == nil {
+		t.Error("expected an error for an invalid public key")
+	}
+}
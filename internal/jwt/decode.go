@@ -69,3 +69,11 @@ func ExtractSubClaim(authHeader string) (string, error) {
 	}
 	return claims.Sub, nil
 }
+
+// SigningKeyID returns the key ID carried in the kid header of session JWTs
+// signed with key. derived from the key so the controller, which generates
+// keys, and the broker, which only receives them, agree without sharing state.
+func SigningKeyID(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:8])
+}
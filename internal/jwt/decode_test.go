@@ -100,3 +100,16 @@ func TestLogSafeSessionID(t *testing.T) {
 		t.Error("output must not contain the raw token")
 	}
 }
+
+func TestSigningKeyID(t *testing.T) {
+	a := SigningKeyID("key-a-must-be-at-least-32-bytes-long")
+	if len(a) != 16 {
+		t.Errorf("expected 16 hex chars, got %q", a)
+	}
+	if a != SigningKeyID("key-a-must-be-at-least-32-bytes-long") {
+		t.Error("expected a deterministic key ID")
+	}
+	if a == SigningKeyID("key-b-must-be-at-least-32-bytes-long") {
+		t.Error("expected different keys to get different IDs")
+	}
+}
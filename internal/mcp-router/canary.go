@@ -0,0 +1,145 @@
+package mcprouter
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/Kuadrant/mcp-gateway/internal/routing"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// CanaryMetrics counts tool calls to servers with a canary, per variant, so
+// the stable and canary error rates can be compared before promoting
+type CanaryMetrics struct {
+	toolCalls metric.Int64Counter
+}
+
+// NewCanaryMetrics creates the canary tool call instruments
+func NewCanaryMetrics() (*CanaryMetrics, error) {
+	meter := otel.GetMeterProvider().Meter("mcp-router")
+	toolCalls, err := meter.Int64Counter("mcp_router_canary_tool_calls",
+		metric.WithDescription("tool calls to upstream servers with a canary, per variant and outcome"),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create mcp_router_canary_tool_calls: %w", err)
+	}
+	return &CanaryMetrics{toolCalls: toolCalls}, nil
+}
+
+// canaryBodyLimit is how much of a response body a canary call buffers to
+// find its final JSON-RPC response. Earlier SSE events are dropped first.
+const canaryBodyLimit = 1 << 20
+
+// canaryCall follows the response of one tool call routed to a server with a
+// canary and records its outcome once
+type canaryCall struct {
+	metrics  *CanaryMetrics
+	server   string
+	variant  string
+	failed   bool
+	recorded bool
+	body     []byte
+	// tooLarge is set once the final response outgrew the buffer, leaving
+	// the outcome to the response status
+	tooLarge bool
+}
+
+// startCanaryCall returns the tracker for a tool call the router sent to a
+// variant of a server with a canary, or nil for any other request
+func (s *ExtProcServer) startCanaryCall(req *routing.MCPRequest, decision *routing.Decision) *canaryCall {
+	if s.CanaryMetrics == nil || decision.Error != nil || req == nil || !req.IsToolCall() {
+		return nil
+	}
+	variant := decision.SetHeaders[routing.MCPServerVariantHeader]
+	if variant == "" {
+		return nil
+	}
+	return &canaryCall{
+		metrics: s.CanaryMetrics,
+		server:  decision.SetHeaders[routing.MCPServerNameHeader],
+		variant: variant,
+	}
+}
+
+// responseStatus marks the call failed on a non-200 response
+func (c *canaryCall) responseStatus(statusCode string) {
+	if statusCode != "200" {
+		c.failed = true
+	}
+}
+
+// observe buffers a response body chunk
+func (c *canaryCall) observe(chunk []byte) {
+	if c.failed || c.tooLarge {
+		return
+	}
+	c.body = append(c.body, chunk...)
+	// only the final response is judged, so complete SSE events before it
+	// can go
+	for len(c.body) > canaryBodyLimit {
+		i := bytes.Index(c.body, []byte("\n\n"))
+		if i < 0 {
+			c.tooLarge = true
+			c.body = nil
+			return
+		}
+		c.body = c.body[i+2:]
+	}
+}
+
+// failedToolCall reports whether the final JSON-RPC response in a plain
+// JSON or SSE body is an error or a tool result with isError set. Error-like
+// content inside a result does not count.
+func failedToolCall(body []byte) bool {
+	var messages [][]byte
+	if trimmed := bytes.TrimSpace(body); bytes.HasPrefix(trimmed, []byte("{")) {
+		messages = [][]byte{trimmed}
+	} else {
+		messages = sseMessages(body)
+	}
+	for i := len(messages) - 1; i >= 0; i-- {
+		var msg struct {
+			Method string          `json:"method"`
+			Error  json.RawMessage `json:"error"`
+			Result *struct {
+				IsError bool `json:"isError"`
+			} `json:"result"`
+		}
+		if err := json.Unmarshal(messages[i], &msg); err != nil || msg.Method != "" {
+			continue
+		}
+		if msg.Error != nil && string(msg.Error) != "null" {
+			return true
+		}
+		if msg.Result != nil {
+			return msg.Result.IsError
+		}
+	}
+	return false
+}
+
+// record counts the call. A call whose response never completed counts as
+// failed.
+func (c *canaryCall) record(ctx context.Context, completed bool) {
+	if c.recorded {
+		return
+	}
+	c.recorded = true
+	if completed && !c.failed && !c.tooLarge {
+		c.failed = failedToolCall(c.body)
+	}
+	status := "success"
+	if c.failed || !completed {
+		status = "failure"
+	}
+	// the stream context may already be done
+	c.metrics.toolCalls.Add(context.WithoutCancel(ctx), 1, metric.WithAttributes(
+		attribute.String("server_name", c.server),
+		attribute.String("variant", c.variant),
+		attribute.String("status", status),
+	))
+}
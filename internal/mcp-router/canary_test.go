@@ -0,0 +1,103 @@
+package mcprouter
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/Kuadrant/mcp-gateway/internal/routing"
+	"github.com/stretchr/testify/require"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+)
+
+func newTestCanaryMetrics(t *testing.T) (*CanaryMetrics, *sdkmetric.ManualReader) {
+	t.Helper()
+	toolCalls, reader := newTestCounter(t, "mcp_router_canary_tool_calls")
+	return &CanaryMetrics{toolCalls: toolCalls}, reader
+}
+
+func variantDecision(server, variant string) *routing.Decision {
+	return &routing.Decision{SetHeaders: map[string]string{
+		routing.MCPServerNameHeader:    server,
+		routing.MCPServerVariantHeader: variant,
+	}}
+}
+
+func TestStartCanaryCall(t *testing.T) {
+	metrics, _ := newTestCanaryMetrics(t)
+	srv := &ExtProcServer{CanaryMetrics: metrics}
+
+	call := srv.startCanaryCall(testToolCall(), variantDecision("mcp-test/weather", routing.VariantCanary))
+	require.NotNil(t, call)
+	require.Equal(t, "mcp-test/weather", call.server)
+	require.Equal(t, routing.VariantCanary, call.variant)
+
+	require.Nil(t, srv.startCanaryCall(testToolCall(), toolCallDecision("mcp-test/weather")), "server without a canary")
+	rejected := variantDecision("mcp-test/weather", routing.VariantStable)
+	rejected.Error = &routing.Error{StatusCode: 200}
+	require.Nil(t, srv.startCanaryCall(testToolCall(), rejected), "call rejected before reaching a variant")
+	promptGet := &routing.MCPRequest{JSONRPC: "2.0", ID: 1, Method: "prompts/get", Params: map[string]any{"name": "weather_p"}}
+	require.Nil(t, srv.startCanaryCall(promptGet, variantDecision("mcp-test/weather", routing.VariantStable)))
+	require.Nil(t, (&ExtProcServer{}).startCanaryCall(testToolCall(), variantDecision("mcp-test/weather", routing.VariantCanary)), "metrics disabled")
+}
+
+func TestCanaryCall_Outcomes(t *testing.T) {
+	metrics, reader := newTestCanaryMetrics(t)
+	srv := &ExtProcServer{CanaryMetrics: metrics}
+	ctx := context.Background()
+	call := func(variant string) *canaryCall {
+		return srv.startCanaryCall(testToolCall(), variantDecision("mcp-test/weather", variant))
+	}
+
+	ok := call(routing.VariantStable)
+	ok.responseStatus("200")
+	ok.observe([]byte(`event: message` + "\n" + `data: {"jsonrpc":"2.0","id":7,"result":{"content":[],"isError":false}}`))
+	ok.record(ctx, true)
+	ok.record(ctx, false) // recorded once
+
+	// error-like text inside a result is not a tool error
+	errorText := call(routing.VariantStable)
+	errorText.responseStatus("200")
+	errorText.observe([]byte(`{"jsonrpc":"2.0","id":7,"result":{"content":[{"type":"text","text":"{\"error\":{\"isError\":true}}"}]}}`))
+	errorText.record(ctx, true)
+
+	// only the final response counts, not a nested error or a notification
+	nestedError := call(routing.VariantStable)
+	nestedError.responseStatus("200")
+	nestedError.observe([]byte("event: message\ndata: {\"jsonrpc\":\"2.0\",\"method\":\"notifications/message\",\"params\":{\"data\":{\"error\":{\"code\":1}}}}\n\n"))
+	nestedError.observe([]byte("event: message\ndata: {\"jsonrpc\":\"2.0\",\"id\":7,\"result\":{\"structuredContent\":{\"error\":{\"code\":1},\"isError\":true}}}\n\n"))
+	nestedError.record(ctx, true)
+
+	toolError := call(routing.VariantCanary)
+	toolError.responseStatus("200")
+	toolError.observe([]byte(`data: {"jsonrpc":"2.0","id":7,"result":{"content":[],"isErr`))
+	toolError.observe([]byte(`or": true}}`))
+	toolError.record(ctx, true)
+
+	rpcError := call(routing.VariantCanary)
+	rpcError.responseStatus("200")
+	rpcError.observe([]byte(`{"jsonrpc":"2.0","id":7,"error":{"code":-32603,"message":"boom"}}`))
+	rpcError.record(ctx, true)
+
+	// earlier events are dropped to keep the final response in the buffer
+	longStream := call(routing.VariantCanary)
+	longStream.responseStatus("200")
+	longStream.observe([]byte("event: message\ndata: {\"jsonrpc\":\"2.0\",\"method\":\"notifications/progress\",\"params\":{\"message\":\"" + strings.Repeat("x", canaryBodyLimit) + "\"}}\n\n"))
+	longStream.observe([]byte("event: message\ndata: {\"jsonrpc\":\"2.0\",\"id\":7,\"result\":{\"content\":[],\"isError\":true}}\n\n"))
+	longStream.record(ctx, true)
+
+	httpError := call(routing.VariantCanary)
+	httpError.responseStatus("503")
+	httpError.record(ctx, true)
+
+	unfinished := call(routing.VariantStable)
+	unfinished.responseStatus("200")
+	unfinished.observe([]byte(`data: {"jsonrpc":"2.0"`))
+	unfinished.record(ctx, false)
+
+	require.Equal(t, map[string]int64{
+		"mcp-test/weather/stable/success": 3,
+		"mcp-test/weather/stable/failure": 1,
+		"mcp-test/weather/canary/failure": 4,
+	}, counterValues(t, reader, "server_name", "variant", "status"))
+}
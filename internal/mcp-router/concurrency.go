@@ -0,0 +1,43 @@
+package mcprouter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/Kuadrant/mcp-gateway/internal/concurrency"
+	"github.com/Kuadrant/mcp-gateway/internal/routing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// acquireConcurrencySlot takes an in-flight slot for a tool call routed to an
+// upstream server with a concurrency limit. It returns the func that gives the
+// slot back, or a tool error decision when no slot freed up within the
+// server's queue timeout. Limiter failures let the call through.
+func (s *ExtProcServer) acquireConcurrencySlot(ctx context.Context, span trace.Span, req *routing.MCPRequest, decision *routing.Decision, json2026 bool) (func(), *routing.Decision, error) {
+	if s.ConcurrencyLimiter == nil {
+		return nil, nil, nil
+	}
+	cfg := s.routedToolCallServer(req, decision)
+	if cfg == nil || cfg.Concurrency == nil || cfg.Concurrency.MaxInFlight < 1 {
+		return nil, nil, nil
+	}
+
+	release, err := s.ConcurrencyLimiter.Acquire(ctx, cfg.Name, cfg.Concurrency.MaxInFlight, cfg.Concurrency.QueueTimeout())
+	switch {
+	case err == nil:
+		return release, nil, nil
+	case errors.Is(err, concurrency.ErrLimitReached):
+		s.Logger.InfoContext(ctx, "tool call rejected at concurrency limit", "server", cfg.Name, "tool", req.ToolName(), "limit", cfg.Concurrency.MaxInFlight)
+		span.SetAttributes(attribute.Bool("mcp.concurrency.limited", true))
+		s.endInFlight(ctx, req)
+		msg := fmt.Sprintf("MCP error: server %s is at its concurrency limit, retry later", cfg.Name)
+		return nil, toolErrorDecision(req, msg, json2026), nil
+	case ctx.Err() != nil:
+		return nil, nil, ctx.Err()
+	default:
+		s.Logger.WarnContext(ctx, "concurrency limit check failed, allowing tool call", "server", cfg.Name, "error", err)
+		return nil, nil, nil
+	}
+}
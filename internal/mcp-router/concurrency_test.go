@@ -0,0 +1,319 @@
+package mcprouter
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Kuadrant/mcp-gateway/internal/concurrency"
+	"github.com/Kuadrant/mcp-gateway/internal/config"
+	"github.com/Kuadrant/mcp-gateway/internal/routing"
+	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	extProcV3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// failingLimiter cannot check its limits, like a limiter whose Redis is down
+type failingLimiter struct{}
+
+func (failingLimiter) Acquire(context.Context, string, int, time.Duration) (func(), error) {
+	return nil, errors.New("connection refused")
+}
+
+func withConcurrencyTestServers() testServerOption {
+	return withServers(
+		&config.MCPServer{Name: "mcp-test/limited", Concurrency: &config.ConcurrencyConfig{MaxInFlight: 1}},
+		&config.MCPServer{Name: "mcp-test/unlimited"},
+	)
+}
+
+func toolCallDecision(server string) *routing.Decision {
+	return &routing.Decision{SetHeaders: map[string]string{routing.MCPServerNameHeader: server}}
+}
+
+func testToolCall() *routing.MCPRequest {
+	return &routing.MCPRequest{
+		JSONRPC: "2.0",
+		ID:      7,
+		Method:  "tools/call",
+		Params:  map[string]any{"name": "limited_echo"},
+	}
+}
+
+func TestAcquireConcurrencySlot(t *testing.T) {
+	limiter, err := concurrency.New()
+	require.NoError(t, err)
+	srv := newTestServer(t, withConcurrencyTestServers(), withConcurrencyLimiter(limiter))
+	ctx := context.Background()
+	span := trace.SpanFromContext(ctx)
+
+	release, rejected, err := srv.acquireConcurrencySlot(ctx, span, testToolCall(), toolCallDecision("mcp-test/limited"), false)
+	require.NoError(t, err)
+	require.Nil(t, rejected)
+	require.NotNil(t, release)
+
+	// the only slot is held, and no queue timeout is configured
+	_, rejected, err = srv.acquireConcurrencySlot(ctx, span, testToolCall(), toolCallDecision("mcp-test/limited"), false)
+	require.NoError(t, err)
+	require.NotNil(t, rejected)
+	require.NotNil(t, rejected.Error)
+	require.Equal(t, 200, rejected.Error.StatusCode)
+	require.Contains(t, rejected.Error.JSONRPCErr, "event: message")
+	require.Contains(t, rejected.Error.JSONRPCErr, "mcp-test/limited is at its concurrency limit")
+	require.Contains(t, rejected.Error.JSONRPCErr, `"isError":true`)
+
+	_, rejected, err = srv.acquireConcurrencySlot(ctx, span, testToolCall(), toolCallDecision("mcp-test/limited"), true)
+	require.NoError(t, err)
+	require.NotNil(t, rejected)
+	require.Equal(t, "application/json", rejected.Error.ContentType)
+	require.True(t, strings.HasPrefix(rejected.Error.JSONRPCErr, `{"jsonrpc":"2.0","id":7`))
+
+	release()
+	release, rejected, err = srv.acquireConcurrencySlot(ctx, span, testToolCall(), toolCallDecision("mcp-test/limited"), false)
+	require.NoError(t, err)
+	require.Nil(t, rejected)
+	release()
+}
+
+func TestAcquireConcurrencySlot_NotLimited(t *testing.T) {
+	limiter, err := concurrency.New()
+	require.NoError(t, err)
+	srv := newTestServer(t, withConcurrencyTestServers(), withConcurrencyLimiter(limiter))
+	ctx := context.Background()
+	span := trace.SpanFromContext(ctx)
+
+	tests := []struct {
+		name     string
+		req      *routing.MCPRequest
+		decision *routing.Decision
+	}{
+		{name: "server without a limit", req: testToolCall(), decision: toolCallDecision("mcp-test/unlimited")},
+		{name: "unknown server", req: testToolCall(), decision: toolCallDecision("mcp-test/gone")},
+		{name: "broker tool", req: testToolCall(), decision: toolCallDecision(brokerServerName)},
+		{name: "no server header", req: testToolCall(), decision: &routing.Decision{}},
+		{
+			name:     "rejected by the router",
+			req:      testToolCall(),
+			decision: &routing.Decision{Error: &routing.Error{StatusCode: 404}, SetHeaders: map[string]string{routing.MCPServerNameHeader: "mcp-test/limited"}},
+		},
+		{
+			name:     "not a tool call",
+			req:      &routing.MCPRequest{JSONRPC: "2.0", ID: 1, Method: "prompts/get", Params: map[string]any{"name": "limited_p"}},
+			decision: toolCallDecision("mcp-test/limited"),
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			// repeated calls never hit the limit of one
+			for range 3 {
+				release, rejected, err := srv.acquireConcurrencySlot(ctx, span, tc.req, tc.decision, false)
+				require.NoError(t, err)
+				require.Nil(t, rejected)
+				require.Nil(t, release)
+			}
+		})
+	}
+}
+
+func TestAcquireConcurrencySlot_FailsOpen(t *testing.T) {
+	srv := newTestServer(t, withConcurrencyTestServers(), withConcurrencyLimiter(failingLimiter{}))
+	ctx := context.Background()
+
+	release, rejected, err := srv.acquireConcurrencySlot(ctx, trace.SpanFromContext(ctx), testToolCall(), toolCallDecision("mcp-test/limited"), false)
+	require.NoError(t, err)
+	require.Nil(t, rejected)
+	require.Nil(t, release)
+}
+
+func TestAcquireConcurrencySlot_ContextEndsWhileQueued(t *testing.T) {
+	limiter, err := concurrency.New()
+	require.NoError(t, err)
+	srv := newTestServer(t, withConcurrencyTestServers(), withConcurrencyLimiter(limiter))
+	srv.RoutingConfig.Store(&config.MCPServersConfig{Servers: []*config.MCPServer{
+		{Name: "mcp-test/limited", Concurrency: &config.ConcurrencyConfig{MaxInFlight: 1, QueueTimeoutMilliseconds: 5000}},
+	}})
+
+	held, _, err := srv.acquireConcurrencySlot(context.Background(), trace.SpanFromContext(context.Background()), testToolCall(), toolCallDecision("mcp-test/limited"), false)
+	require.NoError(t, err)
+	defer held()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, rejected, err := srv.acquireConcurrencySlot(ctx, trace.SpanFromContext(ctx), testToolCall(), toolCallDecision("mcp-test/limited"), false)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	require.Nil(t, rejected)
+}
+
+// pacedProcessStream is an ext_proc stream fed one message at a time, so a
+// test can hold a response open between body chunks
+type pacedProcessStream struct {
+	extProcV3.ExternalProcessor_ProcessServer
+	ctx  context.Context
+	recv chan *extProcV3.ProcessingRequest
+	sent chan *extProcV3.ProcessingResponse
+}
+
+func newPacedProcessStream(ctx context.Context) *pacedProcessStream {
+	return &pacedProcessStream{
+		ctx:  ctx,
+		recv: make(chan *extProcV3.ProcessingRequest),
+		sent: make(chan *extProcV3.ProcessingResponse, 16),
+	}
+}
+
+func (s *pacedProcessStream) Context() context.Context { return s.ctx }
+
+func (s *pacedProcessStream) Recv() (*extProcV3.ProcessingRequest, error) {
+	select {
+	case msg := <-s.recv:
+		return msg, nil
+	case <-s.ctx.Done():
+		return nil, s.ctx.Err()
+	}
+}
+
+// deliver hands msg to Process, failing t if Process stopped reading
+func (s *pacedProcessStream) deliver(t *testing.T, msg *extProcV3.ProcessingRequest) {
+	t.Helper()
+	select {
+	case s.recv <- msg:
+	case <-s.ctx.Done():
+		t.Fatal("process stopped reading the stream")
+	}
+}
+
+func (s *pacedProcessStream) Send(resp *extProcV3.ProcessingResponse) error {
+	s.sent <- resp
+	return nil
+}
+
+// routedTo is a Router that sends every request to server
+type routedTo string
+
+func (r routedTo) RouteRequest(context.Context, *routing.Request) *routing.Decision {
+	return toolCallDecision(string(r))
+}
+
+func TestProcess_SlotHeldUntilSSEResponseEnds(t *testing.T) {
+	limiter, err := concurrency.New()
+	require.NoError(t, err)
+	srv := newTestServer(t,
+		withServers(&config.MCPServer{Name: "mcp-test/limited", Concurrency: &config.ConcurrencyConfig{MaxInFlight: 1, QueueTimeoutMilliseconds: 5000}}),
+		withConcurrencyLimiter(limiter))
+	srv.Router = routedTo("mcp-test/limited")
+	srv.ResponseHandler = &stubResponseHandler{}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	toolCall := func(stream *pacedProcessStream) {
+		stream.deliver(t, &extProcV3.ProcessingRequest{Request: &extProcV3.ProcessingRequest_RequestHeaders{
+			RequestHeaders: &extProcV3.HttpHeaders{Headers: &corev3.HeaderMap{Headers: []*corev3.HeaderValue{
+				{Key: "content-type", RawValue: []byte("application/json")},
+			}}},
+		}})
+		stream.deliver(t, &extProcV3.ProcessingRequest{Request: &extProcV3.ProcessingRequest_RequestBody{
+			RequestBody: &extProcV3.HttpBody{
+				Body:        []byte(`{"jsonrpc":"2.0","id":7,"method":"tools/call","params":{"name":"limited_echo"}}`),
+				EndOfStream: true,
+			},
+		}})
+	}
+	sseBody := func(stream *pacedProcessStream, chunk string, endOfStream bool) {
+		stream.deliver(t, &extProcV3.ProcessingRequest{Request: &extProcV3.ProcessingRequest_ResponseBody{
+			ResponseBody: &extProcV3.HttpBody{Body: []byte(chunk), EndOfStream: endOfStream},
+		}})
+	}
+
+	first := newPacedProcessStream(ctx)
+	firstDone := make(chan error, 1)
+	go func() { firstDone <- srv.Process(first) }()
+	toolCall(first)
+	first.deliver(t, &extProcV3.ProcessingRequest{Request: &extProcV3.ProcessingRequest_ResponseHeaders{
+		ResponseHeaders: &extProcV3.HttpHeaders{Headers: &corev3.HeaderMap{Headers: []*corev3.HeaderValue{
+			{Key: ":status", RawValue: []byte("200")},
+			{Key: "content-type", RawValue: []byte("text/event-stream")},
+		}}},
+	}})
+	sseBody(first, "event: message\n", false)
+
+	// the first call's SSE response is still open, so the second call queues
+	second := newPacedProcessStream(ctx)
+	secondDone := make(chan error, 1)
+	go func() { secondDone <- srv.Process(second) }()
+	toolCall(second)
+	select {
+	case resp := <-second.sent:
+		require.IsType(t, &extProcV3.ProcessingResponse_RequestHeaders{}, resp.Response)
+	case <-ctx.Done():
+		t.Fatal("no request headers response")
+	}
+	select {
+	case resp := <-second.sent:
+		t.Fatalf("second call was routed while the first response was streaming: %v", resp)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	sseBody(first, `data: {"jsonrpc":"2.0","id":7,"result":{"content":[]}}`+"\n\n", true)
+	require.NoError(t, <-firstDone)
+
+	// the slot is free once the first response has ended
+	select {
+	case resp := <-second.sent:
+		require.IsType(t, &extProcV3.ProcessingResponse_RequestBody{}, resp.Response)
+	case <-ctx.Done():
+		t.Fatal("second call never got the released slot")
+	}
+	cancel()
+	require.ErrorIs(t, <-secondDone, context.Canceled)
+}
+
+// TestProcess_StreamedResponseClearsInFlight covers a tool call whose body is
+// streamed only to hold its slot, with no elicitation rewriting
+func TestProcess_StreamedResponseClearsInFlight(t *testing.T) {
+	limiter, err := concurrency.New()
+	require.NoError(t, err)
+	srv := newTestServer(t, withConcurrencyTestServers(), withConcurrencyLimiter(limiter))
+	srv.Router = routedTo("mcp-test/limited")
+	srv.ResponseHandler = &stubResponseHandler{}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	inFlightKey := routing.RequestIDKey(float64(7))
+	require.NoError(t, srv.SessionCache.SetInFlight(ctx, "gw-session", inFlightKey, "mcp-test/limited", time.Minute))
+
+	stream := newPacedProcessStream(ctx)
+	done := make(chan error, 1)
+	go func() { done <- srv.Process(stream) }()
+	stream.deliver(t, &extProcV3.ProcessingRequest{Request: &extProcV3.ProcessingRequest_RequestHeaders{
+		RequestHeaders: &extProcV3.HttpHeaders{Headers: &corev3.HeaderMap{Headers: []*corev3.HeaderValue{
+			{Key: "content-type", RawValue: []byte("application/json")},
+			{Key: routing.SessionHeader, RawValue: []byte("gw-session")},
+		}}},
+	}})
+	stream.deliver(t, &extProcV3.ProcessingRequest{Request: &extProcV3.ProcessingRequest_RequestBody{
+		RequestBody: &extProcV3.HttpBody{
+			Body:        []byte(`{"jsonrpc":"2.0","id":7,"method":"tools/call","params":{"name":"limited_echo"}}`),
+			EndOfStream: true,
+		},
+	}})
+	stream.deliver(t, &extProcV3.ProcessingRequest{Request: &extProcV3.ProcessingRequest_ResponseHeaders{
+		ResponseHeaders: &extProcV3.HttpHeaders{Headers: &corev3.HeaderMap{Headers: []*corev3.HeaderValue{
+			{Key: ":status", RawValue: []byte("200")},
+			{Key: "content-type", RawValue: []byte("text/event-stream")},
+		}}},
+	}})
+	stream.deliver(t, &extProcV3.ProcessingRequest{Request: &extProcV3.ProcessingRequest_ResponseBody{
+		ResponseBody: &extProcV3.HttpBody{
+			Body:        []byte("event: message\ndata: {\"jsonrpc\":\"2.0\",\"id\":7,\"result\":{\"content\":[]}}\n\n"),
+			EndOfStream: true,
+		},
+	}})
+	require.NoError(t, <-done)
+
+	server, err := srv.SessionCache.GetInFlight(ctx, "gw-session", inFlightKey)
+	require.NoError(t, err)
+	require.Empty(t, server)
+}
@@ -5,6 +5,7 @@ import (
 	"context"
 	"encoding/json"
 	"log/slog"
+	"time"
 
 	"github.com/Kuadrant/mcp-gateway/internal/idmap"
 	"github.com/Kuadrant/mcp-gateway/internal/routing"
@@ -12,25 +13,39 @@ import (
 
 var dataPrefix = []byte("data:")
 
-// elicitationRewriter rewrites sse elicitation requests based on contents of idMap
-// idMap entries are managed in the following way:
+// elicitationRewriter rewrites the IDs of server-initiated sse requests
+// (elicitation/create, sampling/createMessage and roots/list) based on
+// contents of idMap. idMap entries are managed in the following way:
 //  1. Client calls tool
 //  2. Backend starts streaming response
-//  3. backend sends elicitation/create in the stream - stream stays open - id stored
-//  4. Client sends elicitation response (separate HTTP request) -> Lookup() reads the entry,
+//  3. backend sends a server-initiated request in the stream - stream stays open - id stored
+//  4. Client sends its response (separate HTTP request) -> Lookup() reads the entry,
 //     Remove() is called after the response is successfully forwarded
 //  5. Backend receives the response, continues processing, sends the tool result
 //  6. Stream ends -> Flush() called -> Remove() is called on entries to clean up any orphaned elicitations,
 //     is noop for already removed keys
+//
+// sampling/createMessage spends the client's model tokens, so it is only
+// forwarded when allowSampling is set for the server; otherwise the event is
+// dropped from the stream and reject answers it with an error.
 type elicitationRewriter struct {
-	buf        []byte
-	idMap      idmap.Map
-	req        *routing.MCPRequest
-	logger     *slog.Logger
-	gatewayIDs []string
+	buf           []byte
+	idMap         idmap.Map
+	req           *routing.MCPRequest
+	logger        *slog.Logger
+	gatewayIDs    []string
+	allowSampling bool
+	reject        func(ctx context.Context, req *routing.MCPRequest, id any, code int, message string) error
 }
 
-// Process receives a chunk of SSE response data and rewrites any elicitation/create request IDs.
+// samplingRejectedCode is the JSON-RPC error code of a sampling request the
+// gateway refuses, the code MCP uses for a rejected sampling request
+const samplingRejectedCode = -1
+
+// rejectServerRequestTimeout bounds the reply to a refused server request
+const rejectServerRequestTimeout = 5 * time.Second
+
+// Process receives a chunk of SSE response data and rewrites any server-initiated request IDs.
 // As SSE is a line-based protocol, splitting on \n ensures we only
 // parse and rewrite fully received JSON-RPC messages
 func (w *elicitationRewriter) Process(ctx context.Context, chunk []byte) []byte {
@@ -75,6 +90,16 @@ func (w *elicitationRewriter) Flush(ctx context.Context) []byte {
 	return remaining
 }
 
+// rewritesMethod reports whether method is a server-initiated request whose
+// response the client sends back through the router
+func rewritesMethod(method string) bool {
+	switch method {
+	case routing.MethodElicitationCreate, routing.MethodSamplingCreateMessage, routing.MethodRootsList:
+		return true
+	}
+	return false
+}
+
 type jsonRPCMessage struct {
 	JSONRPC string          `json:"jsonrpc"`
 	Method  string          `json:"method,omitempty"`
@@ -84,6 +109,22 @@ type jsonRPCMessage struct {
 	Error   json.RawMessage `json:"error,omitempty"`
 }
 
+// rejectServerRequest answers a dropped server request so the backend does
+// not wait for a reply. It runs in the background: the reply hairpins through
+// the gateway while this response is still streaming.
+func (w *elicitationRewriter) rejectServerRequest(ctx context.Context, id any, code int, message string) {
+	if w.reject == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), rejectServerRequestTimeout)
+	go func() {
+		defer cancel()
+		if err := w.reject(ctx, w.req, id, code, message); err != nil {
+			w.logger.WarnContext(ctx, "failed to reject server request", "serverName", w.req.ServerName, "error", err)
+		}
+	}()
+}
+
 func (w *elicitationRewriter) maybeRewriteElicitation(ctx context.Context, line []byte) []byte {
 	trimmed := bytes.TrimSpace(line)
 	jsonData := bytes.TrimPrefix(trimmed, dataPrefix)
@@ -96,18 +137,25 @@ func (w *elicitationRewriter) maybeRewriteElicitation(ctx context.Context, line
 		return line // not jsonrpc, so definitely not an elicitation req to rewrite
 	}
 
-	if msg.Method != "elicitation/create" || msg.ID == nil {
+	if !rewritesMethod(msg.Method) || msg.ID == nil {
 		return line
 	}
+	if msg.Method == routing.MethodSamplingCreateMessage && !w.allowSampling {
+		w.logger.WarnContext(ctx, "dropping sampling request from server not allowed to sample", "serverName", w.req.ServerName)
+		w.rejectServerRequest(ctx, msg.ID, samplingRejectedCode, "sampling is not allowed for this server")
+		return nil
+	}
 
 	gatewayID, err := w.idMap.Store(ctx, msg.ID, w.req.ServerName, w.req.BackendSessionID, w.req.GetSessionID())
 	if err != nil {
-		w.logger.ErrorContext(ctx, "failed to store elicitation mapping", "error", err)
+		w.logger.ErrorContext(ctx, "failed to store server request mapping", "error", err, "method", msg.Method)
 		return line
 	}
 	w.logger.DebugContext(
 		ctx,
-		"rewriting elicitation request ID",
+		"rewriting server request ID",
+		"method",
+		msg.Method,
 		"backendID",
 		msg.ID,
 		"gatewayID",
@@ -121,7 +169,7 @@ func (w *elicitationRewriter) maybeRewriteElicitation(ctx context.Context, line
 	msg.ID = gatewayID
 	rewritten, err := json.Marshal(&msg)
 	if err != nil {
-		w.logger.ErrorContext(ctx, "failed to marshal rewritten elicitation", "error", err)
+		w.logger.ErrorContext(ctx, "failed to marshal rewritten server request", "error", err)
 		return line
 	}
 
@@ -6,6 +6,7 @@ import (
 	"log/slog"
 	"os"
 	"testing"
+	"time"
 
 	"github.com/Kuadrant/mcp-gateway/internal/idmap"
 	"github.com/Kuadrant/mcp-gateway/internal/routing"
@@ -380,3 +381,70 @@ func TestElicitationRewriter_MaybeRewriteElicitation(t *testing.T) {
 		})
 	}
 }
+
+func TestElicitationRewriter_Process_SamplingAndRoots(t *testing.T) {
+	ctx := context.Background()
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+
+	sampling := `data: {"jsonrpc":"2.0","method":"sampling/createMessage","id":5,"params":{"messages":[],"maxTokens":10}}` + "\n"
+	roots := `data: {"jsonrpc":"2.0","method":"roots/list","id":"r-1"}` + "\n"
+
+	t.Run("rewrites both for a server allowed to sample", func(t *testing.T) {
+		m, err := idmap.New()
+		require.NoError(t, err)
+		w := &elicitationRewriter{
+			idMap:         m,
+			logger:        logger,
+			allowSampling: true,
+			req:           &routing.MCPRequest{ServerName: "sampler", BackendSessionID: "backend-1"},
+		}
+
+		for _, line := range []string{sampling, roots} {
+			out := w.Process(ctx, []byte(line))
+			var msg jsonRPCMessage
+			require.NoError(t, json.Unmarshal(out[len("data: "):], &msg))
+			require.Equal(t, w.gatewayIDs[len(w.gatewayIDs)-1], msg.ID)
+		}
+		require.Len(t, w.gatewayIDs, 2)
+
+		entry, ok, err := m.Lookup(ctx, w.gatewayIDs[0])
+		require.NoError(t, err)
+		require.True(t, ok)
+		require.Equal(t, float64(5), entry.BackendID)
+		require.Equal(t, "sampler", entry.ServerName)
+	})
+
+	t.Run("drops sampling from a server not allowed to sample", func(t *testing.T) {
+		m, err := idmap.New()
+		require.NoError(t, err)
+		type rejection struct {
+			server string
+			id     any
+			code   int
+		}
+		rejected := make(chan rejection, 1)
+		w := &elicitationRewriter{
+			idMap:  m,
+			logger: logger,
+			req:    &routing.MCPRequest{ServerName: "untrusted", BackendSessionID: "backend-1"},
+			reject: func(_ context.Context, req *routing.MCPRequest, id any, code int, _ string) error {
+				rejected <- rejection{server: req.ServerName, id: id, code: code}
+				return nil
+			},
+		}
+
+		out := w.Process(ctx, []byte("event: message\n"+sampling+"\n"))
+		require.Equal(t, "event: message\n\n", string(out), "the sampling request never reaches the client")
+		require.Empty(t, w.gatewayIDs)
+		select {
+		case r := <-rejected:
+			require.Equal(t, rejection{server: "untrusted", id: float64(5), code: samplingRejectedCode}, r, "the backend is answered with an error")
+		case <-time.After(time.Second):
+			t.Fatal("sampling request was not rejected")
+		}
+
+		out = w.Process(ctx, []byte(roots))
+		require.NotEqual(t, roots, string(out), "roots/list is still rewritten")
+		require.Len(t, w.gatewayIDs, 1)
+	})
+}
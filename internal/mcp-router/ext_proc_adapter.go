@@ -4,13 +4,17 @@ package mcprouter
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
 	"net/http"
+	"slices"
 	"strconv"
 	"strings"
 	"sync/atomic"
 
+	"github.com/Kuadrant/mcp-gateway/internal/authn"
+	"github.com/Kuadrant/mcp-gateway/internal/concurrency"
 	"github.com/Kuadrant/mcp-gateway/internal/config"
 	"github.com/Kuadrant/mcp-gateway/internal/headers"
 	"github.com/Kuadrant/mcp-gateway/internal/idmap"
@@ -42,6 +46,37 @@ type ExtProcServer struct {
 	// protocol metadata lifted into headers for Telemetry and AuthPolicy. Off by
 	// default; no A2A code path runs unless it is set.
 	EnableA2A bool
+	// Authenticator, when set, validates client bearer tokens before any
+	// routing, for deployments without an AuthPolicy in front of the
+	// gateway. Rejected requests get a 401, or a 403 for a token lacking a
+	// required scope, whose WWW-Authenticate header points at
+	// ResourceMetadataURL.
+	Authenticator       *authn.Authenticator
+	ResourceMetadataURL string
+	// ValidateBackendInitToken verifies the backend-init token on router
+	// hairpin requests, which skip the Authenticator: they may carry a
+	// per-user upstream credential rather than a gateway access token.
+	ValidateBackendInitToken func(token, host string) error
+	// ConcurrencyLimiter bounds in-flight tool calls to upstream servers
+	// that configure a concurrency limit. Nil disables the limit.
+	ConcurrencyLimiter concurrency.Limiter
+	// CanaryMetrics counts tool calls to servers with a canary per variant.
+	// Nil disables the count.
+	CanaryMetrics *CanaryMetrics
+	// RetryBudget withholds retries from servers whose recent tool calls
+	// mostly failed. Nil keeps tool call timeouts but disables retries.
+	RetryBudget *RetryBudget
+	// ResultCache serves repeated calls to cached read-only tools; nil
+	// disables result caching
+	ResultCache *ResultCache
+	// RejectServerRequest answers a request a backend sent on a tool call's
+	// stream that the router drops, such as sampling from a server not
+	// allowed to sample. Nil leaves the backend without a reply.
+	RejectServerRequest func(ctx context.Context, req *routing.MCPRequest, id any, code int, message string) error
+	// TrustedHeadersPublicKey verifies the signed x-mcp-authorized header.
+	// The router runs before any AuthPolicy, so a cached result is only
+	// served to a call whose header allows the tool.
+	TrustedHeadersPublicKey string
 }
 
 // OnConfigChange is used to register the router for config changes
@@ -50,18 +85,88 @@ func (s *ExtProcServer) OnConfigChange(_ context.Context, newConfig *config.MCPS
 }
 
 // HandleRequestHeaders sets the gateway authority and extracts the verified sub claim.
+// With an Authenticator configured the bearer token is validated first and
+// an invalid one ends the request with an immediate 401, or 403 when it
+// lacks a required scope.
 func (s *ExtProcServer) HandleRequestHeaders(ctx context.Context, headers *extProcV3.HttpHeaders) ([]*extProcV3.ProcessingResponse, error) {
 	s.Logger.DebugContext(ctx, "Request Handler: HandleRequestHeaders called")
 	requestHeaders := NewHeaders()
 	response := NewResponse()
 	requestHeaders.WithAuthority(s.RoutingConfig.Load().MCPGatewayExternalHostname)
 	authHeader := getSingleValueHeader(headers.GetHeaders(), routing.AuthorizationHeader)
-	if sub, _ := internaljwt.ExtractSubClaim(authHeader); sub != "" {
+	if s.requiresAuthentication(headers.GetHeaders()) {
+		claims, err := s.Authenticator.Authenticate(ctx, authHeader)
+		if err != nil {
+			return s.unauthenticatedResponse(ctx, err), nil
+		}
+		if claims.Subject != "" {
+			requestHeaders.WithVerifiedSub(claims.Subject)
+		}
+	} else if sub, _ := internaljwt.ExtractSubClaim(authHeader); sub != "" {
 		requestHeaders.WithVerifiedSub(sub)
 	}
 	return response.WithRequestHeadersResponse(requestHeaders.Build(), routing.InternalOnlyHeaders...).Build(), nil
 }
 
+// clientRegistrationPath is the broker's dynamic client registration
+// endpoint, which clients call before they hold a token
+const clientRegistrationPath = "/oauth/register"
+
+// requiresAuthentication reports whether the Authenticator applies to a
+// request. OAuth discovery documents, dynamic client registration and CORS
+// preflights must stay reachable without a token, and router hairpin
+// requests are trusted on their backend-init token.
+func (s *ExtProcServer) requiresAuthentication(headers *basepb.HeaderMap) bool {
+	if s.Authenticator == nil {
+		return false
+	}
+	if getSingleValueHeader(headers, ":method") == http.MethodOptions {
+		return false
+	}
+	path := getSingleValueHeader(headers, ":path")
+	if strings.HasPrefix(path, "/.well-known/") {
+		return false
+	}
+	if p, _, _ := strings.Cut(path, "?"); p == clientRegistrationPath {
+		return false
+	}
+	if s.ValidateBackendInitToken != nil {
+		token := getSingleValueHeader(headers, routing.RoutingKey)
+		host := getSingleValueHeader(headers, "mcp-init-host")
+		if token != "" && host != "" && s.ValidateBackendInitToken(token, host) == nil {
+			return false
+		}
+	}
+	return true
+}
+
+// unauthenticatedResponse is the response sent for a rejected bearer token:
+// 403 when a valid token lacks a required scope, 401 otherwise
+func (s *ExtProcServer) unauthenticatedResponse(ctx context.Context, err error) []*extProcV3.ProcessingResponse {
+	authErr := &authn.Error{Code: authn.ErrorCodeInvalidToken, Description: "the access token is invalid"}
+	_ = errors.As(err, &authErr)
+	status := int32(authErr.StatusCode()) //nolint:gosec // the status is 401 or 403
+	span := trace.SpanFromContext(ctx)
+	recordError(span, err, status)
+	if status == http.StatusForbidden {
+		s.Logger.InfoContext(ctx, "rejecting request with insufficient scope", "error", err)
+		span.SetAttributes(attribute.String("error.type", "insufficient_scope"))
+	} else {
+		s.Logger.InfoContext(ctx, "rejecting unauthenticated request", "error", err)
+		span.SetAttributes(attribute.String("error.type", "unauthenticated"))
+	}
+
+	code := authErr.Code
+	if code == "" {
+		code = "unauthorized"
+	}
+	body, _ := json.Marshal(map[string]string{"error": code, "error_description": authErr.Description})
+	challenge := []*basepb.HeaderValueOption{{
+		Header: &basepb.HeaderValue{Key: "www-authenticate", RawValue: []byte(authErr.Challenge(s.ResourceMetadataURL))},
+	}}
+	return NewResponse().WithImmediateJSONRPCResponse(status, challenge, string(body), "application/json").Build()
+}
+
 // decisionToResponse translates a transport-agnostic RoutingDecision into
 // ext_proc ProcessingResponse(s) for the body phase.
 func decisionToResponse(d *routing.Decision) []*extProcV3.ProcessingResponse {
@@ -170,6 +275,11 @@ func (s *ExtProcServer) Process(stream extProcV3.ExternalProcessor_ProcessServer
 		isA2A               = false              // true for /a2a traffic when A2A passthrough is enabled
 		rewriter            *elicitationRewriter // nil until a tool call response arrives
 		resourceRewriter    *resourceURIRewriter // nil until a tool call response with resources arrives
+		releaseSlot         func()               // non-nil while a tool call holds a concurrency slot
+		canary              *canaryCall          // non-nil for a tool call to a server with a canary
+		retryServer         string               // server whose retry budget the tool call outcome counts against
+		capture             *resultCapture       // non-nil while the response of a cacheable tool call is buffered
+		streamed            = false              // true once the response body of a tool call is streamed through the router
 	)
 	span := trace.SpanFromContext(ctx)
 	defer func() { span.End() }()
@@ -180,9 +290,21 @@ func (s *ExtProcServer) Process(stream extProcV3.ExternalProcessor_ProcessServer
 		if rewriter != nil {
 			_ = rewriter.Flush(ctx)
 		}
+		if streamed {
+			s.endInFlight(ctx, mcpRequest)
+		}
 		if resourceRewriter != nil {
 			_ = resourceRewriter.Flush(ctx)
 		}
+		if releaseSlot != nil {
+			releaseSlot()
+		}
+		if canary != nil {
+			canary.record(ctx, false)
+		}
+		if retryServer != "" {
+			s.RetryBudget.Record(retryServer, true)
+		}
 	}()
 	for {
 		req, err := stream.Recv()
@@ -279,6 +401,10 @@ func (s *ExtProcServer) Process(stream extProcV3.ExternalProcessor_ProcessServer
 					return err
 				}
 			}
+			// an immediate response (a rejected token) ends the request
+			if len(responses) > 0 && responses[0].GetImmediateResponse() != nil {
+				return nil
+			}
 			continue
 
 		case *extProcV3.ProcessingRequest_RequestBody:
@@ -412,6 +538,14 @@ func (s *ExtProcServer) Process(stream extProcV3.ExternalProcessor_ProcessServer
 				continue
 			}
 			mcpRequest.Headers = headerMapToMap(localRequestHeaders.Headers)
+			// these are the headers as the client sent them: replace any
+			// x-mcp-verified-sub it supplied with the one set in the headers
+			// phase, so the value re-injected for the broker is always derived
+			// from the bearer token
+			delete(mcpRequest.Headers, routing.MCPVerifiedSubHeader)
+			if sub, _ := internaljwt.ExtractSubClaim(mcpRequest.Headers[routing.AuthorizationHeader]); sub != "" {
+				mcpRequest.Headers[routing.MCPVerifiedSubHeader] = sub
+			}
 			span.SetAttributes(spanAttributes(mcpRequest)...)
 
 			routingReq := &routing.Request{
@@ -447,6 +581,26 @@ func (s *ExtProcServer) Process(stream extProcV3.ExternalProcessor_ProcessServer
 			span.SetAttributes(attribute.String("mcp.router", routerName))
 			s.Logger.DebugContext(ctx, "routing request", "router", routerName, "protocol-version", protocolVersion, "mcp-method", routingReq.MCPMethod, "mcp-name", routingReq.MCPName)
 			decision := router.RouteRequest(ctx, routingReq)
+			json2026 := routerName == "202607"
+			if rejected := s.maintenanceRejection(ctx, span, mcpRequest, decision, json2026); rejected != nil {
+				decision = rejected
+			}
+			if cached, toCapture := s.lookupToolResult(ctx, span, mcpRequest, decision, body, json2026); cached != nil {
+				decision = cached
+			} else {
+				capture = toCapture
+			}
+			release, limited, err := s.acquireConcurrencySlot(ctx, span, mcpRequest, decision, json2026)
+			if err != nil {
+				s.Logger.DebugContext(ctx, "request ended while queued for a concurrency slot", "request id", requestID, "error", err)
+				return err
+			}
+			releaseSlot = release
+			if limited != nil {
+				decision = limited
+			}
+			canary = s.startCanaryCall(mcpRequest, decision)
+			retryServer = s.applyToolCallPolicy(ctx, span, mcpRequest, decision)
 			if decision.Error != nil && mcpRequest.IsToolCall() {
 				authSub, _ := internaljwt.ExtractSubClaim(mcpRequest.Headers[routing.AuthorizationHeader])
 				s.Logger.InfoContext(ctx, "tool call",
@@ -499,6 +653,13 @@ func (s *ExtProcServer) Process(stream extProcV3.ExternalProcessor_ProcessServer
 			}
 
 			statusCode := getSingleValueHeader(r.ResponseHeaders.Headers, ":status")
+			if canary != nil {
+				canary.responseStatus(statusCode)
+			}
+			if retryServer != "" {
+				s.RetryBudget.Record(retryServer, toolCallFailed(statusCode))
+				retryServer = ""
+			}
 			span.SetAttributes(
 				attribute.String("http.status_code", statusCode),
 				attribute.String("mcp.response.protocol_version", protocolVersion),
@@ -509,6 +670,7 @@ func (s *ExtProcServer) Process(stream extProcV3.ExternalProcessor_ProcessServer
 				GatewaySessionID:  getSingleValueHeader(localRequestHeaders.Headers, routing.SessionHeader),
 				ResponseSessionID: getSingleValueHeader(r.ResponseHeaders.Headers, routing.SessionHeader),
 				InitHost:          getSingleValueHeader(localRequestHeaders.Headers, "mcp-init-host"),
+				ContentType:       getSingleValueHeader(r.ResponseHeaders.Headers, "content-type"),
 				Request:           mcpRequest,
 			}
 
@@ -522,10 +684,29 @@ func (s *ExtProcServer) Process(stream extProcV3.ExternalProcessor_ProcessServer
 						s.Logger.ErrorContext(ctx, "failed to check client elicitation", "error", elErr)
 					}
 					mcpRequest.ClientElicitation = clientElicitation
+					declared, capErr := s.SessionCache.GetClientCapabilities(ctx, mcpRequest.GetSessionID())
+					if capErr != nil {
+						s.Logger.ErrorContext(ctx, "failed to check client capabilities", "error", capErr)
+					}
+					mcpRequest.ClientSampling = slices.Contains(declared, routing.CapabilitySampling)
+					mcpRequest.ClientRoots = slices.Contains(declared, routing.CapabilityRoots)
 				}
 			}
 
 			respDecision := respHandler.HandleResponse(ctx, respInput)
+			// only successful results are cached, and the body must be seen to
+			// capture one
+			if capture != nil && statusCode != "200" {
+				capture = nil
+			}
+			rewriteBody := respDecision.StreamBody
+			// a cacheable result is captured from the body, a canary call
+			// is judged by its final response, and a concurrency slot is
+			// held until the body has ended, including SSE that is
+			// otherwise passed through unseen
+			if capture != nil || releaseSlot != nil || canary != nil {
+				respDecision.StreamBody = true
+			}
 
 			if mcpRequest != nil && mcpRequest.IsToolCall() {
 				authSub, _ := internaljwt.ExtractSubClaim(mcpRequest.Headers[routing.AuthorizationHeader])
@@ -542,12 +723,14 @@ func (s *ExtProcServer) Process(stream extProcV3.ExternalProcessor_ProcessServer
 
 			responses := responseDecisionToResponse(respDecision)
 
-			if respDecision.StreamBody {
+			if rewriteBody {
 				rewriter = &elicitationRewriter{
-					idMap:      s.ElicitationMap,
-					req:        mcpRequest,
-					logger:     s.Logger,
-					gatewayIDs: make([]string, 0),
+					idMap:         s.ElicitationMap,
+					req:           mcpRequest,
+					logger:        s.Logger,
+					gatewayIDs:    make([]string, 0),
+					allowSampling: s.samplingAllowed(mcpRequest.ServerName),
+					reject:        s.RejectServerRequest,
 				}
 				// also construct resourceURIRewriter for tool calls with resources on 200 responses
 				if mcpRequest.ServerPrefix != "" && statusCode == "200" {
@@ -566,7 +749,8 @@ func (s *ExtProcServer) Process(stream extProcV3.ExternalProcessor_ProcessServer
 					return err
 				}
 			}
-			if rewriter != nil {
+			if rewriter != nil || capture != nil || releaseSlot != nil || canary != nil {
+				streamed = true
 				continue // tool call: response body is streamed
 			}
 			return nil // non-tool-call: response body is not streamed
@@ -574,6 +758,13 @@ func (s *ExtProcServer) Process(stream extProcV3.ExternalProcessor_ProcessServer
 			body := r.ResponseBody.GetBody()
 			endOfStream := r.ResponseBody.GetEndOfStream()
 
+			if canary != nil {
+				canary.observe(body)
+				if endOfStream {
+					canary.record(ctx, true)
+				}
+			}
+
 			if rewriter != nil {
 				body = rewriter.Process(ctx, body)
 
@@ -594,6 +785,16 @@ func (s *ExtProcServer) Process(stream extProcV3.ExternalProcessor_ProcessServer
 
 			}
 
+			if capture != nil {
+				capture.write(body)
+				if endOfStream {
+					if err := capture.store(ctx); err != nil {
+						s.Logger.WarnContext(ctx, "failed to cache tool result", "server", capture.server, "error", err)
+					}
+					capture = nil
+				}
+			}
+
 			response := &extProcV3.ProcessingResponse{
 				Response: &extProcV3.ProcessingResponse_ResponseBody{
 					ResponseBody: &extProcV3.BodyResponse{
@@ -621,3 +822,66 @@ func (s *ExtProcServer) Process(stream extProcV3.ExternalProcessor_ProcessServer
 		}
 	}
 }
+
+// samplingAllowed reports whether the named server may send
+// sampling/createMessage to clients
+func (s *ExtProcServer) samplingAllowed(serverName string) bool {
+	cfg := s.RoutingConfig.Load()
+	if cfg == nil {
+		return false
+	}
+	server, err := cfg.GetServerConfigByName(serverName)
+	return err == nil && server.Sampling
+}
+
+// brokerServerName is the x-mcp-servername value of calls served by the broker itself
+const brokerServerName = "mcpBroker"
+
+// routedToolCallServer returns the config of the upstream server a tool call
+// was routed to, or nil for other requests, rejected calls and broker tools
+func (s *ExtProcServer) routedToolCallServer(req *routing.MCPRequest, decision *routing.Decision) *config.MCPServer {
+	if decision.Error != nil || req == nil || !req.IsToolCall() {
+		return nil
+	}
+	server := decision.SetHeaders[routing.MCPServerNameHeader]
+	if server == "" || server == brokerServerName {
+		return nil
+	}
+	routingConfig := s.RoutingConfig.Load()
+	if routingConfig == nil {
+		return nil
+	}
+	cfg, err := routingConfig.GetServerConfigByName(server)
+	if err != nil {
+		return nil
+	}
+	return cfg
+}
+
+// toolErrorDecision answers a tool call with an isError result carrying msg,
+// as SSE for 2025-11-25 sessions and as plain JSON for 2026-07-28
+func toolErrorDecision(req *routing.MCPRequest, msg string, json2026 bool) *routing.Decision {
+	if json2026 {
+		return &routing.Decision{Error: &routing.Error{
+			StatusCode:  200,
+			JSONRPCErr:  routing.BuildJSONToolError(req.ID, msg),
+			ContentType: "application/json",
+		}}
+	}
+	return &routing.Decision{
+		Error:      &routing.Error{StatusCode: 200, JSONRPCErr: routing.BuildSSEToolError(req.ID, msg)},
+		SetHeaders: map[string]string{routing.SessionHeader: req.GetSessionID()},
+	}
+}
+
+// endInFlight forgets a streamed tool call once its response has ended, so a
+// late cancellation is no longer routed to the backend
+func (s *ExtProcServer) endInFlight(ctx context.Context, req *routing.MCPRequest) {
+	if req == nil || !req.IsToolCall() || req.ID == nil {
+		return
+	}
+	// the stream context may already be done
+	if err := s.SessionCache.DeleteInFlight(context.WithoutCancel(ctx), req.GetSessionID(), routing.RequestIDKey(req.ID)); err != nil {
+		s.Logger.DebugContext(ctx, "failed to clear in-flight tool call", "error", err)
+	}
+}
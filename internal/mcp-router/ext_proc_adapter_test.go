@@ -12,6 +12,8 @@ import (
 	"sync/atomic"
 	"testing"
 
+	"github.com/Kuadrant/mcp-gateway/internal/authn"
+	"github.com/Kuadrant/mcp-gateway/internal/concurrency"
 	"github.com/Kuadrant/mcp-gateway/internal/config"
 	"github.com/Kuadrant/mcp-gateway/internal/routing"
 	"github.com/Kuadrant/mcp-gateway/internal/session"
@@ -20,6 +22,10 @@ import (
 	typev3 "github.com/envoyproxy/go-control-plane/envoy/type/v3"
 	"github.com/stretchr/testify/require"
 	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
 	sdktrace "go.opentelemetry.io/otel/sdk/trace"
 	"go.opentelemetry.io/otel/sdk/trace/tracetest"
 	"google.golang.org/grpc/metadata"
@@ -374,7 +380,33 @@ func TestProcess_BufferedBodyExceedsMaxSize(t *testing.T) {
 	mock.verifyAllResponsesConsumed()
 }
 
-func newTestServer(t *testing.T) *ExtProcServer {
+// testServerOption configures the server built by newTestServer
+type testServerOption func(*ExtProcServer)
+
+// withServers replaces the servers the test server routes to
+func withServers(servers ...*config.MCPServer) testServerOption {
+	return func(s *ExtProcServer) {
+		s.RoutingConfig.Store(&config.MCPServersConfig{Servers: servers})
+	}
+}
+
+func withConcurrencyLimiter(limiter concurrency.Limiter) testServerOption {
+	return func(s *ExtProcServer) { s.ConcurrencyLimiter = limiter }
+}
+
+func withRetryBudget(budget *RetryBudget) testServerOption {
+	return func(s *ExtProcServer) { s.RetryBudget = budget }
+}
+
+func withResultCache(cache *ResultCache) testServerOption {
+	return func(s *ExtProcServer) { s.ResultCache = cache }
+}
+
+func withAuthenticator(authenticator *authn.Authenticator) testServerOption {
+	return func(s *ExtProcServer) { s.Authenticator = authenticator }
+}
+
+func newTestServer(t *testing.T, opts ...testServerOption) *ExtProcServer {
 	t.Helper()
 	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
 	cache, err := session.NewCache()
@@ -415,9 +447,45 @@ func newTestServer(t *testing.T) *ExtProcServer {
 		ElicitationEnabled: false,
 		Logger:             logger,
 	}
+	for _, opt := range opts {
+		opt(server)
+	}
 	return server
 }
 
+// newTestCounter returns a counter whose data points are read from reader
+func newTestCounter(t *testing.T, name string) (metric.Int64Counter, *sdkmetric.ManualReader) {
+	t.Helper()
+	reader := sdkmetric.NewManualReader()
+	counter, err := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)).Meter("mcp-router").Int64Counter(name)
+	require.NoError(t, err)
+	return counter, reader
+}
+
+// counterValues returns the recorded counts keyed by the values of
+// attributes, joined with /
+func counterValues(t *testing.T, reader *sdkmetric.ManualReader, attributes ...string) map[string]int64 {
+	t.Helper()
+	var rm metricdata.ResourceMetrics
+	require.NoError(t, reader.Collect(context.Background(), &rm))
+	counts := map[string]int64{}
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			data, ok := m.Data.(metricdata.Sum[int64])
+			require.True(t, ok)
+			for _, dp := range data.DataPoints {
+				values := make([]string, 0, len(attributes))
+				for _, attr := range attributes {
+					value, _ := dp.Attributes.Value(attribute.Key(attr))
+					values = append(values, value.AsString())
+				}
+				counts[strings.Join(values, "/")] = dp.Value
+			}
+		}
+	}
+	return counts
+}
+
 // requestHeadersStep returns a standard request headers step
 func requestHeadersStep() mockProcessServerMessageAndErr {
 	return mockProcessServerMessageAndErr{
@@ -697,9 +765,14 @@ func (s *stubRouter) RouteRequest(_ context.Context, _ *routing.Request) *routin
 }
 
 // stubErrorRouter is a Router that always returns an error decision with the given status code.
-type stubErrorRouter struct{ statusCode int }
+type stubErrorRouter struct {
+	statusCode int
+	// parsed is the last request routed
+	parsed *routing.MCPRequest
+}
 
-func (s *stubErrorRouter) RouteRequest(_ context.Context, _ *routing.Request) *routing.Decision {
+func (s *stubErrorRouter) RouteRequest(_ context.Context, req *routing.Request) *routing.Decision {
+	s.parsed = req.Parsed
 	return &routing.Decision{Error: &routing.Error{StatusCode: s.statusCode, Message: "routing error"}}
 }
 
@@ -974,6 +1047,94 @@ func TestProcess_ToolCallAuditLog_RouterError(t *testing.T) {
 	require.Empty(t, found.attrs["session"])
 }
 
+// a client-supplied x-mcp-verified-sub must never reach routing: the router
+// re-injects the header for the broker, which binds sessions to it
+func TestProcess_VerifiedSubFromBearerToken(t *testing.T) {
+	cache, err := session.NewCache()
+	require.NoError(t, err)
+	router := &stubErrorRouter{statusCode: 500}
+	srv := &ExtProcServer{
+		Logger:          slog.Default(),
+		SessionCache:    cache,
+		Router:          router,
+		ResponseHandler: &stubResponseHandler{},
+	}
+	srv.RoutingConfig.Store(&config.MCPServersConfig{})
+
+	for name, tc := range map[string]struct {
+		auth string
+		want string
+	}{
+		"spoofed header replaced": {auth: makeTestBearer("alice@example.com"), want: "alice@example.com"},
+		"spoofed header removed":  {auth: "", want: ""},
+	} {
+		t.Run(name, func(t *testing.T) {
+			headers := []*corev3.HeaderValue{
+				{Key: "content-type", RawValue: []byte("application/json")},
+				{Key: "x-mcp-verified-sub", RawValue: []byte("mallory")},
+			}
+			wantSet := []*corev3.HeaderValueOption{{Header: &corev3.HeaderValue{Key: ":authority"}}}
+			if tc.auth != "" {
+				headers = append(headers, &corev3.HeaderValue{Key: "authorization", RawValue: []byte(tc.auth)})
+				wantSet = append(wantSet, &corev3.HeaderValueOption{Header: &corev3.HeaderValue{Key: "x-mcp-verified-sub", RawValue: []byte(tc.want)}})
+			}
+			mock := makeMockProcessServer(t, []mockProcessServerMessageAndErr{
+				{
+					msg: &extProcV3.ProcessingRequest{
+						Request: &extProcV3.ProcessingRequest_RequestHeaders{
+							RequestHeaders: &extProcV3.HttpHeaders{Headers: &corev3.HeaderMap{Headers: headers}},
+						},
+					},
+					resp: []*extProcV3.ProcessingResponse{
+						{
+							Response: &extProcV3.ProcessingResponse_RequestHeaders{
+								RequestHeaders: &extProcV3.HeadersResponse{
+									Response: &extProcV3.CommonResponse{
+										HeaderMutation: &extProcV3.HeaderMutation{
+											SetHeaders:    wantSet,
+											RemoveHeaders: []string{"x-mcp-authorized", "x-mcp-virtualserver", "x-mcp-verified-sub"},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+				{
+					msg: &extProcV3.ProcessingRequest{
+						Request: &extProcV3.ProcessingRequest_RequestBody{
+							RequestBody: &extProcV3.HttpBody{
+								Body:        []byte(`{"jsonrpc":"2.0","id":1,"method":"tools/list"}`),
+								EndOfStream: true,
+							},
+						},
+					},
+					resp: []*extProcV3.ProcessingResponse{
+						{
+							Response: &extProcV3.ProcessingResponse_ImmediateResponse{
+								ImmediateResponse: &extProcV3.ImmediateResponse{
+									Body:   []byte("dummy"),
+									Status: &typev3.HttpStatus{Code: typev3.StatusCode_InternalServerError},
+									Headers: &extProcV3.HeaderMutation{
+										SetHeaders: []*corev3.HeaderValueOption{
+											{Header: &corev3.HeaderValue{Key: "content-type", RawValue: []byte("text/plain")}},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			})
+			mock.serverStream = append(mock.serverStream, mockProcessServerMessageAndErr{msgErr: fmt.Errorf("EOF")})
+
+			require.ErrorContains(t, srv.Process(mock), "EOF")
+			require.NotNil(t, router.parsed)
+			require.Equal(t, tc.want, router.parsed.GetSingleHeaderValue(routing.MCPVerifiedSubHeader))
+		})
+	}
+}
+
 // TestExtProcServer_OnConfigChange_DataRace exercises a config-reload landing
 // concurrently with a request-handler read of RoutingConfig. The race detector
 // is the assertion; run with go test -race ./internal/mcp-router/...
@@ -0,0 +1,24 @@
+package mcprouter
+
+import (
+	"context"
+	"time"
+
+	"github.com/Kuadrant/mcp-gateway/internal/routing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// maintenanceRejection returns a tool error decision for a tool call routed
+// to a server whose maintenance window is open. Only new calls are rejected:
+// calls routed before the window opened finish normally.
+func (s *ExtProcServer) maintenanceRejection(ctx context.Context, span trace.Span, req *routing.MCPRequest, decision *routing.Decision, json2026 bool) *routing.Decision {
+	cfg := s.routedToolCallServer(req, decision)
+	if cfg == nil || !cfg.InMaintenance(time.Now()) {
+		return nil
+	}
+	s.Logger.InfoContext(ctx, "tool call rejected during maintenance", "server", cfg.Name, "tool", req.ToolName())
+	span.SetAttributes(attribute.Bool("mcp.server.maintenance", true))
+	s.endInFlight(ctx, req)
+	return toolErrorDecision(req, "MCP error: "+cfg.MaintenanceMessage(), json2026)
+}
@@ -0,0 +1,48 @@
+package mcprouter
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Kuadrant/mcp-gateway/internal/config"
+	"github.com/Kuadrant/mcp-gateway/internal/routing"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestMaintenanceRejection(t *testing.T) {
+	past := time.Now().Add(-time.Hour)
+	future := time.Now().Add(time.Hour)
+	srv := newTestServer(t, withServers(
+		&config.MCPServer{Name: "mcp-test/down", State: config.StateMaintenance, Maintenance: &config.MaintenanceConfig{Message: "upgrading, back at noon"}},
+		&config.MCPServer{Name: "mcp-test/default", State: config.StateMaintenance},
+		&config.MCPServer{Name: "mcp-test/scheduled", State: config.StateMaintenance, Maintenance: &config.MaintenanceConfig{Start: &future}},
+		&config.MCPServer{Name: "mcp-test/ended", State: config.StateMaintenance, Maintenance: &config.MaintenanceConfig{End: &past}},
+		&config.MCPServer{Name: "mcp-test/up", State: "Enabled"},
+	))
+	ctx := context.Background()
+	span := trace.SpanFromContext(ctx)
+
+	rejected := srv.maintenanceRejection(ctx, span, testToolCall(), toolCallDecision("mcp-test/down"), false)
+	require.NotNil(t, rejected)
+	require.Equal(t, 200, rejected.Error.StatusCode)
+	require.Contains(t, rejected.Error.JSONRPCErr, "event: message")
+	require.Contains(t, rejected.Error.JSONRPCErr, "MCP error: upgrading, back at noon")
+	require.Contains(t, rejected.Error.JSONRPCErr, `"isError":true`)
+
+	rejected = srv.maintenanceRejection(ctx, span, testToolCall(), toolCallDecision("mcp-test/default"), true)
+	require.NotNil(t, rejected)
+	require.Equal(t, "application/json", rejected.Error.ContentType)
+	require.True(t, strings.HasPrefix(rejected.Error.JSONRPCErr, `{"jsonrpc":"2.0","id":7`))
+	require.Contains(t, rejected.Error.JSONRPCErr, "server mcp-test/default is under maintenance")
+
+	for _, server := range []string{"mcp-test/scheduled", "mcp-test/ended", "mcp-test/up", "mcp-test/unknown", brokerServerName} {
+		require.Nil(t, srv.maintenanceRejection(ctx, span, testToolCall(), toolCallDecision(server), false), server)
+	}
+
+	// only tool calls are rejected
+	promptGet := &routing.MCPRequest{JSONRPC: "2.0", ID: 1, Method: "prompts/get", Params: map[string]any{"name": "down_p"}}
+	require.Nil(t, srv.maintenanceRejection(ctx, span, promptGet, toolCallDecision("mcp-test/down"), false))
+}
@@ -0,0 +1,354 @@
+package mcprouter
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/Kuadrant/mcp-gateway/internal/config"
+	internaljwt "github.com/Kuadrant/mcp-gateway/internal/jwt"
+	"github.com/Kuadrant/mcp-gateway/internal/resultcache"
+	"github.com/Kuadrant/mcp-gateway/internal/routing"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// resultCacheMetaKey is the _meta key of a tool call that asks the gateway
+// to bypass the result cache, with the values of the Cache-Control request
+// directives of the same name
+const (
+	resultCacheMetaKey = "mcp.kuadrant.io/cache-control"
+	// resultCacheNoCache skips the cached result but stores the fresh one
+	resultCacheNoCache = "no-cache"
+	// resultCacheNoStore neither reads nor stores a result
+	resultCacheNoStore = "no-store"
+)
+
+// ResultCacheHeader is set to "hit" on tool call responses served from the
+// result cache
+const ResultCacheHeader = "x-mcp-result-cache"
+
+// resultCaptureOverhead is how far past a server's maxResultBytes a
+// response body is buffered, to leave room for its JSON-RPC and SSE framing
+const resultCaptureOverhead = 4096
+
+// ResultCache serves repeated calls to read-only tools of servers with a
+// result cache from earlier results
+type ResultCache struct {
+	store   resultcache.Store
+	lookups metric.Int64Counter
+}
+
+// NewResultCache creates a result cache backed by store, and its instruments
+func NewResultCache(store resultcache.Store) (*ResultCache, error) {
+	meter := otel.GetMeterProvider().Meter("mcp-router")
+	lookups, err := meter.Int64Counter("mcp_router_tool_result_cache_lookups",
+		metric.WithDescription("tool calls to cached tools, per result: hit, miss or bypass"),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create mcp_router_tool_result_cache_lookups: %w", err)
+	}
+	return &ResultCache{store: store, lookups: lookups}, nil
+}
+
+func (c *ResultCache) count(ctx context.Context, server, result string) {
+	c.lookups.Add(ctx, 1, metric.WithAttributes(
+		attribute.String("server_name", server),
+		attribute.String("result", result),
+	))
+}
+
+// lookupToolResult answers a call to a cached tool from the result cache.
+// It returns the decision serving the cached result on a hit, and otherwise
+// the capture that stores the upstream result, or nil when the call must
+// not be cached. Cache failures let the call through to the server.
+func (s *ExtProcServer) lookupToolResult(ctx context.Context, span trace.Span, req *routing.MCPRequest, decision *routing.Decision, body []byte, json2026 bool) (*routing.Decision, *resultCapture) {
+	if s.ResultCache == nil {
+		return nil, nil
+	}
+	cfg := s.routedToolCallServer(req, decision)
+	if cfg == nil || cfg.ResultCache == nil {
+		return nil, nil
+	}
+	tool := decision.SetHeaders[routing.ToolHeader]
+	if !cfg.ResultCache.CachesTool(tool) || !readOnlyTool(decision.SetHeaders[routing.ToolAnnotationsHeader]) {
+		return nil, nil
+	}
+	// without an Authenticator the subject is a claim decoded from an
+	// unverified bearer token, so it cannot key a per-subject result
+	if !cfg.ResultCache.Shared() && s.Authenticator == nil {
+		return nil, nil
+	}
+	directive := cacheControl(req)
+	if directive == resultCacheNoStore {
+		s.ResultCache.count(ctx, cfg.Name, "bypass")
+		return nil, nil
+	}
+	key, ok := resultCacheKey(req, decision, cfg, body)
+	if !ok {
+		return nil, nil
+	}
+	capture := &resultCapture{
+		cache:  s.ResultCache,
+		server: cfg.Name,
+		key:    key,
+		cfg:    cfg.ResultCache,
+	}
+	if directive == resultCacheNoCache {
+		s.ResultCache.count(ctx, cfg.Name, "bypass")
+		return nil, capture
+	}
+
+	// a hit is answered here, before the AuthPolicy of the tool runs
+	if !s.toolCallAuthorized(ctx, req, config.RegistrationName(cfg.Name), tool) {
+		s.ResultCache.count(ctx, cfg.Name, "miss")
+		return nil, capture
+	}
+	result, hit, err := s.ResultCache.store.Get(ctx, key)
+	if err != nil {
+		s.Logger.WarnContext(ctx, "tool result cache lookup failed, calling server", "server", cfg.Name, "error", err)
+	}
+	if !hit {
+		s.ResultCache.count(ctx, cfg.Name, "miss")
+		return nil, capture
+	}
+	s.ResultCache.count(ctx, cfg.Name, "hit")
+	s.Logger.DebugContext(ctx, "tool call served from result cache", "server", cfg.Name, "tool", tool)
+	span.SetAttributes(attribute.Bool("mcp.result_cache.hit", true))
+	s.endInFlight(ctx, req)
+	return cachedResultDecision(req, result, json2026), nil
+}
+
+// toolCallAuthorized reports whether the tool call carries an x-mcp-authorized
+// header, signed with TrustedHeadersPublicKey, that allows tool of server
+func (s *ExtProcServer) toolCallAuthorized(ctx context.Context, req *routing.MCPRequest, server, tool string) bool {
+	header := req.GetSingleHeaderValue(routing.MCPAuthorizedHeader)
+	if header == "" || s.TrustedHeadersPublicKey == "" {
+		return false
+	}
+	capabilities, err := internaljwt.ParseAuthorizedCapabilities(header, s.TrustedHeadersPublicKey)
+	if err != nil {
+		s.Logger.DebugContext(ctx, "ignoring x-mcp-authorized header for the result cache", "error", err)
+		return false
+	}
+	return internaljwt.ToolAllowed(capabilities, server, tool)
+}
+
+// readOnlyTool reports whether the annotation hints header of a tool call
+// marks the tool read-only
+func readOnlyTool(hints string) bool {
+	for hint := range strings.SplitSeq(hints, ",") {
+		if hint == "readOnly=true" {
+			return true
+		}
+	}
+	return false
+}
+
+// cacheControl returns the result cache directive in the _meta of a tool call
+func cacheControl(req *routing.MCPRequest) string {
+	meta, _ := req.Params["_meta"].(map[string]any)
+	directive, _ := meta[resultCacheMetaKey].(string)
+	return directive
+}
+
+// resultCacheKey hashes what a tool result depends on: the server and
+// variant it came from, the tool, its arguments in canonical form and, unless
+// the cache is shared, the caller's subject. It fails for a per-subject call
+// without a subject; callers must only pass subjects the Authenticator
+// verified.
+func resultCacheKey(req *routing.MCPRequest, decision *routing.Decision, cfg *config.MCPServer, body []byte) (string, bool) {
+	subject := ""
+	if !cfg.ResultCache.Shared() {
+		subject = req.Headers[routing.MCPVerifiedSubHeader]
+		if subject == "" {
+			return "", false
+		}
+	}
+	arguments, ok := canonicalArguments(body)
+	if !ok {
+		return "", false
+	}
+	h := sha256.New()
+	for _, part := range []string{
+		cfg.Name,
+		decision.SetHeaders[routing.MCPServerVariantHeader],
+		decision.SetHeaders[routing.ToolHeader],
+		subject,
+	} {
+		h.Write([]byte(part))
+		h.Write([]byte{0})
+	}
+	h.Write(arguments)
+	return hex.EncodeToString(h.Sum(nil)), true
+}
+
+// canonicalArguments re-encodes the arguments of a tools/call request body
+// with sorted keys and no insignificant whitespace, keeping numbers as sent.
+// Missing arguments are the same as empty ones.
+func canonicalArguments(body []byte) ([]byte, bool) {
+	var call struct {
+		Params struct {
+			Arguments any `json:"arguments"`
+		} `json:"params"`
+	}
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	if err := dec.Decode(&call); err != nil {
+		return nil, false
+	}
+	if call.Params.Arguments == nil {
+		return []byte("{}"), true
+	}
+	arguments, err := json.Marshal(call.Params.Arguments)
+	if err != nil {
+		return nil, false
+	}
+	return arguments, true
+}
+
+// cachedResultDecision answers a tool call with a cached result, as SSE for
+// 2025-11-25 sessions and as plain JSON for 2026-07-28. The result bytes are
+// sent as they were stored.
+func cachedResultDecision(req *routing.MCPRequest, result []byte, json2026 bool) *routing.Decision {
+	if json2026 {
+		id, err := json.Marshal(req.ID)
+		if err != nil {
+			id = []byte("null")
+		}
+		return &routing.Decision{
+			Error: &routing.Error{
+				StatusCode:  200,
+				JSONRPCErr:  `{"jsonrpc":"2.0","id":` + string(id) + `,"result":` + string(result) + `}`,
+				ContentType: "application/json",
+			},
+			SetHeaders: map[string]string{ResultCacheHeader: "hit"},
+		}
+	}
+	return &routing.Decision{
+		Error: &routing.Error{StatusCode: 200, JSONRPCErr: routing.SseJSONRPC(req.ID, func(b *strings.Builder) {
+			b.WriteString(`,"result":`)
+			b.Write(result)
+			b.WriteString("}")
+		})},
+		SetHeaders: map[string]string{
+			routing.SessionHeader: req.GetSessionID(),
+			ResultCacheHeader:     "hit",
+		},
+	}
+}
+
+// resultCapture buffers the response of a cacheable tool call and stores
+// its result once the response has ended
+type resultCapture struct {
+	cache  *ResultCache
+	server string
+	key    string
+	cfg    *config.ResultCacheConfig
+	body   []byte
+	// tooLarge is set once the body outgrew what could hold a cacheable result
+	tooLarge bool
+}
+
+// write appends a response body chunk as sent to the client
+func (c *resultCapture) write(chunk []byte) {
+	if c.tooLarge {
+		return
+	}
+	if len(c.body)+len(chunk) > c.cfg.MaxResultBytes+resultCaptureOverhead {
+		c.tooLarge = true
+		c.body = nil
+		return
+	}
+	c.body = append(c.body, chunk...)
+}
+
+// store caches the result of the captured response. Responses that carry a
+// server request, a JSON-RPC error, an isError result or a result larger
+// than the server allows are not cached.
+func (c *resultCapture) store(ctx context.Context) error {
+	if c.tooLarge {
+		return nil
+	}
+	result, ok := capturedResult(c.body)
+	if !ok || len(result) > c.cfg.MaxResultBytes {
+		return nil
+	}
+	var outcome struct {
+		IsError bool `json:"isError"`
+	}
+	if err := json.Unmarshal(result, &outcome); err != nil || outcome.IsError {
+		return nil
+	}
+	return c.cache.store.Set(context.WithoutCancel(ctx), c.key, result, c.cfg.TTL())
+}
+
+// capturedResult returns the raw result of the only response in a plain
+// JSON or SSE response body
+func capturedResult(body []byte) (json.RawMessage, bool) {
+	var messages [][]byte
+	if trimmed := bytes.TrimSpace(body); bytes.HasPrefix(trimmed, []byte("{")) {
+		messages = [][]byte{trimmed}
+	} else {
+		messages = sseMessages(body)
+	}
+	var result json.RawMessage
+	for _, raw := range messages {
+		var msg struct {
+			ID     json.RawMessage `json:"id"`
+			Method string          `json:"method"`
+			Result json.RawMessage `json:"result"`
+		}
+		if err := json.Unmarshal(raw, &msg); err != nil {
+			return nil, false
+		}
+		switch {
+		case msg.Method != "" && msg.ID != nil:
+			// the server asked the client something, so the result may
+			// depend on the answer
+			return nil, false
+		case msg.Result != nil:
+			if result != nil {
+				return nil, false
+			}
+			result = msg.Result
+		}
+	}
+	return result, result != nil
+}
+
+// sseMessages returns the data of each event in an SSE body
+func sseMessages(body []byte) [][]byte {
+	var messages [][]byte
+	var data []byte
+	flush := func() {
+		if data != nil {
+			messages = append(messages, data)
+			data = nil
+		}
+	}
+	for line := range bytes.SplitSeq(body, []byte("\n")) {
+		line = bytes.TrimSuffix(line, []byte("\r"))
+		if len(line) == 0 {
+			flush()
+			continue
+		}
+		value, ok := bytes.CutPrefix(line, []byte("data:"))
+		if !ok {
+			continue
+		}
+		value = bytes.TrimPrefix(value, []byte(" "))
+		if data != nil {
+			data = append(data, '\n')
+		}
+		data = append(data, value...)
+	}
+	flush()
+	return messages
+}
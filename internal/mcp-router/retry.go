@@ -0,0 +1,180 @@
+package mcprouter
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Kuadrant/mcp-gateway/internal/routing"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Envoy request headers that set the timeout and retry policy of a single
+// request. The router filter reads them after ext_proc has set them.
+const (
+	upstreamTimeoutHeader = "x-envoy-upstream-rq-timeout-ms"
+	perTryTimeoutHeader   = "x-envoy-upstream-rq-per-try-timeout-ms"
+	maxRetriesHeader      = "x-envoy-max-retries"
+	retryOnHeader         = "x-envoy-retry-on"
+	// retryOn retries 5xx responses, resets, connect failures and per try
+	// timeouts
+	retryOn = "5xx"
+)
+
+const (
+	// retryBudgetWindow is how far back the budget looks at tool call outcomes
+	retryBudgetWindow = 10 * time.Second
+	// retryBudgetMinCalls is the number of calls in the window below which
+	// retries are always allowed, so a few failures on a quiet server do not
+	// switch them off
+	retryBudgetMinCalls = 10
+)
+
+// RetryBudget tracks the recent tool call outcomes of each server with a
+// retry policy, and withholds retries while too many of them failed. The
+// outcomes are those seen by this replica only.
+type RetryBudget struct {
+	mu        sync.Mutex
+	servers   map[string]*outcomeWindow
+	now       func() time.Time
+	exhausted metric.Int64Counter
+}
+
+// NewRetryBudget creates an empty retry budget and its instruments
+func NewRetryBudget() (*RetryBudget, error) {
+	meter := otel.GetMeterProvider().Meter("mcp-router")
+	exhausted, err := meter.Int64Counter("mcp_router_retry_budget_exhausted",
+		metric.WithDescription("tool calls sent without retries because the server's retry budget was exhausted"),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create mcp_router_retry_budget_exhausted: %w", err)
+	}
+	return &RetryBudget{
+		servers:   map[string]*outcomeWindow{},
+		now:       time.Now,
+		exhausted: exhausted,
+	}, nil
+}
+
+// outcomeWindow counts calls and failures in one second buckets
+type outcomeWindow struct {
+	buckets [int(retryBudgetWindow / time.Second)]outcomeBucket
+}
+
+type outcomeBucket struct {
+	second   int64
+	calls    int
+	failures int
+}
+
+// bucket returns the bucket for second, emptied if it last held an older second
+func (w *outcomeWindow) bucket(second int64) *outcomeBucket {
+	b := &w.buckets[second%int64(len(w.buckets))]
+	if b.second != second {
+		*b = outcomeBucket{second: second}
+	}
+	return b
+}
+
+// totals sums the buckets that are still inside the window
+func (w *outcomeWindow) totals(second int64) (calls, failures int) {
+	for _, b := range w.buckets {
+		if second-b.second < int64(len(w.buckets)) {
+			calls += b.calls
+			failures += b.failures
+		}
+	}
+	return calls, failures
+}
+
+// Allow reports whether a call to server may be retried: true while at most
+// percent of the server's recent calls failed
+func (b *RetryBudget) Allow(server string, percent int) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	w, ok := b.servers[server]
+	if !ok {
+		return true
+	}
+	calls, failures := w.totals(b.now().Unix())
+	return calls < retryBudgetMinCalls || failures*100 <= percent*calls
+}
+
+// Record adds the outcome of a call to server
+func (b *RetryBudget) Record(server string, failed bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	w, ok := b.servers[server]
+	if !ok {
+		w = &outcomeWindow{}
+		b.servers[server] = w
+	}
+	bucket := w.bucket(b.now().Unix())
+	bucket.calls++
+	if failed {
+		bucket.failures++
+	}
+}
+
+// applyToolCallPolicy sets the Envoy timeout and retry headers for a tool
+// call routed to a server with a tool call policy. Retries are only enabled
+// for tools annotated as read-only or idempotent, and only while the
+// server's retry budget allows them. It returns the server whose budget the
+// call's outcome must be recorded against, or "" when there is none.
+func (s *ExtProcServer) applyToolCallPolicy(ctx context.Context, span trace.Span, req *routing.MCPRequest, decision *routing.Decision) string {
+	cfg := s.routedToolCallServer(req, decision)
+	if cfg == nil || cfg.ToolCalls == nil {
+		return ""
+	}
+	policy := cfg.ToolCalls
+	if policy.TimeoutMilliseconds > 0 {
+		decision.SetHeaders[upstreamTimeoutHeader] = strconv.Itoa(policy.TimeoutMilliseconds)
+		span.SetAttributes(attribute.Int("mcp.tool_call.timeout_ms", policy.TimeoutMilliseconds))
+	}
+	if policy.Retry == nil || policy.Retry.MaxRetries < 1 || s.RetryBudget == nil {
+		return ""
+	}
+	if !retryableTool(decision.SetHeaders[routing.ToolAnnotationsHeader]) {
+		return cfg.Name
+	}
+	if !s.RetryBudget.Allow(cfg.Name, policy.Retry.BudgetPercent) {
+		s.Logger.DebugContext(ctx, "retry budget exhausted, tool call sent without retries", "server", cfg.Name, "tool", req.ToolName())
+		span.SetAttributes(attribute.Bool("mcp.retry.budget_exhausted", true))
+		s.RetryBudget.exhausted.Add(ctx, 1, metric.WithAttributes(
+			attribute.String("server_name", cfg.Name),
+		))
+		return cfg.Name
+	}
+	decision.SetHeaders[retryOnHeader] = retryOn
+	decision.SetHeaders[maxRetriesHeader] = strconv.Itoa(policy.Retry.MaxRetries)
+	if policy.Retry.PerTryTimeoutMilliseconds > 0 {
+		decision.SetHeaders[perTryTimeoutHeader] = strconv.Itoa(policy.Retry.PerTryTimeoutMilliseconds)
+	}
+	span.SetAttributes(attribute.Int("mcp.retry.max", policy.Retry.MaxRetries))
+	return cfg.Name
+}
+
+// retryableTool reports whether the annotation hints header of a tool call
+// marks the tool read-only or idempotent. Tools without annotations are
+// never retried.
+func retryableTool(hints string) bool {
+	for hint := range strings.SplitSeq(hints, ",") {
+		if hint == "readOnly=true" || hint == "idempotent=true" {
+			return true
+		}
+	}
+	return false
+}
+
+// toolCallFailed reports whether a tool call response status counts against
+// the retry budget
+func toolCallFailed(statusCode string) bool {
+	code, err := strconv.Atoi(statusCode)
+	return err != nil || code >= 500
+}
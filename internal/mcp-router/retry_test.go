@@ -0,0 +1,216 @@
+package mcprouter
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Kuadrant/mcp-gateway/internal/config"
+	"github.com/Kuadrant/mcp-gateway/internal/routing"
+	"github.com/stretchr/testify/require"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func newTestRetryBudget(t *testing.T, now func() time.Time) (*RetryBudget, *sdkmetric.ManualReader) {
+	t.Helper()
+	exhausted, reader := newTestCounter(t, "mcp_router_retry_budget_exhausted")
+	return &RetryBudget{servers: map[string]*outcomeWindow{}, now: now, exhausted: exhausted}, reader
+}
+
+func withRetryTestServers() testServerOption {
+	return withServers(
+		&config.MCPServer{Name: "mcp-test/retried", ToolCalls: &config.ToolCallConfig{
+			TimeoutMilliseconds: 5000,
+			Retry:               &config.RetryConfig{MaxRetries: 2, PerTryTimeoutMilliseconds: 2000, BudgetPercent: 20},
+		}},
+		&config.MCPServer{Name: "mcp-test/timeout-only", ToolCalls: &config.ToolCallConfig{TimeoutMilliseconds: 3000}},
+		&config.MCPServer{Name: "mcp-test/no-policy"},
+	)
+}
+
+func annotatedToolCallDecision(server, hints string) *routing.Decision {
+	decision := toolCallDecision(server)
+	if hints != "" {
+		decision.SetHeaders[routing.ToolAnnotationsHeader] = hints
+	}
+	return decision
+}
+
+func TestApplyToolCallPolicy(t *testing.T) {
+	tests := []struct {
+		name        string
+		server      string
+		hints       string
+		wantHeaders map[string]string
+		wantServer  string
+	}{
+		{
+			name:   "read-only tool is retried",
+			server: "mcp-test/retried",
+			hints:  "readOnly=true,destructive=unspecified,idempotent=unspecified,openWorld=unspecified",
+			wantHeaders: map[string]string{
+				upstreamTimeoutHeader: "5000",
+				retryOnHeader:         "5xx",
+				maxRetriesHeader:      "2",
+				perTryTimeoutHeader:   "2000",
+			},
+			wantServer: "mcp-test/retried",
+		},
+		{
+			name:   "idempotent tool is retried",
+			server: "mcp-test/retried",
+			hints:  "readOnly=false,destructive=true,idempotent=true,openWorld=unspecified",
+			wantHeaders: map[string]string{
+				upstreamTimeoutHeader: "5000",
+				retryOnHeader:         "5xx",
+				maxRetriesHeader:      "2",
+				perTryTimeoutHeader:   "2000",
+			},
+			wantServer: "mcp-test/retried",
+		},
+		{
+			name:        "tool with side effects only gets the timeout",
+			server:      "mcp-test/retried",
+			hints:       "readOnly=false,destructive=true,idempotent=false,openWorld=true",
+			wantHeaders: map[string]string{upstreamTimeoutHeader: "5000"},
+			wantServer:  "mcp-test/retried",
+		},
+		{
+			name:        "tool without annotations only gets the timeout",
+			server:      "mcp-test/retried",
+			wantHeaders: map[string]string{upstreamTimeoutHeader: "5000"},
+			wantServer:  "mcp-test/retried",
+		},
+		{
+			name:        "server without a retry policy",
+			server:      "mcp-test/timeout-only",
+			hints:       "readOnly=true,destructive=false,idempotent=true,openWorld=false",
+			wantHeaders: map[string]string{upstreamTimeoutHeader: "3000"},
+		},
+		{
+			name:        "server without a tool call policy",
+			server:      "mcp-test/no-policy",
+			hints:       "readOnly=true,destructive=false,idempotent=true,openWorld=false",
+			wantHeaders: map[string]string{},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			budget, _ := newTestRetryBudget(t, time.Now)
+			srv := newTestServer(t, withRetryTestServers(), withRetryBudget(budget))
+			ctx := context.Background()
+			decision := annotatedToolCallDecision(tc.server, tc.hints)
+
+			got := srv.applyToolCallPolicy(ctx, trace.SpanFromContext(ctx), testToolCall(), decision)
+			require.Equal(t, tc.wantServer, got)
+			for _, header := range []string{upstreamTimeoutHeader, retryOnHeader, maxRetriesHeader, perTryTimeoutHeader} {
+				want, ok := tc.wantHeaders[header]
+				if !ok {
+					require.NotContains(t, decision.SetHeaders, header)
+					continue
+				}
+				require.Equal(t, want, decision.SetHeaders[header], header)
+			}
+		})
+	}
+}
+
+func TestApplyToolCallPolicy_Skipped(t *testing.T) {
+	budget, _ := newTestRetryBudget(t, time.Now)
+	srv := newTestServer(t, withRetryTestServers(), withRetryBudget(budget))
+	ctx := context.Background()
+	span := trace.SpanFromContext(ctx)
+	hints := "readOnly=true,destructive=false,idempotent=true,openWorld=false"
+
+	rejected := annotatedToolCallDecision("mcp-test/retried", hints)
+	rejected.Error = &routing.Error{StatusCode: 200}
+	require.Empty(t, srv.applyToolCallPolicy(ctx, span, testToolCall(), rejected), "rejected call")
+	require.NotContains(t, rejected.SetHeaders, upstreamTimeoutHeader)
+
+	promptGet := &routing.MCPRequest{JSONRPC: "2.0", ID: 1, Method: "prompts/get", Params: map[string]any{"name": "retried_p"}}
+	decision := annotatedToolCallDecision("mcp-test/retried", hints)
+	require.Empty(t, srv.applyToolCallPolicy(ctx, span, promptGet, decision), "not a tool call")
+	require.NotContains(t, decision.SetHeaders, upstreamTimeoutHeader)
+
+	// without a budget the timeout still applies but calls are never retried
+	srv = newTestServer(t, withRetryTestServers())
+	decision = annotatedToolCallDecision("mcp-test/retried", hints)
+	require.Empty(t, srv.applyToolCallPolicy(ctx, span, testToolCall(), decision))
+	require.Equal(t, "5000", decision.SetHeaders[upstreamTimeoutHeader])
+	require.NotContains(t, decision.SetHeaders, retryOnHeader)
+}
+
+func TestApplyToolCallPolicy_BudgetExhausted(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	budget, reader := newTestRetryBudget(t, func() time.Time { return now })
+	srv := newTestServer(t, withRetryTestServers(), withRetryBudget(budget))
+	ctx := context.Background()
+	span := trace.SpanFromContext(ctx)
+	hints := "readOnly=true,destructive=false,idempotent=true,openWorld=false"
+
+	// 3 of 10 recent calls failed, over the 20% budget
+	for i := range 10 {
+		budget.Record("mcp-test/retried", i < 3)
+	}
+	decision := annotatedToolCallDecision("mcp-test/retried", hints)
+	require.Equal(t, "mcp-test/retried", srv.applyToolCallPolicy(ctx, span, testToolCall(), decision))
+	require.Equal(t, "5000", decision.SetHeaders[upstreamTimeoutHeader])
+	require.NotContains(t, decision.SetHeaders, retryOnHeader)
+	require.NotContains(t, decision.SetHeaders, maxRetriesHeader)
+	require.Equal(t, map[string]int64{"mcp-test/retried": 1}, counterValues(t, reader, "server_name"))
+
+	// the failures age out of the window
+	now = now.Add(retryBudgetWindow)
+	decision = annotatedToolCallDecision("mcp-test/retried", hints)
+	srv.applyToolCallPolicy(ctx, span, testToolCall(), decision)
+	require.Equal(t, "5xx", decision.SetHeaders[retryOnHeader])
+}
+
+func TestRetryBudget(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	budget, _ := newTestRetryBudget(t, func() time.Time { return now })
+
+	require.True(t, budget.Allow("mcp-test/a", 20), "no calls recorded")
+
+	for range 5 {
+		budget.Record("mcp-test/a", true)
+	}
+	require.True(t, budget.Allow("mcp-test/a", 20), "too few calls to judge")
+
+	for range 15 {
+		budget.Record("mcp-test/a", false)
+	}
+	require.False(t, budget.Allow("mcp-test/a", 20), "5 of 20 calls failed")
+	require.True(t, budget.Allow("mcp-test/a", 25), "5 of 20 calls is within a 25% budget")
+	require.True(t, budget.Allow("mcp-test/b", 20), "budgets are per server")
+	require.False(t, budget.Allow("mcp-test/a", 0), "a zero budget allows no failures")
+
+	// successes in later seconds dilute the failures
+	now = now.Add(5 * time.Second)
+	for range 5 {
+		budget.Record("mcp-test/a", false)
+	}
+	require.True(t, budget.Allow("mcp-test/a", 20), "5 of 25 calls failed")
+
+	// only the later successes are left in the window
+	now = now.Add(6 * time.Second)
+	budget.Record("mcp-test/a", true)
+	require.True(t, budget.Allow("mcp-test/a", 20), "1 of 6 calls")
+}
+
+func TestRetryableTool(t *testing.T) {
+	require.True(t, retryableTool("readOnly=true,destructive=unspecified,idempotent=unspecified,openWorld=unspecified"))
+	require.True(t, retryableTool("readOnly=false,destructive=true,idempotent=true,openWorld=true"))
+	require.False(t, retryableTool("readOnly=false,destructive=true,idempotent=false,openWorld=true"))
+	require.False(t, retryableTool("readOnly=unspecified,destructive=unspecified,idempotent=unspecified,openWorld=unspecified"))
+	require.False(t, retryableTool(""))
+}
+
+func TestToolCallFailed(t *testing.T) {
+	require.False(t, toolCallFailed("200"))
+	require.False(t, toolCallFailed("404"))
+	require.True(t, toolCallFailed("503"))
+	require.True(t, toolCallFailed("504"))
+	require.True(t, toolCallFailed(""))
+}
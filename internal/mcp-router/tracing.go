@@ -51,11 +51,17 @@ func extractTraceContext(ctx context.Context, headers *corev3.HeaderMap) context
 }
 
 func spanAttributes(mcpReq *routing.MCPRequest) []attribute.KeyValue {
-	attrs := []attribute.KeyValue{
-		componentAttr,
-		attribute.String("mcp.method.name", mcpReq.Method),
-		attribute.String("jsonrpc.protocol.version", mcpReq.JSONRPC),
+	var target string
+	switch mcpReq.Method {
+	case routing.MethodToolCall:
+		target = mcpReq.ToolName()
+	case routing.MethodPromptGet:
+		target = mcpReq.PromptName()
+	case routing.MethodResourceRead:
+		target = mcpReq.ResourceURI()
 	}
+	attrs := append([]attribute.KeyValue{componentAttr}, mcpotel.MCPMethodAttributes(mcpReq.Method, target)...)
+	attrs = append(attrs, attribute.String("jsonrpc.protocol.version", mcpReq.JSONRPC))
 
 	if mcpReq.ID != nil {
 		attrs = append(attrs, attribute.String("jsonrpc.request.id", fmt.Sprint(mcpReq.ID)))
@@ -69,12 +75,10 @@ func spanAttributes(mcpReq *routing.MCPRequest) []attribute.KeyValue {
 		attrs = append(attrs, attribute.String("mcp.server", mcpReq.ServerName))
 	}
 
-	if toolName := mcpReq.ToolName(); toolName != "" {
-		attrs = append(attrs, attribute.String("gen_ai.tool.name", toolName))
+	if v := mcpReq.Headers["mcp-protocol-version"]; v != "" {
+		attrs = append(attrs, attribute.String("mcp.protocol.version", v))
 	}
 
-	attrs = append(attrs, attribute.String("gen_ai.operation.name", mcpReq.Method))
-
 	if addr := mcpReq.Headers["x-forwarded-for"]; addr != "" {
 		attrs = append(attrs, attribute.String("client.address", addr))
 	}
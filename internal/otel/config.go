@@ -1,7 +1,23 @@
 // Package otel provides OpenTelemetry tracing and logging integration
 package otel
 
-import "k8s.io/utils/env"
+import (
+	"slices"
+	"strings"
+	"time"
+
+	"k8s.io/utils/env"
+)
+
+// Metrics exporters selectable with OTEL_METRICS_EXPORTER
+const (
+	MetricsExporterPrometheus = "prometheus"
+	MetricsExporterOTLP       = "otlp"
+	MetricsExporterNone       = "none"
+)
+
+// defaultMetricsExportInterval matches the SDK's periodic reader default
+const defaultMetricsExportInterval = 60 * time.Second
 
 // Config holds configuration for OpenTelemetry
 type Config struct {
@@ -11,6 +27,16 @@ type Config struct {
 	ServiceVersion string
 	GitSHA         string
 	GitDirty       string
+
+	// MetricsExporters are the enabled metrics exporters. Prometheus serves
+	// /metrics; OTLP pushes to MetricsEndpoint.
+	MetricsExporters []string
+	// MetricsInsecure disables TLS for gRPC metrics export
+	MetricsInsecure bool
+	// MetricsTemporality is cumulative, delta or lowmemory
+	MetricsTemporality string
+	// MetricsExportInterval is the OTLP push interval
+	MetricsExportInterval time.Duration
 }
 
 // NewConfig creates OTel configuration from environment variables
@@ -21,16 +47,45 @@ func NewConfig(gitSHA, dirty, version string) *Config {
 	serviceName := env.GetString("OTEL_SERVICE_NAME", "mcp-gateway")
 	serviceVersion := env.GetString("OTEL_SERVICE_VERSION", version)
 
+	// Prometheus stays the default; a metrics-specific endpoint adds OTLP
+	// alongside it unless the exporters are chosen explicitly
+	exporters := env.GetString("OTEL_METRICS_EXPORTER", "")
+	if exporters == "" {
+		exporters = MetricsExporterPrometheus
+		if env.GetString("OTEL_EXPORTER_OTLP_METRICS_ENDPOINT", "") != "" {
+			exporters += "," + MetricsExporterOTLP
+		}
+	}
+	metricsInsecure, _ := env.GetBool("OTEL_EXPORTER_OTLP_METRICS_INSECURE", insecure)
+	interval := defaultMetricsExportInterval
+	if ms, err := env.GetInt("OTEL_METRIC_EXPORT_INTERVAL", 0); err == nil && ms > 0 {
+		interval = time.Duration(ms) * time.Millisecond
+	}
+
 	return &Config{
-		Endpoint:       endpoint,
-		Insecure:       insecure,
-		ServiceName:    serviceName,
-		ServiceVersion: serviceVersion,
-		GitSHA:         gitSHA,
-		GitDirty:       dirty,
+		Endpoint:              endpoint,
+		Insecure:              insecure,
+		ServiceName:           serviceName,
+		ServiceVersion:        serviceVersion,
+		GitSHA:                gitSHA,
+		GitDirty:              dirty,
+		MetricsExporters:      splitList(exporters),
+		MetricsInsecure:       metricsInsecure,
+		MetricsTemporality:    env.GetString("OTEL_EXPORTER_OTLP_METRICS_TEMPORALITY_PREFERENCE", "cumulative"),
+		MetricsExportInterval: interval,
 	}
 }
 
+func splitList(s string) []string {
+	var out []string
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.ToLower(strings.TrimSpace(v)); v != "" {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
 // TracesEndpoint returns the endpoint for traces, with signal-specific override support
 func (c *Config) TracesEndpoint() string {
 	if endpoint := env.GetString("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT", ""); endpoint != "" {
@@ -47,6 +102,19 @@ func (c *Config) LogsEndpoint() string {
 	return c.Endpoint
 }
 
+// MetricsEndpoint returns the endpoint for OTLP metrics, with signal-specific override support
+func (c *Config) MetricsEndpoint() string {
+	if endpoint := env.GetString("OTEL_EXPORTER_OTLP_METRICS_ENDPOINT", ""); endpoint != "" {
+		return endpoint
+	}
+	return c.Endpoint
+}
+
+// MetricsExporterEnabled returns true if the named metrics exporter is enabled
+func (c *Config) MetricsExporterEnabled(name string) bool {
+	return slices.Contains(c.MetricsExporters, name)
+}
+
 // TracesEnabled returns true if tracing is enabled
 func (c *Config) TracesEnabled() bool {
 	return c.TracesEndpoint() != ""
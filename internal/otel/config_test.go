@@ -1,7 +1,9 @@
 package otel
 
 import (
+	"slices"
 	"testing"
+	"time"
 )
 
 func TestNewConfig(t *testing.T) {
@@ -197,3 +199,86 @@ func TestEnabled(t *testing.T) {
 		})
 	}
 }
+
+func TestMetricsConfig(t *testing.T) {
+	tests := []struct {
+		name            string
+		envVars         map[string]string
+		wantExporters   []string
+		wantEndpoint    string
+		wantInsecure    bool
+		wantTemporality string
+		wantInterval    time.Duration
+	}{
+		{
+			name:            "prometheus only by default",
+			envVars:         map[string]string{},
+			wantExporters:   []string{"prometheus"},
+			wantTemporality: "cumulative",
+			wantInterval:    60 * time.Second,
+		},
+		{
+			name: "base endpoint alone does not enable otlp metrics",
+			envVars: map[string]string{
+				"OTEL_EXPORTER_OTLP_ENDPOINT": "http://collector:4318",
+			},
+			wantExporters:   []string{"prometheus"},
+			wantEndpoint:    "http://collector:4318",
+			wantTemporality: "cumulative",
+			wantInterval:    60 * time.Second,
+		},
+		{
+			name: "metrics endpoint adds otlp alongside prometheus",
+			envVars: map[string]string{
+				"OTEL_EXPORTER_OTLP_ENDPOINT":         "http://collector:4318",
+				"OTEL_EXPORTER_OTLP_METRICS_ENDPOINT": "rpc://mimir:4317",
+				"OTEL_EXPORTER_OTLP_INSECURE":         "true",
+			},
+			wantExporters:   []string{"prometheus", "otlp"},
+			wantEndpoint:    "rpc://mimir:4317",
+			wantInsecure:    true,
+			wantTemporality: "cumulative",
+			wantInterval:    60 * time.Second,
+		},
+		{
+			name: "explicit exporters and settings",
+			envVars: map[string]string{
+				"OTEL_METRICS_EXPORTER":                             " OTLP ",
+				"OTEL_EXPORTER_OTLP_ENDPOINT":                       "rpc://collector:4317",
+				"OTEL_EXPORTER_OTLP_INSECURE":                       "true",
+				"OTEL_EXPORTER_OTLP_METRICS_INSECURE":               "false",
+				"OTEL_EXPORTER_OTLP_METRICS_TEMPORALITY_PREFERENCE": "delta",
+				"OTEL_METRIC_EXPORT_INTERVAL":                       "15000",
+			},
+			wantExporters:   []string{"otlp"},
+			wantEndpoint:    "rpc://collector:4317",
+			wantTemporality: "delta",
+			wantInterval:    15 * time.Second,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for k, v := range tt.envVars {
+				t.Setenv(k, v)
+			}
+
+			cfg := NewConfig("", "", "")
+			if !slices.Equal(cfg.MetricsExporters, tt.wantExporters) {
+				t.Errorf("MetricsExporters = %v, want %v", cfg.MetricsExporters, tt.wantExporters)
+			}
+			if got := cfg.MetricsEndpoint(); got != tt.wantEndpoint {
+				t.Errorf("MetricsEndpoint() = %q, want %q", got, tt.wantEndpoint)
+			}
+			if cfg.MetricsInsecure != tt.wantInsecure {
+				t.Errorf("MetricsInsecure = %v, want %v", cfg.MetricsInsecure, tt.wantInsecure)
+			}
+			if cfg.MetricsTemporality != tt.wantTemporality {
+				t.Errorf("MetricsTemporality = %q, want %q", cfg.MetricsTemporality, tt.wantTemporality)
+			}
+			if cfg.MetricsExportInterval != tt.wantInterval {
+				t.Errorf("MetricsExportInterval = %v, want %v", cfg.MetricsExportInterval, tt.wantInterval)
+			}
+		})
+	}
+}
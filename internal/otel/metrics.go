@@ -2,46 +2,136 @@ package otel
 
 import (
 	"context"
+	"fmt"
 	"net/http"
+	"net/url"
+	"strings"
 
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetricgrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetrichttp"
 	prometheusexporter "go.opentelemetry.io/otel/exporters/prometheus"
 	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
 )
 
-// MetricsProvider wraps the OTel MeterProvider backed by a Prometheus exporter.
+// MetricsProvider wraps the OTel MeterProvider. Every instrument is read by
+// each enabled exporter: a Prometheus registry served over HTTP and/or a
+// periodic OTLP push.
 type MetricsProvider struct {
 	meterProvider *sdkmetric.MeterProvider
 	registry      *prometheus.Registry
 }
 
-// NewMetricsProvider creates a Prometheus-backed OTel MeterProvider.
-// The exporter serves metrics via the returned HTTPHandler — no OTLP endpoint needed.
-func NewMetricsProvider(_ context.Context, config *Config) (*MetricsProvider, error) {
-	registry := prometheus.NewRegistry()
+// NewMetricsProvider creates an OTel MeterProvider with a reader per exporter
+// enabled in config. The Prometheus exporter serves metrics via the returned
+// HTTPHandler; the OTLP exporter pushes to config.MetricsEndpoint.
+func NewMetricsProvider(ctx context.Context, config *Config) (*MetricsProvider, error) {
+	p := &MetricsProvider{}
+	var opts []sdkmetric.Option
 
-	exporter, err := prometheusexporter.New(
-		prometheusexporter.WithRegisterer(registry),
-	)
+	for _, name := range config.MetricsExporters {
+		switch name {
+		case MetricsExporterPrometheus:
+			p.registry = prometheus.NewRegistry()
+			exporter, err := prometheusexporter.New(
+				prometheusexporter.WithRegisterer(p.registry),
+			)
+			if err != nil {
+				return nil, err
+			}
+			opts = append(opts, sdkmetric.WithReader(exporter))
+		case MetricsExporterOTLP:
+			endpoint := config.MetricsEndpoint()
+			if endpoint == "" {
+				return nil, fmt.Errorf("otlp metrics exporter enabled but no endpoint configured")
+			}
+			temporality, err := temporalitySelector(config.MetricsTemporality)
+			if err != nil {
+				return nil, err
+			}
+			exporter, err := newMetricExporter(ctx, endpoint, config.MetricsInsecure, temporality)
+			if err != nil {
+				return nil, fmt.Errorf("failed to create OTLP metric exporter: %w", err)
+			}
+			opts = append(opts, sdkmetric.WithReader(
+				sdkmetric.NewPeriodicReader(exporter, sdkmetric.WithInterval(config.MetricsExportInterval)),
+			))
+		case MetricsExporterNone:
+		default:
+			return nil, fmt.Errorf("unsupported metrics exporter: %s (use 'prometheus', 'otlp' or 'none')", name)
+		}
+	}
+
+	res, err := NewResource(ctx, config)
 	if err != nil {
 		return nil, err
 	}
 
-	res, err := NewResource(context.Background(), config)
+	p.meterProvider = sdkmetric.NewMeterProvider(append(opts, sdkmetric.WithResource(res))...)
+	return p, nil
+}
+
+func newMetricExporter(ctx context.Context, endpoint string, insecure bool, temporality sdkmetric.TemporalitySelector) (sdkmetric.Exporter, error) {
+	u, err := url.Parse(endpoint)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("invalid endpoint URL: %w", err)
 	}
 
-	mp := sdkmetric.NewMeterProvider(
-		sdkmetric.WithReader(exporter),
-		sdkmetric.WithResource(res),
-	)
+	switch u.Scheme {
+	case "rpc":
+		opts := []otlpmetricgrpc.Option{
+			otlpmetricgrpc.WithEndpoint(u.Host),
+			otlpmetricgrpc.WithTemporalitySelector(temporality),
+		}
+		if insecure {
+			opts = append(opts, otlpmetricgrpc.WithInsecure())
+		}
+		return otlpmetricgrpc.New(ctx, opts...)
+
+	case "http", "https":
+		opts := []otlpmetrichttp.Option{
+			otlpmetrichttp.WithEndpoint(u.Host),
+			otlpmetrichttp.WithTemporalitySelector(temporality),
+		}
+		if path := u.Path; path != "" {
+			opts = append(opts, otlpmetrichttp.WithURLPath(path))
+		}
+		if insecure || u.Scheme == "http" {
+			opts = append(opts, otlpmetrichttp.WithInsecure())
+		}
+		return otlpmetrichttp.New(ctx, opts...)
 
-	return &MetricsProvider{
-		meterProvider: mp,
-		registry:      registry,
-	}, nil
+	default:
+		return nil, fmt.Errorf("unsupported endpoint scheme: %s (use 'rpc', 'http', or 'https')", u.Scheme)
+	}
+}
+
+// temporalitySelector maps an OTEL_EXPORTER_OTLP_METRICS_TEMPORALITY_PREFERENCE
+// value to the temporality the OTLP exporter reports each instrument kind with
+func temporalitySelector(preference string) (sdkmetric.TemporalitySelector, error) {
+	switch strings.ToLower(preference) {
+	case "", "cumulative":
+		return sdkmetric.DefaultTemporalitySelector, nil
+	case "delta":
+		return func(kind sdkmetric.InstrumentKind) metricdata.Temporality {
+			switch kind {
+			case sdkmetric.InstrumentKindUpDownCounter, sdkmetric.InstrumentKindObservableUpDownCounter:
+				return metricdata.CumulativeTemporality
+			}
+			return metricdata.DeltaTemporality
+		}, nil
+	case "lowmemory":
+		return func(kind sdkmetric.InstrumentKind) metricdata.Temporality {
+			switch kind {
+			case sdkmetric.InstrumentKindCounter, sdkmetric.InstrumentKindHistogram:
+				return metricdata.DeltaTemporality
+			}
+			return metricdata.CumulativeTemporality
+		}, nil
+	}
+	return nil, fmt.Errorf("unsupported metrics temporality: %s (use 'cumulative', 'delta' or 'lowmemory')", preference)
 }
 
 // MeterProvider returns the underlying MeterProvider for global registration.
@@ -49,8 +139,12 @@ func (p *MetricsProvider) MeterProvider() *sdkmetric.MeterProvider {
 	return p.meterProvider
 }
 
-// HTTPHandler returns an http.Handler that serves Prometheus metrics.
+// HTTPHandler returns an http.Handler that serves Prometheus metrics, or nil
+// when the Prometheus exporter is disabled.
 func (p *MetricsProvider) HTTPHandler() http.Handler {
+	if p.registry == nil {
+		return nil
+	}
 	return promhttp.HandlerFor(p.registry, promhttp.HandlerOpts{})
 }
 
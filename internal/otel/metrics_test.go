@@ -2,9 +2,14 @@ package otel
 
 import (
 	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
 	"testing"
 
 	"github.com/stretchr/testify/require"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
 )
 
 func TestNewMetricsProvider(t *testing.T) {
@@ -29,3 +34,109 @@ func TestMetricsProvider_HTTPHandler(t *testing.T) {
 
 	_ = mp.Shutdown(ctx)
 }
+
+func TestMetricsProvider_OTLPAndPrometheus(t *testing.T) {
+	var pushes atomic.Int32
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v1/metrics" {
+			pushes.Add(1)
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer collector.Close()
+
+	t.Setenv("OTEL_EXPORTER_OTLP_METRICS_ENDPOINT", collector.URL+"/v1/metrics")
+	ctx := context.Background()
+	config := NewConfig("abc123", "", "v0.0.1")
+	require.Equal(t, []string{MetricsExporterPrometheus, MetricsExporterOTLP}, config.MetricsExporters)
+
+	mp, err := NewMetricsProvider(ctx, config)
+	require.NoError(t, err)
+	defer func() { _ = mp.Shutdown(ctx) }()
+
+	counter, err := mp.MeterProvider().Meter("test").Int64Counter("mcp_broker_test_total")
+	require.NoError(t, err)
+	counter.Add(ctx, 1)
+
+	require.NoError(t, mp.MeterProvider().ForceFlush(ctx))
+	require.Positive(t, pushes.Load())
+
+	rec := httptest.NewRecorder()
+	mp.HTTPHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	require.Contains(t, rec.Body.String(), "mcp_broker_test_total")
+}
+
+func TestMetricsProvider_OTLPOnly(t *testing.T) {
+	t.Setenv("OTEL_METRICS_EXPORTER", "otlp")
+	t.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", "http://localhost:4318")
+	ctx := context.Background()
+
+	mp, err := NewMetricsProvider(ctx, NewConfig("", "", ""))
+	require.NoError(t, err)
+	require.Nil(t, mp.HTTPHandler())
+	_ = mp.Shutdown(ctx)
+}
+
+func TestNewMetricsProvider_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		envVars map[string]string
+		wantErr string
+	}{
+		{
+			name:    "otlp without endpoint",
+			envVars: map[string]string{"OTEL_METRICS_EXPORTER": "otlp"},
+			wantErr: "no endpoint configured",
+		},
+		{
+			name:    "unknown exporter",
+			envVars: map[string]string{"OTEL_METRICS_EXPORTER": "statsd"},
+			wantErr: "unsupported metrics exporter",
+		},
+		{
+			name: "unknown temporality",
+			envVars: map[string]string{
+				"OTEL_EXPORTER_OTLP_METRICS_ENDPOINT":               "http://localhost:4318",
+				"OTEL_EXPORTER_OTLP_METRICS_TEMPORALITY_PREFERENCE": "sometimes",
+			},
+			wantErr: "unsupported metrics temporality",
+		},
+		{
+			name:    "unknown endpoint scheme",
+			envVars: map[string]string{"OTEL_EXPORTER_OTLP_METRICS_ENDPOINT": "ftp://localhost:4318"},
+			wantErr: "unsupported endpoint scheme",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for k, v := range tt.envVars {
+				t.Setenv(k, v)
+			}
+			_, err := NewMetricsProvider(context.Background(), NewConfig("", "", ""))
+			require.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestTemporalitySelector(t *testing.T) {
+	tests := []struct {
+		preference string
+		kind       sdkmetric.InstrumentKind
+		want       metricdata.Temporality
+	}{
+		{"cumulative", sdkmetric.InstrumentKindCounter, metricdata.CumulativeTemporality},
+		{"delta", sdkmetric.InstrumentKindCounter, metricdata.DeltaTemporality},
+		{"delta", sdkmetric.InstrumentKindObservableCounter, metricdata.DeltaTemporality},
+		{"delta", sdkmetric.InstrumentKindUpDownCounter, metricdata.CumulativeTemporality},
+		{"Delta", sdkmetric.InstrumentKindHistogram, metricdata.DeltaTemporality},
+		{"lowmemory", sdkmetric.InstrumentKindHistogram, metricdata.DeltaTemporality},
+		{"lowmemory", sdkmetric.InstrumentKindObservableCounter, metricdata.CumulativeTemporality},
+	}
+
+	for _, tt := range tests {
+		selector, err := temporalitySelector(tt.preference)
+		require.NoError(t, err)
+		require.Equal(t, tt.want, selector(tt.kind), "%s/%v", tt.preference, tt.kind)
+	}
+}
@@ -23,7 +23,8 @@ func SpanError(span trace.Span, err error, msg string) {
 }
 
 // SetupOTelSDK initializes the OpenTelemetry SDK with tracing, logs, and metrics support.
-// metricsHandler serves Prometheus metrics and must be mounted by the caller on a dedicated port.
+// metricsHandler serves Prometheus metrics and must be mounted by the caller on a dedicated port;
+// it is nil when the Prometheus exporter is disabled.
 func SetupOTelSDK(ctx context.Context, gitSHA, dirty, version string, logger *slog.Logger) (shutdown func(context.Context) error, loggerProvider *sdklog.LoggerProvider, metricsHandler http.Handler, err error) {
 	var shutdownFuncs []func(context.Context) error
 
@@ -69,7 +70,11 @@ func SetupOTelSDK(ctx context.Context, gitSHA, dirty, version string, logger *sl
 	shutdownFuncs = append(shutdownFuncs, metricsProvider.Shutdown)
 	otel.SetMeterProvider(metricsProvider.MeterProvider())
 	metricsHandler = metricsProvider.HTTPHandler()
-	logger.Info("OpenTelemetry metrics enabled (Prometheus)")
+	if config.MetricsExporterEnabled(MetricsExporterOTLP) {
+		logger.Info("OpenTelemetry metrics enabled", "exporters", config.MetricsExporters, "endpoint", config.MetricsEndpoint())
+	} else {
+		logger.Info("OpenTelemetry metrics enabled", "exporters", config.MetricsExporters)
+	}
 
 	return shutdown, loggerProvider, metricsHandler, nil
 }
@@ -0,0 +1,82 @@
+package otel
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// MCP _meta keys carrying W3C trace context and baggage, per the
+// OpenTelemetry MCP semantic conventions. They let context survive
+// transports and intermediaries that drop HTTP headers.
+const (
+	MetaKeyTraceparent = "traceparent"
+	MetaKeyTracestate  = "tracestate"
+	MetaKeyBaggage     = "baggage"
+)
+
+var metaKeys = []string{MetaKeyTraceparent, MetaKeyTracestate, MetaKeyBaggage}
+
+// InjectHeaders writes the trace context and baggage of ctx into headers
+// using the global propagator. Header names are lower case.
+func InjectHeaders(ctx context.Context, headers map[string]string) {
+	otel.GetTextMapPropagator().Inject(ctx, propagation.MapCarrier(headers))
+}
+
+// TraceMeta returns the trace context and baggage of ctx as MCP _meta
+// fields, or nil when ctx carries neither.
+func TraceMeta(ctx context.Context) map[string]any {
+	carrier := propagation.MapCarrier{}
+	InjectHeaders(ctx, carrier)
+	var meta map[string]any
+	for _, key := range metaKeys {
+		if v := carrier.Get(key); v != "" {
+			if meta == nil {
+				meta = make(map[string]any, len(metaKeys))
+			}
+			meta[key] = v
+		}
+	}
+	return meta
+}
+
+// InjectMeta writes the trace context and baggage of ctx into meta,
+// replacing any trace fields already there, and returns it. A nil meta is
+// allocated only when there is context to write.
+func InjectMeta(ctx context.Context, meta map[string]any) map[string]any {
+	fields := TraceMeta(ctx)
+	if len(fields) == 0 {
+		return meta
+	}
+	if meta == nil {
+		meta = make(map[string]any, len(fields))
+	}
+	for _, key := range metaKeys {
+		delete(meta, key)
+	}
+	for k, v := range fields {
+		meta[k] = v
+	}
+	return meta
+}
+
+// ExtractMeta returns ctx with the trace context and baggage found in MCP
+// _meta fields. Context already on ctx, normally extracted from headers,
+// takes precedence.
+func ExtractMeta(ctx context.Context, meta map[string]any) context.Context {
+	if len(meta) == 0 || trace.SpanContextFromContext(ctx).IsValid() {
+		return ctx
+	}
+	carrier := propagation.MapCarrier{}
+	for _, key := range metaKeys {
+		if v, ok := meta[key].(string); ok && v != "" {
+			carrier[key] = v
+		}
+	}
+	if len(carrier) == 0 {
+		return ctx
+	}
+	return otel.GetTextMapPropagator().Extract(ctx, carrier)
+}
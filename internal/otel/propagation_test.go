@@ -0,0 +1,153 @@
+package otel
+
+import (
+	"context"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/baggage"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func withPropagator(t *testing.T) {
+	t.Helper()
+	prev := otel.GetTextMapPropagator()
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+	t.Cleanup(func() { otel.SetTextMapPropagator(prev) })
+}
+
+func tracedContext(t *testing.T) (context.Context, trace.SpanContext) {
+	t.Helper()
+	traceID, _ := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	spanID, _ := trace.SpanIDFromHex("00f067aa0ba902b7")
+	sc := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    traceID,
+		SpanID:     spanID,
+		TraceFlags: trace.FlagsSampled,
+	})
+	member, err := baggage.NewMember("tenant", "acme")
+	if err != nil {
+		t.Fatalf("baggage member: %v", err)
+	}
+	bag, err := baggage.New(member)
+	if err != nil {
+		t.Fatalf("baggage: %v", err)
+	}
+	ctx := baggage.ContextWithBaggage(context.Background(), bag)
+	return trace.ContextWithSpanContext(ctx, sc), sc
+}
+
+const wantTraceparent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+func TestInjectHeaders(t *testing.T) {
+	withPropagator(t)
+	ctx, _ := tracedContext(t)
+
+	headers := map[string]string{"authorization": "Bearer abc"}
+	InjectHeaders(ctx, headers)
+
+	if headers["traceparent"] != wantTraceparent {
+		t.Errorf("traceparent = %q, want %q", headers["traceparent"], wantTraceparent)
+	}
+	if headers["baggage"] != "tenant=acme" {
+		t.Errorf("baggage = %q, want tenant=acme", headers["baggage"])
+	}
+	if headers["authorization"] != "Bearer abc" {
+		t.Errorf("existing header changed: %q", headers["authorization"])
+	}
+}
+
+func TestTraceMeta(t *testing.T) {
+	withPropagator(t)
+
+	if meta := TraceMeta(context.Background()); meta != nil {
+		t.Errorf("TraceMeta without context = %v, want nil", meta)
+	}
+
+	ctx, _ := tracedContext(t)
+	meta := TraceMeta(ctx)
+	if meta[MetaKeyTraceparent] != wantTraceparent {
+		t.Errorf("traceparent = %v, want %q", meta[MetaKeyTraceparent], wantTraceparent)
+	}
+	if meta[MetaKeyBaggage] != "tenant=acme" {
+		t.Errorf("baggage = %v, want tenant=acme", meta[MetaKeyBaggage])
+	}
+	if _, ok := meta[MetaKeyTracestate]; ok {
+		t.Errorf("empty tracestate should be omitted, got %v", meta[MetaKeyTracestate])
+	}
+}
+
+func TestInjectMeta(t *testing.T) {
+	withPropagator(t)
+	ctx, _ := tracedContext(t)
+
+	t.Run("nil meta without context stays nil", func(t *testing.T) {
+		if meta := InjectMeta(context.Background(), nil); meta != nil {
+			t.Errorf("got %v, want nil", meta)
+		}
+	})
+
+	t.Run("replaces stale trace fields and keeps others", func(t *testing.T) {
+		meta := map[string]any{
+			"progressToken": "p1",
+			"traceparent":   "00-00000000000000000000000000000001-0000000000000001-00",
+			"tracestate":    "stale=1",
+		}
+		meta = InjectMeta(ctx, meta)
+		if meta["progressToken"] != "p1" {
+			t.Errorf("progressToken = %v, want p1", meta["progressToken"])
+		}
+		if meta[MetaKeyTraceparent] != wantTraceparent {
+			t.Errorf("traceparent = %v, want %q", meta[MetaKeyTraceparent], wantTraceparent)
+		}
+		if _, ok := meta[MetaKeyTracestate]; ok {
+			t.Errorf("stale tracestate should be removed, got %v", meta[MetaKeyTracestate])
+		}
+	})
+}
+
+func TestExtractMeta(t *testing.T) {
+	withPropagator(t)
+	traced, sc := tracedContext(t)
+	meta := TraceMeta(traced)
+
+	t.Run("extracts trace context and baggage", func(t *testing.T) {
+		ctx := ExtractMeta(context.Background(), meta)
+		got := trace.SpanContextFromContext(ctx)
+		if got.TraceID() != sc.TraceID() || got.SpanID() != sc.SpanID() {
+			t.Errorf("span context = %v, want %v", got, sc)
+		}
+		if !got.IsRemote() {
+			t.Error("extracted span context should be remote")
+		}
+		if v := baggage.FromContext(ctx).Member("tenant").Value(); v != "acme" {
+			t.Errorf("baggage tenant = %q, want acme", v)
+		}
+	})
+
+	t.Run("existing context takes precedence", func(t *testing.T) {
+		otherTrace, _ := trace.TraceIDFromHex("0af7651916cd43dd8448eb211c80319c")
+		otherSpan, _ := trace.SpanIDFromHex("b7ad6b7169203331")
+		existing := trace.NewSpanContext(trace.SpanContextConfig{TraceID: otherTrace, SpanID: otherSpan})
+		ctx := trace.ContextWithSpanContext(context.Background(), existing)
+
+		got := trace.SpanContextFromContext(ExtractMeta(ctx, meta))
+		if got.TraceID() != otherTrace {
+			t.Errorf("trace ID = %s, want %s", got.TraceID(), otherTrace)
+		}
+	})
+
+	t.Run("ignores non-string and empty fields", func(t *testing.T) {
+		ctx := ExtractMeta(context.Background(), map[string]any{"traceparent": 42})
+		if trace.SpanContextFromContext(ctx).IsValid() {
+			t.Error("expected no span context")
+		}
+		if ctx := ExtractMeta(context.Background(), nil); trace.SpanContextFromContext(ctx).IsValid() {
+			t.Error("expected no span context for nil meta")
+		}
+	})
+}
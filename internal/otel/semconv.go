@@ -0,0 +1,29 @@
+package otel
+
+import "go.opentelemetry.io/otel/attribute"
+
+// GenAIOperationExecuteTool is the gen_ai.operation.name of a tools/call
+const GenAIOperationExecuteTool = "execute_tool"
+
+// MCPMethodAttributes returns the OpenTelemetry MCP semantic convention
+// attributes for a request: the method and, when known, its target tool,
+// prompt or resource.
+func MCPMethodAttributes(method, target string) []attribute.KeyValue {
+	attrs := []attribute.KeyValue{attribute.String("mcp.method.name", method)}
+	switch method {
+	case "tools/call":
+		attrs = append(attrs, attribute.String("gen_ai.operation.name", GenAIOperationExecuteTool))
+		if target != "" {
+			attrs = append(attrs, attribute.String("gen_ai.tool.name", target))
+		}
+	case "prompts/get":
+		if target != "" {
+			attrs = append(attrs, attribute.String("gen_ai.prompt.name", target))
+		}
+	case "resources/read":
+		if target != "" {
+			attrs = append(attrs, attribute.String("mcp.resource.uri", target))
+		}
+	}
+	return attrs
+}
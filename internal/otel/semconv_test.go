@@ -0,0 +1,55 @@
+package otel
+
+import "testing"
+
+func TestMCPMethodAttributes(t *testing.T) {
+	tests := []struct {
+		method string
+		target string
+		want   map[string]string
+	}{
+		{
+			method: "tools/call",
+			target: "weather_get",
+			want: map[string]string{
+				"mcp.method.name":       "tools/call",
+				"gen_ai.operation.name": GenAIOperationExecuteTool,
+				"gen_ai.tool.name":      "weather_get",
+			},
+		},
+		{
+			method: "prompts/get",
+			target: "summarize",
+			want:   map[string]string{"mcp.method.name": "prompts/get", "gen_ai.prompt.name": "summarize"},
+		},
+		{
+			method: "resources/read",
+			target: "file:///a.txt",
+			want:   map[string]string{"mcp.method.name": "resources/read", "mcp.resource.uri": "file:///a.txt"},
+		},
+		{
+			method: "tools/list",
+			want:   map[string]string{"mcp.method.name": "tools/list"},
+		},
+		{
+			method: "prompts/get",
+			want:   map[string]string{"mcp.method.name": "prompts/get"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.method+"/"+tt.target, func(t *testing.T) {
+			got := map[string]string{}
+			for _, kv := range MCPMethodAttributes(tt.method, tt.target) {
+				got[string(kv.Key)] = kv.Value.AsString()
+			}
+			if len(got) != len(tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+			for k, v := range tt.want {
+				if got[k] != v {
+					t.Errorf("%s = %q, want %q", k, got[k], v)
+				}
+			}
+		})
+	}
+}
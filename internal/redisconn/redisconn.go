@@ -0,0 +1,130 @@
+// Package redisconn builds the Redis client shared by the gateway's stores
+// from a cache connection string
+package redisconn
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"strings"
+
+	redis "github.com/redis/go-redis/v9"
+)
+
+// Mode is the Redis deployment a connection string addresses
+type Mode string
+
+const (
+	// ModeStandalone is a single Redis primary
+	ModeStandalone Mode = "standalone"
+	// ModeSentinel is a primary discovered and failed over by Redis Sentinel
+	ModeSentinel Mode = "sentinel"
+	// ModeCluster is a Redis Cluster
+	ModeCluster Mode = "cluster"
+)
+
+// Options are the parsed client options of a connection string. Exactly one
+// of the option fields is set, matching Mode.
+type Options struct {
+	Mode       Mode
+	Standalone *redis.Options
+	Sentinel   *redis.FailoverOptions
+	Cluster    *redis.ClusterOptions
+}
+
+// Parse parses a cache connection string without connecting. The scheme
+// selects the mode; an "s" after "redis" enables TLS:
+//
+//	redis://<user>:<password>@<host>:<port>/<db>
+//	redis+sentinel://<sentinel-user>:<sentinel-password>@<host>:<port>/<db>?master_name=<name>&addr=<host2>:<port2>
+//	redis+cluster://<user>:<password>@<host>:<port>?addr=<host2>:<port2>
+//
+// and rediss://, rediss+sentinel:// and rediss+cluster://. Sentinel mode
+// takes the primary's credentials from the username and password query
+// parameters. Other query parameters set client options as documented by
+// go-redis.
+func Parse(connectionString string) (*Options, error) {
+	scheme, rest, ok := strings.Cut(connectionString, "://")
+	if !ok {
+		return nil, errors.New("cache connection string must be a URL")
+	}
+	base, mode, _ := strings.Cut(scheme, "+")
+	if base != "redis" && base != "rediss" {
+		return nil, fmt.Errorf("unsupported cache connection scheme %q", scheme)
+	}
+	url := base + "://" + rest
+	switch Mode(mode) {
+	case "":
+		opts, err := redis.ParseURL(url)
+		if err != nil {
+			return nil, err
+		}
+		return &Options{Mode: ModeStandalone, Standalone: opts}, nil
+	case ModeSentinel:
+		opts, err := redis.ParseFailoverURL(url)
+		if err != nil {
+			return nil, err
+		}
+		if opts.MasterName == "" {
+			return nil, errors.New("sentinel connection string requires the master_name query parameter")
+		}
+		return &Options{Mode: ModeSentinel, Sentinel: opts}, nil
+	case ModeCluster:
+		opts, err := redis.ParseClusterURL(url)
+		if err != nil {
+			return nil, err
+		}
+		return &Options{Mode: ModeCluster, Cluster: opts}, nil
+	}
+	return nil, fmt.Errorf("unsupported cache connection scheme %q", scheme)
+}
+
+// NewClient parses connectionString and returns a client for it. For TLS
+// connections a non-empty caPEM replaces the system roots as the trusted CAs.
+func NewClient(connectionString, caPEM string) (redis.UniversalClient, error) {
+	opts, err := Parse(connectionString)
+	if err != nil {
+		return nil, err
+	}
+	if err := opts.trustCA(caPEM); err != nil {
+		return nil, err
+	}
+	switch opts.Mode {
+	case ModeSentinel:
+		return redis.NewFailoverClient(opts.Sentinel), nil
+	case ModeCluster:
+		return redis.NewClusterClient(opts.Cluster), nil
+	default:
+		return redis.NewClient(opts.Standalone), nil
+	}
+}
+
+// TLS reports whether the connection uses TLS
+func (o *Options) TLS() bool {
+	return o.tlsConfig() != nil
+}
+
+func (o *Options) tlsConfig() *tls.Config {
+	switch o.Mode {
+	case ModeSentinel:
+		return o.Sentinel.TLSConfig
+	case ModeCluster:
+		return o.Cluster.TLSConfig
+	default:
+		return o.Standalone.TLSConfig
+	}
+}
+
+func (o *Options) trustCA(caPEM string) error {
+	cfg := o.tlsConfig()
+	if cfg == nil || caPEM == "" {
+		return nil
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM([]byte(caPEM)) {
+		return errors.New("cache CA certificate contains no valid PEM certificates")
+	}
+	cfg.RootCAs = pool
+	return nil
+}
@@ -0,0 +1,70 @@
+package resultcache
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+)
+
+// inMemoryStore is a least recently used cache of this replica's results
+type inMemoryStore struct {
+	mu         sync.Mutex
+	maxEntries int
+	entries    map[string]*list.Element
+	// order holds the entries, most recently used first
+	order *list.List
+	now   func() time.Time
+}
+
+type inMemoryEntry struct {
+	key     string
+	result  []byte
+	expires time.Time
+}
+
+func newInMemoryStore(maxEntries int) *inMemoryStore {
+	return &inMemoryStore{
+		maxEntries: maxEntries,
+		entries:    map[string]*list.Element{},
+		order:      list.New(),
+		now:        time.Now,
+	}
+}
+
+func (s *inMemoryStore) Get(_ context.Context, key string) ([]byte, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	elem, ok := s.entries[key]
+	if !ok {
+		return nil, false, nil
+	}
+	entry := elem.Value.(*inMemoryEntry)
+	if !s.now().Before(entry.expires) {
+		s.remove(elem)
+		return nil, false, nil
+	}
+	s.order.MoveToFront(elem)
+	return entry.result, true, nil
+}
+
+func (s *inMemoryStore) Set(_ context.Context, key string, result []byte, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entry := &inMemoryEntry{key: key, result: result, expires: s.now().Add(ttl)}
+	if elem, ok := s.entries[key]; ok {
+		elem.Value = entry
+		s.order.MoveToFront(elem)
+		return nil
+	}
+	s.entries[key] = s.order.PushFront(entry)
+	for s.order.Len() > s.maxEntries {
+		s.remove(s.order.Back())
+	}
+	return nil
+}
+
+func (s *inMemoryStore) remove(elem *list.Element) {
+	s.order.Remove(elem)
+	delete(s.entries, elem.Value.(*inMemoryEntry).key)
+}
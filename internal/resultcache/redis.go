@@ -0,0 +1,39 @@
+package resultcache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	redis "github.com/redis/go-redis/v9"
+)
+
+const keyPrefix = "toolresult:"
+
+// redisStore shares results between replicas; Redis expires them
+type redisStore struct {
+	client redis.UniversalClient
+}
+
+func newRedisStore(client redis.UniversalClient) *redisStore {
+	return &redisStore{client: client}
+}
+
+func (s *redisStore) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	result, err := s.client.Get(ctx, keyPrefix+key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("get tool result: %w", err)
+	}
+	return result, true, nil
+}
+
+func (s *redisStore) Set(ctx context.Context, key string, result []byte, ttl time.Duration) error {
+	if err := s.client.Set(ctx, keyPrefix+key, result, ttl).Err(); err != nil {
+		return fmt.Errorf("set tool result: %w", err)
+	}
+	return nil
+}
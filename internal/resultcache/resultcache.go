@@ -0,0 +1,57 @@
+// Package resultcache stores the cached results of tool calls.
+package resultcache
+
+import (
+	"context"
+	"time"
+
+	redis "github.com/redis/go-redis/v9"
+)
+
+// defaultMaxEntries bounds the results an in-memory store keeps
+const defaultMaxEntries = 10000
+
+// Store keeps tool call results under opaque keys until their TTL passes.
+type Store interface {
+	// Get returns the result stored under key, and false when there is none
+	// or it expired.
+	Get(ctx context.Context, key string) ([]byte, bool, error)
+	// Set stores result under key for ttl, replacing any earlier result.
+	Set(ctx context.Context, key string, result []byte, ttl time.Duration) error
+}
+
+type storeConfig struct {
+	redisClient redis.UniversalClient
+	maxEntries  int
+}
+
+// New returns a Store. Pass WithRedisClient to share results between
+// replicas; otherwise each replica caches the results of its own calls.
+func New(opts ...func(*storeConfig)) Store {
+	cfg := &storeConfig{}
+	for _, o := range opts {
+		o(cfg)
+	}
+	if cfg.redisClient != nil {
+		return newRedisStore(cfg.redisClient)
+	}
+	if cfg.maxEntries <= 0 {
+		cfg.maxEntries = defaultMaxEntries
+	}
+	return newInMemoryStore(cfg.maxEntries)
+}
+
+// WithRedisClient configures the Store to keep results in Redis.
+func WithRedisClient(client redis.UniversalClient) func(*storeConfig) {
+	return func(c *storeConfig) {
+		c.redisClient = client
+	}
+}
+
+// WithMaxEntries bounds the results an in-memory Store keeps, evicting the
+// least recently used. Redis evicts by its own policy.
+func WithMaxEntries(n int) func(*storeConfig) {
+	return func(c *storeConfig) {
+		c.maxEntries = n
+	}
+}
@@ -0,0 +1,94 @@
+package resultcache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	redis "github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testStore pairs a Store with a way to move its clock forward
+type testStore struct {
+	store   Store
+	advance func(time.Duration)
+}
+
+func newTestStores(t *testing.T) map[string]testStore {
+	t.Helper()
+	redisServer := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: redisServer.Addr()})
+	t.Cleanup(func() {
+		require.NoError(t, client.Close())
+	})
+	inMemory := New().(*inMemoryStore)
+	now := time.Now()
+	inMemory.now = func() time.Time { return now }
+	return map[string]testStore{
+		"in-memory": {store: inMemory, advance: func(d time.Duration) { now = now.Add(d) }},
+		"redis":     {store: New(WithRedisClient(client)), advance: redisServer.FastForward},
+	}
+}
+
+func TestStore_ReturnsResultUnchanged(t *testing.T) {
+	for name, ts := range newTestStores(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			_, ok, err := ts.store.Get(ctx, "weather")
+			require.NoError(t, err)
+			assert.False(t, ok)
+
+			result := []byte(`{"content":[{"type":"text","text":"sunny é"}],  "x":1.50}`)
+			require.NoError(t, ts.store.Set(ctx, "weather", result, time.Minute))
+			got, ok, err := ts.store.Get(ctx, "weather")
+			require.NoError(t, err)
+			assert.True(t, ok)
+			assert.Equal(t, result, got)
+
+			require.NoError(t, ts.store.Set(ctx, "weather", []byte(`{}`), time.Minute))
+			got, _, err = ts.store.Get(ctx, "weather")
+			require.NoError(t, err)
+			assert.Equal(t, []byte(`{}`), got)
+		})
+	}
+}
+
+func TestStore_ExpiresResults(t *testing.T) {
+	for name, ts := range newTestStores(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			require.NoError(t, ts.store.Set(ctx, "weather", []byte(`{}`), time.Minute))
+			ts.advance(59 * time.Second)
+			_, ok, err := ts.store.Get(ctx, "weather")
+			require.NoError(t, err)
+			assert.True(t, ok)
+
+			ts.advance(2 * time.Second)
+			_, ok, err = ts.store.Get(ctx, "weather")
+			require.NoError(t, err)
+			assert.False(t, ok)
+		})
+	}
+}
+
+func TestInMemoryStore_EvictsLeastRecentlyUsed(t *testing.T) {
+	ctx := context.Background()
+	store := New(WithMaxEntries(2))
+	require.NoError(t, store.Set(ctx, "a", []byte(`1`), time.Minute))
+	require.NoError(t, store.Set(ctx, "b", []byte(`2`), time.Minute))
+	// reading a makes b the least recently used
+	_, ok, err := store.Get(ctx, "a")
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.NoError(t, store.Set(ctx, "c", []byte(`3`), time.Minute))
+
+	_, ok, _ = store.Get(ctx, "b")
+	assert.False(t, ok)
+	for _, key := range []string{"a", "c"} {
+		_, ok, _ = store.Get(ctx, key)
+		assert.True(t, ok, key)
+	}
+}
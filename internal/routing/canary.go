@@ -0,0 +1,53 @@
+package routing
+
+import (
+	"hash/fnv"
+
+	"github.com/Kuadrant/mcp-gateway/internal/config"
+)
+
+// MCPServerVariantHeader tells the ext_proc which version of a server a
+// tool call went to, for servers with a canary
+const MCPServerVariantHeader = "x-mcp-server-variant"
+
+// Server variants of a server with a canary
+const (
+	VariantStable = "stable"
+	VariantCanary = "canary"
+)
+
+// CanaryRoute is the canary version of a server. It is only set on a route
+// while the broker has found the canary fit to receive tool calls.
+type CanaryRoute struct {
+	Name string `json:"name"`
+	Host string `json:"host,omitempty"`
+	URL  string `json:"url"`
+}
+
+// canaryVariant picks the version of route a gateway session's tool calls
+// go to. The choice is a hash of the session, so a session sticks to one
+// version for as long as the weight is unchanged.
+func canaryVariant(route *ServerRoute, weight int, sessionID string) string {
+	if route.Canary == nil || weight <= 0 {
+		return VariantStable
+	}
+	if weight >= 100 {
+		return VariantCanary
+	}
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(route.Name + "/" + sessionID))
+	if int(h.Sum32()%100) < weight {
+		return VariantCanary
+	}
+	return VariantStable
+}
+
+// canaryServer returns the canary version of serverInfo
+func canaryServer(serverInfo *config.MCPServer, canary *CanaryRoute) *config.MCPServer {
+	return &config.MCPServer{
+		Name:     canary.Name,
+		Hostname: canary.Host,
+		URL:      canary.URL,
+		Prefix:   serverInfo.Prefix,
+	}
+}
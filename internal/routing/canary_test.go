@@ -0,0 +1,119 @@
+package routing
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/Kuadrant/mcp-gateway/internal/config"
+	"github.com/stretchr/testify/require"
+	"k8s.io/utils/ptr"
+)
+
+func TestCanaryVariant(t *testing.T) {
+	route := &ServerRoute{Name: "mcp-test/weather", Canary: &CanaryRoute{Name: "mcp-test/weather#canary"}}
+
+	require.Equal(t, VariantStable, canaryVariant(route, 0, "session"))
+	require.Equal(t, VariantCanary, canaryVariant(route, 100, "session"))
+	require.Equal(t, VariantStable, canaryVariant(&ServerRoute{Name: "mcp-test/weather"}, 100, "session"), "no canary route")
+
+	canary := 0
+	for i := range 1000 {
+		session := fmt.Sprintf("session-%d", i)
+		variant := canaryVariant(route, 20, session)
+		require.Equal(t, variant, canaryVariant(route, 20, session), "a session sticks to its variant")
+		if variant == VariantCanary {
+			canary++
+			// raising the weight keeps canary sessions on the canary
+			require.Equal(t, VariantCanary, canaryVariant(route, 50, session))
+		}
+	}
+	require.InDelta(t, 200, canary, 60)
+}
+
+func TestRouteToolCall_Canary(t *testing.T) {
+	serverConfigs := []*config.MCPServer{{
+		Name:     "mcp-test/weather",
+		URL:      "http://weather.mcp.local:8080/mcp",
+		Prefix:   "weather_",
+		Hostname: "weather.mcp.local",
+		Canary:   &config.CanaryConfig{URL: "http://weather-v2.mcp.local:8080/v2/mcp", Hostname: "weather-v2.mcp.local", Weight: 100},
+	}}
+	router, validToken := newTestRouter(t, serverConfigs, map[string]string{}, map[string]string{})
+	route := &ServerRoute{
+		Name:   "mcp-test/weather",
+		Host:   "weather.mcp.local",
+		Prefix: "weather_",
+		URL:    "http://weather.mcp.local:8080/mcp",
+		Canary: &CanaryRoute{Name: "mcp-test/weather#canary", Host: "weather-v2.mcp.local", URL: "http://weather-v2.mcp.local:8080/v2/mcp"},
+	}
+	table := NewTableBuilder().AddTool("weather_forecast", route).Build()
+	router.Table = func() RoutingTable { return table }
+
+	ctx := context.Background()
+	_, err := router.SessionCache.AddSession(ctx, validToken, "mcp-test/weather", "stable-session", 0)
+	require.NoError(t, err)
+	_, err = router.SessionCache.AddSession(ctx, validToken, "mcp-test/weather#canary", "canary-session", 0)
+	require.NoError(t, err)
+
+	toolCall := func() *MCPRequest {
+		return &MCPRequest{
+			ID:      ptr.To(1),
+			JSONRPC: "2.0",
+			Method:  "tools/call",
+			Params:  map[string]any{"name": "weather_forecast"},
+			Headers: map[string]string{"mcp-session-id": validToken},
+		}
+	}
+
+	req := toolCall()
+	decision := router.RouteRequest(ctx, &Request{Parsed: req})
+	require.Nil(t, decision.Error)
+	require.Equal(t, "weather-v2.mcp.local", decision.Authority)
+	require.Equal(t, "/v2/mcp", decision.Path)
+	require.Equal(t, "canary-session", decision.SetHeaders[SessionHeader])
+	require.Equal(t, VariantCanary, decision.SetHeaders[MCPServerVariantHeader])
+	require.Equal(t, "mcp-test/weather", decision.SetHeaders[MCPServerNameHeader], "the header keeps the registration name")
+	require.Equal(t, "mcp-test/weather#canary", req.ServerName)
+
+	// the weight is read from the live config
+	serverConfigs[0].Canary.Weight = 0
+	req = toolCall()
+	decision = router.RouteRequest(ctx, &Request{Parsed: req})
+	require.Nil(t, decision.Error)
+	require.Equal(t, "weather.mcp.local", decision.Authority)
+	require.Equal(t, "stable-session", decision.SetHeaders[SessionHeader])
+	require.Equal(t, VariantStable, decision.SetHeaders[MCPServerVariantHeader])
+	require.Equal(t, "mcp-test/weather", req.ServerName)
+
+	// servers without a routed canary get no variant header
+	route.Canary = nil
+	decision = router.RouteRequest(ctx, &Request{Parsed: toolCall()})
+	require.Nil(t, decision.Error)
+	require.NotContains(t, decision.SetHeaders, MCPServerVariantHeader)
+}
+
+func TestRouteCancelled_CanaryKeepsRegistrationName(t *testing.T) {
+	serverConfigs := []*config.MCPServer{{
+		Name:     "mcp-test/weather",
+		URL:      "http://weather.mcp.local:8080/mcp",
+		Hostname: "weather.mcp.local",
+		Canary:   &config.CanaryConfig{URL: "http://weather-v2.mcp.local:8080/mcp", Hostname: "weather-v2.mcp.local", Weight: 50},
+	}}
+	router, validToken := newTestRouter(t, serverConfigs, map[string]string{}, map[string]string{})
+	ctx := context.Background()
+	_, err := router.SessionCache.AddSession(ctx, validToken, "mcp-test/weather#canary", "canary-session", 0)
+	require.NoError(t, err)
+	require.NoError(t, router.SessionCache.SetInFlight(ctx, validToken, RequestIDKey(3), "mcp-test/weather#canary", inFlightTTL))
+
+	decision := router.RouteRequest(ctx, &Request{Parsed: &MCPRequest{
+		JSONRPC: "2.0",
+		Method:  "notifications/cancelled",
+		Params:  map[string]any{"requestId": 3},
+		Headers: map[string]string{"mcp-session-id": validToken},
+	}})
+	require.Nil(t, decision.Error)
+	require.Equal(t, "weather-v2.mcp.local", decision.Authority)
+	require.Equal(t, "canary-session", decision.SetHeaders[SessionHeader])
+	require.Equal(t, "mcp-test/weather", decision.SetHeaders[MCPServerNameHeader])
+}
@@ -43,10 +43,21 @@ func NewRouterErrorf(code int32, format string, args ...any) *RouterError {
 
 // mcp json-rpc method names and elicitation constants
 const (
-	MethodToolCall     = "tools/call"
-	MethodPromptGet    = "prompts/get"
-	MethodResourceRead = "resources/read"
-	MethodInitialize   = "initialize"
+	MethodToolCall        = "tools/call"
+	MethodPromptGet       = "prompts/get"
+	MethodResourceRead    = "resources/read"
+	MethodInitialize      = "initialize"
+	MethodLoggingSetLevel = "logging/setLevel"
+	MethodCancelled       = "notifications/cancelled"
+
+	// server-initiated requests the router forwards to the client during a tool call
+	MethodElicitationCreate     = "elicitation/create"
+	MethodSamplingCreateMessage = "sampling/createMessage"
+	MethodRootsList             = "roots/list"
+
+	// client capabilities recorded per gateway session for backend inits
+	CapabilitySampling = "sampling"
+	CapabilityRoots    = "roots"
 
 	elicitationResultAction  = "action"
 	elicitationActionAccept  = "accept"
@@ -88,12 +99,15 @@ type MCPRequest struct {
 	Method            string            `json:"method,omitempty"`
 	Params            map[string]any    `json:"params,omitempty"`
 	Result            map[string]any    `json:"result,omitempty"`
+	Error             map[string]any    `json:"error,omitempty"`
 	Headers           map[string]string `json:"-"`
 	SessionID         string            `json:"-"`
 	ServerName        string            `json:"-"`
 	ServerPrefix      string            `json:"-"`
 	BackendSessionID  string            `json:"-"`
 	ClientElicitation bool              `json:"-"`
+	ClientSampling    bool              `json:"-"`
+	ClientRoots       bool              `json:"-"`
 }
 
 // GetSingleHeaderValue returns header value by key
@@ -117,7 +131,7 @@ func (mr *MCPRequest) Validate() (bool, error) {
 	if mr.JSONRPC != "2.0" {
 		return false, errors.Join(ErrInvalidRequest, fmt.Errorf("json rpc version invalid"))
 	}
-	if mr.Method == "" && !mr.IsElicitationResponse() {
+	if mr.Method == "" && !mr.IsElicitationResponse() && !mr.IsServerRequestResponse() {
 		return false, errors.Join(ErrInvalidRequest, fmt.Errorf("no method set in json rpc payload"))
 	}
 	if mr.ID == nil && !mr.IsNotificationRequest() {
@@ -141,8 +155,58 @@ func (mr *MCPRequest) IsInitializeRequest() bool {
 	return mr.Method == "initialize" || mr.Method == "notifications/initialized"
 }
 
+// IsHairpinRequest checks if the router sent this request back through the
+// gateway to a backend; only the broker passthrough validates its token
+func (mr *MCPRequest) IsHairpinRequest() bool {
+	return mr.GetSingleHeaderValue("mcp-init-host") != ""
+}
+
+// LogLevel extracts the level from logging/setLevel params
+func (mr *MCPRequest) LogLevel() string {
+	if mr.Method != MethodLoggingSetLevel {
+		return ""
+	}
+	level, _ := mr.Params["level"].(string)
+	return level
+}
+
+// CancelledRequestID extracts the requestId from notifications/cancelled params
+func (mr *MCPRequest) CancelledRequestID() any {
+	if mr.Method != MethodCancelled {
+		return nil
+	}
+	return mr.Params["requestId"]
+}
+
+// RequestIDKey renders a JSON-RPC request ID as a cache key. IDs are
+// encoded as JSON so the string "1" and the number 1 stay distinct.
+func RequestIDKey(id any) string {
+	b, err := json.Marshal(id)
+	if err != nil {
+		return fmt.Sprint(id)
+	}
+	return string(b)
+}
+
 // ClientSupportsElicitation checks if client declared elicitation capability
 func (mr *MCPRequest) ClientSupportsElicitation() bool {
+	return mr.clientDeclared("elicitation")
+}
+
+// ClientCapabilities returns the sampling and roots capabilities the client
+// declared on initialize
+func (mr *MCPRequest) ClientCapabilities() []string {
+	var declared []string
+	for _, c := range []string{CapabilitySampling, CapabilityRoots} {
+		if mr.clientDeclared(c) {
+			declared = append(declared, c)
+		}
+	}
+	return declared
+}
+
+// clientDeclared checks if the initialize params declare capability
+func (mr *MCPRequest) clientDeclared(capability string) bool {
 	if mr.Method != MethodInitialize || mr.Params == nil {
 		return false
 	}
@@ -154,8 +218,8 @@ func (mr *MCPRequest) ClientSupportsElicitation() bool {
 	if !ok {
 		return false
 	}
-	_, hasElicitation := capsMap["elicitation"]
-	return hasElicitation
+	_, declared := capsMap[capability]
+	return declared
 }
 
 // IsElicitationResponse checks if result contains accept/decline/cancel action
@@ -174,6 +238,21 @@ func (mr *MCPRequest) IsElicitationResponse() bool {
 	return a == elicitationActionAccept || a == elicitationActionDecline || a == elicitationActionCancel
 }
 
+// IsServerRequestResponse checks if this is the client's reply to a
+// server-initiated request other than elicitation, such as
+// sampling/createMessage or roots/list. Such replies carry no method and
+// either a result or an error. Results carrying an elicitation action are
+// left to IsElicitationResponse, which rejects unknown actions.
+func (mr *MCPRequest) IsServerRequestResponse() bool {
+	if mr.Method != "" || mr.ID == nil {
+		return false
+	}
+	if _, elicitation := mr.Result[elicitationResultAction]; elicitation {
+		return false
+	}
+	return mr.Result != nil || mr.Error != nil
+}
+
 // ToolName extracts tool name from tools/call params
 func (mr *MCPRequest) ToolName() string {
 	if !mr.IsToolCall() {
@@ -256,6 +335,21 @@ func (mr *MCPRequest) ReWriteResourceURI(actualURI string) {
 	mr.Params["uri"] = actualURI
 }
 
+// SetMetaFields merges fields into params._meta, creating it when absent
+func (mr *MCPRequest) SetMetaFields(fields map[string]any) {
+	if mr.Params == nil {
+		mr.Params = map[string]any{}
+	}
+	meta, _ := mr.Params["_meta"].(map[string]any)
+	if meta == nil {
+		meta = make(map[string]any, len(fields))
+	}
+	for k, v := range fields {
+		meta[k] = v
+	}
+	mr.Params["_meta"] = meta
+}
+
 // ToBytes marshals request to json
 func (mr *MCPRequest) ToBytes() ([]byte, error) {
 	return json.Marshal(mr)
@@ -110,6 +110,27 @@ func (t *Table) DumpTools() string {
 	return sb.String()
 }
 
+// TableSnapshot is a serialisable copy of a Table's routes
+type TableSnapshot struct {
+	Tools            map[string]*ServerRoute `json:"tools"`
+	Prompts          map[string]*ServerRoute `json:"prompts"`
+	Prefixes         map[string]*ServerRoute `json:"prefixes"`
+	ResourcePrefixes map[string]*ServerRoute `json:"resourcePrefixes"`
+	BrokerTools      []string                `json:"brokerTools"`
+}
+
+// Snapshot returns every route in the table. Routes are shared with the
+// table and must not be modified.
+func (t *Table) Snapshot() TableSnapshot {
+	return TableSnapshot{
+		Tools:            maps.Clone(t.tools),
+		Prompts:          maps.Clone(t.prompts),
+		Prefixes:         maps.Clone(t.prefixes),
+		ResourcePrefixes: maps.Clone(t.resourcePrefixes),
+		BrokerTools:      slices.Sorted(maps.Keys(t.brokerTools)),
+	}
+}
+
 // LookupTool finds server route for tool name
 func (t *Table) LookupTool(name string) (*ServerRoute, bool) {
 	r, ok := t.tools[name]
@@ -1,6 +1,8 @@
 package routing
 
 import (
+	"encoding/json"
+	"slices"
 	"testing"
 
 	"k8s.io/utils/ptr"
@@ -194,3 +196,26 @@ func TestEmptyTable(t *testing.T) {
 		t.Error("empty table should not have broker tools")
 	}
 }
+
+func TestSnapshot(t *testing.T) {
+	snap := buildTestTable().Snapshot()
+
+	if len(snap.Tools) != 2 || snap.Tools["github_search"].Name != "github" {
+		t.Errorf("unexpected tools %v", snap.Tools)
+	}
+	if len(snap.Prompts) != 1 || len(snap.Prefixes) != 1 || len(snap.ResourcePrefixes) != 2 {
+		t.Errorf("unexpected snapshot %+v", snap)
+	}
+	if !slices.Equal(snap.BrokerTools, []string{"discover_tools", "select_tools"}) {
+		t.Errorf("expected sorted broker tools, got %v", snap.BrokerTools)
+	}
+
+	out, err := json.Marshal(snap.Prefixes["github_"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"name":"github","host":"github.mcp.local","prefix":"github_","path":"/mcp","url":"","userSpecificList":true}`
+	if string(out) != want {
+		t.Errorf("route JSON = %s, want %s", out, want)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"log/slog"
 	"net/http"
 	"strconv"
+	"strings"
 	"sync/atomic"
 	"time"
 
@@ -40,6 +41,7 @@ type ResponseInput struct {
 	GatewaySessionID  string
 	ResponseSessionID string
 	InitHost          string // mcp-init-host from request (empty for direct client inits)
+	ContentType       string // content-type of the response
 	Request           *MCPRequest
 }
 
@@ -69,23 +71,15 @@ func (h *ResponseHandler202511) HandleResponse(ctx context.Context, input *Respo
 
 	req := input.Request
 
-	// on initialize responses, record whether the client declared elicitation support
-	if req != nil && req.Method == "initialize" && req.ClientSupportsElicitation() {
-		if input.InitHost == "" {
-			if sid := input.ResponseSessionID; sid != "" {
-				ttl := time.Duration(0)
-				if h.JWTManager != nil {
-					if expiresAt, jwtErr := h.JWTManager.GetExpiresIn(sid); jwtErr == nil {
-						ttl = time.Until(expiresAt)
-					}
-				}
-				if ttl <= 0 {
-					h.Logger.ErrorContext(ctx, "skipping client elicitation flag: session TTL not positive", "sid", internaljwt.LogSafeSessionID(sid))
-				} else if err := h.SessionCache.SetClientElicitation(ctx, sid, ttl); err != nil {
-					h.Logger.ErrorContext(ctx, "failed to store client elicitation flag", "error", err)
-				}
-			}
+	// on direct client initialize responses, record which server-initiated
+	// requests the client declared support for, so backend sessions created
+	// later advertise the same
+	if req != nil && req.Method == MethodInitialize && input.InitHost == "" && input.ResponseSessionID != "" {
+		declared := req.ClientCapabilities()
+		if elicitation := req.ClientSupportsElicitation(); elicitation || len(declared) > 0 {
+			h.recordClientCapabilities(ctx, input.ResponseSessionID, elicitation, declared)
 		}
+		h.recordSubjectSession(ctx, req, input.ResponseSessionID)
 	}
 
 	// intercept 404: backend session invalid, remove from cache
@@ -108,7 +102,7 @@ func (h *ResponseHandler202511) HandleResponse(ctx context.Context, input *Respo
 				attribute.Bool("token_invalidation.attempted", true),
 			)
 			h.Logger.DebugContext(ctx, "received 401 from upstream, invalidating cached user token", "server", req.ServerName)
-			if err := h.SessionCache.DeleteUserToken(ctx, req.GetSessionID(), req.ServerName); err != nil {
+			if err := h.SessionCache.DeleteUserToken(ctx, req.GetSessionID(), config.RegistrationName(req.ServerName)); err != nil {
 				span.SetAttributes(attribute.String("token_invalidation.error", err.Error()))
 				h.Logger.ErrorContext(ctx, "failed to delete user token", "server", req.ServerName, "session", internaljwt.LogSafeSessionID(req.GetSessionID()), "error", err)
 			} else {
@@ -117,13 +111,66 @@ func (h *ResponseHandler202511) HandleResponse(ctx context.Context, input *Respo
 		}
 	}
 
-	// enable streamed response body mode for elicitation ID rewriting and/or
-	// resource URI rewriting - either gate is sufficient on its own, tool calls
-	// to servers with no prefix and no elicitation stay pass-through
+	// enable streamed response body mode for server-initiated request ID
+	// rewriting (elicitation, sampling, roots) and/or resource URI rewriting -
+	// either gate is sufficient on its own, tool calls to servers with no
+	// prefix from clients declaring none of those capabilities stay pass-through
 	if req != nil && req.IsToolCall() && input.StatusCode == strconv.Itoa(http.StatusOK) &&
-		(req.ClientElicitation || req.ServerPrefix != "") {
+		(req.ClientElicitation || req.ClientSampling || req.ClientRoots || req.ServerPrefix != "") {
 		decision.StreamBody = true
 	}
 
+	// a streamed tool call is forgotten when its stream ends; otherwise a
+	// plain JSON or error response means the call is already over. SSE
+	// passed through unseen stays in flight until its in-flight ttl passes,
+	// in memory as in Redis.
+	if req != nil && req.IsToolCall() && req.ID != nil && !decision.StreamBody &&
+		!strings.Contains(strings.ToLower(input.ContentType), "text/event-stream") {
+		if err := h.SessionCache.DeleteInFlight(ctx, req.GetSessionID(), RequestIDKey(req.ID)); err != nil {
+			h.Logger.DebugContext(ctx, "failed to clear in-flight tool call", "error", err)
+		}
+	}
+
 	return decision
 }
+
+// recordClientCapabilities stores the client's capabilities for the lifetime
+// of its gateway session
+func (h *ResponseHandler202511) recordClientCapabilities(ctx context.Context, sid string, elicitation bool, declared []string) {
+	ttl := time.Duration(0)
+	if h.JWTManager != nil {
+		if expiresAt, jwtErr := h.JWTManager.GetExpiresIn(sid); jwtErr == nil {
+			ttl = time.Until(expiresAt)
+		}
+	}
+	if ttl <= 0 {
+		h.Logger.ErrorContext(ctx, "skipping client capabilities: session TTL not positive", "sid", internaljwt.LogSafeSessionID(sid))
+		return
+	}
+	if elicitation {
+		if err := h.SessionCache.SetClientElicitation(ctx, sid, ttl); err != nil {
+			h.Logger.ErrorContext(ctx, "failed to store client elicitation flag", "error", err)
+		}
+	}
+	if len(declared) > 0 {
+		if err := h.SessionCache.SetClientCapabilities(ctx, sid, declared, ttl); err != nil {
+			h.Logger.ErrorContext(ctx, "failed to store client capabilities", "error", err)
+		}
+	}
+}
+
+// recordSubjectSession indexes a new session under the subject of the
+// AuthPolicy-verified bearer token, so all of a user's sessions can be
+// revoked together
+func (h *ResponseHandler202511) recordSubjectSession(ctx context.Context, req *MCPRequest, sid string) {
+	if h.JWTManager == nil {
+		return
+	}
+	sub, err := internaljwt.ExtractSubClaim(req.GetSingleHeaderValue(AuthorizationHeader))
+	if err != nil || sub == "" {
+		return
+	}
+	if err := h.JWTManager.RecordSubjectSession(ctx, sub, sid); err != nil {
+		h.Logger.ErrorContext(ctx, "failed to record session subject", "session", internaljwt.LogSafeSessionID(sid), "error", err)
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"os"
 	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/Kuadrant/mcp-gateway/internal/config"
 	"github.com/Kuadrant/mcp-gateway/internal/session"
@@ -265,6 +266,45 @@ func TestResponseHandler_StoresElicitationForDirectInit(t *testing.T) {
 	require.True(t, val)
 }
 
+func TestResponseHandler_RecordsSubjectForDirectInit(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+	cache, err := session.NewCache()
+	require.NoError(t, err)
+
+	jwtManager, err := session.NewJWTManager("test-signing-key-must-be-at-least-32-bytes", 0, logger, cache, session.WithRevocationStore(cache))
+	require.NoError(t, err)
+	brokerSessionID := jwtManager.Generate()
+
+	handler := &ResponseHandler202511{
+		Logger:       logger,
+		SessionCache: cache,
+		JWTManager:   jwtManager,
+	}
+
+	for _, initHost := range []string{"backend.example.com", ""} {
+		handler.HandleResponse(context.Background(), &ResponseInput{
+			ResponseSessionID: brokerSessionID,
+			StatusCode:        "200",
+			InitHost:          initHost,
+			Request: &MCPRequest{
+				Method:  "initialize",
+				Headers: map[string]string{AuthorizationHeader: testBearerJWT("alice")},
+			},
+		})
+		sessions, err := cache.SubjectSessions(context.Background(), "alice")
+		require.NoError(t, err)
+		if initHost != "" {
+			require.Empty(t, sessions, "hairpin initializes open backend sessions, not gateway sessions")
+			continue
+		}
+		require.Equal(t, []string{brokerSessionID}, sessions)
+	}
+
+	revoked, err := jwtManager.RevokeSubject(context.Background(), "alice")
+	require.NoError(t, err)
+	require.Equal(t, []string{brokerSessionID}, revoked)
+}
+
 func TestResponseHandler_SkipsElicitationForHairpinInit(t *testing.T) {
 	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
 	cache, err := session.NewCache()
@@ -679,3 +719,107 @@ func storeConfig(cfg *config.MCPServersConfig) *atomic.Pointer[config.MCPServers
 	p.Store(cfg)
 	return p
 }
+
+func TestResponseHandler_StoresClientCapabilitiesForDirectInit(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+	cache, err := session.NewCache()
+	require.NoError(t, err)
+
+	jwtManager, err := session.NewJWTManager("test-signing-key-must-be-at-least-32-bytes", 0, logger, cache)
+	require.NoError(t, err)
+	brokerSessionID := jwtManager.Generate()
+
+	handler := &ResponseHandler202511{
+		Logger:       logger,
+		SessionCache: cache,
+		JWTManager:   jwtManager,
+	}
+
+	mcpReq := &MCPRequest{
+		Method: "initialize",
+		Params: map[string]any{
+			"capabilities": map[string]any{
+				"sampling": map[string]any{},
+				"roots":    map[string]any{"listChanged": true},
+			},
+		},
+	}
+
+	decision := handler.HandleResponse(context.Background(), &ResponseInput{
+		ResponseSessionID: brokerSessionID,
+		StatusCode:        "200",
+		Request:           mcpReq,
+	})
+	require.NotNil(t, decision)
+
+	caps, err := cache.GetClientCapabilities(context.Background(), brokerSessionID)
+	require.NoError(t, err)
+	require.Equal(t, []string{CapabilitySampling, CapabilityRoots}, caps)
+
+	elicitation, err := cache.GetClientElicitation(context.Background(), brokerSessionID)
+	require.NoError(t, err)
+	require.False(t, elicitation, "elicitation was not declared")
+}
+
+func TestResponseHandler_StreamBodyModeForSamplingAndRoots(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+	cache, err := session.NewCache()
+	require.NoError(t, err)
+
+	handler := &ResponseHandler202511{
+		Logger:       logger,
+		SessionCache: cache,
+	}
+
+	for name, mcpReq := range map[string]*MCPRequest{
+		"sampling": {Method: "tools/call", ClientSampling: true},
+		"roots":    {Method: "tools/call", ClientRoots: true},
+	} {
+		t.Run(name, func(t *testing.T) {
+			decision := handler.HandleResponse(context.Background(), &ResponseInput{StatusCode: "200", Request: mcpReq})
+			require.True(t, decision.StreamBody, "server requests to the client need their IDs rewritten")
+		})
+	}
+}
+
+func TestResponseHandler_ClearsInFlightToolCall(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+	ctx := context.Background()
+
+	testCases := []struct {
+		name          string
+		statusCode    string
+		contentType   string
+		expectCleared bool
+	}{
+		{name: "json response", statusCode: "200", contentType: "application/json", expectCleared: true},
+		{name: "error response", statusCode: "500", contentType: "text/plain", expectCleared: true},
+		{name: "sse passed through", statusCode: "200", contentType: "text/event-stream", expectCleared: false},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			cache, err := session.NewCache()
+			require.NoError(t, err)
+			handler := &ResponseHandler202511{
+				Logger:       logger,
+				SessionCache: cache,
+			}
+			require.NoError(t, cache.SetInFlight(ctx, "gw-session", RequestIDKey(float64(4)), "server1", time.Minute))
+
+			mcpReq := &MCPRequest{
+				ID:        float64(4),
+				Method:    "tools/call",
+				SessionID: "gw-session",
+			}
+			handler.HandleResponse(ctx, &ResponseInput{StatusCode: tc.statusCode, ContentType: tc.contentType, Request: mcpReq})
+
+			server, err := cache.GetInFlight(ctx, "gw-session", RequestIDKey(float64(4)))
+			require.NoError(t, err)
+			if tc.expectCleared {
+				require.Empty(t, server)
+			} else {
+				require.Equal(t, "server1", server)
+			}
+		})
+	}
+}
@@ -6,7 +6,9 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+	"slices"
 	"strings"
+	"sync"
 	"sync/atomic"
 	"time"
 
@@ -19,12 +21,22 @@ import (
 	mcpotel "github.com/Kuadrant/mcp-gateway/internal/otel"
 	"github.com/Kuadrant/mcp-gateway/internal/session"
 	"github.com/Kuadrant/mcp-gateway/internal/transport"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/codes"
 	"go.opentelemetry.io/otel/trace"
 	"golang.org/x/sync/singleflight"
 )
 
+// setLogLevelTimeout bounds each backend's logging/setLevel; the client's
+// request waits on the fan-out
+const setLogLevelTimeout = 5 * time.Second
+
+// inFlightTTL bounds how long a routed tool call stays cancellable if its
+// response end is never observed. a cancellation for an older call goes to
+// the broker, which ignores it.
+const inFlightTTL = 5 * time.Minute
+
 // RoutingTableFunc returns the current routing table snapshot.
 //
 //nolint:revive // package-qualified name is clearer
@@ -32,17 +44,26 @@ type RoutingTableFunc func() RoutingTable
 
 // Router202511 implements Router for the 2025-11-25 protocol (stateful, body-based routing).
 type Router202511 struct {
-	RoutingConfig       *atomic.Pointer[config.MCPServersConfig]
-	Table               RoutingTableFunc
-	SessionCache        SessionCache
-	JWTManager          *session.JWTManager
-	InitForClient       InitForClient
+	RoutingConfig *atomic.Pointer[config.MCPServersConfig]
+	Table         RoutingTableFunc
+	SessionCache  SessionCache
+	JWTManager    *session.JWTManager
+	InitForClient InitForClient
+	// SetLogLevelForClient applies a client's logging level to its backend
+	// sessions; nil disables the fan-out
+	SetLogLevelForClient SetLogLevelForClient
+	// RejectForClient answers server-to-client requests the gateway does
+	// not forward; nil leaves them unanswered
+	RejectForClient     RejectForClient
 	HairpinClientPool   *clients.HairpinClientPool
 	ElicitationMap      idmap.Map
 	TokenElicitationMap elicitation.Map
-	ElicitationEnabled  bool
-	Logger              *slog.Logger
-	initGroup           singleflight.Group
+	// TokenRefresher renews user tokens obtained with OAuth before they are
+	// used; nil leaves the stored tokens as they are
+	TokenRefresher     UserTokenRefresher
+	ElicitationEnabled bool
+	Logger             *slog.Logger
+	initGroup          singleflight.Group
 }
 
 var _ Router = &Router202511{}
@@ -67,6 +88,9 @@ func (r *Router202511) RouteRequest(ctx context.Context, req *Request) *Decision
 	case mcpReq.IsElicitationResponse():
 		span.SetAttributes(attribute.String("mcp.route", "elicitation-response"))
 		return r.routeElicitationResponse(ctx, mcpReq)
+	case mcpReq.IsServerRequestResponse():
+		span.SetAttributes(attribute.String("mcp.route", "server-request-response"))
+		return r.routeServerRequestResponse(ctx, mcpReq)
 	case mcpReq.Method == MethodToolCall:
 		span.SetAttributes(attribute.String("mcp.route", "tool-call"))
 		return r.routeToolCall(ctx, table, mcpReq)
@@ -76,6 +100,12 @@ func (r *Router202511) RouteRequest(ctx context.Context, req *Request) *Decision
 	case mcpReq.Method == MethodResourceRead:
 		span.SetAttributes(attribute.String("mcp.route", "resource-read"))
 		return r.routeResourceRead(ctx, table, mcpReq)
+	case mcpReq.Method == MethodCancelled:
+		span.SetAttributes(attribute.String("mcp.route", "cancelled"))
+		return r.routeCancelled(ctx, mcpReq)
+	case mcpReq.Method == MethodLoggingSetLevel && !mcpReq.IsHairpinRequest():
+		span.SetAttributes(attribute.String("mcp.route", "logging-set-level"))
+		return r.routeLoggingSetLevel(ctx, mcpReq)
 	default:
 		span.SetAttributes(attribute.String("mcp.route", "broker"))
 		return r.routeBrokerPassthrough(ctx, mcpReq)
@@ -86,9 +116,9 @@ func (r *Router202511) routeToolCall(ctx context.Context, table RoutingTable, mc
 	toolName := mcpReq.ToolName()
 
 	ctx, span := tracer().Start(ctx, "mcp-router.tool-call",
+		trace.WithAttributes(mcpotel.MCPMethodAttributes(MethodToolCall, toolName)...),
 		trace.WithAttributes(
 			componentAttr,
-			attribute.String("gen_ai.tool.name", toolName),
 			attribute.String("mcp.session.id", internaljwt.LogSafeSessionID(mcpReq.GetSessionID())),
 		),
 	)
@@ -101,7 +131,7 @@ func (r *Router202511) routeToolCall(ctx context.Context, table RoutingTable, mc
 		return &Decision{Error: &Error{StatusCode: 400, Message: "no tool name set"}}
 	}
 
-	if sessionErr := r.validateSession(mcpReq.GetSessionID()); sessionErr != nil {
+	if sessionErr := r.validateSession(mcpReq); sessionErr != nil {
 		r.Logger.ErrorContext(ctx, "session validation failed", "session", internaljwt.LogSafeSessionID(mcpReq.GetSessionID()), "error", sessionErr)
 		mcpotel.SpanError(span, sessionErr, sessionErr.Error())
 		span.SetAttributes(attribute.String("error.type", "invalid_session"))
@@ -203,13 +233,115 @@ func (r *Router202511) routeToolCall(ctx context.Context, table RoutingTable, mc
 		}
 	}
 
-	return r.routeToUpstream(ctx, span, mcpReq, serverInfo, headers)
+	// x-mcp-servername keeps the registration name for AuthPolicy and
+	// per-server limits; only the backend changes for the canary
+	if route.Canary != nil {
+		variant := canaryVariant(route, r.canaryWeight(route.Name), mcpReq.GetSessionID())
+		headers[MCPServerVariantHeader] = variant
+		span.SetAttributes(attribute.String("mcp.server.variant", variant))
+		if variant == VariantCanary {
+			serverInfo = canaryServer(serverInfo, route.Canary)
+			mcpReq.ServerName = serverInfo.Name
+		}
+	}
+
+	decision := r.routeToUpstream(ctx, span, mcpReq, serverInfo, headers)
+	if decision.Error == nil {
+		r.trackInFlight(ctx, mcpReq)
+	}
+	return decision
+}
+
+// canaryWeight returns the share of sessions routed to the canary of a
+// server, read from the live config so weight changes apply without a
+// routing table rebuild
+func (r *Router202511) canaryWeight(serverName string) int {
+	cfg, err := r.RoutingConfig.Load().GetServerConfigByName(serverName)
+	if err != nil || cfg.Canary == nil {
+		return 0
+	}
+	return cfg.Canary.Weight
+}
+
+// trackInFlight remembers which server is serving a tool call so the
+// client's notifications/cancelled can follow it. best effort: without the
+// entry a cancellation only reaches the broker.
+func (r *Router202511) trackInFlight(ctx context.Context, mcpReq *MCPRequest) {
+	if mcpReq.ID == nil {
+		return
+	}
+	if err := r.SessionCache.SetInFlight(ctx, mcpReq.GetSessionID(), RequestIDKey(mcpReq.ID), mcpReq.ServerName, inFlightTTL); err != nil {
+		r.Logger.WarnContext(ctx, "failed to record in-flight tool call", "error", err, "server", mcpReq.ServerName, "session", internaljwt.LogSafeSessionID(mcpReq.GetSessionID()))
+	}
+}
+
+// routeCancelled sends a client's notifications/cancelled for an in-flight
+// tool call to the backend session serving it. tool call IDs are not
+// rewritten, so the body passes through unchanged. cancellations for
+// anything else go to the broker.
+func (r *Router202511) routeCancelled(ctx context.Context, mcpReq *MCPRequest) *Decision {
+	ctx, span := tracer().Start(ctx, "mcp-router.cancelled",
+		trace.WithAttributes(
+			componentAttr,
+			attribute.String("mcp.session.id", internaljwt.LogSafeSessionID(mcpReq.GetSessionID())),
+		),
+	)
+	defer span.End()
+
+	requestID := mcpReq.CancelledRequestID()
+	if requestID == nil || r.validateSession(mcpReq) != nil {
+		return r.routeBrokerPassthrough(ctx, mcpReq)
+	}
+	serverName, err := r.SessionCache.GetInFlight(ctx, mcpReq.GetSessionID(), RequestIDKey(requestID))
+	if err != nil {
+		r.Logger.ErrorContext(ctx, "failed to look up in-flight tool call", "error", err)
+		mcpotel.SpanError(span, err, "in-flight lookup failed")
+		return r.routeBrokerPassthrough(ctx, mcpReq)
+	}
+	if serverName == "" {
+		return r.routeBrokerPassthrough(ctx, mcpReq)
+	}
+	sessions, err := r.SessionCache.GetSession(ctx, mcpReq.GetSessionID())
+	if err != nil {
+		r.Logger.ErrorContext(ctx, "failed to get session from cache", "error", err)
+		mcpotel.SpanError(span, err, "session cache error")
+		return r.routeBrokerPassthrough(ctx, mcpReq)
+	}
+	backendSessionID, ok := sessions[serverName]
+	if !ok {
+		return r.routeBrokerPassthrough(ctx, mcpReq)
+	}
+	mcpServerConfig, err := r.RoutingConfig.Load().GetServerConfigByName(serverName)
+	if err != nil {
+		r.Logger.DebugContext(ctx, "server for in-flight tool call no longer configured", "server", serverName)
+		return r.routeBrokerPassthrough(ctx, mcpReq)
+	}
+	path, err := mcpServerConfig.Path()
+	if err != nil {
+		r.Logger.ErrorContext(ctx, "failed to parse url for backend", "error", err)
+		mcpotel.SpanError(span, err, "path parse failed")
+		return &Decision{Error: &Error{StatusCode: 500, Message: "internal error"}}
+	}
+
+	span.SetAttributes(attribute.String("mcp.server", serverName))
+	r.Logger.DebugContext(ctx, "forwarding cancellation to backend", "server", serverName, "requestID", requestID)
+	return &Decision{
+		Authority: mcpServerConfig.Hostname,
+		Path:      path,
+		SetHeaders: map[string]string{
+			MethodHeader:        mcpReq.Method,
+			SessionHeader:       backendSessionID,
+			MCPServerNameHeader: config.RegistrationName(serverName),
+		},
+		UnsetHeaders: InternalOnlyHeaders,
+	}
 }
 
 func (r *Router202511) routePromptGet(ctx context.Context, table RoutingTable, mcpReq *MCPRequest) *Decision {
 	promptName := mcpReq.PromptName()
 
 	ctx, span := tracer().Start(ctx, "mcp-router.prompt-get",
+		trace.WithAttributes(mcpotel.MCPMethodAttributes(MethodPromptGet, promptName)...),
 		trace.WithAttributes(
 			componentAttr,
 			attribute.String("mcp.prompt.name", promptName),
@@ -225,7 +357,7 @@ func (r *Router202511) routePromptGet(ctx context.Context, table RoutingTable, m
 		return &Decision{Error: &Error{StatusCode: 400, Message: "no prompt name set"}}
 	}
 
-	if sessionErr := r.validateSession(mcpReq.GetSessionID()); sessionErr != nil {
+	if sessionErr := r.validateSession(mcpReq); sessionErr != nil {
 		r.Logger.ErrorContext(ctx, "session validation failed", "session", internaljwt.LogSafeSessionID(mcpReq.GetSessionID()), "error", sessionErr)
 		mcpotel.SpanError(span, sessionErr, sessionErr.Error())
 		span.SetAttributes(attribute.String("error.type", "invalid_session"))
@@ -275,9 +407,9 @@ func (r *Router202511) routeResourceRead(ctx context.Context, table RoutingTable
 	resourceURI := mcpReq.ResourceURI()
 
 	ctx, span := tracer().Start(ctx, "mcp-router.resource-read",
+		trace.WithAttributes(mcpotel.MCPMethodAttributes(MethodResourceRead, resourceURI)...),
 		trace.WithAttributes(
 			componentAttr,
-			attribute.String("mcp.resource.uri", resourceURI),
 			attribute.String("mcp.session.id", internaljwt.LogSafeSessionID(mcpReq.GetSessionID())),
 		),
 	)
@@ -290,7 +422,7 @@ func (r *Router202511) routeResourceRead(ctx context.Context, table RoutingTable
 		return &Decision{Error: &Error{StatusCode: 400, Message: "no resource uri set"}}
 	}
 
-	if sessionErr := r.validateSession(mcpReq.GetSessionID()); sessionErr != nil {
+	if sessionErr := r.validateSession(mcpReq); sessionErr != nil {
 		r.Logger.ErrorContext(ctx, "session validation failed", "session", internaljwt.LogSafeSessionID(mcpReq.GetSessionID()), "error", sessionErr)
 		mcpotel.SpanError(span, sessionErr, sessionErr.Error())
 		span.SetAttributes(attribute.String("error.type", "invalid_session"))
@@ -358,6 +490,7 @@ func (r *Router202511) routeToUpstream(ctx context.Context, span trace.Span, mcp
 	mcpReq.BackendSessionID = remoteMCPServerSession
 
 	headers[SessionHeader] = remoteMCPServerSession
+	propagateTraceContext(ctx, headers, mcpReq)
 	body, err := mcpReq.ToBytes()
 	if err != nil {
 		r.Logger.ErrorContext(ctx, "failed to marshal body to bytes", "error", err)
@@ -394,7 +527,7 @@ func (r *Router202511) routeElicitationResponse(ctx context.Context, mcpReq *MCP
 	)
 	defer span.End()
 
-	if sessionErr := r.validateSession(mcpReq.GetSessionID()); sessionErr != nil {
+	if sessionErr := r.validateSession(mcpReq); sessionErr != nil {
 		mcpotel.SpanError(span, sessionErr, sessionErr.Error())
 		return &Decision{Error: &Error{StatusCode: int(sessionErr.Code()), Message: sessionErr.Error()}}
 	}
@@ -415,11 +548,54 @@ func (r *Router202511) routeElicitationResponse(ctx context.Context, mcpReq *MCP
 		return &Decision{Error: &Error{StatusCode: 403, Message: "session mismatch"}}
 	}
 
+	return r.routeMappedResponse(ctx, span, mcpReq, gatewayID, entry)
+}
+
+// routeServerRequestResponse sends the client's reply to a sampling or roots
+// request back to the backend that asked, restoring the backend's request
+// ID. replies the router never mapped go to the broker as before.
+func (r *Router202511) routeServerRequestResponse(ctx context.Context, mcpReq *MCPRequest) *Decision {
+	ctx, span := tracer().Start(ctx, "mcp-router.server-request-response",
+		trace.WithAttributes(
+			componentAttr,
+			attribute.String("mcp.session.id", internaljwt.LogSafeSessionID(mcpReq.GetSessionID())),
+		),
+	)
+	defer span.End()
+
+	if r.ElicitationMap == nil {
+		return r.routeBrokerPassthrough(ctx, mcpReq)
+	}
+	if sessionErr := r.validateSession(mcpReq); sessionErr != nil {
+		mcpotel.SpanError(span, sessionErr, sessionErr.Error())
+		return &Decision{Error: &Error{StatusCode: int(sessionErr.Code()), Message: sessionErr.Error()}}
+	}
+
+	gatewayID := fmt.Sprint(mcpReq.ID)
+	entry, ok, err := r.ElicitationMap.Lookup(ctx, gatewayID)
+	if err != nil {
+		r.Logger.ErrorContext(ctx, "failed to lookup server request mapping", "error", err, "gatewayID", gatewayID)
+		mcpotel.SpanError(span, err, "server request lookup failed")
+		return &Decision{Error: &Error{StatusCode: 500, Message: "internal error"}}
+	}
+	if !ok {
+		return r.routeBrokerPassthrough(ctx, mcpReq)
+	}
+	if entry.GatewaySessionID != mcpReq.GetSessionID() {
+		r.Logger.ErrorContext(ctx, "server request response session mismatch", "gatewayID", gatewayID, "expected", internaljwt.LogSafeSessionID(entry.GatewaySessionID), "got", internaljwt.LogSafeSessionID(mcpReq.GetSessionID()))
+		return &Decision{Error: &Error{StatusCode: 403, Message: "session mismatch"}}
+	}
+	return r.routeMappedResponse(ctx, span, mcpReq, gatewayID, entry)
+}
+
+// routeMappedResponse forwards a client's reply to the backend session
+// recorded in entry and releases the mapping
+func (r *Router202511) routeMappedResponse(ctx context.Context, span trace.Span, mcpReq *MCPRequest, gatewayID string, entry idmap.Entry) *Decision {
 	mcpReq.ID = entry.BackendID
 
 	mcpServerConfig, err := r.RoutingConfig.Load().GetServerConfigByName(entry.ServerName)
 	if err != nil {
-		r.Logger.ErrorContext(ctx, "server not found for elicitation response", "server", entry.ServerName)
+		r.Logger.ErrorContext(ctx, "server not found for client response", "server", entry.ServerName)
 		mcpotel.SpanError(span, err, "server not found")
 		return &Decision{Error: &Error{StatusCode: 500, Message: "internal error"}}
 	}
@@ -433,7 +609,7 @@ func (r *Router202511) routeElicitationResponse(ctx context.Context, mcpReq *MCP
 
 	body, err := mcpReq.ToBytes()
 	if err != nil {
-		r.Logger.ErrorContext(ctx, "failed to get bytes for elicitation response", "mcpReqID", mcpReq.ID, "serverName", entry.ServerName)
+		r.Logger.ErrorContext(ctx, "failed to get bytes for client response", "mcpReqID", mcpReq.ID, "serverName", entry.ServerName)
 		mcpotel.SpanError(span, err, "marshal failed")
 		return &Decision{Error: &Error{StatusCode: 500, Message: "internal error"}}
 	}
@@ -445,7 +621,7 @@ func (r *Router202511) routeElicitationResponse(ctx context.Context, mcpReq *MCP
 		Path:      path,
 		SetHeaders: map[string]string{
 			SessionHeader:       entry.SessionID,
-			MCPServerNameHeader: entry.ServerName,
+			MCPServerNameHeader: config.RegistrationName(entry.ServerName),
 			"content-length":    fmt.Sprintf("%d", len(body)),
 		},
 		UnsetHeaders: InternalOnlyHeaders,
@@ -468,7 +644,7 @@ func (r *Router202511) routeBrokerPassthrough(ctx context.Context, mcpReq *MCPRe
 		MethodHeader: mcpReq.Method,
 	}
 
-	if mcpReq.IsInitializeRequest() {
+	if mcpReq.IsInitializeRequest() || mcpReq.Method == MethodLoggingSetLevel {
 		remoteInitializeTarget := mcpReq.GetSingleHeaderValue("mcp-init-host")
 		if remoteInitializeTarget != "" {
 			token := mcpReq.GetSingleHeaderValue(RoutingKey)
@@ -503,7 +679,10 @@ func (r *Router202511) routeBrokerPassthrough(ctx context.Context, mcpReq *MCPRe
 	}
 }
 
-func (r *Router202511) validateSession(sessionID string) *RouterError {
+// validateSession checks the request's session is valid and was issued to the
+// subject of the request's bearer token
+func (r *Router202511) validateSession(mcpReq *MCPRequest) *RouterError {
+	sessionID := mcpReq.GetSessionID()
 	if sessionID == "" {
 		return NewRouterError(400, fmt.Errorf("no session ID found"))
 	}
@@ -511,6 +690,11 @@ func (r *Router202511) validateSession(sessionID string) *RouterError {
 	if err != nil || isInvalid {
 		return NewRouterError(401, fmt.Errorf("session no longer valid"))
 	}
+	// the bearer token has passed AuthPolicy by the time the body is routed
+	sub, _ := internaljwt.ExtractSubClaim(mcpReq.GetSingleHeaderValue(AuthorizationHeader))
+	if err := r.JWTManager.CheckSubject(sessionID, sub); err != nil {
+		return NewRouterError(403, fmt.Errorf("session was issued to a different subject"))
+	}
 	return nil
 }
 
@@ -540,37 +724,7 @@ func (r *Router202511) initializeMCPServerSession(ctx context.Context, mcpReq *M
 			r.Logger.DebugContext(ctx, "found session in cache", "session id", internaljwt.LogSafeSessionID(mcpReq.GetSessionID()), "for server", mcpServerConfig.Name, "remote session", internaljwt.LogSafeSessionID(id))
 			return id, nil
 		}
-		passThroughHeaders := map[string]string{}
-		for key, val := range mcpReq.Headers {
-			k := strings.ToLower(key)
-			if strings.HasPrefix(k, ":") ||
-				k == SessionHeader ||
-				k == "mcp-init-host" ||
-				k == RoutingKey ||
-				k == MCPAuthorizedHeader ||
-				k == MCPVirtualServerHeader {
-				continue
-			}
-			passThroughHeaders[key] = val
-		}
-		passThroughHeaders["x-mcp-method"] = mcpReq.Method
-		passThroughHeaders["x-mcp-servername"] = mcpReq.ServerName
-		if toolName := mcpReq.ToolName(); toolName != "" {
-			passThroughHeaders["x-mcp-toolname"] = toolName
-		}
-		if promptName := mcpReq.PromptName(); promptName != "" {
-			passThroughHeaders["x-mcp-promptname"] = promptName
-		}
-		if resourceURI := mcpReq.ResourceURI(); resourceURI != "" {
-			passThroughHeaders[ResourceHeader] = resourceURI
-		}
-		passThroughHeaders["user-agent"] = "mcp-router"
-		if r.ElicitationEnabled && mcpServerConfig.TokenURLElicitation != nil {
-			if userToken, ok, _ := r.SessionCache.GetUserToken(ctx, mcpReq.GetSessionID(), mcpServerConfig.Name); ok {
-				passThroughHeaders["authorization"] = userToken
-			}
-		}
-
+		passThroughHeaders := r.backendPassThroughHeaders(ctx, mcpReq, mcpServerConfig)
 		r.Logger.DebugContext(ctx, "initializing target as no mcp-session-id found for client", "server", mcpReq.ServerName, "passthrough header count", len(passThroughHeaders))
 
 		if !mcpReq.ClientElicitation {
@@ -581,16 +735,25 @@ func (r *Router202511) initializeMCPServerSession(ctx context.Context, mcpReq *M
 			}
 			mcpReq.ClientElicitation = clientElicitation
 		}
+		declared, capErr := r.SessionCache.GetClientCapabilities(ctx, mcpReq.GetSessionID())
+		if capErr != nil {
+			r.Logger.ErrorContext(ctx, "failed to get client capabilities", "error", capErr, "session", internaljwt.LogSafeSessionID(mcpReq.GetSessionID()))
+			return "", NewRouterErrorf(500, "failed to read client capabilities: %w", capErr)
+		}
+		// sampling spends the client's model tokens, so only servers
+		// allowed to sample see the capability
+		clientCaps := clients.ClientCapabilities{
+			Elicitation: mcpReq.ClientElicitation,
+			Sampling:    slices.Contains(declared, CapabilitySampling) && mcpServerConfig.Sampling,
+			Roots:       slices.Contains(declared, CapabilityRoots),
+		}
 
-		initToken, err := r.JWTManager.GenerateBackendInitToken(mcpServerConfig.Hostname)
-		if err != nil {
+		if err := r.addHairpinHeaders(passThroughHeaders, mcpServerConfig.Hostname); err != nil {
 			r.Logger.ErrorContext(ctx, "failed to generate backend-init token", "error", err)
 			mcpotel.SpanError(initSpan, err, "failed to generate backend-init token")
 			return "", NewRouterErrorf(500, "failed to generate backend-init token: %w", err)
 		}
-		passThroughHeaders[RoutingKey] = initToken
-		passThroughHeaders["mcp-init-host"] = mcpServerConfig.Hostname
-		clientHandle, err := r.InitForClient(ctx, routingCfg.MCPGatewayInternalHostname, mcpServerConfig, passThroughHeaders, mcpReq.ClientElicitation, r.HairpinClientPool)
+		clientHandle, err := r.InitForClient(ctx, routingCfg.MCPGatewayInternalHostname, mcpServerConfig, passThroughHeaders, clientCaps, r.HairpinClientPool)
 		if err != nil {
 			r.Logger.ErrorContext(ctx, "failed to get remote session ", "error", err)
 			mcpotel.SpanError(initSpan, err, "failed to initialize backend session")
@@ -634,6 +797,7 @@ func (r *Router202511) initializeMCPServerSession(ctx context.Context, mcpReq *M
 			return "", NewRouterError(500, fmt.Errorf("internal error"))
 		}
 		time.AfterFunc(ttl, sessionCloser)
+		r.applySessionLogLevel(ctx, mcpReq.GetSessionID(), mcpServerConfig, clientHandle, passThroughHeaders)
 		return remoteSessionID, nil
 	})
 	if err != nil {
@@ -642,9 +806,198 @@ func (r *Router202511) initializeMCPServerSession(ctx context.Context, mcpReq *M
 	return result.(string), nil
 }
 
+// backendPassThroughHeaders builds the client headers carried on hairpin
+// requests to mcpServerConfig: router-internal and pseudo headers are
+// dropped, gateway headers describing the call are added.
+func (r *Router202511) backendPassThroughHeaders(ctx context.Context, mcpReq *MCPRequest, mcpServerConfig *config.MCPServer) map[string]string {
+	passThroughHeaders := map[string]string{}
+	for key, val := range mcpReq.Headers {
+		k := strings.ToLower(key)
+		if strings.HasPrefix(k, ":") ||
+			k == SessionHeader ||
+			k == "mcp-init-host" ||
+			k == RoutingKey ||
+			k == MCPAuthorizedHeader ||
+			k == MCPVirtualServerHeader {
+			continue
+		}
+		passThroughHeaders[key] = val
+	}
+	passThroughHeaders["x-mcp-method"] = mcpReq.Method
+	passThroughHeaders["x-mcp-servername"] = config.RegistrationName(mcpServerConfig.Name)
+	if toolName := mcpReq.ToolName(); toolName != "" {
+		passThroughHeaders["x-mcp-toolname"] = toolName
+	}
+	if promptName := mcpReq.PromptName(); promptName != "" {
+		passThroughHeaders["x-mcp-promptname"] = promptName
+	}
+	if resourceURI := mcpReq.ResourceURI(); resourceURI != "" {
+		passThroughHeaders[ResourceHeader] = resourceURI
+	}
+	passThroughHeaders["user-agent"] = "mcp-router"
+	if r.ElicitationEnabled && mcpServerConfig.TokenURLElicitation != nil {
+		serverName := config.RegistrationName(mcpServerConfig.Name)
+		r.refreshUserToken(ctx, mcpReq.GetSessionID(), serverName)
+		if userToken, ok, _ := r.SessionCache.GetUserToken(ctx, mcpReq.GetSessionID(), serverName); ok {
+			passThroughHeaders["authorization"] = userToken
+		}
+	}
+	return passThroughHeaders
+}
+
+// addHairpinHeaders authorizes a hairpin request to host: the backend-init
+// token and target are set by the router, never taken from the client
+func (r *Router202511) addHairpinHeaders(headers map[string]string, host string) error {
+	initToken, err := r.JWTManager.GenerateBackendInitToken(host)
+	if err != nil {
+		return err
+	}
+	headers[RoutingKey] = initToken
+	headers["mcp-init-host"] = host
+	return nil
+}
+
+// routeLoggingSetLevel fans a client's logging/setLevel out to every backend
+// session it holds and records the level for backend sessions created later.
+// the broker still answers the request: it owns the client session the
+// level applies to and the stream carrying its own upstreams' log events.
+func (r *Router202511) routeLoggingSetLevel(ctx context.Context, mcpReq *MCPRequest) *Decision {
+	ctx, span := tracer().Start(ctx, "mcp-router.logging-set-level",
+		trace.WithAttributes(
+			componentAttr,
+			attribute.String("mcp.session.id", internaljwt.LogSafeSessionID(mcpReq.GetSessionID())),
+		),
+	)
+	defer span.End()
+
+	if sessionErr := r.validateSession(mcpReq); sessionErr != nil {
+		r.Logger.ErrorContext(ctx, "session validation failed", "session", internaljwt.LogSafeSessionID(mcpReq.GetSessionID()), "error", sessionErr)
+		mcpotel.SpanError(span, sessionErr, sessionErr.Error())
+		span.SetAttributes(attribute.String("error.type", "invalid_session"))
+		return &Decision{Error: &Error{StatusCode: int(sessionErr.Code()), Message: sessionErr.Error()}}
+	}
+
+	// an invalid level is left for the broker to reject
+	if level := mcpReq.LogLevel(); level != "" {
+		span.SetAttributes(attribute.String("mcp.logging.level", level))
+		r.fanOutLogLevel(ctx, mcpReq, level)
+	}
+	return r.routeBrokerPassthrough(ctx, mcpReq)
+}
+
+// fanOutLogLevel is best effort: a backend that rejects or misses the level
+// must not fail the client's request, which the broker still serves.
+func (r *Router202511) fanOutLogLevel(ctx context.Context, mcpReq *MCPRequest, level string) {
+	sessionID := mcpReq.GetSessionID()
+	if expiresAt, err := r.JWTManager.GetExpiresIn(sessionID); err == nil {
+		if err := r.SessionCache.SetLogLevel(ctx, sessionID, level, time.Until(expiresAt)); err != nil {
+			r.Logger.ErrorContext(ctx, "failed to store session log level", "session", internaljwt.LogSafeSessionID(sessionID), "error", err)
+		}
+	}
+	if r.SetLogLevelForClient == nil {
+		return
+	}
+	backends, err := r.SessionCache.GetSession(ctx, sessionID)
+	if err != nil {
+		r.Logger.ErrorContext(ctx, "failed to get session from cache", "error", err)
+		return
+	}
+	routingCfg := r.RoutingConfig.Load()
+	var wg sync.WaitGroup
+	for serverName, backendSessionID := range backends {
+		// the session hash also holds per-server metadata fields; only
+		// configured server names are backend sessions
+		mcpServerConfig, err := routingCfg.GetServerConfigByName(serverName)
+		if err != nil {
+			continue
+		}
+		headers := r.backendPassThroughHeaders(ctx, mcpReq, mcpServerConfig)
+		if err := r.addHairpinHeaders(headers, mcpServerConfig.Hostname); err != nil {
+			r.Logger.ErrorContext(ctx, "failed to generate backend-init token", "server", mcpServerConfig.Name, "error", err)
+			continue
+		}
+		wg.Go(func() {
+			setCtx, cancel := context.WithTimeout(ctx, setLogLevelTimeout)
+			defer cancel()
+			if err := r.SetLogLevelForClient(setCtx, routingCfg.MCPGatewayInternalHostname, mcpServerConfig, backendSessionID, level, headers, r.HairpinClientPool); err != nil {
+				r.Logger.DebugContext(ctx, "backend did not accept log level", "server", mcpServerConfig.Name, "level", level, "error", err)
+			}
+		})
+	}
+	wg.Wait()
+}
+
+// RejectServerRequest answers the request id that the backend of the tool
+// call mcpReq sent on its stream with a JSON-RPC error, so the backend does
+// not wait for a reply the gateway will never forward. The error is posted on
+// the backend session through the gateway, like a client's reply.
+func (r *Router202511) RejectServerRequest(ctx context.Context, mcpReq *MCPRequest, id any, code int, message string) error {
+	if r.RejectForClient == nil {
+		return nil
+	}
+	routingCfg := r.RoutingConfig.Load()
+	mcpServerConfig, err := routingCfg.GetServerConfigByName(mcpReq.ServerName)
+	if err != nil {
+		return err
+	}
+	headers := r.backendPassThroughHeaders(ctx, mcpReq, mcpServerConfig)
+	// the error is a reply, not a call of the tool
+	for _, name := range []string{"x-mcp-method", "x-mcp-toolname", "x-mcp-promptname", ResourceHeader} {
+		delete(headers, name)
+	}
+	if err := r.addHairpinHeaders(headers, mcpServerConfig.Hostname); err != nil {
+		return fmt.Errorf("failed to generate backend-init token: %w", err)
+	}
+	return r.RejectForClient(ctx, routingCfg.MCPGatewayInternalHostname, mcpServerConfig, mcpReq.BackendSessionID, id, code, message, headers, r.HairpinClientPool)
+}
+
+// applySessionLogLevel brings a freshly initialized backend session to the
+// level the client already requested, so log events from the call that
+// created it are not lost.
+func (r *Router202511) applySessionLogLevel(ctx context.Context, sessionID string, mcpServerConfig *config.MCPServer, backend *mcp.ClientSession, headers map[string]string) {
+	if r.SetLogLevelForClient == nil {
+		return
+	}
+	if init := backend.InitializeResult(); init == nil || init.Capabilities == nil || init.Capabilities.Logging == nil {
+		return
+	}
+	level, err := r.SessionCache.GetLogLevel(ctx, sessionID)
+	if err != nil || level == "" {
+		return
+	}
+	// the init headers describe the call that created the session
+	setLevelHeaders := make(map[string]string, len(headers))
+	for k, v := range headers {
+		switch k {
+		case "x-mcp-toolname", "x-mcp-promptname", ResourceHeader:
+			continue
+		}
+		setLevelHeaders[k] = v
+	}
+	setLevelHeaders["x-mcp-method"] = MethodLoggingSetLevel
+	setCtx, cancel := context.WithTimeout(ctx, setLogLevelTimeout)
+	defer cancel()
+	if err := r.SetLogLevelForClient(setCtx, r.RoutingConfig.Load().MCPGatewayInternalHostname, mcpServerConfig, backend.ID(), level, setLevelHeaders, r.HairpinClientPool); err != nil {
+		r.Logger.DebugContext(ctx, "backend did not accept log level", "server", mcpServerConfig.Name, "level", level, "error", err)
+	}
+}
+
+// refreshUserToken renews the user's token for serverName if it is about to
+// expire. A failed refresh is logged: the router then uses the stored token,
+// or elicits a new one if the refresher deleted it.
+func (r *Router202511) refreshUserToken(ctx context.Context, sessionID, serverName string) {
+	if r.TokenRefresher == nil {
+		return
+	}
+	if err := r.TokenRefresher.RefreshUserToken(ctx, sessionID, serverName); err != nil {
+		r.Logger.WarnContext(ctx, "user token refresh failed", "server", serverName, "error", err)
+	}
+}
+
 func (r *Router202511) resolveUpstreamToken(ctx context.Context, mcpReq *MCPRequest, serverInfo *config.MCPServer, headers map[string]string) (*ElicitationInfo, error) {
 	sessionID := mcpReq.GetSessionID()
 
+	r.refreshUserToken(ctx, sessionID, serverInfo.Name)
 	token, ok, err := r.SessionCache.GetUserToken(ctx, sessionID, serverInfo.Name)
 	if err != nil {
 		r.Logger.ErrorContext(ctx, "user token cache lookup failed", "error", err)
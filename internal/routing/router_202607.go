@@ -8,6 +8,7 @@ import (
 	"sync/atomic"
 
 	"github.com/Kuadrant/mcp-gateway/internal/config"
+	mcpotel "github.com/Kuadrant/mcp-gateway/internal/otel"
 	"github.com/Kuadrant/mcp-gateway/internal/protocol"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/codes"
@@ -60,9 +61,9 @@ func (r *Router202607) routeToolCall(ctx context.Context, table RoutingTable, re
 	toolName := req.MCPName
 
 	ctx, span := tracer().Start(ctx, "mcp-router.tool-call",
+		trace.WithAttributes(mcpotel.MCPMethodAttributes(MethodToolCall, toolName)...),
 		trace.WithAttributes(
 			componentAttr,
-			attribute.String("gen_ai.tool.name", toolName),
 		),
 	)
 	defer span.End()
@@ -94,6 +95,8 @@ func (r *Router202607) routeToolCall(ctx context.Context, table RoutingTable, re
 		span.SetAttributes(attribute.String("mcp.route", "broker-unknown-tool"))
 		return r.routeBrokerPassthrough(ctx, req)
 	}
+	// stateless requests carry no gateway session to keep a canary split
+	// sticky, so they always go to the stable version
 	serverInfo := routeToMCPServer(route)
 
 	span.SetAttributes(
@@ -125,7 +128,7 @@ func (r *Router202607) routeToolCall(ctx context.Context, table RoutingTable, re
 	headers[MCPServerNameHeader] = serverInfo.Name
 	headers["mcp-name"] = upstreamToolName
 
-	bodyMutation, routerErr := r.validateAndRewriteBody(ctx, span, req, toolName, serverInfo.Prefix, upstreamToolName, true)
+	bodyMutation, routerErr := r.validateAndRewriteBody(ctx, span, req, headers, toolName, serverInfo.Prefix, upstreamToolName, true)
 	if routerErr != nil {
 		return &Decision{Error: routerErr}
 	}
@@ -154,6 +157,7 @@ func (r *Router202607) routePromptGet(ctx context.Context, table RoutingTable, r
 	promptName := req.MCPName
 
 	ctx, span := tracer().Start(ctx, "mcp-router.prompt-get",
+		trace.WithAttributes(mcpotel.MCPMethodAttributes(MethodPromptGet, promptName)...),
 		trace.WithAttributes(
 			componentAttr,
 			attribute.String("mcp.prompt.name", promptName),
@@ -195,7 +199,7 @@ func (r *Router202607) routePromptGet(ctx context.Context, table RoutingTable, r
 	headers[MCPServerNameHeader] = serverInfo.Name
 	headers["mcp-name"] = upstreamPromptName
 
-	bodyMutation, routerErr := r.validateAndRewriteBody(ctx, span, req, promptName, serverInfo.Prefix, upstreamPromptName, false)
+	bodyMutation, routerErr := r.validateAndRewriteBody(ctx, span, req, headers, promptName, serverInfo.Prefix, upstreamPromptName, false)
 	if routerErr != nil {
 		return &Decision{Error: routerErr}
 	}
@@ -250,8 +254,9 @@ func (r *Router202607) routeBrokerPassthrough(ctx context.Context, req *Request)
 	}
 }
 
-func (r *Router202607) validateAndRewriteBody(ctx context.Context, span trace.Span, req *Request, headerName, prefix, upstreamName string, isTool bool) ([]byte, *Error) {
+func (r *Router202607) validateAndRewriteBody(ctx context.Context, span trace.Span, req *Request, headers map[string]string, headerName, prefix, upstreamName string, isTool bool) ([]byte, *Error) {
 	if req.Parsed == nil {
+		propagateTraceContext(ctx, headers, nil)
 		return nil, nil
 	}
 
@@ -273,14 +278,18 @@ func (r *Router202607) validateAndRewriteBody(ctx context.Context, span trace.Sp
 		}
 	}
 
-	if prefix == "" {
+	// the body is only re-encoded when something in it changes
+	traced := propagateTraceContext(ctx, headers, req.Parsed)
+	if prefix == "" && !traced {
 		return nil, nil
 	}
 
-	if isTool {
-		req.Parsed.ReWriteToolName(upstreamName)
-	} else {
-		req.Parsed.ReWritePromptName(upstreamName)
+	if prefix != "" {
+		if isTool {
+			req.Parsed.ReWriteToolName(upstreamName)
+		} else {
+			req.Parsed.ReWritePromptName(upstreamName)
+		}
 	}
 
 	bytes, err := req.Parsed.ToBytes()
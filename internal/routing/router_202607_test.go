@@ -2,6 +2,7 @@ package routing
 
 import (
 	"context"
+	"encoding/json"
 	"log/slog"
 	"os"
 	"sync/atomic"
@@ -9,6 +10,9 @@ import (
 
 	"github.com/Kuadrant/mcp-gateway/internal/config"
 	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
 	"k8s.io/utils/ptr"
 )
 
@@ -438,3 +442,60 @@ func TestRouter202607_BrokerPassthroughReInjectsInternalHeaders(t *testing.T) {
 	require.Equal(t, "signed-jwt", decision.SetHeaders[MCPAuthorizedHeader])
 	require.Equal(t, "test/vs", decision.SetHeaders[MCPVirtualServerHeader])
 }
+
+func TestRouter202607_PropagatesTraceContext(t *testing.T) {
+	prev := otel.GetTextMapPropagator()
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() { otel.SetTextMapPropagator(prev) })
+
+	traceID, _ := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	spanID, _ := trace.SpanIDFromHex("00f067aa0ba902b7")
+	ctx := trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    traceID,
+		SpanID:     spanID,
+		TraceFlags: trace.FlagsSampled,
+	}))
+
+	serverConfigs := []*config.MCPServer{
+		{
+			Name:     "plain",
+			URL:      "http://localhost:8080/mcp",
+			State:    "Enabled",
+			Hostname: "localhost",
+		},
+	}
+	router := newTestRouter202607(t, serverConfigs, map[string]string{"mytool": "plain"}, map[string]string{})
+
+	parsed := &MCPRequest{
+		ID:      ptr.To(1),
+		JSONRPC: "2.0",
+		Method:  "tools/call",
+		Params: map[string]any{
+			"name":  "mytool",
+			"_meta": map[string]any{"progressToken": "p1"},
+		},
+	}
+	req := &Request{
+		MCPMethod: MethodToolCall,
+		MCPName:   "mytool",
+		RequestID: "req-1",
+		Parsed:    parsed,
+	}
+
+	decision := router.RouteRequest(ctx, req)
+	require.Nil(t, decision.Error)
+	traceparent := decision.SetHeaders["traceparent"]
+	require.Contains(t, traceparent, "4bf92f3577b34da6a3ce929d0e0e4736")
+	require.NotNil(t, decision.BodyMutation)
+
+	var body struct {
+		Params struct {
+			Name string         `json:"name"`
+			Meta map[string]any `json:"_meta"`
+		} `json:"params"`
+	}
+	require.NoError(t, json.Unmarshal(decision.BodyMutation, &body))
+	require.Equal(t, "mytool", body.Params.Name)
+	require.Equal(t, traceparent, body.Params.Meta["traceparent"])
+	require.Equal(t, "p1", body.Params.Meta["progressToken"])
+}
@@ -15,6 +15,7 @@ import (
 	"log/slog"
 	"os"
 	"testing"
+	"time"
 
 	"github.com/Kuadrant/mcp-gateway/internal/broker/upstream"
 	"github.com/Kuadrant/mcp-gateway/internal/clients"
@@ -109,7 +110,7 @@ func newTestRouterWithSession(t *testing.T, serverConfigs []*config.MCPServer, s
 	require.True(t, sessionAdded)
 
 	// Prevent real initialization from being called
-	router.InitForClient = func(_ context.Context, _ string, _ *config.MCPServer, _ map[string]string, _ bool, _ *clients.HairpinClientPool) (*mcp.ClientSession, error) {
+	router.InitForClient = func(_ context.Context, _ string, _ *config.MCPServer, _ map[string]string, _ clients.ClientCapabilities, _ *clients.HairpinClientPool) (*mcp.ClientSession, error) {
 		return nil, fmt.Errorf("InitForClient should not be called when session exists")
 	}
 
@@ -274,7 +275,7 @@ func TestHandleRequestBody(t *testing.T) {
 	require.True(t, sessionAdded)
 
 	// Mock InitForClient - should not be called since session exists
-	mockInitForClient := func(_ context.Context, _ string, _ *config.MCPServer, _ map[string]string, _ bool, _ *clients.HairpinClientPool) (*mcp.ClientSession, error) {
+	mockInitForClient := func(_ context.Context, _ string, _ *config.MCPServer, _ map[string]string, _ clients.ClientCapabilities, _ *clients.HairpinClientPool) (*mcp.ClientSession, error) {
 		// This should not be called in this test since session exists in cache
 		return nil, fmt.Errorf("InitForClient should not be called when session exists")
 	}
@@ -553,7 +554,7 @@ func TestValidateSession(t *testing.T) {
 	cache, err := session.NewCache()
 	require.NoError(t, err)
 
-	jwtManager, err := session.NewJWTManager(testSigningKey, 0, logger, cache)
+	jwtManager, err := session.NewJWTManager(testSigningKey, 0, logger, cache, session.WithRevocationStore(cache))
 	require.NoError(t, err)
 
 	validToken := jwtManager.Generate()
@@ -564,19 +565,52 @@ func TestValidateSession(t *testing.T) {
 	}
 
 	t.Run("valid session", func(t *testing.T) {
-		require.Nil(t, router.validateSession(validToken))
+		require.Nil(t, router.validateSession(&MCPRequest{SessionID: validToken}))
 	})
 
 	t.Run("empty session ID", func(t *testing.T) {
-		routerErr := router.validateSession("")
+		routerErr := router.validateSession(&MCPRequest{SessionID: ""})
 		require.NotNil(t, routerErr)
 		require.Equal(t, int32(400), routerErr.Code())
 	})
 
 	t.Run("invalid JWT", func(t *testing.T) {
-		routerErr := router.validateSession("invalid-jwt-token")
+		routerErr := router.validateSession(&MCPRequest{SessionID: "invalid-jwt-token"})
+		require.NotNil(t, routerErr)
+		require.Equal(t, int32(401), routerErr.Code())
+	})
+
+	t.Run("revoked session", func(t *testing.T) {
+		revokedToken := jwtManager.Generate()
+		require.Nil(t, router.validateSession(&MCPRequest{SessionID: revokedToken}))
+		require.NoError(t, jwtManager.Revoke(context.Background(), revokedToken))
+		routerErr := router.validateSession(&MCPRequest{SessionID: revokedToken})
 		require.NotNil(t, routerErr)
 		require.Equal(t, int32(401), routerErr.Code())
+		require.Nil(t, router.validateSession(&MCPRequest{SessionID: validToken}))
+	})
+
+	t.Run("session bound to another subject", func(t *testing.T) {
+		aliceToken := jwtManager.GenerateForSubject("alice")
+		require.Nil(t, router.validateSession(&MCPRequest{
+			SessionID: aliceToken,
+			Headers:   map[string]string{AuthorizationHeader: testBearerJWT("alice")},
+		}))
+		for _, auth := range []string{testBearerJWT("bob"), ""} {
+			routerErr := router.validateSession(&MCPRequest{
+				SessionID: aliceToken,
+				Headers:   map[string]string{AuthorizationHeader: auth},
+			})
+			require.NotNil(t, routerErr)
+			require.Equal(t, int32(403), routerErr.Code())
+		}
+		// an unbound session is not usable by an authenticated subject
+		routerErr := router.validateSession(&MCPRequest{
+			SessionID: validToken,
+			Headers:   map[string]string{AuthorizationHeader: testBearerJWT("alice")},
+		})
+		require.NotNil(t, routerErr)
+		require.Equal(t, int32(403), routerErr.Code())
 	})
 }
 
@@ -1177,7 +1211,7 @@ func TestHandleNoneToolCall_HairpinJWTValidation(t *testing.T) {
 // clients.Initialize.
 func TestInitializeMCPServerSession_PassThroughHeaders(t *testing.T) {
 	var captured map[string]string
-	mockInitForClient := func(_ context.Context, _ string, _ *config.MCPServer, headers map[string]string, _ bool, _ *clients.HairpinClientPool) (*mcp.ClientSession, error) {
+	mockInitForClient := func(_ context.Context, _ string, _ *config.MCPServer, headers map[string]string, _ clients.ClientCapabilities, _ *clients.HairpinClientPool) (*mcp.ClientSession, error) {
 		captured = make(map[string]string, len(headers))
 		for k, v := range headers {
 			captured[k] = v
@@ -1291,7 +1325,7 @@ func TestInitializeMCPServerSession_UpstreamErrorPropagation(t *testing.T) {
 
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
-			mockInitForClient := func(_ context.Context, _ string, _ *config.MCPServer, _ map[string]string, _ bool, _ *clients.HairpinClientPool) (*mcp.ClientSession, error) {
+			mockInitForClient := func(_ context.Context, _ string, _ *config.MCPServer, _ map[string]string, _ clients.ClientCapabilities, _ *clients.HairpinClientPool) (*mcp.ClientSession, error) {
 				return nil, tc.initErr
 			}
 
@@ -1437,7 +1471,7 @@ func TestHandlePromptGet(t *testing.T) {
 	require.NoError(t, err)
 	require.True(t, sessionAdded)
 
-	mockInitForClient := func(_ context.Context, _ string, _ *config.MCPServer, _ map[string]string, _ bool, _ *clients.HairpinClientPool) (*mcp.ClientSession, error) {
+	mockInitForClient := func(_ context.Context, _ string, _ *config.MCPServer, _ map[string]string, _ clients.ClientCapabilities, _ *clients.HairpinClientPool) (*mcp.ClientSession, error) {
 		return nil, fmt.Errorf("InitForClient should not be called when session exists")
 	}
 	router.InitForClient = mockInitForClient
@@ -1564,7 +1598,7 @@ func TestHandleResourceRead(t *testing.T) {
 	require.NoError(t, err)
 	require.True(t, sessionAdded)
 
-	router.InitForClient = func(_ context.Context, _ string, _ *config.MCPServer, _ map[string]string, _ bool, _ *clients.HairpinClientPool) (*mcp.ClientSession, error) {
+	router.InitForClient = func(_ context.Context, _ string, _ *config.MCPServer, _ map[string]string, _ clients.ClientCapabilities, _ *clients.HairpinClientPool) (*mcp.ClientSession, error) {
 		return nil, fmt.Errorf("InitForClient should not be called when session exists")
 	}
 
@@ -2033,7 +2067,7 @@ func setupTokenResolutionTestRouter(t *testing.T, serverConfigs []*config.MCPSer
 		require.NoError(t, err)
 	}
 
-	router.InitForClient = func(_ context.Context, _ string, _ *config.MCPServer, _ map[string]string, _ bool, _ *clients.HairpinClientPool) (*mcp.ClientSession, error) {
+	router.InitForClient = func(_ context.Context, _ string, _ *config.MCPServer, _ map[string]string, _ clients.ClientCapabilities, _ *clients.HairpinClientPool) (*mcp.ClientSession, error) {
 		return nil, fmt.Errorf("should not be called")
 	}
 	router.TokenElicitationMap = tokenElicitationMap
@@ -2045,6 +2079,18 @@ func setupTokenResolutionTestRouter(t *testing.T, serverConfigs []*config.MCPSer
 	return router, validToken
 }
 
+// bindTestSession issues a session to subject, pre-populated like the one
+// setupTokenResolutionTestRouter returns
+func bindTestSession(t *testing.T, router *Router202511, serverConfigs []*config.MCPServer, subject string) string {
+	t.Helper()
+	token := router.JWTManager.GenerateForSubject(subject)
+	for _, svr := range serverConfigs {
+		_, err := router.SessionCache.AddSession(context.Background(), token, svr.Name, "mock-upstream-session", 0)
+		require.NoError(t, err)
+	}
+	return token
+}
+
 func TestResolveUpstreamToken_NoElicitationConfig(t *testing.T) {
 	// server without TokenURLElicitation → existing behavior unchanged, no auth header injected
 	serverConfigs := []*config.MCPServer{{
@@ -2094,7 +2140,23 @@ func TestResolveUpstreamToken_CachedTokenInjected(t *testing.T) {
 	require.Equal(t, "ghp_cached_token", decision.SetHeaders["authorization"], "authorization header should be injected with cached token")
 }
 
-func TestResolveUpstreamToken_CacheMiss_ElicitationTriggered(t *testing.T) {
+// stubTokenRefresher stores a renewed token the way the upstream OAuth
+// handler does
+type stubTokenRefresher struct {
+	cache SessionCache
+	calls []string
+	err   error
+}
+
+func (s *stubTokenRefresher) RefreshUserToken(ctx context.Context, sessionID, serverName string) error {
+	s.calls = append(s.calls, serverName)
+	if s.err != nil {
+		return s.err
+	}
+	return s.cache.SetUserToken(ctx, sessionID, serverName, "Bearer renewed", 0)
+}
+
+func TestResolveUpstreamToken_RefreshedBeforeUse(t *testing.T) {
 	serverConfigs := []*config.MCPServer{{
 		Name: "github", URL: "http://github.mcp:8080/mcp", Prefix: "gh_", State: "Enabled", Hostname: "github.mcp",
 		TokenURLElicitation: &config.TokenURLElicitationConfig{},
@@ -2103,6 +2165,42 @@ func TestResolveUpstreamToken_CacheMiss_ElicitationTriggered(t *testing.T) {
 	require.NoError(t, err)
 
 	router, validToken := setupTokenResolutionTestRouter(t, serverConfigs, map[string]string{"gh_tool": "github"}, tokenMap)
+	require.NoError(t, router.SessionCache.SetUserToken(context.Background(), validToken, "github", "Bearer expiring", 0))
+	refresher := &stubTokenRefresher{cache: router.SessionCache}
+	router.TokenRefresher = refresher
+
+	toolCall := func() *Request {
+		return &Request{Parsed: &MCPRequest{
+			ID: ptr.To(1), JSONRPC: "2.0", Method: "tools/call",
+			Params: map[string]any{"name": "gh_tool"},
+			Headers: map[string]string{
+				"mcp-session-id": validToken,
+			},
+		}}
+	}
+	decision := router.RouteRequest(context.Background(), toolCall())
+	require.Nil(t, decision.Error)
+	require.Equal(t, []string{"github"}, refresher.calls)
+	require.Equal(t, "Bearer renewed", decision.SetHeaders["authorization"])
+
+	// a failed refresh falls back to the stored token
+	require.NoError(t, router.SessionCache.SetUserToken(context.Background(), validToken, "github", "Bearer expiring", 0))
+	refresher.err = fmt.Errorf("token endpoint unavailable")
+	decision = router.RouteRequest(context.Background(), toolCall())
+	require.Nil(t, decision.Error)
+	require.Equal(t, "Bearer expiring", decision.SetHeaders["authorization"])
+}
+
+func TestResolveUpstreamToken_CacheMiss_ElicitationTriggered(t *testing.T) {
+	serverConfigs := []*config.MCPServer{{
+		Name: "github", URL: "http://github.mcp:8080/mcp", Prefix: "gh_", State: "Enabled", Hostname: "github.mcp",
+		TokenURLElicitation: &config.TokenURLElicitationConfig{},
+	}}
+	tokenMap, err := elicitation.New()
+	require.NoError(t, err)
+
+	router, _ := setupTokenResolutionTestRouter(t, serverConfigs, map[string]string{"gh_tool": "github"}, tokenMap)
+	validToken := bindTestSession(t, router, serverConfigs, "user456")
 
 	// mark client as supporting elicitation
 	require.NoError(t, router.SessionCache.SetClientElicitation(context.Background(), validToken, 0))
@@ -2217,7 +2315,8 @@ func TestResolveUpstreamToken_SubExtractedAndStored(t *testing.T) {
 	tokenMap, err := elicitation.New()
 	require.NoError(t, err)
 
-	router, validToken := setupTokenResolutionTestRouter(t, serverConfigs, map[string]string{"gh_tool": "github"}, tokenMap)
+	router, _ := setupTokenResolutionTestRouter(t, serverConfigs, map[string]string{"gh_tool": "github"}, tokenMap)
+	validToken := bindTestSession(t, router, serverConfigs, "user123")
 	require.NoError(t, router.SessionCache.SetClientElicitation(context.Background(), validToken, 0))
 
 	req := &MCPRequest{
@@ -2336,3 +2435,554 @@ func TestAnnotationHintsHeader(t *testing.T) {
 		})
 	}
 }
+
+type setLevelCall struct {
+	server           string
+	backendSessionID string
+	level            string
+	headers          map[string]string
+}
+
+// recordSetLevel captures the router's logging/setLevel hairpin calls
+func recordSetLevel(calls chan<- setLevelCall) SetLogLevelForClient {
+	return func(_ context.Context, _ string, conf *config.MCPServer, backendSessionID, level string, headers map[string]string, _ *clients.HairpinClientPool) error {
+		calls <- setLevelCall{server: conf.Name, backendSessionID: backendSessionID, level: level, headers: headers}
+		return nil
+	}
+}
+
+func TestRouteLoggingSetLevel_FansOutToBackendSessions(t *testing.T) {
+	serverConfigs := []*config.MCPServer{
+		{Name: "one", URL: "http://one.mcp.local/mcp", Prefix: "one_", Hostname: "one.mcp.local"},
+		{Name: "two", URL: "http://two.mcp.local/mcp", Prefix: "two_", Hostname: "two.mcp.local"},
+		{Name: "idle", URL: "http://idle.mcp.local/mcp", Prefix: "idle_", Hostname: "idle.mcp.local"},
+	}
+	router, validToken := newTestRouterWithSession(t, serverConfigs, "one")
+	ctx := context.Background()
+	_, err := router.SessionCache.AddSession(ctx, validToken, "two", "backend-two", 0)
+	require.NoError(t, err)
+	require.NoError(t, router.SessionCache.SetUserToken(ctx, validToken, "two", "Bearer user", 0))
+
+	calls := make(chan setLevelCall, 4)
+	router.SetLogLevelForClient = recordSetLevel(calls)
+
+	req := &MCPRequest{
+		JSONRPC: "2.0",
+		Method:  MethodLoggingSetLevel,
+		ID:      ptr.To(3),
+		Params:  map[string]any{"level": "debug"},
+		Headers: map[string]string{
+			"mcp-session-id":   validToken,
+			"authorization":    "Bearer client",
+			"x-mcp-authorized": "forged",
+		},
+	}
+	decision := router.RouteRequest(ctx, &Request{Parsed: req})
+	require.Nil(t, decision.Error)
+	require.True(t, decision.BrokerPass, "the broker still answers setLevel for the client session")
+	close(calls)
+
+	got := map[string]setLevelCall{}
+	for c := range calls {
+		got[c.server] = c
+	}
+	require.Len(t, got, 2, "only servers the client holds a backend session with are told")
+	require.Equal(t, "mock-upstream-session-id", got["one"].backendSessionID)
+	require.Equal(t, "backend-two", got["two"].backendSessionID)
+	for name, c := range got {
+		require.Equal(t, "debug", c.level)
+		require.Equal(t, name+".mcp.local", c.headers["mcp-init-host"])
+		require.NoError(t, router.JWTManager.ValidateBackendInitToken(c.headers[RoutingKey], name+".mcp.local"))
+		require.Equal(t, MethodLoggingSetLevel, c.headers["x-mcp-method"])
+		require.Equal(t, name, c.headers["x-mcp-servername"])
+		require.Equal(t, "Bearer client", c.headers["authorization"])
+		require.NotContains(t, c.headers, "x-mcp-authorized")
+		require.NotContains(t, c.headers, "mcp-session-id")
+	}
+
+	level, err := router.SessionCache.GetLogLevel(ctx, validToken)
+	require.NoError(t, err)
+	require.Equal(t, "debug", level, "the level is kept for backend sessions created later")
+}
+
+func TestRouteLoggingSetLevel_SkipsServerWithoutToken(t *testing.T) {
+	serverConfigs := []*config.MCPServer{
+		{Name: "one", URL: "http://one.mcp.local/mcp", Prefix: "one_", Hostname: "one.mcp.local"},
+		{Name: "two", URL: "http://two.mcp.local/mcp", Prefix: "two_", Hostname: "two.mcp.local"},
+		{Name: "three", URL: "http://three.mcp.local/mcp", Prefix: "three_", Hostname: "three.mcp.local"},
+		// no backend-init token can be issued without a hostname
+		{Name: "nohost", URL: "http://nohost.mcp.local/mcp", Prefix: "nohost_"},
+	}
+	router, validToken := newTestRouterWithSession(t, serverConfigs, "one")
+	ctx := context.Background()
+	for _, name := range []string{"two", "three", "nohost"} {
+		_, err := router.SessionCache.AddSession(ctx, validToken, name, "backend-"+name, 0)
+		require.NoError(t, err)
+	}
+	calls := make(chan setLevelCall, 4)
+	router.SetLogLevelForClient = recordSetLevel(calls)
+
+	req := &MCPRequest{
+		JSONRPC: "2.0",
+		Method:  MethodLoggingSetLevel,
+		ID:      ptr.To(3),
+		Params:  map[string]any{"level": "debug"},
+		Headers: map[string]string{"mcp-session-id": validToken},
+	}
+	decision := router.RouteRequest(ctx, &Request{Parsed: req})
+	require.Nil(t, decision.Error)
+	close(calls)
+
+	got := []string{}
+	for c := range calls {
+		got = append(got, c.server)
+	}
+	require.ElementsMatch(t, []string{"one", "two", "three"}, got, "the other servers are still told")
+}
+
+func TestRouteLoggingSetLevel_InvalidSession(t *testing.T) {
+	router, _ := newTestRouter(t, []*config.MCPServer{}, map[string]string{}, map[string]string{})
+	calls := make(chan setLevelCall, 1)
+	router.SetLogLevelForClient = recordSetLevel(calls)
+
+	req := &MCPRequest{
+		JSONRPC: "2.0",
+		Method:  MethodLoggingSetLevel,
+		ID:      ptr.To(3),
+		Params:  map[string]any{"level": "debug"},
+		Headers: map[string]string{"mcp-session-id": "not-a-session"},
+	}
+	decision := router.RouteRequest(context.Background(), &Request{Parsed: req})
+	require.NotNil(t, decision.Error)
+	require.Empty(t, calls)
+}
+
+func TestRejectServerRequest(t *testing.T) {
+	serverConfigs := []*config.MCPServer{
+		{Name: "one", URL: "http://one.mcp.local/mcp", Prefix: "one_", Hostname: "one.mcp.local"},
+	}
+	router, validToken := newTestRouterWithSession(t, serverConfigs, "one")
+	type rejectCall struct {
+		server           string
+		backendSessionID string
+		id               any
+		code             int
+		headers          map[string]string
+	}
+	calls := make(chan rejectCall, 1)
+	router.RejectForClient = func(_ context.Context, _ string, conf *config.MCPServer, backendSessionID string, id any, code int, _ string, headers map[string]string, _ *clients.HairpinClientPool) error {
+		calls <- rejectCall{server: conf.Name, backendSessionID: backendSessionID, id: id, code: code, headers: headers}
+		return nil
+	}
+
+	toolCall := &MCPRequest{
+		JSONRPC:          "2.0",
+		Method:           "tools/call",
+		ID:               ptr.To(3),
+		Params:           map[string]any{"name": "one_ask"},
+		ServerName:       "one",
+		BackendSessionID: "backend-one",
+		Headers: map[string]string{
+			"mcp-session-id":   validToken,
+			"authorization":    "Bearer client",
+			"x-mcp-authorized": "forged",
+		},
+	}
+	require.NoError(t, router.RejectServerRequest(context.Background(), toolCall, float64(5), -1, "no"))
+	c := <-calls
+	require.Equal(t, "one", c.server)
+	require.Equal(t, "backend-one", c.backendSessionID)
+	require.Equal(t, float64(5), c.id)
+	require.Equal(t, -1, c.code)
+	require.Equal(t, "one.mcp.local", c.headers["mcp-init-host"])
+	require.NoError(t, router.JWTManager.ValidateBackendInitToken(c.headers[RoutingKey], "one.mcp.local"))
+	require.Equal(t, "Bearer client", c.headers["authorization"])
+	require.Equal(t, "one", c.headers["x-mcp-servername"])
+	require.NotContains(t, c.headers, "x-mcp-toolname", "the reply is not a call of the tool")
+	require.NotContains(t, c.headers, "x-mcp-method")
+	require.NotContains(t, c.headers, "x-mcp-authorized")
+
+	toolCall.ServerName = "unknown"
+	require.Error(t, router.RejectServerRequest(context.Background(), toolCall, float64(5), -1, "no"))
+}
+
+func TestRouteLoggingSetLevel_Hairpin(t *testing.T) {
+	router, _ := newTestRouter(t, []*config.MCPServer{}, map[string]string{}, map[string]string{})
+	calls := make(chan setLevelCall, 1)
+	router.SetLogLevelForClient = recordSetLevel(calls)
+	const targetHost = "backend.example.com"
+
+	t.Run("accepts a router-issued token and routes to the backend", func(t *testing.T) {
+		token, err := router.JWTManager.GenerateBackendInitToken(targetHost)
+		require.NoError(t, err)
+		req := &MCPRequest{
+			JSONRPC: "2.0",
+			Method:  MethodLoggingSetLevel,
+			ID:      "1",
+			Params:  map[string]any{"level": "info"},
+			Headers: map[string]string{"mcp-init-host": targetHost, RoutingKey: token},
+		}
+		decision := router.RouteRequest(context.Background(), &Request{Parsed: req})
+		require.Nil(t, decision.Error)
+		require.Equal(t, targetHost, decision.Authority)
+		require.Contains(t, decision.UnsetHeaders, RoutingKey)
+		require.Empty(t, calls, "a hairpin setLevel is not fanned out again")
+	})
+
+	t.Run("rejects a missing token", func(t *testing.T) {
+		req := &MCPRequest{
+			JSONRPC: "2.0",
+			Method:  MethodLoggingSetLevel,
+			ID:      "1",
+			Params:  map[string]any{"level": "info"},
+			Headers: map[string]string{"mcp-init-host": targetHost},
+		}
+		decision := router.RouteRequest(context.Background(), &Request{Parsed: req})
+		require.NotNil(t, decision.Error)
+		require.Equal(t, 400, decision.Error.StatusCode)
+	})
+}
+
+// TestRouteLoggingSetLevel_AppliedOnLazyInit drives a real hairpin init and
+// setLevel against an SDK server: a backend session created after the
+// client chose a level must start at that level.
+func TestRouteLoggingSetLevel_AppliedOnLazyInit(t *testing.T) {
+	levels := make(chan mcp.LoggingLevel, 1)
+	srv := mcp.NewServer(&mcp.Implementation{Name: "test", Version: "0.0.1"}, nil)
+	srv.AddReceivingMiddleware(func(next mcp.MethodHandler) mcp.MethodHandler {
+		return func(ctx context.Context, method string, req mcp.Request) (mcp.Result, error) {
+			if method == MethodLoggingSetLevel {
+				levels <- req.GetParams().(*mcp.SetLoggingLevelParams).Level
+			}
+			return next(ctx, method, req)
+		}
+	})
+	mcp.AddTool(srv, &mcp.Tool{Name: "echo"}, func(context.Context, *mcp.CallToolRequest, any) (*mcp.CallToolResult, any, error) {
+		return &mcp.CallToolResult{}, nil, nil
+	})
+	ts := httptest.NewServer(mcp.NewStreamableHTTPHandler(func(*http.Request) *mcp.Server { return srv }, nil))
+	defer ts.Close()
+
+	serverConfigs := []*config.MCPServer{
+		{Name: "dummy", URL: ts.URL, Prefix: "s_", State: "Enabled", Hostname: "dummy.mcp.local"},
+	}
+	router, validToken := newTestRouter(t, serverConfigs, map[string]string{"s_echo": "dummy"}, map[string]string{})
+	router.InitForClient = clients.Initialize
+	router.SetLogLevelForClient = clients.SetLoggingLevel
+	pool, err := clients.BuildHairpinHTTPClientPool(ts.URL, "", "")
+	require.NoError(t, err)
+	router.HairpinClientPool = pool
+	router.RoutingConfig.Store(&config.MCPServersConfig{
+		Servers:                    serverConfigs,
+		MCPGatewayInternalHostname: ts.URL,
+	})
+
+	ctx := context.Background()
+	setLevel := &MCPRequest{
+		JSONRPC: "2.0",
+		Method:  MethodLoggingSetLevel,
+		ID:      ptr.To(1),
+		Params:  map[string]any{"level": "warning"},
+		Headers: map[string]string{"mcp-session-id": validToken},
+	}
+	decision := router.RouteRequest(ctx, &Request{Parsed: setLevel})
+	require.Nil(t, decision.Error)
+	require.Empty(t, levels, "no backend session exists yet")
+
+	call := &MCPRequest{
+		JSONRPC: "2.0",
+		Method:  "tools/call",
+		ID:      ptr.To(2),
+		Params:  map[string]any{"name": "s_echo"},
+		Headers: map[string]string{"mcp-session-id": validToken},
+	}
+	decision = router.RouteRequest(ctx, &Request{Parsed: call})
+	require.Nil(t, decision.Error)
+	require.NotEmpty(t, decision.SetHeaders["mcp-session-id"])
+	select {
+	case level := <-levels:
+		require.Equal(t, mcp.LoggingLevel("warning"), level)
+	default:
+		t.Fatal("the new backend session was not given the client's level")
+	}
+}
+
+func TestMCPRequest_IsServerRequestResponse(t *testing.T) {
+	testCases := []struct {
+		name     string
+		req      *MCPRequest
+		expected bool
+	}{
+		{
+			name:     "sampling result",
+			req:      &MCPRequest{ID: "g-1", Result: map[string]any{"role": "assistant", "model": "m"}},
+			expected: true,
+		},
+		{
+			name:     "roots result",
+			req:      &MCPRequest{ID: "g-1", Result: map[string]any{"roots": []any{}}},
+			expected: true,
+		},
+		{
+			name:     "error reply",
+			req:      &MCPRequest{ID: "g-1", Error: map[string]any{"code": float64(-1), "message": "user rejected"}},
+			expected: true,
+		},
+		{
+			name:     "elicitation result is handled separately",
+			req:      &MCPRequest{ID: "g-1", Result: map[string]any{"action": "accept"}},
+			expected: false,
+		},
+		{
+			name:     "request with method",
+			req:      &MCPRequest{ID: "g-1", Method: MethodToolCall, Result: map[string]any{}},
+			expected: false,
+		},
+		{
+			name:     "no id",
+			req:      &MCPRequest{Result: map[string]any{"roots": []any{}}},
+			expected: false,
+		},
+		{
+			name:     "neither result nor error",
+			req:      &MCPRequest{ID: "g-1"},
+			expected: false,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.expected, tc.req.IsServerRequestResponse())
+		})
+	}
+}
+
+func TestRouteServerRequestResponse(t *testing.T) {
+	serverConfigs := []*config.MCPServer{
+		{
+			Name:     "test-server",
+			URL:      "http://test.mcp.local:8080/mcp",
+			Hostname: "test.mcp.local",
+			Sampling: true,
+		},
+	}
+
+	t.Run("sampling result goes to the backend that asked", func(t *testing.T) {
+		router, validToken := newTestRouter(t, serverConfigs, map[string]string{}, map[string]string{})
+		gatewayID := mustStoreIDMap(t, router.ElicitationMap, float64(7), "test-server", "backend-session-456", validToken)
+
+		req := &MCPRequest{
+			ID:      gatewayID,
+			JSONRPC: "2.0",
+			Result:  map[string]any{"role": "assistant", "model": "m", "content": map[string]any{"type": "text", "text": "hi"}},
+			Headers: map[string]string{"mcp-session-id": validToken},
+		}
+		valid, err := req.Validate()
+		require.NoError(t, err)
+		require.True(t, valid)
+
+		decision := router.RouteRequest(context.Background(), &Request{Parsed: req})
+		require.Nil(t, decision.Error)
+		require.False(t, decision.BrokerPass)
+		require.Equal(t, "test.mcp.local", decision.Authority)
+		require.Equal(t, "backend-session-456", decision.SetHeaders[SessionHeader])
+		require.Equal(t, "test-server", decision.SetHeaders[MCPServerNameHeader])
+
+		var restored MCPRequest
+		require.NoError(t, json.Unmarshal(decision.BodyMutation, &restored))
+		require.Equal(t, float64(7), restored.ID)
+		require.Equal(t, "assistant", restored.Result["role"])
+
+		_, ok, err := router.ElicitationMap.Lookup(context.Background(), gatewayID)
+		require.NoError(t, err)
+		require.False(t, ok, "mapping is released once the response is forwarded")
+	})
+
+	t.Run("error reply to roots/list is routed too", func(t *testing.T) {
+		router, validToken := newTestRouter(t, serverConfigs, map[string]string{}, map[string]string{})
+		gatewayID := mustStoreIDMap(t, router.ElicitationMap, "backend-roots-1", "test-server", "backend-session-456", validToken)
+
+		req := &MCPRequest{
+			ID:      gatewayID,
+			JSONRPC: "2.0",
+			Error:   map[string]any{"code": float64(-32601), "message": "roots not supported"},
+			Headers: map[string]string{"mcp-session-id": validToken},
+		}
+		decision := router.RouteRequest(context.Background(), &Request{Parsed: req})
+		require.Nil(t, decision.Error)
+
+		var restored MCPRequest
+		require.NoError(t, json.Unmarshal(decision.BodyMutation, &restored))
+		require.Equal(t, "backend-roots-1", restored.ID)
+		require.Equal(t, "roots not supported", restored.Error["message"])
+	})
+
+	t.Run("unmapped replies still go to the broker", func(t *testing.T) {
+		router, validToken := newTestRouter(t, serverConfigs, map[string]string{}, map[string]string{})
+		req := &MCPRequest{
+			ID:      float64(3),
+			JSONRPC: "2.0",
+			Result:  map[string]any{"roots": []any{}},
+			Headers: map[string]string{"mcp-session-id": validToken},
+		}
+		decision := router.RouteRequest(context.Background(), &Request{Parsed: req})
+		require.Nil(t, decision.Error)
+		require.True(t, decision.BrokerPass)
+	})
+
+	t.Run("reply from another session is rejected", func(t *testing.T) {
+		router, validToken := newTestRouter(t, serverConfigs, map[string]string{}, map[string]string{})
+		gatewayID := mustStoreIDMap(t, router.ElicitationMap, float64(7), "test-server", "backend-session-456", router.JWTManager.Generate())
+
+		req := &MCPRequest{
+			ID:      gatewayID,
+			JSONRPC: "2.0",
+			Result:  map[string]any{"roots": []any{}},
+			Headers: map[string]string{"mcp-session-id": validToken},
+		}
+		decision := router.RouteRequest(context.Background(), &Request{Parsed: req})
+		require.NotNil(t, decision.Error)
+		require.Equal(t, 403, decision.Error.StatusCode)
+	})
+}
+
+// TestInitializeMCPServerSession_ClientCapabilities verifies the backend
+// session only advertises sampling when both the client declared it and the
+// server is allowed to sample, while roots follow the client alone.
+func TestInitializeMCPServerSession_ClientCapabilities(t *testing.T) {
+	testCases := []struct {
+		name            string
+		serverSampling  bool
+		clientDeclared  []string
+		expectedCapsSet clients.ClientCapabilities
+	}{
+		{
+			name:            "sampling allowed and declared",
+			serverSampling:  true,
+			clientDeclared:  []string{CapabilitySampling, CapabilityRoots},
+			expectedCapsSet: clients.ClientCapabilities{Sampling: true, Roots: true},
+		},
+		{
+			name:            "server not allowed to sample",
+			serverSampling:  false,
+			clientDeclared:  []string{CapabilitySampling, CapabilityRoots},
+			expectedCapsSet: clients.ClientCapabilities{Roots: true},
+		},
+		{
+			name:            "client did not declare sampling",
+			serverSampling:  true,
+			clientDeclared:  nil,
+			expectedCapsSet: clients.ClientCapabilities{},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			serverConfigs := []*config.MCPServer{
+				{
+					Name:     "dummy",
+					URL:      "http://localhost:8080/mcp",
+					Prefix:   "s_",
+					State:    "Enabled",
+					Hostname: "backend.example.com",
+					Sampling: tc.serverSampling,
+				},
+			}
+			router, validToken := newTestRouter(t, serverConfigs, map[string]string{"s_mytool": "dummy"}, map[string]string{})
+			router.RoutingConfig.Store(&config.MCPServersConfig{
+				Servers:                    serverConfigs,
+				MCPGatewayInternalHostname: "mcp-gateway.local",
+			})
+			if len(tc.clientDeclared) > 0 {
+				require.NoError(t, router.SessionCache.SetClientCapabilities(context.Background(), validToken, tc.clientDeclared, time.Hour))
+			}
+
+			var captured *clients.ClientCapabilities
+			router.InitForClient = func(_ context.Context, _ string, _ *config.MCPServer, _ map[string]string, caps clients.ClientCapabilities, _ *clients.HairpinClientPool) (*mcp.ClientSession, error) {
+				captured = &caps
+				return nil, fmt.Errorf("mock init: skip further work")
+			}
+
+			req := &MCPRequest{
+				ID:      ptr.To(0),
+				JSONRPC: "2.0",
+				Method:  MethodToolCall,
+				Params:  map[string]any{"name": "s_mytool"},
+				Headers: map[string]string{"mcp-session-id": validToken},
+			}
+			_ = router.RouteRequest(context.Background(), &Request{Parsed: req})
+			require.NotNil(t, captured, "InitForClient must have been called")
+			require.Equal(t, tc.expectedCapsSet, *captured)
+		})
+	}
+}
+
+func TestRouteCancelled(t *testing.T) {
+	serverConfigs := []*config.MCPServer{
+		{
+			Name:     "slow",
+			URL:      "http://slow.mcp.local:8080/mcp",
+			Prefix:   "s_",
+			Hostname: "slow.mcp.local",
+		},
+	}
+	newRouter := func(t *testing.T) (*Router202511, string) {
+		t.Helper()
+		router, validToken := newTestRouter(t, serverConfigs, map[string]string{"s_long": "slow"}, map[string]string{})
+		_, err := router.SessionCache.AddSession(context.Background(), validToken, "slow", "backend-session-1", 0)
+		require.NoError(t, err)
+		return router, validToken
+	}
+	cancel := func(token string, requestID any) *MCPRequest {
+		return &MCPRequest{
+			JSONRPC: "2.0",
+			Method:  MethodCancelled,
+			Params:  map[string]any{"requestId": requestID, "reason": "user stopped"},
+			Headers: map[string]string{"mcp-session-id": token},
+		}
+	}
+
+	t.Run("cancellation follows an in-flight tool call", func(t *testing.T) {
+		router, validToken := newRouter(t)
+		call := &MCPRequest{
+			ID:      float64(12),
+			JSONRPC: "2.0",
+			Method:  MethodToolCall,
+			Params:  map[string]any{"name": "s_long"},
+			Headers: map[string]string{"mcp-session-id": validToken},
+		}
+		decision := router.RouteRequest(context.Background(), &Request{Parsed: call})
+		require.Nil(t, decision.Error)
+
+		decision = router.RouteRequest(context.Background(), &Request{Parsed: cancel(validToken, float64(12))})
+		require.Nil(t, decision.Error)
+		require.False(t, decision.BrokerPass)
+		require.Equal(t, "slow.mcp.local", decision.Authority)
+		require.Equal(t, "/mcp", decision.Path)
+		require.Equal(t, "backend-session-1", decision.SetHeaders[SessionHeader])
+		require.Equal(t, "slow", decision.SetHeaders[MCPServerNameHeader])
+		require.Nil(t, decision.BodyMutation, "the notification is forwarded as sent")
+	})
+
+	t.Run("string and number IDs are distinct", func(t *testing.T) {
+		router, validToken := newRouter(t)
+		require.NoError(t, router.SessionCache.SetInFlight(context.Background(), validToken, RequestIDKey(float64(12)), "slow", time.Minute))
+
+		decision := router.RouteRequest(context.Background(), &Request{Parsed: cancel(validToken, "12")})
+		require.True(t, decision.BrokerPass)
+	})
+
+	t.Run("unknown requests go to the broker", func(t *testing.T) {
+		router, validToken := newRouter(t)
+		decision := router.RouteRequest(context.Background(), &Request{Parsed: cancel(validToken, float64(99))})
+		require.Nil(t, decision.Error)
+		require.True(t, decision.BrokerPass)
+	})
+
+	t.Run("in-flight entries are per session", func(t *testing.T) {
+		router, validToken := newRouter(t)
+		require.NoError(t, router.SessionCache.SetInFlight(context.Background(), validToken, RequestIDKey(float64(12)), "slow", time.Minute))
+
+		other := router.JWTManager.Generate()
+		decision := router.RouteRequest(context.Background(), &Request{Parsed: cancel(other, float64(12))})
+		require.True(t, decision.BrokerPass)
+	})
+}
@@ -21,7 +21,26 @@ type SessionCache interface {
 	SetUserToken(ctx context.Context, sessionID, serverName, token string, ttl time.Duration) error
 	GetUserToken(ctx context.Context, sessionID, serverName string) (string, bool, error)
 	DeleteUserToken(ctx context.Context, sessionID, serverName string) error
+	SetLogLevel(ctx context.Context, gatewaySessionID, level string, ttl time.Duration) error
+	GetLogLevel(ctx context.Context, gatewaySessionID string) (string, error)
+	SetClientCapabilities(ctx context.Context, gatewaySessionID string, capabilities []string, ttl time.Duration) error
+	GetClientCapabilities(ctx context.Context, gatewaySessionID string) ([]string, error)
+	SetInFlight(ctx context.Context, gatewaySessionID, requestID, serverName string, ttl time.Duration) error
+	GetInFlight(ctx context.Context, gatewaySessionID, requestID string) (string, error)
+	DeleteInFlight(ctx context.Context, gatewaySessionID, requestID string) error
 }
 
 // InitForClient defines a function for initializing an MCP server for a client.
-type InitForClient func(ctx context.Context, gatewayHost string, conf *config.MCPServer, passThroughHeaders map[string]string, clientElicitation bool, hairpinClientPool *clients.HairpinClientPool) (*mcp.ClientSession, error)
+type InitForClient func(ctx context.Context, gatewayHost string, conf *config.MCPServer, passThroughHeaders map[string]string, clientCaps clients.ClientCapabilities, hairpinClientPool *clients.HairpinClientPool) (*mcp.ClientSession, error)
+
+// SetLogLevelForClient defines a function for applying a client's logging level to one of its backend sessions.
+type SetLogLevelForClient func(ctx context.Context, gatewayHost string, conf *config.MCPServer, backendSessionID, level string, passThroughHeaders map[string]string, hairpinClientPool *clients.HairpinClientPool) error
+
+// RejectForClient defines a function for answering a request a backend sent on one of its sessions with a JSON-RPC error.
+type RejectForClient func(ctx context.Context, gatewayHost string, conf *config.MCPServer, backendSessionID string, id any, code int, message string, passThroughHeaders map[string]string, hairpinClientPool *clients.HairpinClientPool) error
+
+// UserTokenRefresher renews a user's upstream token before the router reads
+// it from the session cache.
+type UserTokenRefresher interface {
+	RefreshUserToken(ctx context.Context, sessionID, serverName string) error
+}
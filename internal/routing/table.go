@@ -18,18 +18,19 @@ type RoutingTable interface {
 
 // ServerRoute identifies the upstream server that handles a tool or prompt.
 type ServerRoute struct {
-	Name                string
-	Host                string
-	Prefix              string
-	Path                string
-	URL                 string
-	TokenURLElicitation *TokenURLElicitationRoute
-	UserSpecificList    bool
+	Name                string                    `json:"name"`
+	Host                string                    `json:"host,omitempty"`
+	Prefix              string                    `json:"prefix,omitempty"`
+	Path                string                    `json:"path,omitempty"`
+	URL                 string                    `json:"url"`
+	TokenURLElicitation *TokenURLElicitationRoute `json:"tokenURLElicitation,omitempty"`
+	UserSpecificList    bool                      `json:"userSpecificList,omitempty"`
+	Canary              *CanaryRoute              `json:"canary,omitempty"`
 }
 
 // TokenURLElicitationRoute holds the URL elicitation config relevant to routing.
 type TokenURLElicitationRoute struct {
-	URL string
+	URL string `json:"url"`
 }
 
 // ToolAnnotation preserves the *bool annotation fidelity from upstream
@@ -1,6 +1,9 @@
 package routing
 
 import (
+	"context"
+
+	mcpotel "github.com/Kuadrant/mcp-gateway/internal/otel"
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/trace"
@@ -13,3 +16,17 @@ var componentAttr = attribute.String("component", "mcp-router")
 func tracer() trace.Tracer {
 	return otel.Tracer(tracerName)
 }
+
+// propagateTraceContext carries the routing span's trace context and baggage
+// to the upstream, both as W3C headers and in params._meta for backends
+// reached through intermediaries that drop headers. It reports whether the
+// body changed.
+func propagateTraceContext(ctx context.Context, headers map[string]string, mcpReq *MCPRequest) bool {
+	mcpotel.InjectHeaders(ctx, headers)
+	meta := mcpotel.TraceMeta(ctx)
+	if mcpReq == nil || meta == nil {
+		return false
+	}
+	mcpReq.SetMetaFields(meta)
+	return true
+}
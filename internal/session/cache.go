@@ -2,9 +2,13 @@ package session
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"maps"
+	"slices"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
 
@@ -13,14 +17,46 @@ import (
 
 const clientElicitationPrefix = "clientelicitation:"
 
+const logLevelPrefix = "loglevel:"
+
+const clientCapabilitiesPrefix = "clientcaps:"
+
+const inFlightPrefix = "inflight:"
+
 const userTokenFieldPrefix = "token:"
 
+const userTokenGrantFieldPrefix = "tokengrant:"
+
+const revokedSessionPrefix = "revoked:"
+
+const subjectSessionsPrefix = "subject:"
+
+// drainsKey is the hash of drained servers shared by every broker replica
+const drainsKey = "drains:servers"
+
 // Cache implements a cache
 type Cache struct {
 	inmemory      *sync.Map
 	innerMu       sync.Mutex // serializes copy-on-write mutations on inner map[string]string values
-	extClient     *redis.Client
+	extClient     redis.UniversalClient
 	encryptionKey []byte
+	// decryptionKeys are previous encryption keys, tried when the active key
+	// fails to open a value
+	decryptionKeys [][]byte
+	// hashTagSessions wraps session IDs in a hash tag, set for Redis Cluster
+	hashTagSessions bool
+	// now is the clock in-memory expiries are checked against
+	now func() time.Time
+}
+
+// sessionKey returns the key of a gateway session's hash, or with a prefix
+// the key of its metadata. On Redis Cluster the session ID is hash-tagged so
+// every key of a session lives in one slot and they can be deleted together.
+func (c *Cache) sessionKey(prefix, sessionID string) string {
+	if c.hashTagSessions {
+		return prefix + "{" + sessionID + "}"
+	}
+	return prefix + sessionID
 }
 
 // KeyExists checks if a key exists in the cache
@@ -29,7 +65,7 @@ func (c *Cache) KeyExists(ctx context.Context, key string) (bool, error) {
 		_, ok := c.inmemory.Load(key)
 		return ok, nil
 	}
-	count, err := c.extClient.Exists(ctx, key).Result()
+	count, err := c.extClient.Exists(ctx, c.sessionKey("", key)).Result()
 	if err != nil {
 		return false, err
 	}
@@ -49,7 +85,7 @@ func (c *Cache) GetSession(ctx context.Context, key string) (map[string]string,
 		}
 		return map[string]string{}, nil
 	}
-	return c.extClient.HGetAll(ctx, key).Result()
+	return c.extClient.HGetAll(ctx, c.sessionKey("", key)).Result()
 }
 
 // DeleteSessions deletes sessions and associated metadata from the cache
@@ -60,14 +96,92 @@ func (c *Cache) DeleteSessions(ctx context.Context, key ...string) error {
 		for _, k := range key {
 			c.inmemory.Delete(k)
 			c.inmemory.Delete(clientElicitationPrefix + k)
+			c.inmemory.Delete(logLevelPrefix + k)
+			c.inmemory.Delete(clientCapabilitiesPrefix + k)
+			c.inmemory.Delete(inFlightPrefix + k)
 		}
 		return nil
 	}
-	allKeys := make([]string, 0, len(key)*2)
+	// one DEL per session: on Redis Cluster a command must stay within a slot
+	pipe := c.extClient.Pipeline()
 	for _, k := range key {
-		allKeys = append(allKeys, k, clientElicitationPrefix+k)
+		pipe.Del(ctx, c.sessionKey("", k), c.sessionKey(clientElicitationPrefix, k), c.sessionKey(logLevelPrefix, k),
+			c.sessionKey(clientCapabilitiesPrefix, k), c.sessionKey(inFlightPrefix, k))
+	}
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// listScanCount is the SCAN page size used by ListSessions
+const listScanCount = 100
+
+// ListSessions returns every gateway session in the cache with its backend
+// sessions keyed by server. Cached user tokens are never included. Gateway
+// session keys are JWTs and so never contain a colon, which tells them
+// apart from the prefixed metadata and catalog keys sharing the store.
+func (c *Cache) ListSessions(ctx context.Context) (map[string]map[string]string, error) {
+	out := map[string]map[string]string{}
+	if c.inmemory != nil {
+		c.inmemory.Range(func(k, v any) bool {
+			key, ok := k.(string)
+			if !ok || strings.Contains(key, ":") {
+				return true
+			}
+			if fields, ok := v.(map[string]string); ok {
+				out[key] = backendSessions(fields)
+			}
+			return true
+		})
+		return out, nil
+	}
+	var mu sync.Mutex
+	scan := func(ctx context.Context, client redis.UniversalClient) error {
+		var cursor uint64
+		for {
+			keys, next, err := client.ScanType(ctx, cursor, "*", listScanCount, "hash").Result()
+			if err != nil {
+				return err
+			}
+			for _, key := range keys {
+				if strings.Contains(key, ":") {
+					continue
+				}
+				fields, err := client.HGetAll(ctx, key).Result()
+				if err != nil {
+					return err
+				}
+				// the key may have expired or been deleted since the scan
+				if len(fields) > 0 {
+					mu.Lock()
+					out[strings.TrimSuffix(strings.TrimPrefix(key, "{"), "}")] = backendSessions(fields)
+					mu.Unlock()
+				}
+			}
+			if next == 0 {
+				return nil
+			}
+			cursor = next
+		}
 	}
-	return c.extClient.Del(ctx, allKeys...).Err()
+	// SCAN only covers the node it runs on
+	if cluster, ok := c.extClient.(*redis.ClusterClient); ok {
+		err := cluster.ForEachMaster(ctx, func(ctx context.Context, node *redis.Client) error {
+			return scan(ctx, node)
+		})
+		return out, err
+	}
+	return out, scan(ctx, c.extClient)
+}
+
+// backendSessions drops the user token and grant fields from a session hash
+func backendSessions(fields map[string]string) map[string]string {
+	out := make(map[string]string, len(fields))
+	for server, id := range fields {
+		if !strings.HasPrefix(server, userTokenFieldPrefix) && !strings.HasPrefix(server, userTokenGrantFieldPrefix) {
+			out[server] = id
+		}
+	}
+	return out
 }
 
 // AddSession will add a session under the key. If the key exists it will append that session.
@@ -89,9 +203,9 @@ func (c *Cache) AddSession(ctx context.Context, key, mcpServerID, mcpSession str
 		return true, nil
 	}
 	pipe := c.extClient.Pipeline()
-	pipe.HSet(ctx, key, mcpServerID, mcpSession)
+	pipe.HSet(ctx, c.sessionKey("", key), mcpServerID, mcpSession)
 	if ttl > 0 {
-		pipe.Expire(ctx, key, ttl)
+		pipe.Expire(ctx, c.sessionKey("", key), ttl)
 	}
 	if _, err := pipe.Exec(ctx); err != nil {
 		return false, err
@@ -114,13 +228,13 @@ func (c *Cache) RemoveServerSession(ctx context.Context, key, mcpServerID string
 		c.inmemory.Store(key, next)
 		return nil
 	}
-	return c.extClient.HDel(ctx, key, mcpServerID).Err()
+	return c.extClient.HDel(ctx, c.sessionKey("", key), mcpServerID).Err()
 }
 
 // SetClientElicitation records that the client for this gateway session supports elicitation.
 // ttl sets the key expiry in Redis; pass 0 for no expiry (in-memory mode ignores ttl).
 func (c *Cache) SetClientElicitation(ctx context.Context, gatewaySessionID string, ttl time.Duration) error {
-	key := clientElicitationPrefix + gatewaySessionID
+	key := c.sessionKey(clientElicitationPrefix, gatewaySessionID)
 	if c.inmemory != nil {
 		c.inmemory.Store(key, true)
 		return nil
@@ -130,7 +244,7 @@ func (c *Cache) SetClientElicitation(ctx context.Context, gatewaySessionID strin
 
 // GetClientElicitation returns whether the client for this gateway session supports elicitation
 func (c *Cache) GetClientElicitation(ctx context.Context, gatewaySessionID string) (bool, error) {
-	key := clientElicitationPrefix + gatewaySessionID
+	key := c.sessionKey(clientElicitationPrefix, gatewaySessionID)
 	if c.inmemory != nil {
 		_, ok := c.inmemory.Load(key)
 		return ok, nil
@@ -145,10 +259,220 @@ func (c *Cache) GetClientElicitation(ctx context.Context, gatewaySessionID strin
 	return val == "1", nil
 }
 
+// SetLogLevel records the logging level the client of this gateway session
+// requested, so backend sessions created later can be brought in line.
+// ttl sets the key expiry in Redis; pass 0 for no expiry (in-memory mode ignores ttl).
+func (c *Cache) SetLogLevel(ctx context.Context, gatewaySessionID, level string, ttl time.Duration) error {
+	key := c.sessionKey(logLevelPrefix, gatewaySessionID)
+	if c.inmemory != nil {
+		c.inmemory.Store(key, level)
+		return nil
+	}
+	return c.extClient.Set(ctx, key, level, ttl).Err()
+}
+
+// GetLogLevel returns the logging level requested for this gateway session,
+// or "" if the client never set one
+func (c *Cache) GetLogLevel(ctx context.Context, gatewaySessionID string) (string, error) {
+	key := c.sessionKey(logLevelPrefix, gatewaySessionID)
+	if c.inmemory != nil {
+		val, ok := c.inmemory.Load(key)
+		if !ok {
+			return "", nil
+		}
+		return val.(string), nil
+	}
+	val, err := c.extClient.Get(ctx, key).Result()
+	if errors.Is(err, redis.Nil) {
+		return "", nil
+	}
+	return val, err
+}
+
+// SetClientCapabilities records the server-initiated request capabilities
+// (e.g. "sampling", "roots") the client declared for this gateway session.
+// ttl sets the key expiry in Redis; pass 0 for no expiry (in-memory mode ignores ttl).
+func (c *Cache) SetClientCapabilities(ctx context.Context, gatewaySessionID string, capabilities []string, ttl time.Duration) error {
+	key := c.sessionKey(clientCapabilitiesPrefix, gatewaySessionID)
+	if c.inmemory != nil {
+		c.inmemory.Store(key, slices.Clone(capabilities))
+		return nil
+	}
+	return c.extClient.Set(ctx, key, strings.Join(capabilities, ","), ttl).Err()
+}
+
+// GetClientCapabilities returns the capabilities recorded for this gateway
+// session, or nil if none were
+func (c *Cache) GetClientCapabilities(ctx context.Context, gatewaySessionID string) ([]string, error) {
+	key := c.sessionKey(clientCapabilitiesPrefix, gatewaySessionID)
+	if c.inmemory != nil {
+		val, ok := c.inmemory.Load(key)
+		if !ok {
+			return nil, nil
+		}
+		return slices.Clone(val.([]string)), nil
+	}
+	val, err := c.extClient.Get(ctx, key).Result()
+	if errors.Is(err, redis.Nil) || (err == nil && val == "") {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return strings.Split(val, ","), nil
+}
+
+// inFlightEntry is an in-memory in-flight request; a zero expires never expires
+type inFlightEntry struct {
+	serverName string
+	expires    time.Time
+}
+
+func (e inFlightEntry) expired(now time.Time) bool {
+	return !e.expires.IsZero() && !now.Before(e.expires)
+}
+
+// SetInFlight records that requestID on this gateway session is being served
+// by serverName, so a later cancellation can be routed to it.
+// ttl sets the expiry on the Redis hash key and is refreshed on each call; pass 0 for no expiry.
+// In memory each request expires on its own, and expired requests are dropped
+// whenever another is recorded on the session.
+func (c *Cache) SetInFlight(ctx context.Context, gatewaySessionID, requestID, serverName string, ttl time.Duration) error {
+	key := c.sessionKey(inFlightPrefix, gatewaySessionID)
+	if c.inmemory != nil {
+		c.innerMu.Lock()
+		defer c.innerMu.Unlock()
+		now := c.now()
+		next := map[string]inFlightEntry{}
+		if val, ok := c.inmemory.Load(key); ok {
+			for id, entry := range val.(map[string]inFlightEntry) {
+				if !entry.expired(now) {
+					next[id] = entry
+				}
+			}
+		}
+		entry := inFlightEntry{serverName: serverName}
+		if ttl > 0 {
+			entry.expires = now.Add(ttl)
+		}
+		next[requestID] = entry
+		c.inmemory.Store(key, next)
+		return nil
+	}
+	pipe := c.extClient.Pipeline()
+	pipe.HSet(ctx, key, requestID, serverName)
+	if ttl > 0 {
+		pipe.Expire(ctx, key, ttl)
+	}
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// GetInFlight returns the server serving requestID on this gateway session,
+// or "" if the request is not in flight
+func (c *Cache) GetInFlight(ctx context.Context, gatewaySessionID, requestID string) (string, error) {
+	key := c.sessionKey(inFlightPrefix, gatewaySessionID)
+	if c.inmemory != nil {
+		val, ok := c.inmemory.Load(key)
+		if !ok {
+			return "", nil
+		}
+		entry, ok := val.(map[string]inFlightEntry)[requestID]
+		if !ok || entry.expired(c.now()) {
+			return "", nil
+		}
+		return entry.serverName, nil
+	}
+	val, err := c.extClient.HGet(ctx, key, requestID).Result()
+	if errors.Is(err, redis.Nil) {
+		return "", nil
+	}
+	return val, err
+}
+
+// DeleteInFlight forgets requestID once its response has completed
+func (c *Cache) DeleteInFlight(ctx context.Context, gatewaySessionID, requestID string) error {
+	key := c.sessionKey(inFlightPrefix, gatewaySessionID)
+	if c.inmemory != nil {
+		c.innerMu.Lock()
+		defer c.innerMu.Unlock()
+		val, ok := c.inmemory.Load(key)
+		if !ok {
+			return nil
+		}
+		next := maps.Clone(val.(map[string]inFlightEntry))
+		delete(next, requestID)
+		if len(next) == 0 {
+			c.inmemory.Delete(key)
+			return nil
+		}
+		c.inmemory.Store(key, next)
+		return nil
+	}
+	return c.extClient.HDel(ctx, key, requestID).Err()
+}
+
 // SetUserToken stores a per-user upstream token in the session hash.
 // ttl sets the expiry on the Redis hash key; pass 0 for no expiry (in-memory mode ignores ttl).
 func (c *Cache) SetUserToken(ctx context.Context, sessionID, serverName, token string, ttl time.Duration) error {
-	field := userTokenFieldPrefix + serverName
+	return c.setUserField(ctx, sessionID, userTokenFieldPrefix+serverName, token, ttl)
+}
+
+// GetUserToken retrieves a cached upstream token. Returns ("", false, nil) on miss.
+// JWT tokens are checked for expiry; expired tokens are deleted and treated as a miss.
+func (c *Cache) GetUserToken(ctx context.Context, sessionID, serverName string) (string, bool, error) {
+	token, ok, err := c.getUserField(ctx, sessionID, userTokenFieldPrefix+serverName)
+	if err != nil || !ok {
+		return "", false, err
+	}
+	if checkUpstreamJWTExpiry(token) {
+		_ = c.DeleteUserToken(ctx, sessionID, serverName)
+		return "", false, nil
+	}
+	return token, true, nil
+}
+
+// UserTokenGrant is what renews a per-user upstream token obtained with
+// OAuth: the refresh token, if the authorization server issued one, and when
+// the access token expires
+type UserTokenGrant struct {
+	RefreshToken string    `json:"refreshToken,omitempty"`
+	Expiry       time.Time `json:"expiry,omitzero"`
+}
+
+// SetUserTokenGrant stores the grant of a per-user upstream token next to
+// the token, encrypted like it.
+// ttl sets the expiry on the Redis hash key; pass 0 for no expiry (in-memory mode ignores ttl).
+func (c *Cache) SetUserTokenGrant(ctx context.Context, sessionID, serverName string, grant UserTokenGrant, ttl time.Duration) error {
+	value, err := json.Marshal(grant)
+	if err != nil {
+		return fmt.Errorf("encoding user token grant: %w", err)
+	}
+	return c.setUserField(ctx, sessionID, userTokenGrantFieldPrefix+serverName, string(value), ttl)
+}
+
+// GetUserTokenGrant retrieves the grant of a per-user upstream token.
+// Returns (UserTokenGrant{}, false, nil) on miss.
+func (c *Cache) GetUserTokenGrant(ctx context.Context, sessionID, serverName string) (UserTokenGrant, bool, error) {
+	value, ok, err := c.getUserField(ctx, sessionID, userTokenGrantFieldPrefix+serverName)
+	if err != nil || !ok {
+		return UserTokenGrant{}, false, err
+	}
+	var grant UserTokenGrant
+	if err := json.Unmarshal([]byte(value), &grant); err != nil {
+		return UserTokenGrant{}, false, fmt.Errorf("decoding user token grant: %w", err)
+	}
+	return grant, true, nil
+}
+
+// DeleteUserTokenGrant removes the grant of a per-user upstream token.
+func (c *Cache) DeleteUserTokenGrant(ctx context.Context, sessionID, serverName string) error {
+	return c.deleteUserField(ctx, sessionID, userTokenGrantFieldPrefix+serverName)
+}
+
+// setUserField stores a per-user secret in the session hash, encrypted when
+// an encryption key is configured
+func (c *Cache) setUserField(ctx context.Context, sessionID, field, value string, ttl time.Duration) error {
 	if c.inmemory != nil {
 		c.innerMu.Lock()
 		defer c.innerMu.Unlock()
@@ -160,22 +484,21 @@ func (c *Cache) SetUserToken(ctx context.Context, sessionID, serverName, token s
 		if next == nil {
 			next = map[string]string{}
 		}
-		next[field] = token
+		next[field] = value
 		c.inmemory.Store(sessionID, next)
 		return nil
 	}
-	value := token
 	if c.encryptionKey != nil {
-		encrypted, err := encrypt(c.encryptionKey, token)
+		encrypted, err := encrypt(c.encryptionKey, value)
 		if err != nil {
 			return fmt.Errorf("encrypting user token: %w", err)
 		}
 		value = encrypted
 	}
 	pipe := c.extClient.Pipeline()
-	pipe.HSet(ctx, sessionID, field, value)
+	pipe.HSet(ctx, c.sessionKey("", sessionID), field, value)
 	if ttl > 0 {
-		pipe.Expire(ctx, sessionID, ttl)
+		pipe.Expire(ctx, c.sessionKey("", sessionID), ttl)
 	}
 	if _, err := pipe.Exec(ctx); err != nil {
 		return err
@@ -183,10 +506,9 @@ func (c *Cache) SetUserToken(ctx context.Context, sessionID, serverName, token s
 	return nil
 }
 
-// GetUserToken retrieves a cached upstream token. Returns ("", false, nil) on miss.
-// JWT tokens are checked for expiry; expired tokens are deleted and treated as a miss.
-func (c *Cache) GetUserToken(ctx context.Context, sessionID, serverName string) (string, bool, error) {
-	field := userTokenFieldPrefix + serverName
+// getUserField reads a per-user secret from the session hash. Returns
+// ("", false, nil) on miss.
+func (c *Cache) getUserField(ctx context.Context, sessionID, field string) (string, bool, error) {
 	if c.inmemory != nil {
 		c.innerMu.Lock()
 		defer c.innerMu.Unlock()
@@ -194,44 +516,74 @@ func (c *Cache) GetUserToken(ctx context.Context, sessionID, serverName string)
 		if !ok {
 			return "", false, nil
 		}
-		m := val.(map[string]string)
-		token, ok := m[field]
-		if !ok {
-			return "", false, nil
-		}
-		if checkUpstreamJWTExpiry(token) {
-			next := maps.Clone(m)
-			delete(next, field)
-			c.inmemory.Store(sessionID, next)
-			return "", false, nil
-		}
-		return token, true, nil
+		value, ok := val.(map[string]string)[field]
+		return value, ok, nil
 	}
-	raw, err := c.extClient.HGet(ctx, sessionID, field).Result()
+	raw, err := c.extClient.HGet(ctx, c.sessionKey("", sessionID), field).Result()
 	if errors.Is(err, redis.Nil) {
 		return "", false, nil
 	}
 	if err != nil {
 		return "", false, err
 	}
-	token := raw
-	if c.encryptionKey != nil {
-		decrypted, err := decrypt(c.encryptionKey, raw)
-		if err != nil {
-			return "", false, fmt.Errorf("decrypting user token: %w", err)
+	if c.encryptionKey == nil {
+		return raw, true, nil
+	}
+	value, current, err := c.decryptUserToken(raw)
+	if err != nil {
+		return "", false, fmt.Errorf("decrypting user token: %w", err)
+	}
+	if !current {
+		c.reencryptUserToken(ctx, sessionID, field, raw, value)
+	}
+	return value, true, nil
+}
+
+// decryptUserToken opens a token with the active key, falling back to the
+// previous keys. current reports whether the active key opened it.
+func (c *Cache) decryptUserToken(raw string) (token string, current bool, err error) {
+	token, err = decrypt(c.encryptionKey, raw)
+	if err == nil {
+		return token, true, nil
+	}
+	for _, key := range c.decryptionKeys {
+		if token, prevErr := decrypt(key, raw); prevErr == nil {
+			return token, false, nil
 		}
-		token = decrypted
 	}
-	if checkUpstreamJWTExpiry(token) {
-		_ = c.DeleteUserToken(ctx, sessionID, serverName)
-		return "", false, nil
+	return "", false, err
+}
+
+// reencryptUserToken rewrites a token sealed under a previous key with the
+// active key, so entries migrate as they are read. the write is skipped if
+// the field changed meanwhile; failures leave the old value readable until
+// its key is retired.
+func (c *Cache) reencryptUserToken(ctx context.Context, sessionID, field, raw, token string) {
+	encrypted, err := encrypt(c.encryptionKey, token)
+	if err != nil {
+		return
 	}
-	return token, true, nil
+	key := c.sessionKey("", sessionID)
+	_ = c.extClient.Watch(ctx, func(tx *redis.Tx) error {
+		current, err := tx.HGet(ctx, key, field).Result()
+		if err != nil || current != raw {
+			return err
+		}
+		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			pipe.HSet(ctx, key, field, encrypted)
+			return nil
+		})
+		return err
+	}, key)
 }
 
 // DeleteUserToken removes a cached upstream token for the given session and server.
 func (c *Cache) DeleteUserToken(ctx context.Context, sessionID, serverName string) error {
-	field := userTokenFieldPrefix + serverName
+	return c.deleteUserField(ctx, sessionID, userTokenFieldPrefix+serverName)
+}
+
+// deleteUserField removes a per-user secret from the session hash
+func (c *Cache) deleteUserField(ctx context.Context, sessionID, field string) error {
 	if c.inmemory != nil {
 		c.innerMu.Lock()
 		defer c.innerMu.Unlock()
@@ -245,13 +597,200 @@ func (c *Cache) DeleteUserToken(ctx context.Context, sessionID, serverName strin
 		c.inmemory.Store(sessionID, next)
 		return nil
 	}
-	return c.extClient.HDel(ctx, sessionID, field).Err()
+	return c.extClient.HDel(ctx, c.sessionKey("", sessionID), field).Err()
+}
+
+// RevokeSession records the session JWT ID jti as revoked. ttl should be the
+// session's remaining lifetime: once the JWT has expired the entry is no
+// longer needed.
+func (c *Cache) RevokeSession(ctx context.Context, jti string, ttl time.Duration) error {
+	if ttl <= 0 {
+		return nil
+	}
+	key := revokedSessionPrefix + jti
+	if c.inmemory != nil {
+		c.inmemory.Store(key, time.Now().Add(ttl))
+		return nil
+	}
+	return c.extClient.Set(ctx, key, "1", ttl).Err()
+}
+
+// IsSessionRevoked reports whether the session JWT ID jti has been revoked
+func (c *Cache) IsSessionRevoked(ctx context.Context, jti string) (bool, error) {
+	key := revokedSessionPrefix + jti
+	if c.inmemory != nil {
+		val, ok := c.inmemory.Load(key)
+		if !ok {
+			return false, nil
+		}
+		if time.Now().After(val.(time.Time)) {
+			c.inmemory.CompareAndDelete(key, val)
+			return false, nil
+		}
+		return true, nil
+	}
+	count, err := c.extClient.Exists(ctx, key).Result()
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// AddSubjectSession records that the gateway session sessionID was issued to
+// subject, until the session expires at expiresAt. Expired sessions are
+// pruned from the subject's index as new ones are added.
+func (c *Cache) AddSubjectSession(ctx context.Context, subject, sessionID string, expiresAt time.Time) error {
+	ttl := time.Until(expiresAt)
+	if ttl <= 0 {
+		return nil
+	}
+	key := subjectSessionsPrefix + subject
+	if c.inmemory != nil {
+		c.innerMu.Lock()
+		defer c.innerMu.Unlock()
+		next := map[string]time.Time{sessionID: expiresAt}
+		if val, ok := c.inmemory.Load(key); ok {
+			now := time.Now()
+			for id, exp := range val.(map[string]time.Time) {
+				if exp.After(now) {
+					next[id] = exp
+				}
+			}
+			next[sessionID] = expiresAt
+		}
+		c.inmemory.Store(key, next)
+		return nil
+	}
+	pipe := c.extClient.TxPipeline()
+	pipe.ZAdd(ctx, key, redis.Z{Score: float64(expiresAt.Unix()), Member: sessionID})
+	pipe.ZRemRangeByScore(ctx, key, "-inf", strconv.FormatInt(time.Now().Unix(), 10))
+	// the index lives as long as its longest session
+	pipe.ExpireNX(ctx, key, ttl)
+	pipe.ExpireGT(ctx, key, ttl)
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// SubjectSessions returns the unexpired gateway sessions issued to subject
+func (c *Cache) SubjectSessions(ctx context.Context, subject string) ([]string, error) {
+	key := subjectSessionsPrefix + subject
+	if c.inmemory != nil {
+		val, ok := c.inmemory.Load(key)
+		if !ok {
+			return nil, nil
+		}
+		now := time.Now()
+		var out []string
+		for id, exp := range val.(map[string]time.Time) {
+			if exp.After(now) {
+				out = append(out, id)
+			}
+		}
+		slices.Sort(out)
+		return out, nil
+	}
+	return c.extClient.ZRangeByScore(ctx, key, &redis.ZRangeBy{
+		Min: "(" + strconv.FormatInt(time.Now().Unix(), 10),
+		Max: "+inf",
+	}).Result()
+}
+
+// SetDrain records the named server as drained until until, replacing any
+// earlier drain. A zero until keeps the drain until DeleteDrain is called.
+func (c *Cache) SetDrain(ctx context.Context, server string, until time.Time) error {
+	if c.inmemory != nil {
+		c.innerMu.Lock()
+		defer c.innerMu.Unlock()
+		next := map[string]time.Time{server: until}
+		if val, ok := c.inmemory.Load(drainsKey); ok {
+			for name, u := range val.(map[string]time.Time) {
+				if name != server {
+					next[name] = u
+				}
+			}
+		}
+		c.inmemory.Store(drainsKey, next)
+		return nil
+	}
+	var stamp int64
+	if !until.IsZero() {
+		stamp = until.UnixNano()
+	}
+	return c.extClient.HSet(ctx, drainsKey, server, strconv.FormatInt(stamp, 10)).Err()
+}
+
+// DeleteDrain lifts the drain on the named server, reporting whether one was
+// recorded
+func (c *Cache) DeleteDrain(ctx context.Context, server string) (bool, error) {
+	if c.inmemory != nil {
+		c.innerMu.Lock()
+		defer c.innerMu.Unlock()
+		val, ok := c.inmemory.Load(drainsKey)
+		if !ok {
+			return false, nil
+		}
+		current := val.(map[string]time.Time)
+		if _, ok := current[server]; !ok {
+			return false, nil
+		}
+		next := maps.Clone(current)
+		delete(next, server)
+		c.inmemory.Store(drainsKey, next)
+		return true, nil
+	}
+	n, err := c.extClient.HDel(ctx, drainsKey, server).Result()
+	return n > 0, err
+}
+
+// Drains returns the unexpired drains keyed by server name. A zero time
+// marks a drain without expiry. Expired drains are left out.
+func (c *Cache) Drains(ctx context.Context) (map[string]time.Time, error) {
+	now := time.Now()
+	out := map[string]time.Time{}
+	if c.inmemory != nil {
+		val, ok := c.inmemory.Load(drainsKey)
+		if !ok {
+			return out, nil
+		}
+		for name, until := range val.(map[string]time.Time) {
+			if until.IsZero() || until.After(now) {
+				out[name] = until
+			}
+		}
+		return out, nil
+	}
+	fields, err := c.extClient.HGetAll(ctx, drainsKey).Result()
+	if err != nil {
+		return nil, err
+	}
+	var expired []string
+	for name, raw := range fields {
+		stamp, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid drain entry for %q: %w", name, err)
+		}
+		if stamp == 0 {
+			out[name] = time.Time{}
+			continue
+		}
+		until := time.Unix(0, stamp)
+		if !until.After(now) {
+			expired = append(expired, name)
+			continue
+		}
+		out[name] = until
+	}
+	if len(expired) > 0 {
+		// best effort: a failed prune is retried on the next read
+		_ = c.extClient.HDel(ctx, drainsKey, expired...).Err()
+	}
+	return out, nil
 }
 
 // NewCache returns a new cache. Pass WithRedisClient to use an external redis
 // store; otherwise an in-memory cache is returned.
 func NewCache(opts ...func(*Cache)) (*Cache, error) {
-	c := &Cache{}
+	c := &Cache{now: time.Now}
 	for _, opt := range opts {
 		opt(c)
 	}
@@ -263,17 +802,21 @@ func NewCache(opts ...func(*Cache)) (*Cache, error) {
 }
 
 // WithRedisClient configures the cache to use an existing redis client
-func WithRedisClient(client *redis.Client) func(c *Cache) {
+func WithRedisClient(client redis.UniversalClient) func(c *Cache) {
 	return func(c *Cache) {
 		if client != nil {
 			c.extClient = client
+			_, c.hashTagSessions = client.(*redis.ClusterClient)
 		}
 	}
 }
 
 // WithEncryptionKey sets the AES-256 key for encrypting user tokens in Redis.
-func WithEncryptionKey(key []byte) func(c *Cache) {
+// previous keys still decrypt tokens written before a key rotation; those
+// tokens are re-encrypted with key when read.
+func WithEncryptionKey(key []byte, previous ...[]byte) func(c *Cache) {
 	return func(c *Cache) {
 		c.encryptionKey = key
+		c.decryptionKeys = previous
 	}
 }
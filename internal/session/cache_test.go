@@ -5,6 +5,8 @@ import (
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
+	"maps"
+	"slices"
 	"strings"
 	"sync"
 	"testing"
@@ -434,6 +436,60 @@ func TestCache_SetGetDeleteUserToken(t *testing.T) {
 	require.False(t, ok)
 }
 
+func TestCache_SetGetDeleteUserTokenGrant(t *testing.T) {
+	ctx := context.Background()
+	redisServer := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: redisServer.Addr()})
+	t.Cleanup(func() {
+		require.NoError(t, client.Close())
+	})
+	key, err := DeriveEncryptionKey([]byte("test-signing-key-for-encryption-32"))
+	require.NoError(t, err)
+	inmemory, err := NewCache()
+	require.NoError(t, err)
+	external, err := NewCache(WithRedisClient(client), WithEncryptionKey(key))
+	require.NoError(t, err)
+
+	expiry := time.Date(2026, 3, 1, 13, 0, 0, 0, time.UTC)
+	for name, cache := range map[string]*Cache{"in-memory": inmemory, "redis": external} {
+		t.Run(name, func(t *testing.T) {
+			_, ok, err := cache.GetUserTokenGrant(ctx, "sess1", "github")
+			require.NoError(t, err)
+			require.False(t, ok)
+
+			grant := UserTokenGrant{RefreshToken: "ghr_refresh", Expiry: expiry}
+			require.NoError(t, cache.SetUserTokenGrant(ctx, "sess1", "github", grant, time.Hour))
+			require.NoError(t, cache.SetUserToken(ctx, "sess1", "github", "Bearer gho_access", time.Hour))
+
+			got, ok, err := cache.GetUserTokenGrant(ctx, "sess1", "github")
+			require.NoError(t, err)
+			require.True(t, ok)
+			require.Equal(t, "ghr_refresh", got.RefreshToken)
+			require.True(t, expiry.Equal(got.Expiry))
+
+			// the grant is kept apart from the token
+			token, ok, err := cache.GetUserToken(ctx, "sess1", "github")
+			require.NoError(t, err)
+			require.True(t, ok)
+			require.Equal(t, "Bearer gho_access", token)
+
+			require.NoError(t, cache.DeleteUserTokenGrant(ctx, "sess1", "github"))
+			_, ok, err = cache.GetUserTokenGrant(ctx, "sess1", "github")
+			require.NoError(t, err)
+			require.False(t, ok)
+			_, ok, err = cache.GetUserToken(ctx, "sess1", "github")
+			require.NoError(t, err)
+			require.True(t, ok)
+		})
+	}
+
+	// the refresh token is encrypted at rest
+	require.NoError(t, external.SetUserTokenGrant(ctx, "sess2", "github", UserTokenGrant{RefreshToken: "ghr_refresh"}, time.Hour))
+	raw, err := client.HGet(ctx, "sess2", "tokengrant:github").Result()
+	require.NoError(t, err)
+	require.NotContains(t, raw, "ghr_refresh")
+}
+
 func TestCache_OpaqueTokenNoExpiryCheck(t *testing.T) {
 	ctx := context.Background()
 	cache, err := NewCache()
@@ -535,6 +591,45 @@ func TestDecryptWithWrongKey(t *testing.T) {
 	require.Error(t, err)
 }
 
+func TestCache_UserTokenReencryptedAfterKeyRotation(t *testing.T) {
+	ctx := context.Background()
+	redisServer := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: redisServer.Addr()})
+	t.Cleanup(func() {
+		require.NoError(t, client.Close())
+	})
+	oldKey, err := DeriveEncryptionKey([]byte("key-one-long-enough-for-32-bytes"))
+	require.NoError(t, err)
+	newKey, err := DeriveEncryptionKey([]byte("key-two-long-enough-for-32-bytes"))
+	require.NoError(t, err)
+
+	before, err := NewCache(WithRedisClient(client), WithEncryptionKey(oldKey))
+	require.NoError(t, err)
+	require.NoError(t, before.SetUserToken(ctx, "sess1", "github", "ghp_abc123XYZ", time.Hour))
+
+	// without the previous key the entry is unreadable
+	unaware, err := NewCache(WithRedisClient(client), WithEncryptionKey(newKey))
+	require.NoError(t, err)
+	_, _, err = unaware.GetUserToken(ctx, "sess1", "github")
+	require.Error(t, err)
+
+	after, err := NewCache(WithRedisClient(client), WithEncryptionKey(newKey, oldKey))
+	require.NoError(t, err)
+	token, ok, err := after.GetUserToken(ctx, "sess1", "github")
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, "ghp_abc123XYZ", token)
+
+	// the read re-encrypted the entry under the new key, keeping its expiry
+	token, ok, err = unaware.GetUserToken(ctx, "sess1", "github")
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, "ghp_abc123XYZ", token)
+	ttl, err := client.TTL(ctx, "sess1").Result()
+	require.NoError(t, err)
+	require.Positive(t, ttl)
+}
+
 func TestCheckJWTExpiry(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -618,3 +713,382 @@ func TestSetClientElicitation_TTL(t *testing.T) {
 		require.True(t, ok)
 	})
 }
+
+func TestCache_SetGetLogLevel(t *testing.T) {
+	ctx := context.Background()
+	redisServer := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: redisServer.Addr()})
+	t.Cleanup(func() {
+		require.NoError(t, client.Close())
+	})
+	inmemory, err := NewCache()
+	require.NoError(t, err)
+	external, err := NewCache(WithRedisClient(client))
+	require.NoError(t, err)
+
+	for name, cache := range map[string]*Cache{"in-memory": inmemory, "redis": external} {
+		t.Run(name, func(t *testing.T) {
+			level, err := cache.GetLogLevel(ctx, "sess")
+			require.NoError(t, err)
+			require.Empty(t, level, "no level until the client sets one")
+
+			require.NoError(t, cache.SetLogLevel(ctx, "sess", "debug", time.Hour))
+			level, err = cache.GetLogLevel(ctx, "sess")
+			require.NoError(t, err)
+			require.Equal(t, "debug", level)
+
+			// the level is per-session metadata, not a backend session
+			_, err = cache.AddSession(ctx, "sess", "server1", "upstream-1", time.Hour)
+			require.NoError(t, err)
+			sessions, err := cache.GetSession(ctx, "sess")
+			require.NoError(t, err)
+			require.Equal(t, map[string]string{"server1": "upstream-1"}, sessions)
+
+			require.NoError(t, cache.DeleteSessions(ctx, "sess"))
+			level, err = cache.GetLogLevel(ctx, "sess")
+			require.NoError(t, err)
+			require.Empty(t, level, "deleting the session drops its log level")
+		})
+	}
+}
+
+func TestCache_SetGetClientCapabilities(t *testing.T) {
+	ctx := context.Background()
+	redisServer := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: redisServer.Addr()})
+	t.Cleanup(func() {
+		require.NoError(t, client.Close())
+	})
+	inmemory, err := NewCache()
+	require.NoError(t, err)
+	external, err := NewCache(WithRedisClient(client))
+	require.NoError(t, err)
+
+	for name, cache := range map[string]*Cache{"in-memory": inmemory, "redis": external} {
+		t.Run(name, func(t *testing.T) {
+			caps, err := cache.GetClientCapabilities(ctx, "sess")
+			require.NoError(t, err)
+			require.Empty(t, caps)
+
+			require.NoError(t, cache.SetClientCapabilities(ctx, "sess", []string{"sampling", "roots"}, time.Hour))
+			caps, err = cache.GetClientCapabilities(ctx, "sess")
+			require.NoError(t, err)
+			require.Equal(t, []string{"sampling", "roots"}, caps)
+
+			require.NoError(t, cache.DeleteSessions(ctx, "sess"))
+			caps, err = cache.GetClientCapabilities(ctx, "sess")
+			require.NoError(t, err)
+			require.Empty(t, caps, "deleting the session drops its capabilities")
+		})
+	}
+}
+
+func TestCache_InFlight(t *testing.T) {
+	ctx := context.Background()
+	redisServer := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: redisServer.Addr()})
+	t.Cleanup(func() {
+		require.NoError(t, client.Close())
+	})
+	inmemory, err := NewCache()
+	require.NoError(t, err)
+	external, err := NewCache(WithRedisClient(client))
+	require.NoError(t, err)
+
+	for name, cache := range map[string]*Cache{"in-memory": inmemory, "redis": external} {
+		t.Run(name, func(t *testing.T) {
+			server, err := cache.GetInFlight(ctx, "sess", "1")
+			require.NoError(t, err)
+			require.Empty(t, server)
+
+			require.NoError(t, cache.SetInFlight(ctx, "sess", "1", "server1", time.Minute))
+			require.NoError(t, cache.SetInFlight(ctx, "sess", `"abc"`, "server2", time.Minute))
+			server, err = cache.GetInFlight(ctx, "sess", "1")
+			require.NoError(t, err)
+			require.Equal(t, "server1", server)
+
+			// in-flight requests are not backend sessions
+			sessions, err := cache.GetSession(ctx, "sess")
+			require.NoError(t, err)
+			require.Empty(t, sessions)
+
+			require.NoError(t, cache.DeleteInFlight(ctx, "sess", "1"))
+			server, err = cache.GetInFlight(ctx, "sess", "1")
+			require.NoError(t, err)
+			require.Empty(t, server)
+			server, err = cache.GetInFlight(ctx, "sess", `"abc"`)
+			require.NoError(t, err)
+			require.Equal(t, "server2", server, "other requests stay in flight")
+
+			require.NoError(t, cache.DeleteSessions(ctx, "sess"))
+			server, err = cache.GetInFlight(ctx, "sess", `"abc"`)
+			require.NoError(t, err)
+			require.Empty(t, server, "deleting the session drops its in-flight requests")
+		})
+	}
+}
+
+func TestCache_InFlightExpires(t *testing.T) {
+	ctx := context.Background()
+	redisServer := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: redisServer.Addr()})
+	t.Cleanup(func() {
+		require.NoError(t, client.Close())
+	})
+	now := time.Now()
+	inmemory, err := NewCache()
+	require.NoError(t, err)
+	inmemory.now = func() time.Time { return now }
+	external, err := NewCache(WithRedisClient(client))
+	require.NoError(t, err)
+
+	for name, tc := range map[string]struct {
+		cache   *Cache
+		advance func(time.Duration)
+	}{
+		"in-memory": {cache: inmemory, advance: func(d time.Duration) { now = now.Add(d) }},
+		"redis":     {cache: external, advance: redisServer.FastForward},
+	} {
+		t.Run(name, func(t *testing.T) {
+			require.NoError(t, tc.cache.SetInFlight(ctx, "sess", "1", "server1", time.Minute))
+			tc.advance(2 * time.Minute)
+			server, err := tc.cache.GetInFlight(ctx, "sess", "1")
+			require.NoError(t, err)
+			require.Empty(t, server, "a call passed through unseen is forgotten after its ttl")
+
+			require.NoError(t, tc.cache.SetInFlight(ctx, "sess", "2", "server1", time.Minute))
+			server, err = tc.cache.GetInFlight(ctx, "sess", "2")
+			require.NoError(t, err)
+			require.Equal(t, "server1", server)
+		})
+	}
+
+	// recording a call drops the session's expired ones
+	val, ok := inmemory.inmemory.Load(inFlightPrefix + "sess")
+	require.True(t, ok)
+	require.Len(t, val.(map[string]inFlightEntry), 1)
+
+	require.NoError(t, inmemory.DeleteInFlight(ctx, "sess", "2"))
+	_, ok = inmemory.inmemory.Load(inFlightPrefix + "sess")
+	require.False(t, ok, "a session with nothing in flight keeps no entry")
+}
+
+func TestCache_ListSessions(t *testing.T) {
+	ctx := context.Background()
+	redisServer := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: redisServer.Addr()})
+	t.Cleanup(func() {
+		require.NoError(t, client.Close())
+	})
+	inmemory, err := NewCache()
+	require.NoError(t, err)
+	external, err := NewCache(WithRedisClient(client))
+	require.NoError(t, err)
+
+	for name, cache := range map[string]*Cache{"in-memory": inmemory, "redis": external} {
+		t.Run(name, func(t *testing.T) {
+			sessions, err := cache.ListSessions(ctx)
+			require.NoError(t, err)
+			require.Empty(t, sessions)
+
+			_, err = cache.AddSession(ctx, "header.session1.sig", "server1", "backend-1", time.Minute)
+			require.NoError(t, err)
+			_, err = cache.AddSession(ctx, "header.session1.sig", "server2", "backend-2", time.Minute)
+			require.NoError(t, err)
+			require.NoError(t, cache.SetUserToken(ctx, "header.session1.sig", "server1", "secret", time.Minute))
+			require.NoError(t, cache.SetUserTokenGrant(ctx, "header.session1.sig", "server1", UserTokenGrant{RefreshToken: "refresh"}, time.Minute))
+			_, err = cache.AddSession(ctx, "header.session2.sig", "server1", "backend-3", time.Minute)
+			require.NoError(t, err)
+			// metadata keys share the store but are not sessions
+			require.NoError(t, cache.SetInFlight(ctx, "header.session2.sig", "1", "server1", time.Minute))
+			require.NoError(t, cache.SetLogLevel(ctx, "header.session2.sig", "debug", time.Minute))
+			require.NoError(t, cache.SetClientElicitation(ctx, "header.session2.sig", time.Minute))
+
+			sessions, err = cache.ListSessions(ctx)
+			require.NoError(t, err)
+			require.Equal(t, map[string]map[string]string{
+				"header.session1.sig": {"server1": "backend-1", "server2": "backend-2"},
+				"header.session2.sig": {"server1": "backend-3"},
+			}, sessions, "user tokens are never listed")
+
+			require.NoError(t, cache.DeleteSessions(ctx, "header.session1.sig", "header.session2.sig"))
+			sessions, err = cache.ListSessions(ctx)
+			require.NoError(t, err)
+			require.Empty(t, sessions)
+		})
+	}
+}
+
+func TestCache_RevokeSession(t *testing.T) {
+	ctx := context.Background()
+	redisServer := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: redisServer.Addr()})
+	t.Cleanup(func() {
+		require.NoError(t, client.Close())
+	})
+	inmemory, err := NewCache()
+	require.NoError(t, err)
+	external, err := NewCache(WithRedisClient(client))
+	require.NoError(t, err)
+
+	for name, cache := range map[string]*Cache{"in-memory": inmemory, "redis": external} {
+		t.Run(name, func(t *testing.T) {
+			revoked, err := cache.IsSessionRevoked(ctx, "jti-1")
+			require.NoError(t, err)
+			require.False(t, revoked)
+
+			require.NoError(t, cache.RevokeSession(ctx, "jti-1", time.Minute))
+			revoked, err = cache.IsSessionRevoked(ctx, "jti-1")
+			require.NoError(t, err)
+			require.True(t, revoked)
+
+			// an already expired session needs no entry
+			require.NoError(t, cache.RevokeSession(ctx, "jti-2", 0))
+			revoked, err = cache.IsSessionRevoked(ctx, "jti-2")
+			require.NoError(t, err)
+			require.False(t, revoked)
+
+			sessions, err := cache.ListSessions(ctx)
+			require.NoError(t, err)
+			require.Empty(t, sessions, "revocations are not sessions")
+		})
+	}
+	require.Equal(t, time.Minute, redisServer.TTL(revokedSessionPrefix+"jti-1"))
+
+	// in memory entries lapse once the session would have expired
+	require.NoError(t, inmemory.RevokeSession(ctx, "short", time.Millisecond))
+	time.Sleep(5 * time.Millisecond)
+	revoked, err := inmemory.IsSessionRevoked(ctx, "short")
+	require.NoError(t, err)
+	require.False(t, revoked)
+}
+
+func TestCache_SubjectSessions(t *testing.T) {
+	ctx := context.Background()
+	redisServer := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: redisServer.Addr()})
+	t.Cleanup(func() {
+		require.NoError(t, client.Close())
+	})
+	inmemory, err := NewCache()
+	require.NoError(t, err)
+	external, err := NewCache(WithRedisClient(client))
+	require.NoError(t, err)
+
+	for name, cache := range map[string]*Cache{"in-memory": inmemory, "redis": external} {
+		t.Run(name, func(t *testing.T) {
+			sessions, err := cache.SubjectSessions(ctx, "alice")
+			require.NoError(t, err)
+			require.Empty(t, sessions)
+
+			now := time.Now()
+			require.NoError(t, cache.AddSubjectSession(ctx, "alice", "header.a1.sig", now.Add(time.Hour)))
+			require.NoError(t, cache.AddSubjectSession(ctx, "alice", "header.a2.sig", now.Add(2*time.Hour)))
+			require.NoError(t, cache.AddSubjectSession(ctx, "bob", "header.b1.sig", now.Add(time.Hour)))
+			// expired sessions are never indexed
+			require.NoError(t, cache.AddSubjectSession(ctx, "alice", "header.old.sig", now.Add(-time.Minute)))
+
+			sessions, err = cache.SubjectSessions(ctx, "alice")
+			require.NoError(t, err)
+			require.ElementsMatch(t, []string{"header.a1.sig", "header.a2.sig"}, sessions)
+			sessions, err = cache.SubjectSessions(ctx, "bob")
+			require.NoError(t, err)
+			require.Equal(t, []string{"header.b1.sig"}, sessions)
+
+			listed, err := cache.ListSessions(ctx)
+			require.NoError(t, err)
+			require.Empty(t, listed, "subject indexes are not sessions")
+		})
+	}
+	// the index lives as long as its longest session
+	ttl := redisServer.TTL(subjectSessionsPrefix + "alice")
+	require.Greater(t, ttl, time.Hour+59*time.Minute)
+}
+
+func TestCache_Drains(t *testing.T) {
+	ctx := context.Background()
+	redisServer := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: redisServer.Addr()})
+	t.Cleanup(func() {
+		require.NoError(t, client.Close())
+	})
+	inmemory, err := NewCache()
+	require.NoError(t, err)
+	external, err := NewCache(WithRedisClient(client))
+	require.NoError(t, err)
+
+	for name, cache := range map[string]*Cache{"in-memory": inmemory, "redis": external} {
+		t.Run(name, func(t *testing.T) {
+			drains, err := cache.Drains(ctx)
+			require.NoError(t, err)
+			require.Empty(t, drains)
+
+			until := time.Now().Add(time.Hour).Truncate(time.Second)
+			require.NoError(t, cache.SetDrain(ctx, "team-a/weather", time.Time{}))
+			require.NoError(t, cache.SetDrain(ctx, "team-a/files", until))
+			// an expired drain is no longer in place
+			require.NoError(t, cache.SetDrain(ctx, "team-a/old", time.Now().Add(-time.Minute)))
+
+			drains, err = cache.Drains(ctx)
+			require.NoError(t, err)
+			require.Len(t, drains, 2)
+			require.True(t, drains["team-a/weather"].IsZero())
+			require.True(t, until.Equal(drains["team-a/files"]))
+
+			deleted, err := cache.DeleteDrain(ctx, "team-a/weather")
+			require.NoError(t, err)
+			require.True(t, deleted)
+			deleted, err = cache.DeleteDrain(ctx, "team-a/weather")
+			require.NoError(t, err)
+			require.False(t, deleted)
+
+			drains, err = cache.Drains(ctx)
+			require.NoError(t, err)
+			require.Equal(t, []string{"team-a/files"}, slices.Collect(maps.Keys(drains)))
+
+			listed, err := cache.ListSessions(ctx)
+			require.NoError(t, err)
+			require.Empty(t, listed, "drains are not sessions")
+		})
+	}
+	// expired entries are pruned from the shared hash
+	fields, err := redisServer.HKeys(drainsKey)
+	require.NoError(t, err)
+	require.Equal(t, []string{"team-a/files"}, fields)
+}
+
+func TestCache_HashTaggedSessionKeys(t *testing.T) {
+	ctx := context.Background()
+	redisServer := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: redisServer.Addr()})
+	t.Cleanup(func() {
+		require.NoError(t, client.Close())
+	})
+	cache, err := NewCache(WithRedisClient(client))
+	require.NoError(t, err)
+	require.False(t, cache.hashTagSessions, "only cluster clients hash-tag keys")
+	// cluster mode without a cluster
+	cache.hashTagSessions = true
+
+	_, err = cache.AddSession(ctx, "sess1", "server1", "backend1", time.Hour)
+	require.NoError(t, err)
+	require.NoError(t, cache.SetLogLevel(ctx, "sess1", "debug", time.Hour))
+	require.NoError(t, cache.SetUserToken(ctx, "sess1", "github", "ghp_abc123XYZ", time.Hour))
+
+	require.True(t, redisServer.Exists("{sess1}"))
+	require.True(t, redisServer.Exists(logLevelPrefix+"{sess1}"))
+	require.False(t, redisServer.Exists("sess1"))
+
+	sessions, err := cache.ListSessions(ctx)
+	require.NoError(t, err)
+	require.Equal(t, map[string]map[string]string{"sess1": {"server1": "backend1"}}, sessions)
+
+	token, ok, err := cache.GetUserToken(ctx, "sess1", "github")
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, "ghp_abc123XYZ", token)
+
+	require.NoError(t, cache.DeleteSessions(ctx, "sess1"))
+	require.False(t, redisServer.Exists("{sess1}"))
+	require.False(t, redisServer.Exists(logLevelPrefix+"{sess1}"))
+}
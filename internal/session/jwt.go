@@ -33,12 +33,52 @@ const (
 // ErrInvalidBackendInitToken is returned when a backend-init JWT cannot be validated
 var ErrInvalidBackendInitToken = errors.New("invalid backend-init token")
 
+// ErrSubjectMismatch is returned when a session is used by a subject other
+// than the one it was issued to
+var ErrSubjectMismatch = errors.New("session subject does not match the request subject")
+
+// SubjectBinding controls how the subject a session was issued to is matched
+// against the verified subject of each request made with it
+type SubjectBinding string
+
+const (
+	// SubjectBindingStrict requires the subjects to be equal. A session issued
+	// without a subject only serves requests without one, which keeps
+	// unauthenticated deployments working.
+	SubjectBindingStrict SubjectBinding = "strict"
+	// SubjectBindingLenient only compares the subjects when the session and
+	// the request both carry one, for deployments that authenticate some
+	// requests but not others
+	SubjectBindingLenient SubjectBinding = "lenient"
+	// SubjectBindingDisabled accepts a session for any subject
+	SubjectBindingDisabled SubjectBinding = "disabled"
+)
+
+// ParseSubjectBinding validates a subject binding mode
+func ParseSubjectBinding(s string) (SubjectBinding, error) {
+	switch b := SubjectBinding(s); b {
+	case SubjectBindingStrict, SubjectBindingLenient, SubjectBindingDisabled:
+		return b, nil
+	}
+	return "", fmt.Errorf("invalid subject binding %q: must be strict, lenient or disabled", s)
+}
+
 // Deleter interface for providing session deletion
 type Deleter interface {
 	DeleteSessions(ctx context.Context, key ...string) error
 }
 
-// Claims represents the claims in a session JWT
+// RevocationStore records revoked session JWT IDs and the sessions issued to
+// each subject. Cache implements it.
+type RevocationStore interface {
+	RevokeSession(ctx context.Context, jti string, ttl time.Duration) error
+	IsSessionRevoked(ctx context.Context, jti string) (bool, error)
+	AddSubjectSession(ctx context.Context, subject, sessionID string, expiresAt time.Time) error
+	SubjectSessions(ctx context.Context, subject string) ([]string, error)
+}
+
+// Claims represents the claims in a session JWT. The registered sub claim
+// holds the verified subject the session was issued to, if any.
 type Claims struct {
 	jwt.RegisteredClaims
 }
@@ -52,16 +92,49 @@ type BackendInitClaims struct {
 	Host    string `json:"host"`
 }
 
-// JWTManager handles JWT generation and validation for session IDs
+// JWTManager handles JWT generation and validation for session IDs. Tokens
+// are signed with the active key and carry its ID in the kid header; they
+// verify against the active key or any verification key, so sessions signed
+// before a key rotation stay valid.
 type JWTManager struct {
 	signingKey     []byte
+	keyID          string
+	extraKeys      []string
+	verifyKeys     map[string][]byte
 	duration       time.Duration
 	logger         *slog.Logger
 	sessionDeleter Deleter
+	revocations    RevocationStore
+	subjectBinding SubjectBinding
+}
+
+// WithSubjectBinding sets how CheckSubject matches a session's subject
+// against the request's. Defaults to SubjectBindingStrict.
+func WithSubjectBinding(binding SubjectBinding) func(*JWTManager) {
+	return func(m *JWTManager) {
+		m.subjectBinding = binding
+	}
+}
+
+// WithVerificationKeys adds keys that verify tokens but never sign them:
+// previous signing keys whose sessions have not expired yet, and the next key
+// before it becomes active
+func WithVerificationKeys(keys ...string) func(*JWTManager) {
+	return func(m *JWTManager) {
+		m.extraKeys = append(m.extraKeys, keys...)
+	}
+}
+
+// WithRevocationStore makes the manager reject revoked sessions and enables
+// Revoke and RevokeSubject
+func WithRevocationStore(store RevocationStore) func(*JWTManager) {
+	return func(m *JWTManager) {
+		m.revocations = store
+	}
 }
 
 // NewJWTManager creates a new JWT manager with the provided signing key
-func NewJWTManager(signingKey string, sessionLength int64, logger *slog.Logger, sessionHandler Deleter) (*JWTManager, error) {
+func NewJWTManager(signingKey string, sessionLength int64, logger *slog.Logger, sessionHandler Deleter, opts ...func(*JWTManager)) (*JWTManager, error) {
 	if len(signingKey) < 32 {
 		return nil, fmt.Errorf("signing key must be at least 32 bytes for HS256")
 	}
@@ -70,19 +143,33 @@ func NewJWTManager(signingKey string, sessionLength int64, logger *slog.Logger,
 		sessionDuration = time.Duration(sessionLength) * time.Minute
 	}
 
-	return &JWTManager{
+	m := &JWTManager{
 		signingKey:     []byte(signingKey),
+		keyID:          internaljwt.SigningKeyID(signingKey),
 		duration:       sessionDuration,
 		logger:         logger,
 		sessionDeleter: sessionHandler,
-	}, nil
+		subjectBinding: SubjectBindingStrict,
+	}
+	for _, opt := range opts {
+		opt(m)
+	}
+	m.verifyKeys = map[string][]byte{m.keyID: m.signingKey}
+	for _, key := range m.extraKeys {
+		if len(key) < 32 {
+			return nil, fmt.Errorf("verification key must be at least 32 bytes for HS256")
+		}
+		m.verifyKeys[internaljwt.SigningKeyID(key)] = []byte(key)
+	}
+	return m, nil
 }
 
-// generateSessionJWT creates a JWT token
-func (m *JWTManager) generateSessionJWT() (string, error) {
+// generateSessionJWT creates a JWT token bound to subject, which may be empty
+func (m *JWTManager) generateSessionJWT(subject string) (string, error) {
 	now := time.Now()
 	claims := Claims{
 		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   subject,
 			IssuedAt:  jwt.NewNumericDate(now),
 			ExpiresAt: jwt.NewNumericDate(now.Add(m.duration)),
 			NotBefore: jwt.NewNumericDate(now),
@@ -92,10 +179,39 @@ func (m *JWTManager) generateSessionJWT() (string, error) {
 		},
 	}
 
+	return m.sign(claims)
+}
+
+// sign signs claims with the active key, naming it in the kid header
+func (m *JWTManager) sign(claims jwt.Claims) (string, error) {
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	token.Header["kid"] = m.keyID
 	return token.SignedString(m.signingKey)
 }
 
+// verificationKey resolves the key a token was signed with from its kid
+// header. tokens issued before keys had IDs are tried against every key.
+func (m *JWTManager) verificationKey(t *jwt.Token) (interface{}, error) {
+	if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
+		return nil, fmt.Errorf("unexpected signing method: %v", t.Header["alg"])
+	}
+	kid, ok := t.Header["kid"].(string)
+	if !ok {
+		set := jwt.VerificationKeySet{Keys: []jwt.VerificationKey{m.signingKey}}
+		for id, key := range m.verifyKeys {
+			if id != m.keyID {
+				set.Keys = append(set.Keys, key)
+			}
+		}
+		return set, nil
+	}
+	key, ok := m.verifyKeys[kid]
+	if !ok {
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	}
+	return key, nil
+}
+
 // GenerateBackendInitToken creates a short-lived JWT bound to a specific upstream
 // host, used to authenticate the router's hairpin backend-init request. The
 // token is signed with the same HMAC key used for client session JWTs but uses
@@ -119,8 +235,7 @@ func (m *JWTManager) GenerateBackendInitToken(host string) (string, error) {
 		Host:    host,
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString(m.signingKey)
+	return m.sign(claims)
 }
 
 // ValidateBackendInitToken verifies a short-lived backend-init JWT. The token
@@ -129,12 +244,7 @@ func (m *JWTManager) GenerateBackendInitToken(host string) (string, error) {
 // expected target host. Any mismatch or expiry returns ErrInvalidBackendInitToken.
 func (m *JWTManager) ValidateBackendInitToken(tokenValue, expectedHost string) error {
 	claims := &BackendInitClaims{}
-	parsed, err := jwt.ParseWithClaims(tokenValue, claims, func(t *jwt.Token) (interface{}, error) {
-		if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", t.Header["alg"])
-		}
-		return m.signingKey, nil
-	},
+	parsed, err := jwt.ParseWithClaims(tokenValue, claims, m.verificationKey,
 		jwt.WithIssuer(issuer),
 		jwt.WithAudience(backendInitAudience),
 		jwt.WithValidMethods([]string{signingMethodHS256}),
@@ -157,8 +267,14 @@ func (m *JWTManager) ValidateBackendInitToken(tokenValue, expectedHost string) e
 
 // Generate returns a session id JWT to fullfil SessionIdManager interface
 func (m *JWTManager) Generate() string {
+	return m.GenerateForSubject("")
+}
+
+// GenerateForSubject returns a session id JWT bound to the verified subject
+// of the initialize request. An empty subject issues an unbound session.
+func (m *JWTManager) GenerateForSubject(subject string) string {
 	m.logger.Debug("generating session id in jwt session manager")
-	sessID, err := m.generateSessionJWT()
+	sessID, err := m.generateSessionJWT(subject)
 	if err != nil {
 		m.logger.Error("failed to generate session id", "error", err)
 		return ""
@@ -166,56 +282,83 @@ func (m *JWTManager) Generate() string {
 	return sessID
 }
 
-// Validate validates a JWT token and fulfils SessionIdManager interface. returns IsInValid as a bool
+// CheckSubject verifies that a request made by subject may use the session,
+// according to the manager's subject binding. subject is the verified sub of
+// the request's bearer token, or empty for an unauthenticated request.
+// Returns ErrSubjectMismatch when it may not.
+func (m *JWTManager) CheckSubject(tokenValue, subject string) error {
+	if m.subjectBinding == SubjectBindingDisabled {
+		return nil
+	}
+	claims, err := m.parseSession(tokenValue)
+	if err != nil {
+		return fmt.Errorf("failed to parse token: %w", err)
+	}
+	if claims.Subject == subject {
+		return nil
+	}
+	if m.subjectBinding == SubjectBindingLenient && (claims.Subject == "" || subject == "") {
+		return nil
+	}
+	return ErrSubjectMismatch
+}
+
+// Validate validates a JWT token and fulfils SessionIdManager interface. returns IsInValid as a bool.
+// With a revocation store configured a revoked session is invalid; a store
+// error fails closed.
 func (m *JWTManager) Validate(tokenValue string) (bool, error) {
 	m.logger.Debug("validating JWT session")
-	token, err := jwt.ParseWithClaims(tokenValue, &Claims{}, func(t *jwt.Token) (interface{}, error) {
-		if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", t.Header["alg"])
-		}
-		return m.signingKey, nil
-	},
-		jwt.WithIssuer(issuer),
-		jwt.WithAudience(sessionAudience),
-		jwt.WithValidMethods([]string{signingMethodHS256}),
-		jwt.WithExpirationRequired(),
-	)
+	claims, err := m.parseSession(tokenValue)
 	if err != nil {
 		return true, fmt.Errorf("failed to parse token: %w", err)
 	}
-
-	if !token.Valid {
-		return true, nil
+	if m.revocations != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), revocationCheckTimeout)
+		defer cancel()
+		revoked, err := m.revocations.IsSessionRevoked(ctx, claims.ID)
+		if err != nil {
+			return true, fmt.Errorf("checking session revocation: %w", err)
+		}
+		if revoked {
+			return true, nil
+		}
 	}
 	return false, nil
 }
 
 // GetExpiresIn returns the time a token will expire
 func (m *JWTManager) GetExpiresIn(tokenValue string) (time.Time, error) {
-	token, err := jwt.ParseWithClaims(tokenValue, &Claims{}, func(t *jwt.Token) (interface{}, error) {
-		if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", t.Header["alg"])
-		}
-		return m.signingKey, nil
-	},
+	claims, err := m.parseSession(tokenValue)
+	if err != nil {
+		return time.Now(), fmt.Errorf("failed to parse token: %w", err)
+	}
+	return claims.ExpiresAt.Time, nil
+}
+
+// parseSession verifies a session JWT and returns its claims
+func (m *JWTManager) parseSession(tokenValue string) (*Claims, error) {
+	claims := &Claims{}
+	token, err := jwt.ParseWithClaims(tokenValue, claims, m.verificationKey,
 		jwt.WithIssuer(issuer),
 		jwt.WithAudience(sessionAudience),
 		jwt.WithValidMethods([]string{signingMethodHS256}),
 		jwt.WithExpirationRequired(),
 	)
 	if err != nil {
-		return time.Now(), fmt.Errorf("failed to parse token: %w", err)
+		return nil, err
 	}
-	nd, err := token.Claims.GetExpirationTime()
-	if err != nil {
-		return time.Now(), fmt.Errorf("failed to parse token: %w", err)
+	if !token.Valid {
+		return nil, fmt.Errorf("token is not valid")
 	}
-	return nd.Time, nil
+	return claims, nil
 }
 
 // bounds cache deletion in Terminate so a stalled store cannot block forever
 const terminateTimeout = 5 * time.Second
 
+// bounds the revocation lookup made on every Validate
+const revocationCheckTimeout = 2 * time.Second
+
 // Terminate part of the SessionIDManager interface. Will remove the associated sessions from cache
 func (m *JWTManager) Terminate(sessionID string) (isNotAllowed bool, err error) {
 	// session ids are bearer JWTs; never log the raw value
@@ -231,3 +374,69 @@ func (m *JWTManager) Terminate(sessionID string) (isNotAllowed bool, err error)
 	}
 	return false, nil
 }
+
+// ErrRevocationDisabled is returned by the revocation APIs when the manager
+// has no revocation store
+var ErrRevocationDisabled = errors.New("session revocation is not configured")
+
+// Revoke ends a session on every replica: its JWT ID is recorded as revoked
+// until the JWT expires, so Validate rejects it and it can no longer be
+// resurrected, and its cached backend sessions are deleted. Tokens that fail
+// verification or have already expired are not sessions and are ignored.
+func (m *JWTManager) Revoke(ctx context.Context, sessionID string) error {
+	if m.revocations == nil {
+		return ErrRevocationDisabled
+	}
+	claims, err := m.parseSession(sessionID)
+	if err != nil {
+		m.logger.Debug("not revoking invalid session", "session", internaljwt.LogSafeSessionID(sessionID), "error", err)
+		return nil
+	}
+	if err := m.revocations.RevokeSession(ctx, claims.ID, time.Until(claims.ExpiresAt.Time)); err != nil {
+		return fmt.Errorf("revoking session: %w", err)
+	}
+	m.logger.Info("session revoked", "session", internaljwt.LogSafeSessionID(sessionID))
+	if m.sessionDeleter != nil {
+		if err := m.sessionDeleter.DeleteSessions(ctx, sessionID); err != nil {
+			return fmt.Errorf("error clearing out associated sessions : %w", err)
+		}
+	}
+	return nil
+}
+
+// RevokeSubject revokes every unexpired session issued to subject, e.g. when
+// a user is offboarded, and returns the revoked session IDs. Only sessions
+// recorded with RecordSubjectSession are known.
+func (m *JWTManager) RevokeSubject(ctx context.Context, subject string) ([]string, error) {
+	if m.revocations == nil {
+		return nil, ErrRevocationDisabled
+	}
+	sessions, err := m.revocations.SubjectSessions(ctx, subject)
+	if err != nil {
+		return nil, fmt.Errorf("listing sessions for subject: %w", err)
+	}
+	var errs []error
+	revoked := make([]string, 0, len(sessions))
+	for _, sessionID := range sessions {
+		if err := m.Revoke(ctx, sessionID); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		revoked = append(revoked, sessionID)
+	}
+	return revoked, errors.Join(errs...)
+}
+
+// RecordSubjectSession indexes a newly issued session under the verified
+// subject it was issued to, so RevokeSubject can find it. A no-op without a
+// revocation store.
+func (m *JWTManager) RecordSubjectSession(ctx context.Context, subject, sessionID string) error {
+	if m.revocations == nil || subject == "" {
+		return nil
+	}
+	claims, err := m.parseSession(sessionID)
+	if err != nil {
+		return fmt.Errorf("failed to parse token: %w", err)
+	}
+	return m.revocations.AddSubjectSession(ctx, subject, sessionID, claims.ExpiresAt.Time)
+}
@@ -10,6 +10,8 @@ import (
 	"time"
 
 	jwt "github.com/golang-jwt/jwt/v5"
+
+	internaljwt "github.com/Kuadrant/mcp-gateway/internal/jwt"
 )
 
 const testSigningKey = "test-signing-key-must-be-at-least-32-bytes"
@@ -487,3 +489,273 @@ func TestTerminate(t *testing.T) {
 		}
 	})
 }
+
+func TestRevoke(t *testing.T) {
+	ctx := context.Background()
+	cache, _ := NewCache()
+	manager, err := NewJWTManager(testSigningKey, 0, testLogger(), cache, WithRevocationStore(cache))
+	if err != nil {
+		t.Fatalf("unexpected error constructing manager: %v", err)
+	}
+
+	t.Run("revoked session is invalid and its backend sessions are gone", func(t *testing.T) {
+		token := manager.Generate()
+		other := manager.Generate()
+		if _, err := cache.AddSession(ctx, token, "server1", "backend-1", 0); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if err := manager.Revoke(ctx, token); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		isInvalid, err := manager.Validate(token)
+		if err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+		if !isInvalid {
+			t.Error("expected revoked session to be invalid")
+		}
+		if exists, _ := cache.KeyExists(ctx, token); exists {
+			t.Error("expected backend sessions to be deleted")
+		}
+		if isInvalid, _ := manager.Validate(other); isInvalid {
+			t.Error("revoking one session must not affect another")
+		}
+	})
+
+	t.Run("invalid tokens are ignored", func(t *testing.T) {
+		otherManager, _ := NewJWTManager("different-key-must-be-at-least-32-bytes", 0, testLogger(), nil)
+		for _, token := range []string{"not-a-jwt", otherManager.Generate()} {
+			if err := manager.Revoke(ctx, token); err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		}
+	})
+
+	t.Run("terminate does not revoke", func(t *testing.T) {
+		// idle sessions are terminated too, and must stay resurrectable
+		token := manager.Generate()
+		if _, err := manager.Terminate(token); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if isInvalid, _ := manager.Validate(token); isInvalid {
+			t.Error("expected terminated session to remain valid")
+		}
+	})
+
+	t.Run("store errors fail closed", func(t *testing.T) {
+		failing, _ := NewJWTManager(testSigningKey, 0, testLogger(), nil, WithRevocationStore(failingRevocationStore{}))
+		isInvalid, err := failing.Validate(manager.Generate())
+		if err == nil || !isInvalid {
+			t.Errorf("expected invalid with error, got %v, %v", isInvalid, err)
+		}
+	})
+
+	t.Run("disabled without a store", func(t *testing.T) {
+		plain, _ := NewJWTManager(testSigningKey, 0, testLogger(), nil)
+		if err := plain.Revoke(ctx, plain.Generate()); !errors.Is(err, ErrRevocationDisabled) {
+			t.Errorf("expected ErrRevocationDisabled, got %v", err)
+		}
+		if _, err := plain.RevokeSubject(ctx, "alice"); !errors.Is(err, ErrRevocationDisabled) {
+			t.Errorf("expected ErrRevocationDisabled, got %v", err)
+		}
+		if err := plain.RecordSubjectSession(ctx, "alice", plain.Generate()); err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+	})
+}
+
+func TestRevokeSubject(t *testing.T) {
+	ctx := context.Background()
+	cache, _ := NewCache()
+	manager, _ := NewJWTManager(testSigningKey, 0, testLogger(), cache, WithRevocationStore(cache))
+
+	alice1, alice2, bob := manager.Generate(), manager.Generate(), manager.Generate()
+	for token, sub := range map[string]string{alice1: "alice", alice2: "alice", bob: "bob"} {
+		if err := manager.RecordSubjectSession(ctx, sub, token); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if err := manager.RecordSubjectSession(ctx, "alice", "not-a-jwt"); err == nil {
+		t.Error("expected an error recording an invalid session")
+	}
+
+	revoked, err := manager.RevokeSubject(ctx, "alice")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(revoked) != 2 {
+		t.Errorf("expected 2 revoked sessions, got %d", len(revoked))
+	}
+	for token, want := range map[string]bool{alice1: true, alice2: true, bob: false} {
+		if isInvalid, _ := manager.Validate(token); isInvalid != want {
+			t.Errorf("expected invalid=%v, got %v", want, isInvalid)
+		}
+	}
+
+	revoked, err = manager.RevokeSubject(ctx, "carol")
+	if err != nil || len(revoked) != 0 {
+		t.Errorf("expected nothing revoked for an unknown subject, got %v, %v", revoked, err)
+	}
+}
+
+// failingRevocationStore fails every lookup
+type failingRevocationStore struct{}
+
+func (failingRevocationStore) RevokeSession(context.Context, string, time.Duration) error {
+	return errors.New("store unavailable")
+}
+func (failingRevocationStore) IsSessionRevoked(context.Context, string) (bool, error) {
+	return false, errors.New("store unavailable")
+}
+func (failingRevocationStore) AddSubjectSession(context.Context, string, string, time.Time) error {
+	return errors.New("store unavailable")
+}
+func (failingRevocationStore) SubjectSessions(context.Context, string) ([]string, error) {
+	return nil, errors.New("store unavailable")
+}
+
+func TestParseSubjectBinding(t *testing.T) {
+	for _, s := range []string{"strict", "lenient", "disabled"} {
+		b, err := ParseSubjectBinding(s)
+		if err != nil {
+			t.Fatalf("ParseSubjectBinding(%q): %v", s, err)
+		}
+		if string(b) != s {
+			t.Errorf("expected %q, got %q", s, b)
+		}
+	}
+	for _, s := range []string{"", "Strict", "off"} {
+		if _, err := ParseSubjectBinding(s); err == nil {
+			t.Errorf("expected error for %q", s)
+		}
+	}
+}
+
+func TestCheckSubject(t *testing.T) {
+	tests := []struct {
+		name    string
+		binding SubjectBinding
+		session string
+		request string
+		wantErr bool
+	}{
+		{name: "strict same subject", binding: SubjectBindingStrict, session: "alice", request: "alice"},
+		{name: "strict unauthenticated", binding: SubjectBindingStrict},
+		{name: "strict other subject", binding: SubjectBindingStrict, session: "alice", request: "bob", wantErr: true},
+		{name: "strict no request subject", binding: SubjectBindingStrict, session: "alice", wantErr: true},
+		{name: "strict unbound session", binding: SubjectBindingStrict, request: "alice", wantErr: true},
+		{name: "lenient other subject", binding: SubjectBindingLenient, session: "alice", request: "bob", wantErr: true},
+		{name: "lenient no request subject", binding: SubjectBindingLenient, session: "alice"},
+		{name: "lenient unbound session", binding: SubjectBindingLenient, request: "alice"},
+		{name: "disabled other subject", binding: SubjectBindingDisabled, session: "alice", request: "bob"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			manager, err := NewJWTManager(testSigningKey, 0, testLogger(), nil, WithSubjectBinding(tt.binding))
+			if err != nil {
+				t.Fatalf("failed to create manager: %v", err)
+			}
+			token := manager.GenerateForSubject(tt.session)
+			err = manager.CheckSubject(token, tt.request)
+			if tt.wantErr && !errors.Is(err, ErrSubjectMismatch) {
+				t.Errorf("expected ErrSubjectMismatch, got %v", err)
+			}
+			if !tt.wantErr && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+
+	t.Run("subject is carried in sub claim", func(t *testing.T) {
+		manager, _ := NewJWTManager(testSigningKey, 0, testLogger(), nil)
+		claims, err := manager.parseSession(manager.GenerateForSubject("alice"))
+		if err != nil {
+			t.Fatalf("failed to parse token: %v", err)
+		}
+		if claims.Subject != "alice" {
+			t.Errorf("expected sub alice, got %q", claims.Subject)
+		}
+	})
+
+	t.Run("invalid token", func(t *testing.T) {
+		manager, _ := NewJWTManager(testSigningKey, 0, testLogger(), nil)
+		err := manager.CheckSubject("not-a-jwt", "alice")
+		if err == nil || errors.Is(err, ErrSubjectMismatch) {
+			t.Errorf("expected parse error, got %v", err)
+		}
+	})
+}
+
+func TestVerificationKeys(t *testing.T) {
+	const previousKey = "previous-signing-key-at-least-32-bytes"
+	previous, err := NewJWTManager(previousKey, 0, testLogger(), nil)
+	if err != nil {
+		t.Fatalf("failed to create manager: %v", err)
+	}
+	oldSession := previous.Generate()
+
+	t.Run("tokens name their signing key", func(t *testing.T) {
+		token, _ := jwt.Parse(oldSession, func(*jwt.Token) (interface{}, error) { return []byte(previousKey), nil })
+		if token == nil || token.Header["kid"] != internaljwt.SigningKeyID(previousKey) {
+			t.Errorf("expected kid %q in header", internaljwt.SigningKeyID(previousKey))
+		}
+	})
+
+	t.Run("rotated manager accepts sessions signed with a verification key", func(t *testing.T) {
+		rotated, err := NewJWTManager(testSigningKey, 0, testLogger(), nil, WithVerificationKeys(previousKey))
+		if err != nil {
+			t.Fatalf("failed to create manager: %v", err)
+		}
+		if invalid, err := rotated.Validate(oldSession); err != nil || invalid {
+			t.Errorf("expected session signed with the previous key to be valid, got invalid=%v err=%v", invalid, err)
+		}
+		// new sessions are signed with the active key only
+		if invalid, _ := previous.Validate(rotated.Generate()); !invalid {
+			t.Error("expected a new session to be signed with the active key")
+		}
+	})
+
+	t.Run("manager without the key rejects the session", func(t *testing.T) {
+		other, _ := NewJWTManager(testSigningKey, 0, testLogger(), nil)
+		invalid, err := other.Validate(oldSession)
+		if !invalid || err == nil || !strings.Contains(err.Error(), "unknown signing key") {
+			t.Errorf("expected unknown signing key error, got invalid=%v err=%v", invalid, err)
+		}
+	})
+
+	t.Run("sessions without kid verify against every key", func(t *testing.T) {
+		now := time.Now()
+		legacy, err := jwt.NewWithClaims(jwt.SigningMethodHS256, Claims{RegisteredClaims: jwt.RegisteredClaims{
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(time.Hour)),
+			Issuer:    issuer,
+			Audience:  jwt.ClaimStrings{sessionAudience},
+			ID:        "legacy",
+		}}).SignedString([]byte(previousKey))
+		if err != nil {
+			t.Fatalf("failed to sign token: %v", err)
+		}
+		rotated, _ := NewJWTManager(testSigningKey, 0, testLogger(), nil, WithVerificationKeys(previousKey))
+		if invalid, err := rotated.Validate(legacy); err != nil || invalid {
+			t.Errorf("expected legacy session to be valid, got invalid=%v err=%v", invalid, err)
+		}
+	})
+
+	t.Run("backend init tokens verify across rotation", func(t *testing.T) {
+		token, err := previous.GenerateBackendInitToken("upstream.example.com")
+		if err != nil {
+			t.Fatalf("failed to generate token: %v", err)
+		}
+		rotated, _ := NewJWTManager(testSigningKey, 0, testLogger(), nil, WithVerificationKeys(previousKey))
+		if err := rotated.ValidateBackendInitToken(token, "upstream.example.com"); err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("short verification key rejected", func(t *testing.T) {
+		if _, err := NewJWTManager(testSigningKey, 0, testLogger(), nil, WithVerificationKeys("short")); err == nil {
+			t.Error("expected error for short verification key")
+		}
+	})
+}
@@ -6,6 +6,10 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // captureTransport records the request it receives.
@@ -175,3 +179,47 @@ func TestStatusCapturingRoundTripper_5xxReturnsTypedError(t *testing.T) {
 		t.Errorf("expected code 502, got %d", httpErr.Code)
 	}
 }
+
+func TestHeaderRoundTripper_InjectsTraceContext(t *testing.T) {
+	prev := otel.GetTextMapPropagator()
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+	t.Cleanup(func() { otel.SetTextMapPropagator(prev) })
+
+	traceID, _ := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	spanID, _ := trace.SpanIDFromHex("00f067aa0ba902b7")
+	ctx := trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    traceID,
+		SpanID:     spanID,
+		TraceFlags: trace.FlagsSampled,
+	}))
+
+	base := &captureTransport{}
+	rt := &HeaderRoundTripper{Base: base, Headers: map[string]string{"Authorization": "Bearer abc"}}
+	resp, err := rt.RoundTrip(newRequest(t).WithContext(ctx))
+	if err != nil {
+		t.Fatalf("round trip: %v", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	want := "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	if got := base.got.Header.Get("traceparent"); got != want {
+		t.Errorf("expected traceparent %q, got %q", want, got)
+	}
+	if got := base.got.Header.Get("Authorization"); got != "Bearer abc" {
+		t.Errorf("expected injected Authorization, got %q", got)
+	}
+}
+
+func TestHeaderRoundTripper_NoTraceContext(t *testing.T) {
+	base := &captureTransport{}
+	rt := &HeaderRoundTripper{Base: base}
+	resp, err := rt.RoundTrip(newRequest(t))
+	if err != nil {
+		t.Fatalf("round trip: %v", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if got := base.got.Header.Get("traceparent"); got != "" {
+		t.Errorf("expected no traceparent without a span, got %q", got)
+	}
+}
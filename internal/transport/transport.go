@@ -8,9 +8,13 @@ import (
 	"io"
 	"net/http"
 	"strconv"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
 )
 
-// HeaderRoundTripper injects custom headers into every outgoing HTTP request.
+// HeaderRoundTripper injects custom headers into every outgoing HTTP request,
+// along with the W3C trace context and baggage of the request's context.
 // It clones the request before modifying headers to avoid mutating the caller's original.
 type HeaderRoundTripper struct {
 	Base    http.RoundTripper
@@ -20,15 +24,17 @@ type HeaderRoundTripper struct {
 // RoundTrip implements http.RoundTripper.
 func (h *HeaderRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
 	r2 := req.Clone(req.Context())
+	injectTraceContext(r2)
 	for k, v := range h.Headers {
 		r2.Header.Set(k, v)
 	}
 	return h.Base.RoundTrip(r2)
 }
 
-// DynamicHeaderRoundTripper injects headers resolved per request, for
-// callers whose header set changes over a connection's lifetime (e.g. a
-// pooled session that must always carry the caller's current credentials).
+// DynamicHeaderRoundTripper injects headers resolved per request, plus the
+// trace context as HeaderRoundTripper does, for callers whose header set
+// changes over a connection's lifetime (e.g. a pooled session that must
+// always carry the caller's current credentials).
 type DynamicHeaderRoundTripper struct {
 	Base    http.RoundTripper
 	Headers func() map[string]string
@@ -37,12 +43,20 @@ type DynamicHeaderRoundTripper struct {
 // RoundTrip implements http.RoundTripper.
 func (d *DynamicHeaderRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
 	r2 := req.Clone(req.Context())
+	injectTraceContext(r2)
 	for k, v := range d.Headers() {
 		r2.Header.Set(k, v)
 	}
 	return d.Base.RoundTrip(r2)
 }
 
+// injectTraceContext propagates the request context's span and baggage so
+// upstream servers join the caller's trace. configured headers are applied
+// afterwards and win.
+func injectTraceContext(req *http.Request) {
+	otel.GetTextMapPropagator().Inject(req.Context(), propagation.HeaderCarrier(req.Header))
+}
+
 // PeekRequestBody returns the request body without consuming it, preferring
 // GetBody and restoring req.Body otherwise. ok is false when the request has
 // no body or reading it fails.